@@ -122,6 +122,7 @@ func postAuthRequest<T: Decodable>(
         var request = URLRequest(url: url)
         request.httpMethod = "POST"
         request.setValue("application/json", forHTTPHeaderField: "Content-Type")
+        request.setValue(APIConfig.client, forHTTPHeaderField: APIConfig.clientHeader)
         request.httpBody = try JSONEncoder().encode(requestBody)
 
         let (data, response) = try await URLSession.shared.data(for: request)
@@ -181,6 +181,49 @@ class SRSStore: ObservableObject {
         try? modelContext.save()
     }
 
+    /// Grade a review of a record, the server working out its next review state
+    func reviewSRSRecord(_ recordId: String, grade: Int) async {
+        setLoading()
+
+        let result = await postReview(recordId, grade: grade)
+
+        switch result {
+        case .success:
+            handleSyncSuccess()
+            await refresh()
+        case let .failure(error):
+            print("ERROR: Failed to post SRS review to PocketBase:", error)
+            handleRemoteSyncFailure()
+        }
+    }
+
+    /// Posts a grade to the server's review route, which saves and logs the review
+    private func postReview(_ recordId: String, grade: Int) async -> Result<Void, Error> {
+        guard let url = URL(string: "\(APIConfig.baseURL)/api/fushigi/v1/srs/\(recordId)/review") else {
+            return .failure(URLError(.badURL))
+        }
+
+        do {
+            var request = URLRequest(url: url)
+            if let token = KeychainHelper.shared.load(forKey: "pbToken") {
+                request.setValue("Bearer \(token)", forHTTPHeaderField: "Authorization")
+            }
+            request.setValue(APIConfig.client, forHTTPHeaderField: APIConfig.clientHeader)
+
+            request.httpMethod = "POST"
+            request.setValue("application/json", forHTTPHeaderField: "Content-Type")
+            request.httpBody = try JSONEncoder().encode(["grade": grade])
+
+            let (_, response) = try await URLSession.shared.data(for: request)
+            guard let httpResponse = response as? HTTPURLResponse, (200 ... 299).contains(httpResponse.statusCode) else {
+                return .failure(URLError(.badServerResponse))
+            }
+            return .success(())
+        } catch {
+            return .failure(error)
+        }
+    }
+
     /// Updates random selection (once per day unless forced)
     func updateRandomSRSRecords(force: Bool = false) {
         let today = Calendar.current.startOfDay(for: Date())
@@ -42,4 +42,17 @@ enum APIConfig {
         // Fallback to demo to be safe
         return "DEMO"
     }
+
+    /// Header the server reads the build of the app from
+    static let clientHeader = "Fushigi-Client"
+
+    /// Build of the app as the server knows it, e.g. "ios/1.0"
+    static var client: String {
+        let version = Bundle.main.infoDictionary?["CFBundleShortVersionString"] as? String ?? "0"
+        #if os(macOS)
+            return "macos/\(version)"
+        #else
+            return "ios/\(version)"
+        #endif
+    }
 }
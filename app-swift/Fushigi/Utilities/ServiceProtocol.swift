@@ -109,6 +109,7 @@ class ProdRemoteService<Item: Codable, Create: Encodable>: RemoteServiceProtocol
             if let token = KeychainHelper.shared.load(forKey: "pbToken") {
                 request.setValue("Bearer \(token)", forHTTPHeaderField: "Authorization")
             }
+            request.setValue(APIConfig.client, forHTTPHeaderField: APIConfig.clientHeader)
 
             let (data, _) = try await URLSession.shared.data(for: request)
             let decoded = try decoder.decode(PaginatedResponse<Item>.self, from: data)
@@ -129,6 +130,7 @@ class ProdRemoteService<Item: Codable, Create: Encodable>: RemoteServiceProtocol
             if let token = KeychainHelper.shared.load(forKey: "pbToken") {
                 request.setValue("Bearer \(token)", forHTTPHeaderField: "Authorization")
             }
+            request.setValue(APIConfig.client, forHTTPHeaderField: APIConfig.clientHeader)
 
             request.httpMethod = "POST"
             request.setValue("application/json", forHTTPHeaderField: "Content-Type")
@@ -182,6 +184,7 @@ class ProdRemoteService<Item: Codable, Create: Encodable>: RemoteServiceProtocol
             if let token = KeychainHelper.shared.load(forKey: "pbToken") {
                 request.setValue("Bearer \(token)", forHTTPHeaderField: "Authorization")
             }
+            request.setValue(APIConfig.client, forHTTPHeaderField: APIConfig.clientHeader)
 
             request.httpMethod = "POST"
             request.setValue("application/json", forHTTPHeaderField: "Content-Type")
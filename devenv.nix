@@ -92,6 +92,9 @@
       };
       git-hooks.hooks = {
         gofmt.enable = true;
+        # before every commit rather than on push, so no commit goes in
+        # unformatted
+        gofmt.stages = ["pre-commit" "pre-push"];
         #golangci-lint.enable = true;
         govet.enable = true;
       };
@@ -0,0 +1,43 @@
+package achievements
+
+import (
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Milestone is an achievement and the completed study sessions earning it
+type Milestone struct {
+	Key      string
+	Sessions int
+}
+
+// Milestones are every achievement there is, the first earned first
+var Milestones = []Milestone{
+	{"first_session", 1},
+	{"sessions_10", 10},
+	{"sessions_50", 50},
+	{"sessions_100", 100},
+	{"sessions_365", 365},
+}
+
+// Achievement is a milestone and whether the user reached it
+type Achievement struct {
+	Key string `json:"key"`
+
+	// Sessions is how many guided study sessions the user has to see
+	// through to earn it
+	Sessions int `json:"sessions"`
+
+	// Earned is when the user earned it, zero until they do
+	Earned types.DateTime `json:"earned"`
+}
+
+// reached returns the milestones completed sessions have earned
+func reached(completed int) []Milestone {
+	var earned []Milestone
+	for _, m := range Milestones {
+		if completed >= m.Sessions {
+			earned = append(earned, m)
+		}
+	}
+	return earned
+}
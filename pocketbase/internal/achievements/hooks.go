@@ -0,0 +1,15 @@
+package achievements
+
+import (
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/events"
+)
+
+// BindHooks awards achievements as users see study sessions through
+func BindHooks(bus *events.Bus, achievementsService Service) {
+	bus.On(events.SessionCompleted).BindFunc(func(e *events.Event) error {
+		if _, err := achievementsService.Award(e.User); err != nil {
+			e.App.Logger().Error("Failed to award achievements", "user", e.User, "error", err)
+		}
+		return e.Next()
+	})
+}
@@ -0,0 +1,17 @@
+package achievements
+
+import (
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+func RegisterRoutes(g *api.Group, achievementsService Service) {
+	g.GET("/achievements", "Every achievement, with when the user earned the ones they have", []Achievement{}, func(e *core.RequestEvent) error {
+		achievements, err := achievementsService.List(e.Auth.Id)
+		if err != nil {
+			return e.InternalServerError("Failed to load achievements.", err)
+		}
+		return e.JSON(200, achievements)
+	})
+}
@@ -0,0 +1,84 @@
+package achievements
+
+import (
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/sessions"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+type Service interface {
+	// List returns every achievement, with when the user earned those they
+	// have
+	List(userId string) ([]Achievement, error)
+
+	// Award gives the user the achievements their completed study sessions
+	// reached and they don't have yet, returning those
+	Award(userId string) ([]Achievement, error)
+}
+
+type service struct {
+	app core.App
+}
+
+func NewService(app core.App) Service {
+	return &service{app: app}
+}
+
+func (s *service) List(userId string) ([]Achievement, error) {
+	earned, err := s.earned(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	achievements := make([]Achievement, 0, len(Milestones))
+	for _, m := range Milestones {
+		achievements = append(achievements, Achievement{Key: m.Key, Sessions: m.Sessions, Earned: earned[m.Key]})
+	}
+	return achievements, nil
+}
+
+func (s *service) Award(userId string) ([]Achievement, error) {
+	completed, err := s.app.CountRecords("sessions", dbx.HashExp{"user": userId, "kind": sessions.KindStudy, "completed": true})
+	if err != nil {
+		return nil, err
+	}
+	earned, err := s.earned(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	collection, err := s.app.FindCollectionByNameOrId("achievements")
+	if err != nil {
+		return nil, err
+	}
+
+	awarded := []Achievement{}
+	for _, m := range reached(int(completed)) {
+		if _, ok := earned[m.Key]; ok {
+			continue
+		}
+		record := core.NewRecord(collection)
+		record.Set("user", userId)
+		record.Set("key", m.Key)
+		if err := s.app.Save(record); err != nil {
+			return nil, err
+		}
+		awarded = append(awarded, Achievement{Key: m.Key, Sessions: m.Sessions, Earned: record.GetDateTime("created")})
+	}
+	return awarded, nil
+}
+
+// earned maps the keys of the user's achievements to when they earned them
+func (s *service) earned(userId string) (map[string]types.DateTime, error) {
+	records, err := s.app.FindAllRecords("achievements", dbx.HashExp{"user": userId})
+	if err != nil {
+		return nil, err
+	}
+	earned := make(map[string]types.DateTime, len(records))
+	for _, record := range records {
+		earned[record.GetString("key")] = record.GetDateTime("created")
+	}
+	return earned, nil
+}
@@ -0,0 +1,117 @@
+package achievements
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/events"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/sessions"
+	_ "github.com/bunkbed-tech/fushigi/pocketbase/migrations"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+// newTestApp is a migrated app with one user
+func newTestApp(t *testing.T) (*tests.TestApp, *core.Record) {
+	t.Helper()
+	// the initial migration creates a superuser from these
+	t.Setenv("ADMIN_EMAIL", "admin@example.com")
+	t.Setenv("ADMIN_PASSWORD", "correct horse battery")
+	app, err := tests.NewTestApp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(app.Cleanup)
+	if err := app.RunAllMigrations(); err != nil {
+		t.Fatal(err)
+	}
+
+	users, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := core.NewRecord(users)
+	user.SetEmail("learner@example.com")
+	// usernames are unique when set, so every user gets one
+	user.Set("username", "learner")
+	user.SetPassword("correct horse battery")
+	if err := app.Save(user); err != nil {
+		t.Fatal(err)
+	}
+	return app, user
+}
+
+// saveSession saves a study session started an hour ago aiming for target
+func saveSession(t *testing.T, app core.App, userId string, target time.Duration) *core.Record {
+	t.Helper()
+	collection, err := app.FindCollectionByNameOrId("sessions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := core.NewRecord(collection)
+	record.Set("user", userId)
+	record.Set("kind", sessions.KindStudy)
+	record.Set("started", time.Now().Add(-time.Hour))
+	record.Set("target_duration", int(target.Seconds()))
+	if err := app.Save(record); err != nil {
+		t.Fatal(err)
+	}
+	return record
+}
+
+func TestReached(t *testing.T) {
+	tests := []struct {
+		completed int
+		want      int
+	}{
+		{0, 0},
+		{1, 1},
+		{9, 1},
+		{10, 2},
+		{1000, len(Milestones)},
+	}
+	for _, tt := range tests {
+		if got := reached(tt.completed); len(got) != tt.want {
+			t.Errorf("reached(%d) = %v, want %d milestones", tt.completed, got, tt.want)
+		}
+	}
+}
+
+func TestAward(t *testing.T) {
+	app, user := newTestApp(t)
+	service := NewService(app)
+	bus := events.NewBus()
+	events.BindHooks(app, bus)
+	BindHooks(bus, service)
+	sessionsService := sessions.NewService(app)
+
+	// stopped short of its target
+	short := saveSession(t, app, user.Id, 2*time.Hour)
+	if _, err := sessionsService.Stop(user.Id, short.Id); err != nil {
+		t.Fatal(err)
+	}
+	list, err := service.List(user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != len(Milestones) || !list[0].Earned.IsZero() {
+		t.Errorf("List() after an incomplete session = %+v, want none earned", list)
+	}
+
+	completed := saveSession(t, app, user.Id, time.Minute)
+	if _, err := sessionsService.Stop(user.Id, completed.Id); err != nil {
+		t.Fatal(err)
+	}
+	list, err = service.List(user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list[0].Earned.IsZero() || !list[1].Earned.IsZero() {
+		t.Errorf("List() after a completed session = %+v, want only %s earned", list, Milestones[0].Key)
+	}
+
+	if awarded, err := service.Award(user.Id); err != nil || len(awarded) != 0 {
+		t.Errorf("Award() again = %+v, %v, want nothing new", awarded, err)
+	}
+}
@@ -0,0 +1,47 @@
+package activity
+
+import (
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// The kinds of things the log shows the user did
+const (
+	// KindEntry is a journal entry written
+	KindEntry = "entry"
+
+	// KindReviews is a batch of reviews, Count being how many
+	KindReviews = "reviews"
+
+	// KindCard is grammar added to the user's reviews
+	KindCard = "card"
+
+	// KindGrammar is grammar the user wrote themselves
+	KindGrammar = "grammar"
+
+	// KindImport is an import of journal entries committed
+	KindImport = "import"
+)
+
+var Kinds = []string{KindEntry, KindReviews, KindCard, KindGrammar, KindImport}
+
+// Item is one thing the user did. Record is the id of the record it's about,
+// in the collection its kind is stored in.
+type Item struct {
+	Id     string `json:"id"`
+	Kind   string `json:"kind"`
+	Record string `json:"record"`
+
+	// Title is the entry's title, the grammar's usage, or the import's
+	// source, empty for reviews
+	Title string `json:"title"`
+	Count int    `json:"count"`
+
+	Time types.DateTime `json:"time"`
+}
+
+// ItemPage is one page of the log, Next being the cursor of the following
+// page, empty on the last one
+type ItemPage struct {
+	Items []Item `json:"items"`
+	Next  string `json:"next"`
+}
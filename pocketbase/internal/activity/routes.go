@@ -0,0 +1,38 @@
+package activity
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+func RegisterRoutes(g *api.Group, activityService Service) {
+	g.GET("/activity", "What the user did, entries written, reviews, grammar added and imports, a page at a time, newest first, with ?cursor=, ?limit= and ?kind= a comma separated list of kinds", ItemPage{}, func(e *core.RequestEvent) error {
+		page, err := api.PageFromRequest(e)
+		if err != nil {
+			return e.BadRequestError("Invalid activity request.", err)
+		}
+
+		var kinds []string
+		if raw := e.Request.URL.Query().Get("kind"); raw != "" {
+			for _, kind := range strings.Split(raw, ",") {
+				if !slices.Contains(Kinds, kind) {
+					return e.BadRequestError("Invalid activity request.", validation.Errors{
+						"kind": validation.NewError("validation_invalid_value", "Invalid value."),
+					})
+				}
+				kinds = append(kinds, kind)
+			}
+		}
+
+		result, err := activityService.List(e.Auth.Id, kinds, page)
+		if err != nil {
+			return e.InternalServerError("Failed to load the activity.", err)
+		}
+		return e.JSON(200, result)
+	})
+}
@@ -0,0 +1,90 @@
+package activity
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// sources select each kind of item from the records it's built from, with
+// the same columns so they can be merged into one list. Ids are prefixed with
+// the kind so they stay unique across collections.
+var sources = map[string]string{
+	KindEntry: `SELECT 'entry:' || id AS id, 'entry' AS kind, id AS record, title, 0 AS count, created AS time
+		FROM journal_entry WHERE user = {:user}`,
+	KindReviews: `SELECT 'reviews:' || id AS id, 'reviews' AS kind, id AS record, '' AS title, reviewed AS count, ended AS time
+		FROM review_summaries WHERE user = {:user}`,
+	KindCard: `SELECT 'card:' || srs.id AS id, 'card' AS kind, srs.id AS record, COALESCE(grammar.usage, vocabulary.term, '') AS title, 0 AS count, srs.created AS time
+		FROM srs LEFT JOIN grammar ON grammar.id = srs.grammar LEFT JOIN vocabulary ON vocabulary.id = srs.vocabulary WHERE srs.user = {:user}`,
+	KindGrammar: `SELECT 'grammar:' || id AS id, 'grammar' AS kind, id AS record, usage AS title, 0 AS count, created AS time
+		FROM grammar WHERE user = {:user}`,
+	KindImport: `SELECT 'import:' || id AS id, 'import' AS kind, id AS record, kind AS title, 0 AS count, committed AS time
+		FROM imports WHERE user = {:user} AND status = 'committed'`,
+}
+
+type Service interface {
+	// List returns what the user did a page at a time, newest first, only
+	// the given kinds when there are any
+	List(userId string, kinds []string, page api.Page) (ItemPage, error)
+}
+
+type service struct {
+	app core.App
+}
+
+func NewService(app core.App) Service {
+	return &service{app: app}
+}
+
+func (s *service) List(userId string, kinds []string, page api.Page) (ItemPage, error) {
+	if len(kinds) == 0 {
+		kinds = Kinds
+	}
+	selects := make([]string, 0, len(kinds))
+	for _, kind := range Kinds {
+		if slices.Contains(kinds, kind) {
+			selects = append(selects, sources[kind])
+		}
+	}
+
+	params := dbx.Params{"user": userId, "limit": page.Limit + 1}
+	where := "time != ''"
+	if page.After != nil {
+		params["cursorValue"] = page.After.Value
+		params["cursorId"] = page.After.Id
+		where += " AND (time < {:cursorValue} OR (time = {:cursorValue} AND id < {:cursorId}))"
+	}
+
+	var rows []struct {
+		Id     string         `db:"id"`
+		Kind   string         `db:"kind"`
+		Record string         `db:"record"`
+		Title  string         `db:"title"`
+		Count  int            `db:"count"`
+		Time   types.DateTime `db:"time"`
+	}
+	err := s.app.DB().
+		NewQuery("SELECT * FROM (" + strings.Join(selects, " UNION ALL ") + ") WHERE " + where + " ORDER BY time DESC, id DESC LIMIT {:limit}").
+		Bind(params).
+		All(&rows)
+	if err != nil {
+		return ItemPage{}, err
+	}
+
+	items := make([]Item, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, Item(row))
+	}
+	next := page.Next(len(items), func(i int) api.Cursor {
+		return api.Cursor{Value: items[i].Time.String(), Id: items[i].Id}
+	})
+	if len(items) > page.Limit {
+		items = items[:page.Limit]
+	}
+	return ItemPage{Items: items, Next: next}, nil
+}
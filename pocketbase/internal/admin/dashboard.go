@@ -0,0 +1,78 @@
+package admin
+
+import (
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/dbstats"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/jobs"
+
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// SlowRequestMillis is how long a request takes before it counts as slow
+const SlowRequestMillis = 500
+
+// Dashboard is a snapshot of the instance's health for the ops page
+type Dashboard struct {
+	Users        UserCounts    `json:"users"`
+	Storage      StorageUsage  `json:"storage"`
+	Jobs         JobHealth     `json:"jobs"`
+	SlowRequests []SlowRequest `json:"slow_requests"`
+	AISpend      []AISpend     `json:"ai_spend"`
+
+	// Since the server started, to tell which indexes are missing and which
+	// routes query in a loop
+	SlowQueries  []dbstats.SlowQuery `json:"slow_queries"`
+	HotEndpoints []dbstats.Endpoint  `json:"hot_endpoints"`
+
+	Generated types.DateTime `json:"generated"`
+}
+
+type UserCounts struct {
+	Total    int `json:"total"`
+	Verified int `json:"verified"`
+
+	// Signups in the last 7 days
+	New int `json:"new"`
+
+	// Users who reviewed or wrote in their journal in the last day, week and month
+	ActiveDay   int `json:"active_day"`
+	ActiveWeek  int `json:"active_week"`
+	ActiveMonth int `json:"active_month"`
+}
+
+// StorageUsage is the size on disk of the data dir, in bytes. Files are only
+// counted when stored locally rather than on S3.
+type StorageUsage struct {
+	Database  int64 `json:"database"`
+	Auxiliary int64 `json:"auxiliary"`
+	Files     int64 `json:"files"`
+	Backups   int64 `json:"backups"`
+	Total     int64 `json:"total"`
+}
+
+type JobHealth struct {
+	// Number of jobs per status, over the whole history
+	ByStatus map[string]int `json:"by_status"`
+
+	// Jobs that failed in the last day, most recent first
+	RecentFailures []jobs.Job `json:"recent_failures"`
+}
+
+// SlowRequest is a request from the last day that took over SlowRequestMillis
+type SlowRequest struct {
+	Method   string         `json:"method"`
+	URL      string         `json:"url"`
+	Status   int            `json:"status"`
+	ExecTime float64        `json:"exec_time"`
+	Created  types.DateTime `json:"created"`
+}
+
+// AISpend is what the instance sent to one provider and model in a UTC day,
+// kept after the audit log it's counted from is purged
+type AISpend struct {
+	Day              string `json:"day" db:"day"`
+	Provider         string `json:"provider" db:"provider"`
+	Model            string `json:"model" db:"model"`
+	Requests         int    `json:"requests" db:"requests"`
+	PromptTokens     int    `json:"prompt_tokens" db:"prompt_tokens"`
+	CompletionTokens int    `json:"completion_tokens" db:"completion_tokens"`
+}
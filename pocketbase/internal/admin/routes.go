@@ -0,0 +1,61 @@
+package admin
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// dashboardMaxAge matches how often the ops page polls
+const dashboardMaxAge = time.Minute
+
+func RegisterRoutes(g *api.Group, adminService Service, conditional *api.Conditional) {
+	// Backs the ops page of the web app, which polls it every minute or so
+	g.GET("/dashboard", "Instance health at a glance", Dashboard{}, func(e *core.RequestEvent) error {
+		if conditional.NotModified(e, dashboardMaxAge) {
+			return e.NoContent(http.StatusNotModified)
+		}
+
+		dashboard, err := adminService.Dashboard()
+		if err != nil {
+			return e.InternalServerError("Failed to build the dashboard.", err)
+		}
+		return conditional.JSON(e, dashboardMaxAge, dashboard)
+	})
+}
+
+// RegisterStaffRoutes adds the routes handing out staff roles, for full
+// admins
+func RegisterStaffRoutes(g *api.Group, adminService Service) {
+	g.GET("/staff", "The users with a staff role", []StaffMember{}, func(e *core.RequestEvent) error {
+		staff, err := adminService.Staff()
+		if err != nil {
+			return e.InternalServerError("Failed to load the staff.", err)
+		}
+		return e.JSON(200, staff)
+	})
+
+	g.POST("/staff/{id}", "Give a user a staff role (admin, moderator or support), or take theirs away with an empty one", RoleRequest{}, StaffMember{}, func(e *core.RequestEvent) error {
+		var req RoleRequest
+		if err := e.BindBody(&req); err != nil {
+			return e.BadRequestError("Invalid request body.", err)
+		}
+		if err := req.Validate(); err != nil {
+			return e.BadRequestError("Invalid role.", err)
+		}
+
+		member, err := adminService.SetRole(e.Request.PathValue("id"), req.Role)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return e.NotFoundError("", err)
+		case err != nil:
+			return e.InternalServerError("Failed to save the role.", err)
+		}
+		return e.JSON(200, member)
+	})
+}
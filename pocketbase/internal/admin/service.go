@@ -0,0 +1,296 @@
+package admin
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/dbstats"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/jobs"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+const (
+	day = 24 * time.Hour
+
+	recentFailuresLimit = 20
+	slowRequestsLimit   = 20
+	hotEndpointsLimit   = 20
+
+	// aiSpendDays is how far back the dashboard shows AI spend
+	aiSpendDays = 30
+)
+
+type Service interface {
+	Dashboard() (Dashboard, error)
+
+	// Staff lists the users with a staff role, by name
+	Staff() ([]StaffMember, error)
+
+	// SetRole gives the user a staff role, or takes theirs away when empty
+	SetRole(userId string, role string) (StaffMember, error)
+}
+
+type service struct {
+	app     core.App
+	queries *dbstats.Tracker
+}
+
+func NewService(app core.App, queries *dbstats.Tracker) Service {
+	return &service{app: app, queries: queries}
+}
+
+func (s *service) Dashboard() (Dashboard, error) {
+	var err error
+	dashboard := Dashboard{Generated: types.NowDateTime()}
+
+	if dashboard.Users, err = s.userCounts(); err != nil {
+		return Dashboard{}, err
+	}
+	if dashboard.Storage, err = s.storageUsage(); err != nil {
+		return Dashboard{}, err
+	}
+	if dashboard.Jobs, err = s.jobHealth(); err != nil {
+		return Dashboard{}, err
+	}
+	if dashboard.SlowRequests, err = s.slowRequests(); err != nil {
+		return Dashboard{}, err
+	}
+	if dashboard.AISpend, err = s.aiSpend(); err != nil {
+		return Dashboard{}, err
+	}
+	dashboard.SlowQueries = s.queries.SlowQueries()
+	dashboard.HotEndpoints = s.queries.HotEndpoints(hotEndpointsLimit)
+
+	return dashboard, nil
+}
+
+func (s *service) userCounts() (UserCounts, error) {
+	var counts UserCounts
+	var err error
+
+	if counts.Total, err = s.countUsers(nil); err != nil {
+		return UserCounts{}, err
+	}
+	if counts.Verified, err = s.countUsers(dbx.HashExp{"verified": true}); err != nil {
+		return UserCounts{}, err
+	}
+	if counts.New, err = s.countUsers(dbx.NewExp("created >= {:since}", dbx.Params{"since": since(7 * day)})); err != nil {
+		return UserCounts{}, err
+	}
+
+	if counts.ActiveDay, err = s.countActiveUsers(day); err != nil {
+		return UserCounts{}, err
+	}
+	if counts.ActiveWeek, err = s.countActiveUsers(7 * day); err != nil {
+		return UserCounts{}, err
+	}
+	if counts.ActiveMonth, err = s.countActiveUsers(30 * day); err != nil {
+		return UserCounts{}, err
+	}
+
+	return counts, nil
+}
+
+func (s *service) countUsers(where dbx.Expression) (int, error) {
+	var count int
+	// sandboxes and study profiles belong to users counted already, guests
+	// aren't users yet
+	query := s.app.RecordQuery("users").Select("COUNT(*)").AndWhere(dbx.HashExp{"sandbox_of": "", "profile_of": "", "guest_expires": ""})
+	if where != nil {
+		query.AndWhere(where)
+	}
+	err := query.Row(&count)
+	return count, err
+}
+
+// countActiveUsers counts users who saved a review or a journal entry within
+// d, in any of their study profiles
+func (s *service) countActiveUsers(d time.Duration) (int, error) {
+	var count int
+	err := s.app.DB().NewQuery(`
+		SELECT COUNT(DISTINCT CASE WHEN users.profile_of != '' THEN users.profile_of ELSE users.id END) FROM (
+			SELECT user FROM srs WHERE updated >= {:since}
+			UNION
+			SELECT user FROM journal_entry WHERE updated >= {:since}
+		) active JOIN users ON users.id = active.user
+		WHERE users.sandbox_of = '' AND users.guest_expires = ''
+	`).Bind(dbx.Params{"since": since(d)}).Row(&count)
+	return count, err
+}
+
+func (s *service) storageUsage() (StorageUsage, error) {
+	var usage StorageUsage
+	var err error
+	dataDir := s.app.DataDir()
+
+	// sqlite keeps recent writes in the -wal file until the next checkpoint
+	if usage.Database, err = filesSize(dataDir, "data.db", "data.db-wal"); err != nil {
+		return StorageUsage{}, err
+	}
+	if usage.Auxiliary, err = filesSize(dataDir, "auxiliary.db", "auxiliary.db-wal"); err != nil {
+		return StorageUsage{}, err
+	}
+	if usage.Files, err = dirSize(filepath.Join(dataDir, "storage")); err != nil {
+		return StorageUsage{}, err
+	}
+	if usage.Backups, err = dirSize(filepath.Join(dataDir, "backups")); err != nil {
+		return StorageUsage{}, err
+	}
+	usage.Total = usage.Database + usage.Auxiliary + usage.Files + usage.Backups
+
+	return usage, nil
+}
+
+func (s *service) jobHealth() (JobHealth, error) {
+	health := JobHealth{ByStatus: map[string]int{}}
+
+	var rows []struct {
+		Status string `db:"status"`
+		Count  int    `db:"count"`
+	}
+	err := s.app.RecordQuery("jobs").
+		Select("status", "COUNT(*) AS count").
+		GroupBy("status").
+		All(&rows)
+	if err != nil {
+		return JobHealth{}, err
+	}
+	for _, row := range rows {
+		health.ByStatus[row.Status] = row.Count
+	}
+
+	records, err := s.app.FindRecordsByFilter(
+		"jobs",
+		"status = {:status} && updated >= {:since}",
+		"-updated",
+		recentFailuresLimit,
+		0,
+		dbx.Params{"status": jobs.StatusFailed, "since": since(day)},
+	)
+	if err != nil {
+		return JobHealth{}, err
+	}
+	health.RecentFailures = make([]jobs.Job, 0, len(records))
+	for _, record := range records {
+		health.RecentFailures = append(health.RecentFailures, jobs.FromRecord(record))
+	}
+
+	return health, nil
+}
+
+// slowRequests reads the request logs, so it only sees what the log retention
+// and minimum level settings keep
+func (s *service) slowRequests() ([]SlowRequest, error) {
+	var logs []*core.Log
+	err := s.app.LogQuery().
+		AndWhere(dbx.NewExp("json_extract(data, '$.type') = 'request'")).
+		AndWhere(dbx.NewExp("json_extract(data, '$.execTime') >= {:ms}", dbx.Params{"ms": SlowRequestMillis})).
+		AndWhere(dbx.NewExp("created >= {:since}", dbx.Params{"since": since(day)})).
+		OrderBy("json_extract(data, '$.execTime') DESC").
+		Limit(slowRequestsLimit).
+		All(&logs)
+	if err != nil {
+		return nil, err
+	}
+
+	requests := make([]SlowRequest, 0, len(logs))
+	for _, log := range logs {
+		status, _ := log.Data["status"].(float64)
+		execTime, _ := log.Data["execTime"].(float64)
+		method, _ := log.Data["method"].(string)
+		url, _ := log.Data["url"].(string)
+
+		requests = append(requests, SlowRequest{
+			Method:   method,
+			URL:      url,
+			Status:   int(status),
+			ExecTime: execTime,
+			Created:  log.Created,
+		})
+	}
+	return requests, nil
+}
+
+// aiSpend returns the daily AI spend of the last aiSpendDays days, newest
+// first
+func (s *service) aiSpend() ([]AISpend, error) {
+	spend := []AISpend{}
+	err := s.app.DB().Select("*").
+		From("_ai_spend").
+		AndWhere(dbx.NewExp("day >= {:since}", dbx.Params{"since": time.Now().UTC().Add(-aiSpendDays * day).Format(time.DateOnly)})).
+		OrderBy("day DESC", "provider", "model").
+		All(&spend)
+	return spend, err
+}
+
+// since formats the moment d ago the way PocketBase stores dates
+func since(d time.Duration) string {
+	return types.NowDateTime().Add(-d).String()
+}
+
+func filesSize(dir string, names ...string) (int64, error) {
+	var total int64
+	for _, name := range names {
+		info, err := os.Stat(filepath.Join(dir, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		total += info.Size()
+	}
+	return total, nil
+}
+
+func dirSize(dir string) (int64, error) {
+	var total int64
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if os.IsNotExist(err) {
+			return filepath.SkipDir
+		}
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		total += info.Size()
+		return nil
+	})
+	return total, err
+}
+
+func (s *service) Staff() ([]StaffMember, error) {
+	records, err := s.app.FindRecordsByFilter("users", "admin_role != ''", "name", 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	staff := make([]StaffMember, 0, len(records))
+	for _, rec := range records {
+		staff = append(staff, StaffMemberFromRecord(rec))
+	}
+	return staff, nil
+}
+
+func (s *service) SetRole(userId string, role string) (StaffMember, error) {
+	user, err := s.app.FindRecordById("users", userId)
+	if err != nil {
+		return StaffMember{}, err
+	}
+	user.Set("admin_role", role)
+	if err := s.app.Save(user); err != nil {
+		return StaffMember{}, err
+	}
+	return StaffMemberFromRecord(user), nil
+}
@@ -0,0 +1,41 @@
+package admin
+
+import (
+	"slices"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// StaffMember is a user with a staff role
+type StaffMember struct {
+	Id    string `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+func StaffMemberFromRecord(rec *core.Record) StaffMember {
+	return StaffMember{
+		Id:    rec.Id,
+		Name:  rec.GetString("name"),
+		Email: rec.Email(),
+		Role:  rec.GetString("admin_role"),
+	}
+}
+
+// RoleRequest gives a user a staff role, or takes theirs away when empty
+type RoleRequest struct {
+	Role string `json:"role"`
+}
+
+func (r RoleRequest) Validate() error {
+	if r.Role != "" && !slices.Contains(api.Roles, r.Role) {
+		return validation.Errors{
+			"role": validation.NewError("validation_invalid_value", "Must be admin, moderator, support or empty."),
+		}
+	}
+	return nil
+}
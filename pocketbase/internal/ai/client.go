@@ -0,0 +1,175 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	defaultAPIURL = "https://api.openai.com/v1"
+
+	// requestTimeout bounds one completion, models can be slow on long
+	// journal entries
+	requestTimeout = 90 * time.Second
+)
+
+// ErrEmptyCompletion means the model answered with nothing
+var ErrEmptyCompletion = errors.New("ai completion is empty")
+
+// Roles of the messages of a conversation with the model
+const (
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// Message is an earlier turn of a conversation with the model
+type Message struct {
+	Role    string
+	Content string
+}
+
+// Request is one prompt for the model
+type Request struct {
+	// System sets up the model's role and the shape of its answer
+	System string
+
+	// History is the conversation so far, oldest first, which the prompt
+	// carries on
+	History []Message
+	Prompt  string
+
+	// JSON asks for the answer as a JSON object
+	JSON bool
+}
+
+// Client completes prompts with a chat model
+type Client interface {
+	Complete(ctx context.Context, req Request) (string, error)
+}
+
+// ClientFromEnv returns nil when self-hosters didn't set up AI features. Any
+// OpenAI compatible chat completions API works: AI_API_URL (defaults to
+// OpenAI's), AI_API_KEY and AI_MODEL. With AI_LOCAL_ONLY it defaults to a
+// local Ollama and refuses anything that isn't local. Every call is reported
+// to observer, which may be nil.
+func ClientFromEnv(observer Observer) (Client, error) {
+	key, model := os.Getenv("AI_API_KEY"), os.Getenv("AI_MODEL")
+
+	// Ollama needs no key, a model is enough to turn local AI on
+	if os.Getenv("AI_API_URL") == "" && key == "" && (!localOnly() || model == "") {
+		return nil, nil
+	}
+	if model == "" {
+		return nil, errors.New("AI_MODEL is required to enable AI features")
+	}
+	apiURL, err := apiURLFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	return &chatClient{
+		url:      strings.TrimSuffix(apiURL, "/") + "/chat/completions",
+		key:      key,
+		model:    model,
+		http:     newHTTPClient(),
+		observer: observer,
+	}, nil
+}
+
+type chatClient struct {
+	url      string
+	key      string
+	model    string
+	http     *http.Client
+	observer Observer
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model          string         `json:"model"`
+	Messages       []chatMessage  `json:"messages"`
+	ResponseFormat map[string]any `json:"response_format,omitempty"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+func (c *chatClient) Complete(ctx context.Context, req Request) (answer string, err error) {
+	model := pick(currentDefaults().Model, c.model)
+	body := chatRequest{Model: model}
+	if req.System != "" {
+		body.Messages = append(body.Messages, chatMessage{Role: "system", Content: req.System})
+	}
+	for _, m := range req.History {
+		body.Messages = append(body.Messages, chatMessage{Role: m.Role, Content: m.Content})
+	}
+	body.Messages = append(body.Messages, chatMessage{Role: RoleUser, Content: req.Prompt})
+	if req.JSON {
+		body.ResponseFormat = map[string]any{"type": "json_object"}
+	}
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+
+	var completion chatResponse
+	started := time.Now()
+	defer func() {
+		observe(ctx, c.observer, Call{
+			Provider:         providerOf(c.url),
+			Model:            model,
+			Operation:        OperationComplete,
+			PromptTokens:     completion.Usage.PromptTokens,
+			CompletionTokens: completion.Usage.CompletionTokens,
+			Err:              err,
+		}, started, payload)
+	}()
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.key != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.key)
+	}
+
+	res, err := c.http.Do(httpReq)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return "", fmt.Errorf("ai completion failed with %s: %s", res.Status, detail)
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(&completion); err != nil {
+		return "", err
+	}
+	if len(completion.Choices) == 0 || strings.TrimSpace(completion.Choices[0].Message.Content) == "" {
+		return "", ErrEmptyCompletion
+	}
+	return completion.Choices[0].Message.Content, nil
+}
@@ -0,0 +1,36 @@
+package ai
+
+import "sync/atomic"
+
+// Defaults override the models and voice set up in the environment while the
+// server runs, empty ones leaving the environment's. Where the providers are
+// and their keys only come from the environment, and so does the embedding
+// model since vectors of different models can't be compared.
+type Defaults struct {
+	Model           string
+	TranscribeModel string
+	SpeechModel     string
+	SpeechVoice     string
+}
+
+var defaults atomic.Pointer[Defaults]
+
+// SetDefaults applies to the calls made from then on
+func SetDefaults(d Defaults) {
+	defaults.Store(&d)
+}
+
+func currentDefaults() Defaults {
+	if d := defaults.Load(); d != nil {
+		return *d
+	}
+	return Defaults{}
+}
+
+// pick returns override unless it's empty
+func pick(override string, value string) string {
+	if override != "" {
+		return override
+	}
+	return value
+}
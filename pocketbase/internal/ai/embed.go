@@ -0,0 +1,140 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Embedder turns text into vectors that are close for text that means
+// similar things
+type Embedder interface {
+	// Model names what the vectors come from, vectors of different models
+	// can't be compared
+	Model() string
+
+	// Embed returns one vector for each text, in order
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// EmbedderFromEnv returns nil unless an embedding model is set up with
+// AI_EMBEDDING_MODEL. Embeddings can come from another provider than
+// completions: AI_EMBEDDING_API_URL and AI_EMBEDDING_API_KEY default to
+// AI_API_URL and AI_API_KEY. Every call is reported to observer, which may be
+// nil.
+func EmbedderFromEnv(observer Observer) (Embedder, error) {
+	model := os.Getenv("AI_EMBEDDING_MODEL")
+	if model == "" {
+		return nil, nil
+	}
+	apiURL, key := os.Getenv("AI_EMBEDDING_API_URL"), os.Getenv("AI_EMBEDDING_API_KEY")
+	if apiURL == "" {
+		apiURL = os.Getenv("AI_API_URL")
+	}
+	if key == "" {
+		key = os.Getenv("AI_API_KEY")
+	}
+	if apiURL == "" && key == "" && !localOnly() {
+		return nil, errors.New("AI_EMBEDDING_API_URL or AI_EMBEDDING_API_KEY is required to enable embeddings")
+	}
+	apiURL, err := checkAPIURL(apiURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &embeddingClient{
+		url:      strings.TrimSuffix(apiURL, "/") + "/embeddings",
+		key:      key,
+		model:    model,
+		http:     newHTTPClient(),
+		observer: observer,
+	}, nil
+}
+
+type embeddingClient struct {
+	url      string
+	key      string
+	model    string
+	http     *http.Client
+	observer Observer
+}
+
+type embeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type embeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+	Usage struct {
+		PromptTokens int `json:"prompt_tokens"`
+	} `json:"usage"`
+}
+
+func (c *embeddingClient) Model() string {
+	return c.model
+}
+
+func (c *embeddingClient) Embed(ctx context.Context, texts []string) (vectors [][]float32, err error) {
+	payload, err := json.Marshal(embeddingRequest{Model: c.model, Input: texts})
+	if err != nil {
+		return nil, err
+	}
+
+	var embedded embeddingResponse
+	started := time.Now()
+	defer func() {
+		observe(ctx, c.observer, Call{
+			Provider:     providerOf(c.url),
+			Model:        c.model,
+			Operation:    OperationEmbed,
+			PromptTokens: embedded.Usage.PromptTokens,
+			Err:          err,
+		}, started, payload)
+	}()
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.key != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.key)
+	}
+
+	res, err := c.http.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return nil, fmt.Errorf("ai embedding failed with %s: %s", res.Status, detail)
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(&embedded); err != nil {
+		return nil, err
+	}
+	if len(embedded.Data) != len(texts) {
+		return nil, fmt.Errorf("ai embedding returned %d vectors for %d texts", len(embedded.Data), len(texts))
+	}
+	vectors = make([][]float32, len(texts))
+	for _, d := range embedded.Data {
+		if d.Index < 0 || d.Index >= len(texts) || len(d.Embedding) == 0 {
+			return nil, fmt.Errorf("ai embedding returned an unexpected vector at %d", d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	return vectors, nil
+}
@@ -0,0 +1,96 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// defaultLocalAPIURL is where Ollama serves its OpenAI compatible API
+const defaultLocalAPIURL = "http://localhost:11434/v1"
+
+// ErrNotLocal means a request was about to leave the local network while
+// AI_LOCAL_ONLY is on
+var ErrNotLocal = errors.New("AI_LOCAL_ONLY refuses providers outside the local network")
+
+// localOnly reports whether AI_LOCAL_ONLY keeps every AI request on the
+// machine or local network, for self-hosters who won't send journals to a
+// cloud provider
+func localOnly() bool {
+	on, _ := strconv.ParseBool(os.Getenv("AI_LOCAL_ONLY"))
+	return on
+}
+
+// apiURLFromEnv is AI_API_URL, defaulting to OpenAI's or in local only mode
+// to Ollama's. It refuses URLs that are plainly not local in that mode,
+// hostnames are only checked once they resolve.
+func apiURLFromEnv() (string, error) {
+	return checkAPIURL(os.Getenv("AI_API_URL"))
+}
+
+// checkAPIURL defaults and checks an API URL like apiURLFromEnv does
+func checkAPIURL(apiURL string) (string, error) {
+	if !localOnly() {
+		if apiURL == "" {
+			apiURL = defaultAPIURL
+		}
+		return apiURL, nil
+	}
+
+	if apiURL == "" {
+		return defaultLocalAPIURL, nil
+	}
+	u, err := url.Parse(apiURL)
+	if err != nil {
+		return "", err
+	}
+	if ip := net.ParseIP(u.Hostname()); ip != nil && !isLocal(ip) {
+		return "", fmt.Errorf("%w: %s", ErrNotLocal, u.Hostname())
+	}
+	return apiURL, nil
+}
+
+// newHTTPClient is the client requests to the provider go through. In local
+// only mode it refuses to connect anywhere but local addresses, whatever a
+// hostname resolves to, and ignores proxies.
+func newHTTPClient() *http.Client {
+	if !localOnly() {
+		return &http.Client{Timeout: requestTimeout}
+	}
+
+	dialer := &net.Dialer{
+		Timeout: 30 * time.Second,
+		Control: func(network string, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !isLocal(ip) {
+				return fmt.Errorf("%w: %s", ErrNotLocal, host)
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: requestTimeout,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network string, address string) (net.Conn, error) {
+				return dialer.DialContext(ctx, network, address)
+			},
+			ForceAttemptHTTP2:   true,
+			TLSHandshakeTimeout: 10 * time.Second,
+		},
+	}
+}
+
+// isLocal reports whether an address is on the machine or a private network
+func isLocal(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast()
+}
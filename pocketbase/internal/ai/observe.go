@@ -0,0 +1,92 @@
+package ai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"time"
+)
+
+// Operations sent to the provider
+const (
+	OperationComplete   = "complete"
+	OperationTranscribe = "transcribe"
+	OperationSynthesize = "synthesize"
+	OperationEmbed      = "embed"
+)
+
+// Call is one request sent to the provider, without what was sent
+type Call struct {
+	// Provider is the host of the API
+	Provider  string
+	Model     string
+	Operation string
+
+	// PromptHash is the SHA-256 of everything sent, so users can match a
+	// call to their content without it being stored a second time
+	PromptHash string
+
+	// tokens as reported by the provider, zero when it doesn't
+	PromptTokens     int
+	CompletionTokens int
+
+	Duration time.Duration
+
+	// Err is why the call failed, nil when it didn't
+	Err error
+
+	Subject Subject
+}
+
+// Subject is who and what a call is on behalf of
+type Subject struct {
+	User string
+
+	// Collection and Record are the record whose content is sent, empty when
+	// it isn't one
+	Collection string
+	Record     string
+}
+
+// Observer is told about every call sent to the provider
+type Observer interface {
+	Observe(ctx context.Context, call Call)
+}
+
+type subjectKey struct{}
+
+// WithSubject tags the calls made with ctx with who and what they're for
+func WithSubject(ctx context.Context, subject Subject) context.Context {
+	return context.WithValue(ctx, subjectKey{}, subject)
+}
+
+// SubjectFrom returns the subject ctx was tagged with, zero when it wasn't
+func SubjectFrom(ctx context.Context) Subject {
+	subject, _ := ctx.Value(subjectKey{}).(Subject)
+	return subject
+}
+
+// observe reports a call when there's an observer
+func observe(ctx context.Context, observer Observer, call Call, started time.Time, hashed ...[]byte) {
+	if observer == nil {
+		return
+	}
+	h := sha256.New()
+	for _, b := range hashed {
+		h.Write(b)
+	}
+	call.PromptHash = hex.EncodeToString(h.Sum(nil))
+	call.Duration = time.Since(started)
+	call.Subject = SubjectFrom(ctx)
+	observer.Observe(ctx, call)
+}
+
+// providerOf is the host calls to an API URL go to
+func providerOf(apiURL string) string {
+	u, err := url.Parse(apiURL)
+	if err != nil || u.Host == "" {
+		return apiURL
+	}
+	return u.Host
+}
@@ -0,0 +1,177 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	defaultVoice = "alloy"
+
+	// maxSpeechSize bounds the synthesized audio read back, a few minutes
+	maxSpeechSize = 10 << 20
+)
+
+// ErrNoSpeechModel means only transcription is set up, not speaking back
+var ErrNoSpeechModel = errors.New("AI_SPEECH_MODEL is not set")
+
+// Speech transcribes speech and speaks text
+type Speech interface {
+	// Transcribe turns a recording into text, language being an ISO 639-1
+	// code hinting at what's spoken or empty to detect it
+	Transcribe(ctx context.Context, audio []byte, filename string, language string) (string, error)
+
+	// Synthesize reads text out, as mp3
+	Synthesize(ctx context.Context, text string) ([]byte, error)
+}
+
+// SpeechFromEnv returns nil unless a transcription model is set up on the
+// same API as ClientFromEnv: AI_TRANSCRIBE_MODEL, and AI_SPEECH_MODEL and
+// AI_SPEECH_VOICE (defaults to alloy) to also speak. Every call is reported
+// to observer, which may be nil.
+func SpeechFromEnv(observer Observer) (Speech, error) {
+	key := os.Getenv("AI_API_KEY")
+	transcribeModel, speechModel := os.Getenv("AI_TRANSCRIBE_MODEL"), os.Getenv("AI_SPEECH_MODEL")
+	if transcribeModel == "" {
+		if speechModel != "" {
+			return nil, errors.New("AI_TRANSCRIBE_MODEL is required to enable speech features")
+		}
+		return nil, nil
+	}
+	if os.Getenv("AI_API_URL") == "" && key == "" && !localOnly() {
+		return nil, errors.New("AI_API_URL or AI_API_KEY is required to enable speech features")
+	}
+	apiURL, err := apiURLFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	voice := os.Getenv("AI_SPEECH_VOICE")
+	if voice == "" {
+		voice = defaultVoice
+	}
+	return &speechClient{
+		url:             strings.TrimSuffix(apiURL, "/"),
+		key:             key,
+		transcribeModel: transcribeModel,
+		speechModel:     speechModel,
+		voice:           voice,
+		http:            newHTTPClient(),
+		observer:        observer,
+	}, nil
+}
+
+type speechClient struct {
+	url             string
+	key             string
+	transcribeModel string
+	speechModel     string
+	voice           string
+	http            *http.Client
+	observer        Observer
+}
+
+type transcription struct {
+	Text string `json:"text"`
+}
+
+type speechRequest struct {
+	Model          string `json:"model"`
+	Input          string `json:"input"`
+	Voice          string `json:"voice"`
+	ResponseFormat string `json:"response_format"`
+}
+
+func (c *speechClient) Transcribe(ctx context.Context, audio []byte, filename string, language string) (text string, err error) {
+	model := pick(currentDefaults().TranscribeModel, c.transcribeModel)
+	defer func(started time.Time) {
+		observe(ctx, c.observer, Call{Provider: providerOf(c.url), Model: model, Operation: OperationTranscribe, Err: err}, started, audio)
+	}(time.Now())
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	_ = form.WriteField("model", model)
+	if language != "" {
+		_ = form.WriteField("language", language)
+	}
+	part, err := form.CreateFormFile("file", filename)
+	if err != nil {
+		return "", err
+	}
+	if _, err := part.Write(audio); err != nil {
+		return "", err
+	}
+	if err := form.Close(); err != nil {
+		return "", err
+	}
+
+	res, err := c.post(ctx, "/audio/transcriptions", form.FormDataContentType(), &body)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	var result transcription
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(result.Text) == "" {
+		return "", ErrEmptyCompletion
+	}
+	return strings.TrimSpace(result.Text), nil
+}
+
+func (c *speechClient) Synthesize(ctx context.Context, text string) (audio []byte, err error) {
+	d := currentDefaults()
+	model, voice := pick(d.SpeechModel, c.speechModel), pick(d.SpeechVoice, c.voice)
+	if model == "" {
+		return nil, ErrNoSpeechModel
+	}
+	defer func(started time.Time) {
+		observe(ctx, c.observer, Call{Provider: providerOf(c.url), Model: model, Operation: OperationSynthesize, Err: err}, started, []byte(text))
+	}(time.Now())
+
+	payload, err := json.Marshal(speechRequest{Model: model, Input: text, Voice: voice, ResponseFormat: "mp3"})
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.post(ctx, "/audio/speech", "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	return io.ReadAll(io.LimitReader(res.Body, maxSpeechSize))
+}
+
+// post sends a request to the API, failing on anything but 200
+func (c *speechClient) post(ctx context.Context, path string, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if c.key != "" {
+		req.Header.Set("Authorization", "Bearer "+c.key)
+	}
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		detail, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return nil, fmt.Errorf("ai speech request failed with %s: %s", res.Status, detail)
+	}
+	return res, nil
+}
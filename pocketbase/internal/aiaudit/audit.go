@@ -0,0 +1,56 @@
+package aiaudit
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Entry is one request an AI feature sent to the provider for a user
+type Entry struct {
+	Id        string `json:"id"`
+	User      string `json:"user"`
+	Provider  string `json:"provider"`
+	Model     string `json:"model"`
+	Operation string `json:"operation"`
+
+	// PromptHash is the SHA-256 of what was sent, hex encoded
+	PromptHash string `json:"prompt_hash"`
+
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+
+	// RecordCollection and RecordId are the record whose content was sent,
+	// the id empty when it was several or a new one
+	RecordCollection string `json:"record_collection"`
+	RecordId         string `json:"record_id"`
+
+	DurationMs int    `json:"duration_ms"`
+	Error      string `json:"error"`
+
+	Created types.DateTime `json:"created"`
+}
+
+// EntryPage is one page of a user's audit log, Next being the cursor of the
+// following page, empty on the last one
+type EntryPage struct {
+	Items []Entry `json:"items"`
+	Next  string  `json:"next"`
+}
+
+func FromRecord(rec *core.Record) Entry {
+	return Entry{
+		Id:               rec.Id,
+		User:             rec.GetString("user"),
+		Provider:         rec.GetString("provider"),
+		Model:            rec.GetString("model"),
+		Operation:        rec.GetString("operation"),
+		PromptHash:       rec.GetString("prompt_hash"),
+		PromptTokens:     rec.GetInt("prompt_tokens"),
+		CompletionTokens: rec.GetInt("completion_tokens"),
+		RecordCollection: rec.GetString("record_collection"),
+		RecordId:         rec.GetString("record_id"),
+		DurationMs:       rec.GetInt("duration_ms"),
+		Error:            rec.GetString("error"),
+		Created:          rec.GetDateTime("created"),
+	}
+}
@@ -0,0 +1,29 @@
+package aiaudit
+
+import (
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+func RegisterRoutes(g *api.Group, aiauditService Service) {
+	g.GET("/ai/audit", "What AI features sent to the provider for the user, a page at a time, newest first, with ?cursor= and ?limit=", EntryPage{}, func(e *core.RequestEvent) error {
+		page, err := api.PageFromRequest(e)
+		if err != nil {
+			return e.BadRequestError("Invalid audit log request.", err)
+		}
+
+		result, err := aiauditService.List(e.Auth.Id, page)
+		if err != nil {
+			return e.InternalServerError("Failed to load the audit log.", err)
+		}
+		return e.JSON(200, result)
+	})
+
+	g.DELETE("/ai/audit", "Purge the user's AI audit log", func(e *core.RequestEvent) error {
+		if err := aiauditService.Purge(e.Auth.Id); err != nil {
+			return e.InternalServerError("Failed to purge the audit log.", err)
+		}
+		return e.NoContent(204)
+	})
+}
@@ -0,0 +1,120 @@
+package aiaudit
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/ai"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// maxErrorLength keeps provider error bodies from filling the log
+const maxErrorLength = 1000
+
+type Service interface {
+	// Observe logs a call to the provider for the user it was made for.
+	// Calls made for no one aren't logged. Every call counts towards the
+	// instance's daily spend, which purging the log leaves alone.
+	Observe(ctx context.Context, call ai.Call)
+
+	// List returns the user's log a page at a time, newest first
+	List(userId string, page api.Page) (EntryPage, error)
+
+	// Purge deletes the user's whole log
+	Purge(userId string) error
+}
+
+type service struct {
+	app core.App
+}
+
+func NewService(app core.App) Service {
+	return &service{app: app}
+}
+
+func (s *service) Observe(ctx context.Context, call ai.Call) {
+	if err := s.addSpend(call); err != nil {
+		s.app.Logger().Error("Failed to add up AI spend", "error", err)
+	}
+
+	if call.Subject.User == "" {
+		return
+	}
+
+	collection, err := s.app.FindCollectionByNameOrId("ai_audit")
+	if err != nil {
+		s.app.Logger().Error("Failed to log an AI call", "error", err)
+		return
+	}
+	rec := core.NewRecord(collection)
+	rec.Set("user", call.Subject.User)
+	rec.Set("provider", call.Provider)
+	rec.Set("model", call.Model)
+	rec.Set("operation", call.Operation)
+	rec.Set("prompt_hash", call.PromptHash)
+	rec.Set("prompt_tokens", call.PromptTokens)
+	rec.Set("completion_tokens", call.CompletionTokens)
+	rec.Set("record_collection", call.Subject.Collection)
+	rec.Set("record_id", call.Subject.Record)
+	rec.Set("duration_ms", call.Duration.Milliseconds())
+	if call.Err != nil {
+		message := []rune(call.Err.Error())
+		rec.Set("error", string(message[:min(len(message), maxErrorLength)]))
+	}
+	if err := s.app.Save(rec); err != nil {
+		s.app.Logger().Error("Failed to log an AI call", "user", call.Subject.User, "error", err)
+	}
+}
+
+func (s *service) List(userId string, page api.Page) (EntryPage, error) {
+	params := map[string]any{"user": userId}
+	filters := []string{"user = {:user}"}
+	if keyset := page.KeysetFilterDesc("created", params); keyset != "" {
+		filters = append(filters, keyset)
+	}
+
+	records, err := s.app.FindRecordsByFilter("ai_audit", strings.Join(filters, " && "), "-created,-id", page.Limit+1, 0, params)
+	if err != nil {
+		return EntryPage{}, err
+	}
+
+	items := make([]Entry, 0, len(records))
+	for _, rec := range records {
+		items = append(items, FromRecord(rec))
+	}
+	next := page.Next(len(items), func(i int) api.Cursor {
+		return api.Cursor{Value: items[i].Created.String(), Id: items[i].Id}
+	})
+	if len(items) > page.Limit {
+		items = items[:page.Limit]
+	}
+	return EntryPage{Items: items, Next: next}, nil
+}
+
+func (s *service) Purge(userId string) error {
+	_, err := s.app.DB().Delete("ai_audit", dbx.HashExp{"user": userId}).Execute()
+	return err
+}
+
+// addSpend adds the call to the day's spend on its provider and model
+func (s *service) addSpend(call ai.Call) error {
+	_, err := s.app.DB().NewQuery(`
+		INSERT INTO _ai_spend (day, provider, model, requests, prompt_tokens, completion_tokens)
+		VALUES ({:day}, {:provider}, {:model}, 1, {:prompt}, {:completion})
+		ON CONFLICT (day, provider, model) DO UPDATE SET
+			requests = requests + 1,
+			prompt_tokens = prompt_tokens + excluded.prompt_tokens,
+			completion_tokens = completion_tokens + excluded.completion_tokens
+	`).Bind(dbx.Params{
+		"day":        time.Now().UTC().Format(time.DateOnly),
+		"provider":   call.Provider,
+		"model":      call.Model,
+		"prompt":     call.PromptTokens,
+		"completion": call.CompletionTokens,
+	}).Execute()
+	return err
+}
@@ -0,0 +1,85 @@
+package aifeedback
+
+import (
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/prompts"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+const (
+	RatingUp   = "up"
+	RatingDown = "down"
+)
+
+// output is a kind of model output users rate
+type output struct {
+	// key is the prompt the output is asked with
+	key string
+
+	// field holds the template version the output was rendered from
+	field string
+
+	// byModel only matches the outputs of the kind the model wrote
+	byModel string
+}
+
+// outputs are the collections of what can be rated
+var outputs = map[string]output{
+	"corrections":   {key: prompts.KeySpokenCorrection, field: "prompt", byModel: "source = 'model'"},
+	"reports":       {key: prompts.KeyWeeklyFeedback, field: "prompt", byModel: "generated_by = 'model'"},
+	"conversations": {key: prompts.KeyConversationAnalysis, field: "analysis_prompt", byModel: "ended != ''"},
+}
+
+// Feedback is a user's rating of something the model wrote for them
+type Feedback struct {
+	Id               string `json:"id"`
+	User             string `json:"user"`
+	OutputCollection string `json:"output_collection"`
+	OutputId         string `json:"output_id"`
+
+	// Key and Prompt are the prompt template version the output was rendered
+	// from, Prompt is empty for the built in defaults
+	Key    string `json:"key"`
+	Prompt string `json:"prompt"`
+
+	Rating  string    `json:"rating"`
+	Comment string    `json:"comment"`
+	Created time.Time `json:"created"`
+	Updated time.Time `json:"updated"`
+}
+
+// VersionReport is how one version of a prompt template fared
+type VersionReport struct {
+	Key string `json:"key"`
+
+	// Prompt, Language and Version are zero for the built in defaults
+	Prompt   string `json:"prompt"`
+	Language string `json:"language"`
+	Version  int    `json:"version"`
+
+	Up   int `json:"up"`
+	Down int `json:"down"`
+
+	// Approval is the share of ratings that are up, 0 to 1
+	Approval float64 `json:"approval"`
+
+	// Comments are the latest left with ratings
+	Comments []Feedback `json:"comments"`
+}
+
+func FromRecord(rec *core.Record) Feedback {
+	return Feedback{
+		Id:               rec.Id,
+		User:             rec.GetString("user"),
+		OutputCollection: rec.GetString("output_collection"),
+		OutputId:         rec.GetString("output_id"),
+		Key:              rec.GetString("key"),
+		Prompt:           rec.GetString("prompt"),
+		Rating:           rec.GetString("rating"),
+		Comment:          rec.GetString("comment"),
+		Created:          rec.GetDateTime("created").Time(),
+		Updated:          rec.GetDateTime("updated").Time(),
+	}
+}
@@ -0,0 +1,86 @@
+package aifeedback
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// maxCommentLength bounds what users say about a rating
+const maxCommentLength = 1000
+
+type rateRequest struct {
+	// OutputCollection is corrections, reports or conversations, for their
+	// analysis
+	OutputCollection string `json:"output_collection"`
+	OutputId         string `json:"output_id"`
+	Rating           string `json:"rating"`
+	Comment          string `json:"comment"`
+}
+
+// RegisterRoutes adds the routes for users to rate what the model wrote for
+// them
+func RegisterRoutes(g *api.Group, aifeedbackService Service) {
+	g.POST("/ai/feedback", "Rate a correction, report or conversation analysis the model wrote for the user up or down, replacing an earlier rating", rateRequest{}, Feedback{}, func(e *core.RequestEvent) error {
+		var body rateRequest
+		if err := e.BindBody(&body); err != nil {
+			return e.BadRequestError("Invalid request body.", err)
+		}
+		comment := strings.TrimSpace(body.Comment)
+		errs := validation.Errors{}
+		if _, ok := outputs[body.OutputCollection]; !ok {
+			errs["output_collection"] = validation.NewError("validation_invalid_value", "Invalid value.")
+		}
+		if body.OutputId == "" {
+			errs["output_id"] = validation.NewError("validation_required", "Cannot be blank.")
+		}
+		if body.Rating != RatingUp && body.Rating != RatingDown {
+			errs["rating"] = validation.NewError("validation_invalid_value", "Invalid value.")
+		}
+		if utf8.RuneCountInString(comment) > maxCommentLength {
+			errs["comment"] = validation.NewError("validation_length_too_long", "The length must be no more than {{.max}}.").
+				SetParams(map[string]any{"max": maxCommentLength})
+		}
+		if len(errs) > 0 {
+			return e.BadRequestError("Invalid rating.", errs)
+		}
+
+		feedback, err := aifeedbackService.Rate(e.Auth.Id, body.OutputCollection, body.OutputId, body.Rating, comment)
+		if errors.Is(err, sql.ErrNoRows) {
+			return e.NotFoundError("", err)
+		}
+		if err != nil {
+			return e.InternalServerError("Failed to save the rating.", err)
+		}
+		return e.JSON(200, feedback)
+	})
+
+	g.DELETE("/ai/feedback/{id}", "Take back one of the user's ratings", func(e *core.RequestEvent) error {
+		err := aifeedbackService.Remove(e.Auth.Id, e.Request.PathValue("id"))
+		if errors.Is(err, sql.ErrNoRows) {
+			return e.NotFoundError("", err)
+		}
+		if err != nil {
+			return e.InternalServerError("Failed to remove the rating.", err)
+		}
+		return e.NoContent(204)
+	})
+}
+
+// RegisterAdminRoutes adds the report on how prompt template versions are
+// rated, for superusers and support staff
+func RegisterAdminRoutes(g *api.Group, aifeedbackService Service) {
+	g.GET("/ai-feedback", "How users rate the output of each AI prompt template version, for all prompts or ?key=", []VersionReport{}, func(e *core.RequestEvent) error {
+		reports, err := aifeedbackService.Report(e.Request.URL.Query().Get("key"))
+		if err != nil {
+			return e.InternalServerError("Failed to load the rating report.", err)
+		}
+		return e.JSON(200, reports)
+	})
+}
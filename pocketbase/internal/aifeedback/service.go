@@ -0,0 +1,139 @@
+package aifeedback
+
+import (
+	"errors"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// commentsReported bounds the comments reported for a template version
+const commentsReported = 5
+
+var ErrUnknownOutput = errors.New("no such kind of output")
+
+type Service interface {
+	// Rate records the user's rating of one of their outputs the model wrote,
+	// replacing the one they gave before
+	Rate(userId string, collection string, id string, rating string, comment string) (Feedback, error)
+
+	// Remove deletes one of the user's ratings
+	Remove(userId string, id string) error
+
+	// Report sums up the ratings of every prompt template version, or only
+	// those of key, newest versions first
+	Report(key string) ([]VersionReport, error)
+}
+
+type service struct {
+	app core.App
+}
+
+func NewService(app core.App) Service {
+	return &service{app: app}
+}
+
+func (s *service) Rate(userId string, collection string, id string, rating string, comment string) (Feedback, error) {
+	out, ok := outputs[collection]
+	if !ok {
+		return Feedback{}, ErrUnknownOutput
+	}
+	rated, err := s.app.FindFirstRecordByFilter(collection, "id = {:id} && user = {:user} && "+out.byModel, dbx.Params{"id": id, "user": userId})
+	if err != nil {
+		return Feedback{}, err
+	}
+
+	rec, err := s.app.FindFirstRecordByFilter("ai_feedback", "user = {:user} && output_collection = {:collection} && output_id = {:id}", dbx.Params{"user": userId, "collection": collection, "id": id})
+	if err != nil {
+		feedbackCollection, err := s.app.FindCollectionByNameOrId("ai_feedback")
+		if err != nil {
+			return Feedback{}, err
+		}
+		rec = core.NewRecord(feedbackCollection)
+		rec.Set("user", userId)
+		rec.Set("output_collection", collection)
+		rec.Set("output_id", id)
+	}
+	// rated again after the output was regenerated, it's the new version
+	// being rated
+	rec.Set("key", out.key)
+	rec.Set("prompt", rated.GetString(out.field))
+	rec.Set("rating", rating)
+	rec.Set("comment", comment)
+	if err := s.app.Save(rec); err != nil {
+		return Feedback{}, err
+	}
+	return FromRecord(rec), nil
+}
+
+func (s *service) Remove(userId string, id string) error {
+	rec, err := s.app.FindFirstRecordByFilter("ai_feedback", "id = {:id} && user = {:user}", dbx.Params{"id": id, "user": userId})
+	if err != nil {
+		return err
+	}
+	return s.app.Delete(rec)
+}
+
+func (s *service) Report(key string) ([]VersionReport, error) {
+	query := s.app.DB().
+		Select(
+			"f.key AS key",
+			"f.prompt AS prompt",
+			"COALESCE(p.language, '') AS language",
+			"COALESCE(p.version, 0) AS version",
+			"SUM(f.rating = 'up') AS up",
+			"SUM(f.rating = 'down') AS down",
+		).
+		From("ai_feedback f").
+		LeftJoin("ai_prompts p", dbx.NewExp("p.id = f.prompt")).
+		GroupBy("f.key", "f.prompt").
+		OrderBy("f.key ASC", "version DESC")
+	if key != "" {
+		query.AndWhere(dbx.HashExp{"f.key": key})
+	}
+
+	var rows []struct {
+		Key      string `db:"key"`
+		Prompt   string `db:"prompt"`
+		Language string `db:"language"`
+		Version  int    `db:"version"`
+		Up       int    `db:"up"`
+		Down     int    `db:"down"`
+	}
+	if err := query.All(&rows); err != nil {
+		return nil, err
+	}
+
+	reports := make([]VersionReport, 0, len(rows))
+	for _, row := range rows {
+		report := VersionReport{
+			Key:      row.Key,
+			Prompt:   row.Prompt,
+			Language: row.Language,
+			Version:  row.Version,
+			Up:       row.Up,
+			Down:     row.Down,
+			Comments: []Feedback{},
+		}
+		if total := row.Up + row.Down; total > 0 {
+			report.Approval = float64(row.Up) / float64(total)
+		}
+
+		// filters can't match an empty prompt, the built in defaults'
+		var records []*core.Record
+		err := s.app.RecordQuery("ai_feedback").
+			AndWhere(dbx.HashExp{"key": row.Key, "prompt": row.Prompt}).
+			AndWhere(dbx.NewExp("comment != ''")).
+			OrderBy("updated DESC").
+			Limit(commentsReported).
+			All(&records)
+		if err != nil {
+			return nil, err
+		}
+		for _, rec := range records {
+			report.Comments = append(report.Comments, FromRecord(rec))
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
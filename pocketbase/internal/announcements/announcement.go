@@ -0,0 +1,120 @@
+package announcements
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Kinds of announcements, which the apps show differently
+const (
+	KindInfo        = "info"
+	KindMaintenance = "maintenance"
+	KindFeature     = "feature"
+)
+
+var Kinds = []string{KindInfo, KindMaintenance, KindFeature}
+
+// Limits matching the collection's fields
+const (
+	maxTitleLength   = 200
+	maxMessageLength = 5000
+)
+
+// Announcement is something operators tell every user
+type Announcement struct {
+	Id      string `json:"id"`
+	Title   string `json:"title"`
+	Message string `json:"message"`
+	Kind    string `json:"kind"`
+
+	// Starts and Ends bound when it's shown, zero for right away and for good
+	Starts types.DateTime `json:"starts"`
+	Ends   types.DateTime `json:"ends"`
+
+	// Email is whether it's emailed once it starts, Emailed when it was
+	Email   bool           `json:"email"`
+	Emailed types.DateTime `json:"emailed"`
+
+	// Read is whether the user it's listed for read it
+	Read bool `json:"read"`
+
+	Created types.DateTime `json:"created"`
+}
+
+func FromRecord(rec *core.Record) Announcement {
+	return Announcement{
+		Id:      rec.Id,
+		Title:   rec.GetString("title"),
+		Message: rec.GetString("message"),
+		Kind:    rec.GetString("kind"),
+		Starts:  rec.GetDateTime("starts"),
+		Ends:    rec.GetDateTime("ends"),
+		Email:   rec.GetBool("email"),
+		Emailed: rec.GetDateTime("emailed"),
+		Created: rec.GetDateTime("created"),
+	}
+}
+
+// CreateRequest announces something, right away unless Starts is set
+type CreateRequest struct {
+	Title   string         `json:"title"`
+	Message string         `json:"message"`
+	Kind    string         `json:"kind"`
+	Starts  types.DateTime `json:"starts"`
+	Ends    types.DateTime `json:"ends"`
+	Email   bool           `json:"email"`
+}
+
+func (r CreateRequest) Validate(now time.Time) error {
+	errs := validation.Errors{}
+
+	switch title := strings.TrimSpace(r.Title); {
+	case title == "":
+		errs["title"] = validation.NewError("validation_required", "Cannot be blank.")
+	case len([]rune(title)) > maxTitleLength:
+		errs["title"] = validation.NewError("validation_length_too_long", "The length must be no more than {{.max}}.").
+			SetParams(map[string]any{"max": maxTitleLength})
+	}
+	switch message := strings.TrimSpace(r.Message); {
+	case message == "":
+		errs["message"] = validation.NewError("validation_required", "Cannot be blank.")
+	case len([]rune(message)) > maxMessageLength:
+		errs["message"] = validation.NewError("validation_length_too_long", "The length must be no more than {{.max}}.").
+			SetParams(map[string]any{"max": maxMessageLength})
+	}
+	if r.Kind != "" && !slices.Contains(Kinds, r.Kind) {
+		errs["kind"] = validation.NewError("validation_invalid_value", "Invalid value.")
+	}
+	if err := checkWindow(r.Starts, r.Ends, now); err != nil {
+		errs["ends"] = err
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// checkWindow refuses announcements that would never be shown
+func checkWindow(starts types.DateTime, ends types.DateTime, now time.Time) error {
+	switch {
+	case ends.IsZero():
+		return nil
+	case !starts.IsZero() && !ends.Time().After(starts.Time()):
+		return validation.NewError("validation_ended_before_started", "Must be after the start.")
+	case !ends.Time().After(now):
+		return validation.NewError("validation_invalid_value", "Must be in the future.")
+	}
+	return nil
+}
+
+// EmailResult is what an announcement's email job did
+type EmailResult struct {
+	Sent   int `json:"sent"`
+	Failed int `json:"failed"`
+}
@@ -0,0 +1,26 @@
+package announcements
+
+import (
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// BindHooks emails announcements as they start, and checks the ones saved
+// from the dashboard can be shown
+func BindHooks(app core.App, announcementsService Service) {
+	app.Cron().MustAdd("fushigiAnnouncementEmails", "* * * * *", func() {
+		if err := announcementsService.SendEmails(); err != nil {
+			app.Logger().Error("Failed to start emailing announcements", "error", err)
+		}
+	})
+
+	validate := func(e *core.RecordEvent) error {
+		if err := checkWindow(e.Record.GetDateTime("starts"), e.Record.GetDateTime("ends"), time.Now()); err != nil {
+			return err
+		}
+		return e.Next()
+	}
+	app.OnRecordCreate("announcements").BindFunc(validate)
+	app.OnRecordUpdate("announcements").BindFunc(validate)
+}
@@ -0,0 +1,79 @@
+package announcements
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+func RegisterRoutes(g *api.Group, announcementsService Service) {
+	g.GET("/announcements", "The announcements being shown, newest first, marked read if the user read them", []Announcement{}, func(e *core.RequestEvent) error {
+		announcements, err := announcementsService.Running(e.Auth.Id)
+		if err != nil {
+			return e.InternalServerError("Failed to load announcements.", err)
+		}
+		return e.JSON(200, announcements)
+	})
+
+	g.POST("/announcements/read", "Mark every announcement being shown read", nil, nil, func(e *core.RequestEvent) error {
+		if err := announcementsService.MarkAllRead(e.Auth.Id); err != nil {
+			return e.InternalServerError("Failed to mark the announcements read.", err)
+		}
+		return e.NoContent(204)
+	})
+
+	g.POST("/announcements/{id}/read", "Mark an announcement read", nil, nil, func(e *core.RequestEvent) error {
+		err := announcementsService.MarkRead(e.Auth.Id, e.Request.PathValue("id"))
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return e.NotFoundError("", err)
+		case err != nil:
+			return e.InternalServerError("Failed to mark the announcement read.", err)
+		}
+		return e.NoContent(204)
+	})
+}
+
+// RegisterAdminRoutes lets superusers and full admins announce things to
+// every user. Users get new ones in realtime by subscribing to the
+// announcements collection.
+func RegisterAdminRoutes(g *api.Group, announcementsService Service) {
+	g.GET("/announcements", "Every announcement, including those over or yet to start, newest first", []Announcement{}, func(e *core.RequestEvent) error {
+		announcements, err := announcementsService.All()
+		if err != nil {
+			return e.InternalServerError("Failed to load announcements.", err)
+		}
+		return e.JSON(200, announcements)
+	})
+
+	g.POST("/announcements", "Announce something to every user, in the apps and by email if asked, right away unless it starts later", CreateRequest{}, Announcement{}, func(e *core.RequestEvent) error {
+		var req CreateRequest
+		if err := e.BindBody(&req); err != nil {
+			return e.BadRequestError("Invalid request body.", err)
+		}
+		if err := req.Validate(time.Now()); err != nil {
+			return e.BadRequestError("Invalid announcement.", err)
+		}
+
+		announcement, err := announcementsService.Create(req)
+		if err != nil {
+			return e.InternalServerError("Failed to create the announcement.", err)
+		}
+		return e.JSON(200, announcement)
+	})
+
+	g.DELETE("/announcements/{id}", "Take an announcement down", func(e *core.RequestEvent) error {
+		err := announcementsService.Delete(e.Request.PathValue("id"))
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return e.NotFoundError("", err)
+		case err != nil:
+			return e.InternalServerError("Failed to delete the announcement.", err)
+		}
+		return e.NoContent(204)
+	})
+}
@@ -0,0 +1,266 @@
+package announcements
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/emails"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/jobs"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/settings"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// JobKindEmails emails an announcement to every user who gets emails
+const JobKindEmails = "announcement_emails"
+
+// running matches the announcements being shown
+const running = "(starts = '' || starts <= @now) && (ends = '' || ends > @now)"
+
+type Service interface {
+	// Running returns the announcements being shown, newest first, marked
+	// read if the user read them
+	Running(userId string) ([]Announcement, error)
+
+	// MarkRead marks a running announcement read by the user
+	MarkRead(userId string, id string) error
+
+	// MarkAllRead marks every running announcement read by the user
+	MarkAllRead(userId string) error
+
+	// All returns every announcement, including those over or yet to start,
+	// newest first
+	All() ([]Announcement, error)
+
+	Create(req CreateRequest) (Announcement, error)
+
+	Delete(id string) error
+
+	// SendEmails starts emailing the announcements that started and asked to
+	// be emailed, once each
+	SendEmails() error
+}
+
+type service struct {
+	app             core.App
+	jobsService     jobs.Service
+	settingsService settings.Service
+	emailsService   emails.Service
+}
+
+func NewService(app core.App, jobsService jobs.Service, settingsService settings.Service, emailsService emails.Service) Service {
+	return &service{app: app, jobsService: jobsService, settingsService: settingsService, emailsService: emailsService}
+}
+
+func (s *service) Running(userId string) ([]Announcement, error) {
+	records, err := s.app.FindRecordsByFilter("announcements", running, "-created", 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var read []string
+	err = s.app.RecordQuery("announcement_reads").
+		Select("announcement").
+		AndWhere(dbx.HashExp{"user": userId}).
+		Column(&read)
+	if err != nil {
+		return nil, err
+	}
+
+	announcements := make([]Announcement, 0, len(records))
+	for _, rec := range records {
+		a := FromRecord(rec)
+		a.Read = slices.Contains(read, a.Id)
+		announcements = append(announcements, a)
+	}
+	return announcements, nil
+}
+
+func (s *service) MarkRead(userId string, id string) error {
+	rec, err := s.app.FindFirstRecordByFilter("announcements", "id = {:id} && "+running, dbx.Params{"id": id})
+	if err != nil {
+		return err
+	}
+	return s.markRead(userId, []string{rec.Id})
+}
+
+func (s *service) MarkAllRead(userId string) error {
+	records, err := s.app.FindRecordsByFilter("announcements", running, "", 0, 0)
+	if err != nil {
+		return err
+	}
+	ids := make([]string, 0, len(records))
+	for _, rec := range records {
+		ids = append(ids, rec.Id)
+	}
+	return s.markRead(userId, ids)
+}
+
+// markRead records the announcements read by the user, leaving alone those
+// read already
+func (s *service) markRead(userId string, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return s.app.RunInTransaction(func(txApp core.App) error {
+		var read []string
+		err := txApp.RecordQuery("announcement_reads").
+			Select("announcement").
+			AndWhere(dbx.HashExp{"user": userId}).
+			Column(&read)
+		if err != nil {
+			return err
+		}
+
+		collection, err := txApp.FindCollectionByNameOrId("announcement_reads")
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			if slices.Contains(read, id) {
+				continue
+			}
+			rec := core.NewRecord(collection)
+			rec.Set("user", userId)
+			rec.Set("announcement", id)
+			if err := txApp.Save(rec); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *service) All() ([]Announcement, error) {
+	records, err := s.app.FindRecordsByFilter("announcements", "", "-created", 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	announcements := make([]Announcement, 0, len(records))
+	for _, rec := range records {
+		announcements = append(announcements, FromRecord(rec))
+	}
+	return announcements, nil
+}
+
+func (s *service) Create(req CreateRequest) (Announcement, error) {
+	collection, err := s.app.FindCollectionByNameOrId("announcements")
+	if err != nil {
+		return Announcement{}, err
+	}
+
+	kind := req.Kind
+	if kind == "" {
+		kind = KindInfo
+	}
+	rec := core.NewRecord(collection)
+	rec.Set("title", strings.TrimSpace(req.Title))
+	rec.Set("message", strings.TrimSpace(req.Message))
+	rec.Set("kind", kind)
+	rec.Set("starts", req.Starts)
+	rec.Set("ends", req.Ends)
+	rec.Set("email", req.Email)
+	if err := s.app.Save(rec); err != nil {
+		return Announcement{}, err
+	}
+
+	// no need to wait for the next run of the schedule
+	if req.Email && (req.Starts.IsZero() || !req.Starts.Time().After(time.Now())) {
+		if err := s.SendEmails(); err != nil {
+			s.app.Logger().Error("Failed to start emailing announcements", "error", err)
+		}
+		if emailed, err := s.app.FindRecordById("announcements", rec.Id); err == nil {
+			rec = emailed
+		}
+	}
+	return FromRecord(rec), nil
+}
+
+func (s *service) Delete(id string) error {
+	rec, err := s.app.FindRecordById("announcements", id)
+	if err != nil {
+		return err
+	}
+	return s.app.Delete(rec)
+}
+
+func (s *service) SendEmails() error {
+	records, err := s.app.FindRecordsByFilter("announcements", "email = true && emailed = '' && "+running, "created", 0, 0)
+	if err != nil {
+		return err
+	}
+
+	for _, rec := range records {
+		// marked first so the next run doesn't email it again
+		rec.Set("emailed", types.NowDateTime())
+		if err := s.app.Save(rec); err != nil {
+			return err
+		}
+		if _, err := s.enqueue(FromRecord(rec)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *service) enqueue(announcement Announcement) (jobs.Job, error) {
+	return s.jobsService.Enqueue("", JobKindEmails, func(ctx context.Context, jobId string, progress jobs.Progress) (any, error) {
+		var ids []string
+		err := s.app.RecordQuery("users").
+			Select("id").
+			AndWhere(dbx.HashExp{"verified": true}).
+			AndWhere(dbx.Not(dbx.HashExp{"email": ""})).
+			OrderBy("created").
+			Column(&ids)
+		if err != nil {
+			return nil, err
+		}
+
+		meta := s.app.Settings().Meta
+		var result EmailResult
+		for i, id := range ids {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			progress.Report(float64(i)*100/float64(len(ids)), fmt.Sprintf("%d of %d", i, len(ids)))
+
+			user, err := s.app.FindRecordById("users", id)
+			if err != nil {
+				continue
+			}
+			userSettings, err := s.settingsService.ForUser(id)
+			if err != nil || !userSettings.NotifyEmail {
+				continue
+			}
+			err = s.emailsService.Send(
+				mail.Address{Name: user.GetString("name"), Address: user.Email()},
+				emails.KeyAnnouncement,
+				userSettings.Locale,
+				emails.Data{
+					AppName:   meta.AppName,
+					AppURL:    meta.AppURL,
+					ActionURL: meta.AppURL,
+					Name:      user.GetString("name"),
+					Email:     user.Email(),
+					Title:     announcement.Title,
+					Message:   announcement.Message,
+				},
+			)
+			if err != nil {
+				s.app.Logger().Warn("Failed to email an announcement", "announcement", announcement.Id, "user", id, "error", err)
+				result.Failed++
+				continue
+			}
+			result.Sent++
+		}
+		progress.Report(100, fmt.Sprintf("%d of %d", len(ids), len(ids)))
+		return result, nil
+	})
+}
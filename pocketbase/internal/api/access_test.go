@@ -0,0 +1,55 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/router"
+)
+
+func TestRequireRole(t *testing.T) {
+	user := func(role string) *core.Record {
+		record := core.NewRecord(core.NewAuthCollection("users"))
+		record.Set("admin_role", role)
+		return record
+	}
+	superuser := core.NewRecord(core.NewAuthCollection(core.CollectionNameSuperusers))
+
+	tests := []struct {
+		name   string
+		access Access
+		auth   *core.Record
+		want   int
+	}{
+		{"superuser", Admin, superuser, http.StatusOK},
+		{"admin", Admin, user(RoleAdmin), http.StatusOK},
+		{"moderator to admin routes", Admin, user(RoleModerator), http.StatusForbidden},
+		{"admin to moderator routes", Moderator, user(RoleAdmin), http.StatusOK},
+		{"moderator", Moderator, user(RoleModerator), http.StatusOK},
+		{"support to moderator routes", Moderator, user(RoleSupport), http.StatusForbidden},
+		{"support", Support, user(RoleSupport), http.StatusOK},
+		{"moderator to support routes", Support, user(RoleModerator), http.StatusForbidden},
+		{"user without a role", Support, user(""), http.StatusForbidden},
+		{"logged out", Support, nil, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newRequestEvent("/api/fushigi/v1/admin/staff")
+			e.Auth = tt.auth
+
+			got := http.StatusOK
+			if err := requireRole(roles[tt.access])(e); err != nil {
+				var apiErr *router.ApiError
+				if !errors.As(err, &apiErr) {
+					t.Fatal(err)
+				}
+				got = apiErr.Status
+			}
+			if got != tt.want {
+				t.Errorf("requireRole() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// minCompressSize is the smallest body worth compressing, below it the
+// encoding overhead eats the savings
+const minCompressSize = 1024
+
+// incompressible are content types that are compressed already
+var incompressible = []string{"image/", "audio/", "video/", "application/zip", "application/pdf", "application/epub+zip", "application/gzip"}
+
+// bufferedWriter holds a custom route's response, which is always written
+// in one go, so it can be compressed as a whole
+type bufferedWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferedWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(b)
+}
+
+func (w *bufferedWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Hijack hands the connection over, e.g. to a websocket, whose frames never
+// go through the buffer
+func (w *bufferedWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+// withCompression compresses responses with brotli or gzip, whichever the
+// client prefers. Error responses are written by PocketBase after the
+// handler returns and go out as is.
+func withCompression(handler func(e *core.RequestEvent) error) func(e *core.RequestEvent) error {
+	return func(e *core.RequestEvent) error {
+		e.Response.Header().Add("Vary", "Accept-Encoding")
+
+		encoding := negotiateEncoding(e.Request.Header.Get("Accept-Encoding"))
+		if encoding == "" {
+			return handler(e)
+		}
+
+		rw := e.Response
+		bw := &bufferedWriter{ResponseWriter: rw}
+		e.Response = bw
+		err := handler(e)
+		e.Response = rw
+
+		if bw.status == 0 {
+			return err
+		}
+		if bw.status == http.StatusNoContent || bw.status == http.StatusNotModified {
+			rw.WriteHeader(bw.status)
+			return err
+		}
+
+		body := bw.body.Bytes()
+		header := rw.Header()
+		contentType := header.Get("Content-Type")
+		skip := len(body) < minCompressSize ||
+			header.Get("Content-Encoding") != "" ||
+			slices.ContainsFunc(incompressible, func(prefix string) bool { return strings.HasPrefix(contentType, prefix) })
+		if !skip {
+			if compressed, compressErr := compress(encoding, body); compressErr == nil {
+				body = compressed
+				header.Set("Content-Encoding", encoding)
+			}
+		}
+
+		header.Set("Content-Length", strconv.Itoa(len(body)))
+		rw.WriteHeader(bw.status)
+		if _, writeErr := rw.Write(body); writeErr != nil && err == nil {
+			err = writeErr
+		}
+		return err
+	}
+}
+
+// negotiateEncoding picks br over gzip when the client takes both, honoring
+// q=0 refusals
+func negotiateEncoding(accept string) string {
+	accepted := map[string]bool{}
+	for _, part := range strings.Split(accept, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			q, _ = strconv.ParseFloat(value, 64)
+		}
+		accepted[strings.ToLower(strings.TrimSpace(name))] = q > 0
+	}
+
+	for _, encoding := range []string{"br", "gzip"} {
+		if accepted[encoding] {
+			return encoding
+		}
+	}
+	return ""
+}
+
+func compress(encoding string, body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	if encoding == "br" {
+		// the default level trades size for speed, fine for API responses
+		w = brotli.NewWriterLevel(&buf, brotli.DefaultCompression)
+	} else {
+		w = gzip.NewWriter(&buf)
+	}
+
+	if _, err := w.Write(body); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
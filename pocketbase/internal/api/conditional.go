@@ -0,0 +1,196 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/i18n"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/picker"
+)
+
+// SharedScope covers content that isn't any one user's, like the grammar
+// library. Scopes name what a response is built from, see Conditional.
+const SharedScope = "shared"
+
+// UserScope covers the records of one user
+func UserScope(userId string) string {
+	return "user:" + userId
+}
+
+// maxRemembered bounds the responses Conditional keeps track of, it starts
+// over when reached
+const maxRemembered = 10000
+
+type remembered struct {
+	etag     string
+	modified time.Time
+	built    time.Time
+}
+
+// Conditional adds ETag and Last-Modified validation to expensive JSON
+// responses, so clients revalidating an unchanged response get a bodyless
+// 304. It remembers the last response to each request, which is trusted
+// for its max age unless a record in one of its scopes changes, sparing the
+// work of building it again.
+type Conditional struct {
+	mu        sync.Mutex
+	changed   map[string]time.Time
+	responses map[string]remembered
+}
+
+func NewConditional() *Conditional {
+	return &Conditional{changed: map[string]time.Time{}, responses: map[string]remembered{}}
+}
+
+// BindHooks marks the scope of every record of the given collections as
+// changed when one is saved or deleted: the owner's if it has a user,
+// SharedScope otherwise
+func (c *Conditional) BindHooks(app core.App, collections ...string) {
+	touch := func(e *core.RecordEvent) error {
+		if userId := e.Record.GetString("user"); userId != "" {
+			c.Touch(UserScope(userId))
+		} else {
+			c.Touch(SharedScope)
+		}
+		return e.Next()
+	}
+
+	for _, name := range collections {
+		app.OnRecordAfterCreateSuccess(name).BindFunc(touch)
+		app.OnRecordAfterUpdateSuccess(name).BindFunc(touch)
+		app.OnRecordAfterDeleteSuccess(name).BindFunc(touch)
+	}
+}
+
+// Touch marks scopes as changed, so responses built from them are built
+// again
+func (c *Conditional) Touch(scopes ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for _, scope := range scopes {
+		c.changed[scope] = now
+	}
+}
+
+// NotModified sets the caching headers of a response built from scopes and
+// reports whether the client already has it, in which case the handler
+// answers with a 304 right away
+func (c *Conditional) NotModified(e *core.RequestEvent, maxAge time.Duration, scopes ...string) bool {
+	setCacheHeaders(e, maxAge)
+
+	c.mu.Lock()
+	prev, ok := c.responses[responseKey(e)]
+	fresh := ok && time.Since(prev.built) < maxAge && !c.changedSince(prev.built, scopes)
+	c.mu.Unlock()
+
+	if !fresh || !clientHas(e, prev) {
+		return false
+	}
+	setValidators(e, prev)
+	return true
+}
+
+// JSON writes data with its validators, or a 304 when it is what the client
+// already has. Last-Modified is when the response last came out different.
+// Like e.JSON it honors ?fields=.
+func (c *Conditional) JSON(e *core.RequestEvent, maxAge time.Duration, data any) error {
+	if fields := e.Request.URL.Query().Get("fields"); fields != "" {
+		picked, err := picker.Pick(data, fields)
+		if err != nil {
+			return err
+		}
+		data = picked
+	}
+
+	body, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(body)
+
+	now := time.Now()
+	current := remembered{etag: `"` + hex.EncodeToString(sum[:16]) + `"`, modified: now, built: now}
+
+	key := responseKey(e)
+	c.mu.Lock()
+	if prev, ok := c.responses[key]; ok && prev.etag == current.etag {
+		current.modified = prev.modified
+	}
+	if len(c.responses) >= maxRemembered {
+		c.responses = map[string]remembered{}
+	}
+	c.responses[key] = current
+	c.mu.Unlock()
+
+	setCacheHeaders(e, maxAge)
+	setValidators(e, current)
+	if clientHas(e, current) {
+		return e.NoContent(http.StatusNotModified)
+	}
+	return e.Blob(http.StatusOK, "application/json", body)
+}
+
+// changedSince must be called with the lock held
+func (c *Conditional) changedSince(t time.Time, scopes []string) bool {
+	for _, scope := range scopes {
+		if c.changed[scope].After(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// responseKey tells apart the responses of different users, locales and
+// query parameters
+func responseKey(e *core.RequestEvent) string {
+	userId := ""
+	if e.Auth != nil {
+		userId = e.Auth.Id
+	}
+	return userId + " " + i18n.FromRequest(e) + " " + e.Request.URL.RequestURI()
+}
+
+func setCacheHeaders(e *core.RequestEvent, maxAge time.Duration) {
+	header := e.Response.Header()
+	header.Set("Cache-Control", "private, max-age="+strconv.Itoa(int(maxAge.Seconds())))
+	// the profile picked changes whose response it is as much as the token
+	for _, name := range []string{"Authorization", ProfileHeader} {
+		if !slices.Contains(header.Values("Vary"), name) {
+			header.Add("Vary", name)
+		}
+	}
+}
+
+func setValidators(e *core.RequestEvent, r remembered) {
+	header := e.Response.Header()
+	header.Set("ETag", r.etag)
+	header.Set("Last-Modified", r.modified.UTC().Format(http.TimeFormat))
+}
+
+// clientHas follows RFC 9110: If-None-Match wins over If-Modified-Since when
+// both are sent
+func clientHas(e *core.RequestEvent, r remembered) bool {
+	if match := e.Request.Header.Get("If-None-Match"); match != "" {
+		for _, tag := range strings.Split(match, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == r.etag || tag == "*" {
+				return true
+			}
+		}
+		return false
+	}
+
+	since, err := http.ParseTime(e.Request.Header.Get("If-Modified-Since"))
+	return err == nil && !r.modified.Truncate(time.Second).After(since)
+}
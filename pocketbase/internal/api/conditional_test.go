@@ -0,0 +1,133 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+func newRequestEvent(target string) *core.RequestEvent {
+	e := &core.RequestEvent{}
+	e.Request = httptest.NewRequest("GET", target, nil)
+	e.Response = httptest.NewRecorder()
+	return e
+}
+
+type stats struct {
+	Due int `json:"due"`
+}
+
+// serve answers a request for /api/stats the way routes use Conditional
+func serve(c *Conditional, userId string, header http.Header, data any) *httptest.ResponseRecorder {
+	e := newRequestEvent("/api/stats")
+	e.Request.Header = header
+	if userId != "" {
+		e.Auth = core.NewRecord(core.NewAuthCollection("users"))
+		e.Auth.Id = userId
+	}
+	rec := e.Response.(*httptest.ResponseRecorder)
+
+	if c.NotModified(e, time.Minute, UserScope(userId)) {
+		e.NoContent(http.StatusNotModified)
+		return rec
+	}
+	c.JSON(e, time.Minute, data)
+	return rec
+}
+
+func TestConditionalETag(t *testing.T) {
+	c := NewConditional()
+	first := serve(c, "u1", http.Header{}, stats{Due: 3})
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" {
+		t.Fatalf("first response = %d with ETag %q, want 200 with one", first.Code, etag)
+	}
+
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		want        int
+	}{
+		{"same tag", etag, http.StatusNotModified},
+		{"weak tag", "W/" + etag, http.StatusNotModified},
+		{"one of several tags", `"stale", ` + etag, http.StatusNotModified},
+		{"any tag", "*", http.StatusNotModified},
+		{"other tag", `"stale"`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := serve(c, "u1", http.Header{"If-None-Match": {tt.ifNoneMatch}}, stats{Due: 3})
+			if got.Code != tt.want {
+				t.Errorf("If-None-Match %s = %d, want %d", tt.ifNoneMatch, got.Code, tt.want)
+			}
+			if got.Code == http.StatusNotModified && got.Body.Len() > 0 {
+				t.Errorf("304 has a body: %q", got.Body.String())
+			}
+			if got.Header().Get("ETag") != etag {
+				t.Errorf("ETag = %q, want %q", got.Header().Get("ETag"), etag)
+			}
+		})
+	}
+}
+
+func TestConditionalChanged(t *testing.T) {
+	c := NewConditional()
+	etag := serve(c, "u1", http.Header{}, stats{Due: 3}).Header().Get("ETag")
+	header := http.Header{"If-None-Match": {etag}}
+
+	// another user's changes leave the response be
+	c.Touch(UserScope("u2"))
+	if got := serve(c, "u1", header, stats{Due: 3}); got.Code != http.StatusNotModified {
+		t.Errorf("after another user's change = %d, want 304", got.Code)
+	}
+
+	// the user's own are built again, still a 304 when the result is the same
+	c.Touch(UserScope("u1"))
+	if got := serve(c, "u1", header, stats{Due: 3}); got.Code != http.StatusNotModified {
+		t.Errorf("after an unchanged rebuild = %d, want 304", got.Code)
+	}
+
+	c.Touch(UserScope("u1"))
+	got := serve(c, "u1", header, stats{Due: 4})
+	if got.Code != http.StatusOK || got.Header().Get("ETag") == etag {
+		t.Errorf("after a change = %d with ETag %q, want 200 with a new one", got.Code, got.Header().Get("ETag"))
+	}
+}
+
+func TestConditionalPerUser(t *testing.T) {
+	c := NewConditional()
+	etag := serve(c, "u1", http.Header{}, stats{Due: 3}).Header().Get("ETag")
+
+	// a tag from another user's response doesn't count until the response
+	// is built for this one
+	got := serve(c, "u2", http.Header{"If-None-Match": {etag}}, stats{Due: 5})
+	if got.Code != http.StatusOK {
+		t.Errorf("another user's ETag = %d, want 200", got.Code)
+	}
+}
+
+func TestConditionalIfModifiedSince(t *testing.T) {
+	c := NewConditional()
+	first := serve(c, "u1", http.Header{}, stats{Due: 3})
+	modified := first.Header().Get("Last-Modified")
+
+	tests := []struct {
+		name   string
+		header http.Header
+		want   int
+	}{
+		{"not modified since", http.Header{"If-Modified-Since": {modified}}, http.StatusNotModified},
+		{"modified since", http.Header{"If-Modified-Since": {time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)}}, http.StatusOK},
+		{"If-None-Match wins", http.Header{"If-Modified-Since": {modified}, "If-None-Match": {`"stale"`}}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := serve(c, "u1", tt.header, stats{Due: 3}); got.Code != tt.want {
+				t.Errorf("response = %d, want %d", got.Code, tt.want)
+			}
+		})
+	}
+}
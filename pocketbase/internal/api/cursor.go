@@ -0,0 +1,108 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 200
+)
+
+// Cursor is where a page of a keyset paginated list ends: the sort value and
+// id of its last item. Unlike offsets it stays put when items before it are
+// added or removed while a client scrolls.
+type Cursor struct {
+	Value string `json:"v"`
+	Id    string `json:"id"`
+}
+
+// Page is which page of a list a client asks for, with ?cursor= and ?limit=
+type Page struct {
+	// After is the cursor of the previous page, nil for the first one
+	After *Cursor
+	Limit int
+}
+
+// PageFromRequest reads the pagination query parameters
+func PageFromRequest(e *core.RequestEvent) (Page, error) {
+	query := e.Request.URL.Query()
+	page := Page{Limit: DefaultPageSize}
+	errs := validation.Errors{}
+
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > MaxPageSize {
+			errs["limit"] = validation.NewError("validation_invalid_page_size", "Must be between 1 and {{.max}}.").
+				SetParams(map[string]any{"max": MaxPageSize})
+		}
+		page.Limit = limit
+	}
+
+	if raw := query.Get("cursor"); raw != "" {
+		cursor, err := DecodeCursor(raw)
+		if err != nil {
+			errs["cursor"] = validation.NewError("validation_invalid_cursor", "Invalid cursor.")
+		}
+		page.After = &cursor
+	}
+
+	if len(errs) > 0 {
+		return Page{}, errs
+	}
+	return page, nil
+}
+
+// KeysetFilter restricts a PocketBase filter sorted by field then id to what
+// comes after the page's cursor, binding its values into params
+func (p Page) KeysetFilter(field string, params map[string]any) string {
+	if p.After == nil {
+		return ""
+	}
+	params["cursorValue"] = p.After.Value
+	params["cursorId"] = p.After.Id
+	return "(" + field + " > {:cursorValue} || (" + field + " = {:cursorValue} && id > {:cursorId}))"
+}
+
+// KeysetFilterDesc is KeysetFilter for lists sorted by field then id in
+// descending order
+func (p Page) KeysetFilterDesc(field string, params map[string]any) string {
+	if p.After == nil {
+		return ""
+	}
+	params["cursorValue"] = p.After.Value
+	params["cursorId"] = p.After.Id
+	return "(" + field + " < {:cursorValue} || (" + field + " = {:cursorValue} && id < {:cursorId}))"
+}
+
+// Next returns the cursor of the following page given the items fetched,
+// which should be one more than the limit to tell whether there is one
+func (p Page) Next(fetched int, last func(i int) Cursor) string {
+	if fetched <= p.Limit {
+		return ""
+	}
+	return EncodeCursor(last(p.Limit - 1))
+}
+
+func EncodeCursor(c Cursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeCursor(raw string) (Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return Cursor{}, err
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return Cursor{}, err
+	}
+	return c, nil
+}
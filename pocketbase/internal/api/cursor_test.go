@@ -0,0 +1,136 @@
+package api
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []Cursor{
+		{Value: "2026-05-01 09:00:00.000Z", Id: "abc123def456ghi"},
+		{Value: "", Id: "abc123def456ghi"},
+		{Value: "日記 & \"quotes\"", Id: "x"},
+		{},
+	}
+	for _, want := range tests {
+		raw := EncodeCursor(want)
+		got, err := DecodeCursor(raw)
+		if err != nil {
+			t.Errorf("DecodeCursor(EncodeCursor(%+v)) failed: %v", want, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("DecodeCursor(EncodeCursor(%+v)) = %+v", want, got)
+		}
+	}
+}
+
+func TestDecodeCursorTampered(t *testing.T) {
+	valid := EncodeCursor(Cursor{Value: "2026-05-01 09:00:00.000Z", Id: "abc123def456ghi"})
+
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{"not base64", "not a cursor!"},
+		{"truncated", valid[:len(valid)/2]},
+		{"not json", base64.RawURLEncoding.EncodeToString([]byte("v=a&id=b"))},
+		{"wrong types", base64.RawURLEncoding.EncodeToString([]byte(`{"v":1,"id":"b"}`))},
+		{"not an object", base64.RawURLEncoding.EncodeToString([]byte(`["a","b"]`))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if c, err := DecodeCursor(tt.raw); err == nil {
+				t.Errorf("DecodeCursor(%q) = %+v, want an error", tt.raw, c)
+			}
+		})
+	}
+}
+
+func TestPageFromRequest(t *testing.T) {
+	cursor := Cursor{Value: "2026-05-01 09:00:00.000Z", Id: "abc123def456ghi"}
+
+	tests := []struct {
+		name    string
+		query   string
+		want    Page
+		invalid string
+	}{
+		{"defaults", "", Page{Limit: DefaultPageSize}, ""},
+		{"limit", "?limit=10", Page{Limit: 10}, ""},
+		{"cursor", "?limit=10&cursor=" + EncodeCursor(cursor), Page{After: &cursor, Limit: 10}, ""},
+		{"tampered cursor", "?cursor=" + EncodeCursor(cursor)[3:], Page{}, "cursor"},
+		{"limit too small", "?limit=0", Page{}, "limit"},
+		{"limit too large", "?limit=201", Page{}, "limit"},
+		{"limit not a number", "?limit=ten", Page{}, "limit"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := PageFromRequest(newRequestEvent("/api/feed" + tt.query))
+			if tt.invalid != "" {
+				var errs validation.Errors
+				if !errors.As(err, &errs) || errs[tt.invalid] == nil {
+					t.Fatalf("PageFromRequest() error = %v, want one on %s", err, tt.invalid)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("PageFromRequest() failed: %v", err)
+			}
+			if got.Limit != tt.want.Limit || (got.After == nil) != (tt.want.After == nil) || (got.After != nil && *got.After != *tt.want.After) {
+				t.Errorf("PageFromRequest() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPageNext(t *testing.T) {
+	page := Page{Limit: 2}
+	last := func(i int) Cursor {
+		return Cursor{Value: "v", Id: string(rune('a' + i))}
+	}
+
+	if next := page.Next(2, last); next != "" {
+		t.Errorf("Next() on the last page = %q, want none", next)
+	}
+	if next := page.Next(0, last); next != "" {
+		t.Errorf("Next() on an empty page = %q, want none", next)
+	}
+
+	next := page.Next(3, last)
+	got, err := DecodeCursor(next)
+	if err != nil {
+		t.Fatalf("Next() = %q, which doesn't decode: %v", next, err)
+	}
+	if want := (Cursor{Value: "v", Id: "b"}); got != want {
+		t.Errorf("Next() points after %+v, want the last item of the page %+v", got, want)
+	}
+}
+
+func TestPageKeysetFilter(t *testing.T) {
+	params := map[string]any{}
+	if filter := (Page{Limit: 10}).KeysetFilter("created", params); filter != "" || len(params) > 0 {
+		t.Errorf("KeysetFilter() on the first page = %q with %v, want none", filter, params)
+	}
+
+	page := Page{After: &Cursor{Value: "2026-05-01", Id: "abc"}, Limit: 10}
+	tests := []struct {
+		filter func(string, map[string]any) string
+		want   string
+	}{
+		{page.KeysetFilter, "(created > {:cursorValue} || (created = {:cursorValue} && id > {:cursorId}))"},
+		{page.KeysetFilterDesc, "(created < {:cursorValue} || (created = {:cursorValue} && id < {:cursorId}))"},
+	}
+	for _, tt := range tests {
+		params := map[string]any{}
+		if got := tt.filter("created", params); got != tt.want {
+			t.Errorf("filter = %q, want %q", got, tt.want)
+		}
+		if params["cursorValue"] != "2026-05-01" || params["cursorId"] != "abc" {
+			t.Errorf("filter params = %v, want the cursor's", params)
+		}
+	}
+}
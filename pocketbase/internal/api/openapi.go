@@ -0,0 +1,240 @@
+package api
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+var pathParamRegex = regexp.MustCompile(`\{([^}.]+)\}`)
+
+var dateTimeType = reflect.TypeOf(types.DateTime{})
+
+// spec collects route definitions and renders them as an OpenAPI 3
+// document. Named struct types become shared component schemas.
+type spec struct {
+	mu      sync.Mutex
+	title   string
+	version string
+	paths   map[string]map[string]any
+	schemas map[string]any
+}
+
+func newSpec(title string, version string) *spec {
+	return &spec{
+		title:   title,
+		version: version,
+		paths:   map[string]map[string]any{},
+		schemas: map[string]any{},
+	}
+}
+
+func (s *spec) add(path string, access Access, deprecated bool, route Route) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// ids and tags ignore the version prefix so generated clients stay stable
+	relative := strings.TrimPrefix(path, BasePath+"/"+s.version)
+
+	operation := map[string]any{
+		"summary":     route.Summary,
+		"operationId": operationId(route.Method, relative),
+		"tags":        []string{operationTag(relative)},
+	}
+	if deprecated {
+		operation["deprecated"] = true
+	}
+
+	var params []map[string]any
+	for _, match := range pathParamRegex.FindAllStringSubmatch(path, -1) {
+		params = append(params, map[string]any{
+			"name":     match[1],
+			"in":       "path",
+			"required": true,
+			"schema":   map[string]any{"type": "string"},
+		})
+	}
+	// e.JSON trims responses down to the requested fields, e.g. for list
+	// cells that only show a few
+	if route.Method == "GET" && route.Response != nil {
+		params = append(params, map[string]any{
+			"name":        "fields",
+			"in":          "query",
+			"description": "Comma separated fields to return, e.g. id,usage,meaning. Nested fields are dotted.",
+			"schema":      map[string]any{"type": "string"},
+		})
+	}
+	if len(params) > 0 {
+		operation["parameters"] = params
+	}
+
+	if route.Request != nil {
+		operation["requestBody"] = map[string]any{
+			"required": true,
+			"content": map[string]any{
+				"application/json": map[string]any{"schema": s.schemaFor(reflect.TypeOf(route.Request))},
+			},
+		}
+	}
+
+	responses := map[string]any{
+		"400": map[string]any{"$ref": "#/components/responses/Error"},
+	}
+	if route.Response != nil {
+		responses["200"] = map[string]any{
+			"description": "OK",
+			"content": map[string]any{
+				"application/json": map[string]any{"schema": s.schemaFor(reflect.TypeOf(route.Response))},
+			},
+		}
+	} else {
+		responses["204"] = map[string]any{"description": "No Content"}
+	}
+	if access != Public {
+		operation["security"] = []map[string]any{{"bearerAuth": []string{}}}
+		responses["401"] = map[string]any{"$ref": "#/components/responses/Error"}
+	}
+	if roles[access] != nil {
+		responses["403"] = map[string]any{"$ref": "#/components/responses/Error"}
+	}
+	operation["responses"] = responses
+
+	if s.paths[path] == nil {
+		s.paths[path] = map[string]any{}
+	}
+	s.paths[path][strings.ToLower(route.Method)] = operation
+}
+
+// document renders the full OpenAPI document
+func (s *spec) document() map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   s.title,
+			"version": s.version,
+		},
+		"paths": s.paths,
+		"components": map[string]any{
+			"schemas": s.schemas,
+			"securitySchemes": map[string]any{
+				// PocketBase expects the raw token in the Authorization header
+				"bearerAuth": map[string]any{"type": "apiKey", "in": "header", "name": "Authorization"},
+			},
+			"responses": map[string]any{
+				"Error": map[string]any{
+					"description": "PocketBase API error",
+					"content": map[string]any{
+						"application/json": map[string]any{
+							"schema": map[string]any{
+								"type": "object",
+								"properties": map[string]any{
+									"status":  map[string]any{"type": "integer"},
+									"message": map[string]any{"type": "string"},
+									"data":    map[string]any{"type": "object"},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (s *spec) schemaFor(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == dateTimeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t == reflect.TypeOf(core.Record{}):
+		return map[string]any{"type": "object", "additionalProperties": true}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": s.schemaFor(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": s.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.structSchema(t)
+		}
+		// exported-looking names read better in generated client code
+		name := strings.ToUpper(t.Name()[:1]) + t.Name()[1:]
+		if _, ok := s.schemas[name]; !ok {
+			s.schemas[name] = map[string]any{} // placeholder guards against recursive types
+			s.schemas[name] = s.structSchema(t)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + name}
+	default:
+		return map[string]any{}
+	}
+}
+
+func (s *spec) structSchema(t reflect.Type) map[string]any {
+	properties := map[string]any{}
+	var required []string
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		properties[name] = s.schemaFor(field.Type)
+		if !strings.Contains(opts, "omitempty") {
+			required = append(required, name)
+		}
+	}
+
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// operationId turns "POST /assessment/next" into "postAssessmentNext"
+func operationId(method string, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, part := range strings.FieldsFunc(path, func(r rune) bool {
+		return r == '/' || r == '{' || r == '}' || r == '-' || r == '_'
+	}) {
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+// operationTag groups operations by the first segment of their path
+func operationTag(path string) string {
+	first, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	return first
+}
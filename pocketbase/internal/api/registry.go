@@ -0,0 +1,271 @@
+package api
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/i18n"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/router"
+)
+
+const (
+	BasePath = "/api/fushigi"
+
+	// Clients calling the unversioned paths pick a version with this header,
+	// and every response echoes the version that handled it
+	VersionHeader = "Fushigi-Version"
+
+	// Clients pick which of the account's study profiles custom routes act
+	// on with this header
+	ProfileHeader = "Fushigi-Profile"
+)
+
+// Access is who may call the routes of a group
+type Access int
+
+const (
+	Public Access = iota
+	Authenticated
+	Superuser
+
+	// Admin, Moderator and Support let in users given a staff role as well
+	// as superusers, full admins being let in everywhere staff is
+	Admin
+	Moderator
+	Support
+)
+
+// Staff roles superusers give users so they can run part of the instance
+// without superuser credentials
+const (
+	RoleAdmin     = "admin"
+	RoleModerator = "moderator"
+	RoleSupport   = "support"
+)
+
+var Roles = []string{RoleAdmin, RoleModerator, RoleSupport}
+
+// roles are the staff roles each access level lets in
+var roles = map[Access][]string{
+	Admin:     {RoleAdmin},
+	Moderator: {RoleAdmin, RoleModerator},
+	Support:   {RoleAdmin, RoleSupport},
+}
+
+// bind attaches the middleware enforcing the access level to a route
+func (a Access) bind(rt *router.Route[*core.RequestEvent]) {
+	switch a {
+	case Authenticated:
+		rt.Bind(apis.RequireAuth())
+	case Superuser:
+		rt.Bind(apis.RequireSuperuserAuth())
+	case Admin, Moderator, Support:
+		rt.BindFunc(requireRole(roles[a]))
+	}
+}
+
+// requireRole lets in superusers and users with one of the roles
+func requireRole(roles []string) func(e *core.RequestEvent) error {
+	return func(e *core.RequestEvent) error {
+		if e.HasSuperuserAuth() {
+			return e.Next()
+		}
+		if e.Auth == nil || e.Auth.Collection().Name != "users" {
+			return e.UnauthorizedError("The request requires valid staff authorization token.", nil)
+		}
+		if !slices.Contains(roles, e.Auth.GetString("admin_role")) {
+			return e.ForbiddenError("Your role doesn't allow you to do that.", nil)
+		}
+		return e.Next()
+	}
+}
+
+// Version is one released version of the custom API. Once a successor
+// ships, set Deprecated so clients get warned, and Sunset once old builds
+// have had time to upgrade.
+type Version struct {
+	Name       string
+	Deprecated time.Time
+	Sunset     time.Time
+}
+
+// Versions lists every version ever released, oldest first
+var Versions = []Version{
+	{Name: "v1"},
+}
+
+// Route describes a custom route together with the shapes it accepts and
+// returns, so the same definition drives both the router and the OpenAPI doc
+type Route struct {
+	Method  string
+	Path    string
+	Summary string
+
+	// Zero values of the request body and response types, nil when there is none
+	Request  any
+	Response any
+
+	// The route exists from Since up to but excluding Until. Leave Since empty
+	// for the first version and Until empty while the route is current. A
+	// breaking change is a new route with Since set to the version the old
+	// one gets as Until.
+	Since string
+	Until string
+
+	Handler func(e *core.RequestEvent) error
+}
+
+// Registry mounts custom routes under every API version they belong to and
+// keeps the unversioned paths working for clients that predate versioning
+type Registry struct {
+	router   *router.RouterGroup[*core.RequestEvent]
+	versions []Version
+	specs    map[string]*spec
+
+	// "GET /stats" -> version name -> handler, for the unversioned aliases
+	aliases map[string]map[string]func(e *core.RequestEvent) error
+}
+
+func NewRegistry(r *router.RouterGroup[*core.RequestEvent], title string, versions []Version) *Registry {
+	specs := make(map[string]*spec, len(versions))
+	for _, v := range versions {
+		specs[v.Name] = newSpec(title, v.Name)
+	}
+
+	return &Registry{
+		router:   r,
+		versions: versions,
+		specs:    specs,
+		aliases:  map[string]map[string]func(e *core.RequestEvent) error{},
+	}
+}
+
+// Group returns a group for routes living under the given section of every
+// version, e.g. "/public" for /api/fushigi/v1/public/...
+func (r *Registry) Group(section string, access Access) *Group {
+	return &Group{registry: r, section: section, access: access}
+}
+
+// ServeSpecs exposes the OpenAPI document of each version. They are public so
+// clients can be generated from a running instance.
+func (r *Registry) ServeSpecs() {
+	for _, v := range r.versions {
+		doc := r.specs[v.Name]
+		r.router.GET(BasePath+"/"+v.Name+"/openapi.json", func(e *core.RequestEvent) error {
+			return e.JSON(200, doc.document())
+		})
+	}
+}
+
+func (r *Registry) add(section string, access Access, route Route) {
+	from := r.versionIndex(route.Since, 0)
+	until := r.versionIndex(route.Until, len(r.versions))
+
+	key := route.Method + " " + section + route.Path
+	isNewAlias := r.aliases[key] == nil
+	if isNewAlias {
+		r.aliases[key] = map[string]func(e *core.RequestEvent) error{}
+	}
+
+	for _, v := range r.versions[from:until] {
+		path := BasePath + "/" + v.Name + section + route.Path
+
+		r.specs[v.Name].add(path, access, !v.Deprecated.IsZero(), route)
+		r.aliases[key][v.Name] = route.Handler
+
+		access.bind(r.router.Route(route.Method, path, withCompression(withVersion(v, route.Handler))))
+	}
+
+	if !isNewAlias {
+		return
+	}
+
+	// Unversioned paths are what clients shipped before versioning existed, so
+	// they default to the oldest version and are always flagged as deprecated
+	legacyPath := BasePath + section + route.Path
+	handlers := r.aliases[key]
+	rt := r.router.Route(route.Method, legacyPath, withCompression(func(e *core.RequestEvent) error {
+		name := e.Request.Header.Get(VersionHeader)
+		if name == "" {
+			name = r.versions[0].Name
+		}
+
+		i := slices.IndexFunc(r.versions, func(v Version) bool { return v.Name == name })
+		handler, ok := handlers[name]
+		if i < 0 || !ok {
+			return e.BadRequestError(i18n.T(i18n.FromRequest(e), "Unsupported %s %s.", VersionHeader, name), nil)
+		}
+
+		e.Response.Header().Set("Deprecation", "true")
+		successor := strings.Replace(e.Request.URL.Path, BasePath, BasePath+"/"+name, 1)
+		e.Response.Header().Set("Link", "<"+successor+`>; rel="successor-version"`)
+
+		return withVersion(r.versions[i], handler)(e)
+	}))
+	access.bind(rt)
+}
+
+// versionIndex returns the position of the named version, or fallback when
+// the name is empty
+func (r *Registry) versionIndex(name string, fallback int) int {
+	if name == "" {
+		return fallback
+	}
+	for i, v := range r.versions {
+		if v.Name == name {
+			return i
+		}
+	}
+	panic("unknown api version " + name)
+}
+
+// withVersion stamps responses with the version that handled them and
+// applies its deprecation schedule (RFC 9745 and RFC 8594 headers)
+func withVersion(v Version, handler func(e *core.RequestEvent) error) func(e *core.RequestEvent) error {
+	return func(e *core.RequestEvent) error {
+		header := e.Response.Header()
+		header.Set(VersionHeader, v.Name)
+
+		if !v.Sunset.IsZero() {
+			if time.Now().After(v.Sunset) {
+				return e.Error(http.StatusGone, i18n.T(i18n.FromRequest(e), "API %s is no longer supported, please update the app.", v.Name), nil)
+			}
+			header.Set("Sunset", v.Sunset.UTC().Format(http.TimeFormat))
+		}
+		if !v.Deprecated.IsZero() {
+			header.Set("Deprecation", "@"+strconv.FormatInt(v.Deprecated.Unix(), 10))
+		}
+
+		return handler(e)
+	}
+}
+
+// Group registers routes under one section of the custom API
+type Group struct {
+	registry *Registry
+	section  string
+	access   Access
+}
+
+// Add registers a fully described route, e.g. one limited to some versions
+func (g *Group) Add(route Route) {
+	g.registry.add(g.section, g.access, route)
+}
+
+func (g *Group) GET(path string, summary string, response any, handler func(e *core.RequestEvent) error) {
+	g.Add(Route{Method: http.MethodGet, Path: path, Summary: summary, Response: response, Handler: handler})
+}
+
+func (g *Group) DELETE(path string, summary string, handler func(e *core.RequestEvent) error) {
+	g.Add(Route{Method: http.MethodDelete, Path: path, Summary: summary, Handler: handler})
+}
+
+func (g *Group) POST(path string, summary string, request any, response any, handler func(e *core.RequestEvent) error) {
+	g.Add(Route{Method: http.MethodPost, Path: path, Summary: summary, Request: request, Response: response, Handler: handler})
+}
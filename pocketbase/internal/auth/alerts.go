@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"net"
+	"net/mail"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/emails"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/settings"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/security"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// alertNewDevice remembers where the user logs in from and emails them when
+// it's somewhere new. The very first login only seeds the list.
+func alertNewDevice(e *core.RequestEvent, user *core.Record, emailsService emails.Service, settingsService settings.Service) error {
+	userAgent := truncate(e.Request.UserAgent(), 500)
+	ip := e.RealIP()
+	fingerprint := deviceFingerprint(userAgent, ip)
+
+	known, err := e.App.CountRecords("login_devices", dbx.HashExp{"user": user.Id})
+	if err != nil {
+		return err
+	}
+
+	device, err := e.App.FindFirstRecordByFilter(
+		"login_devices",
+		"user = {:user} && fingerprint = {:fingerprint}",
+		dbx.Params{"user": user.Id, "fingerprint": fingerprint},
+	)
+	isNew := err != nil
+	if isNew {
+		collection, err := e.App.FindCollectionByNameOrId("login_devices")
+		if err != nil {
+			return err
+		}
+		device = core.NewRecord(collection)
+		device.Set("user", user.Id)
+		device.Set("fingerprint", fingerprint)
+		device.Set("user_agent", userAgent)
+		device.Set("ip", ip)
+	}
+	device.Set("last_login", types.NowDateTime())
+	if err := e.App.Save(device); err != nil {
+		return err
+	}
+
+	if !isNew || known == 0 || user.Email() == "" {
+		return nil
+	}
+
+	userSettings, err := settingsService.ForUser(user.Id)
+	if err != nil {
+		return err
+	}
+	if !userSettings.LoginAlerts {
+		return nil
+	}
+
+	meta := e.App.Settings().Meta
+	to := mail.Address{Name: user.GetString("name"), Address: user.Email()}
+	data := emails.Data{
+		AppName:   meta.AppName,
+		AppURL:    meta.AppURL,
+		ActionURL: meta.AppURL,
+		Name:      user.GetString("name"),
+		Email:     user.Email(),
+		UserAgent: userAgent,
+		IP:        ip,
+	}
+
+	// SMTP can be slow or down, neither should hold up the login
+	app := e.App
+	go func() {
+		if err := emailsService.Send(to, emails.KeyLoginAlert, userSettings.Locale, data); err != nil {
+			app.Logger().Error("Failed to send login alert", "user", user.Id, "error", err)
+		}
+	}()
+
+	return nil
+}
+
+// deviceFingerprint tells login devices apart by their user agent and the
+// network they're on, only the /24 (IPv4) or /48 (IPv6) of their address so
+// the same device doesn't look new whenever its ISP hands out another one
+func deviceFingerprint(userAgent string, ip string) string {
+	return security.SHA256(coarseNetwork(ip) + "|" + userAgent)
+}
+
+func coarseNetwork(ip string) string {
+	parsed := net.ParseIP(ip)
+	switch {
+	case parsed == nil:
+		return ""
+	case parsed.To4() != nil:
+		return parsed.Mask(net.CIDRMask(24, 32)).String()
+	default:
+		return parsed.Mask(net.CIDRMask(48, 128)).String()
+	}
+}
@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"net/mail"
+	"testing"
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/emails"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/settings"
+)
+
+// sentEmails stands in for the mailer, passing on what would be sent
+type sentEmails struct {
+	emails.Service
+	sent chan mail.Address
+}
+
+func (s sentEmails) Send(to mail.Address, key string, locale string, data emails.Data) error {
+	s.sent <- to
+	return nil
+}
+
+func TestAlertNewDevice(t *testing.T) {
+	app := newTestApp(t)
+	settingsService := settings.NewService(app)
+	emailsService := sentEmails{sent: make(chan mail.Address, 10)}
+	user := saveUser(t, app, "user@example.com")
+
+	quiet := saveUser(t, app, "quiet@example.com")
+	if _, err := settingsService.ForUser(quiet.Id); err != nil {
+		t.Fatal(err)
+	}
+	quietSettings, err := app.FindFirstRecordByData("user_settings", "user", quiet.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	quietSettings.Set("login_alerts", false)
+	if err := app.Save(quietSettings); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		user      string
+		userAgent string
+		ip        string
+		alert     bool
+	}{
+		{"first login seeds the devices", user.Id, "Safari", "203.0.113.7", false},
+		{"same device", user.Id, "Safari", "203.0.113.7", false},
+		{"new browser", user.Id, "Firefox", "203.0.113.7", true},
+		{"new network", user.Id, "Safari", "198.51.100.20", true},
+		{"alerts turned off", quiet.Id, "Safari", "203.0.113.7", false},
+		{"alerts turned off on a new device", quiet.Id, "Firefox", "203.0.113.7", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record, err := app.FindRecordById("users", tt.user)
+			if err != nil {
+				t.Fatal(err)
+			}
+			e := newRequestEvent(app, record, "", tt.userAgent)
+			e.Request.RemoteAddr = tt.ip + ":41234"
+			if err := alertNewDevice(e, record, emailsService, settingsService); err != nil {
+				t.Fatal(err)
+			}
+
+			select {
+			case to := <-emailsService.sent:
+				if !tt.alert {
+					t.Errorf("alerted %s, want no alert", to.Address)
+				} else if to.Address != record.Email() {
+					t.Errorf("alerted %s, want %s", to.Address, record.Email())
+				}
+			case <-time.After(100 * time.Millisecond):
+				if tt.alert {
+					t.Error("no alert, want one")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/emails"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/settings"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/hook"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func BindHooks(app core.App, emailsService emails.Service, settingsService settings.Service) {
+	app.OnRecordAuthRequest("users").BindFunc(func(e *core.RecordAuthRequestEvent) error {
+		if err := e.Next(); err != nil {
+			return err
+		}
+
+		// Refreshes come from a device that already logged in, just maybe on
+		// another network
+		if e.AuthMethod != "" {
+			if err := alertNewDevice(e.RequestEvent, e.Record, emailsService, settingsService); err != nil {
+				e.App.Logger().Error("Failed to check login device", "user", e.Record.Id, "error", err)
+			}
+		}
+
+		// A refresh carries the old token, the session moves on to the new one.
+		// Tokens for another account, e.g. the user's sandbox, start their own.
+		if old := RequestToken(e.RequestEvent); old != "" && old != e.Token {
+			if record, err := e.App.FindFirstRecordByData("auth_sessions", "token_hash", hashToken(old)); err == nil && record.GetString("user") == e.Record.Id {
+				if err := rotate(e.App, record, e.Token); err != nil {
+					e.App.Logger().Error("Failed to rotate auth session", "session", record.Id, "error", err)
+				}
+				return nil
+			}
+		}
+
+		if _, err := track(e.App, e.RequestEvent, e.Record.Id, e.Token); err != nil {
+			e.App.Logger().Error("Failed to record auth session", "user", e.Record.Id, "error", err)
+		}
+		return nil
+	})
+
+	// Track whether the user knows their password, OAuth2 signups get a
+	// random one and never pass through these
+	app.OnRecordCreateRequest("users").BindFunc(func(e *core.RecordRequestEvent) error {
+		e.Record.Set("has_password", e.Record.GetString("password") != "")
+		return e.Next()
+	})
+	app.OnRecordUpdateRequest("users").BindFunc(func(e *core.RecordRequestEvent) error {
+		if e.Record.GetString("password") != "" {
+			e.Record.Set("has_password", true)
+		}
+		return e.Next()
+	})
+	app.OnRecordConfirmPasswordResetRequest("users").BindFunc(func(e *core.RecordConfirmPasswordResetRequestEvent) error {
+		e.Record.Set("has_password", true)
+		return e.Next()
+	})
+
+	// Once a session has been idle for a whole token lifetime its token has
+	// expired, so neither listing nor revoking it means anything anymore
+	app.Cron().MustAdd("fushigiAuthSessionsCleanup", "30 3 * * *", func() {
+		users, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			app.Logger().Error("Failed to load users collection for session cleanup", "error", err)
+			return
+		}
+
+		lifetime := time.Duration(users.AuthToken.Duration) * time.Second
+		_, err = app.DB().Delete("auth_sessions", dbx.NewExp(
+			"last_seen < {:before}",
+			dbx.Params{"before": types.NowDateTime().Add(-lifetime).String()},
+		)).Execute()
+		if err != nil {
+			app.Logger().Error("Failed to clean up auth sessions", "error", err)
+		}
+	})
+}
+
+// Middleware refuses tokens of revoked sessions and keeps the last seen time
+// and address of the others up to date
+func Middleware() *hook.Handler[*core.RequestEvent] {
+	return &hook.Handler[*core.RequestEvent]{
+		Id: "fushigiAuthSessions",
+		Func: func(e *core.RequestEvent) error {
+			if e.Auth == nil || e.Auth.Collection().Name != "users" {
+				return e.Next()
+			}
+
+			// a session that can't be looked up may well be revoked
+			record, err := track(e.App, e, e.Auth.Id, RequestToken(e))
+			if err != nil {
+				e.App.Logger().Error("Failed to track auth session", "user", e.Auth.Id, "error", err)
+				return e.InternalServerError("Failed to check the session.", err)
+			}
+			if record.GetBool("revoked") {
+				return e.UnauthorizedError("This session was signed out.", nil)
+			}
+
+			return e.Next()
+		},
+	}
+}
@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+	"golang.org/x/oauth2"
+)
+
+// LinkableProviders are the OAuth2 providers the clients offer to link
+var LinkableProviders = []string{"apple", "google"}
+
+var (
+	ErrProviderUnavailable = errors.New("provider is not enabled")
+	ErrIdentityTaken       = errors.New("identity is linked to another account")
+	ErrLastSignInMethod    = errors.New("identity is the only way to sign in")
+)
+
+// Identity is an OAuth2 account linked to the user
+type Identity struct {
+	Provider string         `json:"provider"`
+	Created  types.DateTime `json:"created"`
+}
+
+type Identities struct {
+	// Whether the user can sign in with a password they chose
+	HasPassword bool `json:"has_password"`
+
+	Linked []Identity `json:"linked"`
+
+	// Providers the instance has enabled that the user can link
+	Available []string `json:"available"`
+}
+
+// LinkRequest carries the result of the provider's OAuth2 redirect, as for
+// PocketBase's auth-with-oauth2
+type LinkRequest struct {
+	Provider     string `json:"provider"`
+	Code         string `json:"code"`
+	CodeVerifier string `json:"code_verifier"`
+	RedirectURL  string `json:"redirect_url"`
+}
+
+func (s *service) Identities(user *core.Record) (Identities, error) {
+	externalAuths, err := s.app.FindAllExternalAuthsByRecord(user)
+	if err != nil {
+		return Identities{}, err
+	}
+
+	identities := Identities{
+		HasPassword: user.GetBool("has_password"),
+		Linked:      []Identity{},
+		Available:   []string{},
+	}
+	for _, externalAuth := range externalAuths {
+		identities.Linked = append(identities.Linked, Identity{
+			Provider: externalAuth.Provider(),
+			Created:  externalAuth.Created(),
+		})
+	}
+	for _, name := range LinkableProviders {
+		if _, ok := user.Collection().OAuth2.GetProviderConfig(name); ok && user.Collection().OAuth2.Enabled {
+			identities.Available = append(identities.Available, name)
+		}
+	}
+	return identities, nil
+}
+
+func (s *service) Link(ctx context.Context, user *core.Record, req LinkRequest) error {
+	collection := user.Collection()
+	config, ok := collection.OAuth2.GetProviderConfig(req.Provider)
+	if !ok || !collection.OAuth2.Enabled || !slices.Contains(LinkableProviders, req.Provider) {
+		return ErrProviderUnavailable
+	}
+
+	provider, err := config.InitProvider()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	provider.SetContext(ctx)
+	provider.SetRedirectURL(req.RedirectURL)
+
+	var opts []oauth2.AuthCodeOption
+	if provider.PKCE() {
+		opts = append(opts, oauth2.SetAuthURLParam("code_verifier", req.CodeVerifier))
+	}
+	token, err := provider.FetchToken(req.Code, opts...)
+	if err != nil {
+		return err
+	}
+	authUser, err := provider.FetchAuthUser(token)
+	if err != nil {
+		return err
+	}
+
+	existing, err := s.app.FindFirstExternalAuthByExpr(dbx.HashExp{
+		"collectionRef": collection.Id,
+		"provider":      req.Provider,
+		"providerId":    authUser.Id,
+	})
+	if err == nil {
+		// PocketBase would quietly log into the other account instead
+		if existing.RecordRef() != user.Id {
+			return ErrIdentityTaken
+		}
+		return nil
+	}
+
+	externalAuth := core.NewExternalAuth(s.app)
+	externalAuth.SetCollectionRef(collection.Id)
+	externalAuth.SetRecordRef(user.Id)
+	externalAuth.SetProvider(req.Provider)
+	externalAuth.SetProviderId(authUser.Id)
+	return s.app.Save(externalAuth)
+}
+
+func (s *service) Unlink(user *core.Record, providerName string) error {
+	externalAuths, err := s.app.FindAllExternalAuthsByRecord(user)
+	if err != nil {
+		return err
+	}
+
+	i := slices.IndexFunc(externalAuths, func(a *core.ExternalAuth) bool { return a.Provider() == providerName })
+	if i < 0 {
+		return errors.New("identity not linked")
+	}
+
+	// Users who signed up through a provider have a random password, they
+	// need to set one with a password reset before dropping their last provider
+	if len(externalAuths) == 1 && !user.GetBool("has_password") {
+		return ErrLastSignInMethod
+	}
+
+	return s.app.Delete(externalAuths[i])
+}
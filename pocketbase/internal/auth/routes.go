@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+type revokeOthersResponse struct {
+	Revoked int `json:"revoked"`
+}
+
+func RegisterRoutes(g *api.Group, authService Service) {
+	g.GET("/sessions", "The user's signed in sessions", []Session{}, func(e *core.RequestEvent) error {
+		sessions, err := authService.List(e.Auth.Id, RequestToken(e))
+		if err != nil {
+			return e.InternalServerError("Failed to load sessions.", err)
+		}
+		return e.JSON(200, sessions)
+	})
+
+	g.DELETE("/sessions/{id}", "Sign a session out", func(e *core.RequestEvent) error {
+		if err := authService.Revoke(e.Auth.Id, e.Request.PathValue("id")); err != nil {
+			return e.NotFoundError("", err)
+		}
+		return e.NoContent(204)
+	})
+
+	g.POST("/sessions/revoke-others", "Sign out every session but the current one", nil, revokeOthersResponse{}, func(e *core.RequestEvent) error {
+		revoked, err := authService.RevokeOthers(e.Auth.Id, RequestToken(e))
+		if err != nil {
+			return e.InternalServerError("Failed to sign out the other sessions.", err)
+		}
+		return e.JSON(200, revokeOthersResponse{Revoked: revoked})
+	})
+
+	g.GET("/identities", "OAuth2 providers linked to the account", Identities{}, func(e *core.RequestEvent) error {
+		identities, err := authService.Identities(e.Auth)
+		if err != nil {
+			return e.InternalServerError("Failed to load linked accounts.", err)
+		}
+		return e.JSON(200, identities)
+	})
+
+	g.POST("/identities", "Link an OAuth2 provider to the account", LinkRequest{}, nil, func(e *core.RequestEvent) error {
+		var req LinkRequest
+		if err := e.BindBody(&req); err != nil {
+			return e.BadRequestError("Invalid request body.", err)
+		}
+
+		err := authService.Link(e.Request.Context(), e.Auth, req)
+		switch {
+		case errors.Is(err, ErrProviderUnavailable):
+			return e.BadRequestError("This sign in method isn't available.", err)
+		case errors.Is(err, ErrIdentityTaken):
+			return e.Error(http.StatusConflict, "This account is already linked to another user.", err)
+		case err != nil:
+			return e.BadRequestError("Failed to link the account.", err)
+		}
+		return e.NoContent(204)
+	})
+
+	g.DELETE("/identities/{provider}", "Unlink an OAuth2 provider from the account", func(e *core.RequestEvent) error {
+		err := authService.Unlink(e.Auth, e.Request.PathValue("provider"))
+		if errors.Is(err, ErrLastSignInMethod) {
+			return e.BadRequestError("Set a password before unlinking your only sign in method.", err)
+		}
+		if err != nil {
+			return e.NotFoundError("", err)
+		}
+		return e.NoContent(204)
+	})
+}
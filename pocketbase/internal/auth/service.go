@@ -0,0 +1,182 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// lastSeenInterval throttles the last_seen writes, a rough value is plenty
+const lastSeenInterval = 5 * time.Minute
+
+type Service interface {
+	// List returns the user's live sessions, most recently used first, flagging
+	// the one holding currentToken
+	List(userId string, currentToken string) ([]Session, error)
+
+	// Revoke signs the session out, its token is refused from then on
+	Revoke(userId string, id string) error
+
+	// RevokeOthers signs out every session but the one holding currentToken
+	RevokeOthers(userId string, currentToken string) (int, error)
+
+	// Identities lists the OAuth2 providers linked to the user and those they
+	// could link
+	Identities(user *core.Record) (Identities, error)
+
+	// Link exchanges an OAuth2 code and attaches the provider account to user
+	Link(ctx context.Context, user *core.Record, req LinkRequest) error
+
+	// Unlink detaches a provider, refusing to leave the user without any way
+	// to sign in
+	Unlink(user *core.Record, provider string) error
+}
+
+type service struct {
+	app core.App
+}
+
+func NewService(app core.App) Service {
+	return &service{app: app}
+}
+
+func (s *service) List(userId string, currentToken string) ([]Session, error) {
+	records, err := s.app.FindRecordsByFilter(
+		"auth_sessions",
+		"user = {:user} && revoked = false",
+		"-last_seen",
+		0,
+		0,
+		dbx.Params{"user": userId},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	currentHash := hashToken(currentToken)
+	sessions := make([]Session, 0, len(records))
+	for _, record := range records {
+		session := FromRecord(record)
+		session.Current = record.GetString("token_hash") == currentHash
+		sessions = append(sessions, session)
+	}
+	return sessions, nil
+}
+
+func (s *service) Revoke(userId string, id string) error {
+	record, err := s.app.FindFirstRecordByFilter(
+		"auth_sessions",
+		"id = {:id} && user = {:user} && revoked = false",
+		dbx.Params{"id": id, "user": userId},
+	)
+	if err != nil {
+		return err
+	}
+
+	record.Set("revoked", true)
+	return s.app.Save(record)
+}
+
+func (s *service) RevokeOthers(userId string, currentToken string) (int, error) {
+	records, err := s.app.FindRecordsByFilter(
+		"auth_sessions",
+		"user = {:user} && revoked = false && token_hash != {:hash}",
+		"",
+		0,
+		0,
+		dbx.Params{"user": userId, "hash": hashToken(currentToken)},
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	err = s.app.RunInTransaction(func(txApp core.App) error {
+		for _, record := range records {
+			record.Set("revoked", true)
+			if err := txApp.Save(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(records), nil
+}
+
+// track records a use of token, creating the session if it's the first time
+// the server sees it, e.g. tokens issued before sessions existed. Only failing
+// to look the session up is an error, failing to record the use is logged.
+func track(app core.App, e *core.RequestEvent, userId string, token string) (*core.Record, error) {
+	hash := hashToken(token)
+	record, err := app.FindFirstRecordByData("auth_sessions", "token_hash", hash)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		collection, err := app.FindCollectionByNameOrId("auth_sessions")
+		if err != nil {
+			return nil, err
+		}
+		record = core.NewRecord(collection)
+		record.Set("user", userId)
+		record.Set("token_hash", hash)
+	case err != nil:
+		return nil, err
+	case record.GetBool("revoked") || time.Since(record.GetDateTime("last_seen").Time()) < lastSeenInterval:
+		return record, nil
+	}
+
+	record.Set("user_agent", truncate(e.Request.UserAgent(), 500))
+	record.Set("ip", e.RealIP())
+	record.Set("last_seen", types.NowDateTime())
+	if err := app.Save(record); err != nil {
+		if !record.IsNew() {
+			app.Logger().Warn("Failed to update auth session", "session", record.Id, "error", err)
+			return record, nil
+		}
+
+		// another request with the same token may have created the session
+		// first, its row being the one that can be revoked
+		existing, findErr := app.FindFirstRecordByData("auth_sessions", "token_hash", hash)
+		switch {
+		case errors.Is(findErr, sql.ErrNoRows):
+			app.Logger().Warn("Failed to create auth session", "user", userId, "error", err)
+			return record, nil
+		case findErr != nil:
+			return nil, findErr
+		}
+		return existing, nil
+	}
+	return record, nil
+}
+
+// rotate moves the session over to a refreshed token. The old token stays
+// valid as a JWT until it expires, so a revoked stub keeps it refused.
+func rotate(app core.App, record *core.Record, token string) error {
+	return app.RunInTransaction(func(txApp core.App) error {
+		oldHash := record.GetString("token_hash")
+		record.Set("token_hash", hashToken(token))
+		if err := txApp.Save(record); err != nil {
+			return err
+		}
+
+		stub := core.NewRecord(record.Collection())
+		stub.Set("user", record.GetString("user"))
+		stub.Set("token_hash", oldHash)
+		stub.Set("last_seen", record.GetDateTime("last_seen"))
+		stub.Set("revoked", true)
+		return txApp.Save(stub)
+	})
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
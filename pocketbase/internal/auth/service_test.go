@@ -0,0 +1,275 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/bunkbed-tech/fushigi/pocketbase/migrations"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/pocketbase/pocketbase/tools/router"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// newTestApp is a migrated app
+func newTestApp(t *testing.T) *tests.TestApp {
+	t.Helper()
+	// the initial migration creates a superuser from these
+	t.Setenv("ADMIN_EMAIL", "admin@example.com")
+	t.Setenv("ADMIN_PASSWORD", "correct horse battery")
+	app, err := tests.NewTestApp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(app.Cleanup)
+	if err := app.RunAllMigrations(); err != nil {
+		t.Fatal(err)
+	}
+	return app
+}
+
+func saveUser(t *testing.T, app core.App, email string) *core.Record {
+	t.Helper()
+	users, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := core.NewRecord(users)
+	user.SetEmail(email)
+	// usernames are unique when set, so every user gets one
+	user.Set("username", strings.Split(email, "@")[0])
+	user.SetPassword("correct horse battery")
+	if err := app.Save(user); err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+// newRequestEvent is a request made with token by user from a browser
+func newRequestEvent(app core.App, user *core.Record, token string, userAgent string) *core.RequestEvent {
+	e := &core.RequestEvent{App: app}
+	e.Request = httptest.NewRequest("GET", "/api/fushigi/v1/stats", nil)
+	e.Request.Header.Set("Authorization", token)
+	e.Request.Header.Set("User-Agent", userAgent)
+	e.Request.RemoteAddr = "203.0.113.7:41234"
+	e.Response = httptest.NewRecorder()
+	e.Auth = user
+	return e
+}
+
+func TestTrack(t *testing.T) {
+	app := newTestApp(t)
+	user := saveUser(t, app, "user@example.com")
+
+	first, err := track(app, newRequestEvent(app, user, "token", "Safari"), user.Id, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.GetString("user") != user.Id || first.GetString("user_agent") != "Safari" || first.GetString("ip") != "203.0.113.7" {
+		t.Errorf("new session = %v, want Safari at 203.0.113.7 for the user", first)
+	}
+	if first.GetString("token_hash") == "token" {
+		t.Error("new session stored the token itself, want its hash")
+	}
+
+	// seen again straight away, nothing is written
+	again, err := track(app, newRequestEvent(app, user, "token", "Firefox"), user.Id, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Id != first.Id || again.GetString("user_agent") != "Safari" {
+		t.Errorf("session seen again = %s from %s, want %s from Safari", again.Id, again.GetString("user_agent"), first.Id)
+	}
+
+	// seen again after a while, last_seen and the client move on
+	again.Set("last_seen", types.NowDateTime().Add(-2*lastSeenInterval))
+	if err := app.Save(again); err != nil {
+		t.Fatal(err)
+	}
+	later, err := track(app, newRequestEvent(app, user, "token", "Firefox"), user.Id, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if later.Id != first.Id || later.GetString("user_agent") != "Firefox" || time.Since(later.GetDateTime("last_seen").Time()) > time.Minute {
+		t.Errorf("session seen later = %s from %s at %v, want %s from Firefox now", later.Id, later.GetString("user_agent"), later.GetDateTime("last_seen"), first.Id)
+	}
+}
+
+func TestTrackSaveFailure(t *testing.T) {
+	app := newTestApp(t)
+	user := saveUser(t, app, "user@example.com")
+
+	first, err := track(app, newRequestEvent(app, user, "token", "Safari"), user.Id, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	first.Set("last_seen", types.NowDateTime().Add(-2*lastSeenInterval))
+	if err := app.Save(first); err != nil {
+		t.Fatal(err)
+	}
+
+	app.OnRecordUpdate("auth_sessions").BindFunc(func(e *core.RecordEvent) error {
+		return errors.New("disk full")
+	})
+	got, err := track(app, newRequestEvent(app, user, "token", "Firefox"), user.Id, "token")
+	if err != nil {
+		t.Fatalf("track() error = %v, want the failed last_seen write only logged", err)
+	}
+	if got.Id != first.Id || got.GetBool("revoked") {
+		t.Errorf("track() = %s, revoked %v, want %s still signed in", got.Id, got.GetBool("revoked"), first.Id)
+	}
+}
+
+func TestTrackConcurrentCreate(t *testing.T) {
+	app := newTestApp(t)
+	user := saveUser(t, app, "user@example.com")
+
+	// another request creates the session, and it's signed out, between
+	// track looking it up and creating it
+	var other *core.Record
+	app.OnRecordCreate("auth_sessions").BindFunc(func(e *core.RecordEvent) error {
+		if other == nil {
+			other = core.NewRecord(e.Record.Collection())
+			other.Set("user", user.Id)
+			other.Set("token_hash", e.Record.GetString("token_hash"))
+			other.Set("revoked", true)
+			if err := e.App.Save(other); err != nil {
+				return err
+			}
+		}
+		return e.Next()
+	})
+
+	got, err := track(app, newRequestEvent(app, user, "token", "Safari"), user.Id, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Id != other.Id || !got.GetBool("revoked") {
+		t.Errorf("track() = %s, revoked %v, want the other request's %s, revoked", got.Id, got.GetBool("revoked"), other.Id)
+	}
+}
+
+func TestRotate(t *testing.T) {
+	app := newTestApp(t)
+	user := saveUser(t, app, "user@example.com")
+
+	record, err := track(app, newRequestEvent(app, user, "old", "Safari"), user.Id, "old")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rotate(app, record, "new"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		token   string
+		revoked bool
+	}{
+		{"new", false},
+		{"old", true},
+	}
+	for _, tt := range tests {
+		got, err := app.FindFirstRecordByData("auth_sessions", "token_hash", hashToken(tt.token))
+		if err != nil {
+			t.Fatalf("session of the %s token: %v", tt.token, err)
+		}
+		if got.GetBool("revoked") != tt.revoked {
+			t.Errorf("session of the %s token revoked = %v, want %v", tt.token, got.GetBool("revoked"), tt.revoked)
+		}
+	}
+}
+
+func TestService(t *testing.T) {
+	app := newTestApp(t)
+	service := NewService(app)
+	user := saveUser(t, app, "user@example.com")
+	other := saveUser(t, app, "other@example.com")
+
+	sessions := map[string]*core.Record{}
+	for _, token := range []string{"phone", "laptop", "tablet"} {
+		record, err := track(app, newRequestEvent(app, user, token, token), user.Id, token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sessions[token] = record
+	}
+	otherSession, err := track(app, newRequestEvent(app, other, "other", "other"), other.Id, "other")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listed, err := service.List(user.Id, "phone")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listed) != 3 {
+		t.Fatalf("List() = %d sessions, want 3", len(listed))
+	}
+	for _, session := range listed {
+		if want := session.Id == sessions["phone"].Id; session.Current != want {
+			t.Errorf("session from %s current = %v, want %v", session.UserAgent, session.Current, want)
+		}
+	}
+
+	if err := service.Revoke(user.Id, otherSession.Id); err == nil {
+		t.Error("Revoke() of another user's session succeeded, want an error")
+	}
+	if err := service.Revoke(user.Id, sessions["tablet"].Id); err != nil {
+		t.Fatal(err)
+	}
+	revoked, err := service.RevokeOthers(user.Id, "phone")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if revoked != 1 {
+		t.Errorf("RevokeOthers() = %d, want only the laptop", revoked)
+	}
+
+	listed, err = service.List(user.Id, "phone")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listed) != 1 || listed[0].Id != sessions["phone"].Id {
+		t.Errorf("List() after revoking = %v, want only the phone", listed)
+	}
+	if listed, err := service.List(other.Id, "other"); err != nil || len(listed) != 1 {
+		t.Errorf("other user's List() = %v, %v, want their session untouched", listed, err)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	app := newTestApp(t)
+	service := NewService(app)
+	user := saveUser(t, app, "user@example.com")
+
+	serve := func(token string) int {
+		e := newRequestEvent(app, user, token, "Safari")
+		if err := Middleware().Func(e); err != nil {
+			var apiErr *router.ApiError
+			if errors.As(err, &apiErr) {
+				return apiErr.Status
+			}
+			return http.StatusInternalServerError
+		}
+		return http.StatusOK
+	}
+
+	if got := serve("token"); got != http.StatusOK {
+		t.Fatalf("first request = %d, want %d", got, http.StatusOK)
+	}
+	record, err := app.FindFirstRecordByData("auth_sessions", "token_hash", hashToken("token"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := service.Revoke(user.Id, record.Id); err != nil {
+		t.Fatal(err)
+	}
+	if got := serve("token"); got != http.StatusUnauthorized {
+		t.Errorf("request after revoking = %d, want %d", got, http.StatusUnauthorized)
+	}
+}
@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"strings"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/security"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Session is one signed in client, i.e. one auth token and its refreshes
+type Session struct {
+	Id        string         `json:"id"`
+	UserAgent string         `json:"user_agent"`
+	IP        string         `json:"ip"`
+	LastSeen  types.DateTime `json:"last_seen"`
+	Created   types.DateTime `json:"created"`
+
+	// Whether this is the session making the request
+	Current bool `json:"current"`
+}
+
+func FromRecord(rec *core.Record) Session {
+	return Session{
+		Id:        rec.Id,
+		UserAgent: rec.GetString("user_agent"),
+		IP:        rec.GetString("ip"),
+		LastSeen:  rec.GetDateTime("last_seen"),
+		Created:   rec.GetDateTime("created"),
+	}
+}
+
+// RequestToken returns the auth token the request was made with, the same way
+// PocketBase reads it
+func RequestToken(e *core.RequestEvent) string {
+	return strings.TrimPrefix(e.Request.Header.Get("Authorization"), "Bearer ")
+}
+
+func hashToken(token string) string {
+	return security.SHA256(token)
+}
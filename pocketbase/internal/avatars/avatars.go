@@ -0,0 +1,74 @@
+package avatars
+
+import (
+	"bytes"
+	"image/jpeg"
+	"path/filepath"
+	"strings"
+
+	"github.com/disintegration/imaging"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/filesystem"
+	_ "golang.org/x/image/webp" // registers the decoder, avatars are re-encoded as png
+)
+
+// Size is the width and height every avatar is cropped and scaled to
+const Size = 512
+
+// BindHooks normalizes uploaded avatars before they are stored. Re-encoding
+// also drops the EXIF metadata, which can include where a photo was taken.
+func BindHooks(app core.App) {
+	resize := func(e *core.RecordEvent) error {
+		files := e.Record.GetUnsavedFiles("avatar")
+		if len(files) == 0 {
+			return e.Next()
+		}
+
+		resized, err := normalize(files[0])
+		if err != nil {
+			return validation.Errors{
+				"avatar": validation.NewError("validation_invalid_image", "The avatar is not a valid image."),
+			}
+		}
+		e.Record.Set("avatar", resized)
+
+		return e.Next()
+	}
+
+	app.OnRecordCreate("users").BindFunc(resize)
+	app.OnRecordUpdate("users").BindFunc(resize)
+}
+
+// normalize crops the image to a centered square of Size pixels, keeping jpegs
+// as jpeg and turning everything else (png, gif, webp) into png
+func normalize(file *filesystem.File) (*filesystem.File, error) {
+	r, err := file.Reader.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	img, err := imaging.Decode(r, imaging.AutoOrientation(true))
+	if err != nil {
+		return nil, err
+	}
+	img = imaging.Fill(img, Size, Size, imaging.Center, imaging.Lanczos)
+
+	ext := strings.ToLower(filepath.Ext(file.OriginalName))
+	base := strings.TrimSuffix(file.OriginalName, filepath.Ext(file.OriginalName))
+
+	var buf bytes.Buffer
+	if ext == ".jpg" || ext == ".jpeg" {
+		err = imaging.Encode(&buf, img, imaging.JPEG, imaging.JPEGQuality(jpeg.DefaultQuality))
+		ext = ".jpg"
+	} else {
+		err = imaging.Encode(&buf, img, imaging.PNG)
+		ext = ".png"
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return filesystem.NewFileFromBytes(buf.Bytes(), base+ext)
+}
@@ -0,0 +1,81 @@
+package changelog
+
+import (
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/clients"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Kinds of changelog items
+const (
+	KindFeature     = "feature"
+	KindImprovement = "improvement"
+	KindFix         = "fix"
+)
+
+// Item is one thing a release brought
+type Item struct {
+	Id          string `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Kind        string `json:"kind"`
+
+	// Flag is the feature flag the item is behind, empty when it's for
+	// everyone
+	Flag string `json:"flag"`
+}
+
+func ItemFromRecord(rec *core.Record) Item {
+	return Item{
+		Id:          rec.Id,
+		Title:       rec.GetString("title"),
+		Description: rec.GetString("description"),
+		Kind:        rec.GetString("kind"),
+		Flag:        rec.GetString("flag"),
+	}
+}
+
+// Release is what's new in a version
+type Release struct {
+	Version  string         `json:"version"`
+	Released types.DateTime `json:"released"`
+	Items    []Item         `json:"items"`
+}
+
+// Query picks the releases a client shows. Since and Until bound the
+// versions, Since excluded, and Platform leaves out other platforms' items.
+// Empty fields don't filter.
+type Query struct {
+	Platform string
+	Since    string
+	Until    string
+}
+
+func (q Query) Validate() error {
+	errs := validation.Errors{}
+
+	if q.Since != "" && !clients.IsVersion(q.Since) {
+		errs["since"] = validation.NewError("validation_invalid_value", "Not a version, e.g. 1.4.2.")
+	}
+	if q.Until != "" && !clients.IsVersion(q.Until) {
+		errs["until"] = validation.NewError("validation_invalid_value", "Not a version, e.g. 1.4.2.")
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// includes reports whether version is in the query's bounds
+func (q Query) includes(version string) bool {
+	if q.Since != "" && clients.CompareVersions(version, q.Since) <= 0 {
+		return false
+	}
+	if q.Until != "" && clients.CompareVersions(version, q.Until) > 0 {
+		return false
+	}
+	return true
+}
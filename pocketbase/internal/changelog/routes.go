@@ -0,0 +1,38 @@
+package changelog
+
+import (
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/clients"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+func RegisterRoutes(g *api.Group, changelogService Service) {
+	// Clients call this after upgrading with ?since= the version they last
+	// showed, the platform and the upper bound being their own build's
+	g.GET("/changelog", "What's new by release, newest first, from ?since= (excluded) to ?until= for ?platform=, defaulting to the build sending Fushigi-Client", []Release{}, func(e *core.RequestEvent) error {
+		query := e.Request.URL.Query()
+		q := Query{
+			Platform: query.Get("platform"),
+			Since:    query.Get("since"),
+			Until:    query.Get("until"),
+		}
+		if client, ok := clients.FromRequest(e.Request); ok {
+			if q.Platform == "" {
+				q.Platform = client.Platform
+			}
+			if q.Until == "" {
+				q.Until = client.Version
+			}
+		}
+		if err := q.Validate(); err != nil {
+			return e.BadRequestError("Invalid changelog query.", err)
+		}
+
+		releases, err := changelogService.Releases(e.Auth.Id, q)
+		if err != nil {
+			return e.InternalServerError("Failed to load the changelog.", err)
+		}
+		return e.JSON(200, releases)
+	})
+}
@@ -0,0 +1,74 @@
+package changelog
+
+import (
+	"slices"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/clients"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/features"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+type Service interface {
+	// Releases returns the releases matching q, newest first, with the items
+	// behind flags the user doesn't have left out. Releases left without
+	// items are left out too.
+	Releases(userId string, q Query) ([]Release, error)
+}
+
+type service struct {
+	app             core.App
+	featuresService features.Service
+}
+
+func NewService(app core.App, featuresService features.Service) Service {
+	return &service{app: app, featuresService: featuresService}
+}
+
+func (s *service) Releases(userId string, q Query) ([]Release, error) {
+	filter, params := "", dbx.Params{}
+	if q.Platform != "" {
+		filter, params["platform"] = "platform = '' || platform = {:platform}", q.Platform
+	}
+	records, err := s.app.FindRecordsByFilter("changelog", filter, "position,created", 0, 0, params)
+	if err != nil {
+		return nil, err
+	}
+
+	flags, err := s.featuresService.All(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[string]*Release{}
+	for _, rec := range records {
+		version := rec.GetString("version")
+		if !q.includes(version) {
+			continue
+		}
+		item := ItemFromRecord(rec)
+		if item.Flag != "" && !flags[item.Flag] {
+			continue
+		}
+
+		release, ok := byVersion[version]
+		if !ok {
+			release = &Release{Version: version}
+			byVersion[version] = release
+		}
+		if released := rec.GetDateTime("released"); released.After(release.Released) {
+			release.Released = released
+		}
+		release.Items = append(release.Items, item)
+	}
+
+	releases := make([]Release, 0, len(byVersion))
+	for _, release := range byVersion {
+		releases = append(releases, *release)
+	}
+	slices.SortFunc(releases, func(a, b Release) int {
+		return clients.CompareVersions(b.Version, a.Version)
+	})
+	return releases, nil
+}
@@ -0,0 +1,141 @@
+package children
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+const (
+	// MaxChildren bounds the child accounts of a parent
+	MaxChildren = 10
+
+	minPasswordLength = 8
+	maxNameLength     = 100
+)
+
+// usernamePattern matches the users collection's username field
+var usernamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{2,29}$`)
+
+// Controls are what a parent lets their child do. Children can never use
+// the social features, whatever their controls.
+type Controls struct {
+	// AI is whether the child can use the AI features
+	AI bool `json:"ai"`
+
+	// PublicContent is whether the child sees the entries and mnemonics
+	// others share, and not only their own
+	PublicContent bool `json:"public_content"`
+}
+
+// Child is a child account as their parent manages it
+type Child struct {
+	Id       string         `json:"id"`
+	Name     string         `json:"name"`
+	Username string         `json:"username"`
+	Controls Controls       `json:"controls"`
+	Created  types.DateTime `json:"created"`
+}
+
+func FromRecord(rec *core.Record) Child {
+	return Child{
+		Id:       rec.Id,
+		Name:     rec.GetString("name"),
+		Username: rec.GetString("username"),
+		Controls: ControlsOf(rec),
+		Created:  rec.GetDateTime("created"),
+	}
+}
+
+// IsChild reports whether user is a child account
+func IsChild(user *core.Record) bool {
+	return user.GetString("parent") != ""
+}
+
+// ControlsOf returns what user may do, everything unless they're a child
+func ControlsOf(user *core.Record) Controls {
+	if !IsChild(user) {
+		return Controls{AI: true, PublicContent: true}
+	}
+	var controls Controls
+	_ = user.UnmarshalJSONField("parental_controls", &controls)
+	return controls
+}
+
+// CreateRequest makes a child account, which logs in with Username and
+// Password. Controls default to neither AI features nor others' content.
+type CreateRequest struct {
+	Name     string    `json:"name"`
+	Username string    `json:"username"`
+	Password string    `json:"password"`
+	Controls *Controls `json:"controls"`
+}
+
+func (r CreateRequest) Validate() error {
+	errs := validation.Errors{}
+
+	if err := validateName(r.Name); err != nil {
+		errs["name"] = err
+	}
+	if !usernamePattern.MatchString(r.Username) {
+		errs["username"] = validation.NewError("validation_invalid_username", "Use 3 to 30 lowercase letters, digits, dots, dashes and underscores.")
+	}
+	if err := validatePassword(r.Password); err != nil {
+		errs["password"] = err
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// UpdateRequest changes what's set of a child account
+type UpdateRequest struct {
+	Name     *string   `json:"name"`
+	Password *string   `json:"password"`
+	Controls *Controls `json:"controls"`
+}
+
+func (r UpdateRequest) Validate() error {
+	errs := validation.Errors{}
+
+	if r.Name != nil {
+		if err := validateName(*r.Name); err != nil {
+			errs["name"] = err
+		}
+	}
+	if r.Password != nil {
+		if err := validatePassword(*r.Password); err != nil {
+			errs["password"] = err
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func validateName(name string) error {
+	switch name = strings.TrimSpace(name); {
+	case name == "":
+		return validation.NewError("validation_required", "Cannot be blank.")
+	case utf8.RuneCountInString(name) > maxNameLength:
+		return validation.NewError("validation_length_too_long", "The length must be no more than {{.max}}.").
+			SetParams(map[string]any{"max": maxNameLength})
+	}
+	return nil
+}
+
+func validatePassword(password string) error {
+	if utf8.RuneCountInString(password) < minPasswordLength {
+		return validation.NewError("validation_length_too_short", "The length must be at least {{.min}}.").
+			SetParams(map[string]any{"min": minPasswordLength})
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package children
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/ratelimit"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/settings"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/hook"
+)
+
+// versionPrefix is the version segment of versioned paths
+var versionPrefix = regexp.MustCompile(`^/v\d+`)
+
+// Routes children can't use, by their path under the API version
+var (
+	socialRoutes = map[string]bool{
+		"POST /decks/{id}/share":        true,
+		"GET /journal/feed":             true,
+		"POST /live-edits":              true,
+		"POST /mnemonics/{id}/vote":     true,
+		"POST /study-rooms":             true,
+		"POST /study-rooms/{code}/join": true,
+		"POST /tutor-sessions":          true,
+	}
+
+	// what others share, unless the parent allows it
+	publicContentRoutes = map[string]bool{
+		"POST /decks/import":          true,
+		"POST /shared/{token}/import": true,
+	}
+
+	// accounts of their own, which the controls wouldn't follow into
+	accountRoutes = map[string]bool{
+		"POST /mcp/tokens": true,
+		"POST /profiles":   true,
+		"POST /sandbox":    true,
+	}
+)
+
+// socialCollections are those only social features write to
+var socialCollections = []string{"follows", "groups", "tutors", "mnemonic_votes"}
+
+// BindHooks keeps children out of the social features, whatever their
+// controls, and keeps others from following them or adding them to groups
+func BindHooks(app core.App) {
+	forbid := func(e *core.RecordRequestEvent) error {
+		return e.ForbiddenError("Child accounts can't use social features.", nil)
+	}
+
+	for _, name := range socialCollections {
+		app.OnRecordCreateRequest(name).BindFunc(func(e *core.RecordRequestEvent) error {
+			if e.Auth != nil && IsChild(e.Auth) {
+				return forbid(e)
+			}
+			return e.Next()
+		})
+	}
+
+	// children are only ever in touch with their own family
+	app.OnRecordCreateRequest("follows").BindFunc(func(e *core.RecordRequestEvent) error {
+		if involvesChild(e.App, e.Record.GetString("following")) {
+			return forbid(e)
+		}
+		return e.Next()
+	})
+	app.OnRecordCreateRequest("tutors").BindFunc(func(e *core.RecordRequestEvent) error {
+		if involvesChild(e.App, e.Record.GetString("tutor")) {
+			return forbid(e)
+		}
+		return e.Next()
+	})
+	groupMembers := func(e *core.RecordRequestEvent) error {
+		if involvesChild(e.App, e.Record.GetStringSlice("members")...) {
+			return forbid(e)
+		}
+		return e.Next()
+	}
+	app.OnRecordCreateRequest("groups").BindFunc(groupMembers)
+	app.OnRecordUpdateRequest("groups").BindFunc(groupMembers)
+
+	// what children write stays theirs
+	private := map[string]func(rec *core.Record) bool{
+		"journal_entry": func(rec *core.Record) bool {
+			audience := rec.GetString("audience")
+			return (audience == "" || audience == settings.DefaultAudience) && !rec.GetBool("published")
+		},
+		"mnemonics": func(rec *core.Record) bool {
+			return !rec.GetBool("public")
+		},
+		"decks": func(rec *core.Record) bool {
+			return !rec.GetBool("published")
+		},
+		"users": func(rec *core.Record) bool {
+			return !rec.GetBool("published")
+		},
+		"user_settings": func(rec *core.Record) bool {
+			return rec.GetString("default_audience") == settings.DefaultAudience &&
+				rec.GetString("profile_visibility") == settings.VisibilityPrivate &&
+				rec.GetString("stats_visibility") == settings.VisibilityPrivate
+		},
+	}
+	for name, isPrivate := range private {
+		check := func(e *core.RecordRequestEvent) error {
+			if e.Auth != nil && IsChild(e.Auth) && !isPrivate(e.Record) {
+				return forbid(e)
+			}
+			return e.Next()
+		}
+		app.OnRecordCreateRequest(name).BindFunc(check)
+		app.OnRecordUpdateRequest(name).BindFunc(check)
+	}
+
+	// children have no inbox, their email address is a placeholder
+	app.OnRecordRequestEmailChangeRequest("users").BindFunc(func(e *core.RecordRequestEmailChangeRequestEvent) error {
+		if IsChild(e.Record) {
+			return e.ForbiddenError("Ask your parent to do this.", nil)
+		}
+		return e.Next()
+	})
+}
+
+// Middleware refuses children the custom routes of the social features, and
+// those their parent turned off
+func Middleware() *hook.Handler[*core.RequestEvent] {
+	return &hook.Handler[*core.RequestEvent]{
+		Id: "fushigiChildren",
+		Func: func(e *core.RequestEvent) error {
+			if e.Auth == nil || e.Auth.Collection().Name != "users" || !IsChild(e.Auth) {
+				return e.Next()
+			}
+			controls := ControlsOf(e.Auth)
+
+			method, path, _ := strings.Cut(e.Request.Pattern, " ")
+			route := method + " " + versionPrefix.ReplaceAllString(strings.TrimPrefix(path, api.BasePath), "")
+			switch {
+			case socialRoutes[route]:
+				return e.ForbiddenError("Child accounts can't use social features.", nil)
+			case accountRoutes[route]:
+				return e.ForbiddenError("Ask your parent to do this.", nil)
+			case publicContentRoutes[route] && !controls.PublicContent:
+				return e.ForbiddenError("Your parent turned off content shared by others.", nil)
+			}
+			if class, ok := ratelimit.ClassOf(e.Request.Pattern); ok && class == ratelimit.ClassAI && !controls.AI {
+				return e.ForbiddenError("Your parent turned AI features off.", nil)
+			}
+			return e.Next()
+		},
+	}
+}
+
+// involvesChild reports whether any of the users is a child account
+func involvesChild(app core.App, userIds ...string) bool {
+	for _, id := range userIds {
+		if id == "" {
+			continue
+		}
+		if user, err := app.FindRecordById("users", id); err == nil && IsChild(user) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+package children
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/passwords"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// authResponse is what switching to a child returns, as logging in does
+type authResponse struct {
+	Token  string         `json:"token"`
+	Record map[string]any `json:"record"`
+}
+
+// RegisterRoutes adds the routes parents manage their children with.
+// Children's passwords are held to the same policy as everyone's.
+func RegisterRoutes(g *api.Group, childrenService Service, policy passwords.Policy) {
+	g.GET("/children", "The child accounts the user manages, oldest first", []Child{}, func(e *core.RequestEvent) error {
+		children, err := childrenService.List(e.Auth)
+		if err := childError(e, err); err != nil {
+			return err
+		}
+		return e.JSON(200, children)
+	})
+
+	g.POST("/children", "Add a child account, which logs in with its username and only uses the features the user allows", CreateRequest{}, Child{}, func(e *core.RequestEvent) error {
+		var req CreateRequest
+		if err := e.BindBody(&req); err != nil {
+			return e.BadRequestError("Invalid request body.", err)
+		}
+		if err := req.Validate(); err != nil {
+			return e.BadRequestError("Invalid child account.", err)
+		}
+		if err := checkPassword(e, policy, req.Password, req.Name, req.Username); err != nil {
+			return err
+		}
+
+		child, err := childrenService.Create(e.Auth, req)
+		if err := childError(e, err); err != nil {
+			return err
+		}
+		return e.JSON(200, child)
+	})
+
+	g.POST("/children/{id}", "Rename a child account, set its password or change what it's allowed to do", UpdateRequest{}, Child{}, func(e *core.RequestEvent) error {
+		var req UpdateRequest
+		if err := e.BindBody(&req); err != nil {
+			return e.BadRequestError("Invalid request body.", err)
+		}
+		if err := req.Validate(); err != nil {
+			return e.BadRequestError("Invalid child account.", err)
+		}
+		if req.Password != nil {
+			child, err := childrenService.Find(e.Auth, e.Request.PathValue("id"))
+			if err := childError(e, err); err != nil {
+				return err
+			}
+			if err := checkPassword(e, policy, *req.Password, child.GetString("name"), child.GetString("username")); err != nil {
+				return err
+			}
+		}
+
+		child, err := childrenService.Update(e.Auth, e.Request.PathValue("id"), req)
+		if err := childError(e, err); err != nil {
+			return err
+		}
+		return e.JSON(200, child)
+	})
+
+	g.DELETE("/children/{id}", "Delete a child account and everything it studied", func(e *core.RequestEvent) error {
+		err := childrenService.Delete(e.Auth, e.Request.PathValue("id"))
+		if err := childError(e, err); err != nil {
+			return err
+		}
+		return e.NoContent(204)
+	})
+
+	g.POST("/children/{id}/token", "Log in as a child account, to hand a shared device over without typing its password", nil, authResponse{}, func(e *core.RequestEvent) error {
+		child, err := childrenService.Find(e.Auth, e.Request.PathValue("id"))
+		if err := childError(e, err); err != nil {
+			return err
+		}
+		return apis.RecordAuthResponse(e, child, "", nil)
+	})
+}
+
+// checkPassword holds a child's password to the policy, the way the password
+// hooks do the users' own
+func checkPassword(e *core.RequestEvent, policy passwords.Policy, password string, userInputs ...string) error {
+	errs, err := policy.Validate(e.Request.Context(), password, userInputs...)
+	if err != nil {
+		e.App.Logger().Warn("Failed to check password breaches", "error", err)
+	}
+	if errs != nil {
+		return e.BadRequestError("The password doesn't meet the password policy.", errs)
+	}
+	return nil
+}
+
+// childError turns what the service returned into the response, nil when it
+// succeeded
+func childError(e *core.RequestEvent, err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, ErrNotParent):
+		return e.ForbiddenError("Only your own account can manage child accounts.", err)
+	case errors.Is(err, ErrTooMany):
+		return e.BadRequestError("You have as many child accounts as you can.", err)
+	case errors.Is(err, ErrUsernameTaken):
+		return e.BadRequestError("Invalid child account.", validation.Errors{
+			"username": validation.NewError("validation_not_unique", "This username is taken."),
+		})
+	case errors.Is(err, sql.ErrNoRows):
+		return e.NotFoundError("", err)
+	}
+	return e.InternalServerError("Failed to save the child account.", err)
+}
@@ -0,0 +1,169 @@
+package children
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/settings"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/security"
+)
+
+// emailDomain is where children's addresses are, reserved so nothing is ever
+// delivered there (RFC 2606)
+const emailDomain = "children.fushigi.invalid"
+
+var (
+	// ErrNotParent means children were managed from an account that can't
+	// have any, a child, a sandbox or a study profile
+	ErrNotParent = errors.New("only real accounts can have children")
+
+	// ErrTooMany means the parent has MaxChildren already
+	ErrTooMany = errors.New("too many children")
+
+	// ErrUsernameTaken means another account logs in with the username
+	ErrUsernameTaken = errors.New("username taken")
+)
+
+type Service interface {
+	// List returns the parent's children, oldest first
+	List(parent *core.Record) ([]Child, error)
+
+	// Create adds a child account to the parent's, with the parent's
+	// settings but without notifications
+	Create(parent *core.Record, req CreateRequest) (Child, error)
+
+	// Update changes the name, password or controls of one of the parent's
+	// children
+	Update(parent *core.Record, id string, req UpdateRequest) (Child, error)
+
+	// Delete deletes one of the parent's children along with everything
+	// they studied
+	Delete(parent *core.Record, id string) error
+
+	// Find returns the record of one of the parent's children
+	Find(parent *core.Record, id string) (*core.Record, error)
+}
+
+type service struct {
+	app core.App
+}
+
+func NewService(app core.App) Service {
+	return &service{app: app}
+}
+
+func (s *service) List(parent *core.Record) ([]Child, error) {
+	if !canParent(parent) {
+		return nil, ErrNotParent
+	}
+	records, err := s.app.FindRecordsByFilter("users", "parent = {:parent}", "created", 0, 0, dbx.Params{"parent": parent.Id})
+	if err != nil {
+		return nil, err
+	}
+
+	children := make([]Child, 0, len(records))
+	for _, rec := range records {
+		children = append(children, FromRecord(rec))
+	}
+	return children, nil
+}
+
+func (s *service) Create(parent *core.Record, req CreateRequest) (Child, error) {
+	if !canParent(parent) {
+		return Child{}, ErrNotParent
+	}
+	controls := Controls{}
+	if req.Controls != nil {
+		controls = *req.Controls
+	}
+
+	var child *core.Record
+	err := s.app.RunInTransaction(func(txApp core.App) error {
+		total, err := txApp.CountRecords("users", dbx.HashExp{"parent": parent.Id})
+		if err != nil {
+			return err
+		}
+		if total >= MaxChildren {
+			return ErrTooMany
+		}
+		if _, err := txApp.FindFirstRecordByData("users", "username", req.Username); err == nil {
+			return ErrUsernameTaken
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		child = core.NewRecord(parent.Collection())
+		child.SetEmail("child+" + security.RandomStringWithAlphabet(15, "abcdefghijklmnopqrstuvwxyz0123456789") + "@" + emailDomain)
+		child.SetEmailVisibility(false)
+		child.SetVerified(false)
+		child.SetPassword(req.Password)
+		child.Set("name", strings.TrimSpace(req.Name))
+		child.Set("username", req.Username)
+		child.Set("parent", parent.Id)
+		child.Set("parental_controls", controls)
+		child.Set("has_password", true)
+		child.Set("rate_tier", parent.GetString("rate_tier"))
+		child.Set("storage_quota", parent.GetInt("storage_quota"))
+		if err := txApp.Save(child); err != nil {
+			return err
+		}
+
+		// the child gets the defaults like any new account if the parent
+		// has no settings
+		if _, err := settings.CreateLinked(txApp, parent.Id, child.Id, ""); err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return Child{}, err
+	}
+	return FromRecord(child), nil
+}
+
+func (s *service) Update(parent *core.Record, id string, req UpdateRequest) (Child, error) {
+	child, err := s.Find(parent, id)
+	if err != nil {
+		return Child{}, err
+	}
+
+	if req.Name != nil {
+		child.Set("name", strings.TrimSpace(*req.Name))
+	}
+	if req.Password != nil {
+		// changing the password logs the child out everywhere
+		child.SetPassword(*req.Password)
+	}
+	if req.Controls != nil {
+		child.Set("parental_controls", *req.Controls)
+	}
+	if err := s.app.Save(child); err != nil {
+		return Child{}, err
+	}
+	return FromRecord(child), nil
+}
+
+func (s *service) Delete(parent *core.Record, id string) error {
+	child, err := s.Find(parent, id)
+	if err != nil {
+		return err
+	}
+	// what the child made goes with them, every relation to users cascading
+	return s.app.Delete(child)
+}
+
+func (s *service) Find(parent *core.Record, id string) (*core.Record, error) {
+	if !canParent(parent) {
+		return nil, ErrNotParent
+	}
+	return s.app.FindFirstRecordByFilter("users", "id = {:id} && parent = {:parent}", dbx.Params{"id": id, "parent": parent.Id})
+}
+
+// canParent reports whether user is a real account, which can have children
+func canParent(user *core.Record) bool {
+	return !IsChild(user) && user.GetString("sandbox_of") == "" && user.GetString("profile_of") == ""
+}
@@ -0,0 +1,155 @@
+package children_test
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/children"
+	_ "github.com/bunkbed-tech/fushigi/pocketbase/migrations"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+// newTestApp is a migrated app
+func newTestApp(t *testing.T) *tests.TestApp {
+	t.Helper()
+	// the initial migration creates a superuser from these
+	t.Setenv("ADMIN_EMAIL", "admin@example.com")
+	t.Setenv("ADMIN_PASSWORD", "correct horse battery")
+	app, err := tests.NewTestApp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(app.Cleanup)
+	if err := app.RunAllMigrations(); err != nil {
+		t.Fatal(err)
+	}
+	return app
+}
+
+func saveUser(t *testing.T, app core.App, email string) *core.Record {
+	t.Helper()
+	users, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := core.NewRecord(users)
+	user.SetEmail(email)
+	// usernames are unique when set, so every user gets one
+	user.Set("username", strings.Split(email, "@")[0])
+	user.SetPassword("correct horse battery")
+	if err := app.Save(user); err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func TestChildren(t *testing.T) {
+	app := newTestApp(t)
+	service := children.NewService(app)
+	parent := saveUser(t, app, "parent@example.com")
+	other := saveUser(t, app, "other@example.com")
+
+	child, err := service.Create(parent, children.CreateRequest{Name: " Hana ", Username: "hana", Password: "correct horse battery"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if child.Name != "Hana" || child.Username != "hana" || child.Controls != (children.Controls{}) {
+		t.Errorf("Create() = %+v, want Hana without AI or others' content", child)
+	}
+	if _, err := service.Create(other, children.CreateRequest{Name: "Hana", Username: "hana", Password: "correct horse battery"}); !errors.Is(err, children.ErrUsernameTaken) {
+		t.Errorf("Create() with a taken username error = %v, want %v", err, children.ErrUsernameTaken)
+	}
+	if _, err := service.Create(other, children.CreateRequest{Name: "Parent", Username: "parent", Password: "correct horse battery"}); !errors.Is(err, children.ErrUsernameTaken) {
+		t.Errorf("Create() with an adult's username error = %v, want %v", err, children.ErrUsernameTaken)
+	}
+
+	record, err := service.Find(parent, child.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.List(record); !errors.Is(err, children.ErrNotParent) {
+		t.Errorf("List() of a child's children error = %v, want %v", err, children.ErrNotParent)
+	}
+	if _, err := service.Find(other, child.Id); err == nil {
+		t.Error("Find() of another parent's child succeeded, want an error")
+	}
+
+	allowed := children.Controls{AI: true}
+	updated, err := service.Update(parent, child.Id, children.UpdateRequest{Controls: &allowed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Controls != allowed || updated.Name != "Hana" {
+		t.Errorf("Update() = %+v, want Hana allowed AI", updated)
+	}
+
+	if err := service.Delete(other, child.Id); err == nil {
+		t.Error("Delete() of another parent's child succeeded, want an error")
+	}
+	if err := service.Delete(parent, child.Id); err != nil {
+		t.Fatal(err)
+	}
+	if listed, err := service.List(parent); err != nil || len(listed) != 0 {
+		t.Errorf("List() after deleting = %v, %v, want none", listed, err)
+	}
+}
+
+func TestChildrenLimit(t *testing.T) {
+	app := newTestApp(t)
+	service := children.NewService(app)
+	parent := saveUser(t, app, "parent@example.com")
+
+	for i := range children.MaxChildren {
+		if _, err := service.Create(parent, children.CreateRequest{Name: "Child", Username: "child" + string(rune('a'+i)), Password: "correct horse battery"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := service.Create(parent, children.CreateRequest{Name: "Child", Username: "onetoomany", Password: "correct horse battery"}); !errors.Is(err, children.ErrTooMany) {
+		t.Errorf("Create() past the limit error = %v, want %v", err, children.ErrTooMany)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	users := core.NewAuthCollection("users")
+	users.Fields.Add(&core.JSONField{Name: "parental_controls"})
+	child := func(controls children.Controls) *core.Record {
+		record := core.NewRecord(users)
+		record.Set("parent", "parent")
+		record.Set("parental_controls", controls)
+		return record
+	}
+
+	tests := []struct {
+		name    string
+		auth    *core.Record
+		pattern string
+		allowed bool
+	}{
+		{"adult on a social route", core.NewRecord(users), "GET /api/fushigi/v1/journal/feed", true},
+		{"social route", child(children.Controls{AI: true, PublicContent: true}), "GET /api/fushigi/v1/journal/feed", false},
+		{"unversioned social route", child(children.Controls{AI: true, PublicContent: true}), "POST /api/fushigi/tutor-sessions", false},
+		{"their own account", child(children.Controls{AI: true, PublicContent: true}), "POST /api/fushigi/v1/profiles", false},
+		{"shared content turned off", child(children.Controls{}), "POST /api/fushigi/v1/decks/import", false},
+		{"shared content allowed", child(children.Controls{PublicContent: true}), "POST /api/fushigi/v1/decks/import", true},
+		{"AI turned off", child(children.Controls{}), "POST /api/fushigi/v1/conversations", false},
+		{"AI allowed", child(children.Controls{AI: true}), "POST /api/fushigi/v1/conversations", true},
+		{"studying", child(children.Controls{}), "GET /api/fushigi/v1/srs/due", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &core.RequestEvent{}
+			e.Request = httptest.NewRequest("GET", "/", nil)
+			e.Request.Pattern = tt.pattern
+			e.Response = httptest.NewRecorder()
+			e.Auth = tt.auth
+
+			if err := children.Middleware().Func(e); (err == nil) != tt.allowed {
+				t.Errorf("Middleware() error = %v, want allowed %v", err, tt.allowed)
+			}
+		})
+	}
+}
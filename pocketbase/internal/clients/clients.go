@@ -0,0 +1,143 @@
+package clients
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// Header is how clients tell the server which build they are, e.g.
+// "ios/1.4.2". Clients that don't send it, like the dashboard, are never
+// gated.
+const Header = "Fushigi-Client"
+
+// PlatformIOS is the platform of the iOS app's builds
+const PlatformIOS = "ios"
+
+// What a client is asked to do about its build
+const (
+	UpgradeNone        = "none"
+	UpgradeRecommended = "recommended"
+	UpgradeRequired    = "required"
+)
+
+// Client is the build that sent a request
+type Client struct {
+	Platform string `json:"platform"`
+	Version  string `json:"version"`
+}
+
+// FromRequest reads the client from its header, false when there's none or
+// it's malformed
+func FromRequest(r *http.Request) (Client, bool) {
+	platform, version, ok := strings.Cut(strings.TrimSpace(r.Header.Get(Header)), "/")
+	if !ok || platform == "" {
+		return Client{}, false
+	}
+	if _, ok := parseVersion(version); !ok {
+		return Client{}, false
+	}
+	return Client{Platform: strings.ToLower(platform), Version: version}, true
+}
+
+// Requirement is the builds of a platform the server works with
+type Requirement struct {
+	Platform           string `json:"platform"`
+	MinVersion         string `json:"min_version"`
+	RecommendedVersion string `json:"recommended_version"`
+	UpdateURL          string `json:"update_url"`
+}
+
+func FromRecord(rec *core.Record) Requirement {
+	return Requirement{
+		Platform:           rec.GetString("platform"),
+		MinVersion:         rec.GetString("min_version"),
+		RecommendedVersion: rec.GetString("recommended_version"),
+		UpdateURL:          rec.GetString("update_url"),
+	}
+}
+
+// Upgrade tells what a build of version should do, builds older than the
+// minimum being refused writes
+func (r Requirement) Upgrade(version string) string {
+	switch {
+	case r.MinVersion != "" && CompareVersions(version, r.MinVersion) < 0:
+		return UpgradeRequired
+	case r.RecommendedVersion != "" && CompareVersions(version, r.RecommendedVersion) < 0:
+		return UpgradeRecommended
+	}
+	return UpgradeNone
+}
+
+// validate checks what the collection's fields can't, before it's loaded
+func validate(rec *core.Record) error {
+	req := FromRecord(rec)
+	if req.MinVersion != "" && req.RecommendedVersion != "" && CompareVersions(req.RecommendedVersion, req.MinVersion) < 0 {
+		return validation.Errors{
+			"recommended_version": validation.NewError("validation_invalid_value", "Can't be older than the minimum version."),
+		}
+	}
+	return nil
+}
+
+// IsVersion reports whether s reads as a version like "1.4.2"
+func IsVersion(s string) bool {
+	_, ok := parseVersion(s)
+	return ok
+}
+
+// parseVersion reads a version like "1.4.2", ignoring what follows a space,
+// "-" or "+" as in "1.4.2 (57)" or "1.5.0-beta"
+func parseVersion(s string) ([]int, bool) {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexAny(s, " -+"); i >= 0 {
+		s = s[:i]
+	}
+	parts := strings.Split(s, ".")
+	if len(parts) > 4 {
+		return nil, false
+	}
+	numbers := make([]int, 0, len(parts))
+	for _, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, false
+		}
+		numbers = append(numbers, n)
+	}
+	return numbers, true
+}
+
+// CompareVersions orders versions, missing parts counting as 0 so "1.4" is
+// "1.4.0". Versions that don't parse are older than any other.
+func CompareVersions(a, b string) int {
+	va, okA := parseVersion(a)
+	vb, okB := parseVersion(b)
+	switch {
+	case !okA && !okB:
+		return 0
+	case !okA:
+		return -1
+	case !okB:
+		return 1
+	}
+	for i := 0; i < max(len(va), len(vb)); i++ {
+		var x, y int
+		if i < len(va) {
+			x = va[i]
+		}
+		if i < len(vb) {
+			y = vb[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,71 @@
+package clients
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/hook"
+)
+
+// BindHooks loads the requirements at start and again whenever superusers
+// change them
+func BindHooks(app core.App, clientsService Service) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		if err := clientsService.Load(se.App); err != nil {
+			return err
+		}
+		return se.Next()
+	})
+
+	check := func(e *core.RecordEvent) error {
+		if err := validate(e.Record); err != nil {
+			return err
+		}
+		return e.Next()
+	}
+	app.OnRecordCreate("client_versions").BindFunc(check)
+	app.OnRecordUpdate("client_versions").BindFunc(check)
+
+	reload := func(e *core.RecordEvent) error {
+		if err := clientsService.Load(e.App); err != nil {
+			e.App.Logger().Error("Failed to load client versions", "error", err)
+		}
+		return e.Next()
+	}
+	app.OnRecordAfterCreateSuccess("client_versions").BindFunc(reload)
+	app.OnRecordAfterUpdateSuccess("client_versions").BindFunc(reload)
+	app.OnRecordAfterDeleteSuccess("client_versions").BindFunc(reload)
+}
+
+// Middleware refuses writes from builds older than their platform's minimum,
+// which still read so users see their data while they upgrade. The handshake
+// tells them where to.
+func Middleware(clientsService Service) *hook.Handler[*core.RequestEvent] {
+	return &hook.Handler[*core.RequestEvent]{
+		Id: "fushigiClientVersions",
+		Func: func(e *core.RequestEvent) error {
+			switch e.Request.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				return e.Next()
+			}
+			if !strings.HasPrefix(e.Request.URL.Path, "/api/") || e.HasSuperuserAuth() {
+				return e.Next()
+			}
+
+			client, ok := FromRequest(e.Request)
+			if !ok {
+				return e.Next()
+			}
+			req, ok := clientsService.Requirement(client.Platform)
+			if !ok || req.Upgrade(client.Version) != UpgradeRequired {
+				return e.Next()
+			}
+
+			e.Response.Header().Set("Link", "<"+api.BasePath+"/handshake>; rel=\"help\"")
+			return e.Error(http.StatusUpgradeRequired, "This version of the app is no longer supported, please update it.", nil)
+		},
+	}
+}
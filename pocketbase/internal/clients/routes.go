@@ -0,0 +1,19 @@
+package clients
+
+import (
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// RegisterPublicRoutes adds the handshake, which clients call before logging
+// in so old builds learn to upgrade first
+func RegisterPublicRoutes(g *api.Group, clientsService Service) {
+	g.GET("/handshake", "The API versions, client versions and capabilities of the server, and whether the build sending Fushigi-Client should upgrade", Handshake{}, func(e *core.RequestEvent) error {
+		var client *Client
+		if c, ok := FromRequest(e.Request); ok {
+			client = &c
+		}
+		return e.JSON(200, clientsService.Handshake(client))
+	})
+}
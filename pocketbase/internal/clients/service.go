@@ -0,0 +1,125 @@
+package clients
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/instance"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// Capabilities are what the environment set up, which the instance settings
+// can still turn off
+type Capabilities struct {
+	AI             bool
+	Speech         bool
+	SemanticSearch bool
+	GuestTrials    bool
+	MCP            bool
+	GraphQL        bool
+}
+
+// Handshake is what clients check at start, before anything else
+type Handshake struct {
+	// APIVersions are the versions of the custom API still served, oldest
+	// first
+	APIVersions []string `json:"api_versions"`
+
+	// Client is the build that asked, nil without the Fushigi-Client header
+	Client *Client `json:"client"`
+
+	MinVersion         string `json:"min_version"`
+	RecommendedVersion string `json:"recommended_version"`
+	UpdateURL          string `json:"update_url"`
+
+	// Upgrade is none, recommended or required, where required builds can
+	// read but not write
+	Upgrade string `json:"upgrade"`
+
+	// Capabilities are the features the server has on, e.g. "ai"
+	Capabilities map[string]bool `json:"capabilities"`
+}
+
+type Service interface {
+	// Handshake describes the server to client, which may be nil
+	Handshake(client *Client) Handshake
+
+	// Requirement returns what the server needs of the platform's builds,
+	// false when it needs nothing
+	Requirement(platform string) (Requirement, bool)
+
+	// Load reads the requirements saved, app being the one of the
+	// transaction they're saved in if any
+	Load(app core.App) error
+}
+
+type service struct {
+	app             core.App
+	instanceService instance.Service
+	capabilities    Capabilities
+
+	// platform -> requirement
+	requirements atomic.Pointer[map[string]Requirement]
+}
+
+func NewService(app core.App, instanceService instance.Service, capabilities Capabilities) Service {
+	s := &service{app: app, instanceService: instanceService, capabilities: capabilities}
+	s.requirements.Store(&map[string]Requirement{})
+	return s
+}
+
+func (s *service) Handshake(client *Client) Handshake {
+	settings := s.instanceService.Current()
+	handshake := Handshake{
+		Client:  client,
+		Upgrade: UpgradeNone,
+		Capabilities: map[string]bool{
+			"ai":              s.capabilities.AI && settings.AIFeatures,
+			"speech":          s.capabilities.Speech && settings.AIFeatures,
+			"semantic_search": s.capabilities.SemanticSearch,
+			"signups":         settings.Signups,
+			"guest_trials":    s.capabilities.GuestTrials && settings.Signups,
+			"email":           s.app.Settings().SMTP.Enabled,
+			"mcp":             s.capabilities.MCP,
+			"graphql":         s.capabilities.GraphQL,
+		},
+	}
+
+	now := time.Now()
+	for _, v := range api.Versions {
+		if v.Sunset.IsZero() || now.Before(v.Sunset) {
+			handshake.APIVersions = append(handshake.APIVersions, v.Name)
+		}
+	}
+
+	if client != nil {
+		if req, ok := s.Requirement(client.Platform); ok {
+			handshake.MinVersion = req.MinVersion
+			handshake.RecommendedVersion = req.RecommendedVersion
+			handshake.UpdateURL = req.UpdateURL
+			handshake.Upgrade = req.Upgrade(client.Version)
+		}
+	}
+	return handshake
+}
+
+func (s *service) Requirement(platform string) (Requirement, bool) {
+	req, ok := (*s.requirements.Load())[platform]
+	return req, ok
+}
+
+func (s *service) Load(app core.App) error {
+	records, err := app.FindAllRecords("client_versions")
+	if err != nil {
+		return err
+	}
+	requirements := make(map[string]Requirement, len(records))
+	for _, rec := range records {
+		req := FromRecord(rec)
+		requirements[req.Platform] = req
+	}
+	s.requirements.Store(&requirements)
+	return nil
+}
@@ -0,0 +1,118 @@
+package comparisons
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/grammar"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Bounds of the grammar compared at once
+const (
+	MinCompared = 2
+	MaxCompared = 4
+)
+
+// Comparison sets grammar that's easily confused side by side, like 〜そうだ,
+// 〜ようだ and 〜らしい. Every list of values in it has one for each grammar,
+// in the order of Grammar.
+type Comparison struct {
+	Id string `json:"id"`
+
+	// User is empty for comparisons of library grammar, which are shared
+	User    string   `json:"user"`
+	Grammar []string `json:"grammar"`
+
+	// Locale is the locale explanations are written in
+	Locale  string `json:"locale"`
+	Summary string `json:"summary"`
+
+	Aspects  []Aspect  `json:"aspects"`
+	Examples []Example `json:"examples"`
+
+	Created types.DateTime `json:"created"`
+	Updated types.DateTime `json:"updated"`
+}
+
+// Aspect is one row of the comparison table, e.g. how certain the speaker is
+type Aspect struct {
+	Aspect string   `json:"aspect"`
+	Values []string `json:"values"`
+}
+
+// Example says one situation with each grammar, a sentence left empty where
+// its grammar doesn't fit
+type Example struct {
+	Situation    string   `json:"situation"`
+	Sentences    []string `json:"sentences"`
+	Translations []string `json:"translations"`
+	Note         string   `json:"note"`
+}
+
+func FromRecord(rec *core.Record) Comparison {
+	c := Comparison{
+		Id:       rec.Id,
+		User:     rec.GetString("user"),
+		Grammar:  rec.GetStringSlice("grammar"),
+		Locale:   rec.GetString("locale"),
+		Summary:  rec.GetString("summary"),
+		Aspects:  []Aspect{},
+		Examples: []Example{},
+		Created:  rec.GetDateTime("created"),
+		Updated:  rec.GetDateTime("updated"),
+	}
+	_ = rec.UnmarshalJSONField("aspects", &c.Aspects)
+	_ = rec.UnmarshalJSONField("examples", &c.Examples)
+	c.normalize()
+	return c
+}
+
+// normalize gives every list one value per grammar, so tables line up even
+// when the model miscounts
+func (c *Comparison) normalize() {
+	if c.Aspects == nil {
+		c.Aspects = []Aspect{}
+	}
+	if c.Examples == nil {
+		c.Examples = []Example{}
+	}
+	for i := range c.Aspects {
+		c.Aspects[i].Values = fit(c.Aspects[i].Values, len(c.Grammar))
+	}
+	for i := range c.Examples {
+		c.Examples[i].Sentences = fit(c.Examples[i].Sentences, len(c.Grammar))
+		c.Examples[i].Translations = fit(c.Examples[i].Translations, len(c.Grammar))
+	}
+}
+
+// fit pads or cuts values to n
+func fit(values []string, n int) []string {
+	fitted := make([]string, n)
+	copy(fitted, values)
+	return fitted
+}
+
+// key identifies the grammar compared whatever order it was asked in
+func key(ids []string) string {
+	sorted := slices.Clone(ids)
+	slices.Sort(sorted)
+	return strings.Join(sorted, ",")
+}
+
+// hashOf fingerprints what the model is shown of the grammar, in order
+func hashOf(items []grammar.Grammar) string {
+	sum := sha256.New()
+	for _, g := range items {
+		fmt.Fprintf(sum, "%s\x00%s\x00%s\x00%s\x00%s\x00", g.Id, g.Usage, g.Meaning, g.Nuance, g.Context)
+		for _, example := range g.Examples {
+			fmt.Fprintf(sum, "%s\x00%s\x00", example.Japanese, example.English)
+		}
+	}
+	return hex.EncodeToString(sum.Sum(nil))
+}
@@ -0,0 +1,72 @@
+package comparisons
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/ai"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/grammar"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/i18n"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/prompts"
+)
+
+const (
+	// maxAspects and maxExamples bound what's kept of the model's answer
+	maxAspects  = 6
+	maxExamples = 4
+
+	// maxExamplesShown bounds the examples of each grammar sent to the model
+	maxExamplesShown = 3
+)
+
+// compareByModel has the model compare grammar in language, explaining in
+// locale, and returns the prompt template it was asked with
+func compareByModel(ctx context.Context, client ai.Client, promptsService prompts.Service, items []grammar.Grammar, language string, locale string) (Comparison, string, error) {
+	explained := i18n.LanguageNames[locale]
+	if explained == "" {
+		explained = i18n.LanguageNames[i18n.DefaultLocale]
+	}
+
+	var prompt strings.Builder
+	for i, g := range items {
+		fmt.Fprintf(&prompt, "Grammar point %d: %s\n", i+1, g.Usage)
+		for _, field := range [][2]string{{"Meaning", g.Meaning}, {"Nuance", g.Nuance}, {"Context", g.Context}} {
+			if value := strings.TrimSpace(field[1]); value != "" {
+				fmt.Fprintf(&prompt, "%s: %s\n", field[0], value)
+			}
+		}
+		for _, example := range g.Examples[:min(len(g.Examples), maxExamplesShown)] {
+			fmt.Fprintf(&prompt, "Example: %s (%s)\n", example.Japanese, example.English)
+		}
+		prompt.WriteString("\n")
+	}
+
+	rendered, err := promptsService.Render(prompts.KeyGrammarComparison, language, prompts.Data{Language: language, Explained: explained, Input: prompt.String()})
+	if err != nil {
+		return Comparison{}, "", err
+	}
+	answer, err := client.Complete(ctx, ai.Request{
+		System: rendered.System,
+		Prompt: rendered.User,
+		JSON:   true,
+	})
+	if err != nil {
+		return Comparison{}, "", err
+	}
+
+	var comparison Comparison
+	if err := json.Unmarshal([]byte(answer), &comparison); err != nil {
+		return Comparison{}, "", fmt.Errorf("unexpected comparison from the model: %w", err)
+	}
+	comparison.Summary = strings.TrimSpace(comparison.Summary)
+	comparison.Aspects = comparison.Aspects[:min(len(comparison.Aspects), maxAspects)]
+	comparison.Examples = comparison.Examples[:min(len(comparison.Examples), maxExamples)]
+	comparison.Grammar = make([]string, 0, len(items))
+	for _, g := range items {
+		comparison.Grammar = append(comparison.Grammar, g.Id)
+	}
+	comparison.normalize()
+	return comparison, rendered.Template, nil
+}
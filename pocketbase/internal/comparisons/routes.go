@@ -0,0 +1,84 @@
+package comparisons
+
+import (
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/i18n"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/jobs"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+type compareRequest struct {
+	// Grammar is the ids of the grammar to compare, in any order
+	Grammar []string `json:"grammar"`
+}
+
+func RegisterRoutes(g *api.Group, comparisonsService Service) {
+	g.GET("/grammar/comparisons", "The comparison of the grammar in ?grammar=, comma separated ids, in the request's locale, if it was compared since the grammar last changed", Comparison{}, func(e *core.RequestEvent) error {
+		ids, err := grammarIds(strings.Split(e.Request.URL.Query().Get("grammar"), ","))
+		if err != nil {
+			return e.BadRequestError("Invalid comparison request.", err)
+		}
+
+		comparison, err := comparisonsService.Find(e.Auth.Id, ids, i18n.FromRequest(e))
+		if err != nil {
+			return comparisonError(e, err)
+		}
+		return e.JSON(200, comparison)
+	})
+
+	g.POST("/grammar/comparisons", "Compare similar grammar in a background job, a table of how they differ with example sentences, unless it was compared already", compareRequest{}, jobs.Job{}, func(e *core.RequestEvent) error {
+		var body compareRequest
+		if err := e.BindBody(&body); err != nil {
+			return e.BadRequestError("Invalid request body.", err)
+		}
+		ids, err := grammarIds(body.Grammar)
+		if err != nil {
+			return e.BadRequestError("Invalid comparison request.", err)
+		}
+
+		job, err := comparisonsService.Compare(e.Auth.Id, ids, i18n.FromRequest(e))
+		if err != nil {
+			return comparisonError(e, err)
+		}
+		return e.JSON(200, job)
+	})
+}
+
+// grammarIds checks the grammar asked to be compared, ignoring repeats
+func grammarIds(raw []string) ([]string, error) {
+	var ids []string
+	for _, id := range raw {
+		if id = strings.TrimSpace(id); id != "" && !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) < MinCompared || len(ids) > MaxCompared {
+		return nil, validation.Errors{
+			"grammar": validation.NewError("validation_out_of_range", "Must be between {{.min}} and {{.max}}.").
+				SetParams(map[string]any{"min": MinCompared, "max": MaxCompared}),
+		}
+	}
+	return ids, nil
+}
+
+// comparisonError maps the errors of the comparison service to responses
+func comparisonError(e *core.RequestEvent, err error) error {
+	switch {
+	case errors.Is(err, ErrUnavailable):
+		return e.BadRequestError("Grammar comparisons aren't set up on this server.", err)
+	case errors.Is(err, ErrLanguages):
+		return e.BadRequestError("Invalid comparison request.", validation.Errors{
+			"grammar": validation.NewError("validation_invalid_value", "Grammar compared must all be of one language."),
+		})
+	case errors.Is(err, sql.ErrNoRows):
+		return e.NotFoundError("", err)
+	}
+	return e.InternalServerError("Failed to compare the grammar.", err)
+}
@@ -0,0 +1,191 @@
+package comparisons
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/ai"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/grammar"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/jobs"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/prompts"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+const JobKindComparison = "grammar_comparison"
+
+var (
+	// ErrUnavailable means the server has no model to compare with
+	ErrUnavailable = errors.New("AI features are not configured")
+
+	// ErrLanguages means the grammar compared isn't all of one language
+	ErrLanguages = errors.New("grammar compared is in different languages")
+)
+
+type Service interface {
+	// Find returns the comparison of the grammar with explanations in locale,
+	// sql.ErrNoRows when there's none or the grammar changed since
+	Find(userId string, ids []string, locale string) (Comparison, error)
+
+	// Compare has the model compare grammar the user can see in a background
+	// job, with explanations in locale, unless it already has since the
+	// grammar last changed
+	Compare(userId string, ids []string, locale string) (jobs.Job, error)
+}
+
+type service struct {
+	app            core.App
+	jobsService    jobs.Service
+	grammarService grammar.Service
+	promptsService prompts.Service
+
+	// nil when AI features aren't configured
+	client ai.Client
+}
+
+func NewService(app core.App, jobsService jobs.Service, grammarService grammar.Service, promptsService prompts.Service, client ai.Client) Service {
+	return &service{app: app, jobsService: jobsService, grammarService: grammarService, promptsService: promptsService, client: client}
+}
+
+func (s *service) Find(userId string, ids []string, locale string) (Comparison, error) {
+	items, err := s.grammar(userId, ids)
+	if err != nil {
+		return Comparison{}, err
+	}
+	rec, err := s.cached(items, locale)
+	if err != nil {
+		return Comparison{}, err
+	}
+	return FromRecord(rec), nil
+}
+
+func (s *service) Compare(userId string, ids []string, locale string) (jobs.Job, error) {
+	items, err := s.grammar(userId, ids)
+	if err != nil {
+		return jobs.Job{}, err
+	}
+	if s.client == nil {
+		// what was compared before can still be read without a model
+		if _, err := s.cached(items, locale); err != nil {
+			return jobs.Job{}, ErrUnavailable
+		}
+	}
+
+	return s.jobsService.Enqueue(userId, JobKindComparison, func(ctx context.Context, jobId string, progress jobs.Progress) (any, error) {
+		items, err := s.grammar(userId, ids)
+		if err != nil {
+			return nil, err
+		}
+		if rec, err := s.cached(items, locale); err == nil {
+			return FromRecord(rec), nil
+		}
+		if s.client == nil {
+			return nil, ErrUnavailable
+		}
+
+		names, err := s.grammarService.Languages()
+		if err != nil {
+			return nil, err
+		}
+		ctx = ai.WithSubject(ctx, ai.Subject{User: userId, Collection: "grammar_comparisons"})
+		comparison, template, err := compareByModel(ctx, s.client, s.promptsService, items, names[items[0].Language], locale)
+		if err != nil {
+			return nil, err
+		}
+		progress.Report(80, "Saving the comparison")
+
+		rec, err := s.save(items, locale, comparison, template)
+		if err != nil {
+			return nil, err
+		}
+		return FromRecord(rec), nil
+	})
+}
+
+// grammar loads the grammar compared, sorted by id so the same grammar is
+// always compared in the same order
+func (s *service) grammar(userId string, ids []string) ([]grammar.Grammar, error) {
+	sorted := slices.Clone(ids)
+	slices.Sort(sorted)
+
+	items := make([]grammar.Grammar, 0, len(sorted))
+	for _, id := range slices.Compact(sorted) {
+		g, err := s.grammarService.Find(userId, id)
+		if err != nil {
+			return nil, err
+		}
+		if len(items) > 0 && g.Language != items[0].Language {
+			return nil, ErrLanguages
+		}
+		items = append(items, g)
+	}
+	return items, nil
+}
+
+// owner is who a comparison of items belongs to, no one when it's all
+// library grammar
+func owner(items []grammar.Grammar) string {
+	for _, g := range items {
+		if !g.IsLibrary() {
+			return g.User
+		}
+	}
+	return ""
+}
+
+func (s *service) find(items []grammar.Grammar, locale string) (*core.Record, error) {
+	ids := make([]string, len(items))
+	for i, g := range items {
+		ids[i] = g.Id
+	}
+	rec := &core.Record{}
+	err := s.app.RecordQuery("grammar_comparisons").
+		AndWhere(dbx.HashExp{"user": owner(items), "key": key(ids), "locale": locale}).
+		Limit(1).
+		One(rec)
+	return rec, err
+}
+
+// cached finds the comparison of items, sql.ErrNoRows when any of them was
+// edited since it was written
+func (s *service) cached(items []grammar.Grammar, locale string) (*core.Record, error) {
+	rec, err := s.find(items, locale)
+	if err != nil {
+		return nil, err
+	}
+	if rec.GetString("hash") != hashOf(items) {
+		return nil, sql.ErrNoRows
+	}
+	return rec, nil
+}
+
+// save stores a comparison, over the one written before the grammar changed
+func (s *service) save(items []grammar.Grammar, locale string, comparison Comparison, template string) (*core.Record, error) {
+	rec, err := s.find(items, locale)
+	if errors.Is(err, sql.ErrNoRows) {
+		collection, err := s.app.FindCollectionByNameOrId("grammar_comparisons")
+		if err != nil {
+			return nil, err
+		}
+		rec = core.NewRecord(collection)
+		rec.Set("user", owner(items))
+		rec.Set("key", key(comparison.Grammar))
+		rec.Set("locale", locale)
+	} else if err != nil {
+		return nil, err
+	}
+
+	rec.Set("grammar", comparison.Grammar)
+	rec.Set("hash", hashOf(items))
+	rec.Set("summary", comparison.Summary)
+	rec.Set("aspects", comparison.Aspects)
+	rec.Set("examples", comparison.Examples)
+	rec.Set("prompt", template)
+	if err := s.app.Save(rec); err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
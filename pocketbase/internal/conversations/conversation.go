@@ -0,0 +1,92 @@
+package conversations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Conversation is a user's spoken-style practice with the model, one
+// scenario from opening line to analysis
+type Conversation struct {
+	Id       string    `json:"id"`
+	User     string    `json:"user"`
+	Scenario string    `json:"scenario"`
+	Messages []Message `json:"messages"`
+
+	// Analysis is nil until the conversation ends
+	Analysis *Analysis      `json:"analysis"`
+	Ended    types.DateTime `json:"ended"`
+
+	Created types.DateTime `json:"created"`
+	Updated types.DateTime `json:"updated"`
+}
+
+// Message is one turn, the user's or the model's (ai.RoleUser and
+// ai.RoleAssistant)
+type Message struct {
+	Role    string         `json:"role"`
+	Content string         `json:"content"`
+	Sent    types.DateTime `json:"sent"`
+}
+
+// Analysis is what a finished conversation suggests studying
+type Analysis struct {
+	// Summary is a sentence or two on how the conversation went
+	Summary string `json:"summary"`
+
+	Grammar    []GrammarSuggestion    `json:"grammar"`
+	Vocabulary []VocabularySuggestion `json:"vocabulary"`
+}
+
+// GrammarSuggestion is grammar the user could have used or got wrong
+type GrammarSuggestion struct {
+	// Grammar is the matching library grammar, empty when there's none
+	Grammar string `json:"grammar"`
+	Usage   string `json:"usage"`
+	Reason  string `json:"reason"`
+
+	// Example is how the user could have said one of their lines with it
+	Example string `json:"example"`
+}
+
+// VocabularySuggestion is a word that came up or that the user was missing
+type VocabularySuggestion struct {
+	Word    string `json:"word"`
+	Reading string `json:"reading"`
+	Meaning string `json:"meaning"`
+}
+
+// IsEnded reports whether the conversation is over, which it is once analyzed
+func (c Conversation) IsEnded() bool {
+	return !c.Ended.IsZero()
+}
+
+func FromRecord(rec *core.Record) Conversation {
+	c := Conversation{
+		Id:       rec.Id,
+		User:     rec.GetString("user"),
+		Scenario: rec.GetString("scenario"),
+		Messages: []Message{},
+		Ended:    rec.GetDateTime("ended"),
+		Created:  rec.GetDateTime("created"),
+		Updated:  rec.GetDateTime("updated"),
+	}
+	_ = rec.UnmarshalJSONField("messages", &c.Messages)
+
+	var analysis Analysis
+	if err := rec.UnmarshalJSONField("analysis", &analysis); err == nil && c.IsEnded() {
+		analysis.normalize()
+		c.Analysis = &analysis
+	}
+	return c
+}
+
+// normalize turns the lists the model left out into empty ones
+func (a *Analysis) normalize() {
+	if a.Grammar == nil {
+		a.Grammar = []GrammarSuggestion{}
+	}
+	if a.Vocabulary == nil {
+		a.Vocabulary = []VocabularySuggestion{}
+	}
+}
@@ -0,0 +1,93 @@
+package conversations
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/ai"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/i18n"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/prompts"
+)
+
+const (
+	// openingPrompt has the model speak first, the user answers its opening
+	// line
+	openingPrompt = "(Start the conversation with your first line.)"
+
+	// maxSuggestions bounds each list of an analysis
+	maxSuggestions = 5
+)
+
+// reply has the model answer the user's latest line, or open the
+// conversation when there's none
+func reply(ctx context.Context, client ai.Client, promptsService prompts.Service, scenario Scenario, language string, history []Message, line string) (string, error) {
+	if line == "" {
+		line = openingPrompt
+	}
+
+	turns := make([]ai.Message, 0, len(history))
+	for _, m := range history {
+		turns = append(turns, ai.Message{Role: m.Role, Content: m.Content})
+	}
+	if len(turns) > 0 && turns[0].Role == ai.RoleAssistant {
+		// the model opened the conversation, as prompted to
+		turns = append([]ai.Message{{Role: ai.RoleUser, Content: openingPrompt}}, turns...)
+	}
+
+	rendered, err := promptsService.Render(prompts.KeyConversationPartner, language, prompts.Data{Language: language, Setting: scenario.setting, Input: line})
+	if err != nil {
+		return "", err
+	}
+	answer, err := client.Complete(ctx, ai.Request{
+		System:  rendered.System,
+		History: turns,
+		Prompt:  rendered.User,
+	})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(answer), nil
+}
+
+// analyze has the model pick out what the user should study from a
+// conversation, with its explanations in locale, and the prompt template it
+// was asked with
+func analyze(ctx context.Context, client ai.Client, promptsService prompts.Service, language string, messages []Message, locale string) (Analysis, string, error) {
+	explained := i18n.LanguageNames[locale]
+	if explained == "" {
+		explained = i18n.LanguageNames[i18n.DefaultLocale]
+	}
+
+	var prompt strings.Builder
+	for _, m := range messages {
+		speaker := "Partner"
+		if m.Role == ai.RoleUser {
+			speaker = "Learner"
+		}
+		fmt.Fprintf(&prompt, "%s: %s\n", speaker, m.Content)
+	}
+
+	rendered, err := promptsService.Render(prompts.KeyConversationAnalysis, language, prompts.Data{Language: language, Explained: explained, Input: prompt.String()})
+	if err != nil {
+		return Analysis{}, "", err
+	}
+	answer, err := client.Complete(ctx, ai.Request{
+		System: rendered.System,
+		Prompt: rendered.User,
+		JSON:   true,
+	})
+	if err != nil {
+		return Analysis{}, "", err
+	}
+
+	var analysis Analysis
+	if err := json.Unmarshal([]byte(answer), &analysis); err != nil {
+		return Analysis{}, "", fmt.Errorf("unexpected analysis from the model: %w", err)
+	}
+	analysis.normalize()
+	analysis.Grammar = analysis.Grammar[:min(len(analysis.Grammar), maxSuggestions)]
+	analysis.Vocabulary = analysis.Vocabulary[:min(len(analysis.Vocabulary), maxSuggestions)]
+	return analysis, rendered.Template, nil
+}
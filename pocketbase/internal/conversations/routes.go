@@ -0,0 +1,120 @@
+package conversations
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/i18n"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/jobs"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// maxLineLength bounds one line the user sends
+const maxLineLength = 500
+
+type startRequest struct {
+	// Scenario is the key of a preset, ScenarioFree by default
+	Scenario string `json:"scenario"`
+}
+
+type sendRequest struct {
+	Content string `json:"content"`
+}
+
+func RegisterRoutes(g *api.Group, conversationsService Service) {
+	g.GET("/conversations/scenarios", "The scenarios conversations can play out, in the request's locale", []Scenario{}, func(e *core.RequestEvent) error {
+		return e.JSON(200, Scenarios(i18n.FromRequest(e)))
+	})
+
+	g.GET("/conversations", "The user's practice conversations, newest first", []Conversation{}, func(e *core.RequestEvent) error {
+		items, err := conversationsService.List(e.Auth.Id)
+		if err != nil {
+			return e.InternalServerError("Failed to load conversations.", err)
+		}
+		return e.JSON(200, items)
+	})
+
+	g.GET("/conversations/{id}", "One of the user's practice conversations", Conversation{}, func(e *core.RequestEvent) error {
+		c, err := conversationsService.Find(e.Auth.Id, e.Request.PathValue("id"))
+		if err != nil {
+			return e.NotFoundError("", err)
+		}
+		return e.JSON(200, c)
+	})
+
+	g.POST("/conversations", "Start a practice conversation in a scenario, with the model's opening line", startRequest{}, Conversation{}, func(e *core.RequestEvent) error {
+		var body startRequest
+		if err := e.BindBody(&body); err != nil {
+			return e.BadRequestError("Invalid request body.", err)
+		}
+		if body.Scenario == "" {
+			body.Scenario = ScenarioFree
+		}
+		scenario, ok := FindScenario(body.Scenario)
+		if !ok {
+			return e.BadRequestError("Invalid conversation request.", validation.Errors{
+				"scenario": validation.NewError("validation_invalid_value", "Invalid value."),
+			})
+		}
+
+		c, err := conversationsService.Start(e.Request.Context(), e.Auth.Id, scenario)
+		if err != nil {
+			return conversationError(e, err)
+		}
+		return e.JSON(200, c)
+	})
+
+	g.POST("/conversations/{id}/messages", "Say the next line of one of the user's conversations and get the model's reply", sendRequest{}, Conversation{}, func(e *core.RequestEvent) error {
+		var body sendRequest
+		if err := e.BindBody(&body); err != nil {
+			return e.BadRequestError("Invalid request body.", err)
+		}
+		content := strings.TrimSpace(body.Content)
+		if content == "" {
+			return e.BadRequestError("Invalid message.", validation.Errors{
+				"content": validation.NewError("validation_required", "Cannot be blank."),
+			})
+		}
+		if utf8.RuneCountInString(content) > maxLineLength {
+			return e.BadRequestError("Invalid message.", validation.Errors{
+				"content": validation.NewError("validation_length_too_long", "The length must be no more than {{.max}}.").
+					SetParams(map[string]any{"max": maxLineLength}),
+			})
+		}
+
+		c, err := conversationsService.Send(e.Request.Context(), e.Auth.Id, e.Request.PathValue("id"), content)
+		if err != nil {
+			return conversationError(e, err)
+		}
+		return e.JSON(200, c)
+	})
+
+	g.POST("/conversations/{id}/end", "End one of the user's conversations, analyzing it in a background job for grammar and vocabulary to save", nil, jobs.Job{}, func(e *core.RequestEvent) error {
+		job, err := conversationsService.End(e.Auth.Id, e.Request.PathValue("id"), i18n.FromRequest(e))
+		if err != nil {
+			return conversationError(e, err)
+		}
+		return e.JSON(200, job)
+	})
+}
+
+// conversationError maps the errors of the conversation service to responses
+func conversationError(e *core.RequestEvent, err error) error {
+	switch {
+	case errors.Is(err, ErrUnavailable):
+		return e.BadRequestError("Conversation practice isn't set up on this server.", err)
+	case errors.Is(err, ErrEnded):
+		return e.Error(http.StatusConflict, "This conversation has ended.", err)
+	case errors.Is(err, ErrFull):
+		return e.Error(http.StatusConflict, "This conversation is at its message limit, end it to see what to study.", err)
+	case errors.Is(err, sql.ErrNoRows):
+		return e.NotFoundError("", err)
+	}
+	return e.InternalServerError("Failed to talk to the model.", err)
+}
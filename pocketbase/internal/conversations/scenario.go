@@ -0,0 +1,76 @@
+package conversations
+
+import (
+	"slices"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/i18n"
+)
+
+// ScenarioFree is a conversation about whatever the user brings up
+const ScenarioFree = "free"
+
+// Scenario is a situation a conversation plays out
+type Scenario struct {
+	Key   string `json:"key"`
+	Title string `json:"title"`
+
+	// Description tells the user who they're talking to and what about
+	Description string `json:"description"`
+
+	// setting tells the model the role it plays
+	setting string
+}
+
+// scenarios are the presets conversations start from, ScenarioFree first
+var scenarios = []Scenario{
+	{
+		Key:         ScenarioFree,
+		Title:       "Free conversation",
+		Description: "Chat with a friendly acquaintance about anything.",
+		setting:     "You are a friendly acquaintance chatting with the learner about whatever they bring up. Ask about their day and interests to keep the conversation going.",
+	},
+	{
+		Key:         "ordering_food",
+		Title:       "Ordering food",
+		Description: "Order a meal from a server at a restaurant.",
+		setting:     "You are a server at a casual restaurant and the learner is a customer. Greet them, take their order, answer questions about the menu and bring the bill when they ask.",
+	},
+	{
+		Key:         "job_interview",
+		Title:       "Job interview",
+		Description: "Answer an interviewer's questions for an office job.",
+		setting:     "You are interviewing the learner for an office job. Ask one question at a time about their background, strengths and motivation, in a polite register.",
+	},
+	{
+		Key:         "asking_directions",
+		Title:       "Asking for directions",
+		Description: "Find your way to the station with a passerby's help.",
+		setting:     "You are a passerby the learner stops on the street. Help them find their way to the nearest train station, giving directions with landmarks.",
+	},
+	{
+		Key:         "hotel_check_in",
+		Title:       "Checking in at a hotel",
+		Description: "Check in at a hotel front desk and ask about the stay.",
+		setting:     "You are at the front desk of a hotel and the learner is checking in. Confirm their reservation, explain breakfast and check out times, and answer their questions.",
+	},
+}
+
+// FindScenario looks a preset up by key
+func FindScenario(key string) (Scenario, bool) {
+	i := slices.IndexFunc(scenarios, func(s Scenario) bool { return s.Key == key })
+	if i < 0 {
+		return Scenario{}, false
+	}
+	return scenarios[i], true
+}
+
+// Scenarios lists the presets with their titles and descriptions in locale
+func Scenarios(locale string) []Scenario {
+	translated := make([]Scenario, 0, len(scenarios))
+	for _, s := range scenarios {
+		s.Title = i18n.T(locale, s.Title)
+		s.Description = i18n.T(locale, s.Description)
+		translated = append(translated, s)
+	}
+	return translated
+}
@@ -0,0 +1,250 @@
+package conversations
+
+import (
+	"context"
+	"errors"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/ai"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/grammar"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/jobs"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/prompts"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/settings"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+const (
+	JobKindAnalysis = "conversation_analysis"
+
+	// MaxMessages bounds a conversation, both sides' turns counted, which
+	// all go back to the model with every line
+	MaxMessages = 60
+
+	// conversationsListed bounds the conversations listed
+	conversationsListed = 100
+
+	// defaultLanguage is what conversations are in for users who haven't
+	// picked a default language
+	defaultLanguage = "Japanese"
+)
+
+var (
+	// ErrUnavailable means the server has no model to talk to
+	ErrUnavailable = errors.New("AI features are not configured")
+
+	ErrEnded = errors.New("conversation has ended")
+	ErrFull  = errors.New("conversation is at its message limit")
+)
+
+type Service interface {
+	// Start opens a conversation in the scenario with the model's first line
+	Start(ctx context.Context, userId string, scenario Scenario) (Conversation, error)
+
+	// Send adds the user's line to one of their conversations along with the
+	// model's reply
+	Send(ctx context.Context, userId string, id string, line string) (Conversation, error)
+
+	// End has the model suggest what to study from one of the user's
+	// conversations in a background job, with explanations in locale, which
+	// ends it
+	End(userId string, id string, locale string) (jobs.Job, error)
+
+	// List returns the user's conversations, newest first
+	List(userId string) ([]Conversation, error)
+
+	// Find returns one of the user's conversations
+	Find(userId string, id string) (Conversation, error)
+}
+
+type service struct {
+	app             core.App
+	jobsService     jobs.Service
+	grammarService  grammar.Service
+	settingsService settings.Service
+	promptsService  prompts.Service
+
+	// nil when AI features aren't configured
+	client ai.Client
+}
+
+func NewService(app core.App, jobsService jobs.Service, grammarService grammar.Service, settingsService settings.Service, promptsService prompts.Service, client ai.Client) Service {
+	return &service{app: app, jobsService: jobsService, grammarService: grammarService, settingsService: settingsService, promptsService: promptsService, client: client}
+}
+
+func (s *service) Start(ctx context.Context, userId string, scenario Scenario) (Conversation, error) {
+	if s.client == nil {
+		return Conversation{}, ErrUnavailable
+	}
+
+	language, err := s.language(userId)
+	if err != nil {
+		return Conversation{}, err
+	}
+	ctx = ai.WithSubject(ctx, ai.Subject{User: userId, Collection: "conversations"})
+	opening, err := reply(ctx, s.client, s.promptsService, scenario, language, nil, "")
+	if err != nil {
+		return Conversation{}, err
+	}
+
+	collection, err := s.app.FindCollectionByNameOrId("conversations")
+	if err != nil {
+		return Conversation{}, err
+	}
+	rec := core.NewRecord(collection)
+	rec.Set("user", userId)
+	rec.Set("scenario", scenario.Key)
+	rec.Set("messages", []Message{newMessage(ai.RoleAssistant, opening)})
+	if err := s.app.Save(rec); err != nil {
+		return Conversation{}, err
+	}
+	return FromRecord(rec), nil
+}
+
+func (s *service) Send(ctx context.Context, userId string, id string, line string) (Conversation, error) {
+	if s.client == nil {
+		return Conversation{}, ErrUnavailable
+	}
+
+	rec, err := s.findOwned(userId, id)
+	if err != nil {
+		return Conversation{}, err
+	}
+	c := FromRecord(rec)
+	if c.IsEnded() {
+		return Conversation{}, ErrEnded
+	}
+	if len(c.Messages)+2 > MaxMessages {
+		return Conversation{}, ErrFull
+	}
+
+	scenario, ok := FindScenario(c.Scenario)
+	if !ok {
+		scenario, _ = FindScenario(ScenarioFree)
+	}
+	language, err := s.language(userId)
+	if err != nil {
+		return Conversation{}, err
+	}
+	ctx = ai.WithSubject(ctx, ai.Subject{User: userId, Collection: "conversations", Record: id})
+	answer, err := reply(ctx, s.client, s.promptsService, scenario, language, c.Messages, line)
+	if err != nil {
+		return Conversation{}, err
+	}
+
+	// saved only once the model answered, so a failed reply can be sent again
+	rec.Set("messages", append(c.Messages, newMessage(ai.RoleUser, line), newMessage(ai.RoleAssistant, answer)))
+	if err := s.app.Save(rec); err != nil {
+		return Conversation{}, err
+	}
+	return FromRecord(rec), nil
+}
+
+func (s *service) End(userId string, id string, locale string) (jobs.Job, error) {
+	if s.client == nil {
+		return jobs.Job{}, ErrUnavailable
+	}
+	rec, err := s.findOwned(userId, id)
+	if err != nil {
+		return jobs.Job{}, err
+	}
+	if FromRecord(rec).IsEnded() {
+		return jobs.Job{}, ErrEnded
+	}
+
+	return s.jobsService.Enqueue(userId, JobKindAnalysis, func(ctx context.Context, jobId string, progress jobs.Progress) (any, error) {
+		rec, err := s.findOwned(userId, id)
+		if err != nil {
+			return nil, err
+		}
+		c := FromRecord(rec)
+
+		language, err := s.language(userId)
+		if err != nil {
+			return nil, err
+		}
+		ctx = ai.WithSubject(ctx, ai.Subject{User: userId, Collection: "conversations", Record: id})
+		analysis, template, err := analyze(ctx, s.client, s.promptsService, language, c.Messages, locale)
+		if err != nil {
+			return nil, err
+		}
+		progress.Report(80, "Saving the analysis")
+
+		// point suggestions at the library grammar they name, so they can
+		// be studied right away
+		library, err := s.grammarService.Library()
+		if err != nil {
+			return nil, err
+		}
+		byUsage := make(map[string]string, len(library))
+		for _, g := range library {
+			byUsage[g.Usage] = g.Id
+		}
+		for i := range analysis.Grammar {
+			analysis.Grammar[i].Grammar = byUsage[analysis.Grammar[i].Usage]
+		}
+
+		// a line sent while the model was reading is kept, the analysis just
+		// doesn't cover it
+		rec, err = s.findOwned(userId, id)
+		if err != nil {
+			return nil, err
+		}
+		rec.Set("analysis", analysis)
+		rec.Set("analysis_prompt", template)
+		rec.Set("ended", types.NowDateTime())
+		if err := s.app.Save(rec); err != nil {
+			return nil, err
+		}
+		return FromRecord(rec), nil
+	})
+}
+
+func (s *service) List(userId string) ([]Conversation, error) {
+	records, err := s.app.FindRecordsByFilter("conversations", "user = {:user}", "-created", conversationsListed, 0, dbx.Params{"user": userId})
+	if err != nil {
+		return nil, err
+	}
+	items := make([]Conversation, 0, len(records))
+	for _, rec := range records {
+		items = append(items, FromRecord(rec))
+	}
+	return items, nil
+}
+
+func (s *service) Find(userId string, id string) (Conversation, error) {
+	rec, err := s.findOwned(userId, id)
+	if err != nil {
+		return Conversation{}, err
+	}
+	return FromRecord(rec), nil
+}
+
+func (s *service) findOwned(userId string, id string) (*core.Record, error) {
+	return s.app.FindFirstRecordByFilter("conversations", "id = {:id} && user = {:user}", dbx.Params{"id": id, "user": userId})
+}
+
+// language names the language the user practices, their default one
+func (s *service) language(userId string) (string, error) {
+	userSettings, err := s.settingsService.ForUser(userId)
+	if err != nil {
+		return "", err
+	}
+	if userSettings.DefaultLanguage == "" {
+		return defaultLanguage, nil
+	}
+
+	names, err := s.grammarService.Languages()
+	if err != nil {
+		return "", err
+	}
+	if name := names[userSettings.DefaultLanguage]; name != "" {
+		return name, nil
+	}
+	return defaultLanguage, nil
+}
+
+func newMessage(role string, content string) Message {
+	return Message{Role: role, Content: content, Sent: types.NowDateTime()}
+}
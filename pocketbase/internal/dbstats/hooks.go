@@ -0,0 +1,86 @@
+package dbstats
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/hook"
+)
+
+// BindHooks times the queries to the main database once it is open. The
+// logs database is left alone, writing the slow query log would feed it.
+func BindHooks(app core.App, tracker *Tracker) {
+	app.OnBootstrap().BindFunc(func(e *core.BootstrapEvent) error {
+		if err := e.Next(); err != nil {
+			return err
+		}
+
+		for _, builder := range []dbx.Builder{e.App.ConcurrentDB(), e.App.NonconcurrentDB()} {
+			if db, ok := builder.(*dbx.DB); ok {
+				instrument(e.App, db, tracker)
+			}
+		}
+		return nil
+	})
+}
+
+// instrument chains onto the log funcs PocketBase already set, which print
+// queries in dev mode
+func instrument(app core.App, db *dbx.DB, tracker *Tracker) {
+	observe := func(d time.Duration, sql string) {
+		if slow, ok := tracker.query(d, sql); ok {
+			app.Logger().Warn("Slow query", "sql", slow.SQL, "duration", slow.Duration)
+		}
+	}
+
+	queryLog := db.QueryLogFunc
+	db.QueryLogFunc = func(ctx context.Context, t time.Duration, query string, rows *sql.Rows, err error) {
+		observe(t, query)
+		if queryLog != nil {
+			queryLog(ctx, t, query, rows, err)
+		}
+	}
+
+	execLog := db.ExecLogFunc
+	db.ExecLogFunc = func(ctx context.Context, t time.Duration, query string, result sql.Result, err error) {
+		observe(t, query)
+		if execLog != nil {
+			execLog(ctx, t, query, result, err)
+		}
+	}
+}
+
+// Middleware counts the queries of every request by route. It should be
+// bound before any middleware that queries so those are counted too.
+func Middleware(tracker *Tracker) *hook.Handler[*core.RequestEvent] {
+	return &hook.Handler[*core.RequestEvent]{
+		Id: "fushigiQueryStats",
+		Func: func(e *core.RequestEvent) error {
+			start := time.Now()
+			alone := tracker.inFlight.Add(1) == 1
+			started := tracker.started.Add(1)
+			queries := tracker.queries.Load()
+
+			err := e.Next()
+
+			count := int(tracker.queries.Load() - queries)
+			alone = alone && tracker.started.Load() == started
+			tracker.inFlight.Add(-1)
+
+			// the pattern rather than the path, so /decks/{id} is one route
+			route := e.Request.Pattern
+			if route == "" {
+				route = "unmatched"
+			}
+			tracker.request(route, time.Since(start), count, alone)
+
+			if alone && count >= ManyQueries {
+				e.App.Logger().Warn("Many queries in one request", "route", route, "queries", count)
+			}
+			return err
+		},
+	}
+}
@@ -0,0 +1,186 @@
+package dbstats
+
+import (
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+const (
+	// DefaultSlowQueryMillis applies when SLOW_QUERY_MS isn't set
+	DefaultSlowQueryMillis = 100
+
+	// ManyQueries is how many queries one request runs before it gets
+	// logged, usually a lookup in a loop that should be a single query
+	ManyQueries = 50
+
+	// slowQueriesKept bounds the recent slow queries kept in memory
+	slowQueriesKept = 50
+
+	// maxSQLLength is where logged statements get cut
+	maxSQLLength = 500
+)
+
+// SlowQuery is a statement that took longer than the threshold
+type SlowQuery struct {
+	SQL      string         `json:"sql"`
+	Duration float64        `json:"duration"`
+	Created  types.DateTime `json:"created"`
+}
+
+// Endpoint sums up the requests to one route since the server started.
+// Query counts are only sampled from requests that ran alone, as queries
+// can't be told apart otherwise.
+type Endpoint struct {
+	Route      string  `json:"route"`
+	Requests   int     `json:"requests"`
+	Sampled    int     `json:"sampled"`
+	AvgQueries float64 `json:"avg_queries"`
+	MaxQueries int     `json:"max_queries"`
+	AvgTime    float64 `json:"avg_time"`
+}
+
+type endpointTotals struct {
+	requests   int
+	sampled    int
+	queries    int
+	maxQueries int
+	time       time.Duration
+}
+
+// Tracker times every query and counts them per request
+type Tracker struct {
+	threshold time.Duration
+
+	queries  atomic.Int64
+	inFlight atomic.Int64
+
+	// started counts requests, telling whether another one began while a
+	// request ran
+	started atomic.Int64
+
+	mu        sync.Mutex
+	endpoints map[string]*endpointTotals
+	slow      []SlowQuery
+}
+
+func NewTracker(threshold time.Duration) *Tracker {
+	return &Tracker{threshold: threshold, endpoints: map[string]*endpointTotals{}}
+}
+
+// ThresholdFromEnv reads the slow query threshold self-hosters configure
+// with SLOW_QUERY_MS
+func ThresholdFromEnv() time.Duration {
+	if ms, err := strconv.Atoi(os.Getenv("SLOW_QUERY_MS")); err == nil && ms > 0 {
+		return time.Duration(ms) * time.Millisecond
+	}
+	return DefaultSlowQueryMillis * time.Millisecond
+}
+
+// SlowQueries returns the recent slow queries, slowest first
+func (t *Tracker) SlowQueries() []SlowQuery {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	queries := slices.Clone(t.slow)
+	slices.SortFunc(queries, func(a, b SlowQuery) int {
+		return int(b.Duration*1000) - int(a.Duration*1000)
+	})
+	return queries
+}
+
+// HotEndpoints returns the routes running the most queries per request
+func (t *Tracker) HotEndpoints(limit int) []Endpoint {
+	t.mu.Lock()
+	endpoints := make([]Endpoint, 0, len(t.endpoints))
+	for route, totals := range t.endpoints {
+		endpoint := Endpoint{
+			Route:      route,
+			Requests:   totals.requests,
+			Sampled:    totals.sampled,
+			MaxQueries: totals.maxQueries,
+			AvgTime:    float64(totals.time.Microseconds()) / 1000 / float64(totals.requests),
+		}
+		if totals.sampled > 0 {
+			endpoint.AvgQueries = float64(totals.queries) / float64(totals.sampled)
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+	t.mu.Unlock()
+
+	slices.SortFunc(endpoints, func(a, b Endpoint) int {
+		if a.AvgQueries != b.AvgQueries {
+			if a.AvgQueries > b.AvgQueries {
+				return -1
+			}
+			return 1
+		}
+		return b.Requests - a.Requests
+	})
+	if len(endpoints) > limit {
+		endpoints = endpoints[:limit]
+	}
+	return endpoints
+}
+
+// query records one statement, reporting whether it was slow
+func (t *Tracker) query(d time.Duration, sql string) (SlowQuery, bool) {
+	t.queries.Add(1)
+	if d < t.threshold {
+		return SlowQuery{}, false
+	}
+
+	slow := SlowQuery{
+		SQL:      normalizeSQL(sql),
+		Duration: float64(d.Microseconds()) / 1000,
+		Created:  types.NowDateTime(),
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.slow) >= slowQueriesKept {
+		t.slow = t.slow[1:]
+	}
+	t.slow = append(t.slow, slow)
+	return slow, true
+}
+
+// request records a finished request. queries is only trusted when it ran
+// alone.
+func (t *Tracker) request(route string, d time.Duration, queries int, alone bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	totals, ok := t.endpoints[route]
+	if !ok {
+		totals = &endpointTotals{}
+		t.endpoints[route] = totals
+	}
+	totals.requests++
+	totals.time += d
+	if alone {
+		totals.sampled++
+		totals.queries += queries
+		totals.maxQueries = max(totals.maxQueries, queries)
+	}
+}
+
+// stringLiteral matches the values dbx inlines into the statements it logs,
+// which may be emails, tokens or journal text
+var stringLiteral = regexp.MustCompile(`'(?:[^']|'')*'`)
+
+func normalizeSQL(sql string) string {
+	sql = stringLiteral.ReplaceAllString(sql, "?")
+	sql = strings.Join(strings.Fields(sql), " ")
+	if len(sql) > maxSQLLength {
+		sql = sql[:maxSQLLength] + "…"
+	}
+	return sql
+}
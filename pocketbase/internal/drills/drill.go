@@ -0,0 +1,85 @@
+package drills
+
+import (
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/mistakes"
+)
+
+const (
+	// Blank stands in for what the learner fills in
+	Blank = "＿＿"
+
+	// maxSentenceLength keeps drills to sentences, not whole paragraphs
+	maxSentenceLength = 120
+
+	// particleChoices is how many particles a particle drill offers
+	particleChoices = 4
+)
+
+// Drill is a fill-in-the-blank exercise made from one of the user's mistakes
+type Drill struct {
+	// Id is the id of the mistake the drill was made from
+	Id       string `json:"id"`
+	Category string `json:"category"`
+
+	// Prompt is the corrected sentence with the part the user got wrong
+	// blanked out
+	Prompt string `json:"prompt"`
+	Answer string `json:"answer"`
+
+	// Mistake is what the user wrote in place of the answer, empty when they
+	// left it out
+	Mistake string `json:"mistake"`
+
+	// Choices are the answers to pick from, sorted, empty for drills the
+	// answer is typed into
+	Choices []string `json:"choices"`
+
+	// Sentence is the corrected sentence in full
+	Sentence string `json:"sentence"`
+}
+
+// newDrill blanks out what a correction changed. ok is false for
+// corrections that only took something out, which leave nothing to fill in.
+func newDrill(mistake mistakes.Mistake, original string, corrected string) (Drill, bool) {
+	prefix, removed, added, suffix := mistakes.Diff(original, corrected)
+	if added == "" || utf8.RuneCountInString(corrected) > maxSentenceLength {
+		return Drill{}, false
+	}
+
+	drill := Drill{
+		Id:       mistake.Id,
+		Category: mistake.Category,
+		Prompt:   prefix + Blank + suffix,
+		Answer:   added,
+		Mistake:  removed,
+		Choices:  []string{},
+		Sentence: strings.TrimSpace(corrected),
+	}
+	if mistake.Category == mistakes.CategoryParticle {
+		drill.Choices = particleOptions(added, removed)
+	}
+	return drill, true
+}
+
+// particleOptions are the answer, the user's mistake and the most common
+// particles to make up the rest
+func particleOptions(answer string, mistake string) []string {
+	options := []string{answer}
+	if mistake != "" && mistake != answer {
+		options = append(options, mistake)
+	}
+	for _, p := range mistakes.Particles {
+		if len(options) == particleChoices {
+			break
+		}
+		if !slices.Contains(options, p) {
+			options = append(options, p)
+		}
+	}
+	slices.Sort(options)
+	return options
+}
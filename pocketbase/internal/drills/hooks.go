@@ -0,0 +1,16 @@
+package drills
+
+import (
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// BindHooks clears out expired drill cards nightly
+func BindHooks(app core.App, drillsService Service) {
+	app.Cron().MustAdd("fushigiDrillCardsCleanup", "45 3 * * *", func() {
+		if _, err := drillsService.Expire(time.Now()); err != nil {
+			app.Logger().Error("Failed to remove expired drill cards", "error", err)
+		}
+	})
+}
@@ -0,0 +1,73 @@
+package drills
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+const (
+	defaultDrills = 10
+	maxDrills     = 50
+)
+
+type drillsResponse struct {
+	Items []Drill `json:"items"`
+}
+
+type cardsRequest struct {
+	Ids []string `json:"ids"`
+}
+
+type cardsResponse struct {
+	Added []Drill `json:"added"`
+}
+
+func RegisterRoutes(g *api.Group, drillsService Service) {
+	g.GET("/drills", "Fill-in-the-blank drills made from the user's recent mistakes, the kinds they make most first, up to ?limit= (10 by default)", drillsResponse{}, func(e *core.RequestEvent) error {
+		limit := defaultDrills
+		if raw := e.Request.URL.Query().Get("limit"); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil || n < 1 || n > maxDrills {
+				return e.BadRequestError("Invalid drills request.", validation.Errors{
+					"limit": validation.NewError("validation_out_of_range", "Must be between {{.min}} and {{.max}}.").
+						SetParams(map[string]any{"min": 1, "max": maxDrills}),
+				})
+			}
+			limit = n
+		}
+
+		drills, err := drillsService.Drills(e.Auth.Id, limit)
+		if err != nil {
+			return e.InternalServerError("Failed to load drills.", err)
+		}
+		return e.JSON(200, drillsResponse{Items: drills})
+	})
+
+	// Drill cards expire after two weeks, they're for working on a mistake
+	// while it's fresh
+	g.POST("/drills/cards", "Add the drills with the given ids to the user's reviews for two weeks", cardsRequest{}, cardsResponse{}, func(e *core.RequestEvent) error {
+		var body cardsRequest
+		if err := e.BindBody(&body); err != nil {
+			return e.BadRequestError("Invalid request body.", err)
+		}
+		if len(body.Ids) == 0 {
+			return e.BadRequestError("Invalid drill cards request.", validation.Errors{
+				"ids": validation.NewError("validation_required", "Cannot be blank."),
+			})
+		}
+
+		added, err := drillsService.AddCards(e.Auth.Id, body.Ids)
+		if errors.Is(err, ErrNoLanguage) {
+			return e.BadRequestError("Pick a default language first.", err)
+		}
+		if err != nil {
+			return e.InternalServerError("Failed to add the drill cards.", err)
+		}
+		return e.JSON(200, cardsResponse{Added: added})
+	})
+}
@@ -0,0 +1,241 @@
+package drills
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/grammar"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/mistakes"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/settings"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+const (
+	// Source marks the grammar drill cards are made from
+	Source = "drill"
+
+	// recentWeeks is how far back mistakes are drilled
+	recentWeeks = 8
+
+	// cardDays is how long a drill stays in the user's reviews
+	cardDays = 14
+
+	// drill cards start out as if learned yesterday, so they come up right
+	// away
+	cardEaseFactor   = 2.5
+	cardIntervalDays = 1
+	cardRepetition   = 1
+)
+
+var ErrNoLanguage = errors.New("no language to file drills under")
+
+type Service interface {
+	// Drills makes up to limit drills from the user's recent mistakes, the
+	// kinds they make most often first
+	Drills(userId string, limit int) ([]Drill, error)
+
+	// AddCards adds the drills made from the given mistakes to the user's
+	// reviews as cards that expire, skipping ones already added
+	AddCards(userId string, ids []string) ([]Drill, error)
+
+	// Expire removes the drill cards that expired by now
+	Expire(now time.Time) (int, error)
+}
+
+type service struct {
+	app             core.App
+	grammarService  grammar.Service
+	settingsService settings.Service
+}
+
+func NewService(app core.App, grammarService grammar.Service, settingsService settings.Service) Service {
+	return &service{app: app, grammarService: grammarService, settingsService: settingsService}
+}
+
+func (s *service) Drills(userId string, limit int) ([]Drill, error) {
+	from, _ := types.ParseDateTime(time.Now().AddDate(0, 0, -7*recentWeeks))
+	records, err := s.app.FindRecordsByFilter("errors", "user = {:user} && occurred >= {:from}", "-occurred", 0, 0, dbx.Params{"user": userId, "from": from.String()})
+	if err != nil {
+		return nil, err
+	}
+
+	made := []mistakes.Mistake{}
+	counts := map[string]int{}
+	for _, rec := range records {
+		mistake := mistakes.FromRecord(rec)
+		made = append(made, mistake)
+		counts[mistake.Category]++
+	}
+	// the stable sort keeps the newest first within a kind
+	slices.SortStableFunc(made, func(a, b mistakes.Mistake) int {
+		return counts[b.Category] - counts[a.Category]
+	})
+
+	// mistakes only keep what changed, the drills need the whole sentences
+	correctionIds := make([]string, 0, len(made))
+	for _, mistake := range made {
+		correctionIds = append(correctionIds, mistake.Correction)
+	}
+	corrections, err := s.app.FindRecordsByIds("corrections", correctionIds)
+	if err != nil {
+		return nil, err
+	}
+	byId := make(map[string]*core.Record, len(corrections))
+	for _, rec := range corrections {
+		byId[rec.Id] = rec
+	}
+
+	drills := []Drill{}
+	seen := map[string]bool{}
+	for _, mistake := range made {
+		if len(drills) == limit {
+			break
+		}
+		correction, ok := byId[mistake.Correction]
+		if !ok {
+			continue
+		}
+		drill, ok := newDrill(mistake, correction.GetString("original"), correction.GetString("corrected"))
+		if !ok || seen[drill.Prompt] {
+			continue
+		}
+		seen[drill.Prompt] = true
+		drills = append(drills, drill)
+	}
+	return drills, nil
+}
+
+func (s *service) AddCards(userId string, ids []string) ([]Drill, error) {
+	language, err := s.language(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	added := []Drill{}
+	err = s.app.RunInTransaction(func(txApp core.App) error {
+		grammarCollection, err := txApp.FindCollectionByNameOrId("grammar")
+		if err != nil {
+			return err
+		}
+		srsCollection, err := txApp.FindCollectionByNameOrId("srs")
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for _, id := range ids {
+			rec, err := txApp.FindFirstRecordByFilter("errors", "id = {:id} && user = {:user}", dbx.Params{"id": id, "user": userId})
+			if err != nil {
+				continue
+			}
+			mistake := mistakes.FromRecord(rec)
+			correction, err := txApp.FindRecordById("corrections", mistake.Correction)
+			if err != nil {
+				continue
+			}
+			drill, ok := newDrill(mistake, correction.GetString("original"), correction.GetString("corrected"))
+			if !ok {
+				continue
+			}
+
+			// the same mistake made twice is drilled once
+			existing, _ := txApp.FindFirstRecordByFilter("grammar", "user = {:user} && source = {:source} && (source_id = {:id} || usage = {:usage})", dbx.Params{"user": userId, "source": Source, "id": mistake.Id, "usage": drill.Prompt})
+			if existing != nil {
+				continue
+			}
+
+			meaning := drill.Answer
+			if drill.Mistake != "" {
+				meaning += " (not " + drill.Mistake + ")"
+			}
+			g := core.NewRecord(grammarCollection)
+			g.Set("user", userId)
+			g.Set("language", language)
+			g.Set("usage", drill.Prompt)
+			g.Set("meaning", meaning)
+			g.Set("tags", []string{Source, drill.Category})
+			g.Set("examples", []grammar.Example{{Japanese: drill.Sentence}})
+			g.Set("source", Source)
+			g.Set("source_id", mistake.Id)
+			if err := txApp.Save(g); err != nil {
+				return err
+			}
+
+			card := core.NewRecord(srsCollection)
+			card.Set("user", userId)
+			card.Set("grammar", g.Id)
+			card.Set("ease_factor", cardEaseFactor)
+			card.Set("interval_days", cardIntervalDays)
+			card.Set("repetition", cardRepetition)
+			card.Set("last_reviewed", now.AddDate(0, 0, -cardIntervalDays))
+			card.Set("expires", now.AddDate(0, 0, cardDays))
+			if err := txApp.Save(card); err != nil {
+				return err
+			}
+			added = append(added, drill)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return added, nil
+}
+
+func (s *service) Expire(now time.Time) (int, error) {
+	at, _ := types.ParseDateTime(now)
+	expired := 0
+	err := s.app.RunInTransaction(func(txApp core.App) error {
+		cards, err := txApp.FindRecordsByFilter("srs", "expires != '' && expires <= {:now}", "", 0, 0, dbx.Params{"now": at.String()})
+		if err != nil {
+			return err
+		}
+		for _, card := range cards {
+			grammarId := card.GetString("grammar")
+			if err := txApp.Delete(card); err != nil {
+				return err
+			}
+			// the grammar only existed for the drill
+			g, err := txApp.FindRecordById("grammar", grammarId)
+			if err == nil && g.GetString("source") == Source {
+				if err := txApp.Delete(g); err != nil {
+					return err
+				}
+			}
+			expired++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return expired, nil
+}
+
+// language is the user's default language, or the library's Japanese for
+// users who haven't picked one
+func (s *service) language(userId string) (string, error) {
+	userSettings, err := s.settingsService.ForUser(userId)
+	if err != nil {
+		return "", err
+	}
+	if userSettings.DefaultLanguage != "" {
+		return userSettings.DefaultLanguage, nil
+	}
+
+	names, err := s.grammarService.Languages()
+	if err != nil {
+		return "", err
+	}
+	for id, name := range names {
+		if strings.EqualFold(name, "japanese") {
+			return id, nil
+		}
+	}
+	return "", ErrNoLanguage
+}
@@ -0,0 +1,45 @@
+package emails
+
+import (
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/settings"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// BindHooks swaps the bodies of the auth emails PocketBase sends to users for
+// the customizable templates in the user's locale
+func BindHooks(app core.App, emailsService Service, settingsService settings.Service) {
+	localize := func(key string, path string) func(e *core.MailerRecordEvent) error {
+		return func(e *core.MailerRecordEvent) error {
+			token, _ := e.Meta["token"].(string)
+
+			locale := settings.DefaultLocale
+			if userSettings, err := settingsService.ForUser(e.Record.Id); err == nil && userSettings.Locale != "" {
+				locale = userSettings.Locale
+			}
+
+			meta := e.App.Settings().Meta
+			subject, body, err := emailsService.Render(key, locale, Data{
+				AppName:   meta.AppName,
+				AppURL:    meta.AppURL,
+				ActionURL: meta.AppURL + path + token,
+				Name:      e.Record.GetString("name"),
+				Email:     e.Record.Email(),
+			})
+			if err != nil {
+				// PocketBase's own message is still in place, better than nothing
+				e.App.Logger().Error("Failed to render email template", "key", key, "locale", locale, "error", err)
+				return e.Next()
+			}
+
+			e.Message.Subject = subject
+			e.Message.HTML = body
+			return e.Next()
+		}
+	}
+
+	// Same confirmation pages PocketBase links to by default
+	app.OnMailerRecordVerificationSend("users").BindFunc(localize(KeyVerification, "/_/#/auth/confirm-verification/"))
+	app.OnMailerRecordPasswordResetSend("users").BindFunc(localize(KeyPasswordReset, "/_/#/auth/confirm-password-reset/"))
+	app.OnMailerRecordEmailChangeSend("users").BindFunc(localize(KeyEmailChange, "/_/#/auth/confirm-email-change/"))
+}
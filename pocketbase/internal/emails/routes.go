@@ -0,0 +1,110 @@
+package emails
+
+import (
+	"net/mail"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+type RenderRequest struct {
+	Key    string `json:"key"`
+	Locale string `json:"locale"`
+
+	// Optional, sample values are used for anything left empty
+	Data Data `json:"data"`
+}
+
+type RenderResponse struct {
+	Locale  string `json:"locale"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+type TestSendRequest struct {
+	RenderRequest
+	To string `json:"to"`
+}
+
+// RegisterRoutes adds the superuser tools for previewing edited templates
+func RegisterRoutes(g *api.Group, emailsService Service) {
+	g.POST("/email-templates/render", "Render an email template with sample data", RenderRequest{}, RenderResponse{}, func(e *core.RequestEvent) error {
+		var req RenderRequest
+		if err := e.BindBody(&req); err != nil {
+			return e.BadRequestError("Invalid request body.", err)
+		}
+
+		tmpl, err := emailsService.Find(req.Key, req.Locale)
+		if err != nil {
+			return e.NotFoundError("No such email template.", err)
+		}
+		subject, body, err := emailsService.Render(req.Key, req.Locale, withSamples(e.App, req.Data))
+		if err != nil {
+			return e.BadRequestError("Failed to render the template.", err)
+		}
+
+		return e.JSON(200, RenderResponse{Locale: tmpl.Locale, Subject: subject, Body: body})
+	})
+
+	g.POST("/email-templates/test-send", "Send a rendered email template to an address", TestSendRequest{}, nil, func(e *core.RequestEvent) error {
+		var req TestSendRequest
+		if err := e.BindBody(&req); err != nil {
+			return e.BadRequestError("Invalid request body.", err)
+		}
+
+		to, err := mail.ParseAddress(req.To)
+		if err != nil {
+			return e.BadRequestError("Invalid recipient address.", err)
+		}
+		if _, err := emailsService.Find(req.Key, req.Locale); err != nil {
+			return e.NotFoundError("No such email template.", err)
+		}
+		if err := emailsService.Send(*to, req.Key, req.Locale, withSamples(e.App, req.Data)); err != nil {
+			return e.InternalServerError("Failed to send the email.", err)
+		}
+
+		return e.NoContent(204)
+	})
+}
+
+func withSamples(app core.App, data Data) Data {
+	meta := app.Settings().Meta
+	if data.AppName == "" {
+		data.AppName = meta.AppName
+	}
+	if data.AppURL == "" {
+		data.AppURL = meta.AppURL
+	}
+	if data.ActionURL == "" {
+		data.ActionURL = meta.AppURL + "/_/#/auth/confirm-verification/sample-token"
+	}
+	if data.Name == "" {
+		data.Name = "Sample User"
+	}
+	if data.Email == "" {
+		data.Email = "user@example.com"
+	}
+	if data.DueCount == 0 {
+		data.DueCount = 12
+	}
+	if data.UserAgent == "" {
+		data.UserAgent = "Fushigi/1.0 (iPhone; iOS 18.0)"
+	}
+	if data.IP == "" {
+		data.IP = "203.0.113.7"
+	}
+	if data.EntryCount == 0 {
+		data.EntryCount = 5
+	}
+	if data.Summary == "" {
+		data.Summary = "A steady week of writing about work and travel."
+	}
+	if data.Title == "" {
+		data.Title = "Scheduled maintenance"
+	}
+	if data.Message == "" {
+		data.Message = "The app will be unavailable on Sunday from 02:00 to 03:00 UTC while we upgrade the server."
+	}
+	return data
+}
@@ -0,0 +1,108 @@
+package emails
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+	"net/mail"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/mailer"
+)
+
+type Service interface {
+	// Find returns the variant of the template closest to locale, e.g. "ja"
+	// for "ja-JP", falling back to English
+	Find(key string, locale string) (Template, error)
+
+	// Render executes the template closest to locale with data
+	Render(key string, locale string, data Data) (subject string, body string, err error)
+
+	// Send renders the template and mails it to the given address
+	Send(to mail.Address, key string, locale string, data Data) error
+}
+
+type service struct {
+	app core.App
+}
+
+func NewService(app core.App) Service {
+	return &service{app: app}
+}
+
+func (s *service) Find(key string, locale string) (Template, error) {
+	var err error
+	for _, candidate := range localeCandidates(locale) {
+		var record *core.Record
+		record, err = s.app.FindFirstRecordByFilter(
+			"email_templates",
+			"key = {:key} && locale = {:locale}",
+			dbx.Params{"key": key, "locale": candidate},
+		)
+		if err == nil {
+			return FromRecord(record), nil
+		}
+	}
+	return Template{}, err
+}
+
+func (s *service) Render(key string, locale string, data Data) (string, string, error) {
+	tmpl, err := s.Find(key, locale)
+	if err != nil {
+		return "", "", err
+	}
+
+	subject, err := texttemplate.New("subject").Parse(tmpl.Subject)
+	if err != nil {
+		return "", "", err
+	}
+	var subjectBuf bytes.Buffer
+	if err := subject.Execute(&subjectBuf, data); err != nil {
+		return "", "", err
+	}
+
+	body, err := htmltemplate.New("body").Parse(tmpl.Body)
+	if err != nil {
+		return "", "", err
+	}
+	var bodyBuf bytes.Buffer
+	if err := body.Execute(&bodyBuf, data); err != nil {
+		return "", "", err
+	}
+
+	return subjectBuf.String(), bodyBuf.String(), nil
+}
+
+func (s *service) Send(to mail.Address, key string, locale string, data Data) error {
+	subject, body, err := s.Render(key, locale, data)
+	if err != nil {
+		return err
+	}
+
+	return s.app.NewMailClient().Send(&mailer.Message{
+		From: mail.Address{
+			Name:    s.app.Settings().Meta.SenderName,
+			Address: s.app.Settings().Meta.SenderAddress,
+		},
+		To:      []mail.Address{to},
+		Subject: subject,
+		HTML:    body,
+	})
+}
+
+// localeCandidates lists the locales to try in order, e.g. ja-JP, ja, en
+func localeCandidates(locale string) []string {
+	var candidates []string
+	if locale != "" {
+		candidates = append(candidates, locale)
+		if base, _, found := strings.Cut(locale, "-"); found {
+			candidates = append(candidates, base)
+		}
+	}
+	if locale != FallbackLocale {
+		candidates = append(candidates, FallbackLocale)
+	}
+	return candidates
+}
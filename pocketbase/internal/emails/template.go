@@ -0,0 +1,61 @@
+package emails
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// Template keys, one per kind of email
+const (
+	KeyVerification  = "verification"
+	KeyPasswordReset = "password_reset"
+	KeyEmailChange   = "email_change"
+	KeyReminder      = "reminder"
+	KeyLoginAlert    = "login_alert"
+	KeyWeeklyDigest  = "weekly_digest"
+	KeyAnnouncement  = "announcement"
+)
+
+// FallbackLocale is used when a template has no variant for the user's locale
+const FallbackLocale = "en"
+
+// Template is one locale's variant of an email. The subject is a
+// text/template and the body an html/template, both executed with Data.
+type Template struct {
+	Id      string `json:"id"`
+	Key     string `json:"key"`
+	Locale  string `json:"locale"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// Data is everything a template can reference
+type Data struct {
+	AppName   string `json:"app_name"`
+	AppURL    string `json:"app_url"`
+	ActionURL string `json:"action_url"`
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+	DueCount  int    `json:"due_count"`
+
+	// What the user did over the week, for digests
+	EntryCount int    `json:"entry_count"`
+	Summary    string `json:"summary"`
+
+	// Where a login came from, for alerts
+	UserAgent string `json:"user_agent"`
+	IP        string `json:"ip"`
+
+	// What operators announced, for announcements
+	Title   string `json:"title"`
+	Message string `json:"message"`
+}
+
+func FromRecord(rec *core.Record) Template {
+	return Template{
+		Id:      rec.Id,
+		Key:     rec.GetString("key"),
+		Locale:  rec.GetString("locale"),
+		Subject: rec.GetString("subject"),
+		Body:    rec.GetString("body"),
+	}
+}
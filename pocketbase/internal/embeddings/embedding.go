@@ -0,0 +1,108 @@
+package embeddings
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"math"
+	"strings"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/grammar"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/journal"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// What semantic search looks through
+const (
+	KindGrammar = "grammar"
+	KindJournal = "journal"
+)
+
+// collections are where each kind's records are
+var collections = map[string]string{
+	KindGrammar: "grammar",
+	KindJournal: "journal_entry",
+}
+
+// maxTextLength bounds how much of a record is embedded, long entries are
+// mostly about what they start with
+const maxTextLength = 4000
+
+// Match is a grammar or journal entry found by meaning, with how close it is
+// to the query, -1 to 1
+type Match struct {
+	Kind  string  `json:"kind"`
+	Id    string  `json:"id"`
+	Score float64 `json:"score"`
+
+	// only the one of the match's kind is set
+	Grammar *grammar.Grammar `json:"grammar,omitempty"`
+	Entry   *journal.Entry   `json:"entry,omitempty"`
+}
+
+// Text is what's embedded of a grammar or journal entry record
+func Text(rec *core.Record) string {
+	var text string
+	switch rec.Collection().Name {
+	case "grammar":
+		var parts []string
+		for _, field := range []string{"usage", "meaning", "nuance", "context"} {
+			if value := strings.TrimSpace(rec.GetString(field)); value != "" {
+				parts = append(parts, value)
+			}
+		}
+		text = strings.Join(parts, "\n")
+	case "journal_entry":
+		text = strings.TrimSpace(journal.Text(rec))
+	}
+	if runes := []rune(text); len(runes) > maxTextLength {
+		text = string(runes[:maxTextLength])
+	}
+	return text
+}
+
+func hash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// encode stores a vector scaled to unit length, so comparing two is a dot
+// product
+func encode(vector []float32) []byte {
+	var norm float64
+	for _, v := range vector {
+		norm += float64(v) * float64(v)
+	}
+	norm = math.Sqrt(norm)
+	if norm == 0 {
+		norm = 1
+	}
+
+	buf := make([]byte, 4*len(vector))
+	for i, v := range vector {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(float32(float64(v)/norm)))
+	}
+	return buf
+}
+
+func decode(buf []byte) []float32 {
+	vector := make([]float32, len(buf)/4)
+	for i := range vector {
+		vector[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[4*i:]))
+	}
+	return vector
+}
+
+// similarity is the cosine similarity of two unit vectors, 0 for vectors of
+// different sizes
+func similarity(a []float32, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+	}
+	return dot
+}
@@ -0,0 +1,42 @@
+package embeddings
+
+import (
+	"context"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// backfillLimit bounds the records embedded by one backfill run
+const backfillLimit = 500
+
+// BindHooks embeds grammar and journal entries as their text changes, drops
+// the vectors of deleted ones, and every quarter hour catches up on what
+// was saved before embeddings were set up or while the provider was down
+func BindHooks(app core.App, embeddingsService Service) {
+	queue := func(e *core.RecordEvent) error {
+		if e.Record.IsNew() || Text(e.Record) != Text(e.Record.Original()) {
+			collection := e.Record.Collection().Name
+			if _, err := embeddingsService.Queue(e.Record.GetString("user"), collection, e.Record.Id); err != nil {
+				e.App.Logger().Error("Failed to start embedding", "collection", collection, "record", e.Record.Id, "error", err)
+			}
+		}
+		return e.Next()
+	}
+	remove := func(e *core.RecordEvent) error {
+		if err := embeddingsService.Remove(e.Record.Collection().Name, e.Record.Id); err != nil {
+			e.App.Logger().Error("Failed to remove an embedding", "record", e.Record.Id, "error", err)
+		}
+		return e.Next()
+	}
+	for _, collection := range collections {
+		app.OnRecordAfterCreateSuccess(collection).BindFunc(queue)
+		app.OnRecordAfterUpdateSuccess(collection).BindFunc(queue)
+		app.OnRecordAfterDeleteSuccess(collection).BindFunc(remove)
+	}
+
+	app.Cron().MustAdd("fushigiEmbeddingsBackfill", "*/15 * * * *", func() {
+		if _, err := embeddingsService.Backfill(context.Background(), backfillLimit); err != nil {
+			app.Logger().Error("Failed to backfill embeddings", "error", err)
+		}
+	})
+}
@@ -0,0 +1,62 @@
+package embeddings
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+const (
+	defaultMatches = 10
+	maxMatches     = 50
+
+	// maxQueryLength bounds what's searched for, a description rather than
+	// a passage
+	maxQueryLength = 500
+)
+
+func RegisterRoutes(g *api.Group, embeddingsService Service) {
+	g.GET("/search/semantic", "Search the grammar the user can see and their journal entries by meaning, with ?q=, ?kind=grammar or journal and ?limit=", []Match{}, func(e *core.RequestEvent) error {
+		query := e.Request.URL.Query()
+		q := strings.TrimSpace(query.Get("q"))
+		kind := query.Get("kind")
+
+		errs := validation.Errors{}
+		if q == "" {
+			errs["q"] = validation.NewError("validation_required", "Cannot be blank.")
+		} else if utf8.RuneCountInString(q) > maxQueryLength {
+			errs["q"] = validation.NewError("validation_length_too_long", "The length must be no more than {{.max}}.").
+				SetParams(map[string]any{"max": maxQueryLength})
+		}
+		if _, ok := collections[kind]; kind != "" && !ok {
+			errs["kind"] = validation.NewError("validation_invalid_value", "Invalid value.")
+		}
+		limit := defaultMatches
+		if raw := query.Get("limit"); raw != "" {
+			parsed, err := strconv.Atoi(raw)
+			if err != nil || parsed < 1 || parsed > maxMatches {
+				errs["limit"] = validation.NewError("validation_out_of_range", "Must be between {{.min}} and {{.max}}.").
+					SetParams(map[string]any{"min": 1, "max": maxMatches})
+			}
+			limit = parsed
+		}
+		if len(errs) > 0 {
+			return e.BadRequestError("Invalid search request.", errs)
+		}
+
+		matches, err := embeddingsService.Search(e.Request.Context(), e.Auth.Id, q, kind, limit)
+		if errors.Is(err, ErrUnavailable) {
+			return e.BadRequestError("Semantic search isn't set up on this server.", err)
+		}
+		if err != nil {
+			return e.InternalServerError("Failed to search.", err)
+		}
+		return e.JSON(200, matches)
+	})
+}
@@ -0,0 +1,356 @@
+package embeddings
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/ai"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/grammar"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/jobs"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/journal"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+const (
+	JobKindEmbedding = "embedding"
+
+	// batchSize bounds the texts embedded in one request
+	batchSize = 32
+)
+
+// ErrUnavailable means the server has no embedding model
+var ErrUnavailable = errors.New("embeddings are not configured")
+
+type Service interface {
+	// Queue embeds one grammar or journal entry record in a background job.
+	// Without a model it does nothing, returning a zero job.
+	Queue(userId string, collection string, id string) (jobs.Job, error)
+
+	// Backfill embeds up to limit records that have no vector yet, or one
+	// older than the record or made by another model, returning how many
+	Backfill(ctx context.Context, limit int) (int, error)
+
+	// Remove deletes the vector of a record
+	Remove(collection string, id string) error
+
+	// Search finds the grammar the user can see and their journal entries
+	// closest in meaning to query, or only those of kind, closest first
+	Search(ctx context.Context, userId string, query string, kind string, limit int) ([]Match, error)
+
+	// Neighbors finds the grammar the user can see closest in meaning to one
+	// of them, closest first, none when it isn't embedded yet
+	Neighbors(userId string, grammarId string, limit int) ([]string, error)
+}
+
+type service struct {
+	app            core.App
+	jobsService    jobs.Service
+	grammarService grammar.Service
+
+	// nil when embeddings aren't configured
+	embedder ai.Embedder
+}
+
+func NewService(app core.App, jobsService jobs.Service, grammarService grammar.Service, embedder ai.Embedder) Service {
+	return &service{app: app, jobsService: jobsService, grammarService: grammarService, embedder: embedder}
+}
+
+func (s *service) Queue(userId string, collection string, id string) (jobs.Job, error) {
+	if s.embedder == nil {
+		return jobs.Job{}, nil
+	}
+	return s.jobsService.Enqueue(userId, JobKindEmbedding, func(ctx context.Context, jobId string, progress jobs.Progress) (any, error) {
+		rec, err := s.app.FindRecordById(collection, id)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.embed(ctx, collection, []*core.Record{rec}); err != nil {
+			return nil, err
+		}
+		return map[string]string{"collection": collection, "id": id}, nil
+	})
+}
+
+func (s *service) Backfill(ctx context.Context, limit int) (int, error) {
+	if s.embedder == nil {
+		return 0, nil
+	}
+
+	embedded := 0
+	for _, collection := range []string{"grammar", "journal_entry"} {
+		if embedded == limit {
+			break
+		}
+		var ids []string
+		err := s.app.DB().
+			Select("r.id").
+			From(collection+" r").
+			LeftJoin("_embeddings e", dbx.NewExp("e.collection = {:collection} AND e.record = r.id", dbx.Params{"collection": collection})).
+			Where(dbx.NewExp("e.record IS NULL OR e.model != {:model} OR e.updated < r.updated", dbx.Params{"model": s.embedder.Model()})).
+			Limit(int64(limit - embedded)).
+			Column(&ids)
+		if err != nil {
+			return embedded, err
+		}
+		if len(ids) == 0 {
+			continue
+		}
+
+		records, err := s.app.FindRecordsByIds(collection, ids)
+		if err != nil {
+			return embedded, err
+		}
+		if err := s.embed(ctx, collection, records); err != nil {
+			return embedded, err
+		}
+		embedded += len(records)
+	}
+	return embedded, nil
+}
+
+func (s *service) Remove(collection string, id string) error {
+	_, err := s.app.DB().Delete("_embeddings", dbx.HashExp{"collection": collection, "record": id}).Execute()
+	return err
+}
+
+func (s *service) Search(ctx context.Context, userId string, query string, kind string, limit int) ([]Match, error) {
+	if s.embedder == nil {
+		return nil, ErrUnavailable
+	}
+
+	vectors, err := s.embedder.Embed(ai.WithSubject(ctx, ai.Subject{User: userId}), []string{query})
+	if err != nil {
+		return nil, err
+	}
+	matches, err := s.nearest(userId, decode(encode(vectors[0])), kind, "", limit)
+	if err != nil {
+		return nil, err
+	}
+	return s.load(matches)
+}
+
+func (s *service) Neighbors(userId string, grammarId string, limit int) ([]string, error) {
+	if s.embedder == nil {
+		return nil, nil
+	}
+
+	var vector []byte
+	err := s.app.DB().
+		Select("vector").
+		From("_embeddings").
+		Where(dbx.HashExp{"collection": "grammar", "record": grammarId, "model": s.embedder.Model()}).
+		Row(&vector)
+	if errors.Is(err, sql.ErrNoRows) || len(vector) == 0 {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	matches, err := s.nearest(userId, decode(vector), KindGrammar, grammarId, limit)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(matches))
+	for _, m := range matches {
+		ids = append(ids, m.Id)
+	}
+	return ids, nil
+}
+
+// nearest finds the limit records the user can see closest to target, of
+// kind or of either, leaving out the one excluded
+func (s *service) nearest(userId string, target []float32, kind string, excluded string, limit int) ([]Match, error) {
+	visible := dbx.Or(
+		dbx.And(dbx.HashExp{"collection": "grammar"}, dbx.NewExp("(user = '' OR user = {:user})", dbx.Params{"user": userId})),
+		dbx.HashExp{"collection": "journal_entry", "user": userId},
+	)
+	if kind != "" {
+		visible = dbx.And(visible, dbx.HashExp{"collection": collections[kind]})
+	}
+	var rows []struct {
+		Collection string `db:"collection"`
+		Record     string `db:"record"`
+		Vector     []byte `db:"vector"`
+	}
+	err := s.app.DB().
+		Select("collection", "record", "vector").
+		From("_embeddings").
+		Where(dbx.HashExp{"model": s.embedder.Model()}).
+		AndWhere(visible).
+		AndWhere(dbx.Not(dbx.HashExp{"record": excluded})).
+		All(&rows)
+	if err != nil {
+		return nil, err
+	}
+
+	// every vector is compared, which is quick enough for what one user and
+	// the library hold
+	matches := make([]Match, 0, len(rows))
+	for _, row := range rows {
+		if len(row.Vector) == 0 {
+			continue
+		}
+		match := Match{Kind: KindGrammar, Id: row.Record, Score: similarity(target, decode(row.Vector))}
+		if row.Collection == "journal_entry" {
+			match.Kind = KindJournal
+		}
+		matches = append(matches, match)
+	}
+	slices.SortStableFunc(matches, func(a, b Match) int {
+		switch {
+		case a.Score > b.Score:
+			return -1
+		case a.Score < b.Score:
+			return 1
+		}
+		return 0
+	})
+	return matches[:min(len(matches), limit)], nil
+}
+
+// load fills in the grammar and entries matched, dropping any deleted since
+// they were embedded
+func (s *service) load(matches []Match) ([]Match, error) {
+	var grammarIds, entryIds []string
+	for _, m := range matches {
+		if m.Kind == KindGrammar {
+			grammarIds = append(grammarIds, m.Id)
+		} else {
+			entryIds = append(entryIds, m.Id)
+		}
+	}
+
+	items, err := s.grammarService.FindByIds(grammarIds)
+	if err != nil {
+		return nil, err
+	}
+	byId := make(map[string]grammar.Grammar, len(items))
+	for _, g := range items {
+		byId[g.Id] = g
+	}
+	records, err := s.app.FindRecordsByIds("journal_entry", entryIds)
+	if err != nil {
+		return nil, err
+	}
+	entries := make(map[string]journal.Entry, len(records))
+	for _, rec := range records {
+		entries[rec.Id] = journal.FromRecord(rec)
+	}
+
+	loaded := make([]Match, 0, len(matches))
+	for _, m := range matches {
+		if g, ok := byId[m.Id]; ok && m.Kind == KindGrammar {
+			m.Grammar = &g
+		} else if entry, ok := entries[m.Id]; ok && m.Kind == KindJournal {
+			m.Entry = &entry
+		} else {
+			continue
+		}
+		loaded = append(loaded, m)
+	}
+	return loaded, nil
+}
+
+// embed saves the vectors of records of one collection, only sending the
+// ones whose text changed since they were last embedded, each user's on
+// their behalf
+func (s *service) embed(ctx context.Context, collection string, records []*core.Record) error {
+	byUser := map[string][]*core.Record{}
+	texts := map[string]string{}
+	for _, rec := range records {
+		text := Text(rec)
+		texts[rec.Id] = text
+
+		var current struct {
+			Model string `db:"model"`
+			Hash  string `db:"hash"`
+		}
+		err := s.app.DB().
+			Select("model", "hash").
+			From("_embeddings").
+			Where(dbx.HashExp{"collection": collection, "record": rec.Id}).
+			One(&current)
+		if err == nil && current.Model == s.embedder.Model() && current.Hash == hash(text) {
+			// saved again without its text changing
+			if err := s.touch(collection, rec); err != nil {
+				return err
+			}
+			continue
+		}
+		if text == "" {
+			// nothing to embed, kept so backfills don't come back to it
+			if err := s.save(collection, rec, "", nil); err != nil {
+				return err
+			}
+			continue
+		}
+		byUser[rec.GetString("user")] = append(byUser[rec.GetString("user")], rec)
+	}
+
+	for userId, pending := range byUser {
+		subject := ai.WithSubject(ctx, ai.Subject{User: userId, Collection: collection})
+		for batch := range slices.Chunk(pending, batchSize) {
+			batchTexts := make([]string, len(batch))
+			for i, rec := range batch {
+				batchTexts[i] = texts[rec.Id]
+			}
+			vectors, err := s.embedder.Embed(subject, batchTexts)
+			if err != nil {
+				return err
+			}
+			for i, rec := range batch {
+				if err := s.save(collection, rec, batchTexts[i], encode(vectors[i])); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (s *service) save(collection string, rec *core.Record, text string, vector []byte) error {
+	if vector == nil {
+		vector = []byte{}
+	}
+	_, err := s.app.DB().NewQuery(`
+		INSERT INTO _embeddings (collection, record, user, model, hash, vector, updated)
+		VALUES ({:collection}, {:record}, {:user}, {:model}, {:hash}, {:vector}, {:updated})
+		ON CONFLICT (collection, record) DO UPDATE SET
+			user = excluded.user,
+			model = excluded.model,
+			hash = excluded.hash,
+			vector = excluded.vector,
+			updated = excluded.updated
+	`).Bind(dbx.Params{
+		"collection": collection,
+		"record":     rec.Id,
+		"user":       rec.GetString("user"),
+		"model":      s.embedder.Model(),
+		"hash":       hash(text),
+		"vector":     vector,
+		"updated":    updatedOf(rec),
+	}).Execute()
+	return err
+}
+
+// touch marks a vector as up to date with its record
+func (s *service) touch(collection string, rec *core.Record) error {
+	_, err := s.app.DB().Update("_embeddings", dbx.Params{"updated": updatedOf(rec), "user": rec.GetString("user")}, dbx.HashExp{"collection": collection, "record": rec.Id}).Execute()
+	return err
+}
+
+// updatedOf is when the record was last saved, as stored, so backfills can
+// compare the two
+func updatedOf(rec *core.Record) string {
+	updated := rec.GetDateTime("updated")
+	if updated.IsZero() {
+		updated = types.NowDateTime()
+	}
+	return updated.String()
+}
@@ -0,0 +1,83 @@
+package events
+
+import (
+	"sync"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/hook"
+)
+
+// The domain events record hooks publish, for subsystems to react to without
+// binding to the collections they come from
+const (
+	// GrammarCreated is grammar a user wrote themselves, Record the grammar
+	GrammarCreated = "grammar.created"
+
+	// ReviewCompleted is a review of a card, Record its review_log row
+	ReviewCompleted = "review.completed"
+
+	// CardAdded, CardRescheduled and CardRemoved are a card created, falling
+	// due at another time, by a review or being resurrected, and deleted,
+	// Record the srs card
+	CardAdded       = "card.added"
+	CardRescheduled = "card.rescheduled"
+	CardRemoved     = "card.removed"
+
+	// EntryWritten is a journal entry created or with its text edited,
+	// Record the entry
+	EntryWritten = "entry.written"
+
+	// EntryPublished is a journal entry shown to anyone past its writer for
+	// the first time, Record the entry
+	EntryPublished = "entry.published"
+
+	// SessionCompleted is a guided study session stopped having reached its
+	// target, Record the session
+	SessionCompleted = "session.completed"
+)
+
+// Event is a domain event about a user's record. Subscribers call e.Next()
+// like any PocketBase hook handler.
+type Event struct {
+	hook.Event
+
+	App    core.App
+	Name   string
+	User   string
+	Record *core.Record
+}
+
+// Bus passes domain events from the hooks publishing them to the subscribers
+// of each
+type Bus struct {
+	mu    sync.Mutex
+	hooks map[string]*hook.Hook[*Event]
+}
+
+func NewBus() *Bus {
+	return &Bus{hooks: map[string]*hook.Hook[*Event]{}}
+}
+
+// On returns the hook to subscribe to an event with
+func (b *Bus) On(name string) *hook.Hook[*Event] {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	h, ok := b.hooks[name]
+	if !ok {
+		h = &hook.Hook[*Event]{}
+		b.hooks[name] = h
+	}
+	return h
+}
+
+// Publish runs the subscribers of an event about a user's record, in the
+// order they subscribed
+func (b *Bus) Publish(app core.App, name string, record *core.Record) error {
+	return b.On(name).Trigger(&Event{
+		App:    app,
+		Name:   name,
+		User:   record.GetString("user"),
+		Record: record,
+	})
+}
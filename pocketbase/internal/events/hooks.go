@@ -0,0 +1,72 @@
+package events
+
+import (
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/journal"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// BindHooks publishes the domain events as the records they're about are
+// saved. A subscriber failing is logged, the save it follows already happened.
+func BindHooks(app core.App, bus *Bus) {
+	publish := func(e *core.RecordEvent, name string) {
+		if err := bus.Publish(e.App, name, e.Record); err != nil {
+			e.App.Logger().Error("Failed to handle an event", "event", name, "record", e.Record.Id, "error", err)
+		}
+	}
+
+	app.OnRecordAfterCreateSuccess("grammar").BindFunc(func(e *core.RecordEvent) error {
+		// library grammar belongs to no one
+		if e.Record.GetString("user") != "" {
+			publish(e, GrammarCreated)
+		}
+		return e.Next()
+	})
+
+	app.OnRecordAfterCreateSuccess("review_log").BindFunc(func(e *core.RecordEvent) error {
+		publish(e, ReviewCompleted)
+		return e.Next()
+	})
+
+	app.OnRecordAfterCreateSuccess("srs").BindFunc(func(e *core.RecordEvent) error {
+		publish(e, CardAdded)
+		return e.Next()
+	})
+	app.OnRecordAfterUpdateSuccess("srs").BindFunc(func(e *core.RecordEvent) error {
+		if !e.Record.GetDateTime("due_date").Equal(e.Record.Original().GetDateTime("due_date")) {
+			publish(e, CardRescheduled)
+		}
+		return e.Next()
+	})
+	app.OnRecordAfterDeleteSuccess("srs").BindFunc(func(e *core.RecordEvent) error {
+		publish(e, CardRemoved)
+		return e.Next()
+	})
+
+	written := func(e *core.RecordEvent) error {
+		if journal.TextChanged(e.Record) {
+			publish(e, EntryWritten)
+		}
+		return e.Next()
+	}
+	app.OnRecordAfterCreateSuccess("journal_entry").BindFunc(written)
+	app.OnRecordAfterUpdateSuccess("journal_entry").BindFunc(written)
+
+	published := func(e *core.RecordEvent) error {
+		// a created entry has no original audience
+		before := e.Record.Original().GetString("audience")
+		if (before == "" || before == journal.AudiencePrivate) && e.Record.GetString("audience") != journal.AudiencePrivate {
+			publish(e, EntryPublished)
+		}
+		return e.Next()
+	}
+	app.OnRecordAfterCreateSuccess("journal_entry").BindFunc(published)
+	app.OnRecordAfterUpdateSuccess("journal_entry").BindFunc(published)
+
+	app.OnRecordAfterUpdateSuccess("sessions").BindFunc(func(e *core.RecordEvent) error {
+		if e.Record.GetBool("completed") && !e.Record.Original().GetBool("completed") {
+			publish(e, SessionCompleted)
+		}
+		return e.Next()
+	})
+}
@@ -0,0 +1,125 @@
+package exports
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/grammar"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/i18n"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/journal"
+)
+
+// book is a journal export laid out independently of the output format
+type book struct {
+	Title    string
+	Author   string
+	Locale   string
+	Chapters []chapter
+
+	// GrammarHeading titles the grammar list under each chapter
+	GrammarHeading string
+
+	// CorrectionsHeading titles the corrections under each chapter
+	CorrectionsHeading string
+}
+
+// chapter is one journal entry
+type chapter struct {
+	Title      string
+	Date       string
+	Audience   string
+	Paragraphs []paragraph
+	Grammar    []grammarNote
+
+	// Corrections are only loaded when the export asks for them
+	Corrections []journal.Correction
+
+	// Tags are the tags of the grammar practiced in the entry
+	Tags []string
+
+	// Mood is how the writer felt, empty when they didn't say
+	Mood string
+}
+
+// grammarNote is a grammar point practiced in an entry, with the sentence
+// that practiced it
+type grammarNote struct {
+	Usage    string
+	Meaning  string
+	Sentence string
+}
+
+// newBook lays out the entries. Readings are the furigana printed over the
+// entries, leave them and corrections empty to export entries as written.
+func newBook(author string, locale string, loc *time.Location, entries []journal.Entry, sentences map[string][]journal.Sentence, grammarById map[string]grammar.Grammar, corrections map[string][]journal.Correction, readings map[string]string) book {
+	b := book{
+		Title:              i18n.T(locale, "Journal"),
+		Author:             author,
+		Locale:             locale,
+		Chapters:           make([]chapter, 0, len(entries)),
+		GrammarHeading:     i18n.T(locale, "Grammar used"),
+		CorrectionsHeading: i18n.T(locale, "Corrections"),
+	}
+
+	if len(entries) > 0 {
+		first := entries[0].Created.Time().In(loc).Format(dateLayout)
+		last := entries[len(entries)-1].Created.Time().In(loc).Format(dateLayout)
+		if first == last {
+			b.Title += " " + first
+		} else {
+			b.Title += " " + first + " – " + last
+		}
+	}
+
+	for _, entry := range entries {
+		ch := chapter{
+			Title:       entry.Title,
+			Date:        entry.Created.Time().In(loc).Format(dateLayout),
+			Audience:    entry.Audience,
+			Mood:        entry.Mood,
+			Corrections: corrections[entry.Id],
+		}
+		for _, p := range paragraphs(entry.Content) {
+			ch.Paragraphs = append(ch.Paragraphs, furigana(p, readings))
+		}
+		if ch.Title == "" {
+			ch.Title = ch.Date
+		}
+
+		for _, sentence := range sentences[entry.Id] {
+			g, ok := grammarById[sentence.Grammar]
+			if !ok {
+				continue
+			}
+			ch.Grammar = append(ch.Grammar, grammarNote{
+				Usage:    g.Usage,
+				Meaning:  g.Meaning,
+				Sentence: sentence.Content,
+			})
+			for _, tag := range g.Tags {
+				if !slices.Contains(ch.Tags, tag) {
+					ch.Tags = append(ch.Tags, tag)
+				}
+			}
+		}
+
+		b.Chapters = append(b.Chapters, ch)
+	}
+
+	return b
+}
+
+// paragraphs splits entry content on blank lines, keeping single line breaks
+// inside a paragraph
+func paragraphs(content string) []string {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+
+	var result []string
+	for _, p := range strings.Split(content, "\n\n") {
+		if p = strings.TrimSpace(p); p != "" {
+			result = append(result, p)
+		}
+	}
+	return result
+}
@@ -0,0 +1,201 @@
+package exports
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"html/template"
+	"time"
+)
+
+// xmlDeclaration is written ahead of the templates, html/template would
+// escape it
+const xmlDeclaration = `<?xml version="1.0" encoding="UTF-8"?>
+`
+
+const epubContainer = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+`
+
+const epubStyle = `body { font-family: serif; line-height: 1.7; }
+h1 { font-size: 1.6em; margin-bottom: 0.2em; }
+.date { color: #808080; font-size: 0.8em; margin-top: 0; }
+.title-page { text-align: center; margin-top: 30%; }
+.grammar, .corrections { margin-top: 2em; border-top: 1px solid #c0c0c0; }
+.grammar h2, .corrections h2 { font-size: 1.1em; }
+.grammar dd, .corrections dd { color: #606060; font-size: 0.9em; margin-bottom: 0.6em; }
+.corrections del { color: #a04040; }
+.corrections ins { color: #407040; text-decoration: none; }
+rt { font-size: 0.5em; }
+`
+
+func chapterFile(i int) string {
+	return fmt.Sprintf("chapter-%04d.xhtml", i+1)
+}
+
+var epubTemplates = template.Must(template.New("epub").
+	Funcs(template.FuncMap{"chapterFile": chapterFile}).
+	Parse(`{{define "package"}}<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="book-id" xml:lang="{{.Book.Locale}}">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="book-id">{{.Identifier}}</dc:identifier>
+    <dc:title>{{.Book.Title}}</dc:title>
+    <dc:language>{{.Book.Locale}}</dc:language>
+    {{- if .Book.Author}}
+    <dc:creator>{{.Book.Author}}</dc:creator>
+    {{- end}}
+    <meta property="dcterms:modified">{{.Modified}}</meta>
+  </metadata>
+  <manifest>
+    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+    <item id="style" href="style.css" media-type="text/css"/>
+    <item id="title" href="title.xhtml" media-type="application/xhtml+xml"/>
+    {{- range $i, $ch := .Book.Chapters}}
+    <item id="chapter-{{$i}}" href="{{chapterFile $i}}" media-type="application/xhtml+xml"/>
+    {{- end}}
+  </manifest>
+  <spine>
+    <itemref idref="title"/>
+    {{- range $i, $ch := .Book.Chapters}}
+    <itemref idref="chapter-{{$i}}"/>
+    {{- end}}
+  </spine>
+</package>
+{{end}}
+{{define "nav"}}<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" xml:lang="{{.Locale}}" lang="{{.Locale}}">
+<head><title>{{.Title}}</title></head>
+<body>
+  <nav epub:type="toc">
+    <h1>{{.Title}}</h1>
+    <ol>
+      {{- range $i, $ch := .Chapters}}
+      <li><a href="{{chapterFile $i}}">{{$ch.Title}}</a></li>
+      {{- end}}
+    </ol>
+  </nav>
+</body>
+</html>
+{{end}}
+{{define "title"}}<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xml:lang="{{.Locale}}" lang="{{.Locale}}">
+<head><title>{{.Title}}</title><link rel="stylesheet" type="text/css" href="style.css"/></head>
+<body>
+  <div class="title-page">
+    <h1>{{.Title}}</h1>
+    {{- if .Author}}
+    <p>{{.Author}}</p>
+    {{- end}}
+  </div>
+</body>
+</html>
+{{end}}
+{{define "chapter"}}<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xml:lang="{{.Locale}}" lang="{{.Locale}}">
+<head><title>{{.Chapter.Title}}</title><link rel="stylesheet" type="text/css" href="style.css"/></head>
+<body>
+  <h1>{{.Chapter.Title}}</h1>
+  {{- if ne .Chapter.Title .Chapter.Date}}
+  <p class="date">{{.Chapter.Date}}</p>
+  {{- end}}
+  {{- range .Chapter.Paragraphs}}
+  <p>{{range .}}{{if .Ruby}}<ruby>{{.Text}}<rt>{{.Ruby}}</rt></ruby>{{else}}{{.Text}}{{end}}{{end}}</p>
+  {{- end}}
+  {{- if .Chapter.Grammar}}
+  <section class="grammar">
+    <h2>{{.GrammarHeading}}</h2>
+    <dl>
+      {{- range .Chapter.Grammar}}
+      <dt>{{.Usage}} — {{.Meaning}}</dt>
+      <dd>{{.Sentence}}</dd>
+      {{- end}}
+    </dl>
+  </section>
+  {{- end}}
+  {{- if .Chapter.Corrections}}
+  <section class="corrections">
+    <h2>{{.CorrectionsHeading}}</h2>
+    <dl>
+      {{- range .Chapter.Corrections}}
+      <dt><del>{{.Original}}</del> → <ins>{{.Corrected}}</ins></dt>
+      {{- if .Comment}}
+      <dd>{{.Comment}}</dd>
+      {{- end}}
+      {{- end}}
+    </dl>
+  </section>
+  {{- end}}
+</body>
+</html>
+{{end}}
+`))
+
+type epubFile struct {
+	name string
+
+	// either a template to execute with data, or raw content
+	template string
+	data     any
+	raw      string
+}
+
+// renderEPUB packs the book as an EPUB 3, with a page per entry. identifier
+// must be unique to the export.
+func renderEPUB(b book, identifier string, modified time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	// the mimetype must come first and uncompressed so readers can sniff it
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write([]byte("application/epub+zip")); err != nil {
+		return nil, err
+	}
+
+	files := []epubFile{
+		{name: "META-INF/container.xml", raw: epubContainer},
+		{name: "OEBPS/style.css", raw: epubStyle},
+		{name: "OEBPS/content.opf", template: "package", data: map[string]any{
+			"Book":       b,
+			"Identifier": identifier,
+			"Modified":   modified.UTC().Format("2006-01-02T15:04:05Z"),
+		}},
+		{name: "OEBPS/nav.xhtml", template: "nav", data: b},
+		{name: "OEBPS/title.xhtml", template: "title", data: b},
+	}
+	for i, ch := range b.Chapters {
+		files = append(files, epubFile{name: "OEBPS/" + chapterFile(i), template: "chapter", data: map[string]any{
+			"Locale":             b.Locale,
+			"Chapter":            ch,
+			"GrammarHeading":     b.GrammarHeading,
+			"CorrectionsHeading": b.CorrectionsHeading,
+		}})
+	}
+
+	for _, f := range files {
+		w, err := zw.Create(f.name)
+		if err != nil {
+			return nil, err
+		}
+		if f.template == "" {
+			_, err = w.Write([]byte(f.raw))
+		} else {
+			if _, err = w.Write([]byte(xmlDeclaration)); err == nil {
+				err = epubTemplates.ExecuteTemplate(w, f.template, f.data)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", f.name, err)
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,122 @@
+package exports
+
+import (
+	"os"
+	"slices"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+const (
+	FormatPDF      = "pdf"
+	FormatEPUB     = "epub"
+	FormatMarkdown = "markdown"
+)
+
+// Formats are the formats a journal can be exported to
+var Formats = []string{FormatPDF, FormatEPUB, FormatMarkdown}
+
+// Finished exports can be downloaded for this long before they're deleted
+const exportTTL = 7 * 24 * time.Hour
+
+// dateLayout is how the bounds of an export are given
+const dateLayout = "2006-01-02"
+
+// Export is a file produced by an export job
+type Export struct {
+	Id      string         `json:"id"`
+	User    string         `json:"user"`
+	Job     string         `json:"job"`
+	Format  string         `json:"format"`
+	File    string         `json:"file"`
+	Created types.DateTime `json:"created"`
+}
+
+func FromRecord(rec *core.Record) Export {
+	file := ""
+	if name := rec.GetString("file"); name != "" {
+		// protected, clients append a ?token= from /api/files/token
+		file = "/api/files/" + rec.Collection().Id + "/" + rec.Id + "/" + name
+	}
+
+	return Export{
+		Id:      rec.Id,
+		User:    rec.GetString("user"),
+		Job:     rec.GetString("job"),
+		Format:  rec.GetString("format"),
+		File:    file,
+		Created: rec.GetDateTime("created"),
+	}
+}
+
+// JournalRequest picks the entries of a journal export. From and To are
+// inclusive dates in the user's time zone, either may be left out.
+type JournalRequest struct {
+	Format string `json:"format"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+
+	// IncludeGrammar lists the grammar practiced under each entry. Markdown
+	// exports always have it in their frontmatter.
+	IncludeGrammar bool `json:"include_grammar"`
+
+	// IncludeCorrections lists the corrections each entry received under it
+	IncludeCorrections bool `json:"include_corrections"`
+
+	// IncludeFurigana prints readings over the words of the user's vocabulary
+	IncludeFurigana bool `json:"include_furigana"`
+}
+
+func (r JournalRequest) Validate() error {
+	errs := validation.Errors{}
+
+	if !slices.Contains(Formats, r.Format) {
+		errs["format"] = validation.NewError("validation_invalid_value", "Invalid value.")
+	}
+
+	from, fromErr := time.Parse(dateLayout, r.From)
+	if r.From != "" && fromErr != nil {
+		errs["from"] = validation.NewError("validation_invalid_date", "Must be a date like 2025-01-31.")
+	}
+	to, toErr := time.Parse(dateLayout, r.To)
+	if r.To != "" && toErr != nil {
+		errs["to"] = validation.NewError("validation_invalid_date", "Must be a date like 2025-01-31.")
+	}
+	if r.From != "" && r.To != "" && fromErr == nil && toErr == nil && to.Before(from) {
+		errs["to"] = validation.NewError("validation_invalid_date_range", "Must not be before the start date.")
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// bounds turns the request dates into the [from, to) range of creation times
+// to export, zero where the request leaves a side open
+func (r JournalRequest) bounds(loc *time.Location) (types.DateTime, types.DateTime) {
+	var from, to types.DateTime
+	if t, err := time.ParseInLocation(dateLayout, r.From, loc); err == nil {
+		from, _ = types.ParseDateTime(t)
+	}
+	if t, err := time.ParseInLocation(dateLayout, r.To, loc); err == nil {
+		to, _ = types.ParseDateTime(t.AddDate(0, 0, 1))
+	}
+	return from, to
+}
+
+// fontFile is the TrueType font PDFs are typeset in. The PDF core fonts have
+// no Japanese glyphs, so PDF exports are unavailable until one is configured.
+func fontFile() string {
+	return os.Getenv("EXPORT_FONT_FILE")
+}
+
+func fileExtension(format string) string {
+	if format == FormatMarkdown {
+		return "zip"
+	}
+	return format
+}
@@ -0,0 +1,127 @@
+package exports
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// segment is a run of text, with the reading printed over it when it has one
+type segment struct {
+	Text string
+	Ruby string
+}
+
+// paragraph is a paragraph of an entry, split where readings go
+type paragraph []segment
+
+// inline writes the readings in brackets after their words, for formats that
+// can't print them over the text
+func (p paragraph) inline() string {
+	var sb strings.Builder
+	for _, seg := range p {
+		sb.WriteString(seg.Text)
+		if seg.Ruby != "" {
+			sb.WriteString("（" + seg.Ruby + "）")
+		}
+	}
+	return sb.String()
+}
+
+// readings maps the words the user added or studies to their readings. Only
+// words with kanji in them need one.
+func (s *service) readings(userId string) (map[string]string, error) {
+	var records []*core.Record
+	err := s.app.RecordQuery("vocabulary").
+		AndWhere(dbx.NewExp("reading != ''")).
+		AndWhere(dbx.NewExp(
+			"user = {:user} OR id IN (SELECT vocabulary FROM srs WHERE user = {:user} AND item_type = 'vocabulary')",
+			dbx.Params{"user": userId},
+		)).
+		All(&records)
+	if err != nil {
+		return nil, err
+	}
+
+	readings := make(map[string]string, len(records))
+	for _, rec := range records {
+		term := strings.TrimSpace(rec.GetString("term"))
+		if strings.ContainsFunc(term, isKanji) {
+			readings[term] = strings.TrimSpace(rec.GetString("reading"))
+		}
+	}
+	return readings, nil
+}
+
+// furigana splits text where the words readings has are, the longest word
+// winning where they overlap
+func furigana(text string, readings map[string]string) paragraph {
+	if len(readings) == 0 {
+		return paragraph{{Text: text}}
+	}
+
+	longest := 0
+	for term := range readings {
+		longest = max(longest, utf8.RuneCountInString(term))
+	}
+
+	var p paragraph
+	runes := []rune(text)
+	plain := 0
+	for i := 0; i < len(runes); {
+		n := 0
+		for l := min(longest, len(runes)-i); l > 0; l-- {
+			if _, ok := readings[string(runes[i:i+l])]; ok {
+				n = l
+				break
+			}
+		}
+		if n == 0 {
+			i++
+			continue
+		}
+
+		if plain < i {
+			p = append(p, segment{Text: string(runes[plain:i])})
+		}
+		p = append(p, ruby(string(runes[i:i+n]), readings[string(runes[i:i+n])])...)
+		i += n
+		plain = i
+	}
+	if plain < len(runes) {
+		p = append(p, segment{Text: string(runes[plain:])})
+	}
+	return p
+}
+
+// ruby puts the reading over the kanji of a word only, leaving the kana it
+// starts or ends with, like the る of 食べる, as they are
+func ruby(term string, reading string) []segment {
+	t, r := []rune(term), []rune(reading)
+
+	head := 0
+	for head < len(t) && head < len(r) && t[head] == r[head] && !isKanji(t[head]) {
+		head++
+	}
+	tail := 0
+	for tail < len(t)-head && tail < len(r)-head && t[len(t)-1-tail] == r[len(r)-1-tail] && !isKanji(t[len(t)-1-tail]) {
+		tail++
+	}
+
+	var segments []segment
+	if head > 0 {
+		segments = append(segments, segment{Text: string(t[:head])})
+	}
+	segments = append(segments, segment{Text: string(t[head : len(t)-tail]), Ruby: string(r[head : len(r)-tail])})
+	if tail > 0 {
+		segments = append(segments, segment{Text: string(t[len(t)-tail:])})
+	}
+	return segments
+}
+
+func isKanji(r rune) bool {
+	return unicode.Is(unicode.Han, r)
+}
@@ -0,0 +1,212 @@
+package exports
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/grammar"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/i18n"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/srs"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+const (
+	GrammarFormatCSV      = "csv"
+	GrammarFormatMarkdown = "markdown"
+)
+
+// Study statuses of the grammar in a reference export
+const (
+	StatusNotStudying = "not_studying"
+	StatusNew         = "new"
+	StatusLearning    = "learning"
+	StatusMature      = "mature"
+)
+
+var (
+	GrammarFormats = []string{GrammarFormatCSV, GrammarFormatMarkdown}
+	Statuses       = []string{StatusNotStudying, StatusNew, StatusLearning, StatusMature}
+)
+
+// GrammarRequest picks what goes into a grammar reference. Empty filters
+// match everything.
+type GrammarRequest struct {
+	Format   string `json:"format"`
+	Tag      string `json:"tag"`
+	Language string `json:"language"`
+	Status   string `json:"status"`
+}
+
+func (r GrammarRequest) Validate() error {
+	errs := validation.Errors{}
+	if !slices.Contains(GrammarFormats, r.Format) {
+		errs["format"] = validation.NewError("validation_invalid_value", "Invalid value.")
+	}
+	if r.Status != "" && !slices.Contains(Statuses, r.Status) {
+		errs["status"] = validation.NewError("validation_invalid_value", "Invalid value.")
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// referenceItem is a grammar in the user's collection, with how far along
+// they are with it
+type referenceItem struct {
+	Grammar grammar.Grammar
+	Status  string
+
+	// zero when the grammar has no card
+	Card srs.Card
+}
+
+func (r GrammarRequest) matches(item referenceItem) bool {
+	return (r.Tag == "" || slices.Contains(item.Grammar.Tags, r.Tag)) &&
+		(r.Language == "" || item.Grammar.Language == r.Language) &&
+		(r.Status == "" || item.Status == r.Status)
+}
+
+func cardStatus(card srs.Card) string {
+	switch {
+	case card.IsNew():
+		return StatusNew
+	case card.IsMature():
+		return StatusMature
+	default:
+		return StatusLearning
+	}
+}
+
+// renderGrammarCSV writes one row per grammar, with tags and examples joined
+// into single cells so the sheet can be sorted and filtered as is
+func renderGrammarCSV(items []referenceItem) ([]byte, error) {
+	var buf bytes.Buffer
+
+	// Excel only reads the file as UTF-8, and so gets the Japanese right,
+	// with a byte order mark
+	buf.WriteString("\ufeff")
+
+	w := csv.NewWriter(&buf)
+	err := w.Write([]string{
+		"usage", "meaning", "context", "nuance", "notes", "tags", "examples",
+		"source", "status", "interval_days", "due", "last_reviewed",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		g := item.Grammar
+
+		examples := make([]string, 0, len(g.Examples))
+		for _, ex := range g.Examples {
+			examples = append(examples, strings.TrimSpace(ex.Japanese+" — "+ex.English))
+		}
+
+		source := "library"
+		if !g.IsLibrary() {
+			source = "own"
+		}
+
+		interval, due, reviewed := "", "", ""
+		if item.Card.Id != "" {
+			interval = strconv.Itoa(item.Card.IntervalDays)
+			due = item.Card.DueDate().Format(dateLayout)
+			if !item.Card.IsNew() {
+				reviewed = item.Card.LastReviewed.Format(dateLayout)
+			}
+		}
+
+		err := w.Write([]string{
+			g.Usage, g.Meaning, g.Context, g.Nuance, g.Notes,
+			strings.Join(g.Tags, "; "), strings.Join(examples, "\n"),
+			source, item.Status, interval, due, reviewed,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// renderGrammarMarkdown writes a reference sheet with the grammar grouped
+// under its first tag, groups and grammar in alphabetical order
+func renderGrammarMarkdown(items []referenceItem, locale string) []byte {
+	other := i18n.T(locale, "Other")
+
+	groups := map[string][]grammar.Grammar{}
+	for _, item := range items {
+		group := other
+		if len(item.Grammar.Tags) > 0 {
+			group = item.Grammar.Tags[0]
+		}
+		groups[group] = append(groups[group], item.Grammar)
+	}
+
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		if name != other {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	if _, ok := groups[other]; ok {
+		names = append(names, other)
+	}
+
+	var md bytes.Buffer
+	fmt.Fprintf(&md, "# %s\n", i18n.T(locale, "Grammar reference"))
+
+	for _, name := range names {
+		list := groups[name]
+		slices.SortFunc(list, func(a, b grammar.Grammar) int { return strings.Compare(a.Usage, b.Usage) })
+
+		fmt.Fprintf(&md, "\n## %s\n", name)
+		for _, g := range list {
+			if g.Romanized != "" {
+				fmt.Fprintf(&md, "\n### %s (%s)\n\n%s\n", g.Usage, g.Romanized, g.Meaning)
+			} else {
+				fmt.Fprintf(&md, "\n### %s\n\n%s\n", g.Usage, g.Meaning)
+			}
+
+			for _, field := range []struct{ label, text string }{
+				{i18n.T(locale, "Context"), g.Context},
+				{i18n.T(locale, "Nuance"), g.Nuance},
+				{i18n.T(locale, "Notes"), g.Notes},
+			} {
+				if field.text != "" {
+					fmt.Fprintf(&md, "\n**%s:** %s\n", field.label, field.text)
+				}
+			}
+
+			if len(g.Examples) > 0 {
+				md.WriteString("\n")
+				for _, ex := range g.Examples {
+					fmt.Fprintf(&md, "- %s", ex.Japanese)
+					if ex.English != "" {
+						fmt.Fprintf(&md, "\n  *%s*", ex.English)
+					}
+					md.WriteString("\n")
+				}
+			}
+
+			if len(g.Tags) > 1 {
+				fmt.Fprintf(&md, "\n%s\n", "#"+strings.Join(g.Tags, " #"))
+			}
+		}
+	}
+
+	return md.Bytes()
+}
@@ -0,0 +1,32 @@
+package exports
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func BindHooks(app core.App) {
+	// Exports are big and users download them right away, so don't keep them
+	// eating disk forever
+	app.Cron().MustAdd("fushigiExportsCleanup", "0 4 * * *", func() {
+		records, err := app.FindRecordsByFilter(
+			"exports",
+			"created < {:before}",
+			"",
+			0,
+			0,
+			map[string]any{"before": types.NowDateTime().Add(-exportTTL).String()},
+		)
+		if err != nil {
+			app.Logger().Error("Failed to load expired exports", "error", err)
+			return
+		}
+
+		// deleted one by one rather than with a query so their files go too
+		for _, record := range records {
+			if err := app.Delete(record); err != nil {
+				app.Logger().Error("Failed to delete expired export", "export", record.Id, "error", err)
+			}
+		}
+	})
+}
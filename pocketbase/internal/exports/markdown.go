@@ -0,0 +1,131 @@
+package exports
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// unsafeFileChars can't appear in file names on at least one of the systems
+// a vault gets synced to
+var unsafeFileChars = strings.NewReplacer(
+	"/", "-", "\\", "-", ":", "-", "*", "-", "?", "", "\"", "", "<", "", ">", "", "|", "-", "\n", " ",
+)
+
+// renderMarkdown zips one markdown file per entry, with YAML frontmatter
+// Obsidian and most static site generators understand. The body's grammar
+// section is only written when includeGrammar is set, the frontmatter always
+// lists it. Corrections are written whenever the book has them.
+func renderMarkdown(b book, includeGrammar bool) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	used := map[string]bool{}
+	for _, ch := range b.Chapters {
+		w, err := zw.Create(markdownFileName(ch, used))
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(markdownEntry(b, ch, includeGrammar)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func markdownEntry(b book, ch chapter, includeGrammar bool) []byte {
+	var md bytes.Buffer
+
+	usages := make([]string, 0, len(ch.Grammar))
+	for _, note := range ch.Grammar {
+		usages = append(usages, note.Usage)
+	}
+	tags := ch.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	// JSON strings and arrays are valid YAML flow scalars and sequences, and
+	// quoting everything keeps titles like "No: 1" from changing type
+	md.WriteString("---\n")
+	fmt.Fprintf(&md, "title: %s\n", yamlValue(ch.Title))
+	fmt.Fprintf(&md, "date: %s\n", ch.Date)
+	fmt.Fprintf(&md, "tags: %s\n", yamlValue(tags))
+	fmt.Fprintf(&md, "grammar: %s\n", yamlValue(usages))
+	fmt.Fprintf(&md, "audience: %s\n", ch.Audience)
+	if ch.Mood != "" {
+		fmt.Fprintf(&md, "mood: %s\n", ch.Mood)
+	}
+	md.WriteString("---\n\n")
+
+	for i, p := range ch.Paragraphs {
+		if i > 0 {
+			md.WriteString("\n\n")
+		}
+		md.WriteString(markdownParagraph(p))
+	}
+	md.WriteString("\n")
+
+	if includeGrammar && len(ch.Grammar) > 0 {
+		fmt.Fprintf(&md, "\n## %s\n\n", b.GrammarHeading)
+		for _, note := range ch.Grammar {
+			fmt.Fprintf(&md, "- **%s** — %s\n", note.Usage, note.Meaning)
+			if note.Sentence != "" {
+				fmt.Fprintf(&md, "  - %s\n", note.Sentence)
+			}
+		}
+	}
+
+	if len(ch.Corrections) > 0 {
+		fmt.Fprintf(&md, "\n## %s\n\n", b.CorrectionsHeading)
+		for _, c := range ch.Corrections {
+			fmt.Fprintf(&md, "- ~~%s~~ → **%s**\n", c.Original, c.Corrected)
+			if c.Comment != "" {
+				fmt.Fprintf(&md, "  - %s\n", c.Comment)
+			}
+		}
+	}
+
+	return md.Bytes()
+}
+
+// markdownParagraph writes readings as HTML ruby, which Obsidian and most
+// markdown renderers pass through
+func markdownParagraph(p paragraph) string {
+	var sb strings.Builder
+	for _, seg := range p {
+		if seg.Ruby == "" {
+			sb.WriteString(seg.Text)
+		} else {
+			sb.WriteString("<ruby>" + seg.Text + "<rt>" + seg.Ruby + "</rt></ruby>")
+		}
+	}
+	return sb.String()
+}
+
+func yamlValue(v any) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+// markdownFileName names entries "2025-01-31 Title.md", numbering entries of
+// the same day and title so none overwrite each other
+func markdownFileName(ch chapter, used map[string]bool) string {
+	base := ch.Date
+	if title := strings.TrimSpace(unsafeFileChars.Replace(ch.Title)); title != "" && title != ch.Date {
+		base += " " + title
+	}
+
+	name := base + ".md"
+	for i := 2; used[name]; i++ {
+		name = fmt.Sprintf("%s (%d).md", base, i)
+	}
+	used[name] = true
+	return name
+}
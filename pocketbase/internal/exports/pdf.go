@@ -0,0 +1,117 @@
+package exports
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+const (
+	pdfFont       = "journal"
+	pdfBodySize   = 11
+	pdfLineHeight = 6.5
+)
+
+// renderPDF typesets the book on A4 pages, one entry per page, with the
+// entries in the outline so readers can jump between them
+func renderPDF(b book, fontPath string) ([]byte, error) {
+	font, err := os.ReadFile(fontPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read export font: %w", err)
+	}
+
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.AddUTF8FontFromBytes(pdfFont, "", font)
+	pdf.SetTitle(b.Title, true)
+	pdf.SetAuthor(b.Author, true)
+	pdf.SetCreator("Fushigi", true)
+	pdf.SetMargins(20, 20, 20)
+	pdf.SetAutoPageBreak(true, 20)
+	pdf.AliasNbPages("")
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-15)
+		pdf.SetFont(pdfFont, "", 8)
+		pdf.SetTextColor(128, 128, 128)
+		pdf.CellFormat(0, 10, fmt.Sprintf("%d / {nb}", pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+
+	// title page
+	pdf.AddPage()
+	pdf.SetY(100)
+	pdf.SetFont(pdfFont, "", 24)
+	pdf.MultiCell(0, 12, b.Title, "", "C", false)
+	if b.Author != "" {
+		pdf.Ln(6)
+		pdf.SetFont(pdfFont, "", 14)
+		pdf.MultiCell(0, 8, b.Author, "", "C", false)
+	}
+
+	for _, ch := range b.Chapters {
+		pdf.AddPage()
+		pdf.Bookmark(ch.Title, 0, -1)
+
+		pdf.SetFont(pdfFont, "", 18)
+		pdf.SetTextColor(0, 0, 0)
+		pdf.MultiCell(0, 9, ch.Title, "", "L", false)
+		if ch.Title != ch.Date {
+			pdf.SetFont(pdfFont, "", 9)
+			pdf.SetTextColor(128, 128, 128)
+			pdf.MultiCell(0, 5, ch.Date, "", "L", false)
+		}
+		pdf.Ln(4)
+
+		pdf.SetFont(pdfFont, "", pdfBodySize)
+		pdf.SetTextColor(0, 0, 0)
+		for _, p := range ch.Paragraphs {
+			pdf.MultiCell(0, pdfLineHeight, p.inline(), "", "L", false)
+			pdf.Ln(2)
+		}
+
+		if len(ch.Grammar) > 0 {
+			pdf.Ln(4)
+			pdf.SetFont(pdfFont, "", 13)
+			pdf.MultiCell(0, 7, b.GrammarHeading, "", "L", false)
+			pdf.Ln(1)
+
+			for _, note := range ch.Grammar {
+				pdf.SetFont(pdfFont, "", pdfBodySize)
+				pdf.SetTextColor(0, 0, 0)
+				pdf.MultiCell(0, pdfLineHeight, note.Usage+" — "+note.Meaning, "", "L", false)
+				pdf.SetFont(pdfFont, "", 9)
+				pdf.SetTextColor(96, 96, 96)
+				pdf.SetX(pdf.GetX() + 6)
+				pdf.MultiCell(0, 5, note.Sentence, "", "L", false)
+				pdf.Ln(1)
+			}
+		}
+
+		if len(ch.Corrections) > 0 {
+			pdf.Ln(4)
+			pdf.SetFont(pdfFont, "", 13)
+			pdf.SetTextColor(0, 0, 0)
+			pdf.MultiCell(0, 7, b.CorrectionsHeading, "", "L", false)
+			pdf.Ln(1)
+
+			for _, c := range ch.Corrections {
+				pdf.SetFont(pdfFont, "", pdfBodySize)
+				pdf.SetTextColor(0, 0, 0)
+				pdf.MultiCell(0, pdfLineHeight, c.Original+" → "+c.Corrected, "", "L", false)
+				if c.Comment != "" {
+					pdf.SetFont(pdfFont, "", 9)
+					pdf.SetTextColor(96, 96, 96)
+					pdf.SetX(pdf.GetX() + 6)
+					pdf.MultiCell(0, 5, c.Comment, "", "L", false)
+				}
+				pdf.Ln(1)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
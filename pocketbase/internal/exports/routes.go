@@ -0,0 +1,63 @@
+package exports
+
+import (
+	"errors"
+	"mime"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/i18n"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/jobs"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+func RegisterRoutes(g *api.Group, exportsService Service) {
+	g.GET("/exports", "The user's finished exports, downloadable until they expire", []Export{}, func(e *core.RequestEvent) error {
+		exports, err := exportsService.List(e.Auth.Id)
+		if err != nil {
+			return e.InternalServerError("Failed to load exports.", err)
+		}
+		return e.JSON(200, exports)
+	})
+
+	g.POST("/exports/journal", "Start exporting the journal as a PDF or EPUB book, or a zip of markdown files", JournalRequest{}, jobs.Job{}, func(e *core.RequestEvent) error {
+		var req JournalRequest
+		if err := e.BindBody(&req); err != nil {
+			return e.BadRequestError("Invalid request body.", err)
+		}
+		if err := req.Validate(); err != nil {
+			return e.BadRequestError("Invalid export request.", err)
+		}
+
+		job, err := exportsService.Journal(e.Auth.Id, req)
+		if errors.Is(err, ErrPDFUnavailable) {
+			return e.BadRequestError("PDF exports aren't set up on this server.", err)
+		}
+		if err != nil {
+			return e.InternalServerError("Failed to start the export.", err)
+		}
+		return e.JSON(200, job)
+	})
+
+	g.POST("/exports/grammar", "Download the user's grammar as a CSV sheet or a Markdown reference, filtered by tag, language or study status", GrammarRequest{}, nil, func(e *core.RequestEvent) error {
+		var req GrammarRequest
+		if err := e.BindBody(&req); err != nil {
+			return e.BadRequestError("Invalid request body.", err)
+		}
+		if err := req.Validate(); err != nil {
+			return e.BadRequestError("Invalid export request.", err)
+		}
+
+		data, err := exportsService.Grammar(e.Auth.Id, req, i18n.FromRequest(e))
+		if err != nil {
+			return e.InternalServerError("Failed to export the grammar.", err)
+		}
+
+		contentType, name := "text/csv; charset=utf-8", "grammar.csv"
+		if req.Format == GrammarFormatMarkdown {
+			contentType, name = "text/markdown; charset=utf-8", "grammar.md"
+		}
+		e.Response.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+		return e.Blob(200, contentType, data)
+	})
+}
@@ -0,0 +1,253 @@
+package exports
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/grammar"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/i18n"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/jobs"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/journal"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/settings"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/srs"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/filesystem"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+const JobKindJournal = "journal_export"
+
+// ErrPDFUnavailable means the server has no font configured for PDF exports
+var ErrPDFUnavailable = errors.New("EXPORT_FONT_FILE is not set")
+
+type Service interface {
+	// Journal starts a job exporting the user's journal. The job's result is
+	// the finished Export.
+	Journal(userId string, req JournalRequest) (jobs.Job, error)
+
+	// Grammar writes the user's grammar, the library grammar they study and
+	// their own, as a reference sheet in the given locale. Unlike journal
+	// exports it is small enough to be built right away.
+	Grammar(userId string, req GrammarRequest, locale string) ([]byte, error)
+
+	// List returns the user's exports that haven't expired yet, newest first
+	List(userId string) ([]Export, error)
+}
+
+type service struct {
+	app             core.App
+	jobsService     jobs.Service
+	journalService  journal.Service
+	grammarService  grammar.Service
+	srsService      srs.Service
+	settingsService settings.Service
+}
+
+func NewService(app core.App, jobsService jobs.Service, journalService journal.Service, grammarService grammar.Service, srsService srs.Service, settingsService settings.Service) Service {
+	return &service{
+		app:             app,
+		jobsService:     jobsService,
+		journalService:  journalService,
+		grammarService:  grammarService,
+		srsService:      srsService,
+		settingsService: settingsService,
+	}
+}
+
+func (s *service) Journal(userId string, req JournalRequest) (jobs.Job, error) {
+	if req.Format == FormatPDF && fontFile() == "" {
+		return jobs.Job{}, ErrPDFUnavailable
+	}
+
+	return s.jobsService.Enqueue(userId, JobKindJournal, func(ctx context.Context, jobId string, progress jobs.Progress) (any, error) {
+		return s.exportJournal(ctx, progress, userId, jobId, req)
+	})
+}
+
+func (s *service) Grammar(userId string, req GrammarRequest, locale string) ([]byte, error) {
+	cards, err := s.srsService.Cards(userId)
+	if err != nil {
+		return nil, err
+	}
+	cardByGrammar := make(map[string]srs.Card, len(cards))
+	ids := make([]string, 0, len(cards))
+	for _, card := range cards {
+		cardByGrammar[card.Grammar] = card
+		ids = append(ids, card.Grammar)
+	}
+
+	studied, err := s.grammarService.FindByIds(ids)
+	if err != nil {
+		return nil, err
+	}
+	owned, err := s.grammarService.Owned(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []referenceItem
+	seen := map[string]bool{}
+	for _, g := range append(studied, owned...) {
+		if seen[g.Id] {
+			continue
+		}
+		seen[g.Id] = true
+
+		item := referenceItem{Grammar: g, Status: StatusNotStudying}
+		if card, ok := cardByGrammar[g.Id]; ok {
+			item.Card = card
+			item.Status = cardStatus(card)
+		}
+		if req.matches(item) {
+			items = append(items, item)
+		}
+	}
+
+	if req.Format == GrammarFormatCSV {
+		return renderGrammarCSV(items)
+	}
+	return renderGrammarMarkdown(items, locale), nil
+}
+
+func (s *service) List(userId string) ([]Export, error) {
+	records, err := s.app.FindRecordsByFilter(
+		"exports",
+		"user = {:user} && created >= {:since}",
+		"-created",
+		0,
+		0,
+		map[string]any{"user": userId, "since": types.NowDateTime().Add(-exportTTL).String()},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	exports := make([]Export, 0, len(records))
+	for _, rec := range records {
+		exports = append(exports, FromRecord(rec))
+	}
+	return exports, nil
+}
+
+func (s *service) exportJournal(ctx context.Context, progress jobs.Progress, userId string, jobId string, req JournalRequest) (Export, error) {
+	user, err := s.app.FindRecordById("users", userId)
+	if err != nil {
+		return Export{}, err
+	}
+
+	userSettings, err := s.settingsService.ForUser(userId)
+	if err != nil {
+		return Export{}, err
+	}
+	loc, err := time.LoadLocation(userSettings.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	locale := i18n.Negotiate(userSettings.Locale)
+	if locale == "" {
+		locale = i18n.DefaultLocale
+	}
+
+	progress.Report(5, "Loading entries")
+	from, to := req.bounds(loc)
+	entries, err := s.journalService.EntriesBetween(userId, from, to)
+	if err != nil {
+		return Export{}, err
+	}
+
+	sentences := map[string][]journal.Sentence{}
+	grammarById := map[string]grammar.Grammar{}
+	if (req.IncludeGrammar || req.Format == FormatMarkdown) && len(entries) > 0 {
+		progress.Report(15, "Loading grammar")
+
+		entryIds := make([]string, len(entries))
+		for i, entry := range entries {
+			entryIds[i] = entry.Id
+		}
+		if sentences, err = s.journalService.Sentences(entryIds); err != nil {
+			return Export{}, err
+		}
+
+		var grammarIds []string
+		for _, list := range sentences {
+			for _, sentence := range list {
+				grammarIds = append(grammarIds, sentence.Grammar)
+			}
+		}
+		items, err := s.grammarService.FindByIds(grammarIds)
+		if err != nil {
+			return Export{}, err
+		}
+		for _, item := range items {
+			grammarById[item.Id] = item
+		}
+	}
+
+	corrections := map[string][]journal.Correction{}
+	if req.IncludeCorrections && len(entries) > 0 {
+		progress.Report(20, "Loading corrections")
+
+		entryIds := make([]string, len(entries))
+		for i, entry := range entries {
+			entryIds[i] = entry.Id
+		}
+		if corrections, err = s.journalService.Corrections(entryIds); err != nil {
+			return Export{}, err
+		}
+	}
+
+	var readings map[string]string
+	if req.IncludeFurigana && len(entries) > 0 {
+		progress.Report(25, "Loading readings")
+		if readings, err = s.readings(userId); err != nil {
+			return Export{}, err
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return Export{}, err
+	}
+
+	progress.Report(30, fmt.Sprintf("Writing %d entries", len(entries)))
+	b := newBook(user.GetString("name"), locale, loc, entries, sentences, grammarById, corrections, readings)
+
+	var data []byte
+	switch req.Format {
+	case FormatPDF:
+		data, err = renderPDF(b, fontFile())
+	case FormatEPUB:
+		data, err = renderEPUB(b, "urn:fushigi:export:"+jobId, time.Now())
+	case FormatMarkdown:
+		data, err = renderMarkdown(b, req.IncludeGrammar)
+	default:
+		err = fmt.Errorf("unsupported export format %q", req.Format)
+	}
+	if err != nil {
+		return Export{}, err
+	}
+
+	progress.Report(90, "Saving")
+	collection, err := s.app.FindCollectionByNameOrId("exports")
+	if err != nil {
+		return Export{}, err
+	}
+
+	file, err := filesystem.NewFileFromBytes(data, "journal."+fileExtension(req.Format))
+	if err != nil {
+		return Export{}, err
+	}
+
+	record := core.NewRecord(collection)
+	record.Set("user", userId)
+	record.Set("job", jobId)
+	record.Set("format", req.Format)
+	record.Set("file", file)
+	if err := s.app.Save(record); err != nil {
+		return Export{}, err
+	}
+
+	return FromRecord(record), nil
+}
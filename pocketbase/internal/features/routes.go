@@ -0,0 +1,33 @@
+package features
+
+import (
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+func RegisterRoutes(g *api.Group, featuresService Service) {
+	// Clients call this at startup to decide which experimental UI to show
+	g.GET("/features", "Feature flags resolved for the user", map[string]bool{}, func(e *core.RequestEvent) error {
+		flags, err := featuresService.All(e.Auth.Id)
+		if err != nil {
+			return e.InternalServerError("Failed to load feature flags.", err)
+		}
+		return e.JSON(200, flags)
+	})
+}
+
+// Gate wraps a route handler so it 404s for users without the flag, as if the
+// route didn't exist yet
+func Gate(featuresService Service, key string, handler func(e *core.RequestEvent) error) func(e *core.RequestEvent) error {
+	return func(e *core.RequestEvent) error {
+		userId := ""
+		if e.Auth != nil {
+			userId = e.Auth.Id
+		}
+		if !featuresService.IsEnabled(userId, key) {
+			return e.NotFoundError("", nil)
+		}
+		return handler(e)
+	}
+}
@@ -0,0 +1,91 @@
+package features
+
+import (
+	"hash/fnv"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+type Service interface {
+	// IsEnabled reports whether the flag is on for the user. Unknown flags are off.
+	IsEnabled(userId string, key string) bool
+
+	// All resolves every flag for the user
+	All(userId string) (map[string]bool, error)
+}
+
+type service struct {
+	app core.App
+}
+
+func NewService(app core.App) Service {
+	return &service{app: app}
+}
+
+func (s *service) IsEnabled(userId string, key string) bool {
+	flag, err := s.app.FindFirstRecordByData("feature_flags", "key", key)
+	if err != nil {
+		return false
+	}
+
+	overrides, err := s.overrides(userId)
+	if err != nil {
+		s.app.Logger().Error("Failed to load feature flag overrides", "user", userId, "error", err)
+	}
+	return resolve(flag, userId, overrides)
+}
+
+func (s *service) All(userId string) (map[string]bool, error) {
+	flags, err := s.app.FindAllRecords("feature_flags")
+	if err != nil {
+		return nil, err
+	}
+
+	overrides, err := s.overrides(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := make(map[string]bool, len(flags))
+	for _, flag := range flags {
+		resolved[flag.GetString("key")] = resolve(flag, userId, overrides)
+	}
+	return resolved, nil
+}
+
+// overrides maps flag ids to the user's explicit setting
+func (s *service) overrides(userId string) (map[string]bool, error) {
+	records, err := s.app.FindRecordsByFilter("feature_flag_overrides", "user = {:user}", "", 0, 0, map[string]any{"user": userId})
+	if err != nil {
+		return nil, err
+	}
+
+	byFlag := make(map[string]bool, len(records))
+	for _, rec := range records {
+		byFlag[rec.GetString("flag")] = rec.GetBool("enabled")
+	}
+	return byFlag, nil
+}
+
+// resolve applies, in order: the user's override, the global switch, and the
+// gradual rollout percentage
+func resolve(flag *core.Record, userId string, overrides map[string]bool) bool {
+	if enabled, ok := overrides[flag.Id]; ok {
+		return enabled
+	}
+	if flag.GetBool("enabled") {
+		return true
+	}
+	return inRollout(flag.GetString("key"), userId, flag.GetInt("rollout_percent"))
+}
+
+// inRollout deterministically buckets users so raising the percentage only
+// ever adds users and nobody flips back and forth between requests
+func inRollout(key string, userId string, percent int) bool {
+	if percent <= 0 || userId == "" {
+		return false
+	}
+	h := fnv.New32a()
+	h.Write([]byte(key + ":" + userId))
+	return int(h.Sum32()%100) < percent
+}
@@ -0,0 +1,205 @@
+package federation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/grammar"
+)
+
+const (
+	// maxDeckSize caps the response of another instance, a deck of the
+	// largest size allowed is far below it
+	maxDeckSize = 8 << 20
+
+	// maxDeckGrammar is how many grammar a deck can hold
+	maxDeckGrammar = 9999
+
+	maxTextLength = 5000
+)
+
+var (
+	// ErrFetch means the other instance couldn't be reached or refused
+	ErrFetch = errors.New("failed to fetch the deck")
+
+	// ErrInvalidDeck means the other instance answered with something that
+	// isn't a deck
+	ErrInvalidDeck = errors.New("invalid deck")
+)
+
+// deckPath matches the public deck routes of a fushigi instance, versioned
+// or not: published decks, share links and their embeddable pages
+var deckPath = regexp.MustCompile(`^(/api/fushigi(?:/v\d+)?/public/(?:decks|shared)/[A-Za-z0-9]+)(?:/embed)?/?$`)
+
+// remoteDeck is a deck as served by the public routes of another instance
+type remoteDeck struct {
+	Id          string            `json:"id"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Grammar     []grammar.Grammar `json:"grammar"`
+	Languages   map[string]string `json:"languages"`
+}
+
+// deckURL checks a link to a deck of another instance and returns where to
+// fetch it as JSON, together with the instance it lives on
+func deckURL(raw string) (fetch string, instance string, ok bool) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" || u.User != nil {
+		return "", "", false
+	}
+
+	m := deckPath.FindStringSubmatch(u.Path)
+	if m == nil {
+		return "", "", false
+	}
+
+	instance = u.Scheme + "://" + u.Host
+	return instance + m[1], instance, true
+}
+
+// remoteClient fetches from other instances. They are addressed by whoever
+// calls the import, so it refuses to connect to the server's own network
+// unless FEDERATION_ALLOW_PRIVATE is set, for trying federation between
+// instances running locally.
+var remoteClient = &http.Client{
+	Timeout: 15 * time.Second,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: 5 * time.Second,
+			Control: refusePrivate,
+		}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+	},
+}
+
+// refusePrivate runs on the resolved address, so hostnames pointing inside
+// the network are caught too
+func refusePrivate(network string, address string, _ syscall.RawConn) error {
+	if os.Getenv("FEDERATION_ALLOW_PRIVATE") == "true" {
+		return nil
+	}
+
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !isPublic(addrPort.Addr()) {
+		return fmt.Errorf("refusing to connect to %s", addrPort.Addr())
+	}
+	return nil
+}
+
+// nat64 is the well-known NAT64 prefix, the IPv4 address in the last 32 bits
+var nat64 = netip.MustParsePrefix("64:ff9b::/96")
+
+// deniedPrefixes are the ranges besides loopback, private, link-local,
+// multicast and unspecified ones that don't reach other instances
+var deniedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),      // this network
+	netip.MustParsePrefix("100.64.0.0/10"),  // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),   // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"),  // benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),    // reserved, and broadcast
+	netip.MustParsePrefix("64:ff9b:1::/48"), // local-use NAT64
+}
+
+// isPublic reports whether addr may be another instance, checking IPv4
+// addresses inside IPv4-mapped and NAT64 ones as themselves
+func isPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if nat64.Contains(addr) {
+		embedded := addr.As16()
+		addr = netip.AddrFrom4([4]byte(embedded[12:]))
+	}
+
+	if !addr.IsValid() || addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsMulticast() || addr.IsUnspecified() {
+		return false
+	}
+	for _, prefix := range deniedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+func fetchDeck(ctx context.Context, fetch string) (remoteDeck, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fetch, nil)
+	if err != nil {
+		return remoteDeck{}, fmt.Errorf("%w: %v", ErrFetch, err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	res, err := remoteClient.Do(req)
+	if err != nil {
+		return remoteDeck{}, fmt.Errorf("%w: %v", ErrFetch, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return remoteDeck{}, fmt.Errorf("%w: %s returned %s", ErrFetch, fetch, res.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(res.Body, maxDeckSize+1))
+	if err != nil {
+		return remoteDeck{}, fmt.Errorf("%w: %v", ErrFetch, err)
+	}
+	if len(data) > maxDeckSize {
+		return remoteDeck{}, fmt.Errorf("%w: deck is too large", ErrInvalidDeck)
+	}
+
+	var deck remoteDeck
+	if err := json.Unmarshal(data, &deck); err != nil {
+		return remoteDeck{}, fmt.Errorf("%w: %v", ErrInvalidDeck, err)
+	}
+	if err := deck.validate(); err != nil {
+		return remoteDeck{}, fmt.Errorf("%w: %v", ErrInvalidDeck, err)
+	}
+	return deck, nil
+}
+
+// validate checks what the other instance sent against what this one
+// accepts, before anything is saved
+func (d remoteDeck) validate() error {
+	if d.Id == "" || strings.TrimSpace(d.Name) == "" {
+		return errors.New("deck has no id or name")
+	}
+	if len(d.Grammar) > maxDeckGrammar {
+		return fmt.Errorf("deck has more than %d grammar", maxDeckGrammar)
+	}
+	if !fitsText(d.Name, d.Description) {
+		return errors.New("deck name or description is too long")
+	}
+
+	for i, g := range d.Grammar {
+		if g.Id == "" || strings.TrimSpace(g.Usage) == "" || strings.TrimSpace(g.Meaning) == "" {
+			return fmt.Errorf("grammar %d has no id, usage or meaning", i+1)
+		}
+		if !fitsText(g.Usage, g.Meaning, g.Context, g.Notes, g.Nuance) {
+			return fmt.Errorf("grammar %d is too long", i+1)
+		}
+	}
+	return nil
+}
+
+func fitsText(texts ...string) bool {
+	for _, text := range texts {
+		if !utf8.ValidString(text) || utf8.RuneCountInString(text) > maxTextLength {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,33 @@
+package federation
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+func RegisterRoutes(g *api.Group, federationService Service) {
+	g.POST("/decks/import", "Import a deck published or shared on another fushigi instance by its link", ImportRequest{}, Result{}, func(e *core.RequestEvent) error {
+		var req ImportRequest
+		if err := e.BindBody(&req); err != nil {
+			return e.BadRequestError("Invalid request body.", err)
+		}
+		if err := req.Validate(); err != nil {
+			return e.BadRequestError("Invalid import request.", err)
+		}
+
+		result, err := federationService.ImportDeck(e.Request.Context(), e.Auth.Id, req)
+		switch {
+		case errors.Is(err, ErrFetch):
+			return e.Error(http.StatusBadGateway, "Couldn't get the deck from the other instance.", err)
+		case errors.Is(err, ErrInvalidDeck):
+			return e.BadRequestError("The other instance didn't send a valid deck.", err)
+		case err != nil:
+			return e.InternalServerError("Failed to import the deck.", err)
+		}
+		return e.JSON(200, result)
+	})
+}
@@ -0,0 +1,154 @@
+package federation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/grammar"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+type ImportRequest struct {
+	URL string `json:"url"`
+}
+
+func (r ImportRequest) Validate() error {
+	if _, _, ok := deckURL(r.URL); !ok {
+		return validation.Errors{
+			"url": validation.NewError("validation_invalid_deck_url", "Not a link to a fushigi deck."),
+		}
+	}
+	return nil
+}
+
+// Result is what importing a deck from another instance did
+type Result struct {
+	Deck grammar.Deck `json:"deck"`
+
+	// Imported grammar are new to the user, Updated ones came from an
+	// earlier import of the same deck
+	Imported int `json:"imported"`
+	Updated  int `json:"updated"`
+
+	// Skipped lists the grammar that couldn't be imported and why
+	Skipped []string `json:"skipped"`
+}
+
+type Service interface {
+	// ImportDeck copies a deck published or shared on another fushigi
+	// instance into the user's decks, along with its grammar. Importing the
+	// same deck again updates the copy.
+	ImportDeck(ctx context.Context, userId string, req ImportRequest) (Result, error)
+}
+
+type service struct {
+	app            core.App
+	grammarService grammar.Service
+}
+
+func NewService(app core.App, grammarService grammar.Service) Service {
+	return &service{app: app, grammarService: grammarService}
+}
+
+func (s *service) ImportDeck(ctx context.Context, userId string, req ImportRequest) (Result, error) {
+	fetch, instance, ok := deckURL(req.URL)
+	if !ok {
+		return Result{}, fmt.Errorf("%w: not a deck url", ErrInvalidDeck)
+	}
+
+	remote, err := fetchDeck(ctx, fetch)
+	if err != nil {
+		return Result{}, err
+	}
+
+	names, err := s.grammarService.Languages()
+	if err != nil {
+		return Result{}, err
+	}
+	languageByName := make(map[string]string, len(names))
+	for id, name := range names {
+		languageByName[strings.ToLower(name)] = id
+	}
+
+	result := Result{Skipped: []string{}}
+	err = s.app.RunInTransaction(func(txApp core.App) error {
+		grammarCollection, err := txApp.FindCollectionByNameOrId("grammar")
+		if err != nil {
+			return err
+		}
+		decksCollection, err := txApp.FindCollectionByNameOrId("decks")
+		if err != nil {
+			return err
+		}
+
+		existing, err := txApp.FindRecordsByFilter("grammar", "user = {:user} && source = {:source}", "", 0, 0, map[string]any{"user": userId, "source": instance})
+		if err != nil {
+			return err
+		}
+		bySourceId := make(map[string]*core.Record, len(existing))
+		for _, rec := range existing {
+			bySourceId[rec.GetString("source_id")] = rec
+		}
+
+		ids := make([]string, 0, len(remote.Grammar))
+		for _, g := range remote.Grammar {
+			// match languages by name, ids only line up between instances
+			// seeded from the same database
+			language, ok := languageByName[strings.ToLower(remote.Languages[g.Language])]
+			if !ok {
+				if _, known := names[g.Language]; !known {
+					result.Skipped = append(result.Skipped, fmt.Sprintf("%s: unknown language %q", g.Usage, remote.Languages[g.Language]))
+					continue
+				}
+				language = g.Language
+			}
+
+			rec, ok := bySourceId[g.Id]
+			if ok {
+				result.Updated++
+			} else {
+				rec = core.NewRecord(grammarCollection)
+				rec.Set("user", userId)
+				rec.Set("source", instance)
+				rec.Set("source_id", g.Id)
+				result.Imported++
+			}
+			rec.Set("language", language)
+			rec.Set("usage", g.Usage)
+			rec.Set("meaning", g.Meaning)
+			rec.Set("context", g.Context)
+			rec.Set("tags", g.Tags)
+			rec.Set("notes", g.Notes)
+			rec.Set("nuance", g.Nuance)
+			rec.Set("examples", g.Examples)
+			if err := txApp.Save(rec); err != nil {
+				return err
+			}
+			ids = append(ids, rec.Id)
+		}
+
+		deck, err := txApp.FindFirstRecordByFilter("decks", "user = {:user} && source = {:source} && source_id = {:id}", map[string]any{"user": userId, "source": instance, "id": remote.Id})
+		if err != nil {
+			deck = core.NewRecord(decksCollection)
+			deck.Set("user", userId)
+			deck.Set("source", instance)
+			deck.Set("source_id", remote.Id)
+		}
+		deck.Set("name", remote.Name)
+		deck.Set("description", remote.Description)
+		deck.Set("grammar", ids)
+		if err := txApp.Save(deck); err != nil {
+			return err
+		}
+
+		result.Deck = grammar.DeckFromRecord(deck)
+		return nil
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	return result, nil
+}
@@ -0,0 +1,155 @@
+package feedback
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Platforms reports are sent from
+const (
+	PlatformIOS   = "ios"
+	PlatformWeb   = "web"
+	PlatformOther = "other"
+)
+
+var Platforms = []string{PlatformIOS, PlatformWeb, PlatformOther}
+
+// Statuses of a report as it's triaged
+const (
+	StatusNew       = "new"
+	StatusTriaged   = "triaged"
+	StatusResolved  = "resolved"
+	StatusDismissed = "dismissed"
+)
+
+var Statuses = []string{StatusNew, StatusTriaged, StatusResolved, StatusDismissed}
+
+// Limits matching the collection's fields
+const (
+	maxMessageLength    = 5000
+	maxAppVersionLength = 50
+	maxNoteLength       = 5000
+	maxDiagnosticsSize  = 100 * 1024
+)
+
+// Report is feedback or a bug report a user sent
+type Report struct {
+	Id         string `json:"id"`
+	User       string `json:"user"`
+	Message    string `json:"message"`
+	AppVersion string `json:"app_version"`
+	Platform   string `json:"platform"`
+
+	// Diagnostics is what the app collected to help reproduce it
+	Diagnostics json.RawMessage `json:"diagnostics"`
+
+	Status string `json:"status"`
+
+	// Note is what staff noted while triaging it
+	Note string `json:"note"`
+
+	Created types.DateTime `json:"created"`
+	Updated types.DateTime `json:"updated"`
+}
+
+func FromRecord(rec *core.Record) Report {
+	report := Report{
+		Id:         rec.Id,
+		User:       rec.GetString("user"),
+		Message:    rec.GetString("message"),
+		AppVersion: rec.GetString("app_version"),
+		Platform:   rec.GetString("platform"),
+		Status:     rec.GetString("status"),
+		Note:       rec.GetString("note"),
+		Created:    rec.GetDateTime("created"),
+		Updated:    rec.GetDateTime("updated"),
+	}
+	if raw, ok := rec.Get("diagnostics").(types.JSONRaw); ok && len(raw) > 0 {
+		report.Diagnostics = json.RawMessage(raw)
+	}
+	return report
+}
+
+// SubmitRequest sends feedback or a bug report. Platform is guessed from the
+// User-Agent when left out.
+type SubmitRequest struct {
+	Message     string          `json:"message"`
+	AppVersion  string          `json:"app_version"`
+	Platform    string          `json:"platform"`
+	Diagnostics json.RawMessage `json:"diagnostics"`
+}
+
+func (r SubmitRequest) Validate() error {
+	errs := validation.Errors{}
+
+	switch message := strings.TrimSpace(r.Message); {
+	case message == "":
+		errs["message"] = validation.NewError("validation_required", "Cannot be blank.")
+	case utf8.RuneCountInString(message) > maxMessageLength:
+		errs["message"] = validation.NewError("validation_length_too_long", "The length must be no more than {{.max}}.").
+			SetParams(map[string]any{"max": maxMessageLength})
+	}
+	if utf8.RuneCountInString(r.AppVersion) > maxAppVersionLength {
+		errs["app_version"] = validation.NewError("validation_length_too_long", "The length must be no more than {{.max}}.").
+			SetParams(map[string]any{"max": maxAppVersionLength})
+	}
+	if r.Platform != "" && !slices.Contains(Platforms, r.Platform) {
+		errs["platform"] = validation.NewError("validation_invalid_value", "Invalid value.")
+	}
+	if len(r.Diagnostics) > 0 && string(r.Diagnostics) != "null" {
+		var diagnostics map[string]any
+		switch {
+		case len(r.Diagnostics) > maxDiagnosticsSize:
+			errs["diagnostics"] = validation.NewError("validation_json_size_limit", "The maximum allowed JSON size is {{.maxSize}} bytes.").
+				SetParams(map[string]any{"maxSize": maxDiagnosticsSize})
+		case json.Unmarshal(r.Diagnostics, &diagnostics) != nil:
+			errs["diagnostics"] = validation.NewError("validation_invalid_value", "Must be a JSON object.")
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// TriageRequest moves a report along, keeping its note when Note is nil
+type TriageRequest struct {
+	Status string  `json:"status"`
+	Note   *string `json:"note"`
+}
+
+func (r TriageRequest) Validate() error {
+	errs := validation.Errors{}
+
+	if !slices.Contains(Statuses, r.Status) {
+		errs["status"] = validation.NewError("validation_invalid_value", "Invalid value.")
+	}
+	if r.Note != nil && utf8.RuneCountInString(*r.Note) > maxNoteLength {
+		errs["note"] = validation.NewError("validation_length_too_long", "The length must be no more than {{.max}}.").
+			SetParams(map[string]any{"max": maxNoteLength})
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// platformOf guesses the platform from a User-Agent, the iOS app's being
+// CFNetwork's unless it sets its own
+func platformOf(userAgent string) string {
+	switch {
+	case strings.Contains(userAgent, "CFNetwork") || strings.Contains(userAgent, "iPhone") || strings.Contains(userAgent, "iPad"):
+		return PlatformIOS
+	case strings.HasPrefix(userAgent, "Mozilla/"):
+		return PlatformWeb
+	}
+	return PlatformOther
+}
@@ -0,0 +1,74 @@
+package feedback
+
+import (
+	"database/sql"
+	"errors"
+	"slices"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+func RegisterRoutes(g *api.Group, feedbackService Service) {
+	g.POST("/feedback", "Send feedback or a bug report, with the app's version, platform and any diagnostics", SubmitRequest{}, Report{}, func(e *core.RequestEvent) error {
+		var req SubmitRequest
+		if err := e.BindBody(&req); err != nil {
+			return e.BadRequestError("Invalid request body.", err)
+		}
+		if err := req.Validate(); err != nil {
+			return e.BadRequestError("Invalid feedback.", err)
+		}
+
+		report, err := feedbackService.Submit(e.Auth.Id, req, e.Request.UserAgent())
+		switch {
+		case errors.Is(err, ErrTooMany):
+			return e.TooManyRequestsError("You've sent a lot of feedback lately, try again later.", err)
+		case err != nil:
+			return e.InternalServerError("Failed to send the feedback.", err)
+		}
+		return e.JSON(200, report)
+	})
+}
+
+// RegisterAdminRoutes adds the routes triaging feedback, for superusers and
+// support staff
+func RegisterAdminRoutes(g *api.Group, feedbackService Service) {
+	g.GET("/feedback", "Feedback and bug reports users sent, new ones unless ?status= is triaged, resolved or dismissed", []Report{}, func(e *core.RequestEvent) error {
+		status := e.Request.URL.Query().Get("status")
+		if status == "" {
+			status = StatusNew
+		}
+		if !slices.Contains(Statuses, status) {
+			return e.BadRequestError("Invalid status.", validation.Errors{
+				"status": validation.NewError("validation_invalid_value", "Invalid value."),
+			})
+		}
+
+		reports, err := feedbackService.List(status)
+		if err != nil {
+			return e.InternalServerError("Failed to load the feedback.", err)
+		}
+		return e.JSON(200, reports)
+	})
+
+	g.POST("/feedback/{id}", "Triage feedback, setting its status and optionally a note", TriageRequest{}, Report{}, func(e *core.RequestEvent) error {
+		var req TriageRequest
+		if err := e.BindBody(&req); err != nil {
+			return e.BadRequestError("Invalid request body.", err)
+		}
+		if err := req.Validate(); err != nil {
+			return e.BadRequestError("Invalid triage.", err)
+		}
+
+		report, err := feedbackService.Triage(e.Request.PathValue("id"), req)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return e.NotFoundError("", err)
+		case err != nil:
+			return e.InternalServerError("Failed to triage the feedback.", err)
+		}
+		return e.JSON(200, report)
+	})
+}
@@ -0,0 +1,101 @@
+package feedback
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// maxPerHour bounds how many reports a user sends, enough for a bad day
+const maxPerHour = 10
+
+// ErrTooMany means the user sent maxPerHour reports in the last hour
+var ErrTooMany = errors.New("too many reports")
+
+type Service interface {
+	// Submit saves a report from the user, new until it's triaged
+	Submit(userId string, req SubmitRequest, userAgent string) (Report, error)
+
+	// List returns the reports with status, newest first
+	List(status string) ([]Report, error)
+
+	// Triage sets a report's status, and its note unless req has none
+	Triage(id string, req TriageRequest) (Report, error)
+}
+
+type service struct {
+	app core.App
+}
+
+func NewService(app core.App) Service {
+	return &service{app: app}
+}
+
+func (s *service) Submit(userId string, req SubmitRequest, userAgent string) (Report, error) {
+	since, err := types.ParseDateTime(time.Now().Add(-time.Hour))
+	if err != nil {
+		return Report{}, err
+	}
+	recent, err := s.app.CountRecords("feedback", dbx.HashExp{"user": userId}, dbx.NewExp("created >= {:since}", dbx.Params{"since": since.String()}))
+	if err != nil {
+		return Report{}, err
+	}
+	if recent >= maxPerHour {
+		return Report{}, ErrTooMany
+	}
+
+	collection, err := s.app.FindCollectionByNameOrId("feedback")
+	if err != nil {
+		return Report{}, err
+	}
+
+	platform := req.Platform
+	if platform == "" {
+		platform = platformOf(userAgent)
+	}
+	rec := core.NewRecord(collection)
+	rec.Set("user", userId)
+	rec.Set("message", strings.TrimSpace(req.Message))
+	rec.Set("app_version", strings.TrimSpace(req.AppVersion))
+	rec.Set("platform", platform)
+	if len(req.Diagnostics) > 0 {
+		rec.Set("diagnostics", types.JSONRaw(req.Diagnostics))
+	}
+	rec.Set("status", StatusNew)
+	if err := s.app.Save(rec); err != nil {
+		return Report{}, err
+	}
+	return FromRecord(rec), nil
+}
+
+func (s *service) List(status string) ([]Report, error) {
+	records, err := s.app.FindRecordsByFilter("feedback", "status = {:status}", "-created", 0, 0, dbx.Params{"status": status})
+	if err != nil {
+		return nil, err
+	}
+
+	reports := make([]Report, 0, len(records))
+	for _, rec := range records {
+		reports = append(reports, FromRecord(rec))
+	}
+	return reports, nil
+}
+
+func (s *service) Triage(id string, req TriageRequest) (Report, error) {
+	rec, err := s.app.FindRecordById("feedback", id)
+	if err != nil {
+		return Report{}, err
+	}
+	rec.Set("status", req.Status)
+	if req.Note != nil {
+		rec.Set("note", strings.TrimSpace(*req.Note))
+	}
+	if err := s.app.Save(rec); err != nil {
+		return Report{}, err
+	}
+	return FromRecord(rec), nil
+}
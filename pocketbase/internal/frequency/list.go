@@ -0,0 +1,57 @@
+package frequency
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ErrEmptyList means nothing could be read from an uploaded list
+var ErrEmptyList = errors.New("frequency list has no entries")
+
+// Entry is one pattern or word of a frequency list, 1 being the most frequent
+type Entry struct {
+	Pattern string `json:"pattern"`
+	Rank    int    `json:"rank"`
+}
+
+// ParseList reads a frequency list, one pattern a line, most frequent
+// first. A line may give its rank after a tab or comma instead, like the
+// lists published with corpora do. Blank lines and lines starting with # are
+// skipped.
+func ParseList(data []byte) ([]Entry, error) {
+	entries := []Entry{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		entry := Entry{Pattern: line, Rank: len(entries) + 1}
+		if i := strings.IndexAny(line, "\t,"); i >= 0 {
+			entry.Pattern = strings.TrimSpace(line[:i])
+			if rank, err := strconv.Atoi(strings.TrimSpace(line[i+1:])); err == nil && rank > 0 {
+				entry.Rank = rank
+			}
+		}
+		if entry.Pattern = Normalize(entry.Pattern); entry.Pattern != "" {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, ErrEmptyList
+	}
+	return entries, nil
+}
+
+// Normalize drops what grammar patterns are written with but lists often
+// aren't, like the wave dash of 〜ので
+func Normalize(pattern string) string {
+	return strings.TrimSpace(strings.Trim(strings.TrimSpace(pattern), "〜～~"))
+}
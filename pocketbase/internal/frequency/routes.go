@@ -0,0 +1,53 @@
+package frequency
+
+import (
+	"errors"
+	"io"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// maxListSize bounds uploaded frequency lists
+const maxListSize = 8 << 20
+
+// RegisterRoutes lets superusers import a corpus frequency list for the
+// grammar and vocabulary of one language
+func RegisterRoutes(g *api.Group, frequencyService Service) {
+	g.POST("/frequency", "Rank the grammar and vocabulary of a ?language= by the uploaded frequency list file, one pattern or word a line, most frequent first", nil, Result{}, func(e *core.RequestEvent) error {
+		files, err := e.FindUploadedFiles("file")
+		if err != nil || len(files) == 0 {
+			return e.BadRequestError("Upload the frequency list as file.", err)
+		}
+		if files[0].Size > maxListSize {
+			return e.BadRequestError("The file is too large.", nil)
+		}
+		f, err := files[0].Reader.Open()
+		if err != nil {
+			return e.BadRequestError("Upload the frequency list as file.", err)
+		}
+		defer f.Close()
+		data, err := io.ReadAll(io.LimitReader(f, maxListSize))
+		if err != nil {
+			return e.BadRequestError("Upload the frequency list as file.", err)
+		}
+
+		entries, err := ParseList(data)
+		if errors.Is(err, ErrEmptyList) {
+			return e.BadRequestError("The frequency list is empty.", err)
+		}
+		if err != nil {
+			return e.BadRequestError("Failed to read the frequency list.", err)
+		}
+
+		result, err := frequencyService.Import(e.Request.URL.Query().Get("language"), entries)
+		if errors.Is(err, ErrUnknownLanguage) {
+			return e.NotFoundError("No such language.", err)
+		}
+		if err != nil {
+			return e.InternalServerError("Failed to import the frequency list.", err)
+		}
+		return e.JSON(200, result)
+	})
+}
@@ -0,0 +1,93 @@
+package frequency
+
+import (
+	"errors"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// unmatchedShown bounds the unmatched patterns an import reports
+const unmatchedShown = 50
+
+// ranked are the collections a list ranks, by the field matched against its
+// patterns
+var ranked = []struct{ collection, field string }{
+	{"grammar", "usage"},
+	{"vocabulary", "term"},
+}
+
+var ErrUnknownLanguage = errors.New("unknown language")
+
+// Result sums up an imported list
+type Result struct {
+	Entries   int      `json:"entries"`
+	Matched   int      `json:"matched"`
+	Unmatched []string `json:"unmatched"`
+}
+
+type Service interface {
+	// Import ranks the grammar and vocabulary of a language, the library's and
+	// users' own, by the list, replacing the ranks of any list imported before
+	Import(language string, entries []Entry) (Result, error)
+}
+
+type service struct {
+	app core.App
+}
+
+func NewService(app core.App) Service {
+	return &service{app: app}
+}
+
+func (s *service) Import(language string, entries []Entry) (Result, error) {
+	languageRecord, err := s.app.FindFirstRecordByData("languages", "name", language)
+	if err != nil {
+		return Result{}, ErrUnknownLanguage
+	}
+
+	ranks := make(map[string]int, len(entries))
+	for _, entry := range entries {
+		if rank, ok := ranks[entry.Pattern]; !ok || entry.Rank < rank {
+			ranks[entry.Pattern] = entry.Rank
+		}
+	}
+
+	result := Result{Entries: len(entries), Unmatched: []string{}}
+	err = s.app.RunInTransaction(func(txApp core.App) error {
+		matched := map[string]bool{}
+		for _, r := range ranked {
+			records, err := txApp.FindAllRecords(r.collection, dbx.HashExp{"language": languageRecord.Id})
+			if err != nil {
+				return err
+			}
+
+			for _, record := range records {
+				pattern := Normalize(record.GetString(r.field))
+				rank := ranks[pattern]
+				if rank > 0 {
+					matched[pattern] = true
+				}
+				if record.GetInt("frequency_rank") == rank {
+					continue
+				}
+				record.Set("frequency_rank", rank)
+				if err := txApp.SaveNoValidate(record); err != nil {
+					return err
+				}
+			}
+		}
+
+		result.Matched = len(matched)
+		for _, entry := range entries {
+			if !matched[entry.Pattern] && len(result.Unmatched) < unmatchedShown {
+				result.Unmatched = append(result.Unmatched, entry.Pattern)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	return result, nil
+}
@@ -0,0 +1,91 @@
+package frequency
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	_ "github.com/bunkbed-tech/fushigi/pocketbase/migrations"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+// newTestApp is a migrated app
+func newTestApp(t *testing.T) *tests.TestApp {
+	t.Helper()
+	// the initial migration creates a superuser from these
+	t.Setenv("ADMIN_EMAIL", "admin@example.com")
+	t.Setenv("ADMIN_PASSWORD", "correct horse battery")
+	app, err := tests.NewTestApp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(app.Cleanup)
+	if err := app.RunAllMigrations(); err != nil {
+		t.Fatal(err)
+	}
+	return app
+}
+
+func saveItem(t *testing.T, app core.App, collection string, data map[string]any) *core.Record {
+	t.Helper()
+	japanese, err := app.FindFirstRecordByData("languages", "name", "Japanese")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := app.FindCollectionByNameOrId(collection)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := core.NewRecord(c)
+	record.Set("language", japanese.Id)
+	record.Set("meaning", "test")
+	record.Load(data)
+	if err := app.Save(record); err != nil {
+		t.Fatal(err)
+	}
+	return record
+}
+
+func TestImport(t *testing.T) {
+	app := newTestApp(t)
+	service := NewService(app)
+
+	pattern := saveItem(t, app, "grammar", map[string]any{"usage": "〜てみせる"})
+	word := saveItem(t, app, "vocabulary", map[string]any{"term": "試験"})
+	unranked := saveItem(t, app, "vocabulary", map[string]any{"term": "未収録", "frequency_rank": 7})
+
+	if _, err := service.Import("Klingon", []Entry{{Pattern: "試験", Rank: 1}}); !errors.Is(err, ErrUnknownLanguage) {
+		t.Errorf("Import() of an unknown language error = %v, want %v", err, ErrUnknownLanguage)
+	}
+
+	result, err := service.Import("Japanese", []Entry{{Pattern: "試験", Rank: 1}, {Pattern: "てみせる", Rank: 2}, {Pattern: "どこにもない", Rank: 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Entries != 3 || result.Matched != 2 || !slices.Equal(result.Unmatched, []string{"どこにもない"}) {
+		t.Errorf("Import() = %+v, want 2 of 3 matched", result)
+	}
+
+	tests := []struct {
+		name   string
+		record *core.Record
+		want   int
+	}{
+		{"grammar", pattern, 2},
+		{"vocabulary", word, 1},
+		{"vocabulary the list leaves out", unranked, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record, err := app.FindRecordById(tt.record.Collection().Name, tt.record.Id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := record.GetInt("frequency_rank"); got != tt.want {
+				t.Errorf("frequency_rank = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package grammar
+
+import (
+	"cmp"
+	"slices"
+	"unicode"
+)
+
+// How much each part of an example makes it harder to read: kanji the user
+// never wrote most, then kanji they did, then length. Kanji in the words the
+// user studied read as easily as kana.
+const (
+	unknownKanjiWeight = 3.0
+	knownKanjiWeight   = 1.0
+	lengthWeight       = 0.05
+)
+
+// KnownWords finds the words a user knows, the terms of the vocabulary they
+// studied. The vocabulary service implements it.
+type KnownWords interface {
+	KnownWords(userId string) ([]string, error)
+}
+
+// ScoredExample is an example with how hard it should be for the user
+type ScoredExample struct {
+	Example
+	Difficulty   float64  `json:"difficulty"`
+	UnknownKanji []string `json:"unknown_kanji"`
+
+	// KnownWords are the words the user studied that the example uses
+	KnownWords []string `json:"known_words"`
+}
+
+// ScoreExamples scores the examples against the words and kanji the user
+// knows and orders them easiest first, keeping the original order of equal
+// ones
+func ScoreExamples(examples []Example, words []string, kanji map[rune]bool) []ScoredExample {
+	scored := make([]ScoredExample, 0, len(examples))
+	for _, example := range examples {
+		s := ScoredExample{Example: example, UnknownKanji: []string{}, KnownWords: []string{}}
+		runes := []rune(example.Japanese)
+
+		// the runes some known word covers
+		read := make([]bool, len(runes))
+		for _, word := range words {
+			w := []rune(word)
+			if len(w) == 0 {
+				continue
+			}
+			found := false
+			for i := 0; i+len(w) <= len(runes); i++ {
+				if slices.Equal(runes[i:i+len(w)], w) {
+					found = true
+					for j := range w {
+						read[i+j] = true
+					}
+				}
+			}
+			if found && !slices.Contains(s.KnownWords, word) {
+				s.KnownWords = append(s.KnownWords, word)
+			}
+		}
+
+		for i, r := range runes {
+			switch {
+			case !unicode.Is(unicode.Han, r) || read[i]:
+			case kanji[r]:
+				s.Difficulty += knownKanjiWeight
+			default:
+				s.Difficulty += unknownKanjiWeight
+				if !slices.Contains(s.UnknownKanji, string(r)) {
+					s.UnknownKanji = append(s.UnknownKanji, string(r))
+				}
+			}
+		}
+		s.Difficulty += float64(len(runes)) * lengthWeight
+		scored = append(scored, s)
+	}
+
+	slices.SortStableFunc(scored, func(a, b ScoredExample) int {
+		return cmp.Compare(a.Difficulty, b.Difficulty)
+	})
+	return scored
+}
@@ -0,0 +1,43 @@
+package grammar
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestScoreExamples(t *testing.T) {
+	examples := []Example{
+		{Japanese: "図書館で勉強する。"},
+		{Japanese: "毎日走る。"},
+	}
+
+	tests := []struct {
+		name  string
+		words []string
+		kanji map[rune]bool
+		want  []string
+	}{
+		{"nothing known", nil, nil, []string{"毎日走る。", "図書館で勉強する。"}},
+		{"studied words read like kana", []string{"図書館", "勉強"}, nil, []string{"図書館で勉強する。", "毎日走る。"}},
+		{"one studied word", []string{"勉強"}, nil, []string{"毎日走る。", "図書館で勉強する。"}},
+		{"written kanji", []string{"勉強"}, map[rune]bool{'図': true, '書': true, '館': true}, []string{"図書館で勉強する。", "毎日走る。"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, s := range ScoreExamples(examples, tt.words, tt.kanji) {
+				got = append(got, s.Japanese)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ScoreExamples() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScoreExamplesLists(t *testing.T) {
+	scored := ScoreExamples([]Example{{Japanese: "勉強の後で勉強を見る。"}}, []string{"勉強", "先生", ""}, map[rune]bool{'後': true})
+	if got := scored[0]; !slices.Equal(got.KnownWords, []string{"勉強"}) || !slices.Equal(got.UnknownKanji, []string{"見"}) {
+		t.Errorf("ScoreExamples() = %+v, want 勉強 known and 見 unknown", got)
+	}
+}
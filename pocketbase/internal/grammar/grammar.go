@@ -0,0 +1,168 @@
+package grammar
+
+import (
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/mnemonics"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+type Example struct {
+	Japanese string `json:"japanese"`
+	English  string `json:"english"`
+}
+
+// Grammar is a grammar point, either from the shared library (no user) or
+// added by a user for themselves
+type Grammar struct {
+	Id       string `json:"id"`
+	User     string `json:"user"`
+	Language string `json:"language"`
+	Usage    string `json:"usage"`
+
+	// Romanized is the usage in the latin alphabet, empty when it already is
+	Romanized string `json:"romanized"`
+
+	Meaning  string    `json:"meaning"`
+	Context  string    `json:"context"`
+	Tags     []string  `json:"tags"`
+	Notes    string    `json:"notes"`
+	Nuance   string    `json:"nuance"`
+	Examples []Example `json:"examples"`
+	Source   string    `json:"source"`
+	SourceId string    `json:"source_id"`
+
+	// FrequencyRank is the grammar's rank in the imported frequency list, 1
+	// being the most frequent, zero when it isn't ranked
+	FrequencyRank int `json:"frequency_rank"`
+
+	// SuggestedTags are tags proposed for grammar added without any, until
+	// the user accepts or dismisses them
+	SuggestedTags []string `json:"suggested_tags"`
+}
+
+// IsLibrary reports whether the grammar belongs to the shared library
+func (g Grammar) IsLibrary() bool {
+	return g.User == ""
+}
+
+// Deck is a user's named collection of grammar
+type Deck struct {
+	Id          string         `json:"id"`
+	User        string         `json:"user"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Grammar     []string       `json:"grammar"`
+	Published   bool           `json:"published"`
+	ShareToken  string         `json:"share_token"`
+	Source      string         `json:"source"`
+	SourceId    string         `json:"source_id"`
+	Created     types.DateTime `json:"created"`
+	Updated     types.DateTime `json:"updated"`
+}
+
+func FromRecord(rec *core.Record) Grammar {
+	g := Grammar{
+		Id:        rec.Id,
+		User:      rec.GetString("user"),
+		Language:  rec.GetString("language"),
+		Usage:     rec.GetString("usage"),
+		Romanized: rec.GetString("romanized"),
+		Meaning:   rec.GetString("meaning"),
+		Context:   rec.GetString("context"),
+		Tags:      []string{},
+		Notes:     rec.GetString("notes"),
+		Nuance:    rec.GetString("nuance"),
+		Examples:  []Example{},
+		Source:    rec.GetString("source"),
+		SourceId:  rec.GetString("source_id"),
+
+		FrequencyRank: rec.GetInt("frequency_rank"),
+		SuggestedTags: []string{},
+	}
+	_ = rec.UnmarshalJSONField("tags", &g.Tags)
+	_ = rec.UnmarshalJSONField("suggested_tags", &g.SuggestedTags)
+	_ = rec.UnmarshalJSONField("examples", &g.Examples)
+	return g
+}
+
+func FromRecords(records []*core.Record) []Grammar {
+	items := make([]Grammar, 0, len(records))
+	for _, rec := range records {
+		items = append(items, FromRecord(rec))
+	}
+	return items
+}
+
+func DeckFromRecord(rec *core.Record) Deck {
+	return Deck{
+		Id:          rec.Id,
+		User:        rec.GetString("user"),
+		Name:        rec.GetString("name"),
+		Description: rec.GetString("description"),
+		Grammar:     rec.GetStringSlice("grammar"),
+		Published:   rec.GetBool("published"),
+		ShareToken:  rec.GetString("share_token"),
+		Source:      rec.GetString("source"),
+		SourceId:    rec.GetString("source_id"),
+		Created:     rec.GetDateTime("created"),
+		Updated:     rec.GetDateTime("updated"),
+	}
+}
+
+// ByFrequency orders grammar most frequent first, unranked grammar last
+func ByFrequency(a, b Grammar) int {
+	return CompareRanks(a.FrequencyRank, b.FrequencyRank)
+}
+
+// CompareRanks orders frequency ranks, putting the unranked zero last
+func CompareRanks(a, b int) int {
+	switch {
+	case a == b:
+		return 0
+	case a == 0:
+		return 1
+	case b == 0:
+		return -1
+	}
+	return a - b
+}
+
+// Detail is a grammar with what's shown alongside it on its own page
+type Detail struct {
+	Grammar Grammar `json:"grammar"`
+
+	// Examples are the grammar's examples scored for the user, easiest first
+	Examples []ScoredExample `json:"examples"`
+
+	// Mnemonics are the user's own first, then the community's most voted
+	Mnemonics []mnemonics.Mnemonic `json:"mnemonics"`
+
+	// Related is grammar to look at alongside it, e.g. ones easily confused
+	// with it
+	Related []Related `json:"related"`
+}
+
+// SearchRequest narrows a grammar search, empty fields match everything
+type SearchRequest struct {
+	// Query is matched against usage, its romanization, meaning and tags
+	Query string
+	Tag   string
+
+	// Source is where the grammar came from, e.g. an importer or a capture
+	Source string
+
+	// Mine leaves out the library
+	Mine bool
+
+	// Kana also matches usage against the query typed as romaji converted to
+	// kana, for keyboards without a Japanese IME
+	Kana bool
+}
+
+// SearchResult is one page of a grammar search. Next is the cursor of the
+// following page, empty on the last one.
+type SearchResult struct {
+	Items []Grammar `json:"items"`
+	Next  string    `json:"next"`
+}
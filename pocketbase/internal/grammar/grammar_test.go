@@ -0,0 +1,21 @@
+package grammar
+
+import "testing"
+
+func TestIsLibrary(t *testing.T) {
+	tests := []struct {
+		name    string
+		grammar Grammar
+		library bool
+	}{
+		{"shared", Grammar{Id: "g1"}, true},
+		{"a user's own", Grammar{Id: "g2", User: "u1"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.grammar.IsLibrary(); got != tt.library {
+				t.Errorf("IsLibrary() = %v, want %v", got, tt.library)
+			}
+		})
+	}
+}
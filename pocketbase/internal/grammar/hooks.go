@@ -0,0 +1,31 @@
+package grammar
+
+import (
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/translit"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+func BindHooks(app core.App) {
+	// Keep the romanized usage that search and exports read in step with the
+	// usage, for people without a Japanese keyboard
+	romanize := func(e *core.RecordEvent) error {
+		language := ""
+		if rec, err := e.App.FindRecordById("languages", e.Record.GetString("language")); err == nil {
+			language = rec.GetString("name")
+		}
+		e.Record.Set("romanized", Romanized(language, e.Record.GetString("usage")))
+		return e.Next()
+	}
+	app.OnRecordCreate("grammar").BindFunc(romanize)
+	app.OnRecordUpdate("grammar").BindFunc(romanize)
+}
+
+// Romanized is the usage of a grammar in the given language in the latin
+// alphabet, empty when that changes nothing
+func Romanized(language string, usage string) string {
+	if romanized := translit.Romanize(language, usage); romanized != usage {
+		return romanized
+	}
+	return ""
+}
@@ -0,0 +1,90 @@
+package grammar
+
+import (
+	"slices"
+	"strings"
+)
+
+// Why a grammar is related to another
+const (
+	RelatedByMeaning = "meaning"
+	RelatedByTags    = "tags"
+)
+
+// maxRelated bounds the related grammar shown with a grammar
+const maxRelated = 5
+
+// Related is a grammar worth looking at alongside another, often one it's
+// easily confused with like 〜そうだ and 〜ようだ
+type Related struct {
+	Grammar Grammar `json:"grammar"`
+	Reason  string  `json:"reason"`
+}
+
+// Neighbors finds the grammar a user can see that's closest in meaning to one
+// of them, closest first. The embedding index implements it, and finds none
+// for grammar it hasn't embedded.
+type Neighbors interface {
+	Neighbors(userId string, grammarId string, limit int) ([]string, error)
+}
+
+// relatable reports whether candidate may be shown as related to g, drill
+// grammar being only a copy of a user's mistake
+func relatable(g Grammar, candidate Grammar) bool {
+	return candidate.Id != g.Id && candidate.Language == g.Language && candidate.Source != "drill"
+}
+
+// relatedByTags picks the candidates sharing the most of g's tags, then the
+// ones closest to it in frequency
+func relatedByTags(g Grammar, candidates []Grammar, limit int) []Grammar {
+	type scored struct {
+		grammar Grammar
+		overlap float64
+		gap     int
+	}
+
+	var found []scored
+	for _, candidate := range candidates {
+		if !relatable(g, candidate) {
+			continue
+		}
+		shared := 0
+		for _, tag := range candidate.Tags {
+			if slices.Contains(g.Tags, tag) {
+				shared++
+			}
+		}
+		if shared == 0 {
+			continue
+		}
+		gap := -1
+		if g.FrequencyRank > 0 && candidate.FrequencyRank > 0 {
+			gap = max(g.FrequencyRank-candidate.FrequencyRank, candidate.FrequencyRank-g.FrequencyRank)
+		}
+		union := len(g.Tags) + len(candidate.Tags) - shared
+		found = append(found, scored{grammar: candidate, overlap: float64(shared) / float64(union), gap: gap})
+	}
+
+	slices.SortFunc(found, func(a, b scored) int {
+		switch {
+		case a.overlap != b.overlap:
+			if a.overlap > b.overlap {
+				return -1
+			}
+			return 1
+		case a.gap != b.gap:
+			// unranked grammar goes after ranked
+			if a.gap == -1 || (b.gap != -1 && a.gap > b.gap) {
+				return 1
+			}
+			return -1
+		}
+		return strings.Compare(a.grammar.Usage, b.grammar.Usage)
+	})
+
+	related := make([]Grammar, 0, min(len(found), limit))
+	for _, s := range found[:min(len(found), limit)] {
+		related = append(related, s.grammar)
+	}
+	return related
+}
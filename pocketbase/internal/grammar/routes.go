@@ -0,0 +1,133 @@
+package grammar
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/journal"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/mnemonics"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// Share is a shared deck with the links to hand out
+type Share struct {
+	Deck Deck `json:"deck"`
+
+	// URL serves the deck as JSON for other fushigi apps and instances,
+	// EmbedURL as a page for link previews and embedding
+	URL      string `json:"url"`
+	EmbedURL string `json:"embed_url"`
+}
+
+// libraryMaxAge is how long clients may reuse the library, which only
+// changes when the instance's content does
+const libraryMaxAge = 5 * time.Minute
+
+// RegisterRoutes adds the grammar routes. neighbors, which may be nil, finds
+// related grammar by meaning, knownWords the words examples are scored with.
+func RegisterRoutes(g *api.Group, grammarService Service, journalService journal.Service, mnemonicsService mnemonics.Service, neighbors Neighbors, knownWords KnownWords, conditional *api.Conditional) {
+	g.GET("/library", "Every grammar of the shared library, most frequent first with ?sort=frequency", []Grammar{}, func(e *core.RequestEvent) error {
+		if conditional.NotModified(e, libraryMaxAge, api.SharedScope) {
+			return e.NoContent(http.StatusNotModified)
+		}
+
+		library, err := grammarService.Library()
+		if err != nil {
+			return e.InternalServerError("Failed to load grammar library.", err)
+		}
+		if e.Request.URL.Query().Get("sort") == "frequency" {
+			slices.SortStableFunc(library, ByFrequency)
+		}
+		return conditional.JSON(e, libraryMaxAge, library)
+	})
+
+	g.GET("/grammar", "Search the library and the user's own grammar a page at a time, with ?q=, ?tag=, ?source=, ?mine=true, ?kana=true to convert romaji in ?q= to kana, ?cursor= and ?limit=", SearchResult{}, func(e *core.RequestEvent) error {
+		page, err := api.PageFromRequest(e)
+		if err != nil {
+			return e.BadRequestError("Invalid search request.", err)
+		}
+
+		query := e.Request.URL.Query()
+		req := SearchRequest{
+			Query:  strings.TrimSpace(query.Get("q")),
+			Tag:    query.Get("tag"),
+			Source: query.Get("source"),
+			Mine:   query.Get("mine") == "true",
+			Kana:   query.Get("kana") == "true",
+		}
+
+		result, err := grammarService.Search(e.Auth.Id, req, page)
+		if err != nil {
+			return e.InternalServerError("Failed to search grammar.", err)
+		}
+		return e.JSON(200, result)
+	})
+
+	g.GET("/grammar/{id}", "One grammar the user can see, with its examples easiest first for the user, mnemonics and related grammar", Detail{}, func(e *core.RequestEvent) error {
+		item, err := grammarService.Find(e.Auth.Id, e.Request.PathValue("id"))
+		if err != nil {
+			return e.NotFoundError("", err)
+		}
+
+		found, err := mnemonicsService.ForGrammar(e.Auth, item.Id)
+		if err != nil {
+			return e.InternalServerError("Failed to load mnemonics.", err)
+		}
+		words, err := knownWords.KnownWords(e.Auth.Id)
+		if err != nil {
+			return e.InternalServerError("Failed to load vocabulary.", err)
+		}
+		kanji, err := journalService.WrittenKanji(e.Auth.Id)
+		if err != nil {
+			return e.InternalServerError("Failed to load journal entries.", err)
+		}
+
+		related, err := grammarService.Related(e.Auth.Id, item, neighbors)
+		if err != nil {
+			return e.InternalServerError("Failed to load related grammar.", err)
+		}
+
+		return e.JSON(200, Detail{Grammar: item, Examples: ScoreExamples(item.Examples, words, kanji), Mnemonics: found, Related: related})
+	})
+
+	g.POST("/decks/{id}/share", "Issue a new share link for one of the user's decks, revoking the previous one", nil, Share{}, func(e *core.RequestEvent) error {
+		deck, err := grammarService.ShareDeck(e.Auth.Id, e.Request.PathValue("id"))
+		if err != nil {
+			return e.NotFoundError("", err)
+		}
+		return e.JSON(200, newShare(e, deck))
+	})
+
+	g.DELETE("/decks/{id}/share", "Revoke the share link of one of the user's decks", func(e *core.RequestEvent) error {
+		if err := grammarService.UnshareDeck(e.Auth.Id, e.Request.PathValue("id")); err != nil {
+			return e.NotFoundError("", err)
+		}
+		return e.NoContent(204)
+	})
+
+	g.POST("/shared/{token}/import", "Copy a shared deck into the user's decks", nil, Deck{}, func(e *core.RequestEvent) error {
+		deck, err := grammarService.FindSharedDeck(e.Request.PathValue("token"))
+		if err != nil {
+			return e.NotFoundError("", err)
+		}
+
+		copied, err := grammarService.CopyDeck(e.Auth.Id, deck)
+		if err != nil {
+			return e.InternalServerError("Failed to import the deck.", err)
+		}
+		return e.JSON(200, copied)
+	})
+}
+
+// newShare links to the shared deck routes of the API version the request
+// came in on
+func newShare(e *core.RequestEvent, deck Deck) Share {
+	version := e.Response.Header().Get(api.VersionHeader)
+	base := e.App.Settings().Meta.AppURL + api.BasePath + "/" + version + "/public/shared/" + deck.ShareToken
+
+	return Share{Deck: deck, URL: base, EmbedURL: base + "/embed"}
+}
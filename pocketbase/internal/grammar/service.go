@@ -0,0 +1,351 @@
+package grammar
+
+import (
+	"strings"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/translit"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/security"
+)
+
+// shareTokenLength is long enough that share links can't be guessed
+const shareTokenLength = 32
+
+type Service interface {
+	// Library returns every grammar of the shared library
+	Library() ([]Grammar, error)
+
+	// FindByIds returns the grammar with the given ids, skipping unknown ones
+	FindByIds(ids []string) ([]Grammar, error)
+
+	// Find returns a grammar the user can see, from the library or their own
+	Find(userId string, id string) (Grammar, error)
+
+	// Owned returns the grammar a user added for themselves
+	Owned(userId string) ([]Grammar, error)
+
+	// Search returns a page of the grammar a user can see, the library's and
+	// their own, sorted by usage
+	Search(userId string, req SearchRequest, page api.Page) (SearchResult, error)
+
+	// Related finds up to a handful of grammar the user can see to look at
+	// alongside g: the closest in meaning when neighbors, which may be nil,
+	// finds any, those sharing the most tags otherwise
+	Related(userId string, g Grammar, neighbors Neighbors) ([]Related, error)
+
+	// Languages maps the id of every language to its name
+	Languages() (map[string]string, error)
+
+	// TagsByGrammar maps each of the given grammar ids to its tags
+	TagsByGrammar(ids []string) (map[string][]string, error)
+
+	// Decks returns a user's decks, or only the published ones
+	Decks(userId string, publishedOnly bool) ([]Deck, error)
+
+	// FindPublishedDeck returns a deck only if its owner published it
+	FindPublishedDeck(id string) (Deck, error)
+
+	// ShareDeck gives one of the user's decks a new share token, which stops
+	// any previous link from working
+	ShareDeck(userId string, deckId string) (Deck, error)
+
+	// UnshareDeck revokes the share token of one of the user's decks
+	UnshareDeck(userId string, deckId string) error
+
+	// FindSharedDeck returns the deck a share token was issued for
+	FindSharedDeck(token string) (Deck, error)
+
+	// DeckGrammar returns the grammar of a deck that may be shown to others,
+	// leaving out grammar other users added to it
+	DeckGrammar(deck Deck) ([]Grammar, error)
+
+	// CopyDeck saves a copy of someone's deck as a new deck of the user. The
+	// owner's own grammar is copied along, library grammar is shared.
+	CopyDeck(userId string, deck Deck) (Deck, error)
+}
+
+type service struct {
+	app core.App
+}
+
+func NewService(app core.App) Service {
+	return &service{app: app}
+}
+
+func (s *service) Library() ([]Grammar, error) {
+	records, err := s.app.FindRecordsByFilter("grammar", "user = ''", "", 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	return FromRecords(records), nil
+}
+
+func (s *service) FindByIds(ids []string) ([]Grammar, error) {
+	records, err := s.app.FindRecordsByIds("grammar", ids)
+	if err != nil {
+		return nil, err
+	}
+	return FromRecords(records), nil
+}
+
+func (s *service) Find(userId string, id string) (Grammar, error) {
+	rec, err := s.app.FindFirstRecordByFilter("grammar", "id = {:id} && (user = '' || user = {:user})", map[string]any{"id": id, "user": userId})
+	if err != nil {
+		return Grammar{}, err
+	}
+	return FromRecord(rec), nil
+}
+
+func (s *service) Owned(userId string) ([]Grammar, error) {
+	records, err := s.app.FindRecordsByFilter("grammar", "user = {:user}", "", 0, 0, map[string]any{"user": userId})
+	if err != nil {
+		return nil, err
+	}
+	return FromRecords(records), nil
+}
+
+func (s *service) Search(userId string, req SearchRequest, page api.Page) (SearchResult, error) {
+	params := map[string]any{"user": userId}
+	filters := []string{"(user = '' || user = {:user})"}
+	if req.Mine {
+		filters = []string{"user = {:user}"}
+	}
+	if req.Query != "" {
+		params["query"] = req.Query
+		usage := "usage ~ {:query} || romanized ~ {:query}"
+		if req.Kana {
+			hiragana := translit.ToKana(req.Query)
+			params["hiragana"] = hiragana
+			params["katakana"] = translit.ToKatakana(hiragana)
+			usage += " || usage ~ {:hiragana} || usage ~ {:katakana}"
+		}
+		filters = append(filters, "("+usage+" || meaning ~ {:query} || tags ~ {:query})")
+	}
+	if req.Tag != "" {
+		// tags is a JSON array, so look for the quoted tag
+		params["tag"] = `"` + req.Tag + `"`
+		filters = append(filters, "tags ~ {:tag}")
+	}
+	if req.Source != "" {
+		params["source"] = req.Source
+		filters = append(filters, "source = {:source}")
+	}
+	if keyset := page.KeysetFilter("usage", params); keyset != "" {
+		filters = append(filters, keyset)
+	}
+
+	records, err := s.app.FindRecordsByFilter("grammar", strings.Join(filters, " && "), "usage,id", page.Limit+1, 0, params)
+	if err != nil {
+		return SearchResult{}, err
+	}
+
+	items := FromRecords(records)
+	next := page.Next(len(items), func(i int) api.Cursor {
+		return api.Cursor{Value: items[i].Usage, Id: items[i].Id}
+	})
+	if len(items) > page.Limit {
+		items = items[:page.Limit]
+	}
+	return SearchResult{Items: items, Next: next}, nil
+}
+
+func (s *service) Related(userId string, g Grammar, neighbors Neighbors) ([]Related, error) {
+	if neighbors != nil {
+		// extra neighbors make up for the ones that can't be shown
+		ids, err := neighbors.Neighbors(userId, g.Id, 2*maxRelated)
+		if err != nil {
+			s.app.Logger().Warn("Failed to find grammar close in meaning, using tags", "grammar", g.Id, "error", err)
+		}
+		found, err := s.FindByIds(ids)
+		if err != nil {
+			return nil, err
+		}
+		byId := make(map[string]Grammar, len(found))
+		for _, candidate := range found {
+			byId[candidate.Id] = candidate
+		}
+
+		related := []Related{}
+		for _, id := range ids {
+			if candidate, ok := byId[id]; ok && relatable(g, candidate) && len(related) < maxRelated {
+				related = append(related, Related{Grammar: candidate, Reason: RelatedByMeaning})
+			}
+		}
+		if len(related) > 0 {
+			return related, nil
+		}
+	}
+
+	library, err := s.Library()
+	if err != nil {
+		return nil, err
+	}
+	owned, err := s.Owned(userId)
+	if err != nil {
+		return nil, err
+	}
+	related := []Related{}
+	for _, candidate := range relatedByTags(g, append(library, owned...), maxRelated) {
+		related = append(related, Related{Grammar: candidate, Reason: RelatedByTags})
+	}
+	return related, nil
+}
+
+func (s *service) Languages() (map[string]string, error) {
+	records, err := s.app.FindAllRecords("languages")
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]string, len(records))
+	for _, rec := range records {
+		names[rec.Id] = rec.GetString("name")
+	}
+	return names, nil
+}
+
+func (s *service) TagsByGrammar(ids []string) (map[string][]string, error) {
+	items, err := s.FindByIds(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make(map[string][]string, len(items))
+	for _, item := range items {
+		tags[item.Id] = item.Tags
+	}
+	return tags, nil
+}
+
+func (s *service) Decks(userId string, publishedOnly bool) ([]Deck, error) {
+	filter := "user = {:user}"
+	if publishedOnly {
+		filter += " && published = true"
+	}
+
+	records, err := s.app.FindRecordsByFilter("decks", filter, "name", 0, 0, map[string]any{"user": userId})
+	if err != nil {
+		return nil, err
+	}
+
+	decks := make([]Deck, 0, len(records))
+	for _, rec := range records {
+		decks = append(decks, DeckFromRecord(rec))
+	}
+	return decks, nil
+}
+
+func (s *service) FindPublishedDeck(id string) (Deck, error) {
+	rec, err := s.app.FindFirstRecordByFilter("decks", "id = {:id} && published = true", map[string]any{"id": id})
+	if err != nil {
+		return Deck{}, err
+	}
+	return DeckFromRecord(rec), nil
+}
+
+func (s *service) ShareDeck(userId string, deckId string) (Deck, error) {
+	rec, err := s.findUserDeck(userId, deckId)
+	if err != nil {
+		return Deck{}, err
+	}
+
+	rec.Set("share_token", security.RandomString(shareTokenLength))
+	if err := s.app.Save(rec); err != nil {
+		return Deck{}, err
+	}
+	return DeckFromRecord(rec), nil
+}
+
+func (s *service) UnshareDeck(userId string, deckId string) error {
+	rec, err := s.findUserDeck(userId, deckId)
+	if err != nil {
+		return err
+	}
+
+	rec.Set("share_token", "")
+	return s.app.Save(rec)
+}
+
+func (s *service) FindSharedDeck(token string) (Deck, error) {
+	rec, err := s.app.FindFirstRecordByFilter("decks", "share_token != '' && share_token = {:token}", map[string]any{"token": token})
+	if err != nil {
+		return Deck{}, err
+	}
+	return DeckFromRecord(rec), nil
+}
+
+func (s *service) DeckGrammar(deck Deck) ([]Grammar, error) {
+	items, err := s.FindByIds(deck.Grammar)
+	if err != nil {
+		return nil, err
+	}
+
+	visible := make([]Grammar, 0, len(items))
+	for _, item := range items {
+		// never leak someone else's private grammar through a deck
+		if !item.IsLibrary() && item.User != deck.User {
+			continue
+		}
+		visible = append(visible, item)
+	}
+	return visible, nil
+}
+
+func (s *service) CopyDeck(userId string, deck Deck) (Deck, error) {
+	items, err := s.DeckGrammar(deck)
+	if err != nil {
+		return Deck{}, err
+	}
+
+	var copied *core.Record
+	err = s.app.RunInTransaction(func(txApp core.App) error {
+		grammarCollection, err := txApp.FindCollectionByNameOrId("grammar")
+		if err != nil {
+			return err
+		}
+		decksCollection, err := txApp.FindCollectionByNameOrId("decks")
+		if err != nil {
+			return err
+		}
+
+		ids := make([]string, 0, len(items))
+		for _, item := range items {
+			if item.IsLibrary() || item.User == userId {
+				ids = append(ids, item.Id)
+				continue
+			}
+
+			rec := core.NewRecord(grammarCollection)
+			rec.Set("user", userId)
+			rec.Set("language", item.Language)
+			rec.Set("usage", item.Usage)
+			rec.Set("meaning", item.Meaning)
+			rec.Set("context", item.Context)
+			rec.Set("tags", item.Tags)
+			rec.Set("notes", item.Notes)
+			rec.Set("nuance", item.Nuance)
+			rec.Set("examples", item.Examples)
+			if err := txApp.Save(rec); err != nil {
+				return err
+			}
+			ids = append(ids, rec.Id)
+		}
+
+		copied = core.NewRecord(decksCollection)
+		copied.Set("user", userId)
+		copied.Set("name", deck.Name)
+		copied.Set("description", deck.Description)
+		copied.Set("grammar", ids)
+		return txApp.Save(copied)
+	})
+	if err != nil {
+		return Deck{}, err
+	}
+	return DeckFromRecord(copied), nil
+}
+
+func (s *service) findUserDeck(userId string, deckId string) (*core.Record, error) {
+	return s.app.FindFirstRecordByFilter("decks", "id = {:id} && user = {:user}", map[string]any{"id": deckId, "user": userId})
+}
@@ -0,0 +1,168 @@
+package grammar_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/grammar"
+	_ "github.com/bunkbed-tech/fushigi/pocketbase/migrations"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+// newTestApp is a migrated app with one user
+func newTestApp(t *testing.T) (*tests.TestApp, *core.Record) {
+	t.Helper()
+	// the initial migration creates a superuser from these
+	t.Setenv("ADMIN_EMAIL", "admin@example.com")
+	t.Setenv("ADMIN_PASSWORD", "correct horse battery")
+	app, err := tests.NewTestApp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(app.Cleanup)
+	if err := app.RunAllMigrations(); err != nil {
+		t.Fatal(err)
+	}
+
+	users, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := core.NewRecord(users)
+	user.SetEmail("learner@example.com")
+	user.SetPassword("correct horse battery")
+	if err := app.Save(user); err != nil {
+		t.Fatal(err)
+	}
+	return app, user
+}
+
+func saveDeck(t *testing.T, app core.App, userId string, name string, published bool) *core.Record {
+	t.Helper()
+	decks, err := app.FindCollectionByNameOrId("decks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	deck := core.NewRecord(decks)
+	deck.Set("user", userId)
+	deck.Set("name", name)
+	deck.Set("published", published)
+	if err := app.Save(deck); err != nil {
+		t.Fatal(err)
+	}
+	return deck
+}
+
+func deckNames(decks []grammar.Deck) []string {
+	names := make([]string, 0, len(decks))
+	for _, deck := range decks {
+		names = append(names, deck.Name)
+	}
+	return names
+}
+
+func TestDecks(t *testing.T) {
+	app, user := newTestApp(t)
+	saveDeck(t, app, user.Id, "Verbs", false)
+	saveDeck(t, app, user.Id, "Particles", true)
+	service := grammar.NewService(app)
+
+	tests := []struct {
+		name          string
+		userId        string
+		publishedOnly bool
+		want          []string
+	}{
+		{"every deck by name", user.Id, false, []string{"Particles", "Verbs"}},
+		{"published only", user.Id, true, []string{"Particles"}},
+		{"someone else's", "missing", false, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decks, err := service.Decks(tt.userId, tt.publishedOnly)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := deckNames(decks); !slices.Equal(got, tt.want) {
+				t.Errorf("Decks() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindPublishedDeck(t *testing.T) {
+	app, user := newTestApp(t)
+	private := saveDeck(t, app, user.Id, "Verbs", false)
+	published := saveDeck(t, app, user.Id, "Particles", true)
+	service := grammar.NewService(app)
+
+	tests := []struct {
+		name  string
+		id    string
+		found bool
+	}{
+		{"published", published.Id, true},
+		{"not published", private.Id, false},
+		{"missing", "missing", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deck, err := service.FindPublishedDeck(tt.id)
+			if found := err == nil; found != tt.found {
+				t.Fatalf("FindPublishedDeck() error = %v, want found %v", err, tt.found)
+			}
+			if tt.found && deck.Id != tt.id {
+				t.Errorf("FindPublishedDeck() = %s, want %s", deck.Id, tt.id)
+			}
+		})
+	}
+}
+
+func TestFindByIds(t *testing.T) {
+	app, _ := newTestApp(t)
+	service := grammar.NewService(app)
+
+	library, err := service.Library()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(library) < 2 {
+		t.Fatalf("got %d library grammar, want the seeded ones", len(library))
+	}
+	for _, item := range library {
+		if !item.IsLibrary() {
+			t.Errorf("Library() returned %s, a user's grammar", item.Id)
+		}
+	}
+
+	tests := []struct {
+		name string
+		ids  []string
+		want int
+	}{
+		{"none", []string{}, 0},
+		{"known", []string{library[0].Id, library[1].Id}, 2},
+		{"unknown ones skipped", []string{library[0].Id, "missing"}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found, err := service.FindByIds(tt.ids)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(found) != tt.want {
+				t.Errorf("FindByIds() found %d, want %d", len(found), tt.want)
+			}
+
+			tags, err := service.TagsByGrammar(tt.ids)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(tags) != tt.want {
+				t.Errorf("TagsByGrammar() mapped %d, want %d", len(tags), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,434 @@
+package graph
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/grammar"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/journal"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/sessions"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/srs"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+type idArgs struct {
+	ID graphql.ID
+}
+
+type pageArgs struct {
+	First *int32
+	After *string
+}
+
+func (r *root) Me(ctx context.Context) (*userResolver, error) {
+	return &userResolver{r: r, rec: viewerFrom(ctx).auth}, nil
+}
+
+func (r *root) User(ctx context.Context, args idArgs) (*userResolver, error) {
+	v := viewerFrom(ctx)
+	rec, err := r.app.FindRecordById("users", string(args.ID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, r.fail(err)
+	}
+	if ok, err := r.canView(v, rec); err != nil {
+		return nil, r.fail(err)
+	} else if !ok {
+		return nil, nil
+	}
+	return &userResolver{r: r, rec: rec}, nil
+}
+
+func (r *root) Grammar(ctx context.Context, args idArgs) (*grammarResolver, error) {
+	g, ok, err := r.grammar(viewerFrom(ctx), string(args.ID))
+	if err != nil {
+		return nil, r.fail(err)
+	} else if !ok {
+		return nil, nil
+	}
+	return &grammarResolver{r: r, g: g}, nil
+}
+
+type searchArgs struct {
+	Query *string
+	Tag   *string
+	Mine  *bool
+	First *int32
+	After *string
+}
+
+func (r *root) SearchGrammar(ctx context.Context, args searchArgs) (*grammarPageResolver, error) {
+	page, err := pageOf(args.First, args.After)
+	if err != nil {
+		return nil, err
+	}
+	req := grammar.SearchRequest{Kana: true}
+	if args.Query != nil {
+		req.Query = *args.Query
+	}
+	if args.Tag != nil {
+		req.Tag = *args.Tag
+	}
+	if args.Mine != nil {
+		req.Mine = *args.Mine
+	}
+
+	result, err := r.grammarService.Search(viewerFrom(ctx).auth.Id, req, page)
+	if err != nil {
+		return nil, r.fail(err)
+	}
+	items := make([]*grammarResolver, 0, len(result.Items))
+	for _, g := range result.Items {
+		items = append(items, &grammarResolver{r: r, g: g})
+	}
+	return &grammarPageResolver{items: items, next: result.Next}, nil
+}
+
+func (r *root) Entry(ctx context.Context, args idArgs) (*entryResolver, error) {
+	v := viewerFrom(ctx)
+	rec, err := r.app.FindRecordById("journal_entry", string(args.ID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, r.fail(err)
+	}
+	if ok, err := r.canView(v, rec); err != nil {
+		return nil, r.fail(err)
+	} else if !ok {
+		return nil, nil
+	}
+	return &entryResolver{r: r, entry: journal.FromRecord(rec)}, nil
+}
+
+func (r *root) Due(ctx context.Context) (*dueListResolver, error) {
+	list, err := srs.Due(r.srsService, r.grammarService, r.settingsService, viewerFrom(ctx).auth.Id, time.Now())
+	if err != nil {
+		return nil, r.fail(err)
+	}
+	items := make([]*cardResolver, 0, len(list.Items))
+	for _, item := range list.Items {
+		items = append(items, &cardResolver{r: r, c: item.Card})
+	}
+	return &dueListResolver{items: items, list: list}, nil
+}
+
+type userResolver struct {
+	r   *root
+	rec *core.Record
+}
+
+func (u *userResolver) ID() graphql.ID {
+	return graphql.ID(u.rec.Id)
+}
+
+func (u *userResolver) Name() string {
+	return u.rec.GetString("name")
+}
+
+func (u *userResolver) Stats(ctx context.Context) (*statsResolver, error) {
+	v := viewerFrom(ctx)
+	if u.rec.Id != v.auth.Id {
+		userSettings, err := u.r.settingsService.ForUser(u.rec.Id)
+		if err != nil {
+			return nil, u.r.fail(err)
+		}
+		visible, err := u.r.settingsService.Visible(v.auth.Id, u.rec.Id, userSettings.StatsVisibility)
+		if err != nil {
+			return nil, u.r.fail(err)
+		}
+		if !visible {
+			return nil, errForbidden
+		}
+	}
+
+	report, err := srs.Report(u.r.srsService, u.r.grammarService, u.r.sessionsService, u.rec.Id, v.auth.Id, time.Now())
+	if err != nil {
+		return nil, u.r.fail(err)
+	}
+	return &statsResolver{report: report}, nil
+}
+
+func (u *userResolver) Entries(ctx context.Context, args pageArgs) (*entryPageResolver, error) {
+	page, err := pageOf(args.First, args.After)
+	if err != nil {
+		return nil, err
+	}
+	records, err := u.r.listVisible(viewerFrom(ctx), "journal_entry", "user = {:user}", dbx.Params{"user": u.rec.Id}, page)
+	if err != nil {
+		return nil, u.r.fail(err)
+	}
+
+	entries := make([]journal.Entry, 0, len(records))
+	for _, rec := range records {
+		entries = append(entries, journal.FromRecord(rec))
+	}
+	next := page.Next(len(entries), func(i int) api.Cursor {
+		return api.Cursor{Value: entries[i].Created.String(), Id: entries[i].Id}
+	})
+	items := make([]*entryResolver, 0, min(len(entries), page.Limit))
+	for _, entry := range entries[:min(len(entries), page.Limit)] {
+		items = append(items, &entryResolver{r: u.r, entry: entry})
+	}
+	return &entryPageResolver{items: items, next: next}, nil
+}
+
+func (u *userResolver) Cards(ctx context.Context) (*[]*cardResolver, error) {
+	if u.rec.Id != viewerFrom(ctx).auth.Id {
+		return nil, errForbidden
+	}
+	cards, err := u.r.srsService.Cards(u.rec.Id)
+	if err != nil {
+		return nil, u.r.fail(err)
+	}
+	items := make([]*cardResolver, 0, len(cards))
+	for _, card := range cards {
+		items = append(items, &cardResolver{r: u.r, c: card})
+	}
+	return &items, nil
+}
+
+type grammarResolver struct {
+	r *root
+	g grammar.Grammar
+}
+
+func (g *grammarResolver) ID() graphql.ID       { return graphql.ID(g.g.Id) }
+func (g *grammarResolver) Usage() string        { return g.g.Usage }
+func (g *grammarResolver) Romanized() string    { return g.g.Romanized }
+func (g *grammarResolver) Meaning() string      { return g.g.Meaning }
+func (g *grammarResolver) Context() string      { return g.g.Context }
+func (g *grammarResolver) Tags() []string       { return g.g.Tags }
+func (g *grammarResolver) Notes() string        { return g.g.Notes }
+func (g *grammarResolver) Nuance() string       { return g.g.Nuance }
+func (g *grammarResolver) FrequencyRank() int32 { return int32(g.g.FrequencyRank) }
+func (g *grammarResolver) Library() bool        { return g.g.IsLibrary() }
+
+func (g *grammarResolver) Examples() []*exampleResolver {
+	examples := make([]*exampleResolver, 0, len(g.g.Examples))
+	for _, example := range g.g.Examples {
+		examples = append(examples, &exampleResolver{example})
+	}
+	return examples
+}
+
+func (g *grammarResolver) Card(ctx context.Context) (*cardResolver, error) {
+	card, ok, err := g.r.card(viewerFrom(ctx), g.g.Id)
+	if err != nil {
+		return nil, g.r.fail(err)
+	} else if !ok {
+		return nil, nil
+	}
+	return &cardResolver{r: g.r, c: card}, nil
+}
+
+type exampleResolver struct {
+	e grammar.Example
+}
+
+func (e *exampleResolver) Japanese() string { return e.e.Japanese }
+func (e *exampleResolver) English() string  { return e.e.English }
+
+type grammarPageResolver struct {
+	items []*grammarResolver
+	next  string
+}
+
+func (p *grammarPageResolver) Items() []*grammarResolver { return p.items }
+func (p *grammarPageResolver) Next() string              { return p.next }
+
+type cardResolver struct {
+	r *root
+	c srs.Card
+}
+
+func (c *cardResolver) ID() graphql.ID      { return graphql.ID(c.c.Id) }
+func (c *cardResolver) EaseFactor() float64 { return c.c.EaseFactor }
+func (c *cardResolver) IntervalDays() int32 { return int32(c.c.IntervalDays) }
+func (c *cardResolver) Repetition() int32   { return int32(c.c.Repetition) }
+func (c *cardResolver) Stage() string       { return c.c.DerivedStage() }
+func (c *cardResolver) Confidence() int32   { return int32(c.c.Confidence) }
+func (c *cardResolver) DueDate() string     { return c.c.DueDate().Format(time.RFC3339) }
+func (c *cardResolver) New() bool           { return c.c.IsNew() }
+func (c *cardResolver) Due() bool           { return c.c.IsDue(time.Now()) }
+
+func (c *cardResolver) LastReviewed() *string {
+	if c.c.LastReviewed.IsZero() {
+		return nil
+	}
+	reviewed := c.c.LastReviewed.Format(time.RFC3339)
+	return &reviewed
+}
+
+func (c *cardResolver) Grammar(ctx context.Context) (*grammarResolver, error) {
+	g, ok, err := c.r.grammar(viewerFrom(ctx), c.c.Grammar)
+	if err != nil {
+		return nil, c.r.fail(err)
+	} else if !ok {
+		return nil, nil
+	}
+	return &grammarResolver{r: c.r, g: g}, nil
+}
+
+type dueListResolver struct {
+	items []*cardResolver
+	list  srs.DueList
+}
+
+func (d *dueListResolver) Items() []*cardResolver { return d.items }
+func (d *dueListResolver) NewLeft() int32         { return int32(d.list.NewLeft) }
+func (d *dueListResolver) ReviewsLeft() int32     { return int32(d.list.ReviewsLeft) }
+
+type entryResolver struct {
+	r     *root
+	entry journal.Entry
+}
+
+func (e *entryResolver) ID() graphql.ID   { return graphql.ID(e.entry.Id) }
+func (e *entryResolver) Title() string    { return e.entry.Title }
+func (e *entryResolver) Content() string  { return e.entry.Content }
+func (e *entryResolver) Audience() string { return e.entry.Audience }
+func (e *entryResolver) Topics() []string { return e.entry.Topics }
+func (e *entryResolver) Created() string  { return e.entry.Created.String() }
+
+func (e *entryResolver) Author(ctx context.Context) (*userResolver, error) {
+	return e.r.User(ctx, idArgs{ID: graphql.ID(e.entry.User)})
+}
+
+func (e *entryResolver) Sentences(ctx context.Context) (*[]*sentenceResolver, error) {
+	if e.entry.User != viewerFrom(ctx).auth.Id {
+		return nil, errForbidden
+	}
+	byEntry, err := e.r.journalService.Sentences([]string{e.entry.Id})
+	if err != nil {
+		return nil, e.r.fail(err)
+	}
+	items := make([]*sentenceResolver, 0, len(byEntry[e.entry.Id]))
+	for _, sentence := range byEntry[e.entry.Id] {
+		items = append(items, &sentenceResolver{r: e.r, s: sentence})
+	}
+	return &items, nil
+}
+
+type entryPageResolver struct {
+	items []*entryResolver
+	next  string
+}
+
+func (p *entryPageResolver) Items() []*entryResolver { return p.items }
+func (p *entryPageResolver) Next() string            { return p.next }
+
+type sentenceResolver struct {
+	r *root
+	s journal.Sentence
+}
+
+func (s *sentenceResolver) ID() graphql.ID  { return graphql.ID(s.s.Id) }
+func (s *sentenceResolver) Content() string { return s.s.Content }
+
+func (s *sentenceResolver) Grammar(ctx context.Context) (*grammarResolver, error) {
+	g, ok, err := s.r.grammar(viewerFrom(ctx), s.s.Grammar)
+	if err != nil {
+		return nil, s.r.fail(err)
+	} else if !ok {
+		return nil, nil
+	}
+	return &grammarResolver{r: s.r, g: g}, nil
+}
+
+type statsResolver struct {
+	report srs.StatsReport
+}
+
+func (s *statsResolver) Overall() *cardStatsResolver {
+	return &cardStatsResolver{s.report.Overall}
+}
+
+func (s *statsResolver) ByTag() []*tagStatsResolver {
+	tags := make([]string, 0, len(s.report.ByTag))
+	for tag := range s.report.ByTag {
+		tags = append(tags, tag)
+	}
+	slices.Sort(tags)
+
+	items := make([]*tagStatsResolver, 0, len(tags))
+	for _, tag := range tags {
+		items = append(items, &tagStatsResolver{tag: tag, stats: s.report.ByTag[tag]})
+	}
+	return items
+}
+
+func (s *statsResolver) ByDeck() []*deckStatsResolver {
+	items := make([]*deckStatsResolver, 0, len(s.report.ByDeck))
+	for _, deck := range s.report.ByDeck {
+		items = append(items, &deckStatsResolver{deck})
+	}
+	return items
+}
+
+func (s *statsResolver) Time() *studyTimeResolver {
+	return &studyTimeResolver{s.report.Time}
+}
+
+type cardStatsResolver struct {
+	s *srs.Stats
+}
+
+func (c *cardStatsResolver) Total() int32       { return int32(c.s.Total) }
+func (c *cardStatsResolver) New() int32         { return int32(c.s.New) }
+func (c *cardStatsResolver) Young() int32       { return int32(c.s.Young) }
+func (c *cardStatsResolver) Mature() int32      { return int32(c.s.Mature) }
+func (c *cardStatsResolver) DueNow() int32      { return int32(c.s.DueNow) }
+func (c *cardStatsResolver) Workload() int32    { return int32(c.s.Workload) }
+func (c *cardStatsResolver) Reviewed() int32    { return int32(c.s.Reviewed) }
+func (c *cardStatsResolver) Retention() float64 { return c.s.Retention }
+
+func (c *cardStatsResolver) Stages() []*stageCountResolver {
+	items := make([]*stageCountResolver, 0, len(srs.Stages))
+	for _, stage := range srs.Stages {
+		items = append(items, &stageCountResolver{stage: stage, cards: c.s.Stages[stage]})
+	}
+	return items
+}
+
+type stageCountResolver struct {
+	stage string
+	cards int
+}
+
+func (s *stageCountResolver) Stage() string { return s.stage }
+func (s *stageCountResolver) Cards() int32  { return int32(s.cards) }
+
+type tagStatsResolver struct {
+	tag   string
+	stats *srs.Stats
+}
+
+func (t *tagStatsResolver) Tag() string               { return t.tag }
+func (t *tagStatsResolver) Stats() *cardStatsResolver { return &cardStatsResolver{t.stats} }
+
+type deckStatsResolver struct {
+	d *srs.DeckStats
+}
+
+func (d *deckStatsResolver) ID() graphql.ID            { return graphql.ID(d.d.Id) }
+func (d *deckStatsResolver) Name() string              { return d.d.Name }
+func (d *deckStatsResolver) Stats() *cardStatsResolver { return &cardStatsResolver{d.d.Stats} }
+
+type studyTimeResolver struct {
+	t sessions.Totals
+}
+
+func (t *studyTimeResolver) Day() int32       { return int32(t.t.Day) }
+func (t *studyTimeResolver) Week() int32      { return int32(t.t.Week) }
+func (t *studyTimeResolver) Month() int32     { return int32(t.t.Month) }
+func (t *studyTimeResolver) Total() int32     { return int32(t.t.Total) }
+func (t *studyTimeResolver) Sessions() int32  { return int32(t.t.Sessions) }
+func (t *studyTimeResolver) Completed() int32 { return int32(t.t.Completed) }
@@ -0,0 +1,33 @@
+package graph
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+func RegisterRoutes(g *api.Group, graphService Service) {
+	g.POST("/graphql", "Read grammar, srs, journal and stats in one GraphQL query, fields the user can't see resolving to errors", Request{}, graphql.Response{}, func(e *core.RequestEvent) error {
+		var req Request
+		if err := e.BindBody(&req); err != nil {
+			return e.BadRequestError("Invalid request body.", err)
+		}
+		if strings.TrimSpace(req.Query) == "" {
+			return e.BadRequestError("The GraphQL query is missing.", nil)
+		}
+
+		resp, err := graphService.Exec(e, req)
+		switch {
+		case errors.Is(err, ErrDisabled):
+			return e.NotFoundError("", err)
+		case err != nil:
+			return e.InternalServerError("Failed to run the GraphQL query.", err)
+		}
+		// errors of the query itself are part of a GraphQL response
+		return e.JSON(200, resp)
+	})
+}
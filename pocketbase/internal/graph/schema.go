@@ -0,0 +1,174 @@
+package graph
+
+// schema is read-only: writes go through the REST routes, which have the
+// validation and hooks. Fields of other users' data resolve only when the
+// same rules that guard the collections let the viewer see it.
+const schema = `
+schema {
+	query: Query
+}
+
+type Query {
+	"The logged in user"
+	me: User!
+
+	"A user whose profile the viewer can see"
+	user(id: ID!): User
+
+	"A grammar point from the library or the viewer's own"
+	grammar(id: ID!): Grammar
+
+	"Grammar matching query against usage, romanization, meaning and tags"
+	searchGrammar(query: String, tag: String, mine: Boolean, first: Int, after: String): GrammarPage!
+
+	"A journal entry the viewer can see"
+	entry(id: ID!): Entry
+
+	"What the viewer has left to study today within their daily limits"
+	due: DueList!
+}
+
+type User {
+	id: ID!
+	name: String!
+
+	"Null with an error unless the user shows their stats to the viewer"
+	stats: Stats
+
+	"The user's entries the viewer can see, newest first"
+	entries(first: Int, after: String): EntryPage!
+
+	"Null with an error for anyone but the user themselves"
+	cards: [Card!]
+}
+
+type Grammar {
+	id: ID!
+	usage: String!
+	romanized: String!
+	meaning: String!
+	context: String!
+	tags: [String!]!
+	notes: String!
+	nuance: String!
+	examples: [Example!]!
+	"Rank in the frequency list, 1 being the most frequent, 0 when unranked"
+	frequencyRank: Int!
+	"Whether it's from the shared library rather than the viewer's own"
+	library: Boolean!
+	"The viewer's card for it, null without one"
+	card: Card
+}
+
+type Example {
+	japanese: String!
+	english: String!
+}
+
+type GrammarPage {
+	items: [Grammar!]!
+	"Cursor of the next page, empty on the last one"
+	next: String!
+}
+
+type Card {
+	id: ID!
+	grammar: Grammar
+	easeFactor: Float!
+	intervalDays: Int!
+	repetition: Int!
+	stage: String!
+	confidence: Int!
+	"RFC 3339, null for cards never reviewed"
+	lastReviewed: String
+	"RFC 3339"
+	dueDate: String!
+	new: Boolean!
+	due: Boolean!
+}
+
+type DueList {
+	"Due reviews and new cards, new cards spread among the reviews"
+	items: [Card!]!
+	newLeft: Int!
+	reviewsLeft: Int!
+}
+
+type Entry {
+	id: ID!
+	title: String!
+	content: String!
+	audience: String!
+	topics: [String!]!
+	created: String!
+	"Null when the viewer can't see the writer's profile"
+	author: User
+	"The sentences practicing grammar, only the writer's to see"
+	sentences: [Sentence!]
+}
+
+type EntryPage {
+	items: [Entry!]!
+	"Cursor of the next page, empty on the last one"
+	next: String!
+}
+
+type Sentence {
+	id: ID!
+	content: String!
+	grammar: Grammar
+}
+
+type Stats {
+	overall: CardStats!
+	byTag: [TagStats!]!
+	"Only for the viewer's own stats"
+	byDeck: [DeckStats!]!
+	time: StudyTime!
+}
+
+type CardStats {
+	total: Int!
+	new: Int!
+	young: Int!
+	mature: Int!
+	dueNow: Int!
+	"Cards due over the next week"
+	workload: Int!
+	reviewed: Int!
+	retention: Float!
+	stages: [StageCount!]!
+}
+
+type StageCount {
+	stage: String!
+	cards: Int!
+}
+
+type TagStats {
+	tag: String!
+	stats: CardStats!
+}
+
+type DeckStats {
+	id: ID!
+	name: String!
+	stats: CardStats!
+}
+
+"Time spent in writing and review sessions"
+type StudyTime {
+	"Seconds studied today"
+	day: Int!
+	"Seconds studied this week"
+	week: Int!
+	"Seconds studied this month"
+	month: Int!
+	"Seconds studied in all"
+	total: Int!
+	"How many sessions there were"
+	sessions: Int!
+	"How many of them were completed"
+	completed: Int!
+}
+`
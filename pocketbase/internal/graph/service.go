@@ -0,0 +1,254 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/grammar"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/journal"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/sessions"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/settings"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/srs"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/search"
+)
+
+const (
+	// maxDepth and maxQueryLength keep a query from fanning out over the
+	// whole database
+	maxDepth       = 8
+	maxQueryLength = 10000
+
+	defaultFirst = 20
+	maxFirst     = 100
+)
+
+var (
+	// ErrDisabled means the GraphQL endpoint is turned off
+	ErrDisabled = errors.New("the GraphQL endpoint is turned off")
+
+	// errForbidden is what fields the viewer isn't allowed to see fail with
+	errForbidden = errors.New("not allowed")
+
+	// errInternal stands in for errors clients shouldn't see the details of,
+	// which are logged
+	errInternal = errors.New("something went wrong")
+)
+
+// EnabledFromEnv reads GRAPHQL_ENABLED, the endpoint being off unless it's
+// true
+func EnabledFromEnv() bool {
+	on, _ := strconv.ParseBool(os.Getenv("GRAPHQL_ENABLED"))
+	return on
+}
+
+// Request is a GraphQL request as clients POST it
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+type Service interface {
+	// Enabled reports whether the endpoint is on
+	Enabled() bool
+
+	// Exec runs a query as the user making the request
+	Exec(e *core.RequestEvent, req Request) (*graphql.Response, error)
+}
+
+type service struct {
+	enabled bool
+	schema  *graphql.Schema
+}
+
+func NewService(app core.App, enabled bool, grammarService grammar.Service, journalService journal.Service, srsService srs.Service, sessionsService sessions.Service, settingsService settings.Service) Service {
+	r := &root{
+		app:             app,
+		grammarService:  grammarService,
+		journalService:  journalService,
+		srsService:      srsService,
+		sessionsService: sessionsService,
+		settingsService: settingsService,
+	}
+	return &service{
+		enabled: enabled,
+		schema: graphql.MustParseSchema(schema, r,
+			graphql.MaxDepth(maxDepth),
+			graphql.MaxQueryLength(maxQueryLength),
+			graphql.Logger(panicLogger{app}),
+		),
+	}
+}
+
+func (s *service) Enabled() bool {
+	return s.enabled
+}
+
+func (s *service) Exec(e *core.RequestEvent, req Request) (*graphql.Response, error) {
+	if !s.enabled {
+		return nil, ErrDisabled
+	}
+	info, err := e.RequestInfo()
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.WithValue(e.Request.Context(), viewerKey{}, &viewer{auth: e.Auth, info: info})
+	return s.schema.Exec(ctx, req.Query, req.OperationName, req.Variables), nil
+}
+
+type viewerKey struct{}
+
+// viewer is who a query runs as, with what its resolvers load more than once
+type viewer struct {
+	auth *core.Record
+	info *core.RequestInfo
+
+	mu      sync.Mutex
+	grammar map[string]grammar.Grammar
+
+	// the viewer's cards by grammar
+	cardsOnce sync.Once
+	cards     map[string]srs.Card
+	cardsErr  error
+}
+
+func viewerFrom(ctx context.Context) *viewer {
+	return ctx.Value(viewerKey{}).(*viewer)
+}
+
+// root resolves the queries, the services being shared by every request
+type root struct {
+	app             core.App
+	grammarService  grammar.Service
+	journalService  journal.Service
+	srsService      srs.Service
+	sessionsService sessions.Service
+	settingsService settings.Service
+}
+
+// fail logs an error and hides it from the client
+func (r *root) fail(err error) error {
+	r.app.Logger().Error("Failed to resolve a GraphQL field", "error", err)
+	return errInternal
+}
+
+// grammar loads a grammar point the viewer can see once per request, false
+// when there is none
+func (r *root) grammar(v *viewer, id string) (grammar.Grammar, bool, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.grammar == nil {
+		v.grammar = map[string]grammar.Grammar{}
+	}
+	if g, ok := v.grammar[id]; ok {
+		return g, true, nil
+	}
+
+	found, err := r.grammarService.FindByIds([]string{id})
+	if err != nil {
+		return grammar.Grammar{}, false, err
+	}
+	if len(found) == 0 || (!found[0].IsLibrary() && found[0].User != v.auth.Id) {
+		return grammar.Grammar{}, false, nil
+	}
+	v.grammar[id] = found[0]
+	return found[0], true, nil
+}
+
+// card returns the viewer's card for a grammar point, false without one
+func (r *root) card(v *viewer, grammarId string) (srs.Card, bool, error) {
+	v.cardsOnce.Do(func() {
+		cards, err := r.srsService.Cards(v.auth.Id)
+		if err != nil {
+			v.cardsErr = err
+			return
+		}
+		v.cards = make(map[string]srs.Card, len(cards))
+		for _, card := range cards {
+			v.cards[card.Grammar] = card
+		}
+	})
+	if v.cardsErr != nil {
+		return srs.Card{}, false, v.cardsErr
+	}
+	card, ok := v.cards[grammarId]
+	return card, ok, nil
+}
+
+// canView checks a record against its collection's view rule, the same as
+// the collection API would
+func (r *root) canView(v *viewer, rec *core.Record) (bool, error) {
+	return r.app.CanAccessRecord(rec, v.info, rec.Collection().ViewRule)
+}
+
+// listVisible returns a page of the records matching filter that the
+// collection's list rule shows the viewer, newest first
+func (r *root) listVisible(v *viewer, collectionName string, filter string, params dbx.Params, page api.Page) ([]*core.Record, error) {
+	collection, err := r.app.FindCollectionByNameOrId(collectionName)
+	if err != nil {
+		return nil, err
+	}
+	if collection.ListRule == nil {
+		return nil, errForbidden
+	}
+	if *collection.ListRule != "" {
+		filter = "(" + filter + ") && (" + *collection.ListRule + ")"
+	}
+	if keyset := page.KeysetFilterDesc("created", params); keyset != "" {
+		filter += " && " + keyset
+	}
+
+	resolver := core.NewRecordFieldResolver(r.app, collection, v.info, false)
+	expr, err := search.FilterData(filter).BuildExpr(resolver, params)
+	if err != nil {
+		return nil, err
+	}
+	query := r.app.RecordQuery(collection).
+		AndWhere(expr).
+		OrderBy("[["+collection.Name+".created]] DESC", "[["+collection.Name+".id]] DESC").
+		Limit(int64(page.Limit + 1))
+	if err := resolver.UpdateQuery(query); err != nil {
+		return nil, err
+	}
+
+	var records []*core.Record
+	if err := query.All(&records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// pageOf reads first and after arguments
+func pageOf(first *int32, after *string) (api.Page, error) {
+	page := api.Page{Limit: defaultFirst}
+	if first != nil {
+		if *first < 1 || *first > maxFirst {
+			return api.Page{}, errors.New("first must be between 1 and " + strconv.Itoa(maxFirst))
+		}
+		page.Limit = int(*first)
+	}
+	if after != nil && *after != "" {
+		cursor, err := api.DecodeCursor(*after)
+		if err != nil {
+			return api.Page{}, errors.New("invalid cursor")
+		}
+		page.After = &cursor
+	}
+	return page, nil
+}
+
+type panicLogger struct {
+	app core.App
+}
+
+func (l panicLogger) LogPanic(_ context.Context, value any) {
+	l.app.Logger().Error("GraphQL resolver panicked", "panic", value)
+}
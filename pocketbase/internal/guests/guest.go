@@ -0,0 +1,60 @@
+package guests
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Config bounds guest trials, which are off with a zero TTL
+type Config struct {
+	// TTL is how long a guest account lasts before it's purged
+	TTL time.Duration
+
+	// MaxActive is how many guest accounts there can be at once, 0 for no
+	// limit
+	MaxActive int
+}
+
+var DefaultConfig = Config{
+	TTL:       24 * time.Hour,
+	MaxActive: 500,
+}
+
+// ConfigFromEnv reads GUEST_TRIAL_HOURS, 0 turning trials off, and
+// GUEST_TRIAL_MAX_ACTIVE over the defaults
+func ConfigFromEnv() Config {
+	config := DefaultConfig
+	if n, err := strconv.Atoi(os.Getenv("GUEST_TRIAL_HOURS")); err == nil && n >= 0 {
+		config.TTL = time.Duration(n) * time.Hour
+	}
+	if n, err := strconv.Atoi(os.Getenv("GUEST_TRIAL_MAX_ACTIVE")); err == nil && n >= 0 {
+		config.MaxActive = n
+	}
+	return config
+}
+
+// Enabled reports whether guests can start trials
+func (c Config) Enabled() bool {
+	return c.TTL > 0
+}
+
+// Trial is a guest account just made, as logging in returns it
+type Trial struct {
+	Token  string         `json:"token"`
+	Record map[string]any `json:"record"`
+	Meta   TrialMeta      `json:"meta"`
+}
+
+type TrialMeta struct {
+	// Expires is when the account and everything in it is purged
+	Expires types.DateTime `json:"expires"`
+}
+
+// IsGuest reports whether user is a guest account
+func IsGuest(user *core.Record) bool {
+	return !user.GetDateTime("guest_expires").IsZero()
+}
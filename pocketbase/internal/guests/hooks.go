@@ -0,0 +1,42 @@
+package guests
+
+import (
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/hook"
+)
+
+// BindHooks purges guests once their trial is over, and keeps them from
+// taking on an address, which would make them look like a real account
+func BindHooks(app core.App, guestsService Service) {
+	app.Cron().MustAdd("fushigiGuestsPurge", "*/5 * * * *", func() {
+		if _, err := guestsService.Purge(time.Now()); err != nil {
+			app.Logger().Error("Failed to purge expired guests", "error", err)
+		}
+	})
+
+	app.OnRecordRequestEmailChangeRequest("users").BindFunc(func(e *core.RecordRequestEmailChangeRequestEvent) error {
+		if IsGuest(e.Record) {
+			return e.ForbiddenError("Guest accounts can't be kept, sign up to keep studying.", nil)
+		}
+		return e.Next()
+	})
+}
+
+// Middleware refuses guests whose trial is over but who haven't been purged
+// yet
+func Middleware() *hook.Handler[*core.RequestEvent] {
+	return &hook.Handler[*core.RequestEvent]{
+		Id: "fushigiGuests",
+		Func: func(e *core.RequestEvent) error {
+			if e.Auth == nil || e.Auth.Collection().Name != "users" || !IsGuest(e.Auth) {
+				return e.Next()
+			}
+			if e.Auth.GetDateTime("guest_expires").Time().After(time.Now()) {
+				return e.Next()
+			}
+			return e.UnauthorizedError("Your guest trial is over.", nil)
+		},
+	}
+}
@@ -0,0 +1,38 @@
+package guests
+
+import (
+	"errors"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/i18n"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/instance"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/ipfilter"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// RegisterPublicRoutes adds the route that starts a guest trial. Guests are
+// refused wherever signups are, being accounts all the same.
+func RegisterPublicRoutes(g *api.Group, guestsService Service, instanceService instance.Service, ipFilter ipfilter.Config) {
+	g.POST("/guests", "Start a guest trial, an account seeded with a week of study history that's purged when the trial ends, getting its auth token", nil, Trial{}, func(e *core.RequestEvent) error {
+		if !instanceService.Current().Signups {
+			return e.ForbiddenError("Signups are closed on this server.", nil)
+		}
+		if !ipfilter.CanRegister(e, ipFilter) {
+			return e.ForbiddenError("You can't sign up from your network.", nil)
+		}
+
+		guest, err := guestsService.Create(i18n.FromRequest(e))
+		switch {
+		case errors.Is(err, ErrDisabled):
+			return e.NotFoundError("", err)
+		case errors.Is(err, ErrFull):
+			return e.TooManyRequestsError("There are too many guests right now, try again later.", err)
+		case err != nil:
+			return e.InternalServerError("Failed to start the guest trial.", err)
+		}
+
+		return apis.RecordAuthResponse(e, guest, "", TrialMeta{Expires: guest.GetDateTime("guest_expires")})
+	})
+}
@@ -0,0 +1,152 @@
+package guests
+
+import (
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// The cards a guest starts with, the most frequent library grammar of their
+// language first: some long learned, some being learned, a few started
+// yesterday
+const (
+	seedKnown    = 8
+	seedLearning = 12
+	seedStarted  = 6
+)
+
+// seedEntry is a journal entry a guest starts with, written daysAgo
+type seedEntry struct {
+	daysAgo int
+	title   string
+	content string
+}
+
+// seedEntries read like a beginner's first week of writing
+var seedEntries = []seedEntry{
+	{6, "はじめての日記", "今日から日本語で日記を書きます。毎日少しずつ書きたいです。"},
+	{4, "週末", "週末は友だちと公園に行きました。天気がよくて、とても楽しかったです。"},
+	{1, "雨の日", "今日は雨が降っていたので、家で本を読みました。漢字はまだ難しいですが、がんばります。"},
+}
+
+// seedSessions are the review sessions a guest starts with, by how many days
+// ago they were and how many minutes they took
+var seedSessions = []struct{ daysAgo, minutes int }{
+	{6, 12}, {5, 8}, {4, 15}, {3, 10}, {1, 9},
+}
+
+// seed gives a new guest some history to try the app with, a week of
+// reviews and journal entries
+func seed(txApp core.App, user *core.Record, language string, now time.Time) error {
+	if err := seedCards(txApp, user, language, now); err != nil {
+		return err
+	}
+
+	journal, err := txApp.FindCollectionByNameOrId("journal_entry")
+	if err != nil {
+		return err
+	}
+	for _, e := range seedEntries {
+		entry := core.NewRecord(journal)
+		entry.Set("user", user.Id)
+		entry.Set("title", e.title)
+		entry.Set("content", e.content)
+		entry.Set("audience", "private")
+		if err := txApp.Save(entry); err != nil {
+			return err
+		}
+		if err := backdate(txApp, "journal_entry", entry.Id, now.AddDate(0, 0, -e.daysAgo)); err != nil {
+			return err
+		}
+	}
+
+	sessions, err := txApp.FindCollectionByNameOrId("sessions")
+	if err != nil {
+		return err
+	}
+	for _, s := range seedSessions {
+		started := now.AddDate(0, 0, -s.daysAgo)
+		duration := time.Duration(s.minutes) * time.Minute
+		session := core.NewRecord(sessions)
+		session.Set("user", user.Id)
+		session.Set("kind", "review")
+		session.Set("started", started)
+		session.Set("ended", started.Add(duration))
+		session.Set("duration", int(duration.Seconds()))
+		if err := txApp.Save(session); err != nil {
+			return err
+		}
+		if err := backdate(txApp, "sessions", session.Id, started); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// seedCards gives the guest cards for the most frequent library grammar of
+// language, as if they'd been studying it for a while
+func seedCards(txApp core.App, user *core.Record, language string, now time.Time) error {
+	var grammar []*core.Record
+	err := txApp.RecordQuery("grammar").
+		AndWhere(dbx.HashExp{"user": "", "language": language}).
+		OrderBy("(frequency_rank = 0) ASC", "frequency_rank ASC", "created ASC").
+		Limit(seedKnown + seedLearning + seedStarted).
+		All(&grammar)
+	if err != nil {
+		return err
+	}
+
+	srs, err := txApp.FindCollectionByNameOrId("srs")
+	if err != nil {
+		return err
+	}
+	for i, g := range grammar {
+		card := core.NewRecord(srs)
+		card.Set("user", user.Id)
+		card.Set("grammar", g.Id)
+		card.Set("ease_factor", 2.5)
+		started := now.AddDate(0, 0, -7)
+
+		switch {
+		case i < seedKnown:
+			// learned long before the trial, not due for a while
+			interval := 30 + 5*i
+			card.Set("interval_days", interval)
+			card.Set("repetition", 4)
+			card.Set("last_reviewed", now.AddDate(0, 0, -i-1))
+			started = now.AddDate(0, 0, -interval-i-1)
+		case i < seedKnown+seedLearning:
+			// about half of these are due today
+			n := i - seedKnown
+			interval := 1 + n%6
+			card.Set("ease_factor", 2.3+0.1*float64(n%4))
+			card.Set("interval_days", interval)
+			card.Set("repetition", 1+n%3)
+			card.Set("last_reviewed", now.AddDate(0, 0, -interval+n%2))
+		default:
+			card.Set("interval_days", 1)
+			card.Set("repetition", 1)
+			card.Set("last_reviewed", now.AddDate(0, 0, -1))
+			started = now.AddDate(0, 0, -1)
+		}
+		if err := txApp.Save(card); err != nil {
+			return err
+		}
+		if err := backdate(txApp, "srs", card.Id, started); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backdate sets when a seeded record was made, which saving sets to now
+func backdate(txApp core.App, collection string, id string, at time.Time) error {
+	date, err := types.ParseDateTime(at)
+	if err != nil {
+		return err
+	}
+	_, err = txApp.DB().Update(collection, dbx.Params{"created": date.String(), "updated": date.String()}, dbx.HashExp{"id": id}).Execute()
+	return err
+}
@@ -0,0 +1,125 @@
+package guests
+
+import (
+	"errors"
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/ratelimit"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/settings"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/security"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// emailDomain is where guests' addresses are, reserved so nothing is ever
+// delivered there (RFC 2606)
+const emailDomain = "guests.fushigi.invalid"
+
+var (
+	// ErrDisabled means guest trials are turned off
+	ErrDisabled = errors.New("guest trials are turned off")
+
+	// ErrFull means there are MaxActive guests already
+	ErrFull = errors.New("too many guests")
+)
+
+type Service interface {
+	// Create makes a guest account seeded with a week of study history,
+	// which is purged once the trial is over
+	Create(locale string) (*core.Record, error)
+
+	// Purge deletes the guests whose trial ended by now along with
+	// everything they made, returning how many it deleted
+	Purge(now time.Time) (int, error)
+
+	// Enabled reports whether guests can start trials
+	Enabled() bool
+}
+
+type service struct {
+	app    core.App
+	config Config
+}
+
+func NewService(app core.App, config Config) Service {
+	return &service{app: app, config: config}
+}
+
+func (s *service) Enabled() bool {
+	return s.config.Enabled()
+}
+
+func (s *service) Create(locale string) (*core.Record, error) {
+	if !s.config.Enabled() {
+		return nil, ErrDisabled
+	}
+	now := time.Now()
+	expires, err := types.ParseDateTime(now.Add(s.config.TTL))
+	if err != nil {
+		return nil, err
+	}
+
+	var guest *core.Record
+	err = s.app.RunInTransaction(func(txApp core.App) error {
+		if s.config.MaxActive > 0 {
+			active, err := txApp.CountRecords("users", dbx.NewExp("guest_expires != ''"))
+			if err != nil {
+				return err
+			}
+			if active >= int64(s.config.MaxActive) {
+				return ErrFull
+			}
+		}
+
+		users, err := txApp.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+		guest = core.NewRecord(users)
+		guest.SetEmail("guest+" + security.RandomStringWithAlphabet(15, "abcdefghijklmnopqrstuvwxyz0123456789") + "@" + emailDomain)
+		guest.SetEmailVisibility(false)
+		guest.SetVerified(false)
+		guest.SetRandomPassword()
+		guest.Set("name", "Guest")
+		guest.Set("has_password", false)
+		guest.Set("rate_tier", ratelimit.TierDemo)
+		guest.Set("guest_expires", expires)
+		if err := txApp.Save(guest); err != nil {
+			return err
+		}
+
+		userSettings, err := settings.CreateQuiet(txApp, guest.Id, locale)
+		if err != nil {
+			return err
+		}
+		return seed(txApp, guest, userSettings.GetString("default_language"), now)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return guest, nil
+}
+
+func (s *service) Purge(now time.Time) (int, error) {
+	before, err := types.ParseDateTime(now)
+	if err != nil {
+		return 0, err
+	}
+	guests, err := s.app.FindAllRecords("users", dbx.NewExp("guest_expires != '' AND guest_expires <= {:before}", dbx.Params{"before": before.String()}))
+	if err != nil {
+		return 0, err
+	}
+
+	// deleted one by one rather than with a query so what they made and
+	// uploaded cascades
+	purged := 0
+	for _, guest := range guests {
+		if err := s.app.Delete(guest); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
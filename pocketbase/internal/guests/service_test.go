@@ -0,0 +1,137 @@
+package guests
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/ratelimit"
+	_ "github.com/bunkbed-tech/fushigi/pocketbase/migrations"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// newTestApp is a migrated app
+func newTestApp(t *testing.T) *tests.TestApp {
+	t.Helper()
+	// the initial migration creates a superuser from these
+	t.Setenv("ADMIN_EMAIL", "admin@example.com")
+	t.Setenv("ADMIN_PASSWORD", "correct horse battery")
+	app, err := tests.NewTestApp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(app.Cleanup)
+	if err := app.RunAllMigrations(); err != nil {
+		t.Fatal(err)
+	}
+
+	// PocketBase's test data indexes every username, empty or not, and
+	// guests have none
+	users, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	users.RemoveIndex("__pb_users_auth__username_idx")
+	if err := app.Save(users); err != nil {
+		t.Fatal(err)
+	}
+	return app
+}
+
+func TestCreate(t *testing.T) {
+	app := newTestApp(t)
+
+	if _, err := NewService(app, Config{}).Create("en"); !errors.Is(err, ErrDisabled) {
+		t.Errorf("Create() with trials off error = %v, want %v", err, ErrDisabled)
+	}
+
+	service := NewService(app, Config{TTL: 24 * time.Hour, MaxActive: 1})
+	guest, err := service.Create("ja")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsGuest(guest) || guest.GetString("rate_tier") != ratelimit.TierDemo {
+		t.Errorf("Create() = %v, want a guest on the demo tier", guest)
+	}
+	if expires := guest.GetDateTime("guest_expires").Time(); expires.Before(time.Now().Add(23*time.Hour)) || expires.After(time.Now().Add(24*time.Hour)) {
+		t.Errorf("guest expires %v, want in a day", expires)
+	}
+	if reviews, err := app.CountRecords("srs", nil); err != nil || reviews == 0 {
+		t.Errorf("guest's srs records = %d, %v, want a history seeded", reviews, err)
+	}
+
+	if _, err := service.Create("ja"); !errors.Is(err, ErrFull) {
+		t.Errorf("Create() past the limit error = %v, want %v", err, ErrFull)
+	}
+}
+
+func TestPurge(t *testing.T) {
+	app := newTestApp(t)
+	service := NewService(app, DefaultConfig)
+
+	expired, err := service.Create("en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired.Set("guest_expires", types.NowDateTime().Add(-time.Minute))
+	if err := app.Save(expired); err != nil {
+		t.Fatal(err)
+	}
+	active, err := service.Create("en")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	purged, err := service.Purge(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if purged != 1 {
+		t.Errorf("Purge() = %d, want 1", purged)
+	}
+	if _, err := app.FindRecordById("users", expired.Id); err == nil {
+		t.Error("expired guest is still there")
+	}
+	if _, err := app.FindRecordById("users", active.Id); err != nil {
+		t.Errorf("active guest is gone: %v", err)
+	}
+	if reviews, err := app.CountRecords("srs", nil); err != nil || reviews == 0 {
+		t.Errorf("srs records left = %d, %v, want the active guest's kept", reviews, err)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	users := core.NewAuthCollection("users")
+	guest := func(expires time.Time) *core.Record {
+		record := core.NewRecord(users)
+		record.Set("guest_expires", expires)
+		return record
+	}
+
+	tests := []struct {
+		name    string
+		auth    *core.Record
+		allowed bool
+	}{
+		{"logged out", nil, true},
+		{"account", core.NewRecord(users), true},
+		{"guest", guest(time.Now().Add(time.Hour)), true},
+		{"guest whose trial is over", guest(time.Now().Add(-time.Minute)), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &core.RequestEvent{}
+			e.Request = httptest.NewRequest("GET", "/api/fushigi/v1/srs/due", nil)
+			e.Response = httptest.NewRecorder()
+			e.Auth = tt.auth
+
+			if err := Middleware().Func(e); (err == nil) != tt.allowed {
+				t.Errorf("Middleware() error = %v, want allowed %v", err, tt.allowed)
+			}
+		})
+	}
+}
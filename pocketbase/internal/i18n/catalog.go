@@ -0,0 +1,318 @@
+package i18n
+
+// messages translates the English error messages returned by the API. Keys
+// must match the message exactly, including the trailing period PocketBase
+// adds to every error.
+var messages = map[string]map[string]string{
+	"ja": {
+		// PocketBase
+		"The requested resource wasn't found.":                         "リソースが見つかりません。",
+		"Something went wrong while processing your request.":          "リクエストの処理中にエラーが発生しました。",
+		"The request requires valid record authorization token.":       "有効な認証トークンが必要です。",
+		"The authorized record is not allowed to perform this action.": "この操作を行う権限がありません。",
+		"Only superusers can perform this action.":                     "この操作は管理者のみ実行できます。",
+		"Failed to create record.":                                     "レコードを作成できませんでした。",
+		"Failed to update record.":                                     "レコードを更新できませんでした。",
+		"Failed to delete record.":                                     "レコードを削除できませんでした。",
+		"Failed to authenticate.":                                      "認証に失敗しました。",
+		"Too Many Requests.":                                           "リクエストが多すぎます。しばらくしてから再度お試しください。",
+
+		// Fushigi
+		"Invalid request body.":                                          "リクエストの内容が正しくありません。",
+		"Invalid assessment payload.":                                    "レベル診断の内容が正しくありません。",
+		"Failed to count due cards.":                                     "復習予定のカードを数えられませんでした。",
+		"Failed to load decks.":                                          "デッキを読み込めませんでした。",
+		"Failed to load exports.":                                        "エクスポートを読み込めませんでした。",
+		"Invalid export request.":                                        "エクスポートの指定が正しくありません。",
+		"PDF exports aren't set up on this server.":                      "このサーバーではPDFのエクスポートが設定されていません。",
+		"Failed to start the export.":                                    "エクスポートを開始できませんでした。",
+		"This import is no longer staged.":                               "このインポートはすでに確定または破棄されています。",
+		"This import is not committed.":                                  "このインポートは確定されていません。",
+		"Failed to start the import.":                                    "インポートを開始できませんでした。",
+		"Invalid import request.":                                        "インポートの指定が正しくありません。",
+		"Upload the file to import as file.":                             "インポートするファイルを file にアップロードしてください。",
+		"The file is too large.":                                         "ファイルが大きすぎます。",
+		"The file is not a zip archive.":                                 "ZIPファイルではありません。",
+		"Journal":                                                        "日記",
+		"Grammar used":                                                   "使った文法",
+		"Corrections":                                                    "添削",
+		"Failed to import the deck.":                                     "デッキをインポートできませんでした。",
+		"Invalid search request.":                                        "検索の指定が正しくありません。",
+		"Failed to search grammar.":                                      "文法を検索できませんでした。",
+		"Failed to search vocabulary.":                                   "単語を検索できませんでした。",
+		"Couldn't get the deck from the other instance.":                 "相手のインスタンスからデッキを取得できませんでした。",
+		"The other instance didn't send a valid deck.":                   "相手のインスタンスから正しいデッキが返されませんでした。",
+		"%d grammar points":                                              "文法 %d 件",
+		"Shared from %s":                                                 "%s で共有",
+		"No such maintenance task.":                                      "そのメンテナンス作業はありません。",
+		"Failed to start the maintenance task.":                          "メンテナンス作業を開始できませんでした。",
+		"Failed to export the grammar.":                                  "文法をエクスポートできませんでした。",
+		"Grammar reference":                                              "文法リファレンス",
+		"Context":                                                        "場面",
+		"Nuance":                                                         "ニュアンス",
+		"Notes":                                                          "メモ",
+		"Other":                                                          "その他",
+		"Failed to load feature flags.":                                  "機能フラグを読み込めませんでした。",
+		"Failed to load grammar library.":                                "文法ライブラリを読み込めませんでした。",
+		"Failed to load achievements.":                                   "実績を読み込めませんでした。",
+		"Failed to load vocabulary.":                                     "語彙を読み込めませんでした。",
+		"Failed to load vocabulary library.":                             "語彙ライブラリを読み込めませんでした。",
+		"Failed to load grammar tags.":                                   "文法のタグを読み込めませんでした。",
+		"Failed to load jobs.":                                           "ジョブを読み込めませんでした。",
+		"Failed to load sessions.":                                       "セッションを読み込めませんでした。",
+		"Failed to sign out the other sessions.":                         "他のセッションをログアウトできませんでした。",
+		"The password doesn't meet the password policy.":                 "パスワードがポリシーを満たしていません。",
+		"This session was signed out.":                                   "このセッションはログアウトされました。",
+		"Failed to check the session.":                                   "セッションを確認できませんでした。",
+		"Failed to load linked accounts.":                                "連携アカウントを読み込めませんでした。",
+		"This sign in method isn't available.":                           "このログイン方法は利用できません。",
+		"This account is already linked to another user.":                "このアカウントは既に別のユーザーに連携されています。",
+		"Failed to link the account.":                                    "アカウントを連携できませんでした。",
+		"Set a password before unlinking your only sign in method.":      "唯一のログイン方法を解除する前にパスワードを設定してください。",
+		"Failed to load settings.":                                       "設定を読み込めませんでした。",
+		"Failed to load srs records.":                                    "復習データを読み込めませんでした。",
+		"Failed to load storage usage.":                                  "ストレージの使用量を読み込めませんでした。",
+		"Failed to seed known grammar.":                                  "既知の文法を登録できませんでした。",
+		"Invalid study session.":                                         "学習セッションが正しくありません。",
+		"This study session was already stopped.":                        "この学習セッションは既に終了しています。",
+		"Failed to load study sessions.":                                 "学習セッションを読み込めませんでした。",
+		"Failed to plan the study session.":                              "学習セッションを計画できませんでした。",
+		"Failed to plan the day.":                                        "今日の予定を作成できませんでした。",
+		"What did you eat today, and who with?":                          "今日は何を、誰と食べましたか？",
+		"Describe something you noticed on your way somewhere today.":    "今日どこかへ行く途中で気づいたことを書いてみましょう。",
+		"What are you looking forward to this week?":                     "今週楽しみにしていることは何ですか？",
+		"Write about a conversation you had recently.":                   "最近した会話について書いてみましょう。",
+		"What is something new you learned today?":                       "今日新しく学んだことは何ですか？",
+		"Describe a place you would like to visit, and why.":             "行ってみたい場所とその理由を書いてみましょう。",
+		"What made you laugh recently?":                                  "最近笑ったことは何ですか？",
+		"Invalid resurrect request.":                                     "復活のリクエストが正しくありません。",
+		"Failed to resurrect the cards.":                                 "カードを復活できませんでした。",
+		"Failed to load grammar.":                                        "文法を読み込めませんでした。",
+		"Failed to load mnemonics.":                                      "覚え方を読み込めませんでした。",
+		"You can't vote for your own mnemonic.":                          "自分の覚え方には投票できません。",
+		"Invalid transliteration request.":                               "翻字のリクエストが正しくありません。",
+		"Failed to load journal entries.":                                "日記を読み込めませんでした。",
+		"Upload the frequency list as file.":                             "頻度リストを file としてアップロードしてください。",
+		"The frequency list is empty.":                                   "頻度リストが空です。",
+		"Failed to read the frequency list.":                             "頻度リストを読み込めませんでした。",
+		"No such language.":                                              "その言語はありません。",
+		"Failed to import the frequency list.":                           "頻度リストをインポートできませんでした。",
+		"This grammar has no tag suggestions.":                           "この文法にはタグの提案がありません。",
+		"Invalid journal request.":                                       "日記のリクエストが正しくありません。",
+		"Failed to load journal topics.":                                 "日記のトピックを読み込めませんでした。",
+		"Failed to load reports.":                                        "レポートを読み込めませんでした。",
+		"Failed to start the report.":                                    "レポートを開始できませんでした。",
+		"You're learning it but haven't used it in your journal yet.":    "学習中ですが、まだ日記で使っていません。",
+		"You're learning it but haven't used it in your journal lately.": "学習中ですが、最近日記で使っていません。",
+		"Invalid stats request.":                                         "統計のリクエストが正しくありません。",
+		"Failed to load error stats.":                                    "間違いの統計を読み込めませんでした。",
+		"Invalid drills request.":                                        "ドリルのリクエストが無効です。",
+		"Failed to load drills.":                                         "ドリルを読み込めませんでした。",
+		"Invalid drill cards request.":                                   "ドリルカードのリクエストが無効です。",
+		"Pick a default language first.":                                 "先にデフォルトの言語を選んでください。",
+		"Failed to add the drill cards.":                                 "ドリルカードを追加できませんでした。",
+		"Free conversation":                                              "フリートーク",
+		"Chat with a friendly acquaintance about anything.":              "知り合いと自由におしゃべりしましょう。",
+		"Ordering food":                                                  "料理を注文する",
+		"Order a meal from a server at a restaurant.":                    "レストランで店員に料理を注文しましょう。",
+		"Job interview":                                                  "就職面接",
+		"Answer an interviewer's questions for an office job.":           "会社の面接官の質問に答えましょう。",
+		"Asking for directions":                                          "道をたずねる",
+		"Find your way to the station with a passerby's help.":           "通行人に駅までの道を教えてもらいましょう。",
+		"Checking in at a hotel":                                         "ホテルのチェックイン",
+		"Check in at a hotel front desk and ask about the stay.":         "ホテルのフロントでチェックインして、滞在について質問しましょう。",
+		"Failed to load conversations.":                                  "会話を読み込めませんでした。",
+		"Invalid conversation request.":                                  "会話のリクエストが無効です。",
+		"Invalid message.":                                               "メッセージが無効です。",
+		"Conversation practice isn't set up on this server.":             "このサーバーでは会話練習が設定されていません。",
+		"This conversation has ended.":                                   "この会話は終了しています。",
+		"This conversation is at its message limit, end it to see what to study.": "この会話はメッセージの上限に達しました。終了すると学習する内容を確認できます。",
+		"Failed to talk to the model.":                                            "モデルとやりとりできませんでした。",
+		"Failed to load the prompt.":                                              "お題を読み込めませんでした。",
+		"Listening to prompts isn't set up on this server.":                       "このサーバーではお題の読み上げが設定されていません。",
+		"Failed to read the prompt out.":                                          "お題を読み上げられませんでした。",
+		"Upload the recording as audio.":                                          "録音を audio としてアップロードしてください。",
+		"The recording is too large.":                                             "録音が大きすぎます。",
+		"Invalid spoken entry.":                                                   "音声日記が無効です。",
+		"Speaking journal isn't set up on this server.":                           "このサーバーでは音声日記が設定されていません。",
+		"Failed to start the transcription.":                                      "文字起こしを開始できませんでした。",
+		"Invalid audit log request.":                                              "監査ログのリクエストが無効です。",
+		"Failed to load the audit log.":                                           "監査ログを読み込めませんでした。",
+		"Failed to purge the audit log.":                                          "監査ログを削除できませんでした。",
+		"Invalid rating.":                                                         "評価が正しくありません。",
+		"Failed to save the rating.":                                              "評価を保存できませんでした。",
+		"Failed to remove the rating.":                                            "評価を取り消せませんでした。",
+		"Semantic search isn't set up on this server.":                            "このサーバーでは意味検索が設定されていません。",
+		"Failed to search.":                                                       "検索できませんでした。",
+		"Failed to load related grammar.":                                         "関連する文法を読み込めませんでした。",
+		"Invalid comparison request.":                                             "比較のリクエストが無効です。",
+		"Grammar comparisons aren't set up on this server.":                       "このサーバーでは文法の比較が設定されていません。",
+		"Failed to compare the grammar.":                                          "文法を比較できませんでした。",
+		"Invalid journal entry.":                                                  "日記が無効です。",
+		"Failed to load tutor sessions.":                                          "チューターセッションを読み込めませんでした。",
+		"Invalid tutor session.":                                                  "チューターセッションが無効です。",
+		"There are no entries or questions to bring to the session.":              "セッションに持っていく日記や質問がありません。",
+		"Failed to create the tutor session.":                                     "チューターセッションを作成できませんでした。",
+		"Failed to write the tutor session.":                                      "チューターセッションを書き出せませんでした。",
+		"Invalid annotations.":                                                    "添削が無効です。",
+		"Failed to import the annotations.":                                       "添削を取り込めませんでした。",
+		"Upload the annotated document as file.":                                  "添削済みのドキュメントを file としてアップロードしてください。",
+		"Tutoring session":                                                        "レッスン",
+		"Learner":                                                                 "学習者",
+		"Questions":                                                               "質問",
+		"Write the corrected sentence on a line starting with > right under a sentence, and any comment on more > lines. Answer questions after A:.": "添削した文は各文のすぐ下に > で始まる行で書き、コメントは続けて > の行に書いてください。質問には A: の後に答えてください。",
+		"You've made too many of these requests, try again later.":                                                                                   "リクエストが多すぎます。しばらくしてからもう一度お試しください。",
+		"You can't do that from your network.":                                                                                                       "お使いのネットワークからはこの操作はできません。",
+		"You can't sign up from your network.":                                                                                                       "お使いのネットワークからは登録できません。",
+		"Failed to sign the media URL.":                                                                                                              "メディアのURLを発行できませんでした。",
+		"Failed to sign the media URLs.":                                                                                                             "メディアのURLを発行できませんでした。",
+		"Failed to load the file.":                                                                                                                   "ファイルを読み込めませんでした。",
+		"This link has expired.":                                                                                                                     "このリンクは有効期限が切れています。",
+		"Invalid superuser.":                                                                                                                         "管理者の入力内容が無効です。",
+		"The instance is already set up.":                                                                                                            "このインスタンスはすでにセットアップされています。",
+		"Failed to check the instance's setup.":                                                                                                      "インスタンスのセットアップ状況を確認できませんでした。",
+		"Failed to create the superuser.":                                                                                                            "管理者を作成できませんでした。",
+		"Signups are closed on this server.":                                                                                                         "このサーバーでは現在新規登録を受け付けていません。",
+		"AI features are turned off on this server.":                                                                                                 "このサーバーではAI機能がオフになっています。",
+		"The request requires valid staff authorization token.":                                                                                      "スタッフの有効な認証トークンが必要です。",
+		"Your role doesn't allow you to do that.":                                                                                                    "この操作はあなたの役割では許可されていません。",
+		"Failed to approve the content.":                                                                                                             "コンテンツを承認できませんでした。",
+		"Failed to build the dashboard.":                                                                                                             "ダッシュボードを作成できませんでした。",
+		"Failed to load the moderation queue.":                                                                                                       "モデレーションキューを読み込めませんでした。",
+		"Failed to load the prompt template versions.":                                                                                               "プロンプトテンプレートのバージョンを読み込めませんでした。",
+		"Failed to load the prompt template.":                                                                                                        "プロンプトテンプレートを読み込めませんでした。",
+		"Failed to load the rating report.":                                                                                                          "評価レポートを読み込めませんでした。",
+		"Failed to load the staff.":                                                                                                                  "スタッフを読み込めませんでした。",
+		"Failed to remove the content.":                                                                                                              "コンテンツを削除できませんでした。",
+		"Failed to render the template.":                                                                                                             "テンプレートを表示できませんでした。",
+		"Failed to save the role.":                                                                                                                   "役割を保存できませんでした。",
+		"Failed to send the email.":                                                                                                                  "メールを送信できませんでした。",
+		"Failed to start reprocessing media.":                                                                                                        "メディアの再処理を開始できませんでした。",
+		"Invalid recipient address.":                                                                                                                 "宛先のアドレスが無効です。",
+		"Invalid role.":                                                                                                                              "役割が無効です。",
+		"Invalid status.":                                                                                                                            "ステータスが無効です。",
+		"No such email template.":                                                                                                                    "そのメールテンプレートはありません。",
+		"No such prompt template.":                                                                                                                   "そのプロンプトテンプレートはありません。",
+		"Failed to load announcements.":                                                                                                              "お知らせを読み込めませんでした。",
+		"Failed to mark the announcements read.":                                                                                                     "お知らせを既読にできませんでした。",
+		"Failed to mark the announcement read.":                                                                                                      "お知らせを既読にできませんでした。",
+		"Invalid announcement.":                                                                                                                      "お知らせの内容が無効です。",
+		"Failed to create the announcement.":                                                                                                         "お知らせを作成できませんでした。",
+		"Failed to delete the announcement.":                                                                                                         "お知らせを削除できませんでした。",
+		"Invalid feedback.":                                                                                                                          "フィードバックが無効です。",
+		"You've sent a lot of feedback lately, try again later.":                                                                                     "最近フィードバックを多く送信しています。しばらくしてから再度お試しください。",
+		"Failed to send the feedback.":                                                                                                               "フィードバックを送信できませんでした。",
+		"Failed to load the feedback.":                                                                                                               "フィードバックを読み込めませんでした。",
+		"Invalid triage.":                                                                                                                            "トリアージが無効です。",
+		"Failed to triage the feedback.":                                                                                                             "フィードバックをトリアージできませんでした。",
+		"This version of the app is no longer supported, please update it.":                                                                          "このバージョンのアプリはサポートされていません。アップデートしてください。",
+		"Invalid changelog query.":                                                                                                                   "変更履歴の条件が無効です。",
+		"Failed to load the changelog.":                                                                                                              "変更履歴を読み込めませんでした。",
+		"Failed to load the sandbox.":                                                                                                                "サンドボックスを読み込めませんでした。",
+		"Failed to open the sandbox.":                                                                                                                "サンドボックスを開けませんでした。",
+		"Failed to purge the sandbox.":                                                                                                               "サンドボックスを削除できませんでした。",
+		"No such profile.":                                                                                                                           "そのプロフィールはありません。",
+		"Failed to load the profiles.":                                                                                                               "プロフィールを読み込めませんでした。",
+		"Invalid profile.":                                                                                                                           "プロフィールが無効です。",
+		"You have as many profiles as you can.":                                                                                                      "プロフィールの数が上限に達しています。",
+		"Your account's own profile can't be deleted.":                                                                                               "アカウント自身のプロフィールは削除できません。",
+		"Failed to save the profile.":                                                                                                                "プロフィールを保存できませんでした。",
+		"Invalid child account.":                                                                                                                     "子どもアカウントの内容が正しくありません。",
+		"Only your own account can manage child accounts.":                                                                                           "子どもアカウントはご自身のアカウントからのみ管理できます。",
+		"You have as many child accounts as you can.":                                                                                                "子どもアカウントの数が上限に達しています。",
+		"Failed to save the child account.":                                                                                                          "子どもアカウントを保存できませんでした。",
+		"Child accounts can't use social features.":                                                                                                  "子どもアカウントではソーシャル機能を使えません。",
+		"Ask your parent to do this.":                                                                                                                "保護者の方に依頼してください。",
+		"Your parent turned off content shared by others.":                                                                                           "保護者の設定により、他のユーザーが共有したコンテンツは表示できません。",
+		"Your parent turned AI features off.":                                                                                                        "保護者の設定により、AI機能は使えません。",
+		"Guest accounts can't be kept, sign up to keep studying.":                                                                                    "ゲストアカウントは保存できません。学習を続けるには登録してください。",
+		"Your guest trial is over.":                                                                                                                  "ゲストのお試し期間は終了しました。",
+		"There are too many guests right now, try again later.":                                                                                      "現在ゲストが多すぎます。しばらくしてからもう一度お試しください。",
+		"Failed to start the guest trial.":                                                                                                           "ゲストのお試しを開始できませんでした。",
+		"This account can't refer others.":                                                                                                           "このアカウントでは他のユーザーを招待できません。",
+		"Failed to load referrals.":                                                                                                                  "招待の情報を読み込めませんでした。",
+		"Invalid reviews.":                                                                                                                           "復習の内容が正しくありません。",
+		"Failed to stop the session.":                                                                                                                "セッションを終了できませんでした。",
+		"Failed to save the reviews.":                                                                                                                "復習結果を保存できませんでした。",
+		"Failed to load review summaries.":                                                                                                           "復習のまとめを読み込めませんでした。",
+		"Failed to load the review queue.":                                                                                                           "復習キューを読み込めませんでした。",
+		"Invalid review.":                                                                                                                            "復習の内容が正しくありません。",
+		"Failed to save the review.":                                                                                                                 "復習結果を保存できませんでした。",
+		"Invalid activity request.":                                                                                                                  "アクティビティの指定が正しくありません。",
+		"Failed to load the activity.":                                                                                                               "アクティビティを読み込めませんでした。",
+		"Failed to load the review log.":                                                                                                             "復習履歴を読み込めませんでした。",
+		"Failed to load MCP tokens.":                                                                                                                 "MCPトークンを読み込めませんでした。",
+		"Invalid MCP token.":                                                                                                                         "MCPトークンの指定が正しくありません。",
+		"The MCP server is turned off.":                                                                                                              "MCPサーバーは無効になっています。",
+		"You have too many MCP tokens, revoke one first.":                                                                                            "MCPトークンが多すぎます。先にどれかを取り消してください。",
+		"Failed to create the MCP token.":                                                                                                            "MCPトークンを作成できませんでした。",
+		"The request requires a valid MCP token.":                                                                                                    "有効なMCPトークンが必要です。",
+		"Failed to check the MCP token.":                                                                                                             "MCPトークンを確認できませんでした。",
+		"Unsupported MCP protocol version.":                                                                                                          "サポートされていないMCPプロトコルのバージョンです。",
+		"The MCP server doesn't stream, send messages with POST.":                                                                                    "MCPサーバーはストリーミングに対応していません。メッセージはPOSTで送信してください。",
+		"The GraphQL query is missing.":                                                                                                              "GraphQLクエリがありません。",
+		"Failed to run the GraphQL query.":                                                                                                           "GraphQLクエリの実行に失敗しました。",
+		"Invalid kanji breakdown request.":                                                                                                           "漢字の分解リクエストが正しくありません。",
+		"Failed to load kanji.":                                                                                                                      "漢字を読み込めませんでした。",
+		"Invalid live edit.":                                                                                                                         "ライブ編集が無効です。",
+		"You can't follow this live edit.":                                                                                                           "このライブ編集はフォローできません。",
+		"The live edit has ended.":                                                                                                                   "ライブ編集は終了しました。",
+		"The revision is too old, load the document again.":                                                                                          "リビジョンが古すぎます。ドキュメントを再読み込みしてください。",
+		"The operation doesn't fit the document.":                                                                                                    "操作がドキュメントと一致しません。",
+		"The document is too long.":                                                                                                                  "ドキュメントが長すぎます。",
+		"Failed to start the live edit.":                                                                                                             "ライブ編集を開始できませんでした。",
+		"Failed to apply the operation.":                                                                                                             "操作を適用できませんでした。",
+		"Invalid operation.":                                                                                                                         "操作が無効です。",
+		"Invalid study room.":                                                                                                                        "勉強部屋が無効です。",
+		"Too many study rooms are open, close one first.":                                                                                            "開いている勉強部屋が多すぎます。先にどれかを閉じてください。",
+		"Failed to open the study room.":                                                                                                             "勉強部屋を開けませんでした。",
+		"The study room is for members of its group.":                                                                                                "この勉強部屋はグループのメンバー専用です。",
+		"The room is full.":                                                                                                                          "部屋が満員です。",
+		"Failed to join the study room.":                                                                                                             "勉強部屋に参加できませんでした。",
+		"Open this as a WebSocket.":                                                                                                                  "WebSocketで開いてください。",
+		"The ticket is invalid or expired, join the room again.":                                                                                     "チケットが無効か期限切れです。もう一度部屋に参加してください。",
+		"The room was closed.":                                                                                                                       "部屋は閉じられました。",
+		"Only the host can do that.":                                                                                                                 "ホストのみ実行できます。",
+		"The file is not an Anki export.":                                                                                                            "Ankiのエクスポートファイルではありません。",
+		"Only superusers can set a storage quota.":                                                                                                   "ストレージ容量を設定できるのはスーパーユーザーだけです。",
+		"Unsupported %s %s.":                                                                                                                         "%s %s はサポートされていません。",
+		"API %s is no longer supported, please update the app.":                                                                                      "API %s はサポートが終了しました。アプリを更新してください。",
+	},
+}
+
+// codes translates field validation errors by their code. Messages can use
+// the error's params, e.g. {{.max}}.
+var codes = map[string]map[string]string{
+	"ja": {
+		"validation_required":                  "入力してください。",
+		"validation_nil_or_not_empty_required": "入力してください。",
+		"validation_invalid_value":             "無効な値です。",
+		"validation_invalid_format":            "形式が正しくありません。",
+		"validation_is_email":                  "メールアドレスが正しくありません。",
+		"validation_not_unique":                "この値はすでに使われています。",
+		"validation_values_mismatch":           "値が一致しません。",
+		"validation_min_text_constraint":       "{{.min}}文字以上で入力してください。",
+		"validation_max_text_constraint":       "{{.max}}文字以内で入力してください。",
+		"validation_length_too_long":           "{{.max}}文字以内で入力してください。",
+		"validation_length_out_of_range":       "{{.min}}〜{{.max}}文字で入力してください。",
+		"validation_password_too_weak":         "推測されやすいパスワードです。",
+		"validation_password_breached":         "このパスワードは過去のデータ漏洩で流出しています。",
+		"validation_invalid_password":          "パスワードが正しくありません。",
+		"validation_storage_quota_exceeded":    "ストレージの上限（{{.quota}}MB）を超えるためアップロードできません。",
+		"validation_invalid_image":             "画像として読み込めませんでした。",
+		"validation_invalid_timezone":          "不明なタイムゾーンです。",
+		"validation_item_type_mismatch":        "カードの種類と合っていません。",
+		"validation_invalid_date":              "2025-01-31 のような日付で入力してください。",
+		"validation_invalid_date_range":        "開始日より前の日付は指定できません。",
+		"validation_invalid_deck_url":          "fushigi のデッキのリンクではありません。",
+		"validation_invalid_page_size":         "1〜{{.max}}の範囲で指定してください。",
+		"validation_invalid_cursor":            "カーソルが正しくありません。",
+		"validation_ids_or_tag":                "IDかタグのどちらか一方を指定してください。",
+		"validation_out_of_range":              "{{.min}}〜{{.max}}の範囲で指定してください。",
+		"validation_date_in_future":            "未来の日時は指定できません。",
+		"validation_too_many":                  "{{.max}}件以内で指定してください。",
+		"validation_ended_before_started":      "開始より後の日時を指定してください。",
+		"validation_length_too_short":          "{{.min}}文字以上で入力してください。",
+		"validation_invalid_username":          "3〜30文字の英小文字、数字、ドット、ハイフン、アンダースコアで入力してください。",
+	},
+}
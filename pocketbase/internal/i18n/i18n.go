@@ -0,0 +1,59 @@
+package i18n
+
+import (
+	"fmt"
+
+	"github.com/pocketbase/pocketbase/core"
+	"golang.org/x/text/language"
+)
+
+const DefaultLocale = "en"
+
+// Locales are the locales with a message catalog, DefaultLocale first
+var Locales = []string{DefaultLocale, "ja"}
+
+// LanguageNames names the language of each locale in English, for asking a
+// model to write in it
+var LanguageNames = map[string]string{
+	"en": "English",
+	"ja": "Japanese",
+}
+
+var matcher = language.NewMatcher([]language.Tag{language.English, language.Japanese})
+
+// requestLocaleKey is where Middleware stores the resolved locale on the request
+const requestLocaleKey = "fushigiLocale"
+
+// Negotiate picks the supported locale that best matches an Accept-Language
+// header or a stored locale like "ja-JP", or returns "" if none does
+func Negotiate(accept string) string {
+	tags, _, err := language.ParseAcceptLanguage(accept)
+	if err != nil || len(tags) == 0 {
+		return ""
+	}
+	_, i, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return ""
+	}
+	return Locales[i]
+}
+
+// FromRequest returns the locale the response to e should be written in
+func FromRequest(e *core.RequestEvent) string {
+	if locale, ok := e.Get(requestLocaleKey).(string); ok {
+		return locale
+	}
+	return DefaultLocale
+}
+
+// T translates an English message, which may be a fmt format for args. Messages
+// missing from the catalog are returned in English.
+func T(locale string, message string, args ...any) string {
+	if translated, ok := messages[locale][message]; ok {
+		message = translated
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(message, args...)
+	}
+	return message
+}
@@ -0,0 +1,79 @@
+package i18n
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"text/template"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/hook"
+	"github.com/pocketbase/pocketbase/tools/router"
+)
+
+// Middleware resolves the locale of each request, from Accept-Language or
+// else the locale userLocale returns for the logged in user, and translates
+// the error responses
+func Middleware(userLocale func(userId string) string) *hook.Handler[*core.RequestEvent] {
+	return &hook.Handler[*core.RequestEvent]{
+		Id: "fushigiLocalize",
+		Func: func(e *core.RequestEvent) error {
+			locale := Negotiate(e.Request.Header.Get("Accept-Language"))
+			if locale == "" && e.Auth != nil && e.Auth.Collection().Name == "users" {
+				locale = Negotiate(userLocale(e.Auth.Id))
+			}
+			if locale == "" {
+				locale = DefaultLocale
+			}
+
+			e.Set(requestLocaleKey, locale)
+			e.Response.Header().Set("Content-Language", locale)
+			e.Response.Header().Add("Vary", "Accept-Language")
+
+			err := e.Next()
+
+			var apiErr *router.ApiError
+			if locale != DefaultLocale && errors.As(err, &apiErr) {
+				apiErr.Message = T(locale, apiErr.Message)
+				// PocketBase ends every message with a period, even ones already
+				// translated by the handler
+				if strings.HasSuffix(apiErr.Message, "。.") {
+					apiErr.Message = strings.TrimSuffix(apiErr.Message, ".")
+				}
+				translateErrorData(locale, apiErr.Data)
+			}
+			return err
+		},
+	}
+}
+
+// translateErrorData rewrites the messages of (possibly nested) field errors
+// by their code, as the English text may have params filled in already
+func translateErrorData(locale string, data map[string]any) {
+	for _, value := range data {
+		item, ok := value.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		code, ok := item["code"].(string)
+		if !ok {
+			translateErrorData(locale, item)
+			continue
+		}
+
+		message, ok := codes[locale][code]
+		if !ok {
+			continue
+		}
+		if strings.Contains(message, "{{") {
+			if tmpl, err := template.New(code).Parse(message); err == nil {
+				var buf bytes.Buffer
+				if tmpl.Execute(&buf, item["params"]) == nil {
+					message = buf.String()
+				}
+			}
+		}
+		item["message"] = message
+	}
+}
@@ -0,0 +1,619 @@
+package imports
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+const SourceAnki = "anki"
+
+// What Anki notes can be imported as
+const (
+	AnkiGrammar    = "grammar"
+	AnkiVocabulary = "vocabulary"
+)
+
+// ankiFields are the fields notes can be mapped onto, the two required ones
+// first: they take the note's first two fields unless mapped
+var ankiFields = map[string][]string{
+	AnkiGrammar:    {"usage", "meaning", "context", "notes", "nuance", "example", "example_translation"},
+	AnkiVocabulary: {"term", "meaning", "reading", "part_of_speech", "example", "example_translation"},
+}
+
+// maxCollectionSize bounds the collection database unpacked from a package
+const maxCollectionSize = 256 << 20
+
+// Packages hold the collection in the newest format they can: zstd
+// compressed, then the 2.1 schema, then the legacy one, which newer Anki
+// only fills with a note asking to upgrade
+var ankiCollections = []string{"collection.anki21b", "collection.anki21", "collection.anki2"}
+
+var (
+	ankiSound     = regexp.MustCompile(`\[sound:[^\]]*\]`)
+	ankiLineBreak = regexp.MustCompile(`(?i)<br\s*/?>|</(div|p|li)>`)
+	ankiTag       = regexp.MustCompile(`<[^>]*>`)
+	blankLines    = regexp.MustCompile(`\n{3,}`)
+)
+
+// AnkiOptions say what notes are imported as and which of their fields go
+// where
+type AnkiOptions struct {
+	// Target is grammar or vocabulary
+	Target   string `json:"target"`
+	Language string `json:"language"`
+
+	// NoteType limits the import to the notes of one type, by name
+	NoteType string `json:"note_type"`
+
+	// Fields maps fields of the target to the note fields read into them,
+	// by name or 1-based position
+	Fields map[string]string `json:"fields"`
+}
+
+func (o AnkiOptions) Validate() error {
+	errs := validation.Errors{}
+	if _, ok := ankiFields[o.Target]; !ok {
+		errs["target"] = validation.NewError("validation_invalid_value", "Invalid value.")
+	}
+	if o.Language == "" {
+		errs["language"] = validation.NewError("validation_required", "Cannot be blank.")
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// source returns the note field a target field is read from, the two
+// required ones falling back to the note's first two fields
+func (o AnkiOptions) source(field string) string {
+	if source, ok := o.Fields[field]; ok {
+		return source
+	}
+	switch i := slices.Index(ankiFields[o.Target], field); i {
+	case 0, 1:
+		return strconv.Itoa(i + 1)
+	}
+	return ""
+}
+
+// note reads the fields of the target out of a note as the options map them
+func (o AnkiOptions) note(n ankiNote) Note {
+	note := Note{SourceId: fitSourceId(n.guid), Fields: map[string]string{}, Tags: n.tags}
+	for _, field := range ankiFields[o.Target] {
+		if source := o.source(field); source != "" {
+			if value := n.field(source); value != "" {
+				note.Fields[field] = value
+			}
+		}
+	}
+	return note
+}
+
+// Note is an Anki note staged until the import is committed and it is saved
+// as grammar or vocabulary
+type Note struct {
+	// SourceId is the note's guid, which importing the same export again
+	// finds it by
+	SourceId string `json:"source_id"`
+
+	// Fields are what the note's grammar or vocabulary is saved with, by
+	// field name, e.g. usage
+	Fields map[string]string `json:"fields"`
+	Tags   []string          `json:"tags"`
+}
+
+// NoteType is a kind of note found in an Anki export, with the fields its
+// notes can be mapped by
+type NoteType struct {
+	// Name is empty for plain text exports that don't say
+	Name string `json:"name"`
+
+	// Fields are named as in Anki, or numbered for plain text exports
+	// without column names
+	Fields []string `json:"fields"`
+	Notes  int      `json:"notes"`
+
+	// Sample is the first note's fields, to show what they hold
+	Sample []string `json:"sample"`
+}
+
+// ankiNote is a note read from an export, fields stripped of their HTML
+type ankiNote struct {
+	guid     string
+	noteType string
+	names    []string
+	values   []string
+	tags     []string
+}
+
+// field returns a note field by name or 1-based position
+func (n ankiNote) field(key string) string {
+	if i, err := strconv.Atoi(key); err == nil {
+		if i >= 1 && i <= len(n.values) {
+			return n.values[i-1]
+		}
+		return ""
+	}
+	for i, name := range n.names {
+		if strings.EqualFold(name, key) && i < len(n.values) {
+			return n.values[i]
+		}
+	}
+	return ""
+}
+
+// noteTypes sums up the notes read by type, in the order they first appear
+func noteTypes(notes []ankiNote) []NoteType {
+	types := []NoteType{}
+	for _, note := range notes {
+		i := slices.IndexFunc(types, func(t NoteType) bool { return t.Name == note.noteType })
+		if i < 0 {
+			names := note.names
+			if len(names) == 0 {
+				for j := range note.values {
+					names = append(names, strconv.Itoa(j+1))
+				}
+			}
+			types = append(types, NoteType{Name: note.noteType, Fields: names, Sample: note.values})
+			i = len(types) - 1
+		}
+		types[i].Notes++
+	}
+	return types
+}
+
+// parseAnki reads an .apkg/.colpkg package or a plain text export
+func parseAnki(data []byte) ([]ankiNote, []string, error) {
+	if isZip(data) {
+		return parseAnkiPackage(data)
+	}
+	return parseAnkiText(data)
+}
+
+// checkAnki refuses files that can't be an Anki export without reading them
+// through
+func checkAnki(data []byte) error {
+	if !isZip(data) {
+		if !utf8.Valid(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))) {
+			return errors.New("the export is not UTF-8 text")
+		}
+		return nil
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return err
+	}
+	for _, f := range zr.File {
+		if slices.Contains(ankiCollections, f.Name) {
+			return nil
+		}
+	}
+	return errors.New("the package has no Anki collection")
+}
+
+// parseAnkiText reads Anki's "Notes in Plain Text" export: separated fields,
+// after header lines like #separator:tab, #html:true, #columns:... and
+// #guid column:1 saying which columns hold what besides the note's fields
+func parseAnkiText(data []byte) ([]ankiNote, []string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if !utf8.Valid(data) {
+		return nil, nil, errors.New("the export is not UTF-8 text")
+	}
+
+	separator, isHTML := '\t', true
+	var columns []string
+	// roles are what the columns that aren't note fields hold, by index
+	roles := map[int]string{}
+	body := data
+	for len(body) > 0 && body[0] == '#' {
+		line, rest, _ := bytes.Cut(body, []byte("\n"))
+		body = rest
+		key, value, ok := strings.Cut(strings.TrimSpace(string(line[1:])), ":")
+		if !ok {
+			continue
+		}
+		switch key = strings.ToLower(key); key {
+		case "separator":
+			separator = ankiSeparator(value)
+		case "html":
+			isHTML = value == "true"
+		case "columns":
+			columns = []string{value}
+		case "guid column", "notetype column", "deck column", "tags column":
+			if n, err := strconv.Atoi(value); err == nil && n > 0 {
+				roles[n-1] = strings.TrimSuffix(key, " column")
+			}
+		}
+	}
+	// column names are separated like the rest, which may be declared after
+	if len(columns) == 1 {
+		columns = strings.Split(columns[0], string(separator))
+	}
+
+	r := csv.NewReader(bytes.NewReader(body))
+	r.Comma = separator
+	r.LazyQuotes = true
+	r.FieldsPerRecord = -1
+
+	var notes []ankiNote
+	var problems []string
+	for line := 1; ; line++ {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("line %d: %v", line, err))
+			continue
+		}
+
+		var note ankiNote
+		for i, value := range record {
+			switch roles[i] {
+			case "guid":
+				note.guid = value
+			case "notetype":
+				note.noteType = value
+			case "deck":
+			case "tags":
+				note.tags = strings.Fields(value)
+			default:
+				if i < len(columns) {
+					note.names = append(note.names, columns[i])
+				}
+				if isHTML {
+					value = ankiPlainText(value)
+				}
+				note.values = append(note.values, strings.TrimSpace(value))
+			}
+		}
+		// names only count when every field has one
+		if len(note.names) != len(note.values) {
+			note.names = nil
+		}
+		notes = append(notes, note)
+	}
+	if len(notes) == 0 && len(problems) > 0 {
+		return nil, nil, errors.New(problems[0])
+	}
+	return notes, problems, nil
+}
+
+// ankiSeparator reads the #separator header, a name or the character itself
+func ankiSeparator(value string) rune {
+	switch strings.ToLower(value) {
+	case "tab", `\t`:
+		return '\t'
+	case "comma":
+		return ','
+	case "semicolon":
+		return ';'
+	case "space":
+		return ' '
+	case "pipe":
+		return '|'
+	case "colon":
+		return ':'
+	}
+	if r, size := utf8.DecodeRuneInString(value); size > 0 && size == len(value) {
+		return r
+	}
+	return '\t'
+}
+
+// parseAnkiPackage reads the notes of the collection database a package
+// holds. Media isn't imported.
+func parseAnkiPackage(data []byte) ([]ankiNote, []string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var collection *zip.File
+	for _, name := range ankiCollections {
+		if i := slices.IndexFunc(zr.File, func(f *zip.File) bool { return f.Name == name }); i >= 0 {
+			collection = zr.File[i]
+			break
+		}
+	}
+	if collection == nil {
+		return nil, nil, errors.New("the package has no Anki collection")
+	}
+
+	// the collection is a SQLite database, which has to be a file to open
+	dir, err := os.MkdirTemp("", "fushigi-anki-")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "collection.db")
+	if err := unpackCollection(collection, path); err != nil {
+		return nil, nil, err
+	}
+
+	db, err := core.DefaultDBConnect(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer db.Close()
+
+	noteTypes, err := ankiNoteTypes(db)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading note types: %w", err)
+	}
+
+	var rows []struct {
+		Guid   string `db:"guid"`
+		Mid    int64  `db:"mid"`
+		Tags   string `db:"tags"`
+		Fields string `db:"flds"`
+	}
+	if err := db.NewQuery("SELECT guid, mid, tags, flds FROM notes ORDER BY id").All(&rows); err != nil {
+		return nil, nil, fmt.Errorf("reading notes: %w", err)
+	}
+
+	var problems []string
+	notes := make([]ankiNote, 0, len(rows))
+	for _, row := range rows {
+		noteType, ok := noteTypes[row.Mid]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("note %s: unknown note type", row.Guid))
+		}
+		note := ankiNote{guid: row.Guid, noteType: noteType.name, names: noteType.fields, tags: strings.Fields(row.Tags)}
+		for _, value := range strings.Split(row.Fields, "\x1f") {
+			note.values = append(note.values, ankiPlainText(value))
+		}
+		if len(note.names) != len(note.values) {
+			note.names = nil
+		}
+		notes = append(notes, note)
+	}
+	return notes, problems, nil
+}
+
+// unpackCollection writes the collection database of a package to path,
+// decompressing it when it's zstd compressed
+func unpackCollection(f *zip.File, path string) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	var r io.Reader = rc
+	if strings.HasSuffix(f.Name, ".anki21b") {
+		dec, err := zstd.NewReader(rc, zstd.WithDecoderMaxMemory(maxCollectionSize))
+		if err != nil {
+			return err
+		}
+		defer dec.Close()
+		r = dec
+	}
+
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	// the header can lie about the size, so cap what is actually read
+	n, err := io.Copy(out, io.LimitReader(r, maxCollectionSize+1))
+	if err != nil {
+		return err
+	}
+	if n > maxCollectionSize {
+		return errors.New("the collection is too large")
+	}
+	return out.Close()
+}
+
+type ankiNoteType struct {
+	name   string
+	fields []string
+}
+
+// ankiNoteTypes reads the note types by id: from the notetypes and fields
+// tables of the newer schema, or the models JSON of the older one
+func ankiNoteTypes(db *dbx.DB) (map[int64]ankiNoteType, error) {
+	types := map[int64]ankiNoteType{}
+
+	var tables []string
+	if err := db.NewQuery("SELECT name FROM sqlite_master WHERE type = 'table'").Column(&tables); err != nil {
+		return nil, err
+	}
+
+	if slices.Contains(tables, "notetypes") && slices.Contains(tables, "fields") {
+		var notetypes []struct {
+			Id   int64  `db:"id"`
+			Name string `db:"name"`
+		}
+		if err := db.NewQuery("SELECT id, name FROM notetypes").All(&notetypes); err != nil {
+			return nil, err
+		}
+		for _, nt := range notetypes {
+			types[nt.Id] = ankiNoteType{name: nt.Name}
+		}
+
+		var fields []struct {
+			Ntid int64  `db:"ntid"`
+			Name string `db:"name"`
+		}
+		if err := db.NewQuery("SELECT ntid, name FROM fields ORDER BY ntid, ord").All(&fields); err != nil {
+			return nil, err
+		}
+		for _, field := range fields {
+			nt := types[field.Ntid]
+			nt.fields = append(nt.fields, field.Name)
+			types[field.Ntid] = nt
+		}
+		return types, nil
+	}
+
+	var models string
+	if err := db.NewQuery("SELECT models FROM col LIMIT 1").Row(&models); err != nil {
+		return nil, err
+	}
+	var byId map[string]struct {
+		Name   string `json:"name"`
+		Fields []struct {
+			Name string `json:"name"`
+		} `json:"flds"`
+	}
+	if err := json.Unmarshal([]byte(models), &byId); err != nil {
+		return nil, err
+	}
+	for id, model := range byId {
+		n, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			continue
+		}
+		// kept in order, as ord numbers them
+		nt := ankiNoteType{name: model.Name}
+		for _, field := range model.Fields {
+			nt.fields = append(nt.fields, field.Name)
+		}
+		types[n] = nt
+	}
+	return types, nil
+}
+
+// ankiPlainText turns a field's HTML into text, line breaks kept and sounds
+// and images dropped
+func ankiPlainText(value string) string {
+	value = ankiSound.ReplaceAllString(value, "")
+	value = ankiLineBreak.ReplaceAllString(value, "\n")
+	value = ankiTag.ReplaceAllString(value, "")
+	value = strings.ReplaceAll(html.UnescapeString(value), " ", " ")
+
+	lines := strings.Split(value, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.TrimSpace(blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
+
+// ankiKey is what tells grammar or vocabulary apart, its usage or term and
+// meaning regardless of case and spacing
+func ankiKey(name string, meaning string) string {
+	normalize := func(s string) string {
+		return strings.ToLower(strings.Join(strings.Fields(s), " "))
+	}
+	return normalize(name) + "\x00" + normalize(meaning)
+}
+
+// ankiIndex is the grammar or vocabulary the user already has, to tell what
+// committing would do with staged notes. Notes imported before are found by
+// their guid, the others by their usage or term and meaning, whether the
+// grammar or vocabulary they match is the user's own or the library's.
+type ankiIndex struct {
+	// name is the field holding the usage or term
+	name string
+	seen map[string]bool
+
+	// guids to the records they were imported as, empty for notes of the
+	// import being committed
+	sourced map[string]string
+}
+
+func newAnkiIndex(app core.App, userId string, options AnkiOptions) (*ankiIndex, error) {
+	index := &ankiIndex{name: ankiFields[options.Target][0], seen: map[string]bool{}, sourced: map[string]string{}}
+
+	existing, err := app.FindRecordsByFilter(options.Target, "language = {:language} && (user = {:user} || user = '')", "", 0, 0, dbx.Params{"language": options.Language, "user": userId})
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range existing {
+		index.seen[ankiKey(record.GetString(index.name), record.GetString("meaning"))] = true
+	}
+
+	imported, err := app.FindRecordsByFilter(options.Target, "user = {:user} && source = {:source}", "", 0, 0, dbx.Params{"user": userId, "source": SourceAnki})
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range imported {
+		if sourceId := record.GetString("source_id"); sourceId != "" {
+			index.sourced[sourceId] = record.Id
+		}
+	}
+	return index, nil
+}
+
+// match tells what committing would do with a note, and for updates which
+// record it replaces
+func (a *ankiIndex) match(note Note) (string, string) {
+	if id, ok := a.sourced[note.SourceId]; ok && note.SourceId != "" {
+		if id == "" {
+			return ItemDuplicate, ""
+		}
+		return ItemUpdateExisting, id
+	}
+
+	if a.seen[ankiKey(note.Fields[a.name], note.Fields["meaning"])] {
+		return ItemDuplicate, ""
+	}
+	return ItemReady, ""
+}
+
+// add counts a note as committed, so the ones like it are duplicates
+func (a *ankiIndex) add(note Note) {
+	a.seen[ankiKey(note.Fields[a.name], note.Fields["meaning"])] = true
+	if note.SourceId != "" {
+		a.sourced[note.SourceId] = ""
+	}
+}
+
+// checkNote runs the validation of the grammar or vocabulary a note becomes
+// without saving it
+func checkNote(app core.App, collection *core.Collection, userId string, options AnkiOptions, note Note) error {
+	name := ankiFields[options.Target][0]
+	if note.Fields[name] == "" || note.Fields["meaning"] == "" {
+		return fmt.Errorf("no %s or meaning", name)
+	}
+	return app.Validate(newNoteRecord(collection, userId, options, note))
+}
+
+func newNoteRecord(collection *core.Collection, userId string, options AnkiOptions, note Note) *core.Record {
+	record := core.NewRecord(collection)
+	record.Set("user", userId)
+	record.Set("source", SourceAnki)
+	record.Set("source_id", note.SourceId)
+	record.Set("tags", []string{})
+	setNote(record, options, note)
+	return record
+}
+
+// setNote copies what an imported note says onto its grammar or vocabulary
+func setNote(record *core.Record, options AnkiOptions, note Note) {
+	record.Set("language", options.Language)
+	for field, value := range note.Fields {
+		if field != "example" && field != "example_translation" {
+			record.Set(field, value)
+		}
+	}
+	if note.Fields["example"] != "" {
+		record.Set("examples", []map[string]string{{"japanese": note.Fields["example"], "english": note.Fields["example_translation"]}})
+	}
+	if len(note.Tags) > 0 {
+		record.Set("tags", note.Tags)
+	}
+}
@@ -0,0 +1,152 @@
+package imports
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// dayOneMoment matches the inline references to photos and audio Day One
+// leaves in entry text, e.g. ![](dayone-moment://6D2B...)
+var dayOneMoment = regexp.MustCompile(`!\[[^\]]*\]\(dayone-moment:/{1,2}[^)]*\)`)
+
+// dayOneExport is the JSON file of a Day One "JSON" export, one per journal
+type dayOneExport struct {
+	Entries []dayOneEntry `json:"entries"`
+}
+
+type dayOneEntry struct {
+	UUID         string          `json:"uuid"`
+	CreationDate time.Time       `json:"creationDate"`
+	TimeZone     string          `json:"timeZone"`
+	Text         string          `json:"text"`
+	Location     *dayOneLocation `json:"location"`
+	Photos       []dayOneMedia   `json:"photos"`
+	Audios       []dayOneMedia   `json:"audios"`
+}
+
+type dayOneLocation struct {
+	PlaceName          string `json:"placeName"`
+	LocalityName       string `json:"localityName"`
+	AdministrativeArea string `json:"administrativeArea"`
+	Country            string `json:"country"`
+}
+
+// dayOneMedia is a photo or recording, stored in the archive as
+// photos/<md5>.<type> or audios/<md5>.<format>
+type dayOneMedia struct {
+	MD5 string `json:"md5"`
+}
+
+// parseDayOneArchive reads the zip produced by Day One's JSON export. Every
+// JSON file at its root is a journal, photos and audio recordings come along
+// as attachments.
+func parseDayOneArchive(archive []byte) ([]Entry, []string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// attachments are named after their md5, whatever their extension
+	media := map[string]*zip.File{}
+	var journals []*zip.File
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || isHidden(f.Name) {
+			continue
+		}
+		switch dir := path.Dir(f.Name); {
+		case dir == "." && strings.EqualFold(path.Ext(f.Name), ".json"):
+			journals = append(journals, f)
+		case dir == "photos" || dir == "audios":
+			media[strings.TrimSuffix(path.Base(f.Name), path.Ext(f.Name))] = f
+		}
+	}
+	if len(journals) == 0 {
+		return nil, nil, fmt.Errorf("no Day One journal found in the archive")
+	}
+
+	var entries []Entry
+	var problems []string
+	for _, f := range journals {
+		data, err := readZipFile(f, maxJournalFileSize)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", f.Name, err))
+			continue
+		}
+
+		var export dayOneExport
+		if err := json.Unmarshal(data, &export); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: not a Day One journal: %v", f.Name, err))
+			continue
+		}
+
+		for _, de := range export.Entries {
+			entry, err := newDayOneEntry(f.Name, de, media)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", entry.Source, err))
+				continue
+			}
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, problems, nil
+}
+
+func newDayOneEntry(journalName string, de dayOneEntry, media map[string]*zip.File) (Entry, error) {
+	entry := Entry{
+		Source:   journalName + "#" + de.UUID,
+		SourceId: fitSourceId(de.UUID),
+		Created:  de.CreationDate,
+	}
+	if loc, err := time.LoadLocation(de.TimeZone); err == nil && !entry.Created.IsZero() {
+		entry.Created = entry.Created.In(loc)
+	}
+
+	// Day One treats the first line as the title
+	text := strings.TrimSpace(dayOneMoment.ReplaceAllString(de.Text, ""))
+	title, body, _ := strings.Cut(text, "\n")
+	title = strings.TrimSpace(strings.TrimLeft(title, "# "))
+	body = strings.TrimSpace(body)
+	if body == "" {
+		// a one line entry is all body
+		title, body = "", text
+	}
+	if title == "" && !entry.Created.IsZero() {
+		title = entry.Created.Format("2006-01-02")
+	}
+	entry.Title = title
+	entry.Content = body
+
+	if de.Location != nil {
+		var parts []string
+		for _, part := range []string{de.Location.PlaceName, de.Location.LocalityName, de.Location.AdministrativeArea, de.Location.Country} {
+			if part != "" && !slices.Contains(parts, part) {
+				parts = append(parts, part)
+			}
+		}
+		entry.Location = strings.Join(parts, ", ")
+	}
+
+	for _, m := range de.Photos {
+		if f, ok := media[m.MD5]; ok {
+			entry.Attachments = append(entry.Attachments, zipAttachment(f, KindAttachment))
+		}
+	}
+	for _, m := range de.Audios {
+		if f, ok := media[m.MD5]; ok {
+			entry.Attachments = append(entry.Attachments, zipAttachment(f, KindAudio))
+		}
+	}
+
+	if entry.Content == "" {
+		return entry, fmt.Errorf("entry is empty")
+	}
+	return entry, nil
+}
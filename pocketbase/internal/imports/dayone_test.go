@@ -0,0 +1,76 @@
+package imports
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDayOneArchive(t *testing.T) {
+	entries, problems, err := parseDayOneArchive(zipFixture(t, "dayone"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Entry{
+		{
+			Source:   "Journal.json#6D2B1C0E4F5A4B3C9D8E7F6A5B4C3D2E",
+			SourceId: "6D2B1C0E4F5A4B3C9D8E7F6A5B4C3D2E",
+			Title:    "花見",
+			Content:  "桜がきれいでした。",
+			Location: "上野公園, 台東区, 東京都, 日本",
+			Created:  time.Date(2025, 3, 1, 10, 30, 0, 0, tokyo(t)),
+			Attachments: []Attachment{
+				{Name: "0cc175b9c0f1b6a831c399e269772661.jpeg", Kind: KindAttachment, Path: "photos/0cc175b9c0f1b6a831c399e269772661.jpeg"},
+			},
+		},
+		{
+			// an unknown time zone keeps the date as exported
+			Source:   "Journal.json#A1B2C3D4E5F6A7B8C9D0E1F2A3B4C5D6",
+			SourceId: "A1B2C3D4E5F6A7B8C9D0E1F2A3B4C5D6",
+			Title:    "2025-03-02",
+			Content:  "一行だけ。",
+			Created:  time.Date(2025, 3, 2, 12, 0, 0, 0, time.UTC),
+		},
+	}
+	got := bySource(entries)
+	if len(entries) != len(want) {
+		t.Errorf("parsed %d entries, want %d", len(entries), len(want))
+	}
+	for _, w := range want {
+		wantEntry(t, got[w.Source], w)
+	}
+	checkProblems(t, problems,
+		"Journal.json#FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF: entry is empty",
+		"Broken.json: not a Day One journal",
+	)
+}
+
+func TestParseDayOneMalformed(t *testing.T) {
+	tests := []struct {
+		name    string
+		archive []byte
+	}{
+		{"not a zip", []byte("not a zip")},
+		{"no journal", zipFiles(t, map[string]string{"photos/abc.jpeg": "a", "notes/Journal.json": "{}"})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := parseDayOneArchive(tt.archive); err == nil {
+				t.Error("parseDayOneArchive() succeeded, want an error")
+			}
+		})
+	}
+
+	// journals that parse to nothing are reported, not failed
+	entries, problems, err := parseDayOneArchive(zipFiles(t, map[string]string{
+		"Journal.json": `{"entries": "none"}`,
+		"Other.json":   `{"entries": [{"uuid": "x", "text": "   "}]}`,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) > 0 {
+		t.Errorf("parsed %d entries, want none", len(entries))
+	}
+	checkProblems(t, problems, "Journal.json: not a Day One journal", "Other.json#x: entry is empty")
+}
@@ -0,0 +1,36 @@
+package imports
+
+import (
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// stagedTTL is how long a staged import waits to be committed or discarded
+const stagedTTL = 7 * 24 * time.Hour
+
+func BindHooks(app core.App) {
+	// Staged imports hold their archive, don't keep the forgotten ones
+	app.Cron().MustAdd("fushigiStagedImportsCleanup", "15 4 * * *", func() {
+		records, err := app.FindRecordsByFilter(
+			"imports",
+			"status = {:status} && created < {:before}",
+			"",
+			0,
+			0,
+			map[string]any{"status": StatusStaged, "before": types.NowDateTime().Add(-stagedTTL).String()},
+		)
+		if err != nil {
+			app.Logger().Error("Failed to load expired staged imports", "error", err)
+			return
+		}
+
+		// deleted one by one rather than with a query so their archives go too
+		for _, record := range records {
+			if err := app.Delete(record); err != nil {
+				app.Logger().Error("Failed to delete expired staged import", "import", record.Id, "error", err)
+			}
+		}
+	})
+}
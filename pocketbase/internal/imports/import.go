@@ -0,0 +1,98 @@
+package imports
+
+import (
+	"archive/zip"
+	"crypto/sha1"
+	"encoding/hex"
+	"path"
+	"time"
+)
+
+const (
+	// Archives are held in memory while a job imports them
+	maxArchiveSize = 32 << 20
+
+	// Limit what single files of an archive may expand to, against zip bombs
+	maxEntryFileSize      = 1 << 20
+	maxJournalFileSize    = 64 << 20
+	maxAttachmentFileSize = 50 << 20
+
+	// journal_entry content is a plain text field with PocketBase's default limit
+	maxContentLength = 5000
+
+	// journal_entry source_id limit, longer ids are hashed to fit
+	maxSourceIdLength = 100
+)
+
+// Entry is a journal entry read from another tool's export, staged until the
+// import is committed and it is saved as a journal_entry
+type Entry struct {
+	// Source names where the entry came from in the archive, for error reports
+	Source string `json:"-"`
+
+	// SourceId is the entry's id in the tool it came from, which importing
+	// the same export again finds it by
+	SourceId string `json:"source_id"`
+
+	Title    string    `json:"title"`
+	Content  string    `json:"content"`
+	Location string    `json:"location"`
+	Created  time.Time `json:"created"`
+
+	// Audience is who else the entry is shown to, the user's default when
+	// empty
+	Audience string `json:"audience"`
+
+	// Mood is how the writer felt, empty when the export doesn't say
+	Mood string `json:"mood"`
+
+	Attachments []Attachment `json:"attachments"`
+	Corrections []Correction `json:"corrections"`
+}
+
+// Correction is feedback the entry received on the site it came from
+type Correction struct {
+	Original  string    `json:"original"`
+	Corrected string    `json:"corrected"`
+	Comment   string    `json:"comment"`
+	Corrector string    `json:"corrector"`
+	Source    string    `json:"source"`
+	Created   time.Time `json:"created"`
+}
+
+// Media kinds, as in the media collection
+const (
+	KindAttachment = "attachment"
+	KindAudio      = "audio"
+)
+
+// Attachment is a file that came with an entry, read from the archive only
+// once the entry is saved so archives don't have to be unpacked up front
+type Attachment struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+	Path string `json:"path"`
+}
+
+// fitSourceId keeps ids like long vault paths within the source_id limit
+func fitSourceId(id string) string {
+	if len(id) <= maxSourceIdLength {
+		return id
+	}
+	sum := sha1.Sum([]byte(id))
+	return "sha1:" + hex.EncodeToString(sum[:])
+}
+
+func zipAttachment(f *zip.File, kind string) Attachment {
+	return Attachment{Name: path.Base(f.Name), Kind: kind, Path: f.Name}
+}
+
+// Result is what committing an import stores as its result
+type Result struct {
+	Imported   int      `json:"imported"`
+	Updated    int      `json:"updated"`
+	Duplicates int      `json:"duplicates"`
+	Skipped    int      `json:"skipped"`
+	Failed     int      `json:"failed"`
+	Errors     []string `json:"errors"`
+}
@@ -0,0 +1,122 @@
+package imports
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// zipFixture zips a directory of testdata the way the tools export it
+func zipFixture(t *testing.T, dir string) []byte {
+	t.Helper()
+	root := filepath.Join("testdata", dir)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	err := filepath.WalkDir(root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(root, name)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		w, err := zw.Create(filepath.ToSlash(rel))
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// zipFiles zips the given files by name
+func zipFiles(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// bySource indexes parsed entries, which come in archive order
+func bySource(entries []Entry) map[string]Entry {
+	result := make(map[string]Entry, len(entries))
+	for _, entry := range entries {
+		result[entry.Source] = entry
+	}
+	return result
+}
+
+func wantEntry(t *testing.T, got Entry, want Entry) {
+	t.Helper()
+	if got.SourceId != want.SourceId || got.Title != want.Title || got.Content != want.Content || got.Location != want.Location || got.Audience != want.Audience || got.Mood != want.Mood {
+		t.Errorf("%s = %+v, want %+v", want.Source, got, want)
+	}
+	if !got.Created.Equal(want.Created) {
+		t.Errorf("%s created %v, want %v", want.Source, got.Created, want.Created)
+	}
+	if !slices.Equal(got.Attachments, want.Attachments) {
+		t.Errorf("%s attachments = %+v, want %+v", want.Source, got.Attachments, want.Attachments)
+	}
+	if len(got.Corrections) != len(want.Corrections) {
+		t.Errorf("%s corrections = %+v, want %+v", want.Source, got.Corrections, want.Corrections)
+		return
+	}
+	for i, c := range got.Corrections {
+		w := want.Corrections[i]
+		if c.Original != w.Original || c.Corrected != w.Corrected || c.Comment != w.Comment || c.Corrector != w.Corrector || !c.Created.Equal(w.Created) {
+			t.Errorf("%s correction %d = %+v, want %+v", want.Source, i, c, w)
+		}
+	}
+}
+
+// checkProblems wants one problem mentioning each of the given strings
+func checkProblems(t *testing.T, problems []string, want ...string) {
+	t.Helper()
+	if len(problems) != len(want) {
+		t.Errorf("problems = %q, want %d", problems, len(want))
+	}
+	for _, w := range want {
+		if !slices.ContainsFunc(problems, func(p string) bool { return strings.Contains(p, w) }) {
+			t.Errorf("problems = %q, want one about %q", problems, w)
+		}
+	}
+}
+
+func tokyo(t *testing.T) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
@@ -0,0 +1,214 @@
+package imports
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	SourceLang8       = "lang8"
+	SourceLangCorrect = "langcorrect"
+)
+
+// Sources are the sites corrections can be imported from
+var Sources = []string{SourceLang8, SourceLangCorrect}
+
+// Neither site has a documented dump format, and most Lang-8 archives come
+// from community scripts written before it closed, so fields are looked up
+// under every name they are known to go by
+var (
+	lang8EntryLists      = []string{"entries", "journals", "posts"}
+	lang8TitleKeys       = []string{"title", "subject"}
+	lang8BodyKeys        = []string{"text", "body", "content"}
+	lang8DateKeys        = []string{"created_at", "created", "date", "posted_at"}
+	lang8CorrectionLists = []string{"corrections", "feedback"}
+	lang8SentenceLists   = []string{"sentences", "corrections"}
+	lang8CorrectorKeys   = []string{"corrector", "username", "user", "author"}
+	lang8CommentKeys     = []string{"overall_feedback", "comment", "note", "feedback"}
+	lang8OriginalKeys    = []string{"original", "source", "before"}
+	lang8CorrectedKeys   = []string{"corrected", "correction", "after"}
+)
+
+// parseLang8Archive reads a Lang-8 or LangCorrect dump, either a JSON file or
+// a zip of them. Dates without a time zone are read in loc.
+func parseLang8Archive(archive []byte, loc *time.Location) ([]Entry, []string, error) {
+	if !isZip(archive) {
+		entries, problems := parseLang8JSON("dump", archive, loc)
+		if entries == nil && len(problems) > 0 {
+			return nil, nil, fmt.Errorf("%s", problems[0])
+		}
+		return entries, problems, nil
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var entries []Entry
+	var problems []string
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || isHidden(f.Name) || !strings.EqualFold(path.Ext(f.Name), ".json") {
+			continue
+		}
+
+		data, err := readZipFile(f, maxJournalFileSize)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", f.Name, err))
+			continue
+		}
+
+		fileEntries, fileProblems := parseLang8JSON(f.Name, data, loc)
+		entries = append(entries, fileEntries...)
+		problems = append(problems, fileProblems...)
+	}
+
+	return entries, problems, nil
+}
+
+func parseLang8JSON(name string, data []byte, loc *time.Location) ([]Entry, []string) {
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, []string{fmt.Sprintf("%s: not a JSON file: %v", name, err)}
+	}
+
+	// either a bare list of entries or an object holding one
+	items, ok := doc.([]any)
+	if obj, isObj := doc.(map[string]any); isObj {
+		items, ok = firstList(obj, lang8EntryLists)
+	}
+	if !ok {
+		return nil, []string{fmt.Sprintf("%s: no entries found", name)}
+	}
+
+	var entries []Entry
+	var problems []string
+	for i, item := range items {
+		obj, ok := item.(map[string]any)
+		source := fmt.Sprintf("%s#%d", name, i+1)
+		if !ok {
+			problems = append(problems, source+": not an entry")
+			continue
+		}
+
+		entry := Entry{
+			Source:   source,
+			SourceId: lang8Id(obj),
+			Title:    strings.TrimSpace(firstString(obj, lang8TitleKeys)),
+			Content:  strings.TrimSpace(firstString(obj, lang8BodyKeys)),
+		}
+		entry.Created, _ = parseDate(firstString(obj, lang8DateKeys), loc)
+		if entry.Title == "" && !entry.Created.IsZero() {
+			entry.Title = entry.Created.In(loc).Format("2006-01-02")
+		}
+		if entry.Content == "" {
+			problems = append(problems, source+": entry is empty")
+			continue
+		}
+
+		corrections, _ := firstList(obj, lang8CorrectionLists)
+		for _, c := range corrections {
+			if obj, ok := c.(map[string]any); ok {
+				entry.Corrections = append(entry.Corrections, lang8Corrections(obj, loc)...)
+			}
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, problems
+}
+
+// lang8Corrections reads one correction, which is either a single corrected
+// sentence or a correction of several sentences with overall feedback
+func lang8Corrections(obj map[string]any, loc *time.Location) []Correction {
+	base := Correction{Corrector: corrector(obj)}
+	base.Created, _ = parseDate(firstString(obj, lang8DateKeys), loc)
+
+	sentences, ok := firstList(obj, lang8SentenceLists)
+	if !ok {
+		c := base
+		c.Original = firstString(obj, lang8OriginalKeys)
+		c.Corrected = firstString(obj, lang8CorrectedKeys)
+		c.Comment = firstString(obj, lang8CommentKeys)
+		if c.Original == "" && c.Comment == "" {
+			return nil
+		}
+		return []Correction{c}
+	}
+
+	var result []Correction
+	for _, s := range sentences {
+		sentence, ok := s.(map[string]any)
+		if !ok {
+			continue
+		}
+		c := base
+		c.Original = firstString(sentence, lang8OriginalKeys)
+		c.Corrected = firstString(sentence, lang8CorrectedKeys)
+		c.Comment = firstString(sentence, lang8CommentKeys)
+		if c.Original != "" {
+			result = append(result, c)
+		}
+	}
+	if comment := firstString(obj, lang8CommentKeys); comment != "" {
+		c := base
+		c.Comment = comment
+		result = append(result, c)
+	}
+	return result
+}
+
+// corrector is the name of who corrected, which dumps give either as a
+// string or as a user object
+func corrector(obj map[string]any) string {
+	if name := firstString(obj, lang8CorrectorKeys); name != "" {
+		return name
+	}
+	for _, key := range lang8CorrectorKeys {
+		if user, ok := obj[key].(map[string]any); ok {
+			return firstString(user, []string{"username", "name", "display_name"})
+		}
+	}
+	return ""
+}
+
+// lang8Id is the id of an entry on the site, a number or a string depending
+// on who wrote the dump
+func lang8Id(obj map[string]any) string {
+	switch id := obj["id"].(type) {
+	case string:
+		return fitSourceId(id)
+	case float64:
+		return strconv.FormatFloat(id, 'f', -1, 64)
+	}
+	return ""
+}
+
+func firstString(obj map[string]any, keys []string) string {
+	for _, key := range keys {
+		if s, ok := obj[key].(string); ok && s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+func firstList(obj map[string]any, keys []string) ([]any, bool) {
+	for _, key := range keys {
+		if list, ok := obj[key].([]any); ok {
+			return list, true
+		}
+	}
+	return nil, false
+}
+
+func isZip(data []byte) bool {
+	return bytes.HasPrefix(data, []byte("PK\x03\x04"))
+}
@@ -0,0 +1,101 @@
+package imports
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseLang8Archive(t *testing.T) {
+	loc := tokyo(t)
+	dump, err := os.ReadFile(filepath.Join("testdata", "lang8", "dump.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := func(file string) []Entry {
+		return []Entry{
+			{
+				Source:   file + "#1",
+				SourceId: "1042",
+				Title:    "初めての日記",
+				Content:  "私は日本語を勉強してます。",
+				Created:  time.Date(2014, 5, 6, 21, 15, 0, 0, loc),
+				Corrections: []Correction{
+					{Original: "私は日本語を勉強してます。", Corrected: "私は日本語を勉強しています。", Comment: "「い」を忘れずに", Corrector: "tanaka", Created: time.Date(2014, 5, 7, 8, 0, 0, 0, loc)},
+					{Comment: "よく書けています！", Corrector: "tanaka", Created: time.Date(2014, 5, 7, 8, 0, 0, 0, loc)},
+					{Original: "勉強してます", Corrected: "勉強しています", Corrector: "suzuki"},
+				},
+			},
+			{
+				Source:   file + "#2",
+				SourceId: "lc-77",
+				Title:    "2020-01-02",
+				Content:  "Titleless entry",
+				Created:  time.Date(2020, 1, 2, 0, 0, 0, 0, loc),
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		archive  []byte
+		want     []Entry
+		problems []string
+	}{
+		{"a JSON file", dump, want("dump"), []string{"dump#3: entry is empty", "dump#4: not an entry"}},
+		{"a zip of them", zipFixture(t, "lang8"), want("dump.json"), []string{"dump.json#3: entry is empty", "dump.json#4: not an entry", "broken.json: not a JSON file"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, problems, err := parseLang8Archive(tt.archive, loc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := bySource(entries)
+			if len(entries) != len(tt.want) {
+				t.Errorf("parsed %d entries, want %d", len(entries), len(tt.want))
+			}
+			for _, w := range tt.want {
+				wantEntry(t, got[w.Source], w)
+			}
+			checkProblems(t, problems, tt.problems...)
+		})
+	}
+}
+
+func TestParseLang8Malformed(t *testing.T) {
+	loc := tokyo(t)
+	broken, err := os.ReadFile(filepath.Join("testdata", "lang8", "broken.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		archive []byte
+	}{
+		{"truncated JSON", broken},
+		{"not JSON", []byte("<html></html>")},
+		{"no entry list", []byte(`{"user": "me", "entries": {"id": 1}}`)},
+		{"a bare value", []byte(`"entries"`)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if entries, _, err := parseLang8Archive(tt.archive, loc); err == nil {
+				t.Errorf("parseLang8Archive() = %+v, want an error", entries)
+			}
+		})
+	}
+
+	// bare lists work, and corrections that aren't objects or say nothing are
+	// left out
+	entries, problems, err := parseLang8Archive([]byte(`[{"title": "t", "content": "c", "feedback": ["great", {"user": "x"}]}]`), loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || len(entries[0].Corrections) != 0 || len(problems) != 0 {
+		t.Errorf("parseLang8Archive() = %+v with problems %q, want one entry without corrections", entries, problems)
+	}
+}
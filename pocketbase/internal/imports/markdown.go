@@ -0,0 +1,195 @@
+package imports
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/journal"
+
+	"gopkg.in/yaml.v3"
+)
+
+// markdownExtensions are the files of an archive read as entries, the rest
+// (attachments, vault settings, ...) is ignored
+var markdownExtensions = []string{".md", ".markdown", ".txt"}
+
+// fileDatePrefix matches the date daily notes are usually named after
+var fileDatePrefix = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})[ _-]*`)
+
+// dateLayouts are tried in order on frontmatter dates
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"2006/01/02",
+	"January 2, 2006",
+}
+
+// parseMarkdownArchive reads every markdown file of a zip, like an Obsidian
+// vault or a markdown export, into entries. Dates without a time zone are
+// read in loc. Files that can't be read are reported rather than failing the
+// whole archive.
+func parseMarkdownArchive(archive []byte, loc *time.Location) ([]Entry, []string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var entries []Entry
+	var problems []string
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || isHidden(f.Name) || !isMarkdown(f.Name) {
+			continue
+		}
+		data, err := readZipFile(f, maxEntryFileSize)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", f.Name, err))
+			continue
+		}
+
+		entry, err := parseMarkdown(f.Name, data, f.Modified, loc)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", f.Name, err))
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, problems, nil
+}
+
+func parseMarkdown(name string, data []byte, modified time.Time, loc *time.Location) (Entry, error) {
+	text := strings.TrimPrefix(string(data), "\ufeff")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+
+	frontmatter := map[string]any{}
+	if rest, ok := strings.CutPrefix(text, "---\n"); ok {
+		head, body, found := strings.Cut(rest, "\n---")
+		if found {
+			if err := yaml.Unmarshal([]byte(head), &frontmatter); err != nil {
+				return Entry{}, fmt.Errorf("invalid frontmatter: %w", err)
+			}
+			// drop the rest of the closing line
+			_, text, _ = strings.Cut(body, "\n")
+		}
+	}
+	body := strings.TrimSpace(text)
+
+	// files have no id, their path in the vault is what stays put
+	entry := Entry{Source: name, SourceId: fitSourceId(name)}
+	// exports before audiences only said whether entries were private
+	if audience, ok := frontmatter["audience"].(string); ok {
+		entry.Audience = audience
+	} else if private, ok := frontmatter["private"].(bool); ok && !private {
+		entry.Audience = journal.AudiencePublic
+	}
+
+	// moods fushigi doesn't have are dropped rather than failing the entry
+	if mood, ok := frontmatter["mood"].(string); ok && slices.Contains(journal.Moods, strings.ToLower(strings.TrimSpace(mood))) {
+		entry.Mood = strings.ToLower(strings.TrimSpace(mood))
+	}
+
+	stem := strings.TrimSuffix(path.Base(name), path.Ext(name))
+
+	entry.Title, _ = frontmatter["title"].(string)
+	if entry.Title == "" && strings.HasPrefix(body, "# ") {
+		heading, rest, _ := strings.Cut(body, "\n")
+		entry.Title = strings.TrimPrefix(heading, "# ")
+		body = strings.TrimSpace(rest)
+	}
+	if entry.Title == "" {
+		entry.Title = fileDatePrefix.ReplaceAllString(stem, "")
+	}
+	if entry.Title == "" {
+		entry.Title = stem
+	}
+	entry.Title = strings.TrimSpace(entry.Title)
+
+	for _, key := range []string{"date", "created", "created_at"} {
+		if created, ok := parseDate(frontmatter[key], loc); ok {
+			entry.Created = created
+			break
+		}
+	}
+	if entry.Created.IsZero() {
+		if m := fileDatePrefix.FindStringSubmatch(stem); m != nil {
+			entry.Created, _ = time.ParseInLocation("2006-01-02", m[1], loc)
+		}
+	}
+	if entry.Created.IsZero() && !modified.IsZero() {
+		entry.Created = modified
+	}
+
+	if body == "" {
+		return Entry{}, fmt.Errorf("entry is empty")
+	}
+	entry.Content = body
+
+	return entry, nil
+}
+
+// parseDate reads a frontmatter date, which YAML may already have decoded
+func parseDate(value any, loc *time.Location) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		// a bare date decodes as midnight UTC, but was meant in the user's zone
+		if v.Location() == time.UTC && v.Hour() == 0 && v.Minute() == 0 && v.Second() == 0 {
+			return time.Date(v.Year(), v.Month(), v.Day(), 0, 0, 0, 0, loc), true
+		}
+		return v, true
+	case string:
+		for _, layout := range dateLayouts {
+			if t, err := time.ParseInLocation(layout, strings.TrimSpace(v), loc); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+func readZipFile(f *zip.File, limit int64) ([]byte, error) {
+	if f.UncompressedSize64 > uint64(limit) {
+		return nil, fmt.Errorf("file is too large")
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	// the header can lie about the size, so cap what is actually read too
+	data, err := io.ReadAll(io.LimitReader(rc, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("file is too large")
+	}
+	return data, nil
+}
+
+// isHidden skips dot folders like .obsidian and .trash, and the resource
+// forks macOS adds to zips
+func isHidden(name string) bool {
+	for _, part := range strings.Split(name, "/") {
+		if strings.HasPrefix(part, ".") || part == "__MACOSX" {
+			return true
+		}
+	}
+	return false
+}
+
+func isMarkdown(name string) bool {
+	return slices.Contains(markdownExtensions, strings.ToLower(path.Ext(name)))
+}
@@ -0,0 +1,147 @@
+package imports
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/journal"
+)
+
+func TestParseMarkdownArchive(t *testing.T) {
+	loc := tokyo(t)
+	entries, problems, err := parseMarkdownArchive(zipFixture(t, "markdown"), loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Entry{
+		{
+			Source:   "2025-01-31 Coffee.md",
+			SourceId: "2025-01-31 Coffee.md",
+			Title:    "喫茶店で",
+			Content:  "今日は喫茶店でコーヒーを飲みました。\n\nとてもおいしかったです。",
+			Audience: journal.AudiencePublic,
+			Mood:     journal.MoodGood,
+			Created:  time.Date(2025, 1, 31, 8, 30, 0, 0, loc),
+		},
+		{
+			Source:   "notes/2025-02-01.md",
+			SourceId: "notes/2025-02-01.md",
+			Title:    "雨の日",
+			Content:  "雨が降っていたので、家で本を読みました。",
+			Created:  time.Date(2025, 2, 1, 0, 0, 0, 0, loc),
+		},
+		{
+			Source:   "notes/untitled.txt",
+			SourceId: "notes/untitled.txt",
+			Title:    "untitled",
+			Content:  "日付だけのメモ。",
+			Audience: journal.AudiencePublic,
+			Created:  time.Date(2025, 2, 3, 0, 0, 0, 0, loc),
+		},
+	}
+	got := bySource(entries)
+	if len(entries) != len(want) {
+		t.Errorf("parsed %d entries, want %d", len(entries), len(want))
+	}
+	for _, w := range want {
+		wantEntry(t, got[w.Source], w)
+	}
+	checkProblems(t, problems, "broken.md: invalid frontmatter", "empty.md: entry is empty")
+}
+
+func TestParseMarkdown(t *testing.T) {
+	loc := tokyo(t)
+	modified := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		file    string
+		data    string
+		title   string
+		content string
+		created time.Time
+	}{
+		{"plain text", "note.md", "Just a note.", "note", "Just a note.", modified},
+		{"byte order mark and CRLF", "note.md", "\ufeff# Title\r\n\r\nLine one\r\nLine two", "Title", "Line one\nLine two", modified},
+		{"date prefix dropped from the title", "2025-04-01_Spring.md", "Text", "Spring", "Text", time.Date(2025, 4, 1, 0, 0, 0, 0, loc)},
+		{"date only name", "2025-04-01.md", "Text", "2025-04-01", "Text", time.Date(2025, 4, 1, 0, 0, 0, 0, loc)},
+		{"written dates", "note.md", "---\ndate: January 5, 2025\n---\nText", "note", "Text", time.Date(2025, 1, 5, 0, 0, 0, 0, loc)},
+		{"dates with a zone", "note.md", "---\ndate: 2025-01-05T10:00:00Z\n---\nText", "note", "Text", time.Date(2025, 1, 5, 10, 0, 0, 0, time.UTC)},
+		{"unreadable dates", "note.md", "---\ndate: someday\n---\nText", "note", "Text", modified},
+		{"unclosed frontmatter is text", "note.md", "---\ntitle: x\nText", "note", "---\ntitle: x\nText", modified},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, err := parseMarkdown(tt.file, []byte(tt.data), modified, loc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if entry.Title != tt.title || entry.Content != tt.content || !entry.Created.Equal(tt.created) {
+				t.Errorf("parseMarkdown() = %q, %q, %v, want %q, %q, %v", entry.Title, entry.Content, entry.Created, tt.title, tt.content, tt.created)
+			}
+		})
+	}
+}
+
+func TestParseMarkdownMood(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"none", "Text", ""},
+		{"exported", "---\nmood: okay\n---\nText", journal.MoodOkay},
+		{"written by hand", "---\nmood: \" Great \"\n---\nText", journal.MoodGreat},
+		{"unknown", "---\nmood: sleepy\n---\nText", ""},
+		{"not text", "---\nmood: 4\n---\nText", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, err := parseMarkdown("note.md", []byte(tt.data), time.Time{}, time.UTC)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if entry.Mood != tt.want {
+				t.Errorf("parseMarkdown() mood = %q, want %q", entry.Mood, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMarkdownMalformed(t *testing.T) {
+	loc := tokyo(t)
+
+	if _, _, err := parseMarkdownArchive([]byte("not a zip"), loc); err == nil {
+		t.Error("parseMarkdownArchive() of a file that isn't a zip succeeded")
+	}
+
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"empty", ""},
+		{"whitespace", " \n\n "},
+		{"frontmatter only", "---\ntitle: x\n---\n"},
+		{"invalid frontmatter", "---\ntitle: [x\n---\nText"},
+		{"frontmatter not a map", "---\n- a\n- b\n---\nText"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if entry, err := parseMarkdown("note.md", []byte(tt.data), time.Time{}, loc); err == nil {
+				t.Errorf("parseMarkdown() = %+v, want an error", entry)
+			}
+		})
+	}
+
+	// a file the zip says is small but isn't is cut off
+	archive := zipFiles(t, map[string]string{"big.md": string(make([]byte, maxEntryFileSize+1))})
+	entries, problems, err := parseMarkdownArchive(archive, loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) > 0 {
+		t.Errorf("parsed %d entries from a file over the limit", len(entries))
+	}
+	checkProblems(t, problems, "big.md: file is too large")
+}
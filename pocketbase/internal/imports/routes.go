@@ -0,0 +1,235 @@
+package imports
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/jobs"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// RegisterRoutes adds the importers, which stage what they read for the
+// journal or as grammar or vocabulary, and the routes to preview, fix,
+// commit, discard and roll back imports
+func RegisterRoutes(g *api.Group, importsService Service) {
+	// multipart/form-data with the archive as "file"
+	g.POST("/imports/markdown", "Stage a zip of markdown files, like an Obsidian vault, for the journal", nil, jobs.Job{}, func(e *core.RequestEvent) error {
+		archive, err := uploadedZip(e)
+		if err != nil {
+			return err
+		}
+
+		job, err := importsService.Markdown(e.Auth.Id, archive)
+		if err != nil {
+			return e.InternalServerError("Failed to start the import.", err)
+		}
+		return e.JSON(200, job)
+	})
+
+	// multipart/form-data with the zip Day One's JSON export produces as "file"
+	g.POST("/imports/dayone", "Stage a Day One JSON export, photos included, for the journal", nil, jobs.Job{}, func(e *core.RequestEvent) error {
+		archive, err := uploadedZip(e)
+		if err != nil {
+			return err
+		}
+
+		job, err := importsService.DayOne(e.Auth.Id, archive)
+		if err != nil {
+			return e.InternalServerError("Failed to start the import.", err)
+		}
+		return e.JSON(200, job)
+	})
+
+	// multipart/form-data with the dump, a JSON file or a zip of them, as
+	// "file" and the site it came from as "source" (lang8 by default)
+	g.POST("/imports/lang8", "Stage Lang-8 or LangCorrect entries with the corrections they received", nil, jobs.Job{}, func(e *core.RequestEvent) error {
+		source := e.Request.FormValue("source")
+		if source == "" {
+			source = SourceLang8
+		}
+		if !slices.Contains(Sources, source) {
+			return e.BadRequestError("Invalid import request.", validation.Errors{
+				"source": validation.NewError("validation_invalid_value", "Invalid value."),
+			})
+		}
+
+		dump, err := uploadedFile(e)
+		if err != nil {
+			return err
+		}
+
+		job, err := importsService.Lang8(e.Auth.Id, source, dump)
+		if err != nil {
+			return e.InternalServerError("Failed to start the import.", err)
+		}
+		return e.JSON(200, job)
+	})
+
+	// multipart/form-data with the .apkg package or plain text export as
+	// "file"
+	g.POST("/imports/anki/note-types", "The note types of an Anki export with their fields, to map them before importing", nil, []NoteType{}, func(e *core.RequestEvent) error {
+		file, err := uploadedFile(e)
+		if err != nil {
+			return err
+		}
+
+		noteTypes, err := importsService.AnkiNoteTypes(file)
+		if err != nil {
+			return e.BadRequestError("The file is not an Anki export.", err)
+		}
+		return e.JSON(200, noteTypes)
+	})
+
+	// multipart/form-data with the export as "file", what notes become as
+	// "target" (grammar or vocabulary), their "language", optionally the
+	// "note_type" to import, and per field of the target the note field it's
+	// read from by name or position, e.g. usage=Front
+	g.POST("/imports/anki", "Stage the notes of an Anki .apkg package or plain text export as grammar or vocabulary", nil, jobs.Job{}, func(e *core.RequestEvent) error {
+		options := AnkiOptions{
+			Target:   e.Request.FormValue("target"),
+			Language: e.Request.FormValue("language"),
+			NoteType: e.Request.FormValue("note_type"),
+			Fields:   map[string]string{},
+		}
+		for _, field := range ankiFields[options.Target] {
+			if source := e.Request.FormValue(field); source != "" {
+				options.Fields[field] = source
+			}
+		}
+		if err := options.Validate(); err != nil {
+			return e.BadRequestError("Invalid import request.", err)
+		}
+
+		file, err := uploadedFile(e)
+		if err != nil {
+			return err
+		}
+
+		job, err := importsService.Anki(e.Auth.Id, file, options)
+		switch {
+		case errors.Is(err, ErrInvalidAnki):
+			return e.BadRequestError("The file is not an Anki export.", err)
+		case errors.Is(err, ErrUnknownLanguage):
+			return e.BadRequestError("Invalid import request.", validation.Errors{
+				"language": validation.NewError("validation_invalid_value", "Invalid value."),
+			})
+		case err != nil:
+			return e.InternalServerError("Failed to start the import.", err)
+		}
+		return e.JSON(200, job)
+	})
+
+	g.GET("/imports/{id}", "A staged or committed import", Import{}, func(e *core.RequestEvent) error {
+		imp, err := importsService.Find(e.Auth.Id, e.Request.PathValue("id"))
+		if err != nil {
+			return e.NotFoundError("", err)
+		}
+		return e.JSON(200, imp)
+	})
+
+	g.GET("/imports/{id}/items", "Preview the entries or notes of a staged import a page at a time, with ?cursor= and ?limit=", ItemPage{}, func(e *core.RequestEvent) error {
+		page, err := api.PageFromRequest(e)
+		if err != nil {
+			return e.BadRequestError("Invalid import request.", err)
+		}
+
+		items, err := importsService.Items(e.Auth.Id, e.Request.PathValue("id"), page)
+		if err != nil {
+			return e.NotFoundError("", err)
+		}
+		return e.JSON(200, items)
+	})
+
+	g.POST("/imports/{id}/items/{item}", "Fix or skip an entry or note of a staged import", ItemUpdate{}, Item{}, func(e *core.RequestEvent) error {
+		var update ItemUpdate
+		if err := e.BindBody(&update); err != nil {
+			return e.BadRequestError("Invalid request body.", err)
+		}
+
+		item, err := importsService.UpdateItem(e.Auth.Id, e.Request.PathValue("id"), e.Request.PathValue("item"), update)
+		if err != nil {
+			return stagingError(e, err)
+		}
+		return e.JSON(200, item)
+	})
+
+	g.POST("/imports/{id}/commit", "Save the entries of a staged import to the journal, or its notes as grammar or vocabulary, all or none", nil, jobs.Job{}, func(e *core.RequestEvent) error {
+		job, err := importsService.Commit(e.Auth.Id, e.Request.PathValue("id"))
+		if err != nil {
+			return stagingError(e, err)
+		}
+		return e.JSON(200, job)
+	})
+
+	g.DELETE("/imports/{id}", "Discard a staged import", func(e *core.RequestEvent) error {
+		if err := importsService.Discard(e.Auth.Id, e.Request.PathValue("id")); err != nil {
+			return stagingError(e, err)
+		}
+		return e.NoContent(204)
+	})
+
+	g.POST("/imports/{id}/rollback", "Delete the journal entries, grammar and vocabulary a committed import created", nil, Import{}, func(e *core.RequestEvent) error {
+		imp, err := importsService.Rollback(e.Auth.Id, e.Request.PathValue("id"))
+		if err != nil {
+			return stagingError(e, err)
+		}
+		return e.JSON(200, imp)
+	})
+}
+
+// stagingError tells imports in the wrong state from missing ones
+func stagingError(e *core.RequestEvent, err error) error {
+	switch {
+	case errors.Is(err, ErrNotStaged):
+		return e.Error(http.StatusConflict, "This import is no longer staged.", err)
+	case errors.Is(err, ErrNotCommitted):
+		return e.Error(http.StatusConflict, "This import is not committed.", err)
+	default:
+		return e.NotFoundError("", err)
+	}
+}
+
+// uploadedZip reads the zip archive uploaded as "file"
+func uploadedZip(e *core.RequestEvent) ([]byte, error) {
+	archive, err := uploadedFile(e)
+	if err != nil {
+		return nil, err
+	}
+
+	// refuse garbage right away instead of in a job that fails later
+	if _, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive))); err != nil {
+		return nil, e.BadRequestError("The file is not a zip archive.", err)
+	}
+
+	return archive, nil
+}
+
+// uploadedFile reads the file uploaded as "file"
+func uploadedFile(e *core.RequestEvent) ([]byte, error) {
+	files, err := e.FindUploadedFiles("file")
+	if err != nil || len(files) == 0 {
+		return nil, e.BadRequestError("Upload the file to import as file.", err)
+	}
+	if files[0].Size > maxArchiveSize {
+		return nil, e.BadRequestError("The file is too large.", nil)
+	}
+
+	f, err := files[0].Reader.Open()
+	if err != nil {
+		return nil, e.BadRequestError("Upload the file to import as file.", err)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, maxArchiveSize))
+	if err != nil {
+		return nil, e.BadRequestError("Upload the file to import as file.", err)
+	}
+	return data, nil
+}
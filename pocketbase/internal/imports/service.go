@@ -0,0 +1,1077 @@
+package imports
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/jobs"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/journal"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/settings"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/filesystem"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Sources the entries of the journal importers get, Lang-8 dumps give theirs
+// as the site they came from
+const (
+	SourceMarkdown = "markdown"
+	SourceDayOne   = "dayone"
+)
+
+const (
+	JobKindMarkdown = "markdown_import"
+	JobKindDayOne   = "dayone_import"
+	JobKindLang8    = "lang8_import"
+	JobKindAnki     = "anki_import"
+	JobKindCommit   = "import_commit"
+)
+
+var (
+	ErrNotStaged    = errors.New("import is not staged")
+	ErrNotCommitted = errors.New("import is not committed")
+
+	// ErrInvalidAnki means a file is neither an Anki package nor a plain
+	// text export
+	ErrInvalidAnki = errors.New("not an anki export")
+
+	// ErrUnknownLanguage means notes were to be imported in a language the
+	// instance doesn't have
+	ErrUnknownLanguage = errors.New("unknown language")
+)
+
+type Service interface {
+	// Markdown starts a job staging a zip of markdown files, like an
+	// Obsidian vault, for the user's journal. The job's result is an Import.
+	Markdown(userId string, archive []byte) (jobs.Job, error)
+
+	// DayOne starts a job staging the zip of a Day One JSON export, photos
+	// and audio included. The job's result is an Import.
+	DayOne(userId string, archive []byte) (jobs.Job, error)
+
+	// Lang8 starts a job staging a Lang-8 or LangCorrect dump, a JSON file
+	// or a zip of them, with the corrections the entries received. The job's
+	// result is an Import.
+	Lang8(userId string, source string, archive []byte) (jobs.Job, error)
+
+	// AnkiNoteTypes reads an Anki package or plain text export for the kinds
+	// of notes in it, to map their fields before importing
+	AnkiNoteTypes(file []byte) ([]NoteType, error)
+
+	// Anki starts a job staging the notes of an Anki package or plain text
+	// export as grammar or vocabulary. The job's result is an Import.
+	Anki(userId string, file []byte, options AnkiOptions) (jobs.Job, error)
+
+	// Find returns one of the user's imports
+	Find(userId string, id string) (Import, error)
+
+	// Items returns a page of the entries or notes of a staged import, in
+	// archive order
+	Items(userId string, importId string, page api.Page) (ItemPage, error)
+
+	// UpdateItem fixes or skips a staged entry or note
+	UpdateItem(userId string, importId string, itemId string, update ItemUpdate) (Item, error)
+
+	// Commit starts a job saving the staged entries to the journal, or the
+	// notes as grammar or vocabulary, all of them or none. The job's result
+	// is a Result.
+	Commit(userId string, importId string) (jobs.Job, error)
+
+	// Discard drops a staged import
+	Discard(userId string, importId string) error
+
+	// Rollback deletes the entries, grammar and vocabulary a committed import
+	// created
+	Rollback(userId string, importId string) (Import, error)
+}
+
+type service struct {
+	app             core.App
+	jobsService     jobs.Service
+	journalService  journal.Service
+	settingsService settings.Service
+}
+
+func NewService(app core.App, jobsService jobs.Service, journalService journal.Service, settingsService settings.Service) Service {
+	return &service{
+		app:             app,
+		jobsService:     jobsService,
+		journalService:  journalService,
+		settingsService: settingsService,
+	}
+}
+
+// parseFunc reads an archive into entries, reporting the parts it couldn't
+// read as problems
+type parseFunc func(loc *time.Location) (entries []Entry, problems []string, err error)
+
+func (s *service) Markdown(userId string, archive []byte) (jobs.Job, error) {
+	return s.start(userId, JobKindMarkdown, SourceMarkdown, archive, func(loc *time.Location) ([]Entry, []string, error) {
+		return parseMarkdownArchive(archive, loc)
+	})
+}
+
+func (s *service) DayOne(userId string, archive []byte) (jobs.Job, error) {
+	return s.start(userId, JobKindDayOne, SourceDayOne, archive, func(loc *time.Location) ([]Entry, []string, error) {
+		return parseDayOneArchive(archive)
+	})
+}
+
+func (s *service) Lang8(userId string, source string, archive []byte) (jobs.Job, error) {
+	return s.start(userId, JobKindLang8, source, archive, func(loc *time.Location) ([]Entry, []string, error) {
+		entries, problems, err := parseLang8Archive(archive, loc)
+		for i := range entries {
+			for j := range entries[i].Corrections {
+				entries[i].Corrections[j].Source = source
+			}
+		}
+		return entries, problems, err
+	})
+}
+
+func (s *service) start(userId string, kind string, source string, archive []byte, parse parseFunc) (jobs.Job, error) {
+	return s.jobsService.Enqueue(userId, kind, func(ctx context.Context, jobId string, progress jobs.Progress) (any, error) {
+		loc := s.location(userId)
+
+		progress.Report(5, "Reading archive")
+		entries, problems, err := parse(loc)
+		if err != nil {
+			return nil, err
+		}
+
+		progress.Report(50, "Checking entries")
+		return s.stage(ctx, userId, kind, source, archive, loc, entries, problems)
+	})
+}
+
+// stage saves the entries read from an archive as the items of a new
+// import, marking those that can't be imported, that the user already has
+// and that update what an earlier import of the same source created. The
+// archive is kept when entries have attachments to read from it.
+func (s *service) stage(ctx context.Context, userId string, kind string, source string, archive []byte, loc *time.Location, entries []Entry, problems []string) (Import, error) {
+	index, err := s.journalIndex(userId, source, loc)
+	if err != nil {
+		return Import{}, err
+	}
+
+	var record *core.Record
+	counts := Counts{}
+	err = s.app.RunInTransaction(func(txApp core.App) error {
+		importsCollection, err := txApp.FindCollectionByNameOrId("imports")
+		if err != nil {
+			return err
+		}
+		itemsCollection, err := txApp.FindCollectionByNameOrId("import_items")
+		if err != nil {
+			return err
+		}
+		journalCollection, err := txApp.FindCollectionByNameOrId("journal_entry")
+		if err != nil {
+			return err
+		}
+
+		record = core.NewRecord(importsCollection)
+		record.Set("user", userId)
+		record.Set("kind", kind)
+		record.Set("source", source)
+		record.Set("status", StatusStaged)
+		record.Set("problems", append([]string{}, problems...))
+		if hasAttachments(entries) {
+			file, err := filesystem.NewFileFromBytes(archive, "archive.zip")
+			if err != nil {
+				return err
+			}
+			record.Set("archive", file)
+		}
+		if err := txApp.Save(record); err != nil {
+			return err
+		}
+
+		for i, entry := range entries {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if entry.Created.IsZero() {
+				entry.Created = time.Now()
+			}
+
+			status, problem := ItemReady, ""
+			if err := checkEntry(txApp, journalCollection, userId, source, entry); err != nil {
+				status, problem = ItemInvalid, err.Error()
+			} else if status, _ = index.match(entry); status != ItemDuplicate {
+				index.add(entry)
+			}
+			counts.add(status, false, 1)
+
+			item := core.NewRecord(itemsCollection)
+			item.Set("import", record.Id)
+			item.Set("user", userId)
+			item.Set("position", i)
+			item.Set("source", entry.Source)
+			item.Set("entry", entry)
+			item.Set("status", status)
+			item.Set("error", problem)
+			if err := txApp.Save(item); err != nil {
+				return fmt.Errorf("%s: %w", entry.Source, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return Import{}, err
+	}
+
+	imp := ImportFromRecord(record)
+	imp.Counts = counts
+	return imp, nil
+}
+
+func (s *service) AnkiNoteTypes(file []byte) ([]NoteType, error) {
+	notes, _, err := parseAnki(file)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAnki, err)
+	}
+	return noteTypes(notes), nil
+}
+
+func (s *service) Anki(userId string, file []byte, options AnkiOptions) (jobs.Job, error) {
+	if err := checkAnki(file); err != nil {
+		return jobs.Job{}, fmt.Errorf("%w: %v", ErrInvalidAnki, err)
+	}
+	if _, err := s.app.FindRecordById("languages", options.Language); err != nil {
+		return jobs.Job{}, ErrUnknownLanguage
+	}
+
+	return s.jobsService.Enqueue(userId, JobKindAnki, func(ctx context.Context, jobId string, progress jobs.Progress) (any, error) {
+		progress.Report(5, "Reading export")
+		notes, problems, err := parseAnki(file)
+		if err != nil {
+			return nil, err
+		}
+
+		progress.Report(50, "Checking notes")
+		return s.stageAnki(ctx, userId, options, notes, problems)
+	})
+}
+
+// stageAnki saves the notes read from an export as the items of a new
+// import, marking those that can't be imported, that the user already has
+// and that update what an earlier Anki import created. Notes of other types
+// than the one picked are left out.
+func (s *service) stageAnki(ctx context.Context, userId string, options AnkiOptions, notes []ankiNote, problems []string) (Import, error) {
+	index, err := newAnkiIndex(s.app, userId, options)
+	if err != nil {
+		return Import{}, err
+	}
+
+	var record *core.Record
+	counts := Counts{}
+	err = s.app.RunInTransaction(func(txApp core.App) error {
+		importsCollection, err := txApp.FindCollectionByNameOrId("imports")
+		if err != nil {
+			return err
+		}
+		itemsCollection, err := txApp.FindCollectionByNameOrId("import_items")
+		if err != nil {
+			return err
+		}
+		collection, err := txApp.FindCollectionByNameOrId(options.Target)
+		if err != nil {
+			return err
+		}
+
+		record = core.NewRecord(importsCollection)
+		record.Set("user", userId)
+		record.Set("kind", JobKindAnki)
+		record.Set("source", SourceAnki)
+		record.Set("status", StatusStaged)
+		record.Set("options", options)
+		if err := txApp.Save(record); err != nil {
+			return err
+		}
+
+		leftOut := 0
+		for i, ankiNote := range notes {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if options.NoteType != "" && !strings.EqualFold(ankiNote.noteType, options.NoteType) {
+				leftOut++
+				continue
+			}
+
+			note := options.note(ankiNote)
+			status, problem := ItemReady, ""
+			if err := checkNote(txApp, collection, userId, options, note); err != nil {
+				status, problem = ItemInvalid, err.Error()
+			} else if status, _ = index.match(note); status != ItemDuplicate {
+				index.add(note)
+			}
+			counts.add(status, false, 1)
+
+			item := core.NewRecord(itemsCollection)
+			item.Set("import", record.Id)
+			item.Set("user", userId)
+			item.Set("position", i)
+			item.Set("source", fmt.Sprintf("note %d", i+1))
+			item.Set("note", note)
+			item.Set("status", status)
+			item.Set("error", problem)
+			if err := txApp.Save(item); err != nil {
+				return fmt.Errorf("note %d: %w", i+1, err)
+			}
+		}
+
+		if leftOut > 0 {
+			problems = append(problems, fmt.Sprintf("%d notes of other types were left out", leftOut))
+		}
+		record.Set("problems", append([]string{}, problems...))
+		return txApp.Save(record)
+	})
+	if err != nil {
+		return Import{}, err
+	}
+
+	imp := ImportFromRecord(record)
+	imp.Counts = counts
+	return imp, nil
+}
+
+func (s *service) Find(userId string, id string) (Import, error) {
+	record, err := s.findImport(s.app, userId, id)
+	if err != nil {
+		return Import{}, err
+	}
+
+	imp := ImportFromRecord(record)
+	if imp.Status == StatusStaged {
+		if imp.Counts, err = s.counts(id); err != nil {
+			return Import{}, err
+		}
+	}
+	return imp, nil
+}
+
+func (s *service) Items(userId string, importId string, page api.Page) (ItemPage, error) {
+	if _, err := s.findImport(s.app, userId, importId); err != nil {
+		return ItemPage{}, err
+	}
+
+	params := map[string]any{"import": importId}
+	filter := "import = {:import}"
+	if keyset := page.KeysetFilter("position", params); keyset != "" {
+		filter += " && " + keyset
+	}
+
+	records, err := s.app.FindRecordsByFilter("import_items", filter, "position,id", page.Limit+1, 0, params)
+	if err != nil {
+		return ItemPage{}, err
+	}
+
+	result := ItemPage{Items: make([]Item, 0, min(len(records), page.Limit))}
+	for _, record := range records[:min(len(records), page.Limit)] {
+		result.Items = append(result.Items, ItemFromRecord(record))
+	}
+	result.Next = page.Next(len(records), func(i int) api.Cursor {
+		return api.Cursor{Value: strconv.Itoa(records[i].GetInt("position")), Id: records[i].Id}
+	})
+	return result, nil
+}
+
+func (s *service) UpdateItem(userId string, importId string, itemId string, update ItemUpdate) (Item, error) {
+	record, err := s.findImport(s.app, userId, importId)
+	if err != nil {
+		return Item{}, err
+	}
+	if record.GetString("status") != StatusStaged {
+		return Item{}, ErrNotStaged
+	}
+
+	itemRecord, err := s.app.FindFirstRecordByFilter("import_items", "id = {:id} && import = {:import}", map[string]any{"id": itemId, "import": importId})
+	if err != nil {
+		return Item{}, err
+	}
+	if record.GetString("kind") == JobKindAnki {
+		return s.updateNote(userId, record, itemRecord, update)
+	}
+
+	item := ItemFromRecord(itemRecord)
+	item.Entry.Title = strings.TrimSpace(update.Title)
+	item.Entry.Content = strings.TrimSpace(update.Content)
+	item.Entry.Location = strings.TrimSpace(update.Location)
+	item.Entry.Audience = update.Audience
+	item.Entry.Mood = update.Mood
+	if !update.Created.IsZero() {
+		item.Entry.Created = update.Created.Time()
+	}
+
+	journalCollection, err := s.app.FindCollectionByNameOrId("journal_entry")
+	if err != nil {
+		return Item{}, err
+	}
+	source := record.GetString("source")
+	status, problem := ItemReady, ""
+	if err := checkEntry(s.app, journalCollection, userId, source, item.Entry); err != nil {
+		status, problem = ItemInvalid, err.Error()
+	} else if status, err = s.matchItem(userId, source, importId, itemId, item.Entry); err != nil {
+		return Item{}, err
+	}
+
+	itemRecord.Set("entry", item.Entry)
+	itemRecord.Set("status", status)
+	itemRecord.Set("error", problem)
+	itemRecord.Set("skip", update.Skip)
+	if err := s.app.Save(itemRecord); err != nil {
+		return Item{}, err
+	}
+	return ItemFromRecord(itemRecord), nil
+}
+
+// matchItem tells what committing would do with an edited entry, given the
+// journal and the other entries of the import that would be committed
+func (s *service) matchItem(userId string, source string, importId string, itemId string, entry Entry) (string, error) {
+	index, err := s.journalIndex(userId, source, s.location(userId))
+	if err != nil {
+		return "", err
+	}
+
+	others, err := s.app.FindRecordsByFilter(
+		"import_items",
+		"import = {:import} && id != {:id} && skip = false && status != {:invalid}",
+		"", 0, 0,
+		map[string]any{"import": importId, "id": itemId, "invalid": ItemInvalid},
+	)
+	if err != nil {
+		return "", err
+	}
+	for _, other := range others {
+		index.add(ItemFromRecord(other).Entry)
+	}
+
+	status, _ := index.match(entry)
+	return status, nil
+}
+
+// updateNote fixes a staged Anki note, the fields of the update replacing
+// those of the note
+func (s *service) updateNote(userId string, record *core.Record, itemRecord *core.Record, update ItemUpdate) (Item, error) {
+	var options AnkiOptions
+	if err := record.UnmarshalJSONField("options", &options); err != nil {
+		return Item{}, err
+	}
+	collection, err := s.app.FindCollectionByNameOrId(options.Target)
+	if err != nil {
+		return Item{}, err
+	}
+
+	item := ItemFromRecord(itemRecord)
+	note := Note{Fields: map[string]string{}}
+	if item.Note != nil {
+		note = *item.Note
+	}
+	for _, field := range ankiFields[options.Target] {
+		if value, ok := update.Fields[field]; ok {
+			note.Fields[field] = strings.TrimSpace(value)
+		}
+	}
+
+	status, problem := ItemReady, ""
+	if err := checkNote(s.app, collection, userId, options, note); err != nil {
+		status, problem = ItemInvalid, err.Error()
+	} else if status, err = s.matchNote(userId, options, record.Id, itemRecord.Id, note); err != nil {
+		return Item{}, err
+	}
+
+	itemRecord.Set("note", note)
+	itemRecord.Set("status", status)
+	itemRecord.Set("error", problem)
+	itemRecord.Set("skip", update.Skip)
+	if err := s.app.Save(itemRecord); err != nil {
+		return Item{}, err
+	}
+	return ItemFromRecord(itemRecord), nil
+}
+
+// matchNote tells what committing would do with an edited note, given the
+// user's grammar or vocabulary and the other notes of the import that would
+// be committed
+func (s *service) matchNote(userId string, options AnkiOptions, importId string, itemId string, note Note) (string, error) {
+	index, err := newAnkiIndex(s.app, userId, options)
+	if err != nil {
+		return "", err
+	}
+
+	others, err := s.app.FindRecordsByFilter(
+		"import_items",
+		"import = {:import} && id != {:id} && skip = false && status != {:invalid}",
+		"", 0, 0,
+		map[string]any{"import": importId, "id": itemId, "invalid": ItemInvalid},
+	)
+	if err != nil {
+		return "", err
+	}
+	for _, other := range others {
+		if item := ItemFromRecord(other); item.Note != nil {
+			index.add(*item.Note)
+		}
+	}
+
+	status, _ := index.match(note)
+	return status, nil
+}
+
+func (s *service) Commit(userId string, importId string) (jobs.Job, error) {
+	record, err := s.findImport(s.app, userId, importId)
+	if err != nil {
+		return jobs.Job{}, err
+	}
+	if record.GetString("status") != StatusStaged {
+		return jobs.Job{}, ErrNotStaged
+	}
+
+	if record.GetString("kind") == JobKindAnki {
+		var options AnkiOptions
+		if err := record.UnmarshalJSONField("options", &options); err != nil {
+			return jobs.Job{}, err
+		}
+		return s.jobsService.Enqueue(userId, JobKindCommit, func(ctx context.Context, jobId string, progress jobs.Progress) (any, error) {
+			return s.commitAnki(ctx, progress, userId, options, importId)
+		})
+	}
+
+	source := record.GetString("source")
+	return s.jobsService.Enqueue(userId, JobKindCommit, func(ctx context.Context, jobId string, progress jobs.Progress) (any, error) {
+		return s.commit(ctx, progress, userId, source, importId)
+	})
+}
+
+// commit saves the entries of a staged import in one transaction, so a
+// failure leaves both the journal and the import as they were. What each
+// entry becomes is decided again, the journal may have changed since
+// staging. Updated entries keep their attachments and the import that
+// created them.
+func (s *service) commit(ctx context.Context, progress jobs.Progress, userId string, source string, importId string) (Result, error) {
+	result := Result{Errors: []string{}}
+
+	index, err := s.journalIndex(userId, source, s.location(userId))
+	if err != nil {
+		return result, err
+	}
+
+	// progress is saved outside of the transaction, which would wait on it
+	progress.Report(10, "Importing entries")
+
+	err = s.app.RunInTransaction(func(txApp core.App) error {
+		record, err := s.findImport(txApp, userId, importId)
+		if err != nil {
+			return err
+		}
+		// committed by a job started before this one
+		if record.GetString("status") != StatusStaged {
+			return ErrNotStaged
+		}
+
+		archive, err := s.openArchive(record)
+		if err != nil {
+			return err
+		}
+
+		journalCollection, err := txApp.FindCollectionByNameOrId("journal_entry")
+		if err != nil {
+			return err
+		}
+		mediaCollection, err := txApp.FindCollectionByNameOrId("media")
+		if err != nil {
+			return err
+		}
+		correctionsCollection, err := txApp.FindCollectionByNameOrId("corrections")
+		if err != nil {
+			return err
+		}
+
+		items, err := txApp.FindRecordsByFilter("import_items", "import = {:import}", "position", 0, 0, map[string]any{"import": importId})
+		if err != nil {
+			return err
+		}
+
+		for _, itemRecord := range items {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			item := ItemFromRecord(itemRecord)
+			if item.Skip {
+				result.Skipped++
+				continue
+			}
+			if item.Status == ItemInvalid {
+				result.Failed++
+				result.Errors = append(result.Errors, fmt.Sprintf("%s: %s", item.Source, item.Error))
+				continue
+			}
+
+			status, existingId := index.match(item.Entry)
+			if status == ItemDuplicate {
+				result.Duplicates++
+				continue
+			}
+
+			var entryRecord *core.Record
+			if status == ItemUpdateExisting {
+				entryRecord, err = txApp.FindRecordById(journalCollection, existingId)
+				if err == nil {
+					err = setEntry(entryRecord, item.Entry)
+				}
+			} else {
+				entryRecord, err = newEntryRecord(journalCollection, userId, source, item.Entry)
+				if entryRecord != nil {
+					entryRecord.Set("import", importId)
+				}
+			}
+			if err != nil {
+				return fmt.Errorf("%s: %w", item.Source, err)
+			}
+			if err := txApp.Save(entryRecord); err != nil {
+				return fmt.Errorf("%s: %w", item.Source, err)
+			}
+			index.add(item.Entry)
+
+			if status == ItemUpdateExisting {
+				result.Updated++
+
+				// corrections are read again in full, replace the old ones
+				_, err := txApp.DB().Delete("corrections", dbx.HashExp{"journal_entry": entryRecord.Id, "source": source}).Execute()
+				if err != nil {
+					return err
+				}
+			} else {
+				result.Imported++
+
+				// the entry is in either way, a missing photo is just reported
+				for _, attachment := range item.Entry.Attachments {
+					if err := saveAttachment(txApp, mediaCollection, userId, entryRecord.Id, archive, attachment); err != nil {
+						result.Errors = append(result.Errors, fmt.Sprintf("%s: %s: %v", item.Source, attachment.Name, err))
+					}
+				}
+			}
+			for _, correction := range item.Entry.Corrections {
+				if err := saveCorrection(txApp, correctionsCollection, userId, entryRecord.Id, correction); err != nil {
+					return fmt.Errorf("%s: correction: %w", item.Source, err)
+				}
+			}
+		}
+
+		// the staged items and the archive have served their purpose, the
+		// import itself stays for rolling back
+		if _, err := txApp.DB().Delete("import_items", dbx.HashExp{"import": importId}).Execute(); err != nil {
+			return err
+		}
+		record.Set("status", StatusCommitted)
+		record.Set("committed", types.NowDateTime())
+		record.Set("result", result)
+		record.Set("archive", nil)
+		return txApp.Save(record)
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	return result, nil
+}
+
+// commitAnki saves the notes of a staged import as grammar or vocabulary in
+// one transaction, so a failure leaves both as they were. What each note
+// becomes is decided again, the user's grammar or vocabulary may have
+// changed since staging. Updated items keep the import that created them.
+func (s *service) commitAnki(ctx context.Context, progress jobs.Progress, userId string, options AnkiOptions, importId string) (Result, error) {
+	result := Result{Errors: []string{}}
+
+	index, err := newAnkiIndex(s.app, userId, options)
+	if err != nil {
+		return result, err
+	}
+
+	// progress is saved outside of the transaction, which would wait on it
+	progress.Report(10, "Importing notes")
+
+	err = s.app.RunInTransaction(func(txApp core.App) error {
+		record, err := s.findImport(txApp, userId, importId)
+		if err != nil {
+			return err
+		}
+		// committed by a job started before this one
+		if record.GetString("status") != StatusStaged {
+			return ErrNotStaged
+		}
+
+		collection, err := txApp.FindCollectionByNameOrId(options.Target)
+		if err != nil {
+			return err
+		}
+
+		items, err := txApp.FindRecordsByFilter("import_items", "import = {:import}", "position", 0, 0, map[string]any{"import": importId})
+		if err != nil {
+			return err
+		}
+
+		for _, itemRecord := range items {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			item := ItemFromRecord(itemRecord)
+			if item.Skip {
+				result.Skipped++
+				continue
+			}
+			if item.Status == ItemInvalid || item.Note == nil {
+				result.Failed++
+				result.Errors = append(result.Errors, fmt.Sprintf("%s: %s", item.Source, item.Error))
+				continue
+			}
+
+			note := *item.Note
+			status, existingId := index.match(note)
+			if status == ItemDuplicate {
+				result.Duplicates++
+				continue
+			}
+
+			var noteRecord *core.Record
+			if status == ItemUpdateExisting {
+				noteRecord, err = txApp.FindRecordById(collection, existingId)
+				if err != nil {
+					return fmt.Errorf("%s: %w", item.Source, err)
+				}
+				setNote(noteRecord, options, note)
+			} else {
+				noteRecord = newNoteRecord(collection, userId, options, note)
+				noteRecord.Set("import", importId)
+			}
+			if err := txApp.Save(noteRecord); err != nil {
+				return fmt.Errorf("%s: %w", item.Source, err)
+			}
+			index.add(note)
+
+			if status == ItemUpdateExisting {
+				result.Updated++
+			} else {
+				result.Imported++
+			}
+		}
+
+		// the staged items have served their purpose, the import itself
+		// stays for rolling back
+		if _, err := txApp.DB().Delete("import_items", dbx.HashExp{"import": importId}).Execute(); err != nil {
+			return err
+		}
+		record.Set("status", StatusCommitted)
+		record.Set("committed", types.NowDateTime())
+		record.Set("result", result)
+		return txApp.Save(record)
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	return result, nil
+}
+
+func (s *service) Discard(userId string, importId string) error {
+	record, err := s.findImport(s.app, userId, importId)
+	if err != nil {
+		return err
+	}
+	if record.GetString("status") != StatusStaged {
+		return ErrNotStaged
+	}
+
+	// the items and the archive go with it
+	return s.app.Delete(record)
+}
+
+func (s *service) Rollback(userId string, importId string) (Import, error) {
+	var record *core.Record
+	err := s.app.RunInTransaction(func(txApp core.App) error {
+		var err error
+		if record, err = s.findImport(txApp, userId, importId); err != nil {
+			return err
+		}
+		if record.GetString("status") != StatusCommitted {
+			return ErrNotCommitted
+		}
+
+		entries, err := txApp.FindRecordsByFilter("journal_entry", "user = {:user} && import = {:import}", "", 0, 0, map[string]any{"user": userId, "import": importId})
+		if err != nil {
+			return err
+		}
+		// deleted one by one so their media and corrections go too
+		for _, entry := range entries {
+			if err := txApp.Delete(entry); err != nil {
+				return err
+			}
+		}
+
+		// and what Anki notes became, srs cards with it
+		for _, name := range []string{AnkiGrammar, AnkiVocabulary} {
+			items, err := txApp.FindRecordsByFilter(name, "user = {:user} && import = {:import}", "", 0, 0, map[string]any{"user": userId, "import": importId})
+			if err != nil {
+				return err
+			}
+			for _, item := range items {
+				if err := txApp.Delete(item); err != nil {
+					return err
+				}
+			}
+		}
+
+		record.Set("status", StatusRolledBack)
+		return txApp.Save(record)
+	})
+	if err != nil {
+		return Import{}, err
+	}
+	return ImportFromRecord(record), nil
+}
+
+func (s *service) findImport(app core.App, userId string, id string) (*core.Record, error) {
+	return app.FindFirstRecordByFilter("imports", "id = {:id} && user = {:user}", map[string]any{"id": id, "user": userId})
+}
+
+func (s *service) counts(importId string) (Counts, error) {
+	var rows []struct {
+		Status string `db:"status"`
+		Skip   bool   `db:"skip"`
+		Count  int    `db:"count"`
+	}
+	err := s.app.RecordQuery("import_items").
+		Select("status", "skip", "COUNT(*) AS count").
+		AndWhere(dbx.HashExp{"import": importId}).
+		GroupBy("status", "skip").
+		All(&rows)
+	if err != nil {
+		return Counts{}, err
+	}
+
+	var counts Counts
+	for _, row := range rows {
+		counts.add(row.Status, row.Skip, row.Count)
+	}
+	return counts, nil
+}
+
+// location is the user's time zone, which dates without one are read in
+func (s *service) location(userId string) *time.Location {
+	if userSettings, err := s.settingsService.ForUser(userId); err == nil {
+		if loc, err := time.LoadLocation(userSettings.Timezone); err == nil {
+			return loc
+		}
+	}
+	return time.UTC
+}
+
+// journalIndex is what the user already has, to tell what committing would
+// do with staged entries. Entries from the same source are found by id, the
+// others by their duplicate keys: the same title on the same day, or the
+// same content.
+type journalIndex struct {
+	loc  *time.Location
+	seen map[string]bool
+
+	// ids at the source to the entries they were imported as, empty for
+	// entries of the import being committed
+	sourced map[string]string
+}
+
+func (s *service) journalIndex(userId string, source string, loc *time.Location) (*journalIndex, error) {
+	existing, err := s.journalService.EntriesBetween(userId, types.DateTime{}, types.DateTime{})
+	if err != nil {
+		return nil, err
+	}
+
+	index := &journalIndex{loc: loc, seen: make(map[string]bool, 2*len(existing)), sourced: map[string]string{}}
+	for _, entry := range existing {
+		index.seen[titleKey(entry.Title, entry.Created.Time(), loc)] = true
+		index.seen[contentKey(entry.Content)] = true
+		if entry.Source == source && entry.SourceId != "" {
+			index.sourced[entry.SourceId] = entry.Id
+		}
+	}
+	return index, nil
+}
+
+// match tells what committing would do with an entry, and for updates which
+// entry it replaces
+func (j *journalIndex) match(entry Entry) (string, string) {
+	if id, ok := j.sourced[entry.SourceId]; ok && entry.SourceId != "" {
+		if id == "" {
+			return ItemDuplicate, ""
+		}
+		return ItemUpdateExisting, id
+	}
+
+	keys := entryKeys(entry, j.loc)
+	if j.seen[keys[0]] || j.seen[keys[1]] {
+		return ItemDuplicate, ""
+	}
+	return ItemReady, ""
+}
+
+// add counts an entry as committed, so the ones like it are duplicates
+func (j *journalIndex) add(entry Entry) {
+	keys := entryKeys(entry, j.loc)
+	j.seen[keys[0]], j.seen[keys[1]] = true, true
+	if entry.SourceId != "" {
+		j.sourced[entry.SourceId] = ""
+	}
+}
+
+// openArchive reads back the archive an import kept for its attachments,
+// keyed by path
+func (s *service) openArchive(record *core.Record) (map[string]*zip.File, error) {
+	name := record.GetString("archive")
+	if name == "" {
+		return nil, nil
+	}
+
+	fsys, err := s.app.NewFilesystem()
+	if err != nil {
+		return nil, err
+	}
+	defer fsys.Close()
+
+	r, err := fsys.GetReader(record.BaseFilesPath() + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(io.LimitReader(r, maxArchiveSize))
+	if err != nil {
+		return nil, err
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	return files, nil
+}
+
+// checkEntry runs the journal_entry validation on an entry without saving it
+func checkEntry(app core.App, collection *core.Collection, userId string, source string, entry Entry) error {
+	if utf8.RuneCountInString(entry.Content) > maxContentLength {
+		return fmt.Errorf("entry is longer than %d characters", maxContentLength)
+	}
+	record, err := newEntryRecord(collection, userId, source, entry)
+	if err != nil {
+		return err
+	}
+	return app.Validate(record)
+}
+
+func newEntryRecord(collection *core.Collection, userId string, source string, entry Entry) (*core.Record, error) {
+	record := core.NewRecord(collection)
+	record.Set("user", userId)
+	record.Set("source", source)
+	record.Set("source_id", entry.SourceId)
+	if err := setEntry(record, entry); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// setEntry copies what an imported entry says onto its journal_entry
+func setEntry(record *core.Record, entry Entry) error {
+	created, err := types.ParseDateTime(entry.Created)
+	if err != nil {
+		return err
+	}
+
+	record.Set("title", entry.Title)
+	record.Set("content", entry.Content)
+	record.Set("location", entry.Location)
+	// left empty for the user's default audience
+	record.Set("audience", entry.Audience)
+	record.Set("mood", entry.Mood)
+	// autodate fields keep values set with SetRaw, so entries keep their date
+	record.SetRaw("created", created)
+	return nil
+}
+
+func saveAttachment(app core.App, collection *core.Collection, userId string, entryId string, archive map[string]*zip.File, attachment Attachment) error {
+	f, ok := archive[attachment.Path]
+	if !ok {
+		return fmt.Errorf("file is missing from the archive")
+	}
+	data, err := readZipFile(f, maxAttachmentFileSize)
+	if err != nil {
+		return err
+	}
+
+	file, err := filesystem.NewFileFromBytes(data, attachment.Name)
+	if err != nil {
+		return err
+	}
+
+	record := core.NewRecord(collection)
+	record.Set("user", userId)
+	record.Set("journal_entry", entryId)
+	record.Set("kind", attachment.Kind)
+	record.Set("file", file)
+	return app.Save(record)
+}
+
+func saveCorrection(app core.App, collection *core.Collection, userId string, entryId string, correction Correction) error {
+	record := core.NewRecord(collection)
+	record.Set("user", userId)
+	record.Set("journal_entry", entryId)
+	record.Set("original", correction.Original)
+	record.Set("corrected", correction.Corrected)
+	record.Set("comment", correction.Comment)
+	record.Set("corrector", correction.Corrector)
+	record.Set("source", correction.Source)
+	if !correction.Created.IsZero() {
+		created, err := types.ParseDateTime(correction.Created)
+		if err != nil {
+			return err
+		}
+		record.SetRaw("created", created)
+	}
+	return app.Save(record)
+}
+
+func hasAttachments(entries []Entry) bool {
+	for _, entry := range entries {
+		if len(entry.Attachments) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// entryKeys are the title and content duplicate keys of an entry
+func entryKeys(entry Entry, loc *time.Location) [2]string {
+	return [2]string{titleKey(entry.Title, entry.Created, loc), contentKey(entry.Content)}
+}
+
+func titleKey(title string, created time.Time, loc *time.Location) string {
+	return "title:" + created.In(loc).Format("2006-01-02") + ":" + strings.ToLower(strings.TrimSpace(title))
+}
+
+func contentKey(content string) string {
+	return "content:" + strings.Join(strings.Fields(content), " ")
+}
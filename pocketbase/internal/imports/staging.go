@@ -0,0 +1,150 @@
+package imports
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+const (
+	// StatusStaged imports wait for the user to preview, fix and commit or
+	// discard them
+	StatusStaged     = "staged"
+	StatusCommitted  = "committed"
+	StatusRolledBack = "rolled_back"
+)
+
+const (
+	ItemReady = "ready"
+	// ItemUpdateExisting items replace an entry imported before from the
+	// same source
+	ItemUpdateExisting = "update"
+	ItemDuplicate      = "duplicate"
+	ItemInvalid        = "invalid"
+)
+
+// Import is one run of an importer, staged before anything reaches the
+// journal, grammar or vocabulary. Committed imports can be rolled back.
+type Import struct {
+	Id       string   `json:"id"`
+	User     string   `json:"user"`
+	Kind     string   `json:"kind"`
+	Status   string   `json:"status"`
+	Problems []string `json:"problems"`
+
+	// Source is what the entries get as their source, e.g. dayone
+	Source string `json:"source"`
+
+	// Items of a staged import, by status
+	Counts Counts `json:"counts"`
+
+	// What committing did, nil until then
+	Result *Result `json:"result"`
+
+	Committed types.DateTime `json:"committed"`
+	Created   types.DateTime `json:"created"`
+}
+
+type Counts struct {
+	Ready      int `json:"ready"`
+	Updates    int `json:"updates"`
+	Duplicates int `json:"duplicates"`
+	Invalid    int `json:"invalid"`
+	Skipped    int `json:"skipped"`
+}
+
+// add counts n items
+func (c *Counts) add(status string, skip bool, n int) {
+	switch {
+	case skip:
+		c.Skipped += n
+	case status == ItemReady:
+		c.Ready += n
+	case status == ItemUpdateExisting:
+		c.Updates += n
+	case status == ItemDuplicate:
+		c.Duplicates += n
+	case status == ItemInvalid:
+		c.Invalid += n
+	}
+}
+
+func ImportFromRecord(rec *core.Record) Import {
+	imp := Import{
+		Id:        rec.Id,
+		User:      rec.GetString("user"),
+		Kind:      rec.GetString("kind"),
+		Status:    rec.GetString("status"),
+		Source:    rec.GetString("source"),
+		Problems:  []string{},
+		Committed: rec.GetDateTime("committed"),
+		Created:   rec.GetDateTime("created"),
+	}
+	_ = rec.UnmarshalJSONField("problems", &imp.Problems)
+
+	var result Result
+	if err := rec.UnmarshalJSONField("result", &result); err == nil && imp.Status != StatusStaged {
+		imp.Result = &result
+	}
+	return imp
+}
+
+// Item is a staged entry or Anki note with what committing would do with it
+type Item struct {
+	Id       string `json:"id"`
+	Position int    `json:"position"`
+	Source   string `json:"source"`
+	Status   string `json:"status"`
+
+	// Why an invalid item can't be imported
+	Error string `json:"error"`
+	Skip  bool   `json:"skip"`
+
+	Entry Entry `json:"entry"`
+
+	// Note is what Anki imports stage instead of an entry, nil for the
+	// journal's
+	Note *Note `json:"note"`
+}
+
+func ItemFromRecord(rec *core.Record) Item {
+	item := Item{
+		Id:       rec.Id,
+		Position: rec.GetInt("position"),
+		Source:   rec.GetString("source"),
+		Status:   rec.GetString("status"),
+		Error:    rec.GetString("error"),
+		Skip:     rec.GetBool("skip"),
+	}
+	_ = rec.UnmarshalJSONField("entry", &item.Entry)
+	item.Entry.Source = item.Source
+
+	var note Note
+	if err := rec.UnmarshalJSONField("note", &note); err == nil && note.Fields != nil {
+		item.Note = &note
+	}
+	return item
+}
+
+// ItemUpdate fixes a staged entry or note before committing. Attachments
+// and corrections are kept as read.
+type ItemUpdate struct {
+	Title    string         `json:"title"`
+	Content  string         `json:"content"`
+	Location string         `json:"location"`
+	Created  types.DateTime `json:"created"`
+	Audience string         `json:"audience"`
+	Mood     string         `json:"mood"`
+
+	// Fields replace those of a staged Anki note by name, the others are
+	// kept
+	Fields map[string]string `json:"fields"`
+
+	Skip bool `json:"skip"`
+}
+
+type ItemPage struct {
+	Items []Item `json:"items"`
+
+	// Cursor of the next page, empty on the last one
+	Next string `json:"next"`
+}
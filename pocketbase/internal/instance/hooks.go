@@ -0,0 +1,88 @@
+package instance
+
+import (
+	"errors"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/ratelimit"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/hook"
+)
+
+// ErrSingleton means a second settings record was made, or the one there is
+// deleted
+var ErrSingleton = errors.New("the instance has one settings record, edit it instead")
+
+// BindHooks applies the settings record as it's saved and at start, keeping
+// it the only one, and closes signups when they're turned off
+func BindHooks(app core.App, instanceService Service) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		if err := instanceService.Load(); err != nil {
+			return err
+		}
+		return se.Next()
+	})
+
+	app.OnRecordCreate("instance_settings").BindFunc(func(e *core.RecordEvent) error {
+		total, err := e.App.CountRecords("instance_settings")
+		if err != nil {
+			return err
+		}
+		if total > 0 {
+			return ErrSingleton
+		}
+		if err := validate(e.Record); err != nil {
+			return err
+		}
+		return e.Next()
+	})
+	app.OnRecordUpdate("instance_settings").BindFunc(func(e *core.RecordEvent) error {
+		if err := validate(e.Record); err != nil {
+			return err
+		}
+		return e.Next()
+	})
+	app.OnRecordDelete("instance_settings").BindFunc(func(e *core.RecordEvent) error {
+		return ErrSingleton
+	})
+
+	apply := func(e *core.RecordEvent) error {
+		settings := FromRecord(e.Record)
+		instanceService.Apply(settings)
+		if err := scheduleBackups(e.App, settings); err != nil {
+			e.App.Logger().Error("Failed to schedule backups", "error", err)
+		}
+		return e.Next()
+	}
+	app.OnRecordAfterCreateSuccess("instance_settings").BindFunc(apply)
+	app.OnRecordAfterUpdateSuccess("instance_settings").BindFunc(apply)
+
+	app.OnRecordCreateRequest("users").BindFunc(func(e *core.RecordRequestEvent) error {
+		if !e.HasSuperuserAuth() && !instanceService.Current().Signups {
+			return e.ForbiddenError("Signups are closed on this server.", nil)
+		}
+		return e.Next()
+	})
+	app.OnRecordAuthWithOAuth2Request("users").BindFunc(func(e *core.RecordAuthWithOAuth2RequestEvent) error {
+		if e.IsNewRecord && !instanceService.Current().Signups {
+			return e.ForbiddenError("Signups are closed on this server.", nil)
+		}
+		return e.Next()
+	})
+}
+
+// Middleware refuses the AI routes while AI features are turned off
+func Middleware(instanceService Service) *hook.Handler[*core.RequestEvent] {
+	return &hook.Handler[*core.RequestEvent]{
+		Id: "fushigiInstanceSettings",
+		Func: func(e *core.RequestEvent) error {
+			if instanceService.Current().AIFeatures {
+				return e.Next()
+			}
+			if class, ok := ratelimit.ClassOf(e.Request.Pattern); ok && class == ratelimit.ClassAI {
+				return e.BadRequestError("AI features are turned off on this server.", nil)
+			}
+			return e.Next()
+		},
+	}
+}
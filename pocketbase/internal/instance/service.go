@@ -0,0 +1,89 @@
+package instance
+
+import (
+	"sync/atomic"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/ai"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/ratelimit"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+type Service interface {
+	// Current returns the settings in effect
+	Current() Settings
+
+	// Load reads the settings saved and applies them
+	Load() error
+
+	// Apply puts settings in effect but for the backup schedule, which is
+	// saved with the app's settings: the limits of the tiers and the AI models
+	Apply(settings Settings)
+}
+
+type service struct {
+	app     core.App
+	limiter *ratelimit.Limiter
+
+	// limits are the environment's, which the settings override
+	limits ratelimit.Limits
+
+	current atomic.Pointer[Settings]
+}
+
+func NewService(app core.App, limiter *ratelimit.Limiter, limits ratelimit.Limits) Service {
+	s := &service{app: app, limiter: limiter, limits: limits}
+	s.current.Store(&Defaults)
+	return s
+}
+
+func (s *service) Current() Settings {
+	return *s.current.Load()
+}
+
+func (s *service) Load() error {
+	records, err := s.app.FindRecordsByFilter("instance_settings", "", "", 1, 0)
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		s.Apply(Defaults)
+		return nil
+	}
+	settings := FromRecord(records[0])
+	s.Apply(settings)
+	return scheduleBackups(s.app, settings)
+}
+
+func (s *service) Apply(settings Settings) {
+	s.limiter.SetLimits(s.limits.With(map[string]map[string]int{
+		ratelimit.ClassAI:      settings.RateLimitAI,
+		ratelimit.ClassExports: settings.RateLimitExports,
+	}, settings.RateLimitDefaultTier))
+
+	ai.SetDefaults(ai.Defaults{
+		Model:           settings.AIModel,
+		TranscribeModel: settings.AITranscribeModel,
+		SpeechModel:     settings.AISpeechModel,
+		SpeechVoice:     settings.AISpeechVoice,
+	})
+
+	s.current.Store(&settings)
+}
+
+// scheduleBackups saves the backup schedule with the app's settings, which
+// reloads them and reschedules backups. app is the one of the transaction the
+// settings record is saved in, if any.
+func scheduleBackups(app core.App, settings Settings) error {
+	backups := app.Settings().Backups
+	if backups.Cron == settings.BackupCron && backups.CronMaxKeep == settings.BackupMaxKeep {
+		return nil
+	}
+	appSettings, err := app.Settings().Clone()
+	if err != nil {
+		return err
+	}
+	appSettings.Backups.Cron = settings.BackupCron
+	appSettings.Backups.CronMaxKeep = settings.BackupMaxKeep
+	return app.Save(appSettings)
+}
@@ -0,0 +1,99 @@
+package instance
+
+import (
+	"slices"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/ratelimit"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/cron"
+)
+
+// Settings are the operational settings of the instance, overriding the
+// environment's where set
+type Settings struct {
+	// limits by tier of the expensive routes, tiers left out keep the
+	// environment's
+	RateLimitAI          map[string]int
+	RateLimitExports     map[string]int
+	RateLimitDefaultTier string
+
+	AIModel           string
+	AITranscribeModel string
+	AISpeechModel     string
+	AISpeechVoice     string
+
+	// Signups is whether anyone but superusers can make accounts
+	Signups bool
+
+	// AIFeatures is whether the AI routes are open, even with a model set up
+	AIFeatures bool
+
+	// BackupCron schedules automatic backups, none when empty
+	BackupCron    string
+	BackupMaxKeep int
+}
+
+// Defaults apply while there's no settings record, everything left as the
+// environment has it
+var Defaults = Settings{Signups: true, AIFeatures: true}
+
+func FromRecord(rec *core.Record) Settings {
+	settings := Settings{
+		RateLimitDefaultTier: rec.GetString("rate_limit_default_tier"),
+		AIModel:              rec.GetString("ai_model"),
+		AITranscribeModel:    rec.GetString("ai_transcribe_model"),
+		AISpeechModel:        rec.GetString("ai_speech_model"),
+		AISpeechVoice:        rec.GetString("ai_speech_voice"),
+		Signups:              rec.GetBool("signups"),
+		AIFeatures:           rec.GetBool("ai_features"),
+		BackupCron:           rec.GetString("backup_cron"),
+		BackupMaxKeep:        rec.GetInt("backup_max_keep"),
+	}
+	_ = rec.UnmarshalJSONField("rate_limit_ai", &settings.RateLimitAI)
+	_ = rec.UnmarshalJSONField("rate_limit_exports", &settings.RateLimitExports)
+	return settings
+}
+
+// validate checks what the collection's fields can't, before it's applied
+func validate(rec *core.Record) error {
+	errs := validation.Errors{}
+
+	for _, field := range []string{"rate_limit_ai", "rate_limit_exports"} {
+		if err := validateLimits(rec, field); err != nil {
+			errs[field] = err
+		}
+	}
+	if expr := rec.GetString("backup_cron"); expr != "" {
+		if _, err := cron.NewSchedule(expr); err != nil {
+			errs["backup_cron"] = validation.NewError("validation_invalid_value", "Not a cron expression, e.g. 0 0 * * 0.")
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validateLimits checks a JSON field of limits by tier, empty or null being
+// no override
+func validateLimits(rec *core.Record, field string) error {
+	if raw := rec.GetString(field); raw == "" || raw == "null" {
+		return nil
+	}
+	var limits map[string]int
+	if err := rec.UnmarshalJSONField(field, &limits); err != nil {
+		return validation.NewError("validation_invalid_value", "Must be limits by tier, e.g. {\"standard\": 100}.")
+	}
+	for tier, n := range limits {
+		if !slices.Contains(ratelimit.Tiers, tier) {
+			return validation.NewError("validation_invalid_value", "No such tier: {{.tier}}.").SetParams(map[string]any{"tier": tier})
+		}
+		if n < 0 {
+			return validation.NewError("validation_invalid_value", "Limits can't be negative, 0 means no limit.")
+		}
+	}
+	return nil
+}
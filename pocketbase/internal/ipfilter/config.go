@@ -0,0 +1,141 @@
+package ipfilter
+
+import (
+	"fmt"
+	"net/netip"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Config is who may reach the instance's more sensitive doors, for private
+// instances exposed to the internet. Every list left empty lets everyone
+// through.
+type Config struct {
+	// AdminAllowed are the only networks superusers can sign in and
+	// administer the instance from
+	AdminAllowed []netip.Prefix
+
+	// RegistrationDenied are networks no one can sign up from
+	RegistrationDenied []netip.Prefix
+
+	// RegistrationCountries are the only countries people can sign up from,
+	// as ISO 3166 codes
+	RegistrationCountries []string
+
+	// RegistrationBlockedCountries are countries no one can sign up from
+	RegistrationBlockedCountries []string
+
+	// CountryHeader is the request header a proxy in front of the instance
+	// puts the client's country in, like Cloudflare's CF-IPCountry. Countries
+	// can't be checked without one.
+	CountryHeader string
+}
+
+// ConfigFromEnv reads ADMIN_ALLOWED_IPS, REGISTRATION_DENIED_IPS,
+// REGISTRATION_COUNTRIES, REGISTRATION_BLOCKED_COUNTRIES and
+// GEO_COUNTRY_HEADER. Unlike other settings a list it can't read is an
+// error, a typo in one shouldn't open the instance up.
+func ConfigFromEnv() (Config, error) {
+	var (
+		config Config
+		err    error
+	)
+	if config.AdminAllowed, err = parsePrefixes(os.Getenv("ADMIN_ALLOWED_IPS")); err != nil {
+		return Config{}, fmt.Errorf("ADMIN_ALLOWED_IPS: %w", err)
+	}
+	if config.RegistrationDenied, err = parsePrefixes(os.Getenv("REGISTRATION_DENIED_IPS")); err != nil {
+		return Config{}, fmt.Errorf("REGISTRATION_DENIED_IPS: %w", err)
+	}
+	if config.RegistrationCountries, err = parseCountries(os.Getenv("REGISTRATION_COUNTRIES")); err != nil {
+		return Config{}, fmt.Errorf("REGISTRATION_COUNTRIES: %w", err)
+	}
+	if config.RegistrationBlockedCountries, err = parseCountries(os.Getenv("REGISTRATION_BLOCKED_COUNTRIES")); err != nil {
+		return Config{}, fmt.Errorf("REGISTRATION_BLOCKED_COUNTRIES: %w", err)
+	}
+	config.CountryHeader = strings.TrimSpace(os.Getenv("GEO_COUNTRY_HEADER"))
+
+	if config.CountryHeader == "" && (len(config.RegistrationCountries) > 0 || len(config.RegistrationBlockedCountries) > 0) {
+		return Config{}, fmt.Errorf("GEO_COUNTRY_HEADER is needed to filter registrations by country")
+	}
+	return config, nil
+}
+
+// CanAdminister reports whether superusers may work from ip
+func (c Config) CanAdminister(ip string) bool {
+	return len(c.AdminAllowed) == 0 || contains(c.AdminAllowed, ip)
+}
+
+// CanRegister reports whether people may sign up from ip in country, which
+// is empty when it isn't known
+func (c Config) CanRegister(ip string, country string) bool {
+	if contains(c.RegistrationDenied, ip) {
+		return false
+	}
+	country = strings.ToUpper(strings.TrimSpace(country))
+	if slices.Contains(c.RegistrationBlockedCountries, country) {
+		return false
+	}
+	// an allowlist lets no one in from where it can't tell
+	if len(c.RegistrationCountries) > 0 && !slices.Contains(c.RegistrationCountries, country) {
+		return false
+	}
+	return true
+}
+
+// parsePrefixes reads a comma separated list of addresses and CIDR ranges
+func parsePrefixes(s string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if strings.Contains(field, "/") {
+			prefix, err := netip.ParsePrefix(field)
+			if err != nil {
+				return nil, err
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(field)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+	}
+	return prefixes, nil
+}
+
+// parseCountries reads a comma separated list of two letter country codes
+func parseCountries(s string) ([]string, error) {
+	var countries []string
+	for _, field := range strings.Split(s, ",") {
+		field = strings.ToUpper(strings.TrimSpace(field))
+		if field == "" {
+			continue
+		}
+		if len(field) != 2 || strings.Trim(field, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+			return nil, fmt.Errorf("invalid country code %q", field)
+		}
+		countries = append(countries, field)
+	}
+	return countries, nil
+}
+
+// contains reports whether ip is in any of prefixes, an address that can't
+// be read being in none
+func contains(prefixes []netip.Prefix, ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
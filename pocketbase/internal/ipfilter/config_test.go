@@ -0,0 +1,98 @@
+package ipfilter
+
+import (
+	"testing"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr bool
+	}{
+		{"nothing set", map[string]string{}, false},
+		{"addresses and ranges", map[string]string{"ADMIN_ALLOWED_IPS": "10.0.0.0/8, 203.0.113.7,::1", "REGISTRATION_DENIED_IPS": "2001:db8::/32"}, false},
+		{"countries with a header", map[string]string{"REGISTRATION_COUNTRIES": "jp, US", "GEO_COUNTRY_HEADER": "CF-IPCountry"}, false},
+		{"bad address", map[string]string{"ADMIN_ALLOWED_IPS": "10.0.0.300"}, true},
+		{"bad range", map[string]string{"REGISTRATION_DENIED_IPS": "10.0.0.0/33"}, true},
+		{"bad country", map[string]string{"REGISTRATION_BLOCKED_COUNTRIES": "Japan", "GEO_COUNTRY_HEADER": "CF-IPCountry"}, true},
+		{"countries without a header", map[string]string{"REGISTRATION_BLOCKED_COUNTRIES": "KP"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"ADMIN_ALLOWED_IPS", "REGISTRATION_DENIED_IPS", "REGISTRATION_COUNTRIES", "REGISTRATION_BLOCKED_COUNTRIES", "GEO_COUNTRY_HEADER"} {
+				t.Setenv(name, tt.env[name])
+			}
+			if _, err := ConfigFromEnv(); (err != nil) != tt.wantErr {
+				t.Errorf("ConfigFromEnv() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCanAdminister(t *testing.T) {
+	t.Setenv("ADMIN_ALLOWED_IPS", "10.0.0.0/8,203.0.113.7,2001:db8::/32")
+	config, err := ConfigFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"10.1.2.3", true},
+		{"203.0.113.7", true},
+		{"203.0.113.8", false},
+		{"2001:db8::1", true},
+		{"::ffff:10.1.2.3", true},
+		{"192.168.1.1", false},
+		{"not an address", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := config.CanAdminister(tt.ip); got != tt.want {
+			t.Errorf("CanAdminister(%q) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+
+	if !(Config{}).CanAdminister("192.168.1.1") {
+		t.Error("CanAdminister() with no allowlist = false, want everyone let in")
+	}
+}
+
+func TestCanRegister(t *testing.T) {
+	t.Setenv("REGISTRATION_DENIED_IPS", "198.51.100.0/24")
+	t.Setenv("REGISTRATION_COUNTRIES", "JP,US")
+	t.Setenv("REGISTRATION_BLOCKED_COUNTRIES", "US")
+	t.Setenv("GEO_COUNTRY_HEADER", "CF-IPCountry")
+	config, err := ConfigFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		ip      string
+		country string
+		want    bool
+	}{
+		{"allowed country", "203.0.113.7", "JP", true},
+		{"country in lower case", "203.0.113.7", "jp", true},
+		{"denied network", "198.51.100.20", "JP", false},
+		{"blocked country", "203.0.113.7", "US", false},
+		{"country not allowed", "203.0.113.7", "FR", false},
+		{"unknown country", "203.0.113.7", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := config.CanRegister(tt.ip, tt.country); got != tt.want {
+				t.Errorf("CanRegister(%q, %q) = %v, want %v", tt.ip, tt.country, got, tt.want)
+			}
+		})
+	}
+
+	if !(Config{}).CanRegister("198.51.100.20", "") {
+		t.Error("CanRegister() with nothing configured = false, want everyone let in")
+	}
+}
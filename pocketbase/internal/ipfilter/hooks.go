@@ -0,0 +1,67 @@
+package ipfilter
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/hook"
+)
+
+// adminPath matches the paths of the superuser routes, this API's with or
+// without a version and the first-run setup making the first superuser,
+// PocketBase's superuser auth and its dashboard
+var adminPath = regexp.MustCompile(`^(?:` + regexp.QuoteMeta(api.BasePath) + `(?:/v\d+)?/(?:admin|public/setup)(?:/|$)|/api/collections/_superusers/|/_/)`)
+
+// Middleware keeps the admin routes, and superusers altogether, to the
+// networks they're allowed from
+func Middleware(config Config) *hook.Handler[*core.RequestEvent] {
+	return &hook.Handler[*core.RequestEvent]{
+		Id: "fushigiIPFilter",
+		Func: func(e *core.RequestEvent) error {
+			if len(config.AdminAllowed) == 0 {
+				return e.Next()
+			}
+			if !e.HasSuperuserAuth() && !adminPath.MatchString(e.Request.URL.Path) {
+				return e.Next()
+			}
+			if !config.CanAdminister(e.RealIP()) {
+				return e.ForbiddenError("You can't do that from your network.", nil)
+			}
+			return e.Next()
+		},
+	}
+}
+
+// BindHooks refuses signups from denied networks and countries, with a
+// password or through OAuth2
+func BindHooks(app core.App, config Config) {
+	app.OnRecordCreateRequest("users").BindFunc(func(e *core.RecordRequestEvent) error {
+		if !CanRegister(e.RequestEvent, config) {
+			return e.ForbiddenError("You can't sign up from your network.", nil)
+		}
+		return e.Next()
+	})
+	app.OnRecordAuthWithOAuth2Request("users").BindFunc(func(e *core.RecordAuthWithOAuth2RequestEvent) error {
+		if e.IsNewRecord && !CanRegister(e.RequestEvent, config) {
+			return e.ForbiddenError("You can't sign up from your network.", nil)
+		}
+		return e.Next()
+	})
+}
+
+// CanRegister reports whether the request may make an account, by its
+// network and country
+func CanRegister(e *core.RequestEvent, config Config) bool {
+	// superusers add accounts from wherever they're allowed to work
+	if e.HasSuperuserAuth() {
+		return true
+	}
+	country := ""
+	if config.CountryHeader != "" {
+		country = strings.TrimSpace(e.Request.Header.Get(config.CountryHeader))
+	}
+	return config.CanRegister(e.RealIP(), country)
+}
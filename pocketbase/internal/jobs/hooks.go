@@ -0,0 +1,28 @@
+package jobs
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// BindHooks fails jobs left unfinished by a previous run of the server, since
+// their goroutines died with it
+func BindHooks(app core.App) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		records, err := app.FindRecordsByFilter("jobs", "status = 'queued' || status = 'running'", "", 0, 0)
+		if err != nil {
+			return err
+		}
+
+		for _, record := range records {
+			record.Set("status", StatusFailed)
+			record.Set("error", "Interrupted by a server restart, please try again.")
+			record.Set("finished", types.NowDateTime())
+			if err := app.Save(record); err != nil {
+				return err
+			}
+		}
+
+		return se.Next()
+	})
+}
@@ -0,0 +1,51 @@
+package jobs
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// Job is a long running piece of work (import, export, ...) whose progress the
+// clients can poll
+type Job struct {
+	Id       string         `json:"id"`
+	User     string         `json:"user"`
+	Kind     string         `json:"kind"`
+	Status   string         `json:"status"`
+	Progress float64        `json:"progress"`
+	Message  string         `json:"message"`
+	Error    string         `json:"error"`
+	Result   any            `json:"result"`
+	Started  types.DateTime `json:"started"`
+	Finished types.DateTime `json:"finished"`
+	Created  types.DateTime `json:"created"`
+	Updated  types.DateTime `json:"updated"`
+}
+
+func (j Job) IsDone() bool {
+	return j.Status == StatusSucceeded || j.Status == StatusFailed
+}
+
+func FromRecord(rec *core.Record) Job {
+	return Job{
+		Id:       rec.Id,
+		User:     rec.GetString("user"),
+		Kind:     rec.GetString("kind"),
+		Status:   rec.GetString("status"),
+		Progress: rec.GetFloat("progress"),
+		Message:  rec.GetString("message"),
+		Error:    rec.GetString("error"),
+		Result:   rec.Get("result"),
+		Started:  rec.GetDateTime("started"),
+		Finished: rec.GetDateTime("finished"),
+		Created:  rec.GetDateTime("created"),
+		Updated:  rec.GetDateTime("updated"),
+	}
+}
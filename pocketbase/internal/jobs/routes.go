@@ -0,0 +1,27 @@
+package jobs
+
+import (
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+const listLimit = 50
+
+func RegisterRoutes(g *api.Group, jobsService Service) {
+	g.GET("/jobs", "The user's most recent jobs", []Job{}, func(e *core.RequestEvent) error {
+		jobs, err := jobsService.List(e.Auth.Id, listLimit)
+		if err != nil {
+			return e.InternalServerError("Failed to load jobs.", err)
+		}
+		return e.JSON(200, jobs)
+	})
+
+	g.GET("/jobs/{id}", "Status and progress of a job", Job{}, func(e *core.RequestEvent) error {
+		job, err := jobsService.Find(e.Auth.Id, e.Request.PathValue("id"))
+		if err != nil {
+			return e.NotFoundError("", err)
+		}
+		return e.JSON(200, job)
+	})
+}
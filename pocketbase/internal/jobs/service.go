@@ -0,0 +1,153 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+const (
+	// Jobs are mostly imports and exports that hammer SQLite, so only let a
+	// couple run at once
+	maxConcurrentJobs = 2
+
+	// Progress reports more frequent than this are folded together
+	progressWriteInterval = time.Second
+)
+
+// Progress lets a running job report how far along it is
+type Progress interface {
+	Report(percent float64, message string)
+}
+
+// RunFunc does the work of a job. Whatever it returns is stored as the result.
+// jobId lets the work link what it produces back to its job.
+type RunFunc func(ctx context.Context, jobId string, progress Progress) (any, error)
+
+type Service interface {
+	// Enqueue records a new job and runs it in the background. System jobs
+	// pass an empty userId.
+	Enqueue(userId string, kind string, run RunFunc) (Job, error)
+
+	// Find returns one of the user's jobs
+	Find(userId string, id string) (Job, error)
+
+	// List returns the user's most recent jobs, newest first
+	List(userId string, limit int) ([]Job, error)
+}
+
+type service struct {
+	app   core.App
+	slots chan struct{}
+}
+
+func NewService(app core.App) Service {
+	return &service{app: app, slots: make(chan struct{}, maxConcurrentJobs)}
+}
+
+func (s *service) Enqueue(userId string, kind string, run RunFunc) (Job, error) {
+	collection, err := s.app.FindCollectionByNameOrId("jobs")
+	if err != nil {
+		return Job{}, err
+	}
+
+	record := core.NewRecord(collection)
+	record.Set("user", userId)
+	record.Set("kind", kind)
+	record.Set("status", StatusQueued)
+	if err := s.app.Save(record); err != nil {
+		return Job{}, err
+	}
+
+	go s.run(record, run)
+
+	return FromRecord(record), nil
+}
+
+func (s *service) Find(userId string, id string) (Job, error) {
+	record, err := s.app.FindFirstRecordByFilter("jobs", "id = {:id} && user = {:user}", map[string]any{"id": id, "user": userId})
+	if err != nil {
+		return Job{}, err
+	}
+	return FromRecord(record), nil
+}
+
+func (s *service) List(userId string, limit int) ([]Job, error) {
+	records, err := s.app.FindRecordsByFilter("jobs", "user = {:user}", "-created", limit, 0, map[string]any{"user": userId})
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make([]Job, 0, len(records))
+	for _, rec := range records {
+		jobs = append(jobs, FromRecord(rec))
+	}
+	return jobs, nil
+}
+
+func (s *service) run(record *core.Record, run RunFunc) {
+	s.slots <- struct{}{}
+	defer func() { <-s.slots }()
+
+	record.Set("status", StatusRunning)
+	record.Set("started", types.NowDateTime())
+	s.save(record)
+
+	reporter := &progressReporter{service: s, record: record}
+	result, err := safeRun(run, record.Id, reporter)
+
+	reporter.mu.Lock()
+	defer reporter.mu.Unlock()
+
+	record.Set("finished", types.NowDateTime())
+	if err != nil {
+		record.Set("status", StatusFailed)
+		record.Set("error", err.Error())
+	} else {
+		record.Set("status", StatusSucceeded)
+		record.Set("progress", 100)
+		record.Set("result", result)
+	}
+	s.save(record)
+}
+
+func (s *service) save(record *core.Record) {
+	if err := s.app.Save(record); err != nil {
+		s.app.Logger().Error("Failed to update job", "job", record.Id, "error", err)
+	}
+}
+
+func safeRun(run RunFunc, jobId string, progress Progress) (result any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return run(context.Background(), jobId, progress)
+}
+
+type progressReporter struct {
+	service *service
+	record  *core.Record
+
+	mu        sync.Mutex
+	lastWrite time.Time
+}
+
+func (p *progressReporter) Report(percent float64, message string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.record.Set("progress", min(max(percent, 0), 100))
+	p.record.Set("message", message)
+
+	if time.Since(p.lastWrite) < progressWriteInterval {
+		return
+	}
+	p.lastWrite = time.Now()
+	p.service.save(p.record)
+}
@@ -0,0 +1,189 @@
+package journal_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/journal"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/settings"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+func saveUser(t *testing.T, app core.App, email string) *core.Record {
+	t.Helper()
+	users, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := core.NewRecord(users)
+	user.SetEmail(email)
+	// usernames are unique when set, so every user gets one
+	user.Set("username", strings.Split(email, "@")[0])
+	user.SetPassword("correct horse battery")
+	if err := app.Save(user); err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func saveRecord(t *testing.T, app core.App, collection string, data map[string]any) *core.Record {
+	t.Helper()
+	c, err := app.FindCollectionByNameOrId(collection)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := core.NewRecord(c)
+	record.Load(data)
+	if err := app.Save(record); err != nil {
+		t.Fatal(err)
+	}
+	return record
+}
+
+// canAccess checks a collection rule for a user the way the records API does
+func canAccess(t *testing.T, app core.App, record *core.Record, auth *core.Record, body map[string]any, rule *string) bool {
+	t.Helper()
+	ok, err := app.CanAccessRecord(record, &core.RequestInfo{Auth: auth, Body: body}, rule)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ok
+}
+
+func TestEntryAudience(t *testing.T) {
+	app, writer := newTestApp(t)
+	journal.BindHooks(app, settings.NewService(app))
+
+	approved := saveUser(t, app, "approved@example.com")
+	pending := saveUser(t, app, "pending@example.com")
+	member := saveUser(t, app, "member@example.com")
+	stranger := saveUser(t, app, "stranger@example.com")
+	tutor := saveUser(t, app, "tutor@example.com")
+
+	follow := saveRecord(t, app, "follows", map[string]any{"user": approved.Id, "following": writer.Id})
+	follow.Set("status", journal.FollowApproved)
+	if err := app.Save(follow); err != nil {
+		t.Fatal(err)
+	}
+	// saved without a status, so it waits for approval
+	saveRecord(t, app, "follows", map[string]any{"user": pending.Id, "following": writer.Id})
+
+	saveRecord(t, app, "tutors", map[string]any{"user": writer.Id, "tutor": tutor.Id})
+	group := saveRecord(t, app, "groups", map[string]any{"owner": writer.Id, "name": "Study group", "members": []string{member.Id}})
+
+	entry := func(audience string) *core.Record {
+		return saveRecord(t, app, "journal_entry", map[string]any{
+			"user":     writer.Id,
+			"title":    audience,
+			"content":  "今日は晴れです。",
+			"audience": audience,
+			"groups":   []string{group.Id},
+		})
+	}
+	entries := map[string]*core.Record{}
+	for _, audience := range journal.Audiences {
+		entries[audience] = entry(audience)
+	}
+
+	viewers := []struct {
+		name   string
+		viewer *core.Record
+		want   []string
+	}{
+		{"writer", writer, journal.Audiences},
+		{"approved follower", approved, []string{journal.AudienceFollowers, journal.AudiencePublic}},
+		{"pending follower", pending, []string{journal.AudiencePublic}},
+		{"group member", member, []string{journal.AudienceGroups, journal.AudiencePublic}},
+		{"stranger", stranger, []string{journal.AudiencePublic}},
+		{"tutor", tutor, journal.Audiences},
+		{"logged out", nil, []string{}},
+	}
+	journalEntries, err := app.FindCollectionByNameOrId("journal_entry")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range viewers {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, audience := range journal.Audiences {
+				want := slices.Contains(tt.want, audience)
+				if got := canAccess(t, app, entries[audience], tt.viewer, nil, journalEntries.ViewRule); got != want {
+					t.Errorf("view %s entry = %v, want %v", audience, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestFollowApproval(t *testing.T) {
+	app, writer := newTestApp(t)
+	journal.BindHooks(app, settings.NewService(app))
+	follower := saveUser(t, app, "follower@example.com")
+
+	follow := saveRecord(t, app, "follows", map[string]any{"user": follower.Id, "following": writer.Id})
+	if status := follow.GetString("status"); status != journal.FollowPending {
+		t.Fatalf("new follow status = %q, want %q", status, journal.FollowPending)
+	}
+
+	follows := follow.Collection()
+	approve := map[string]any{"status": journal.FollowApproved}
+	tests := []struct {
+		name string
+		auth *core.Record
+		body map[string]any
+		rule *string
+		want bool
+	}{
+		{"followed user approves", writer, approve, follows.UpdateRule, true},
+		{"follower approves themselves", follower, approve, follows.UpdateRule, false},
+		{"followed user moves the follow", writer, map[string]any{"following": follower.Id}, follows.UpdateRule, false},
+		{"follower unfollows", follower, nil, follows.DeleteRule, true},
+		{"followed user removes the follower", writer, nil, follows.DeleteRule, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := canAccess(t, app, follow, tt.auth, tt.body, tt.rule); got != tt.want {
+				t.Errorf("access = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFeed(t *testing.T) {
+	app, reader := newTestApp(t)
+	journal.BindHooks(app, settings.NewService(app))
+	service := journal.NewService(app)
+
+	approved := saveUser(t, app, "approved@example.com")
+	pending := saveUser(t, app, "pending@example.com")
+	saveRecord(t, app, "follows", map[string]any{"user": reader.Id, "following": approved.Id, "status": journal.FollowApproved})
+	saveRecord(t, app, "follows", map[string]any{"user": reader.Id, "following": pending.Id})
+
+	for _, writer := range []*core.Record{approved, pending} {
+		for _, audience := range []string{journal.AudiencePrivate, journal.AudienceFollowers, journal.AudiencePublic} {
+			saveRecord(t, app, "journal_entry", map[string]any{
+				"user":     writer.Id,
+				"title":    writer.Email() + " " + audience,
+				"content":  "今日は晴れです。",
+				"audience": audience,
+			})
+		}
+	}
+
+	feed, err := service.Feed(reader.Id, api.Page{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	titles := []string{}
+	for _, entry := range feed.Items {
+		titles = append(titles, entry.Title)
+	}
+	slices.Sort(titles)
+	// a follow waiting for approval doesn't bring in even public entries
+	want := []string{"approved@example.com followers", "approved@example.com public"}
+	if !slices.Equal(titles, want) {
+		t.Errorf("Feed() = %v, want %v", titles, want)
+	}
+}
@@ -0,0 +1,129 @@
+package journal
+
+import (
+	"slices"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/settings"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/spf13/cast"
+)
+
+func BindHooks(app core.App, settingsService settings.Service) {
+	// Tag entries with their topics so they can be filtered by them. Topics a
+	// client sets on a new entry are kept, editing the text extracts them again.
+	app.OnRecordCreate("journal_entry").BindFunc(func(e *core.RecordEvent) error {
+		var topics []string
+		_ = e.Record.UnmarshalJSONField("topics", &topics)
+		if len(topics) == 0 {
+			e.Record.Set("topics", ExtractTopics(Text(e.Record)))
+		}
+		// entries saved without an audience get the one their writer picked
+		// for new entries, only them if that fails
+		if e.Record.GetString("audience") == "" {
+			audience := AudiencePrivate
+			if userSettings, err := settingsService.ForUser(e.Record.GetString("user")); err != nil {
+				e.App.Logger().Warn("Failed to load the default audience", "user", e.Record.GetString("user"), "error", err)
+			} else if userSettings.DefaultAudience != "" {
+				audience = userSettings.DefaultAudience
+			}
+			e.Record.Set("audience", audience)
+		}
+		return e.Next()
+	})
+	app.OnRecordUpdate("journal_entry").BindFunc(func(e *core.RecordEvent) error {
+		if TextChanged(e.Record) {
+			e.Record.Set("topics", ExtractTopics(Text(e.Record)))
+		}
+		return e.Next()
+	})
+
+	// followers wait for the user they follow to approve them, which only
+	// that user can do through the collection's update rule
+	app.OnRecordCreate("follows").BindFunc(func(e *core.RecordEvent) error {
+		if e.Record.GetString("status") == "" {
+			e.Record.Set("status", FollowPending)
+		}
+		return e.Next()
+	})
+
+	// Clients that predate audiences still decode is_private, so it stays in
+	// responses until they move to audience. It can't be saved, writes of it
+	// are turned into an audience by checkAudience.
+	app.OnRecordEnrich("journal_entry").BindFunc(func(e *core.RecordEnrichEvent) error {
+		e.Record.WithCustomData(true)
+		e.Record.Set("is_private", e.Record.GetString("audience") == AudiencePrivate)
+		return e.Next()
+	})
+
+	app.OnRecordCreateRequest("journal_entry").BindFunc(func(e *core.RecordRequestEvent) error {
+		if err := checkAudience(e.RequestEvent, e.Record); err != nil {
+			return err
+		}
+		return e.Next()
+	})
+	app.OnRecordUpdateRequest("journal_entry").BindFunc(func(e *core.RecordRequestEvent) error {
+		if err := checkAudience(e.RequestEvent, e.Record); err != nil {
+			return err
+		}
+		return e.Next()
+	})
+}
+
+// checkAudience sets the audience of clients still sending is_private, and
+// keeps entries from being shown to groups their writer isn't in
+func checkAudience(e *core.RequestEvent, entry *core.Record) error {
+	info, err := e.RequestInfo()
+	if err != nil {
+		return err
+	}
+	if _, ok := info.Body["audience"]; !ok {
+		if private, ok := info.Body["is_private"]; ok {
+			if cast.ToBool(private) {
+				entry.Set("audience", AudiencePrivate)
+			} else {
+				entry.Set("audience", AudiencePublic)
+			}
+		}
+	}
+
+	added := entry.GetStringSlice("groups")
+	if !entry.IsNew() {
+		kept := entry.Original().GetStringSlice("groups")
+		added = slices.DeleteFunc(added, func(id string) bool { return slices.Contains(kept, id) })
+	}
+	if len(added) == 0 {
+		return nil
+	}
+	ids := make([]any, len(added))
+	for i, id := range added {
+		ids[i] = id
+	}
+	groups, err := e.App.FindAllRecords("groups", dbx.In("id", ids...))
+	if err != nil {
+		return err
+	}
+	writer := entry.GetString("user")
+	for _, group := range groups {
+		if group.GetString("owner") != writer && !slices.Contains(group.GetStringSlice("members"), writer) {
+			return e.BadRequestError("Invalid journal entry.", validation.Errors{
+				"groups": validation.NewError("validation_invalid_value", "Entries can only be shown to groups you're in."),
+			})
+		}
+	}
+	return nil
+}
+
+// Text is the title and content of an entry record, what its topics are
+// extracted from
+func Text(rec *core.Record) string {
+	return rec.GetString("title") + "\n" + rec.GetString("content")
+}
+
+// TextChanged reports whether the title or content of an entry record differ
+// from what was last saved
+func TextChanged(rec *core.Record) bool {
+	return rec.IsNew() || Text(rec) != Text(rec.Original())
+}
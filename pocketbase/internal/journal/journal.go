@@ -0,0 +1,160 @@
+package journal
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Who an entry is shown to besides its writer
+const (
+	AudiencePrivate   = "private"
+	AudienceFollowers = "followers"
+	AudienceGroups    = "groups"
+	AudiencePublic    = "public"
+)
+
+// Audiences lists every audience, narrowest first
+var Audiences = []string{AudiencePrivate, AudienceFollowers, AudienceGroups, AudiencePublic}
+
+// How the writer of an entry felt, best first
+const (
+	MoodGreat = "great"
+	MoodGood  = "good"
+	MoodOkay  = "okay"
+	MoodBad   = "bad"
+	MoodAwful = "awful"
+)
+
+// Moods lists every mood, best first
+var Moods = []string{MoodGreat, MoodGood, MoodOkay, MoodBad, MoodAwful}
+
+// Where a follow is at, followers only entries are shown once it's approved
+const (
+	FollowPending  = "pending"
+	FollowApproved = "approved"
+)
+
+type Entry struct {
+	Id        string `json:"id"`
+	User      string `json:"user"`
+	Title     string `json:"title"`
+	Content   string `json:"content"`
+	Location  string `json:"location"`
+	Published bool   `json:"published"`
+	Import    string `json:"import"`
+	Source    string `json:"source"`
+	SourceId  string `json:"source_id"`
+
+	// Audience is who else the entry is shown to, for the groups audience
+	// the members of Groups
+	Audience string   `json:"audience"`
+	Groups   []string `json:"groups"`
+
+	// Held entries looked like spam, no one else sees them until a
+	// moderator approves them
+	Held bool `json:"held"`
+
+	// Spoken entries were transcribed from a recording, kept as audio media
+	Spoken bool `json:"spoken"`
+
+	// Topics are what the entry is about, extracted as it's saved
+	Topics []string `json:"topics"`
+
+	// Mood is how the writer felt, empty when they didn't say
+	Mood string `json:"mood"`
+
+	Created types.DateTime `json:"created"`
+	Updated types.DateTime `json:"updated"`
+}
+
+func FromRecord(rec *core.Record) Entry {
+	entry := Entry{
+		Id:        rec.Id,
+		User:      rec.GetString("user"),
+		Title:     rec.GetString("title"),
+		Content:   rec.GetString("content"),
+		Location:  rec.GetString("location"),
+		Audience:  rec.GetString("audience"),
+		Published: rec.GetBool("published"),
+		Import:    rec.GetString("import"),
+		Source:    rec.GetString("source"),
+		SourceId:  rec.GetString("source_id"),
+		Spoken:    rec.GetBool("spoken"),
+		Held:      rec.GetBool("held"),
+		Groups:    rec.GetStringSlice("groups"),
+		Topics:    []string{},
+		Mood:      rec.GetString("mood"),
+		Created:   rec.GetDateTime("created"),
+		Updated:   rec.GetDateTime("updated"),
+	}
+	_ = rec.UnmarshalJSONField("topics", &entry.Topics)
+	return entry
+}
+
+// Sentence is a sentence from an entry that practices one grammar point
+type Sentence struct {
+	Id           string         `json:"id"`
+	User         string         `json:"user"`
+	JournalEntry string         `json:"journal_entry"`
+	Grammar      string         `json:"grammar"`
+	Content      string         `json:"content"`
+	Created      types.DateTime `json:"created"`
+}
+
+func SentenceFromRecord(rec *core.Record) Sentence {
+	return Sentence{
+		Id:           rec.Id,
+		User:         rec.GetString("user"),
+		JournalEntry: rec.GetString("journal_entry"),
+		Grammar:      rec.GetString("grammar"),
+		Content:      rec.GetString("content"),
+		Created:      rec.GetDateTime("created"),
+	}
+}
+
+// Correction is a fix to a passage of an entry, by a tutor, a model or from
+// an imported site
+type Correction struct {
+	Id           string         `json:"id"`
+	User         string         `json:"user"`
+	JournalEntry string         `json:"journal_entry"`
+	Original     string         `json:"original"`
+	Corrected    string         `json:"corrected"`
+	Comment      string         `json:"comment"`
+	Created      types.DateTime `json:"created"`
+}
+
+func CorrectionFromRecord(rec *core.Record) Correction {
+	return Correction{
+		Id:           rec.Id,
+		User:         rec.GetString("user"),
+		JournalEntry: rec.GetString("journal_entry"),
+		Original:     rec.GetString("original"),
+		Corrected:    rec.GetString("corrected"),
+		Comment:      rec.GetString("comment"),
+		Created:      rec.GetDateTime("created"),
+	}
+}
+
+// EntryPage is one page of a user's entries. Next is the cursor of the
+// following page, empty on the last one.
+type EntryPage struct {
+	Items []Entry `json:"items"`
+	Next  string  `json:"next"`
+}
+
+// TopicCount is how many of a user's entries are about a topic
+type TopicCount struct {
+	Topic   string `json:"topic"`
+	Entries int    `json:"entries"`
+}
+
+// OnThisDay is what a user wrote in the past worth looking back at today
+type OnThisDay struct {
+	// Anniversaries were written on this day in earlier years, newest first
+	Anniversaries []Entry `json:"anniversaries"`
+
+	// Related are older entries about what the user has been writing about
+	// lately, oldest first
+	Related []Entry `json:"related"`
+}
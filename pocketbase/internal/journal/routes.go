@@ -0,0 +1,74 @@
+package journal
+
+import (
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/settings"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+type topicsResponse struct {
+	Topics []TopicCount `json:"topics"`
+}
+
+func RegisterRoutes(g *api.Group, journalService Service, settingsService settings.Service) {
+	g.GET("/journal", "The user's entries about a ?topic= a page at a time, newest first, with ?cursor= and ?limit=", EntryPage{}, func(e *core.RequestEvent) error {
+		page, err := api.PageFromRequest(e)
+		if err != nil {
+			return e.BadRequestError("Invalid journal request.", err)
+		}
+		topic := e.Request.URL.Query().Get("topic")
+		if topic == "" {
+			return e.BadRequestError("Invalid journal request.", validation.Errors{
+				"topic": validation.NewError("validation_required", "Cannot be blank."),
+			})
+		}
+
+		result, err := journalService.About(e.Auth.Id, topic, page)
+		if err != nil {
+			return e.InternalServerError("Failed to load journal entries.", err)
+		}
+		return e.JSON(200, result)
+	})
+
+	g.GET("/journal/feed", "Entries of the users the user follows that are shown to them, a page at a time, newest first, with ?cursor= and ?limit=", EntryPage{}, func(e *core.RequestEvent) error {
+		page, err := api.PageFromRequest(e)
+		if err != nil {
+			return e.BadRequestError("Invalid journal request.", err)
+		}
+
+		result, err := journalService.Feed(e.Auth.Id, page)
+		if err != nil {
+			return e.InternalServerError("Failed to load journal entries.", err)
+		}
+		return e.JSON(200, result)
+	})
+
+	g.GET("/journal/topics", "What the user's entries are about, with how many entries each, most written about first", topicsResponse{}, func(e *core.RequestEvent) error {
+		topics, err := journalService.TopicCounts(e.Auth.Id)
+		if err != nil {
+			return e.InternalServerError("Failed to load journal topics.", err)
+		}
+		return e.JSON(200, topicsResponse{Topics: topics})
+	})
+
+	g.GET("/journal/on-this-day", "Entries written on this day in earlier years, and older entries about what the user writes about lately", OnThisDay{}, func(e *core.RequestEvent) error {
+		userSettings, err := settingsService.ForUser(e.Auth.Id)
+		if err != nil {
+			return e.InternalServerError("Failed to load settings.", err)
+		}
+		loc, err := time.LoadLocation(userSettings.Timezone)
+		if err != nil {
+			loc = time.UTC
+		}
+
+		result, err := journalService.OnThisDay(e.Auth.Id, time.Now().In(loc))
+		if err != nil {
+			return e.InternalServerError("Failed to load journal entries.", err)
+		}
+		return e.JSON(200, result)
+	})
+}
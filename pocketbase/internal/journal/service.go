@@ -0,0 +1,393 @@
+package journal
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+type Service interface {
+	// PublishedEntries returns a user's published entries not held for
+	// moderation, newest first
+	PublishedEntries(userId string) ([]Entry, error)
+
+	// FindPublished returns an entry only if its owner published it and it
+	// isn't held for moderation
+	FindPublished(id string) (Entry, error)
+
+	// EntriesBetween returns the user's entries created in [from, to), oldest
+	// first. A zero bound is open.
+	EntriesBetween(userId string, from types.DateTime, to types.DateTime) ([]Entry, error)
+
+	// Sentences returns the sentences of the given entries, keyed by entry
+	Sentences(entryIds []string) (map[string][]Sentence, error)
+
+	// Corrections returns the corrections of the given entries, keyed by
+	// entry
+	Corrections(entryIds []string) (map[string][]Correction, error)
+
+	// WrittenKanji is every kanji in the user's recent entries, which they
+	// can read even outside the words they studied
+	WrittenKanji(userId string) (map[rune]bool, error)
+
+	// LastUsed maps each grammar the user wrote a sentence for to when they
+	// last did
+	LastUsed(userId string) (map[string]types.DateTime, error)
+
+	// About returns a page of the user's entries about a topic, newest first
+	About(userId string, topic string, page api.Page) (EntryPage, error)
+
+	// Feed returns a page of the entries of the users the user follows that
+	// are shown to them, newest first
+	Feed(userId string, page api.Page) (EntryPage, error)
+
+	// TopicCounts returns how many of the user's entries are about each
+	// topic, most written about first
+	TopicCounts(userId string) ([]TopicCount, error)
+
+	// OnThisDay returns the user's entries to look back at on the day of now,
+	// in the user's time zone
+	OnThisDay(userId string, now time.Time) (OnThisDay, error)
+}
+
+type service struct {
+	app core.App
+}
+
+func NewService(app core.App) Service {
+	return &service{app: app}
+}
+
+func (s *service) PublishedEntries(userId string) ([]Entry, error) {
+	records, err := s.app.FindRecordsByFilter(
+		"journal_entry",
+		"user = {:user} && published = true && held = false",
+		"-created", 0, 0,
+		map[string]any{"user": userId},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, 0, len(records))
+	for _, rec := range records {
+		entries = append(entries, FromRecord(rec))
+	}
+	return entries, nil
+}
+
+func (s *service) FindPublished(id string) (Entry, error) {
+	rec, err := s.app.FindFirstRecordByFilter("journal_entry", "id = {:id} && published = true && held = false", map[string]any{"id": id})
+	if err != nil {
+		return Entry{}, err
+	}
+	return FromRecord(rec), nil
+}
+
+func (s *service) EntriesBetween(userId string, from types.DateTime, to types.DateTime) ([]Entry, error) {
+	filter := "user = {:user}"
+	params := dbx.Params{"user": userId}
+	if !from.IsZero() {
+		filter += " && created >= {:from}"
+		params["from"] = from.String()
+	}
+	if !to.IsZero() {
+		filter += " && created < {:to}"
+		params["to"] = to.String()
+	}
+
+	records, err := s.app.FindRecordsByFilter("journal_entry", filter, "created", 0, 0, params)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, 0, len(records))
+	for _, rec := range records {
+		entries = append(entries, FromRecord(rec))
+	}
+	return entries, nil
+}
+
+func (s *service) Sentences(entryIds []string) (map[string][]Sentence, error) {
+	sentences := map[string][]Sentence{}
+	if len(entryIds) == 0 {
+		return sentences, nil
+	}
+
+	ids := make([]any, len(entryIds))
+	for i, id := range entryIds {
+		ids[i] = id
+	}
+
+	var records []*core.Record
+	err := s.app.RecordQuery("sentence").
+		AndWhere(dbx.In("journal_entry", ids...)).
+		OrderBy("created ASC").
+		All(&records)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rec := range records {
+		sentence := SentenceFromRecord(rec)
+		sentences[sentence.JournalEntry] = append(sentences[sentence.JournalEntry], sentence)
+	}
+	return sentences, nil
+}
+
+func (s *service) Corrections(entryIds []string) (map[string][]Correction, error) {
+	corrections := map[string][]Correction{}
+	if len(entryIds) == 0 {
+		return corrections, nil
+	}
+
+	ids := make([]any, len(entryIds))
+	for i, id := range entryIds {
+		ids[i] = id
+	}
+
+	var records []*core.Record
+	err := s.app.RecordQuery("corrections").
+		AndWhere(dbx.In("journal_entry", ids...)).
+		OrderBy("created ASC").
+		All(&records)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rec := range records {
+		correction := CorrectionFromRecord(rec)
+		corrections[correction.JournalEntry] = append(corrections[correction.JournalEntry], correction)
+	}
+	return corrections, nil
+}
+
+func (s *service) LastUsed(userId string) (map[string]types.DateTime, error) {
+	var rows []struct {
+		Grammar string         `db:"grammar"`
+		Used    types.DateTime `db:"used"`
+	}
+	err := s.app.RecordQuery("sentence").
+		Select("grammar", "MAX(created) AS used").
+		AndWhere(dbx.HashExp{"user": userId}).
+		GroupBy("grammar").
+		All(&rows)
+	if err != nil {
+		return nil, err
+	}
+
+	used := make(map[string]types.DateTime, len(rows))
+	for _, row := range rows {
+		used[row.Grammar] = row.Used
+	}
+	return used, nil
+}
+
+// writtenKanjiEntries bounds the entries WrittenKanji reads, newest first
+const writtenKanjiEntries = 500
+
+func (s *service) WrittenKanji(userId string) (map[rune]bool, error) {
+	var contents []string
+	err := s.app.RecordQuery("journal_entry").
+		Select("content").
+		AndWhere(dbx.HashExp{"user": userId}).
+		OrderBy("created DESC").
+		Limit(writtenKanjiEntries).
+		Column(&contents)
+	if err != nil {
+		return nil, err
+	}
+
+	kanji := map[rune]bool{}
+	for _, content := range contents {
+		for _, r := range content {
+			if unicode.Is(unicode.Han, r) {
+				kanji[r] = true
+			}
+		}
+	}
+	return kanji, nil
+}
+
+func (s *service) About(userId string, topic string, page api.Page) (EntryPage, error) {
+	// topics is a JSON array, so look for the quoted topic
+	params := map[string]any{"user": userId, "topic": `"` + topic + `"`}
+	filters := []string{"user = {:user}", "topics ~ {:topic}"}
+	if keyset := page.KeysetFilterDesc("created", params); keyset != "" {
+		filters = append(filters, keyset)
+	}
+
+	records, err := s.app.FindRecordsByFilter("journal_entry", strings.Join(filters, " && "), "-created,-id", page.Limit+1, 0, params)
+	if err != nil {
+		return EntryPage{}, err
+	}
+
+	items := make([]Entry, 0, len(records))
+	for _, rec := range records {
+		items = append(items, FromRecord(rec))
+	}
+	next := page.Next(len(items), func(i int) api.Cursor {
+		return api.Cursor{Value: items[i].Created.String(), Id: items[i].Id}
+	})
+	if len(items) > page.Limit {
+		items = items[:page.Limit]
+	}
+	return EntryPage{Items: items, Next: next}, nil
+}
+
+func (s *service) Feed(userId string, page api.Page) (EntryPage, error) {
+	// the same audiences as the collection's view rule, for approved
+	// followers only
+	params := map[string]any{"user": userId, "approved": FollowApproved}
+	filters := []string{
+		"user != {:user}",
+		"held = false",
+		"@collection.follows.user ?= {:user} && @collection.follows.following ?= user && @collection.follows.status ?= {:approved}",
+		"(audience = 'public' || audience = 'followers' || (audience = 'groups' && (groups.owner ?= {:user} || groups.members.id ?= {:user})))",
+	}
+	if keyset := page.KeysetFilterDesc("created", params); keyset != "" {
+		filters = append(filters, keyset)
+	}
+
+	records, err := s.app.FindRecordsByFilter("journal_entry", strings.Join(filters, " && "), "-created,-id", page.Limit+1, 0, params)
+	if err != nil {
+		return EntryPage{}, err
+	}
+
+	items := make([]Entry, 0, len(records))
+	for _, rec := range records {
+		items = append(items, FromRecord(rec))
+	}
+	next := page.Next(len(items), func(i int) api.Cursor {
+		return api.Cursor{Value: items[i].Created.String(), Id: items[i].Id}
+	})
+	if len(items) > page.Limit {
+		items = items[:page.Limit]
+	}
+	return EntryPage{Items: items, Next: next}, nil
+}
+
+func (s *service) TopicCounts(userId string) ([]TopicCount, error) {
+	counts := []TopicCount{}
+	err := s.app.DB().NewQuery(`
+		SELECT topic.value AS topic, COUNT(*) AS entries
+		FROM journal_entry, json_each(journal_entry.topics) AS topic
+		WHERE journal_entry.user = {:user} AND json_valid(journal_entry.topics)
+		GROUP BY topic.value
+		ORDER BY entries DESC, topic.value
+	`).Bind(dbx.Params{"user": userId}).All(&counts)
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+const (
+	// relatedEntries bounds the related entries surfaced on a day
+	relatedEntries = 3
+
+	// recentTopicsWindow is how far back what the user writes about lately
+	// is read from, and olderThan how old related entries must be to be worth
+	// looking back at
+	recentTopicsWindow = 7 * 24 * time.Hour
+	olderThan          = 30 * 24 * time.Hour
+
+	// relatedCandidates bounds the older entries related ones are picked from
+	relatedCandidates = 200
+)
+
+func (s *service) OnThisDay(userId string, now time.Time) (OnThisDay, error) {
+	result := OnThisDay{Anniversaries: []Entry{}, Related: []Entry{}}
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	// created is stored in UTC, so the day in the user's time zone may fall on
+	// the UTC day before or after
+	days := make([]any, 0, 3)
+	for _, offset := range []int{-1, 0, 1} {
+		days = append(days, dayStart.AddDate(0, 0, offset).UTC().Format("01-02"))
+	}
+	var records []*core.Record
+	err := s.app.RecordQuery("journal_entry").
+		AndWhere(dbx.HashExp{"user": userId}).
+		AndWhere(dbx.In("strftime('%m-%d', created)", days...)).
+		AndWhere(dbx.NewExp("created < {:dayStart}", dbx.Params{"dayStart": dayStart.UTC().Format(types.DefaultDateLayout)})).
+		OrderBy("created DESC").
+		All(&records)
+	if err != nil {
+		return OnThisDay{}, err
+	}
+	for _, rec := range records {
+		local := rec.GetDateTime("created").Time().In(now.Location())
+		if local.Month() == now.Month() && local.Day() == now.Day() {
+			result.Anniversaries = append(result.Anniversaries, FromRecord(rec))
+		}
+	}
+
+	from, _ := types.ParseDateTime(now.Add(-recentTopicsWindow))
+	recent, err := s.EntriesBetween(userId, from, types.DateTime{})
+	if err != nil {
+		return OnThisDay{}, err
+	}
+	lately := map[string]bool{}
+	for _, entry := range recent {
+		for _, topic := range entry.Topics {
+			lately[topic] = true
+		}
+	}
+	if len(lately) == 0 {
+		return result, nil
+	}
+
+	params := dbx.Params{"user": userId, "before": now.Add(-olderThan).UTC().Format(types.DefaultDateLayout)}
+	topicFilters := make([]string, 0, len(lately))
+	for topic := range lately {
+		key := "topic" + strconv.Itoa(len(topicFilters))
+		params[key] = `"` + topic + `"`
+		topicFilters = append(topicFilters, "topics ~ {:"+key+"}")
+	}
+	candidates, err := s.app.FindRecordsByFilter(
+		"journal_entry",
+		"user = {:user} && created < {:before} && ("+strings.Join(topicFilters, " || ")+")",
+		"-created", relatedCandidates, 0,
+		params,
+	)
+	if err != nil {
+		return OnThisDay{}, err
+	}
+
+	related := make([]Entry, 0, len(candidates))
+	shared := map[string]int{}
+	for _, rec := range candidates {
+		entry := FromRecord(rec)
+		if slices.ContainsFunc(result.Anniversaries, func(a Entry) bool { return a.Id == entry.Id }) {
+			continue
+		}
+		for _, topic := range entry.Topics {
+			if lately[topic] {
+				shared[entry.Id]++
+			}
+		}
+		related = append(related, entry)
+	}
+
+	// the entries most alike what the user writes about lately, and of those
+	// the oldest, the ones they're least likely to remember
+	slices.SortFunc(related, func(a, b Entry) int {
+		if c := cmp.Compare(shared[b.Id], shared[a.Id]); c != 0 {
+			return c
+		}
+		return a.Created.Time().Compare(b.Created.Time())
+	})
+	result.Related = related[:min(len(related), relatedEntries)]
+	slices.SortFunc(result.Related, func(a, b Entry) int { return a.Created.Time().Compare(b.Created.Time()) })
+	return result, nil
+}
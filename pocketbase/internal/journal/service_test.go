@@ -0,0 +1,116 @@
+package journal_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/journal"
+	_ "github.com/bunkbed-tech/fushigi/pocketbase/migrations"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+// newTestApp is a migrated app with one user
+func newTestApp(t *testing.T) (*tests.TestApp, *core.Record) {
+	t.Helper()
+	// the initial migration creates a superuser from these
+	t.Setenv("ADMIN_EMAIL", "admin@example.com")
+	t.Setenv("ADMIN_PASSWORD", "correct horse battery")
+	app, err := tests.NewTestApp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(app.Cleanup)
+	if err := app.RunAllMigrations(); err != nil {
+		t.Fatal(err)
+	}
+
+	users, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := core.NewRecord(users)
+	user.SetEmail("writer@example.com")
+	user.SetPassword("correct horse battery")
+	if err := app.Save(user); err != nil {
+		t.Fatal(err)
+	}
+	return app, user
+}
+
+func saveEntry(t *testing.T, app core.App, userId string, title string, published bool) *core.Record {
+	t.Helper()
+	entries, err := app.FindCollectionByNameOrId("journal_entry")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := core.NewRecord(entries)
+	entry.Set("user", userId)
+	entry.Set("title", title)
+	entry.Set("content", "今日は晴れです。")
+	entry.Set("published", published)
+	if err := app.Save(entry); err != nil {
+		t.Fatal(err)
+	}
+	return entry
+}
+
+func TestPublishedEntries(t *testing.T) {
+	app, user := newTestApp(t)
+	saveEntry(t, app, user.Id, "Draft", false)
+	saveEntry(t, app, user.Id, "Published", true)
+	service := journal.NewService(app)
+
+	tests := []struct {
+		name   string
+		userId string
+		want   []string
+	}{
+		{"published only", user.Id, []string{"Published"}},
+		{"someone else's", "missing", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := service.PublishedEntries(tt.userId)
+			if err != nil {
+				t.Fatal(err)
+			}
+			titles := []string{}
+			for _, entry := range entries {
+				titles = append(titles, entry.Title)
+			}
+			if !slices.Equal(titles, tt.want) {
+				t.Errorf("PublishedEntries() = %v, want %v", titles, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindPublished(t *testing.T) {
+	app, user := newTestApp(t)
+	draft := saveEntry(t, app, user.Id, "Draft", false)
+	published := saveEntry(t, app, user.Id, "Published", true)
+	service := journal.NewService(app)
+
+	tests := []struct {
+		name  string
+		id    string
+		found bool
+	}{
+		{"published", published.Id, true},
+		{"draft", draft.Id, false},
+		{"missing", "missing", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, err := service.FindPublished(tt.id)
+			if found := err == nil; found != tt.found {
+				t.Fatalf("FindPublished() error = %v, want found %v", err, tt.found)
+			}
+			if tt.found && (entry.Id != tt.id || entry.User != user.Id) {
+				t.Errorf("FindPublished() = %+v, want %s of %s", entry, tt.id, user.Id)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package journal
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// maxTopics keeps an entry's topics to what it's mostly about
+const maxTopics = 3
+
+// Topics an entry can be about
+const (
+	TopicWork     = "work"
+	TopicSchool   = "school"
+	TopicStudy    = "study"
+	TopicFamily   = "family"
+	TopicFriends  = "friends"
+	TopicLove     = "love"
+	TopicHome     = "home"
+	TopicFood     = "food"
+	TopicTravel   = "travel"
+	TopicHealth   = "health"
+	TopicSports   = "sports"
+	TopicWeather  = "weather"
+	TopicNature   = "nature"
+	TopicShopping = "shopping"
+	TopicMusic    = "music"
+	TopicMovies   = "movies"
+	TopicBooks    = "books"
+	TopicGames    = "games"
+)
+
+var Topics = []string{
+	TopicWork, TopicSchool, TopicStudy, TopicFamily, TopicFriends, TopicLove,
+	TopicHome, TopicFood, TopicTravel, TopicHealth, TopicSports, TopicWeather,
+	TopicNature, TopicShopping, TopicMusic, TopicMovies, TopicBooks, TopicGames,
+}
+
+// topicKeywords give away what an entry is about. Japanese keywords are
+// matched anywhere in the text, others as whole words.
+var topicKeywords = map[string][]string{
+	TopicWork:     {"仕事", "会社", "職場", "上司", "同僚", "会議", "残業", "出勤", "バイト", "働", "work", "job", "office", "boss", "coworker", "meeting"},
+	TopicSchool:   {"学校", "授業", "宿題", "試験", "テスト", "大学", "学生", "先生", "クラス", "school", "class", "homework", "exam", "teacher", "university"},
+	TopicStudy:    {"勉強", "日本語", "漢字", "文法", "単語", "練習", "study", "studied", "kanji", "grammar", "vocabulary", "practice"},
+	TopicFamily:   {"家族", "両親", "母", "父", "兄", "姉", "弟", "妹", "子供", "息子", "娘", "祖母", "祖父", "family", "mother", "father", "mom", "dad", "brother", "sister", "parents"},
+	TopicFriends:  {"友達", "友人", "親友", "friend", "friends"},
+	TopicLove:     {"彼氏", "彼女", "恋人", "デート", "結婚", "恋", "boyfriend", "girlfriend", "date", "wedding"},
+	TopicHome:     {"部屋", "掃除", "洗濯", "引っ越", "家事", "apartment", "room", "cleaning", "laundry"},
+	TopicFood:     {"料理", "ご飯", "食べ", "飲", "レストラン", "美味し", "おいし", "ラーメン", "寿司", "カフェ", "food", "cook", "cooked", "dinner", "lunch", "breakfast", "restaurant"},
+	TopicTravel:   {"旅行", "旅", "空港", "飛行機", "新幹線", "ホテル", "観光", "travel", "trip", "airport", "flight", "hotel"},
+	TopicHealth:   {"病院", "病気", "風邪", "薬", "医者", "頭痛", "熱が", "health", "sick", "doctor", "hospital", "cold"},
+	TopicSports:   {"運動", "サッカー", "野球", "テニス", "ジム", "試合", "泳", "走", "sport", "sports", "gym", "soccer", "baseball", "tennis", "run", "running"},
+	TopicWeather:  {"天気", "雨", "雪", "晴れ", "暑", "寒", "台風", "曇", "weather", "rain", "snow", "sunny", "hot", "cold"},
+	TopicNature:   {"公園", "自然", "花", "桜", "散歩", "海", "山に", "山を", "park", "nature", "flowers", "hike", "hiking", "beach"},
+	TopicShopping: {"買い物", "買っ", "買う", "店", "セール", "shopping", "bought", "store", "shop"},
+	TopicMusic:    {"音楽", "歌", "ピアノ", "ギター", "コンサート", "ライブ", "music", "song", "concert", "piano", "guitar"},
+	TopicMovies:   {"映画", "ドラマ", "アニメ", "movie", "film", "drama", "anime"},
+	TopicBooks:    {"本を", "本が", "本屋", "小説", "漫画", "読書", "読ん", "book", "books", "novel", "manga", "read"},
+	TopicGames:    {"ゲーム", "game", "games", "gaming"},
+}
+
+// ExtractTopics picks what a text is mostly about by counting the keywords
+// of each topic in it, leaving out topics with a fraction of the best's hits
+func ExtractTopics(text string) []string {
+	lower := strings.ToLower(text)
+	words := map[string]int{}
+	for _, word := range strings.FieldsFunc(lower, func(r rune) bool { return !unicode.IsLetter(r) }) {
+		words[word]++
+	}
+
+	hits := map[string]int{}
+	for topic, keywords := range topicKeywords {
+		for _, keyword := range keywords {
+			if isASCII(keyword) {
+				hits[topic] += words[keyword]
+			} else {
+				hits[topic] += strings.Count(lower, keyword)
+			}
+		}
+	}
+
+	topics := make([]string, 0, len(hits))
+	best := 0
+	for topic, n := range hits {
+		if n > 0 {
+			topics = append(topics, topic)
+			best = max(best, n)
+		}
+	}
+	slices.SortFunc(topics, func(a, b string) int {
+		if c := cmp.Compare(hits[b], hits[a]); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	})
+	topics = slices.DeleteFunc(topics, func(topic string) bool { return hits[topic]*3 < best })
+	if len(topics) > maxTopics {
+		topics = topics[:maxTopics]
+	}
+	return topics
+}
+
+func isASCII(s string) bool {
+	for _, r := range s {
+		if r > unicode.MaxASCII {
+			return false
+		}
+	}
+	return true
+}
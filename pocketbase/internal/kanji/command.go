@@ -0,0 +1,81 @@
+package kanji
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// NewCommand adds `kanji import <kanjidic2.xml> [kradfile]`, which fills the
+// kanji dictionary from EDRDG's files, gzipped or not
+func NewCommand(kanjiService Service) *cobra.Command {
+	command := &cobra.Command{
+		Use:   "kanji",
+		Short: "Manage the kanji dictionary",
+	}
+
+	importCommand := &cobra.Command{
+		Use:   "import <kanjidic2.xml> [kradfile]",
+		Short: "Create or update the kanji from KANJIDIC2, and their components from KRADFILE",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var entries []Entry
+			err := readFile(args[0], func(r io.Reader) (err error) {
+				entries, err = ParseKanjidic(r)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+
+			var parts map[string][]string
+			if len(args) > 1 {
+				err := readFile(args[1], func(r io.Reader) (err error) {
+					parts, err = ParseKradfile(r)
+					return err
+				})
+				if err != nil {
+					return err
+				}
+			}
+
+			result, err := kanjiService.Import(entries, parts)
+			if err != nil {
+				return err
+			}
+			out, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return err
+			}
+			cmd.Println(string(out))
+			return nil
+		},
+	}
+
+	command.AddCommand(importCommand)
+	return command
+}
+
+// readFile hands the contents of a file to read, ungzipped if need be
+func readFile(path string, read func(r io.Reader) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	if magic, err := r.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		return read(gz)
+	}
+	return read(r)
+}
@@ -0,0 +1,56 @@
+package kanji
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// Kanji is one character of the kanji dictionary
+type Kanji struct {
+	Id          string   `json:"id"`
+	Character   string   `json:"character"`
+	Meanings    []string `json:"meanings"`
+	OnReadings  []string `json:"on_readings"`
+	KunReadings []string `json:"kun_readings"`
+	StrokeCount int      `json:"stroke_count"`
+
+	// JLPT is the level of the old four-level test, 1 being the hardest,
+	// zero when it isn't on it
+	JLPT int `json:"jlpt"`
+
+	// Radicals are the parts the kanji is written with, as KRADFILE breaks
+	// it down
+	Radicals []string `json:"radicals"`
+
+	// Components are the ids of the radicals that are kanji themselves
+	Components []string `json:"components"`
+}
+
+func FromRecord(rec *core.Record) Kanji {
+	k := Kanji{
+		Id:          rec.Id,
+		Character:   rec.GetString("character"),
+		Meanings:    []string{},
+		OnReadings:  []string{},
+		KunReadings: []string{},
+		StrokeCount: rec.GetInt("stroke_count"),
+		JLPT:        rec.GetInt("jlpt"),
+		Radicals:    []string{},
+		Components:  rec.GetStringSlice("components"),
+	}
+	_ = rec.UnmarshalJSONField("meanings", &k.Meanings)
+	_ = rec.UnmarshalJSONField("on_readings", &k.OnReadings)
+	_ = rec.UnmarshalJSONField("kun_readings", &k.KunReadings)
+	_ = rec.UnmarshalJSONField("radicals", &k.Radicals)
+	return k
+}
+
+// Breakdown is a kanji with its components expanded, the way the collection
+// API expands relations
+type Breakdown struct {
+	Kanji
+	Expand BreakdownExpand `json:"expand"`
+}
+
+type BreakdownExpand struct {
+	Components []Kanji `json:"components"`
+}
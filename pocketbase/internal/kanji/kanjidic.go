@@ -0,0 +1,143 @@
+package kanji
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/japanese"
+)
+
+// ErrEmptyDictionary means no kanji could be read from a dictionary file
+var ErrEmptyDictionary = errors.New("dictionary has no kanji")
+
+// Entry is what KANJIDIC2 says of one kanji
+type Entry struct {
+	Character   string
+	Meanings    []string
+	OnReadings  []string
+	KunReadings []string
+	StrokeCount int
+	JLPT        int
+}
+
+// kanjidicCharacter is the part of a KANJIDIC2 <character> element read
+type kanjidicCharacter struct {
+	Literal string `xml:"literal"`
+	Misc    struct {
+		// the first count is the accepted one, the others common miscounts
+		StrokeCounts []int `xml:"stroke_count"`
+		JLPT         int   `xml:"jlpt"`
+	} `xml:"misc"`
+	Groups []struct {
+		Readings []struct {
+			Type  string `xml:"r_type,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"reading"`
+		Meanings []struct {
+			Lang  string `xml:"m_lang,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"meaning"`
+	} `xml:"reading_meaning>rmgroup"`
+}
+
+// ParseKanjidic reads a KANJIDIC2 XML file, keeping the English meanings and
+// the Japanese readings
+func ParseKanjidic(r io.Reader) ([]Entry, error) {
+	entries := []Entry{}
+	decoder := xml.NewDecoder(r)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "character" {
+			continue
+		}
+
+		var c kanjidicCharacter
+		if err := decoder.DecodeElement(&c, &start); err != nil {
+			return nil, err
+		}
+		entry := Entry{
+			Character:   strings.TrimSpace(c.Literal),
+			Meanings:    []string{},
+			OnReadings:  []string{},
+			KunReadings: []string{},
+			JLPT:        c.Misc.JLPT,
+		}
+		if entry.Character == "" {
+			continue
+		}
+		if len(c.Misc.StrokeCounts) > 0 {
+			entry.StrokeCount = c.Misc.StrokeCounts[0]
+		}
+		for _, group := range c.Groups {
+			for _, reading := range group.Readings {
+				switch reading.Type {
+				case "ja_on":
+					entry.OnReadings = append(entry.OnReadings, reading.Value)
+				case "ja_kun":
+					entry.KunReadings = append(entry.KunReadings, reading.Value)
+				}
+			}
+			for _, meaning := range group.Meanings {
+				// English meanings are the ones without a language
+				if meaning.Lang == "" || meaning.Lang == "en" {
+					entry.Meanings = append(entry.Meanings, meaning.Value)
+				}
+			}
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) == 0 {
+		return nil, ErrEmptyDictionary
+	}
+	return entries, nil
+}
+
+// ParseKradfile reads the parts of each kanji from a KRADFILE, a line like
+// "亜 : ｜ 一 口" a kanji. EDRDG ships it in EUC-JP, which is decoded unless
+// the file is UTF-8 already. Lines starting with # are comments.
+func ParseKradfile(r io.Reader) (map[string][]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if !utf8.Valid(data) {
+		if data, err = japanese.EUCJP.NewDecoder().Bytes(data); err != nil {
+			return nil, err
+		}
+	}
+
+	parts := map[string][]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		character, radicals, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		if character = strings.TrimSpace(character); character != "" {
+			parts[character] = strings.Fields(radicals)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(parts) == 0 {
+		return nil, ErrEmptyDictionary
+	}
+	return parts, nil
+}
@@ -0,0 +1,36 @@
+package kanji
+
+import (
+	"unicode/utf8"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// maxTextLength bounds the text one request breaks down
+const maxTextLength = 1000
+
+func RegisterRoutes(g *api.Group, kanjiService Service) {
+	g.GET("/kanji/breakdown", "The kanji of ?text=, e.g. a grammar example or vocabulary term, in order with their components expanded", []Breakdown{}, func(e *core.RequestEvent) error {
+		text := e.Request.URL.Query().Get("text")
+		if text == "" {
+			return e.BadRequestError("Invalid kanji breakdown request.", validation.Errors{
+				"text": validation.NewError("validation_required", "Cannot be blank."),
+			})
+		}
+		if utf8.RuneCountInString(text) > maxTextLength {
+			return e.BadRequestError("Invalid kanji breakdown request.", validation.Errors{
+				"text": validation.NewError("validation_length_too_long", "The length must be no more than {{.max}}.").
+					SetParams(map[string]any{"max": maxTextLength}),
+			})
+		}
+
+		breakdowns, err := kanjiService.Breakdown(text)
+		if err != nil {
+			return e.InternalServerError("Failed to load kanji.", err)
+		}
+		return e.JSON(200, breakdowns)
+	})
+}
@@ -0,0 +1,160 @@
+package kanji
+
+import (
+	"slices"
+	"unicode"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// Result sums up an imported dictionary
+type Result struct {
+	Entries int `json:"entries"`
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+
+	// Components counts the kanji given components, none without a KRADFILE
+	Components int `json:"components"`
+}
+
+type Service interface {
+	// Import creates or updates a kanji for each entry. With parts, from a
+	// KRADFILE, it also sets their radicals and components, leaving those of
+	// an earlier import otherwise.
+	Import(entries []Entry, parts map[string][]string) (Result, error)
+
+	// Breakdown returns the kanji of a text in the order they first appear,
+	// with their components, skipping those not in the dictionary
+	Breakdown(text string) ([]Breakdown, error)
+}
+
+type service struct {
+	app core.App
+}
+
+func NewService(app core.App) Service {
+	return &service{app: app}
+}
+
+func (s *service) Import(entries []Entry, parts map[string][]string) (Result, error) {
+	result := Result{Entries: len(entries)}
+	err := s.app.RunInTransaction(func(txApp core.App) error {
+		collection, err := txApp.FindCollectionByNameOrId("kanji")
+		if err != nil {
+			return err
+		}
+		existing, err := txApp.FindAllRecords(collection)
+		if err != nil {
+			return err
+		}
+		byCharacter := make(map[string]*core.Record, len(existing))
+		for _, rec := range existing {
+			byCharacter[rec.GetString("character")] = rec
+		}
+
+		for _, entry := range entries {
+			rec, ok := byCharacter[entry.Character]
+			if ok {
+				result.Updated++
+			} else {
+				rec = core.NewRecord(collection)
+				rec.Set("character", entry.Character)
+				byCharacter[entry.Character] = rec
+				result.Created++
+			}
+			rec.Set("meanings", entry.Meanings)
+			rec.Set("on_readings", entry.OnReadings)
+			rec.Set("kun_readings", entry.KunReadings)
+			rec.Set("stroke_count", entry.StrokeCount)
+			rec.Set("jlpt", entry.JLPT)
+			if parts != nil {
+				rec.Set("radicals", append([]string{}, parts[entry.Character]...))
+			}
+			if err := txApp.Save(rec); err != nil {
+				return err
+			}
+		}
+		if parts == nil {
+			return nil
+		}
+
+		// once every kanji has an id to point to
+		for _, entry := range entries {
+			components := []string{}
+			for _, part := range parts[entry.Character] {
+				if component, ok := byCharacter[part]; ok && part != entry.Character {
+					components = append(components, component.Id)
+				}
+			}
+			if len(components) > 0 {
+				result.Components++
+			}
+
+			rec := byCharacter[entry.Character]
+			if slices.Equal(rec.GetStringSlice("components"), components) {
+				continue
+			}
+			rec.Set("components", components)
+			if err := txApp.Save(rec); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	return result, nil
+}
+
+func (s *service) Breakdown(text string) ([]Breakdown, error) {
+	characters := []any{}
+	seen := map[rune]bool{}
+	for _, r := range text {
+		if unicode.Is(unicode.Han, r) && !seen[r] {
+			seen[r] = true
+			characters = append(characters, string(r))
+		}
+	}
+	if len(characters) == 0 {
+		return []Breakdown{}, nil
+	}
+
+	records, err := s.app.FindAllRecords("kanji", dbx.In("character", characters...))
+	if err != nil {
+		return nil, err
+	}
+	byCharacter := make(map[string]Kanji, len(records))
+	componentIds := []string{}
+	for _, rec := range records {
+		k := FromRecord(rec)
+		byCharacter[k.Character] = k
+		componentIds = append(componentIds, k.Components...)
+	}
+
+	components, err := s.app.FindRecordsByIds("kanji", componentIds)
+	if err != nil {
+		return nil, err
+	}
+	componentsById := make(map[string]Kanji, len(components))
+	for _, rec := range components {
+		componentsById[rec.Id] = FromRecord(rec)
+	}
+
+	breakdowns := make([]Breakdown, 0, len(byCharacter))
+	for _, character := range characters {
+		k, ok := byCharacter[character.(string)]
+		if !ok {
+			continue
+		}
+		expanded := make([]Kanji, 0, len(k.Components))
+		for _, id := range k.Components {
+			if component, ok := componentsById[id]; ok {
+				expanded = append(expanded, component)
+			}
+		}
+		breakdowns = append(breakdowns, Breakdown{Kanji: k, Expand: BreakdownExpand{Components: expanded}})
+	}
+	return breakdowns, nil
+}
@@ -0,0 +1,25 @@
+package liveedit
+
+import (
+	"strings"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// BindHooks keeps a live edit's topic to the learner and tutor editing it
+func BindHooks(app core.App, liveeditService Service) {
+	app.OnRealtimeSubscribeRequest().BindFunc(func(e *core.RealtimeSubscribeRequestEvent) error {
+		for _, sub := range e.Subscriptions {
+			// topics can carry options after a "?"
+			topic, _, _ := strings.Cut(sub, "?")
+			id, ok := strings.CutPrefix(topic, topicPrefix)
+			if !ok {
+				continue
+			}
+			if e.Auth == nil || !liveeditService.CanFollow(e.Auth.Id, id) {
+				return e.ForbiddenError("You can't follow this live edit.", nil)
+			}
+		}
+		return e.Next()
+	})
+}
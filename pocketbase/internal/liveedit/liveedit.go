@@ -0,0 +1,132 @@
+package liveedit
+
+import (
+	"unicode/utf8"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// topicPrefix is followed by a live edit's id to make the realtime topic its
+// events are published on, which clients subscribe to through the regular
+// /api/realtime endpoint
+const topicPrefix = "fushigi/live_edits/"
+
+// maxClientLength bounds the id clients tell their own operations apart by
+const maxClientLength = 100
+
+// Event types
+const (
+	EventOperation = "operation"
+	EventEnded     = "ended"
+)
+
+// LiveEdit is a learner and one of their tutors annotating the document of
+// a tutor session for one of the learner's entries together
+type LiveEdit struct {
+	Id           string `json:"id"`
+	User         string `json:"user"`
+	Tutor        string `json:"tutor"`
+	TutorSession string `json:"tutor_session"`
+	Entry        string `json:"journal_entry"`
+
+	// Document and Revision are where the edits are at, the revision
+	// counting the operations applied
+	Document string `json:"document"`
+	Revision int    `json:"revision"`
+
+	// Topic is the realtime topic the live edit's events are published on
+	Topic string `json:"topic"`
+
+	// Ended is when the annotations were imported as corrections, zero while
+	// the document is being edited
+	Ended   types.DateTime `json:"ended"`
+	Created types.DateTime `json:"created"`
+}
+
+func FromRecord(rec *core.Record) LiveEdit {
+	return LiveEdit{
+		Id:           rec.Id,
+		User:         rec.GetString("user"),
+		Tutor:        rec.GetString("tutor"),
+		TutorSession: rec.GetString("tutor_session"),
+		Entry:        rec.GetString("journal_entry"),
+		Document:     rec.GetString("document"),
+		Revision:     rec.GetInt("revision"),
+		Topic:        Topic(rec.Id),
+		Ended:        rec.GetDateTime("ended"),
+		Created:      rec.GetDateTime("created"),
+	}
+}
+
+// Topic is the realtime topic of a live edit
+func Topic(id string) string {
+	return topicPrefix + id
+}
+
+// participant reports whether a user can edit
+func (l LiveEdit) participant(userId string) bool {
+	return userId != "" && (userId == l.User || userId == l.Tutor)
+}
+
+// StartRequest starts editing one of the learner's entries. The learner
+// names which of their tutors; a tutor starting it is the tutor.
+type StartRequest struct {
+	Entry string `json:"journal_entry"`
+	Tutor string `json:"tutor"`
+}
+
+func (r StartRequest) Validate() error {
+	if r.Entry == "" {
+		return validation.Errors{"journal_entry": validation.NewError("validation_required", "Cannot be blank.")}
+	}
+	return nil
+}
+
+// OperationRequest is an edit made on the document at Revision
+type OperationRequest struct {
+	Revision  int       `json:"revision"`
+	Operation Operation `json:"operation"`
+
+	// Client is an id of the client's choosing its own operations come back
+	// with in events
+	Client string `json:"client"`
+}
+
+func (r OperationRequest) Validate() error {
+	errs := validation.Errors{}
+	if r.Revision < 0 {
+		errs["revision"] = validation.NewError("validation_invalid_value", "Invalid value.")
+	}
+	if len(r.Operation) == 0 {
+		errs["operation"] = validation.NewError("validation_required", "Cannot be blank.")
+	}
+	if utf8.RuneCountInString(r.Client) > maxClientLength {
+		errs["client"] = validation.NewError("validation_length_too_long", "The length must be no more than {{.max}}.").
+			SetParams(map[string]any{"max": maxClientLength})
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// OperationResult acknowledges an operation, as it was applied once
+// transformed against those it was concurrent with
+type OperationResult struct {
+	Revision  int       `json:"revision"`
+	Operation Operation `json:"operation"`
+}
+
+// Event is what a live edit's realtime topic publishes: each operation
+// applied, in order, and the edit ending
+type Event struct {
+	Type string `json:"type"`
+
+	// Revision is the document's once the operation is applied
+	Revision  int       `json:"revision"`
+	Operation Operation `json:"operation,omitempty"`
+	Author    string    `json:"author,omitempty"`
+	Client    string    `json:"client,omitempty"`
+}
@@ -0,0 +1,229 @@
+package liveedit
+
+import (
+	"encoding/json"
+	"errors"
+	"unicode/utf8"
+)
+
+// ErrInvalidOperation means an operation doesn't fit the document it's
+// applied to or transformed against
+var ErrInvalidOperation = errors.New("invalid operation")
+
+// component is one step of an operation, exactly one of its fields set
+type component struct {
+	retain int
+	insert string
+	delete int
+}
+
+func (c component) insertLength() int {
+	return utf8.RuneCountInString(c.insert)
+}
+
+// Operation edits a document from start to end, the way ot.js does: it
+// retains, inserts or deletes text in turn, lengths counting Unicode code
+// points. In JSON it's an array of positive numbers to retain, strings to
+// insert and negative numbers to delete, e.g. [5, "abc", -2, 10].
+type Operation []component
+
+func (o *Operation) UnmarshalJSON(data []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	var op Operation
+	for _, item := range raw {
+		var text string
+		if err := json.Unmarshal(item, &text); err == nil {
+			op.insert(text)
+			continue
+		}
+		var n int
+		if err := json.Unmarshal(item, &n); err != nil || n == 0 {
+			return ErrInvalidOperation
+		}
+		if n > 0 {
+			op.retain(n)
+		} else {
+			op.delete(-n)
+		}
+	}
+	*o = op
+	return nil
+}
+
+func (o Operation) MarshalJSON() ([]byte, error) {
+	items := make([]any, 0, len(o))
+	for _, c := range o {
+		switch {
+		case c.retain > 0:
+			items = append(items, c.retain)
+		case c.insert != "":
+			items = append(items, c.insert)
+		default:
+			items = append(items, -c.delete)
+		}
+	}
+	return json.Marshal(items)
+}
+
+func (o *Operation) retain(n int) {
+	if n <= 0 {
+		return
+	}
+	if last := len(*o) - 1; last >= 0 && (*o)[last].retain > 0 {
+		(*o)[last].retain += n
+		return
+	}
+	*o = append(*o, component{retain: n})
+}
+
+// insert keeps inserts ahead of deletes at the same place, so equal edits
+// always come out the same
+func (o *Operation) insert(s string) {
+	if s == "" {
+		return
+	}
+	ops := *o
+	last := len(ops) - 1
+	switch {
+	case last >= 0 && ops[last].insert != "":
+		ops[last].insert += s
+	case last >= 0 && ops[last].delete > 0:
+		if last > 0 && ops[last-1].insert != "" {
+			ops[last-1].insert += s
+		} else {
+			ops = append(ops[:last], component{insert: s}, ops[last])
+		}
+	default:
+		ops = append(ops, component{insert: s})
+	}
+	*o = ops
+}
+
+func (o *Operation) delete(n int) {
+	if n <= 0 {
+		return
+	}
+	if last := len(*o) - 1; last >= 0 && (*o)[last].delete > 0 {
+		(*o)[last].delete += n
+		return
+	}
+	*o = append(*o, component{delete: n})
+}
+
+// BaseLength is the length of the documents the operation applies to
+func (o Operation) BaseLength() int {
+	n := 0
+	for _, c := range o {
+		n += c.retain + c.delete
+	}
+	return n
+}
+
+// TargetLength is the length of the documents the operation makes
+func (o Operation) TargetLength() int {
+	n := 0
+	for _, c := range o {
+		n += c.retain + c.insertLength()
+	}
+	return n
+}
+
+// Apply edits a document
+func (o Operation) Apply(document string) (string, error) {
+	runes := []rune(document)
+	if len(runes) != o.BaseLength() {
+		return "", ErrInvalidOperation
+	}
+
+	out := make([]rune, 0, o.TargetLength())
+	i := 0
+	for _, c := range o {
+		switch {
+		case c.retain > 0:
+			out = append(out, runes[i:i+c.retain]...)
+			i += c.retain
+		case c.insert != "":
+			out = append(out, []rune(c.insert)...)
+		default:
+			i += c.delete
+		}
+	}
+	return string(out), nil
+}
+
+// Transform rewrites two operations made concurrently on the same document
+// so that a then b' edits it the same as b then a'. When both insert at the
+// same place, a's insert comes first.
+func Transform(a, b Operation) (Operation, Operation, error) {
+	if a.BaseLength() != b.BaseLength() {
+		return nil, nil, ErrInvalidOperation
+	}
+
+	var aPrime, bPrime Operation
+	i, j := 0, 0
+	var c1, c2 *component
+	next := func(o Operation, k *int) *component {
+		if *k >= len(o) {
+			return nil
+		}
+		c := o[*k]
+		*k++
+		return &c
+	}
+	c1, c2 = next(a, &i), next(b, &j)
+
+	for c1 != nil || c2 != nil {
+		if c1 != nil && c1.insert != "" {
+			aPrime.insert(c1.insert)
+			bPrime.retain(c1.insertLength())
+			c1 = next(a, &i)
+			continue
+		}
+		if c2 != nil && c2.insert != "" {
+			aPrime.retain(c2.insertLength())
+			bPrime.insert(c2.insert)
+			c2 = next(b, &j)
+			continue
+		}
+		if c1 == nil || c2 == nil {
+			return nil, nil, ErrInvalidOperation
+		}
+
+		n1, n2 := c1.retain+c1.delete, c2.retain+c2.delete
+		n := min(n1, n2)
+		switch {
+		case c1.retain > 0 && c2.retain > 0:
+			aPrime.retain(n)
+			bPrime.retain(n)
+		case c1.delete > 0 && c2.retain > 0:
+			aPrime.delete(n)
+		case c1.retain > 0 && c2.delete > 0:
+			bPrime.delete(n)
+		}
+		// both deleting the same text leaves nothing for either to do
+
+		c1, c2 = shorten(c1, n, a, &i, next), shorten(c2, n, b, &j, next)
+	}
+	return aPrime, bPrime, nil
+}
+
+// shorten takes n off the front of a retain or delete, moving on to the next
+// component once it's used up
+func shorten(c *component, n int, o Operation, k *int, next func(Operation, *int) *component) *component {
+	if c.retain > 0 {
+		c.retain -= n
+		if c.retain > 0 {
+			return c
+		}
+	} else {
+		c.delete -= n
+		if c.delete > 0 {
+			return c
+		}
+	}
+	return next(o, k)
+}
@@ -0,0 +1,92 @@
+package liveedit
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/tutors"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// Clients subscribe to a live edit's topic and send their edits as
+// operations on the revision they last saw, applying the events of others'
+// as they come and transforming their own pending ones against them
+func RegisterRoutes(g *api.Group, liveeditService Service) {
+	g.POST("/live-edits", "Start editing the corrections of an entry live with the learner or tutor, or rejoin the open edit", StartRequest{}, LiveEdit{}, func(e *core.RequestEvent) error {
+		var req StartRequest
+		if err := e.BindBody(&req); err != nil {
+			return e.BadRequestError("Invalid request body.", err)
+		}
+		if err := req.Validate(); err != nil {
+			return e.BadRequestError("Invalid live edit.", err)
+		}
+
+		edit, err := liveeditService.Start(e.Auth.Id, req)
+		switch {
+		case errors.Is(err, tutors.ErrNotTutor):
+			return e.BadRequestError("Invalid live edit.", validation.Errors{
+				"tutor": validation.NewError("validation_invalid_value", "Not one of your tutors who can correct entries."),
+			})
+		case errors.Is(err, sql.ErrNoRows):
+			return e.NotFoundError("", err)
+		case err != nil:
+			return e.InternalServerError("Failed to start the live edit.", err)
+		}
+		return e.JSON(200, edit)
+	})
+
+	g.GET("/live-edits/{id}", "Live edit with its document as edited so far", LiveEdit{}, func(e *core.RequestEvent) error {
+		edit, err := liveeditService.Find(e.Auth.Id, e.Request.PathValue("id"))
+		if err != nil {
+			return e.NotFoundError("", err)
+		}
+		return e.JSON(200, edit)
+	})
+
+	g.POST("/live-edits/{id}/operations", "Apply an edit made on a revision of the document, returning it as applied", OperationRequest{}, OperationResult{}, func(e *core.RequestEvent) error {
+		var req OperationRequest
+		if err := e.BindBody(&req); err != nil {
+			return e.BadRequestError("Invalid request body.", err)
+		}
+		if err := req.Validate(); err != nil {
+			return e.BadRequestError("Invalid operation.", err)
+		}
+
+		result, err := liveeditService.Apply(e.Auth.Id, e.Request.PathValue("id"), req)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return e.NotFoundError("", err)
+		case errors.Is(err, ErrEnded):
+			return e.Error(http.StatusConflict, "The live edit has ended.", err)
+		case errors.Is(err, ErrStale):
+			return e.Error(http.StatusConflict, "The revision is too old, load the document again.", err)
+		case errors.Is(err, ErrInvalidOperation):
+			return e.BadRequestError("The operation doesn't fit the document.", err)
+		case errors.Is(err, ErrTooLong):
+			return e.BadRequestError("The document is too long.", err)
+		case err != nil:
+			return e.InternalServerError("Failed to apply the operation.", err)
+		}
+		return e.JSON(200, result)
+	})
+
+	g.POST("/live-edits/{id}/end", "Import the annotations written on the document as corrections and end the live edit", nil, LiveEdit{}, func(e *core.RequestEvent) error {
+		edit, err := liveeditService.End(e.Auth.Id, e.Request.PathValue("id"))
+		var invalid validation.Errors
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return e.NotFoundError("", err)
+		case errors.Is(err, ErrEnded):
+			return e.Error(http.StatusConflict, "The live edit has ended.", err)
+		case errors.As(err, &invalid):
+			return e.BadRequestError("Invalid annotations.", invalid)
+		case err != nil:
+			return e.InternalServerError("Failed to import the annotations.", err)
+		}
+		return e.JSON(200, edit)
+	})
+}
@@ -0,0 +1,317 @@
+package liveedit
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/tutors"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/subscriptions"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+const (
+	// maxDocumentLength bounds what edits can grow a document to
+	maxDocumentLength = 200000
+
+	// maxHistory is how many operations are kept to transform late ones
+	// against, clients further behind have to load the document again
+	maxHistory = 1000
+
+	// snapshotInterval is how often the document is saved while it's edited
+	snapshotInterval = 5 * time.Second
+)
+
+var (
+	// ErrEnded means the live edit's annotations were imported already
+	ErrEnded = errors.New("the live edit has ended")
+
+	// ErrStale means an operation was made on a revision too old to
+	// transform, or one that doesn't exist yet
+	ErrStale = errors.New("unknown revision")
+
+	// ErrTooLong means an operation would make the document too long
+	ErrTooLong = errors.New("document too long")
+)
+
+type Service interface {
+	// Start opens a live edit of the entry between the learner and a tutor
+	// who can correct their entries, rendering a tutor session for it. It
+	// returns the one still open for the two of them if there is one.
+	Start(userId string, req StartRequest) (LiveEdit, error)
+
+	// Find returns a live edit the user takes part in, as edited so far
+	Find(userId string, id string) (LiveEdit, error)
+
+	// Apply applies an operation of the user's to the document, transformed
+	// against those applied since its revision, and publishes it
+	Apply(userId string, id string, req OperationRequest) (OperationResult, error)
+
+	// End imports the annotations written on the document as corrections of
+	// the entry and answers, closing the live edit
+	End(userId string, id string) (LiveEdit, error)
+
+	// CanFollow reports whether the user can subscribe to a live edit's
+	// topic
+	CanFollow(userId string, id string) bool
+}
+
+// document is a live edit being edited
+type document struct {
+	mu   sync.Mutex
+	edit LiveEdit
+
+	// history holds the operations applied since revision first
+	history []Operation
+	first   int
+
+	saved time.Time
+}
+
+type service struct {
+	app           core.App
+	tutorsService tutors.Service
+
+	mu        sync.Mutex
+	documents map[string]*document
+}
+
+func NewService(app core.App, tutorsService tutors.Service) Service {
+	return &service{app: app, tutorsService: tutorsService, documents: map[string]*document{}}
+}
+
+func (s *service) Start(userId string, req StartRequest) (LiveEdit, error) {
+	entry, err := s.app.FindRecordById("journal_entry", req.Entry)
+	if err != nil {
+		return LiveEdit{}, err
+	}
+	learner, tutor := entry.GetString("user"), req.Tutor
+	if userId != learner {
+		// tutors start them as themselves, on entries they can correct
+		if tutor != "" && tutor != userId {
+			return LiveEdit{}, tutors.ErrNotTutor
+		}
+		tutor = userId
+	}
+	_, err = s.app.FindFirstRecordByFilter(
+		"tutors",
+		"user = {:user} && tutor = {:tutor} && can_correct = true",
+		dbx.Params{"user": learner, "tutor": tutor},
+	)
+	switch {
+	case err != nil && userId != learner:
+		return LiveEdit{}, sql.ErrNoRows
+	case err != nil:
+		return LiveEdit{}, tutors.ErrNotTutor
+	}
+
+	open, err := s.app.FindFirstRecordByFilter(
+		"live_edits",
+		"journal_entry = {:entry} && tutor = {:tutor} && ended = ''",
+		dbx.Params{"entry": entry.Id, "tutor": tutor},
+	)
+	if err == nil {
+		return s.Find(userId, open.Id)
+	}
+
+	session, err := s.tutorsService.CreateSession(learner, tutors.SessionRequest{Entries: []string{entry.Id}, Tutor: tutor})
+	if err != nil {
+		return LiveEdit{}, err
+	}
+	data, err := s.tutorsService.Document(session)
+	if err != nil {
+		return LiveEdit{}, err
+	}
+
+	collection, err := s.app.FindCollectionByNameOrId("live_edits")
+	if err != nil {
+		return LiveEdit{}, err
+	}
+	rec := core.NewRecord(collection)
+	rec.Set("user", learner)
+	rec.Set("tutor", tutor)
+	rec.Set("tutor_session", session.Id)
+	rec.Set("journal_entry", entry.Id)
+	rec.Set("document", string(data))
+	rec.Set("revision", 0)
+	if err := s.app.Save(rec); err != nil {
+		return LiveEdit{}, err
+	}
+	return FromRecord(rec), nil
+}
+
+func (s *service) Find(userId string, id string) (LiveEdit, error) {
+	rec, err := s.app.FindRecordById("live_edits", id)
+	if err != nil {
+		return LiveEdit{}, err
+	}
+	edit := FromRecord(rec)
+	if !edit.participant(userId) {
+		return LiveEdit{}, sql.ErrNoRows
+	}
+
+	// the saved document lags behind the one being edited
+	s.mu.Lock()
+	doc, ok := s.documents[id]
+	s.mu.Unlock()
+	if ok {
+		doc.mu.Lock()
+		edit = doc.edit
+		doc.mu.Unlock()
+	}
+	return edit, nil
+}
+
+func (s *service) Apply(userId string, id string, req OperationRequest) (OperationResult, error) {
+	doc, err := s.load(userId, id)
+	if err != nil {
+		return OperationResult{}, err
+	}
+	doc.mu.Lock()
+	defer doc.mu.Unlock()
+	if !doc.edit.Ended.IsZero() {
+		return OperationResult{}, ErrEnded
+	}
+	if req.Revision < doc.first || req.Revision > doc.edit.Revision {
+		return OperationResult{}, ErrStale
+	}
+
+	op := req.Operation
+	for _, concurrent := range doc.history[req.Revision-doc.first:] {
+		if op, _, err = Transform(op, concurrent); err != nil {
+			return OperationResult{}, err
+		}
+	}
+	text, err := op.Apply(doc.edit.Document)
+	if err != nil {
+		return OperationResult{}, err
+	}
+	if utf8.RuneCountInString(text) > maxDocumentLength {
+		return OperationResult{}, ErrTooLong
+	}
+
+	doc.edit.Document = text
+	doc.edit.Revision++
+	doc.history = append(doc.history, op)
+	if len(doc.history) > maxHistory {
+		doc.history = doc.history[1:]
+		doc.first++
+	}
+	if time.Since(doc.saved) >= snapshotInterval {
+		if err := s.save(doc, types.DateTime{}); err != nil {
+			s.app.Logger().Error("Failed to save a live edit", "live_edit", id, "error", err)
+		}
+	}
+
+	s.publish(id, Event{Type: EventOperation, Revision: doc.edit.Revision, Operation: op, Author: userId, Client: req.Client})
+	return OperationResult{Revision: doc.edit.Revision, Operation: op}, nil
+}
+
+func (s *service) End(userId string, id string) (LiveEdit, error) {
+	doc, err := s.load(userId, id)
+	if err != nil {
+		return LiveEdit{}, err
+	}
+	doc.mu.Lock()
+	defer doc.mu.Unlock()
+	if !doc.edit.Ended.IsZero() {
+		return LiveEdit{}, ErrEnded
+	}
+
+	session, err := s.tutorsService.Find(doc.edit.User, doc.edit.TutorSession)
+	if err != nil {
+		return LiveEdit{}, err
+	}
+	annotations := s.tutorsService.ParseDocument([]byte(doc.edit.Document))
+	if err := annotations.Validate(session); err != nil {
+		return LiveEdit{}, err
+	}
+	if _, err := s.tutorsService.Annotate(session, annotations); err != nil {
+		return LiveEdit{}, err
+	}
+
+	if err := s.save(doc, types.NowDateTime()); err != nil {
+		return LiveEdit{}, err
+	}
+	s.mu.Lock()
+	delete(s.documents, id)
+	s.mu.Unlock()
+
+	s.publish(id, Event{Type: EventEnded, Revision: doc.edit.Revision})
+	return doc.edit, nil
+}
+
+func (s *service) CanFollow(userId string, id string) bool {
+	_, err := s.Find(userId, id)
+	return err == nil
+}
+
+// load returns the document of a live edit the user takes part in, loading
+// it when it isn't being edited yet
+func (s *service) load(userId string, id string) (*document, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	doc, ok := s.documents[id]
+	if !ok {
+		rec, err := s.app.FindRecordById("live_edits", id)
+		if err != nil {
+			return nil, err
+		}
+		edit := FromRecord(rec)
+		doc = &document{edit: edit, first: edit.Revision, saved: time.Now()}
+		if edit.Ended.IsZero() {
+			s.documents[id] = doc
+		}
+	}
+	if !doc.edit.participant(userId) {
+		return nil, sql.ErrNoRows
+	}
+	return doc, nil
+}
+
+// save writes the document as edited so far, and when it ended if it did
+func (s *service) save(doc *document, ended types.DateTime) error {
+	rec, err := s.app.FindRecordById("live_edits", doc.edit.Id)
+	if err != nil {
+		return err
+	}
+	rec.Set("document", doc.edit.Document)
+	rec.Set("revision", doc.edit.Revision)
+	if !ended.IsZero() {
+		rec.Set("ended", ended)
+	}
+	if err := s.app.Save(rec); err != nil {
+		return err
+	}
+	doc.edit = FromRecord(rec)
+	doc.saved = time.Now()
+	return nil
+}
+
+// publish sends an event to the clients following the live edit
+func (s *service) publish(id string, event Event) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+
+	topic := Topic(id)
+	message := subscriptions.Message{Name: topic, Data: data}
+	for _, client := range s.app.SubscriptionsBroker().Clients() {
+		if !client.HasSubscription(topic) {
+			continue
+		}
+		if auth, _ := client.Get(apis.RealtimeClientAuthKey).(*core.Record); auth == nil {
+			continue
+		}
+		client.Send(message)
+	}
+}
@@ -0,0 +1,59 @@
+package maintenance
+
+import (
+	"os"
+	"strings"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// Schedule is when each task runs, as cron expressions. An empty one turns
+// the task off.
+type Schedule map[string]string
+
+// defaultSchedule runs the cheap tasks nightly and the vacuum weekly, at
+// hours the backups (sunday midnight) and cleanups (3:30, 4:00) don't use
+var defaultSchedule = Schedule{
+	TaskCheckpoint: "0 1 * * *",
+	TaskAnalyze:    "15 1 * * *",
+	TaskVacuum:     "0 5 * * 0",
+}
+
+// ScheduleFromEnv reads MAINTENANCE_CHECKPOINT_CRON, MAINTENANCE_ANALYZE_CRON
+// and MAINTENANCE_VACUUM_CRON, where "off" turns a task off
+func ScheduleFromEnv() Schedule {
+	schedule := Schedule{}
+	for task, expr := range defaultSchedule {
+		switch value := os.Getenv("MAINTENANCE_" + strings.ToUpper(task) + "_CRON"); value {
+		case "":
+			schedule[task] = expr
+		case "off":
+			schedule[task] = ""
+		default:
+			schedule[task] = value
+		}
+	}
+	return schedule
+}
+
+func BindHooks(app core.App, maintenanceService Service, schedule Schedule) {
+	// PocketBase checkpoints and optimizes at midnight on its own, these
+	// tasks take that over on a schedule self-hosters control
+	app.Cron().Remove("__pbDBOptimize__")
+
+	for _, task := range Tasks {
+		expr := schedule[task]
+		if expr == "" {
+			continue
+		}
+
+		err := app.Cron().Add("fushigiMaintenance_"+task, expr, func() {
+			if _, err := maintenanceService.Run(task); err != nil {
+				app.Logger().Error("Failed to start database maintenance", "task", task, "error", err)
+			}
+		})
+		if err != nil {
+			app.Logger().Error("Invalid database maintenance schedule", "task", task, "schedule", expr, "error", err)
+		}
+	}
+}
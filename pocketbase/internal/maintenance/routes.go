@@ -0,0 +1,25 @@
+package maintenance
+
+import (
+	"errors"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/jobs"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// RegisterRoutes lets superusers run a task right away, e.g. a vacuum after
+// deleting a lot of content. The job can be followed in the jobs collection.
+func RegisterRoutes(g *api.Group, maintenanceService Service) {
+	g.POST("/maintenance/{task}", "Run a database maintenance task (checkpoint, analyze or vacuum) now", nil, jobs.Job{}, func(e *core.RequestEvent) error {
+		job, err := maintenanceService.Run(e.Request.PathValue("task"))
+		if errors.Is(err, ErrUnknownTask) {
+			return e.NotFoundError("No such maintenance task.", err)
+		}
+		if err != nil {
+			return e.InternalServerError("Failed to start the maintenance task.", err)
+		}
+		return e.JSON(200, job)
+	})
+}
@@ -0,0 +1,49 @@
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/jobs"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// JobKindPrefix prefixes the kind of maintenance jobs, e.g. maintenance_vacuum
+const JobKindPrefix = "maintenance_"
+
+var ErrUnknownTask = errors.New("unknown maintenance task")
+
+type Service interface {
+	// Run starts a task as a system job, so it shows up in the dashboard's
+	// job health whether it was scheduled or triggered by an admin
+	Run(task string) (jobs.Job, error)
+}
+
+type service struct {
+	app         core.App
+	jobsService jobs.Service
+
+	// tasks run one at a time, a vacuum and a checkpoint fight over the
+	// same lock anyway
+	mu sync.Mutex
+}
+
+func NewService(app core.App, jobsService jobs.Service) Service {
+	return &service{app: app, jobsService: jobsService}
+}
+
+func (s *service) Run(task string) (jobs.Job, error) {
+	if !slices.Contains(Tasks, task) {
+		return jobs.Job{}, ErrUnknownTask
+	}
+
+	return s.jobsService.Enqueue("", JobKindPrefix+task, func(ctx context.Context, jobId string, progress jobs.Progress) (any, error) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		return run(s.app, task)
+	})
+}
@@ -0,0 +1,70 @@
+package maintenance
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// Tasks keep long lived SQLite files healthy, each on both the data and the
+// auxiliary (logs) database
+const (
+	// TaskCheckpoint moves the write-ahead log back into the database and
+	// truncates it, which otherwise only shrinks when nothing is reading
+	TaskCheckpoint = "checkpoint"
+
+	// TaskAnalyze refreshes the statistics the query planner picks indexes by
+	TaskAnalyze = "analyze"
+
+	// TaskVacuum rebuilds the databases to reclaim the space of deleted rows.
+	// It blocks writes while it runs and needs as much free disk as the
+	// database takes.
+	TaskVacuum = "vacuum"
+)
+
+// Tasks lists every task, in the order they are best run in
+var Tasks = []string{TaskCheckpoint, TaskAnalyze, TaskVacuum}
+
+// Result is what a task run reports, sizes in bytes of both databases
+// including their write-ahead logs
+type Result struct {
+	Task   string `json:"task"`
+	Before int64  `json:"before"`
+	After  int64  `json:"after"`
+}
+
+const checkpoint = "PRAGMA wal_checkpoint(TRUNCATE)"
+
+var statements = map[string][]string{
+	TaskCheckpoint: {checkpoint},
+	TaskAnalyze:    {"ANALYZE"},
+	// in WAL mode the rebuilt database lands in the log first
+	TaskVacuum: {"VACUUM", checkpoint},
+}
+
+func run(app core.App, task string) (Result, error) {
+	result := Result{Task: task, Before: databasesSize(app)}
+
+	for _, statement := range statements[task] {
+		if _, err := app.NonconcurrentDB().NewQuery(statement).Execute(); err != nil {
+			return Result{}, err
+		}
+		if _, err := app.AuxNonconcurrentDB().NewQuery(statement).Execute(); err != nil {
+			return Result{}, err
+		}
+	}
+
+	result.After = databasesSize(app)
+	return result, nil
+}
+
+func databasesSize(app core.App) int64 {
+	var total int64
+	for _, name := range []string{"data.db", "data.db-wal", "auxiliary.db", "auxiliary.db-wal"} {
+		if info, err := os.Stat(filepath.Join(app.DataDir(), name)); err == nil {
+			total += info.Size()
+		}
+	}
+	return total
+}
@@ -0,0 +1,109 @@
+package mcp
+
+import (
+	"encoding/json"
+	"slices"
+)
+
+// protocolVersions are the MCP revisions the server speaks, newest first.
+// Tools are all it offers, which none of them changed.
+var protocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// JSON-RPC 2.0 error codes
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeInternalError  = -32603
+)
+
+// instructions tell assistants what the server is for when they connect
+const instructions = "Fushigi is the user's Japanese study app. Use it to look up grammar they can study, see what they have left to review today and save journal drafts for them to finish. Drafts stay private until the user shares them."
+
+// request is a JSON-RPC request, or a notification when it has no id
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Id      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+func (r request) isNotification() bool {
+	return len(r.Id) == 0
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Id      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func result(id json.RawMessage, result any) response {
+	return response{JSONRPC: "2.0", Id: id, Result: result}
+}
+
+func failure(id json.RawMessage, code int, message string) response {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	return response{JSONRPC: "2.0", Id: id, Error: &rpcError{Code: code, Message: message}}
+}
+
+type initializeParams struct {
+	ProtocolVersion string `json:"protocolVersion"`
+}
+
+type initializeResult struct {
+	ProtocolVersion string         `json:"protocolVersion"`
+	Capabilities    map[string]any `json:"capabilities"`
+	ServerInfo      serverInfo     `json:"serverInfo"`
+	Instructions    string         `json:"instructions"`
+}
+
+type serverInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// negotiate answers the version a client asked for with it when the server
+// speaks it, and otherwise with the newest the server does
+func negotiate(version string) string {
+	if slices.Contains(protocolVersions, version) {
+		return version
+	}
+	return protocolVersions[0]
+}
+
+type toolsListResult struct {
+	Tools []Tool `json:"tools"`
+}
+
+type toolsCallParams struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+// ToolResult is what a tool call returns. Failures the assistant can do
+// something about, like invalid arguments, are results with IsError set so
+// it sees them.
+type ToolResult struct {
+	Content []Content `json:"content"`
+	IsError bool      `json:"isError"`
+}
+
+// Content is one part of a tool result, only ever text here
+type Content struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+func textResult(text string, isError bool) ToolResult {
+	return ToolResult{Content: []Content{{Type: "text", Text: text}}, IsError: isError}
+}
@@ -0,0 +1,148 @@
+package mcp
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/auth"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// maxMessageSize bounds the JSON-RPC messages assistants send
+const maxMessageSize = 1 << 20
+
+func RegisterRoutes(g *api.Group, mcpService Service) {
+	g.GET("/mcp/tokens", "The tokens the user's assistants connect to the MCP server with, newest first", []Token{}, func(e *core.RequestEvent) error {
+		tokens, err := mcpService.Tokens(e.Auth.Id)
+		if err != nil {
+			return e.InternalServerError("Failed to load MCP tokens.", err)
+		}
+		return e.JSON(200, tokens)
+	})
+
+	g.POST("/mcp/tokens", "Make a token for an assistant to connect to the MCP server with, its secret shown this once only", TokenRequest{}, NewToken{}, func(e *core.RequestEvent) error {
+		var req TokenRequest
+		if err := e.BindBody(&req); err != nil {
+			return e.BadRequestError("Invalid request body.", err)
+		}
+		if err := req.Validate(); err != nil {
+			return e.BadRequestError("Invalid MCP token.", err)
+		}
+
+		token, err := mcpService.CreateToken(e.Auth.Id, req)
+		switch {
+		case errors.Is(err, ErrDisabled):
+			return e.BadRequestError("The MCP server is turned off.", err)
+		case errors.Is(err, ErrTooMany):
+			return e.BadRequestError("You have too many MCP tokens, revoke one first.", err)
+		case err != nil:
+			return e.InternalServerError("Failed to create the MCP token.", err)
+		}
+		return e.JSON(200, token)
+	})
+
+	g.DELETE("/mcp/tokens/{id}", "Revoke an MCP token, refusing the assistant using it", func(e *core.RequestEvent) error {
+		if err := mcpService.RevokeToken(e.Auth.Id, e.Request.PathValue("id")); err != nil {
+			return e.NotFoundError("", err)
+		}
+		return e.NoContent(204)
+	})
+}
+
+// RegisterPublicRoutes adds the MCP server, which assistants call with an MCP
+// token in place of a login. It speaks the streamable HTTP transport without
+// streaming: every message is answered with plain JSON.
+func RegisterPublicRoutes(g *api.Group, mcpService Service) {
+	g.POST("/mcp", "MCP server for assistants holding an MCP token, one JSON-RPC message per request", request{}, response{}, func(e *core.RequestEvent) error {
+		user, token, err := mcpService.Authenticate(auth.RequestToken(e))
+		switch {
+		case errors.Is(err, ErrDisabled):
+			return e.NotFoundError("", err)
+		case errors.Is(err, sql.ErrNoRows):
+			e.Response.Header().Set("WWW-Authenticate", "Bearer")
+			return e.UnauthorizedError("The request requires a valid MCP token.", err)
+		case err != nil:
+			return e.InternalServerError("Failed to check the MCP token.", err)
+		}
+		// logged as the user the assistant acts for
+		e.Auth = user
+
+		if version := e.Request.Header.Get("MCP-Protocol-Version"); version != "" && !slices.Contains(protocolVersions, version) {
+			return e.BadRequestError("Unsupported MCP protocol version.", nil)
+		}
+
+		body, err := io.ReadAll(io.LimitReader(e.Request.Body, maxMessageSize))
+		if err != nil {
+			return e.BadRequestError("Invalid request body.", err)
+		}
+		// batches were dropped from the protocol, clients send one message at a time
+		if bytes.HasPrefix(bytes.TrimSpace(body), []byte("[")) {
+			return e.JSON(400, failure(nil, codeInvalidRequest, "Batches are not supported"))
+		}
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			return e.JSON(400, failure(nil, codeParseError, "Parse error"))
+		}
+		if req.JSONRPC != "2.0" || req.Method == "" {
+			return e.JSON(400, failure(req.Id, codeInvalidRequest, "Invalid request"))
+		}
+
+		// notifications, like the client saying it's initialized, need no answer
+		if req.isNotification() {
+			return e.NoContent(202)
+		}
+		return e.JSON(200, handle(e.App, mcpService, user, token, req))
+	})
+
+	g.GET("/mcp", "MCP servers may stream messages from here, this one doesn't", nil, func(e *core.RequestEvent) error {
+		e.Response.Header().Set("Allow", http.MethodPost)
+		return e.Error(http.StatusMethodNotAllowed, "The MCP server doesn't stream, send messages with POST.", nil)
+	})
+}
+
+// handle answers one JSON-RPC request
+func handle(app core.App, mcpService Service, user *core.Record, token Token, req request) response {
+	switch req.Method {
+	case "initialize":
+		var params initializeParams
+		if err := json.Unmarshal(req.Params, &params); len(req.Params) > 0 && err != nil {
+			return failure(req.Id, codeInvalidParams, "Invalid params")
+		}
+		return result(req.Id, initializeResult{
+			ProtocolVersion: negotiate(params.ProtocolVersion),
+			Capabilities:    map[string]any{"tools": map[string]any{}},
+			ServerInfo:      serverInfo{Name: "fushigi", Version: api.Versions[len(api.Versions)-1].Name},
+			Instructions:    instructions,
+		})
+
+	case "ping":
+		return result(req.Id, struct{}{})
+
+	case "tools/list":
+		return result(req.Id, toolsListResult{Tools: mcpService.Tools(token)})
+
+	case "tools/call":
+		var params toolsCallParams
+		if err := json.Unmarshal(req.Params, &params); err != nil || params.Name == "" {
+			return failure(req.Id, codeInvalidParams, "Invalid params")
+		}
+		res, err := mcpService.CallTool(user, token, params.Name, params.Arguments)
+		switch {
+		case errors.Is(err, ErrUnknownTool):
+			return failure(req.Id, codeInvalidParams, "Unknown tool: "+params.Name)
+		case err != nil:
+			app.Logger().Error("Failed to call MCP tool", "tool", params.Name, "user", user.Id, "error", err)
+			return failure(req.Id, codeInternalError, "Internal error")
+		}
+		return result(req.Id, res)
+	}
+
+	return failure(req.Id, codeMethodNotFound, "Method not found: "+req.Method)
+}
@@ -0,0 +1,263 @@
+package mcp
+
+import (
+	"cmp"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/grammar"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/journal"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/settings"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/srs"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+var (
+	// ErrDisabled means the MCP server is turned off
+	ErrDisabled = errors.New("the MCP server is turned off")
+
+	// ErrTooMany means the user has MaxTokens tokens already
+	ErrTooMany = errors.New("too many tokens")
+
+	// ErrUnknownTool means there is no such tool, or none the token can use
+	ErrUnknownTool = errors.New("unknown tool")
+)
+
+// EnabledFromEnv reads MCP_ENABLED, the server being off unless it's true
+func EnabledFromEnv() bool {
+	on, _ := strconv.ParseBool(os.Getenv("MCP_ENABLED"))
+	return on
+}
+
+type Service interface {
+	// Enabled reports whether assistants can connect
+	Enabled() bool
+
+	// Tokens returns the user's tokens, newest first
+	Tokens(userId string) ([]Token, error)
+
+	// CreateToken makes a token for an assistant to connect with
+	CreateToken(userId string, req TokenRequest) (NewToken, error)
+
+	// RevokeToken deletes one of the user's tokens, assistants using it are
+	// refused from then on
+	RevokeToken(userId string, id string) error
+
+	// Authenticate finds the user a token's secret was made for, noting the
+	// token was used
+	Authenticate(secret string) (*core.Record, Token, error)
+
+	// Tools lists the tools the token can use
+	Tools(token Token) []Tool
+
+	// CallTool runs one of the tools the token can use as the user
+	CallTool(user *core.Record, token Token, name string, arguments json.RawMessage) (ToolResult, error)
+}
+
+type service struct {
+	app             core.App
+	enabled         bool
+	grammarService  grammar.Service
+	srsService      srs.Service
+	settingsService settings.Service
+}
+
+func NewService(app core.App, enabled bool, grammarService grammar.Service, srsService srs.Service, settingsService settings.Service) Service {
+	return &service{app: app, enabled: enabled, grammarService: grammarService, srsService: srsService, settingsService: settingsService}
+}
+
+func (s *service) Enabled() bool {
+	return s.enabled
+}
+
+func (s *service) Tokens(userId string) ([]Token, error) {
+	records, err := s.app.FindRecordsByFilter("mcp_tokens", "user = {:user}", "-created", 0, 0, dbx.Params{"user": userId})
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := make([]Token, 0, len(records))
+	for _, rec := range records {
+		tokens = append(tokens, FromRecord(rec))
+	}
+	return tokens, nil
+}
+
+func (s *service) CreateToken(userId string, req TokenRequest) (NewToken, error) {
+	if !s.enabled {
+		return NewToken{}, ErrDisabled
+	}
+	count, err := s.app.CountRecords("mcp_tokens", dbx.HashExp{"user": userId})
+	if err != nil {
+		return NewToken{}, err
+	}
+	if count >= MaxTokens {
+		return NewToken{}, ErrTooMany
+	}
+
+	collection, err := s.app.FindCollectionByNameOrId("mcp_tokens")
+	if err != nil {
+		return NewToken{}, err
+	}
+	secret := newSecret()
+	rec := core.NewRecord(collection)
+	rec.Set("user", userId)
+	rec.Set("name", strings.TrimSpace(req.Name))
+	rec.Set("token_hash", hashToken(secret))
+	rec.Set("scopes", slices.Compact(slices.Sorted(slices.Values(req.Scopes))))
+	if err := s.app.Save(rec); err != nil {
+		return NewToken{}, err
+	}
+	return NewToken{Token: FromRecord(rec), Secret: secret}, nil
+}
+
+func (s *service) RevokeToken(userId string, id string) error {
+	rec, err := s.app.FindFirstRecordByFilter("mcp_tokens", "id = {:id} && user = {:user}", dbx.Params{"id": id, "user": userId})
+	if err != nil {
+		return err
+	}
+	return s.app.Delete(rec)
+}
+
+func (s *service) Authenticate(secret string) (*core.Record, Token, error) {
+	if !s.enabled {
+		return nil, Token{}, ErrDisabled
+	}
+	if !strings.HasPrefix(secret, tokenPrefix) {
+		return nil, Token{}, sql.ErrNoRows
+	}
+	rec, err := s.app.FindFirstRecordByData("mcp_tokens", "token_hash", hashToken(secret))
+	if err != nil {
+		return nil, Token{}, err
+	}
+	user, err := s.app.FindRecordById("users", rec.GetString("user"))
+	if err != nil {
+		return nil, Token{}, err
+	}
+
+	if time.Since(rec.GetDateTime("last_used").Time()) >= lastUsedInterval {
+		rec.Set("last_used", types.NowDateTime())
+		if err := s.app.Save(rec); err != nil {
+			s.app.Logger().Error("Failed to note MCP token use", "token", rec.Id, "error", err)
+		}
+	}
+	return user, FromRecord(rec), nil
+}
+
+func (s *service) Tools(token Token) []Tool {
+	allowed := []Tool{}
+	for _, tool := range tools {
+		if token.Allows(tool.Scope) {
+			allowed = append(allowed, tool)
+		}
+	}
+	return allowed
+}
+
+func (s *service) CallTool(user *core.Record, token Token, name string, arguments json.RawMessage) (ToolResult, error) {
+	i := slices.IndexFunc(tools, func(t Tool) bool { return t.Name == name })
+	if i < 0 || !token.Allows(tools[i].Scope) {
+		return ToolResult{}, ErrUnknownTool
+	}
+
+	var out any
+	var err error
+	switch name {
+	case "search_grammar":
+		out, err = s.searchGrammar(user.Id, arguments)
+	case "get_due_reviews":
+		out, err = s.dueReviews(user.Id, arguments)
+	case "create_journal_draft":
+		out, err = s.createJournalDraft(user.Id, arguments)
+	}
+
+	var invalid validation.Errors
+	switch {
+	case errors.As(err, &invalid):
+		return textResult("Invalid arguments: "+strings.TrimSuffix(invalid.Error(), "."), true), nil
+	case err != nil:
+		return ToolResult{}, err
+	}
+
+	text, err := json.Marshal(out)
+	if err != nil {
+		return ToolResult{}, err
+	}
+	return textResult(string(text), false), nil
+}
+
+func (s *service) searchGrammar(userId string, arguments json.RawMessage) (any, error) {
+	var args searchGrammarArgs
+	if err := bind(arguments, &args); err != nil {
+		return nil, err
+	}
+
+	req := grammar.SearchRequest{Query: strings.TrimSpace(args.Query), Kana: true}
+	found, err := s.grammarService.Search(userId, req, api.Page{Limit: cmp.Or(args.Limit, defaultSearchLimit)})
+	if err != nil {
+		return nil, err
+	}
+	items := make([]grammarResult, 0, len(found.Items))
+	for _, g := range found.Items {
+		items = append(items, newGrammarResult(g))
+	}
+	return items, nil
+}
+
+func (s *service) dueReviews(userId string, arguments json.RawMessage) (any, error) {
+	var args dueReviewsArgs
+	if err := bind(arguments, &args); err != nil {
+		return nil, err
+	}
+
+	list, err := srs.Due(s.srsService, s.grammarService, s.settingsService, userId, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return newDueReviewsResult(list, cmp.Or(args.Limit, defaultDueLimit)), nil
+}
+
+// createJournalDraft saves a private entry, which only the user can share
+func (s *service) createJournalDraft(userId string, arguments json.RawMessage) (any, error) {
+	var args journalDraftArgs
+	if err := bind(arguments, &args); err != nil {
+		return nil, err
+	}
+
+	collection, err := s.app.FindCollectionByNameOrId("journal_entry")
+	if err != nil {
+		return nil, err
+	}
+	rec := core.NewRecord(collection)
+	rec.Set("user", userId)
+	rec.Set("title", strings.TrimSpace(args.Title))
+	rec.Set("content", strings.TrimSpace(args.Content))
+	rec.Set("audience", journal.AudiencePrivate)
+	rec.Set("source", draftSource)
+	if err := s.app.Save(rec); err != nil {
+		return nil, err
+	}
+	entry := journal.FromRecord(rec)
+	return journalDraftResult{Id: entry.Id, Title: entry.Title, Audience: entry.Audience}, nil
+}
+
+// bind reads the arguments of a tool call into args and validates them
+func bind(arguments json.RawMessage, args validation.Validatable) error {
+	if len(arguments) > 0 && string(arguments) != "null" {
+		if err := json.Unmarshal(arguments, args); err != nil {
+			return validation.Errors{"arguments": validation.NewError("validation_invalid_value", "Invalid value.")}
+		}
+	}
+	return args.Validate()
+}
@@ -0,0 +1,163 @@
+package mcp
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/grammar"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/journal"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/settings"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/srs"
+	_ "github.com/bunkbed-tech/fushigi/pocketbase/migrations"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+// newTestApp is a migrated app
+func newTestApp(t *testing.T) *tests.TestApp {
+	t.Helper()
+	// the initial migration creates a superuser from these
+	t.Setenv("ADMIN_EMAIL", "admin@example.com")
+	t.Setenv("ADMIN_PASSWORD", "correct horse battery")
+	app, err := tests.NewTestApp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(app.Cleanup)
+	if err := app.RunAllMigrations(); err != nil {
+		t.Fatal(err)
+	}
+	return app
+}
+
+func saveUser(t *testing.T, app core.App, email string) *core.Record {
+	t.Helper()
+	users, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := core.NewRecord(users)
+	user.SetEmail(email)
+	// usernames are unique when set, so every user gets one
+	user.Set("username", strings.Split(email, "@")[0])
+	user.SetPassword("correct horse battery")
+	if err := app.Save(user); err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func newService(app core.App, enabled bool) Service {
+	settingsService := settings.NewService(app)
+	return NewService(app, enabled, grammar.NewService(app), srs.NewService(app), settingsService)
+}
+
+func TestTokens(t *testing.T) {
+	app := newTestApp(t)
+	user := saveUser(t, app, "user@example.com")
+	other := saveUser(t, app, "other@example.com")
+
+	req := TokenRequest{Name: " Desktop assistant ", Scopes: []string{ScopeJournalWrite, ScopeGrammarRead, ScopeGrammarRead}}
+	if _, err := newService(app, false).CreateToken(user.Id, req); !errors.Is(err, ErrDisabled) {
+		t.Errorf("CreateToken() with the server off error = %v, want %v", err, ErrDisabled)
+	}
+
+	service := newService(app, true)
+	created, err := service.CreateToken(user.Id, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(created.Secret, tokenPrefix) || created.Name != "Desktop assistant" || len(created.Scopes) != 2 {
+		t.Errorf("CreateToken() = %+v, want a prefixed secret and the two scopes once", created)
+	}
+
+	tests := []struct {
+		name    string
+		service Service
+		secret  string
+		wantErr bool
+	}{
+		{"secret", service, created.Secret, false},
+		{"server off", newService(app, false), created.Secret, true},
+		{"wrong secret", service, tokenPrefix + "guessed", true},
+		{"auth token", service, strings.TrimPrefix(created.Secret, tokenPrefix), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, token, err := tt.service.Authenticate(tt.secret)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Authenticate() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (got.Id != user.Id || token.Id != created.Id) {
+				t.Errorf("Authenticate() = %s with %s, want %s with %s", got.Id, token.Id, user.Id, created.Id)
+			}
+		})
+	}
+
+	if err := service.RevokeToken(other.Id, created.Id); err == nil {
+		t.Error("RevokeToken() of another user's token succeeded, want an error")
+	}
+	if err := service.RevokeToken(user.Id, created.Id); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := service.Authenticate(created.Secret); err == nil {
+		t.Error("Authenticate() with a revoked token succeeded, want an error")
+	}
+}
+
+func TestCallTool(t *testing.T) {
+	app := newTestApp(t)
+	service := newService(app, true)
+	user := saveUser(t, app, "user@example.com")
+
+	token := Token{Scopes: []string{ScopeJournalWrite}}
+	if tools := service.Tools(token); len(tools) != 1 || tools[0].Name != "create_journal_draft" {
+		t.Errorf("Tools() = %v, want only create_journal_draft", tools)
+	}
+	if _, err := service.CallTool(user, token, "search_grammar", json.RawMessage(`{"query":"ながら"}`)); !errors.Is(err, ErrUnknownTool) {
+		t.Errorf("CallTool() out of scope error = %v, want %v", err, ErrUnknownTool)
+	}
+
+	invalid, err := service.CallTool(user, token, "create_journal_draft", json.RawMessage(`{"title":"今日"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !invalid.IsError {
+		t.Errorf("CallTool() without content = %+v, want an error result", invalid)
+	}
+
+	drafted, err := service.CallTool(user, token, "create_journal_draft", json.RawMessage(`{"title":"今日","content":"晴れでした。"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if drafted.IsError {
+		t.Fatalf("CallTool() = %+v, want a draft", drafted)
+	}
+	entries, err := app.FindAllRecords("journal_entry")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].GetString("user") != user.Id || entries[0].GetString("audience") != journal.AudiencePrivate || entries[0].GetString("source") != draftSource {
+		t.Errorf("journal entries = %v, want one private draft of the user's", entries)
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		version string
+		want    string
+	}{
+		{"2025-03-26", "2025-03-26"},
+		{"2024-11-05", "2024-11-05"},
+		{"2099-01-01", protocolVersions[0]},
+		{"", protocolVersions[0]},
+	}
+	for _, tt := range tests {
+		if got := negotiate(tt.version); got != tt.want {
+			t.Errorf("negotiate(%q) = %q, want %q", tt.version, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package mcp
+
+import (
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/security"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Scopes are what a token lets an assistant do with the user's data
+const (
+	ScopeGrammarRead  = "grammar:read"
+	ScopeReviewsRead  = "reviews:read"
+	ScopeJournalWrite = "journal:write"
+)
+
+var Scopes = []string{ScopeGrammarRead, ScopeReviewsRead, ScopeJournalWrite}
+
+const (
+	// MaxTokens is how many tokens a user can have at once
+	MaxTokens = 10
+
+	maxNameLength = 100
+
+	// tokenPrefix tells MCP tokens apart from auth tokens, for the user
+	// pasting them and for secret scanners
+	tokenPrefix = "fushigi_mcp_"
+	tokenLength = 40
+
+	// lastUsedInterval is how often a token's last use is saved at most
+	lastUsedInterval = 5 * time.Minute
+)
+
+// Token lets an assistant call the MCP server as the user, within its scopes
+type Token struct {
+	Id       string         `json:"id"`
+	Name     string         `json:"name"`
+	Scopes   []string       `json:"scopes"`
+	LastUsed types.DateTime `json:"last_used"`
+	Created  types.DateTime `json:"created"`
+}
+
+func FromRecord(rec *core.Record) Token {
+	return Token{
+		Id:       rec.Id,
+		Name:     rec.GetString("name"),
+		Scopes:   rec.GetStringSlice("scopes"),
+		LastUsed: rec.GetDateTime("last_used"),
+		Created:  rec.GetDateTime("created"),
+	}
+}
+
+// Allows reports whether the token was given scope
+func (t Token) Allows(scope string) bool {
+	return slices.Contains(t.Scopes, scope)
+}
+
+// NewToken is a token just made, the only time its secret is shown
+type NewToken struct {
+	Token
+	Secret string `json:"secret"`
+}
+
+type TokenRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+func (r TokenRequest) Validate() error {
+	errs := validation.Errors{}
+
+	name := strings.TrimSpace(r.Name)
+	switch {
+	case name == "":
+		errs["name"] = validation.NewError("validation_required", "Cannot be blank.")
+	case utf8.RuneCountInString(name) > maxNameLength:
+		errs["name"] = validation.NewError("validation_length_too_long", "The length must be no more than {{.max}}.").
+			SetParams(map[string]any{"max": maxNameLength})
+	}
+
+	if len(r.Scopes) == 0 {
+		errs["scopes"] = validation.NewError("validation_required", "Cannot be blank.")
+	}
+	for _, scope := range r.Scopes {
+		if !slices.Contains(Scopes, scope) {
+			errs["scopes"] = validation.NewError("validation_invalid_value", "Invalid value.")
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func newSecret() string {
+	return tokenPrefix + security.RandomString(tokenLength)
+}
+
+func hashToken(token string) string {
+	return security.SHA256(token)
+}
@@ -0,0 +1,191 @@
+package mcp
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/grammar"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/srs"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// Tool is a tool assistants can call, described the way MCP lists them
+type Tool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	InputSchema map[string]any `json:"inputSchema"`
+
+	// Scope is what a token needs to see and call the tool
+	Scope string `json:"-"`
+}
+
+const (
+	defaultSearchLimit = 10
+	maxSearchLimit     = 50
+
+	defaultDueLimit = 20
+	maxDueLimit     = 100
+
+	maxDraftTitleLength   = 200
+	maxDraftContentLength = 20000
+
+	// draftSource marks entries an assistant drafted, so the user can tell
+	// them apart in their journal
+	draftSource = "mcp"
+)
+
+var tools = []Tool{
+	{
+		Name:        "search_grammar",
+		Description: "Search the Japanese grammar points the user can study, from the shared library and their own, by usage (kana, kanji or romaji), meaning or tag.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"query": map[string]any{"type": "string", "description": "What to look for, e.g. ながら or \"while doing\""},
+				"limit": map[string]any{"type": "integer", "minimum": 1, "maximum": maxSearchLimit, "default": defaultSearchLimit},
+			},
+			"required": []string{"query"},
+		},
+		Scope: ScopeGrammarRead,
+	},
+	{
+		Name:        "get_due_reviews",
+		Description: "List the flashcards the user has left to study today within their daily limits, due reviews and new cards mixed the way the app presents them, with their grammar.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"limit": map[string]any{"type": "integer", "minimum": 1, "maximum": maxDueLimit, "default": defaultDueLimit},
+			},
+		},
+		Scope: ScopeReviewsRead,
+	},
+	{
+		Name:        "create_journal_draft",
+		Description: "Save a private draft in the user's Japanese journal for them to finish and share themselves. Nobody else can see it.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"title":   map[string]any{"type": "string", "maxLength": maxDraftTitleLength},
+				"content": map[string]any{"type": "string", "maxLength": maxDraftContentLength},
+			},
+			"required": []string{"title", "content"},
+		},
+		Scope: ScopeJournalWrite,
+	},
+}
+
+type searchGrammarArgs struct {
+	Query string `json:"query"`
+	Limit int    `json:"limit"`
+}
+
+func (a searchGrammarArgs) Validate() error {
+	errs := validation.Errors{}
+	if strings.TrimSpace(a.Query) == "" {
+		errs["query"] = validation.NewError("validation_required", "Cannot be blank.")
+	}
+	if a.Limit < 0 || a.Limit > maxSearchLimit {
+		errs["limit"] = validation.NewError("validation_out_of_range", "Must be between {{.min}} and {{.max}}.").
+			SetParams(map[string]any{"min": 1, "max": maxSearchLimit})
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// grammarResult is a grammar point without what only the app needs
+type grammarResult struct {
+	Id        string            `json:"id"`
+	Usage     string            `json:"usage"`
+	Romanized string            `json:"romanized,omitempty"`
+	Meaning   string            `json:"meaning"`
+	Tags      []string          `json:"tags"`
+	Examples  []grammar.Example `json:"examples"`
+}
+
+func newGrammarResult(g grammar.Grammar) grammarResult {
+	return grammarResult{
+		Id:        g.Id,
+		Usage:     g.Usage,
+		Romanized: g.Romanized,
+		Meaning:   g.Meaning,
+		Tags:      g.Tags,
+		Examples:  g.Examples,
+	}
+}
+
+type dueReviewsArgs struct {
+	Limit int `json:"limit"`
+}
+
+func (a dueReviewsArgs) Validate() error {
+	if a.Limit < 0 || a.Limit > maxDueLimit {
+		return validation.Errors{
+			"limit": validation.NewError("validation_out_of_range", "Must be between {{.min}} and {{.max}}.").
+				SetParams(map[string]any{"min": 1, "max": maxDueLimit}),
+		}
+	}
+	return nil
+}
+
+type dueReviewsResult struct {
+	NewLeft     int       `json:"new_left"`
+	ReviewsLeft int       `json:"reviews_left"`
+	Items       []dueCard `json:"items"`
+}
+
+type dueCard struct {
+	Card    string        `json:"card"`
+	New     bool          `json:"new"`
+	DueDate time.Time     `json:"due_date"`
+	Grammar grammarResult `json:"grammar"`
+}
+
+func newDueReviewsResult(list srs.DueList, limit int) dueReviewsResult {
+	result := dueReviewsResult{NewLeft: list.NewLeft, ReviewsLeft: list.ReviewsLeft, Items: []dueCard{}}
+	for _, item := range list.Items[:min(len(list.Items), limit)] {
+		result.Items = append(result.Items, dueCard{
+			Card:    item.Id,
+			New:     item.New,
+			DueDate: item.DueDate(),
+			Grammar: newGrammarResult(item.Expand.Grammar),
+		})
+	}
+	return result
+}
+
+type journalDraftArgs struct {
+	Title   string `json:"title"`
+	Content string `json:"content"`
+}
+
+func (a journalDraftArgs) Validate() error {
+	errs := validation.Errors{}
+	switch title := strings.TrimSpace(a.Title); {
+	case title == "":
+		errs["title"] = validation.NewError("validation_required", "Cannot be blank.")
+	case utf8.RuneCountInString(title) > maxDraftTitleLength:
+		errs["title"] = validation.NewError("validation_length_too_long", "The length must be no more than {{.max}}.").
+			SetParams(map[string]any{"max": maxDraftTitleLength})
+	}
+	switch content := strings.TrimSpace(a.Content); {
+	case content == "":
+		errs["content"] = validation.NewError("validation_required", "Cannot be blank.")
+	case utf8.RuneCountInString(content) > maxDraftContentLength:
+		errs["content"] = validation.NewError("validation_length_too_long", "The length must be no more than {{.max}}.").
+			SetParams(map[string]any{"max": maxDraftContentLength})
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+type journalDraftResult struct {
+	Id       string `json:"id"`
+	Title    string `json:"title"`
+	Audience string `json:"audience"`
+}
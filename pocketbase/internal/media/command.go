@@ -0,0 +1,73 @@
+package media
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/jobs"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/spf13/cobra"
+)
+
+// pollInterval is how often the command checks on its job
+const pollInterval = time.Second
+
+// NewCommand adds `media reprocess <task>`, which runs a reprocessing job
+// like the admin route does and follows it until it's done
+func NewCommand(app core.App, mediaService Service) *cobra.Command {
+	command := &cobra.Command{
+		Use:   "media",
+		Short: "Manage uploaded media",
+	}
+
+	var req ReprocessRequest
+	reprocess := &cobra.Command{
+		Use:       "reprocess <previews|transcripts>",
+		Short:     "Make previews of or transcribe existing media again",
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: Tasks,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			req.Task = args[0]
+			if err := req.Validate(); err != nil {
+				return err
+			}
+
+			job, err := mediaService.Reprocess(req)
+			if err != nil {
+				return err
+			}
+
+			message := ""
+			for !job.IsDone() {
+				time.Sleep(pollInterval)
+				// system jobs belong to no user to find them by
+				rec, err := app.FindRecordById("jobs", job.Id)
+				if err != nil {
+					return err
+				}
+				job = jobs.FromRecord(rec)
+				if job.Message != message {
+					message = job.Message
+					cmd.Printf("%3.0f%% %s\n", job.Progress, message)
+				}
+			}
+
+			if job.Status == jobs.StatusFailed {
+				return fmt.Errorf("reprocessing failed: %s", job.Error)
+			}
+			result, err := json.MarshalIndent(job.Result, "", "  ")
+			if err != nil {
+				return err
+			}
+			cmd.Println(string(result))
+			return nil
+		},
+	}
+	reprocess.Flags().StringVar(&req.User, "user", "", "only the media of this user id")
+	reprocess.Flags().StringVar(&req.Kind, "kind", "", "only attachment or audio media")
+
+	command.AddCommand(reprocess)
+	return command
+}
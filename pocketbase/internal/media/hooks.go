@@ -0,0 +1,26 @@
+package media
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// BindHooks makes the previews of media as they're uploaded, and again when
+// their file is replaced
+func BindHooks(app core.App, mediaService Service) {
+	queue := func(e *core.RecordEvent) {
+		if _, err := mediaService.MakePreviews(e.Record.GetString("user"), e.Record.Id); err != nil {
+			e.App.Logger().Error("Failed to start making media previews", "media", e.Record.Id, "error", err)
+		}
+	}
+	app.OnRecordAfterCreateSuccess("media").BindFunc(func(e *core.RecordEvent) error {
+		queue(e)
+		return e.Next()
+	})
+	app.OnRecordAfterUpdateSuccess("media").BindFunc(func(e *core.RecordEvent) error {
+		original := e.Record.Original()
+		if e.Record.GetString("hash") != original.GetString("hash") || e.Record.GetString("file") != original.GetString("file") {
+			queue(e)
+		}
+		return e.Next()
+	})
+}
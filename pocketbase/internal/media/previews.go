@@ -0,0 +1,164 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"math"
+	"os"
+	"os/exec"
+	"strconv"
+
+	"github.com/disintegration/imaging"
+	_ "golang.org/x/image/webp" // registers the decoder, thumbnails are jpegs
+)
+
+const (
+	// ThumbnailSize is the most pixels a thumbnail is wide or high
+	ThumbnailSize = 320
+
+	// WaveformPeaks is how many peaks a waveform has, whatever the length
+	WaveformPeaks = 100
+
+	// sampleRate is what other formats are decoded at for waveforms, plenty
+	// to draw them
+	sampleRate = 8000
+)
+
+// ErrNoDecoder means audio is in a format that can't be read without ffmpeg
+var ErrNoDecoder = errors.New("no decoder for this audio")
+
+// FFmpegFromEnv finds the ffmpeg audio other than WAV is decoded with, at
+// FFMPEG_PATH or else on the PATH. Empty when there is none.
+func FFmpegFromEnv() string {
+	if path := os.Getenv("FFMPEG_PATH"); path != "" {
+		return path
+	}
+	path, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		return ""
+	}
+	return path
+}
+
+// thumbnail scales an image down to fit ThumbnailSize as a jpeg, flattening
+// transparency onto white, and returns the size of the original. ok is
+// false when data isn't an image.
+func thumbnail(data []byte) (thumb []byte, width int, height int, ok bool, err error) {
+	img, err := imaging.Decode(bytes.NewReader(data), imaging.AutoOrientation(true))
+	if err != nil {
+		return nil, 0, 0, false, nil
+	}
+	bounds := img.Bounds()
+
+	img = imaging.Fit(img, ThumbnailSize, ThumbnailSize, imaging.Lanczos)
+	img = imaging.Overlay(imaging.New(img.Bounds().Dx(), img.Bounds().Dy(), color.White), img, image.Pt(0, 0), 1)
+
+	var buf bytes.Buffer
+	if err := imaging.Encode(&buf, img, imaging.JPEG, imaging.JPEGQuality(jpeg.DefaultQuality)); err != nil {
+		return nil, 0, 0, false, err
+	}
+	return buf.Bytes(), bounds.Dx(), bounds.Dy(), true, nil
+}
+
+// decodeAudio reads audio as mono samples, WAV by itself and anything else
+// through ffmpeg
+func decodeAudio(ctx context.Context, data []byte, ffmpeg string) (samples []float64, rate int, err error) {
+	if samples, rate, ok := decodeWAV(data); ok {
+		return samples, rate, nil
+	}
+	if ffmpeg == "" {
+		return nil, 0, ErrNoDecoder
+	}
+
+	cmd := exec.CommandContext(ctx, ffmpeg, "-v", "error", "-i", "pipe:0", "-ac", "1", "-ar", strconv.Itoa(sampleRate), "-f", "s16le", "pipe:1")
+	cmd.Stdin = bytes.NewReader(data)
+	var out, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, 0, errors.Join(err, errors.New(stderr.String()))
+	}
+
+	pcm := out.Bytes()
+	samples = make([]float64, len(pcm)/2)
+	for i := range samples {
+		samples[i] = float64(int16(binary.LittleEndian.Uint16(pcm[2*i:]))) / math.MaxInt16
+	}
+	return samples, sampleRate, nil
+}
+
+// decodeWAV reads 8 and 16 bit PCM WAV files, averaging their channels
+func decodeWAV(data []byte) (samples []float64, rate int, ok bool) {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		return nil, 0, false
+	}
+
+	var channels, bits int
+	for pos := 12; pos+8 <= len(data); {
+		id := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4:]))
+		body := data[pos+8 : min(pos+8+size, len(data))]
+
+		switch id {
+		case "fmt ":
+			if len(body) < 16 || binary.LittleEndian.Uint16(body) != 1 {
+				return nil, 0, false
+			}
+			channels = int(binary.LittleEndian.Uint16(body[2:]))
+			rate = int(binary.LittleEndian.Uint32(body[4:]))
+			bits = int(binary.LittleEndian.Uint16(body[14:]))
+		case "data":
+			if channels == 0 || rate == 0 || (bits != 8 && bits != 16) {
+				return nil, 0, false
+			}
+			width := bits / 8
+			frames := len(body) / (width * channels)
+			samples = make([]float64, frames)
+			for i := range samples {
+				var sum float64
+				for c := 0; c < channels; c++ {
+					at := (i*channels + c) * width
+					if bits == 8 {
+						sum += (float64(body[at]) - 128) / 128
+					} else {
+						sum += float64(int16(binary.LittleEndian.Uint16(body[at:]))) / math.MaxInt16
+					}
+				}
+				samples[i] = sum / float64(channels)
+			}
+			return samples, rate, true
+		}
+
+		// chunks are padded to an even size
+		pos += 8 + size + size%2
+	}
+	return nil, 0, false
+}
+
+// waveform is the loudest sample of each of WaveformPeaks stretches of the
+// audio, scaled so the loudest overall is 1
+func waveform(samples []float64) []float64 {
+	peaks := make([]float64, WaveformPeaks)
+	if len(samples) == 0 {
+		return peaks
+	}
+
+	var loudest float64
+	for i := range peaks {
+		from, to := i*len(samples)/WaveformPeaks, (i+1)*len(samples)/WaveformPeaks
+		for _, s := range samples[from:max(to, min(from+1, len(samples)))] {
+			peaks[i] = max(peaks[i], math.Abs(s))
+		}
+		loudest = max(loudest, peaks[i])
+	}
+	if loudest > 0 {
+		for i := range peaks {
+			peaks[i] = math.Round(peaks[i]/loudest*100) / 100
+		}
+	}
+	return peaks
+}
@@ -0,0 +1,69 @@
+package media
+
+import (
+	"context"
+	"slices"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// What existing media can be processed again, after the pipeline changed
+const (
+	// TaskPreviews makes thumbnails and waveforms again
+	TaskPreviews = "previews"
+
+	// TaskTranscripts transcribes spoken entries again
+	TaskTranscripts = "transcripts"
+)
+
+// JobKindReprocessPrefix prefixes the kind of reprocessing jobs, e.g.
+// media_reprocess_previews
+const JobKindReprocessPrefix = "media_reprocess_"
+
+var Tasks = []string{TaskPreviews, TaskTranscripts}
+
+// Transcriber transcribes the recording of a spoken entry again,
+// speaking.Service is one
+type Transcriber interface {
+	Retranscribe(ctx context.Context, entryId string, audio []byte, filename string) (bool, error)
+}
+
+// ReprocessRequest picks what to do to which media
+type ReprocessRequest struct {
+	Task string `json:"task"`
+
+	// User keeps to the media of one user, all users' when empty
+	User string `json:"user"`
+
+	// Kind keeps to attachments or audio, transcripts are always of audio
+	Kind string `json:"kind"`
+}
+
+func (r ReprocessRequest) Validate() error {
+	errs := validation.Errors{}
+
+	if !slices.Contains(Tasks, r.Task) {
+		errs["task"] = validation.NewError("validation_invalid_value", "Invalid value.")
+	}
+	if r.Kind != "" && r.Kind != "attachment" && r.Kind != "audio" {
+		errs["kind"] = validation.NewError("validation_invalid_value", "Invalid value.")
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ReprocessResult is what a reprocessing job leaves behind
+type ReprocessResult struct {
+	Processed int `json:"processed"`
+
+	// Skipped media were left as they were, spoken entries the user edited
+	Skipped int `json:"skipped"`
+
+	Failed int `json:"failed"`
+
+	// Error is the first failure, the others are logged
+	Error string `json:"error,omitempty"`
+}
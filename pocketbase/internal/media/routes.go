@@ -0,0 +1,126 @@
+package media
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/jobs"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+type signRequest struct {
+	Ids []string `json:"ids"`
+}
+
+// RegisterRoutes adds the routes signing URLs to the user's media files
+func RegisterRoutes(g *api.Group, mediaService Service) {
+	g.GET("/media/{id}/url", "A short-lived URL that loads one of the user's media files without authenticating", SignedURL{}, func(e *core.RequestEvent) error {
+		urls, err := mediaService.SignURLs(e.Auth.Id, []string{e.Request.PathValue("id")}, publicBase(e))
+		if errors.Is(err, sql.ErrNoRows) {
+			return e.NotFoundError("", err)
+		}
+		if err != nil {
+			return e.InternalServerError("Failed to sign the media URL.", err)
+		}
+		return e.JSON(200, urls[0])
+	})
+
+	g.POST("/media/urls", "Short-lived URLs for many of the user's media files at once, for list views", signRequest{}, []SignedURL{}, func(e *core.RequestEvent) error {
+		var req signRequest
+		if err := e.BindBody(&req); err != nil {
+			return e.BadRequestError("Invalid request body.", err)
+		}
+		if len(req.Ids) == 0 {
+			return e.BadRequestError("Invalid request body.", validation.Errors{
+				"ids": validation.NewError("validation_required", "Cannot be blank."),
+			})
+		}
+		if len(req.Ids) > MaxURLs {
+			return e.BadRequestError("Invalid request body.", validation.Errors{
+				"ids": validation.NewError("validation_too_many", "Must have at most {{.max}} items.").
+					SetParams(map[string]any{"max": MaxURLs}),
+			})
+		}
+
+		urls, err := mediaService.SignURLs(e.Auth.Id, req.Ids, publicBase(e))
+		if errors.Is(err, sql.ErrNoRows) {
+			return e.NotFoundError("", err)
+		}
+		if err != nil {
+			return e.InternalServerError("Failed to sign the media URLs.", err)
+		}
+		return e.JSON(200, urls)
+	})
+}
+
+// RegisterAdminRoutes lets superusers and support staff process existing
+// media again after the pipeline changed. Superusers can follow the job in
+// the jobs collection.
+func RegisterAdminRoutes(g *api.Group, mediaService Service) {
+	g.POST("/media/reprocess", "Make previews of or transcribe existing media again (task previews or transcripts), for one user or kind of media", ReprocessRequest{}, jobs.Job{}, func(e *core.RequestEvent) error {
+		var req ReprocessRequest
+		if err := e.BindBody(&req); err != nil {
+			return e.BadRequestError("Invalid request body.", err)
+		}
+		if err := req.Validate(); err != nil {
+			return e.BadRequestError("Invalid request body.", err)
+		}
+
+		job, err := mediaService.Reprocess(req)
+		if err != nil {
+			return e.InternalServerError("Failed to start reprocessing media.", err)
+		}
+		return e.JSON(200, job)
+	})
+}
+
+// RegisterPublicRoutes adds the route signed URLs point at, so the web app
+// and iOS app can load media straight into image and audio elements
+func RegisterPublicRoutes(g *api.Group, mediaService Service) {
+	g.GET("/media/{id}/file", "A media file, through a URL signed for it", nil, func(e *core.RequestEvent) error {
+		return serveSigned(e, mediaService, false)
+	})
+
+	g.GET("/media/{id}/thumbnail", "The thumbnail of a media image, through a URL signed for it", nil, func(e *core.RequestEvent) error {
+		return serveSigned(e, mediaService, true)
+	})
+}
+
+func serveSigned(e *core.RequestEvent, mediaService Service, thumbnail bool) error {
+	query := e.Request.URL.Query()
+	key, name, err := mediaService.Open(e.Request.PathValue("id"), thumbnail, query.Get("expires"), query.Get("signature"))
+	switch {
+	case errors.Is(err, ErrExpired):
+		return e.ForbiddenError("This link has expired.", err)
+	case err != nil:
+		return e.NotFoundError("", err)
+	}
+
+	fsys, err := e.App.NewFilesystem()
+	if err != nil {
+		return e.InternalServerError("Failed to load the file.", err)
+	}
+	defer fsys.Close()
+
+	// cached no longer than the URL works
+	expires, _ := strconv.ParseInt(query.Get("expires"), 10, 64)
+	maxAge := max(int(time.Until(time.Unix(expires, 0)).Seconds()), 0)
+	e.Response.Header().Set("Cache-Control", "private, max-age="+strconv.Itoa(maxAge))
+
+	if err := fsys.Serve(e.Response, e.Request, key, name); err != nil {
+		return e.NotFoundError("", err)
+	}
+	return nil
+}
+
+// publicBase is where the public routes of the API version the request came
+// in on are
+func publicBase(e *core.RequestEvent) string {
+	version := e.Response.Header().Get(api.VersionHeader)
+	return e.App.Settings().Meta.AppURL + api.BasePath + "/" + version + "/public"
+}
@@ -0,0 +1,320 @@
+package media
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/jobs"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/filesystem"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+const (
+	JobKindPreviews = "media_previews"
+
+	// MaxURLs bounds how many media are signed at once
+	MaxURLs = 100
+
+	// maxFileSize is the most the media collection takes
+	maxFileSize = 50 << 20
+)
+
+var (
+	// ErrExpired means a signed URL was real but is past its expiry
+	ErrExpired = errors.New("signed URL expired")
+
+	// ErrBadSignature means a URL wasn't signed by this instance, or was
+	// changed since
+	ErrBadSignature = errors.New("invalid signature")
+)
+
+// SignedURL loads a media record's file without authenticating, until it
+// expires
+type SignedURL struct {
+	Media string `json:"media"`
+	URL   string `json:"url"`
+
+	// ThumbnailURL is set for images once their thumbnail is made
+	ThumbnailURL string `json:"thumbnail_url,omitempty"`
+
+	Expires types.DateTime `json:"expires"`
+}
+
+type Service interface {
+	// SignURLs signs URLs to the files of the user's media, under base
+	SignURLs(userId string, ids []string, base string) ([]SignedURL, error)
+
+	// Open checks the expiry and signature of a URL and finds the file or
+	// thumbnail it's for, returning its key in the filesystem and its name
+	Open(id string, thumbnail bool, expires string, signature string) (key string, name string, err error)
+
+	// MakePreviews makes the thumbnail of an image or the waveform of audio
+	// in a background job, for list views to show instead of the file
+	MakePreviews(userId string, id string) (jobs.Job, error)
+
+	// Reprocess runs a task over existing media in a system job, reporting
+	// its progress as it goes. The job's result is a ReprocessResult.
+	Reprocess(req ReprocessRequest) (jobs.Job, error)
+}
+
+type service struct {
+	app         core.App
+	jobsService jobs.Service
+	signer      *Signer
+
+	transcriber Transcriber
+
+	// empty when there is no ffmpeg, only WAV audio gets a waveform then
+	ffmpeg string
+}
+
+func NewService(app core.App, jobsService jobs.Service, transcriber Transcriber, signer *Signer, ffmpeg string) Service {
+	return &service{app: app, jobsService: jobsService, transcriber: transcriber, signer: signer, ffmpeg: ffmpeg}
+}
+
+func (s *service) SignURLs(userId string, ids []string, base string) ([]SignedURL, error) {
+	records, err := s.app.FindAllRecords("media", dbx.HashExp{"user": userId}, dbx.In("id", toAny(ids)...))
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[string]*core.Record, len(records))
+	for _, rec := range records {
+		found[rec.Id] = rec
+	}
+
+	expires := s.signer.Expiry(time.Now())
+	expiresAt, err := types.ParseDateTime(expires)
+	if err != nil {
+		return nil, err
+	}
+
+	urls := make([]SignedURL, 0, len(ids))
+	for _, id := range ids {
+		rec, ok := found[id]
+		if !ok {
+			return nil, sql.ErrNoRows
+		}
+		// one signature is good for the file and its thumbnail
+		query := url.Values{}
+		query.Set("expires", strconv.FormatInt(expires.Unix(), 10))
+		query.Set("signature", s.signer.Sign(id, expires))
+		signed := SignedURL{
+			Media:   id,
+			URL:     base + "/media/" + id + "/file?" + query.Encode(),
+			Expires: expiresAt,
+		}
+		if rec.GetString("thumbnail") != "" {
+			signed.ThumbnailURL = base + "/media/" + id + "/thumbnail?" + query.Encode()
+		}
+		urls = append(urls, signed)
+	}
+	return urls, nil
+}
+
+func (s *service) Open(id string, thumbnail bool, expires string, signature string) (string, string, error) {
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return "", "", ErrBadSignature
+	}
+	expiresAt := time.Unix(unix, 0)
+	if !s.signer.Valid(id, expiresAt, signature) {
+		return "", "", ErrBadSignature
+	}
+	if time.Now().After(expiresAt) {
+		return "", "", ErrExpired
+	}
+
+	rec, err := s.app.FindRecordById("media", id)
+	if err != nil {
+		return "", "", err
+	}
+	if thumbnail {
+		name := rec.GetString("thumbnail")
+		if name == "" {
+			return "", "", sql.ErrNoRows
+		}
+		return rec.BaseFilesPath() + "/" + name, name, nil
+	}
+	return fileKey(s.app, rec)
+}
+
+func (s *service) MakePreviews(userId string, id string) (jobs.Job, error) {
+	return s.jobsService.Enqueue(userId, JobKindPreviews, func(ctx context.Context, jobId string, progress jobs.Progress) (any, error) {
+		return s.makePreviews(ctx, id)
+	})
+}
+
+func (s *service) Reprocess(req ReprocessRequest) (jobs.Job, error) {
+	return s.jobsService.Enqueue("", JobKindReprocessPrefix+req.Task, func(ctx context.Context, jobId string, progress jobs.Progress) (any, error) {
+		query := s.app.RecordQuery("media").Select("id").OrderBy("created")
+		if req.User != "" {
+			query = query.AndWhere(dbx.HashExp{"user": req.User})
+		}
+		if req.Task == TaskTranscripts {
+			query = query.AndWhere(dbx.HashExp{"kind": "audio"}).AndWhere(dbx.Not(dbx.HashExp{"journal_entry": ""}))
+		} else if req.Kind != "" {
+			query = query.AndWhere(dbx.HashExp{"kind": req.Kind})
+		}
+		var ids []string
+		if err := query.Column(&ids); err != nil {
+			return nil, err
+		}
+
+		var result ReprocessResult
+		for i, id := range ids {
+			progress.Report(float64(i)*100/float64(len(ids)), fmt.Sprintf("%d of %d", i, len(ids)))
+
+			var (
+				done bool
+				err  error
+			)
+			if req.Task == TaskTranscripts {
+				done, err = s.retranscribe(ctx, id)
+			} else {
+				_, err = s.makePreviews(ctx, id)
+				done = err == nil
+			}
+			switch {
+			case err != nil:
+				s.app.Logger().Warn("Failed to reprocess media", "task", req.Task, "media", id, "error", err)
+				if result.Failed == 0 {
+					result.Error = err.Error()
+				}
+				result.Failed++
+			case done:
+				result.Processed++
+			default:
+				result.Skipped++
+			}
+		}
+		progress.Report(100, fmt.Sprintf("%d of %d", len(ids), len(ids)))
+		return result, nil
+	})
+}
+
+// retranscribe transcribes the recording of a spoken entry again
+func (s *service) retranscribe(ctx context.Context, id string) (bool, error) {
+	rec, err := s.app.FindRecordById("media", id)
+	if err != nil {
+		return false, err
+	}
+	_, name, err := fileKey(s.app, rec)
+	if err != nil {
+		return false, err
+	}
+	data, err := readFile(s.app, rec)
+	if err != nil {
+		return false, err
+	}
+	return s.transcriber.Retranscribe(ctx, rec.GetString("journal_entry"), data, name)
+}
+
+// makePreviews replaces what an earlier run made, the file may have changed
+// since
+func (s *service) makePreviews(ctx context.Context, id string) (map[string]any, error) {
+	rec, err := s.app.FindRecordById("media", id)
+	if err != nil {
+		return nil, err
+	}
+	data, err := readFile(s.app, rec)
+	if err != nil {
+		return nil, err
+	}
+
+	rec.Set("thumbnail", nil)
+	rec.Set("width", 0)
+	rec.Set("height", 0)
+	rec.Set("duration", 0)
+	rec.Set("waveform", nil)
+
+	result := map[string]any{"media": id}
+	if rec.GetString("kind") == "audio" {
+		samples, rate, err := decodeAudio(ctx, data, s.ffmpeg)
+		switch {
+		case errors.Is(err, ErrNoDecoder):
+			result["waveform"] = false
+		case err != nil:
+			return nil, err
+		default:
+			rec.Set("duration", math.Round(float64(len(samples))/float64(rate)*100)/100)
+			rec.Set("waveform", waveform(samples))
+			result["waveform"] = true
+		}
+	} else {
+		thumb, width, height, ok, err := thumbnail(data)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			file, err := filesystem.NewFileFromBytes(thumb, "thumbnail.jpg")
+			if err != nil {
+				return nil, err
+			}
+			rec.Set("thumbnail", file)
+			rec.Set("width", width)
+			rec.Set("height", height)
+		}
+		result["thumbnail"] = ok
+	}
+
+	rec.Set("processed", types.NowDateTime())
+	if err := s.app.Save(rec); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// fileKey finds where a media record's file is stored. Uploads live in the
+// blob of their content, media saved before blobs keep their own file.
+func fileKey(app core.App, rec *core.Record) (key string, name string, err error) {
+	if blobId := rec.GetString("blob"); blobId != "" {
+		blob, err := app.FindRecordById("blobs", blobId)
+		if err != nil {
+			return "", "", err
+		}
+		rec = blob
+	}
+	name = rec.GetString("file")
+	if name == "" {
+		return "", "", sql.ErrNoRows
+	}
+	return rec.BaseFilesPath() + "/" + name, name, nil
+}
+
+// readFile reads a media record's file into memory
+func readFile(app core.App, rec *core.Record) ([]byte, error) {
+	key, _, err := fileKey(app, rec)
+	if err != nil {
+		return nil, err
+	}
+	fsys, err := app.NewFilesystem()
+	if err != nil {
+		return nil, err
+	}
+	defer fsys.Close()
+
+	r, err := fsys.GetReader(key)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(io.LimitReader(r, maxFileSize))
+}
+
+func toAny(ids []string) []any {
+	values := make([]any, len(ids))
+	for i, id := range ids {
+		values[i] = id
+	}
+	return values
+}
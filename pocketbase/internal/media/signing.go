@@ -0,0 +1,60 @@
+package media
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"os"
+	"strconv"
+	"time"
+)
+
+// DefaultURLLifetime is how long signed URLs last at least, unless
+// MEDIA_URL_MINUTES says otherwise
+const DefaultURLLifetime = 15 * time.Minute
+
+// Signer signs and checks the URLs media files are loaded from
+type Signer struct {
+	key      []byte
+	lifetime time.Duration
+}
+
+func NewSigner(key []byte, lifetime time.Duration) *Signer {
+	return &Signer{key: key, lifetime: lifetime}
+}
+
+// SignerFromEnv signs with MEDIA_SIGNING_KEY, or a key of its own that
+// doesn't outlive the process when it's unset. Instances running side by
+// side need the same key to accept each other's URLs.
+func SignerFromEnv() *Signer {
+	key := []byte(os.Getenv("MEDIA_SIGNING_KEY"))
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		_, _ = rand.Read(key)
+	}
+	lifetime := DefaultURLLifetime
+	if minutes, err := strconv.Atoi(os.Getenv("MEDIA_URL_MINUTES")); err == nil && minutes > 0 {
+		lifetime = time.Duration(minutes) * time.Minute
+	}
+	return NewSigner(key, lifetime)
+}
+
+// Expiry is when a URL signed now expires. It's rounded up to a multiple of
+// the lifetime so the same file keeps the same URL for a while and browsers
+// can cache it.
+func (s *Signer) Expiry(now time.Time) time.Time {
+	return now.Add(s.lifetime).Truncate(s.lifetime).Add(s.lifetime)
+}
+
+// Sign is the signature of the URL of a media record's file until expires
+func (s *Signer) Sign(id string, expires time.Time) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(id + "\n" + strconv.FormatInt(expires.Unix(), 10)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Valid reports whether signature is the one Sign gave for id and expires
+func (s *Signer) Valid(id string, expires time.Time, signature string) bool {
+	return hmac.Equal([]byte(signature), []byte(s.Sign(id, expires)))
+}
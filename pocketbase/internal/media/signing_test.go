@@ -0,0 +1,59 @@
+package media
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSignerExpiry(t *testing.T) {
+	signer := NewSigner([]byte("key"), 15*time.Minute)
+	start := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		now  time.Time
+		want time.Time
+	}{
+		{start, start.Add(30 * time.Minute)},
+		{start.Add(time.Minute), start.Add(30 * time.Minute)},
+		{start.Add(14 * time.Minute), start.Add(30 * time.Minute)},
+		{start.Add(15 * time.Minute), start.Add(45 * time.Minute)},
+	}
+	for _, tt := range tests {
+		got := signer.Expiry(tt.now)
+		if !got.Equal(tt.want) {
+			t.Errorf("Expiry(%v) = %v, want %v", tt.now, got, tt.want)
+		}
+		if got.Sub(tt.now) < 15*time.Minute {
+			t.Errorf("Expiry(%v) = %v, want at least the lifetime away", tt.now, got)
+		}
+	}
+}
+
+func TestSignerValid(t *testing.T) {
+	signer := NewSigner([]byte("key"), 15*time.Minute)
+	expires := time.Date(2026, 5, 1, 9, 30, 0, 0, time.UTC)
+	signature := signer.Sign("m1", expires)
+
+	tests := []struct {
+		name      string
+		signer    *Signer
+		id        string
+		expires   time.Time
+		signature string
+		want      bool
+	}{
+		{"as signed", signer, "m1", expires, signature, true},
+		{"other media", signer, "m2", expires, signature, false},
+		{"later expiry", signer, "m1", expires.Add(time.Hour), signature, false},
+		{"changed signature", signer, "m1", expires, signature[1:], false},
+		{"no signature", signer, "m1", expires, "", false},
+		{"other key", NewSigner([]byte("other"), 15*time.Minute), "m1", expires, signature, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.signer.Valid(tt.id, tt.expires, tt.signature); got != tt.want {
+				t.Errorf("Valid() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
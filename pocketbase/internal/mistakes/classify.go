@@ -0,0 +1,108 @@
+package mistakes
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Particles are the particles a correction can swap, add or drop
+var Particles = []string{
+	"は", "が", "を", "に", "で", "へ", "と", "も", "の", "や", "か", "ね", "よ",
+	"から", "まで", "より", "って", "には", "では", "とは", "にも", "でも",
+}
+
+// politeMarkers give away a polite sentence, keigo the honorific and humble
+// verbs
+var (
+	politeMarkers = []string{"です", "ます", "ました", "ません", "でした", "ましょう", "ください", "ございます"}
+	keigo         = []string{"いらっしゃ", "おっしゃ", "召し上が", "申し", "参り", "伺", "存じ", "なさ", "拝見", "差し上げ"}
+)
+
+// Diff splits two versions of a sentence into what they share at the start,
+// what was removed and added in between, and what they share at the end
+func Diff(original string, corrected string) (prefix string, removed string, added string, suffix string) {
+	a, b := []rune(strings.TrimSpace(original)), []rune(strings.TrimSpace(corrected))
+
+	p := 0
+	for p < len(a) && p < len(b) && a[p] == b[p] {
+		p++
+	}
+	s := 0
+	for s < len(a)-p && s < len(b)-p && a[len(a)-1-s] == b[len(b)-1-s] {
+		s++
+	}
+	return string(a[:p]), string(a[p : len(a)-s]), string(b[p : len(b)-s]), string(a[len(a)-s:])
+}
+
+// Classify files a correction under the kind of mistake it fixes. ok is false
+// when the correction changes nothing.
+func Classify(original string, corrected string) (category string, ok bool) {
+	prefix, removed, added, _ := Diff(original, corrected)
+	if removed == "" && added == "" {
+		return "", false
+	}
+
+	switch {
+	case politeness(original) != politeness(corrected):
+		return CategoryRegister, true
+	case isParticle(removed) && isParticle(added):
+		return CategoryParticle, true
+	case isHiragana(removed) && isHiragana(added) && endsInStem(prefix):
+		return CategoryConjugation, true
+	case hasWordScript(removed) || hasWordScript(added):
+		return CategoryVocabulary, true
+	}
+	return CategoryOther, true
+}
+
+// politeness ranks a sentence plain (0), polite (1) or keigo (2)
+func politeness(sentence string) int {
+	for _, word := range keigo {
+		if strings.Contains(sentence, word) {
+			return 2
+		}
+	}
+	for _, marker := range politeMarkers {
+		if strings.Contains(sentence, marker) {
+			return 1
+		}
+	}
+	return 0
+}
+
+// isParticle also takes a particle missing altogether
+func isParticle(s string) bool {
+	return s == "" || slices.Contains(Particles, s)
+}
+
+func isHiragana(s string) bool {
+	for _, r := range s {
+		if !unicode.Is(unicode.Hiragana, r) {
+			return false
+		}
+	}
+	return true
+}
+
+// endsInStem reports whether a change right after prefix is on the ending of
+// a word, after its kanji or the kana of its stem
+func endsInStem(prefix string) bool {
+	runes := []rune(prefix)
+	if len(runes) == 0 {
+		return false
+	}
+	last := runes[len(runes)-1]
+	return unicode.Is(unicode.Han, last) || unicode.Is(unicode.Hiragana, last)
+}
+
+// hasWordScript reports whether s has kanji or katakana, the scripts words
+// rather than grammar are written in
+func hasWordScript(s string) bool {
+	for _, r := range s {
+		if unicode.Is(unicode.Han, r) || unicode.Is(unicode.Katakana, r) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package mistakes
+
+import (
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// BindHooks files corrections as mistakes once the user accepts them, and
+// takes them back out when they change their mind
+func BindHooks(app core.App) {
+	file := func(e *core.RecordEvent) error {
+		if err := fileCorrection(e.App, e.Record); err != nil {
+			e.App.Logger().Error("Failed to file the correction as a mistake", "correction", e.Record.Id, "error", err)
+		}
+		return e.Next()
+	}
+	app.OnRecordAfterCreateSuccess("corrections").BindFunc(file)
+	app.OnRecordAfterUpdateSuccess("corrections").BindFunc(file)
+}
+
+func fileCorrection(app core.App, correction *core.Record) error {
+	existing, _ := app.FindFirstRecordByFilter("errors", "correction = {:correction}", dbx.Params{"correction": correction.Id})
+
+	original, corrected := correction.GetString("original"), correction.GetString("corrected")
+	category, ok := Classify(original, corrected)
+	if !correction.GetBool("accepted") || original == "" || !ok {
+		if existing != nil {
+			return app.Delete(existing)
+		}
+		return nil
+	}
+
+	entry, err := app.FindRecordById("journal_entry", correction.GetString("journal_entry"))
+	if err != nil {
+		return err
+	}
+
+	rec := existing
+	if rec == nil {
+		collection, err := app.FindCollectionByNameOrId("errors")
+		if err != nil {
+			return err
+		}
+		rec = core.NewRecord(collection)
+		rec.Set("correction", correction.Id)
+	}
+	_, removed, added, _ := Diff(original, corrected)
+	rec.Set("user", correction.GetString("user"))
+	rec.Set("category", category)
+	rec.Set("original", removed)
+	rec.Set("corrected", added)
+	rec.Set("occurred", entry.GetDateTime("created"))
+	return app.Save(rec)
+}
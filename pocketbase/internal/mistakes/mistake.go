@@ -0,0 +1,65 @@
+package mistakes
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Kinds of mistakes corrections fix
+const (
+	CategoryParticle    = "particle"
+	CategoryConjugation = "conjugation"
+	CategoryRegister    = "register"
+	CategoryVocabulary  = "vocabulary"
+	CategoryOther       = "other"
+)
+
+var Categories = []string{CategoryParticle, CategoryConjugation, CategoryRegister, CategoryVocabulary, CategoryOther}
+
+// Mistake is an accepted correction filed under the kind of mistake it fixes
+type Mistake struct {
+	Id         string `json:"id"`
+	User       string `json:"user"`
+	Correction string `json:"correction"`
+	Category   string `json:"category"`
+
+	// Original and Corrected are what the correction changed
+	Original  string `json:"original"`
+	Corrected string `json:"corrected"`
+
+	Occurred types.DateTime `json:"occurred"`
+}
+
+func FromRecord(rec *core.Record) Mistake {
+	return Mistake{
+		Id:         rec.Id,
+		User:       rec.GetString("user"),
+		Correction: rec.GetString("correction"),
+		Category:   rec.GetString("category"),
+		Original:   rec.GetString("original"),
+		Corrected:  rec.GetString("corrected"),
+		Occurred:   rec.GetDateTime("occurred"),
+	}
+}
+
+// Trend says how a kind of mistake is developing
+const (
+	TrendShrinking = "shrinking"
+	TrendSteady    = "steady"
+	TrendGrowing   = "growing"
+)
+
+// Trends are the user's mistakes counted by week, oldest week first
+type Trends struct {
+	Weeks      []types.DateTime `json:"weeks"`
+	Categories []CategoryTrend  `json:"categories"`
+}
+
+type CategoryTrend struct {
+	Category string `json:"category"`
+
+	// Counts are the mistakes of each week of Trends.Weeks
+	Counts []int  `json:"counts"`
+	Total  int    `json:"total"`
+	Trend  string `json:"trend"`
+}
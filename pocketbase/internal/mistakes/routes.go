@@ -0,0 +1,48 @@
+package mistakes
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/settings"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+const (
+	defaultTrendWeeks = 12
+	maxTrendWeeks     = 104
+)
+
+func RegisterRoutes(g *api.Group, mistakesService Service, settingsService settings.Service) {
+	g.GET("/stats/errors", "The user's accepted corrections by kind of mistake and week, over the last ?weeks= (12 by default), with whether each kind is shrinking", Trends{}, func(e *core.RequestEvent) error {
+		weeks := defaultTrendWeeks
+		if raw := e.Request.URL.Query().Get("weeks"); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil || n < 2 || n > maxTrendWeeks {
+				return e.BadRequestError("Invalid stats request.", validation.Errors{
+					"weeks": validation.NewError("validation_out_of_range", "Must be between {{.min}} and {{.max}}.").
+						SetParams(map[string]any{"min": 2, "max": maxTrendWeeks}),
+				})
+			}
+			weeks = n
+		}
+
+		userSettings, err := settingsService.ForUser(e.Auth.Id)
+		if err != nil {
+			return e.InternalServerError("Failed to load settings.", err)
+		}
+		loc, err := time.LoadLocation(userSettings.Timezone)
+		if err != nil {
+			loc = time.UTC
+		}
+
+		trends, err := mistakesService.Trends(e.Auth.Id, weeks, time.Now().In(loc))
+		if err != nil {
+			return e.InternalServerError("Failed to load error stats.", err)
+		}
+		return e.JSON(200, trends)
+	})
+}
@@ -0,0 +1,107 @@
+package mistakes
+
+import (
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/journal"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// trendChange is how much the rate of a kind of mistake must change between
+// the two halves of a period to count as shrinking or growing
+const trendChange = 0.2
+
+type Service interface {
+	// Trends counts the user's mistakes of each kind in the given number of
+	// weeks up to the one of now, in now's time zone
+	Trends(userId string, weeks int, now time.Time) (Trends, error)
+}
+
+type service struct {
+	app            core.App
+	journalService journal.Service
+}
+
+func NewService(app core.App, journalService journal.Service) Service {
+	return &service{app: app, journalService: journalService}
+}
+
+func (s *service) Trends(userId string, weeks int, now time.Time) (Trends, error) {
+	sinceMonday := (int(now.Weekday()) + 6) % 7
+	thisWeek := time.Date(now.Year(), now.Month(), now.Day()-sinceMonday, 0, 0, 0, 0, now.Location())
+	start := thisWeek.AddDate(0, 0, -7*(weeks-1))
+
+	trends := Trends{Weeks: make([]types.DateTime, weeks), Categories: make([]CategoryTrend, 0, len(Categories))}
+	for i := range weeks {
+		trends.Weeks[i], _ = types.ParseDateTime(start.AddDate(0, 0, 7*i))
+	}
+	week := func(at time.Time) int {
+		return int(at.Sub(start) / (7 * 24 * time.Hour))
+	}
+
+	from, _ := types.ParseDateTime(start)
+	records, err := s.app.FindRecordsByFilter("errors", "user = {:user} && occurred >= {:from}", "occurred", 0, 0, dbx.Params{"user": userId, "from": from.String()})
+	if err != nil {
+		return Trends{}, err
+	}
+	counts := map[string][]int{}
+	for _, category := range Categories {
+		counts[category] = make([]int, weeks)
+	}
+	for _, rec := range records {
+		mistake := FromRecord(rec)
+		if i := week(mistake.Occurred.Time()); i >= 0 && i < weeks {
+			counts[mistake.Category][i]++
+		}
+	}
+
+	// mistakes are weighed against how much the user wrote, writing twice as
+	// much isn't getting worse
+	entries, err := s.journalService.EntriesBetween(userId, from, types.DateTime{})
+	if err != nil {
+		return Trends{}, err
+	}
+	written := make([]int, weeks)
+	for _, entry := range entries {
+		if i := week(entry.Created.Time()); i >= 0 && i < weeks {
+			written[i]++
+		}
+	}
+
+	for _, category := range Categories {
+		trend := CategoryTrend{Category: category, Counts: counts[category], Trend: trendOf(counts[category], written)}
+		for _, n := range trend.Counts {
+			trend.Total += n
+		}
+		trends.Categories = append(trends.Categories, trend)
+	}
+	return trends, nil
+}
+
+// trendOf compares the mistakes per entry of the older half of the weeks to
+// the newer half
+func trendOf(counts []int, written []int) string {
+	half := len(counts) / 2
+	rate := func(from int, to int) float64 {
+		mistakes, entries := 0, 0
+		for i := from; i < to; i++ {
+			mistakes += counts[i]
+			entries += written[i]
+		}
+		return float64(mistakes) / float64(max(entries, 1))
+	}
+
+	older, newer := rate(0, half), rate(half, len(counts))
+	switch {
+	case older == 0 && newer == 0:
+		return TrendSteady
+	case newer < older*(1-trendChange):
+		return TrendShrinking
+	case newer > older*(1+trendChange):
+		return TrendGrowing
+	}
+	return TrendSteady
+}
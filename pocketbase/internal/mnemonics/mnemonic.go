@@ -0,0 +1,40 @@
+package mnemonics
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Mnemonic is a user's memory aid for a grammar, shared with everyone when
+// public
+type Mnemonic struct {
+	Id      string `json:"id"`
+	User    string `json:"user"`
+	Grammar string `json:"grammar"`
+	Text    string `json:"text"`
+	Public  bool   `json:"public"`
+	Votes   int    `json:"votes"`
+
+	// Voted is set when the user the mnemonic was loaded for voted for it
+	Voted bool `json:"voted"`
+
+	// Held public mnemonics wait for a moderator before others see them
+	Held bool `json:"held"`
+
+	Created types.DateTime `json:"created"`
+	Updated types.DateTime `json:"updated"`
+}
+
+func FromRecord(rec *core.Record) Mnemonic {
+	return Mnemonic{
+		Id:      rec.Id,
+		User:    rec.GetString("user"),
+		Grammar: rec.GetString("grammar"),
+		Text:    rec.GetString("text"),
+		Public:  rec.GetBool("public"),
+		Votes:   rec.GetInt("votes"),
+		Held:    rec.GetBool("held"),
+		Created: rec.GetDateTime("created"),
+		Updated: rec.GetDateTime("updated"),
+	}
+}
@@ -0,0 +1,33 @@
+package mnemonics
+
+import (
+	"errors"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+func RegisterRoutes(g *api.Group, mnemonicsService Service) {
+	g.POST("/mnemonics/{id}/vote", "Vote for someone else's public mnemonic", nil, Mnemonic{}, func(e *core.RequestEvent) error {
+		mnemonic, err := mnemonicsService.Vote(e.Auth.Id, e.Request.PathValue("id"))
+		if errors.Is(err, ErrOwnMnemonic) {
+			return e.BadRequestError("You can't vote for your own mnemonic.", err)
+		}
+		if err != nil {
+			return e.NotFoundError("", err)
+		}
+		return e.JSON(200, mnemonic)
+	})
+
+	g.DELETE("/mnemonics/{id}/vote", "Take back a vote for a mnemonic", func(e *core.RequestEvent) error {
+		_, err := mnemonicsService.Unvote(e.Auth.Id, e.Request.PathValue("id"))
+		if errors.Is(err, ErrOwnMnemonic) {
+			return e.BadRequestError("You can't vote for your own mnemonic.", err)
+		}
+		if err != nil {
+			return e.NotFoundError("", err)
+		}
+		return e.NoContent(204)
+	})
+}
@@ -0,0 +1,151 @@
+package mnemonics
+
+import (
+	"errors"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/children"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// TopShown is how many community mnemonics come with a grammar
+const TopShown = 5
+
+var ErrOwnMnemonic = errors.New("can't vote for one's own mnemonic")
+
+type Service interface {
+	// ForGrammar returns the user's own mnemonic for a grammar followed by the
+	// most voted public ones of others, unless the user is a child whose
+	// parent doesn't let them see those
+	ForGrammar(user *core.Record, grammarId string) ([]Mnemonic, error)
+
+	// Vote adds the user's vote to someone else's public mnemonic, voting
+	// twice counts once
+	Vote(userId string, id string) (Mnemonic, error)
+
+	// Unvote takes the user's vote back
+	Unvote(userId string, id string) (Mnemonic, error)
+}
+
+type service struct {
+	app core.App
+}
+
+func NewService(app core.App) Service {
+	return &service{app: app}
+}
+
+func (s *service) ForGrammar(user *core.Record, grammarId string) ([]Mnemonic, error) {
+	userId := user.Id
+	records, err := s.app.FindRecordsByFilter(
+		"mnemonics",
+		"grammar = {:grammar} && user = {:user}",
+		"", 1, 0,
+		dbx.Params{"grammar": grammarId, "user": userId},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if children.ControlsOf(user).PublicContent {
+		top, err := s.app.FindRecordsByFilter(
+			"mnemonics",
+			"grammar = {:grammar} && public = true && held = false && user != {:user}",
+			"-votes,created", TopShown, 0,
+			dbx.Params{"grammar": grammarId, "user": userId},
+		)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, top...)
+	}
+
+	voted, err := s.votedFor(userId, records)
+	if err != nil {
+		return nil, err
+	}
+
+	mnemonics := make([]Mnemonic, 0, len(records))
+	for _, rec := range records {
+		mnemonic := FromRecord(rec)
+		mnemonic.Voted = voted[rec.Id]
+		mnemonics = append(mnemonics, mnemonic)
+	}
+	return mnemonics, nil
+}
+
+func (s *service) Vote(userId string, id string) (Mnemonic, error) {
+	return s.setVote(userId, id, true)
+}
+
+func (s *service) Unvote(userId string, id string) (Mnemonic, error) {
+	return s.setVote(userId, id, false)
+}
+
+func (s *service) setVote(userId string, id string, vote bool) (Mnemonic, error) {
+	var mnemonic Mnemonic
+	err := s.app.RunInTransaction(func(txApp core.App) error {
+		record, err := txApp.FindFirstRecordByFilter("mnemonics", "id = {:id} && public = true && held = false", dbx.Params{"id": id})
+		if err != nil {
+			return err
+		}
+		if record.GetString("user") == userId {
+			return ErrOwnMnemonic
+		}
+
+		existing, _ := txApp.FindFirstRecordByFilter("mnemonic_votes", "mnemonic = {:id} && user = {:user}", dbx.Params{"id": id, "user": userId})
+		switch {
+		case vote && existing == nil:
+			collection, err := txApp.FindCollectionByNameOrId("mnemonic_votes")
+			if err != nil {
+				return err
+			}
+			voteRecord := core.NewRecord(collection)
+			voteRecord.Set("mnemonic", id)
+			voteRecord.Set("user", userId)
+			if err := txApp.Save(voteRecord); err != nil {
+				return err
+			}
+		case !vote && existing != nil:
+			if err := txApp.Delete(existing); err != nil {
+				return err
+			}
+		}
+
+		votes, err := txApp.CountRecords("mnemonic_votes", dbx.HashExp{"mnemonic": id})
+		if err != nil {
+			return err
+		}
+		record.Set("votes", votes)
+		if err := txApp.SaveNoValidate(record); err != nil {
+			return err
+		}
+
+		mnemonic = FromRecord(record)
+		mnemonic.Voted = vote
+		return nil
+	})
+	return mnemonic, err
+}
+
+// votedFor tells which of the records the user voted for
+func (s *service) votedFor(userId string, records []*core.Record) (map[string]bool, error) {
+	voted := map[string]bool{}
+	if len(records) == 0 {
+		return voted, nil
+	}
+
+	ids := make([]any, len(records))
+	for i, rec := range records {
+		ids[i] = rec.Id
+	}
+	votes, err := s.app.FindAllRecords("mnemonic_votes", dbx.HashExp{"user": userId}, dbx.In("mnemonic", ids...))
+	if err != nil {
+		return nil, err
+	}
+	for _, vote := range votes {
+		voted[vote.GetString("mnemonic")] = true
+	}
+	return voted, nil
+}
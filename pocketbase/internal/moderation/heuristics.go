@@ -0,0 +1,111 @@
+package moderation
+
+import (
+	"bytes"
+	"compress/flate"
+	"os"
+	"regexp"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// Reasons content is held for
+const (
+	ReasonLinks       = "links"
+	ReasonLinkDensity = "link_density"
+	ReasonRepetition  = "repetition"
+	ReasonVelocity    = "velocity"
+)
+
+// VelocityWindow is the period public content is counted over for velocity
+const VelocityWindow = time.Hour
+
+// minRepetitionSize is the least text worth measuring repetition on, shorter
+// text compresses badly whatever it is
+const minRepetitionSize = 200
+
+var linkPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S+`)
+
+// Config tunes the heuristics, 0 turning one off
+type Config struct {
+	// MaxLinks is the most links one text may have
+	MaxLinks int
+
+	// MaxLinkShare is the most of a text, by characters, links may take up
+	MaxLinkShare float64
+
+	// MaxRepetition is how much of a text may be repeats of the rest, going
+	// by how well it compresses
+	MaxRepetition float64
+
+	// MaxPerWindow is how much public content of a kind a user may post per
+	// VelocityWindow
+	MaxPerWindow int
+}
+
+var DefaultConfig = Config{
+	MaxLinks:      3,
+	MaxLinkShare:  0.5,
+	MaxRepetition: 0.85,
+	MaxPerWindow:  10,
+}
+
+// ConfigFromEnv reads MODERATION_MAX_LINKS, MODERATION_MAX_LINK_SHARE,
+// MODERATION_MAX_REPETITION and MODERATION_MAX_PER_HOUR over the defaults
+func ConfigFromEnv() Config {
+	config := DefaultConfig
+	if n, err := strconv.Atoi(os.Getenv("MODERATION_MAX_LINKS")); err == nil && n >= 0 {
+		config.MaxLinks = n
+	}
+	if f, err := strconv.ParseFloat(os.Getenv("MODERATION_MAX_LINK_SHARE"), 64); err == nil && f >= 0 && f <= 1 {
+		config.MaxLinkShare = f
+	}
+	if f, err := strconv.ParseFloat(os.Getenv("MODERATION_MAX_REPETITION"), 64); err == nil && f >= 0 && f <= 1 {
+		config.MaxRepetition = f
+	}
+	if n, err := strconv.Atoi(os.Getenv("MODERATION_MAX_PER_HOUR")); err == nil && n >= 0 {
+		config.MaxPerWindow = n
+	}
+	return config
+}
+
+// textReasons runs the heuristics that only need the text itself
+func (c Config) textReasons(text string) []string {
+	var reasons []string
+
+	links := linkPattern.FindAllString(text, -1)
+	if c.MaxLinks > 0 && len(links) > c.MaxLinks {
+		reasons = append(reasons, ReasonLinks)
+	}
+	if c.MaxLinkShare > 0 && len(links) > 0 {
+		linked := 0
+		for _, link := range links {
+			linked += utf8.RuneCountInString(link)
+		}
+		if float64(linked)/float64(utf8.RuneCountInString(text)) > c.MaxLinkShare {
+			reasons = append(reasons, ReasonLinkDensity)
+		}
+	}
+
+	if c.MaxRepetition > 0 && len(text) >= minRepetitionSize && repetition(text) > c.MaxRepetition {
+		reasons = append(reasons, ReasonRepetition)
+	}
+
+	return reasons
+}
+
+// repetition estimates how much of text repeats itself, the share of it
+// compression gets rid of
+func repetition(text string) float64 {
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, flate.BestCompression)
+	if err != nil {
+		return 0
+	}
+	_, _ = w.Write([]byte(text))
+	if err := w.Close(); err != nil {
+		return 0
+	}
+	return 1 - float64(buf.Len())/float64(len(text))
+}
@@ -0,0 +1,122 @@
+package moderation
+
+import (
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/journal"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// screened describes how to read the content of a collection others can see
+type screened struct {
+	text     func(rec *core.Record) string
+	isPublic func(rec *core.Record) bool
+
+	// public matches the same records as isPublic, for counting them
+	public string
+}
+
+var collections = map[string]screened{
+	"journal_entry": {
+		text: journal.Text,
+		isPublic: func(rec *core.Record) bool {
+			return rec.GetBool("published") || rec.GetString("audience") == journal.AudiencePublic
+		},
+		public: "(published = true || audience = 'public')",
+	},
+	"mnemonics": {
+		text: func(rec *core.Record) string {
+			return rec.GetString("text")
+		},
+		isPublic: func(rec *core.Record) bool {
+			return rec.GetBool("public")
+		},
+		public: "public = true",
+	},
+}
+
+// BindHooks screens public entries and mnemonics as they're saved, holding
+// back and queueing those that look like spam. It has to be bound after
+// journal.BindHooks, which gives new entries their audience.
+func BindHooks(app core.App, config Config) {
+	for name, c := range collections {
+		app.OnRecordCreate(name).BindFunc(func(e *core.RecordEvent) error {
+			return screen(e, c, config)
+		})
+		app.OnRecordUpdate(name).BindFunc(func(e *core.RecordEvent) error {
+			return screen(e, c, config)
+		})
+	}
+}
+
+// screen runs the heuristics on content that's public, or just became so or
+// changed while it is, and queues it once it's saved when they trip
+func screen(e *core.RecordEvent, c screened, config Config) error {
+	rec := e.Record
+	// entries imported from the user's own files are theirs to begin with
+	if rec.Collection().Name == "journal_entry" && rec.GetString("import") != "" {
+		return e.Next()
+	}
+	if !c.isPublic(rec) {
+		return e.Next()
+	}
+	if !rec.IsNew() {
+		original := rec.Original()
+		if c.isPublic(original) && c.text(rec) == c.text(original) {
+			return e.Next()
+		}
+	}
+
+	reasons := config.textReasons(c.text(rec))
+	if config.MaxPerWindow > 0 {
+		since, err := types.ParseDateTime(time.Now().Add(-VelocityWindow))
+		if err != nil {
+			return err
+		}
+		n, err := e.App.CountRecords(rec.Collection(),
+			dbx.HashExp{"user": rec.GetString("user")},
+			dbx.NewExp(c.public),
+			dbx.NewExp("created > {:since}", dbx.Params{"since": since.String()}),
+			dbx.NewExp("id != {:id}", dbx.Params{"id": rec.Id}),
+		)
+		if err != nil {
+			return err
+		}
+		if int(n) >= config.MaxPerWindow {
+			reasons = append(reasons, ReasonVelocity)
+		}
+	}
+	if len(reasons) == 0 {
+		return e.Next()
+	}
+
+	rec.Set("held", true)
+	if err := e.Next(); err != nil {
+		return err
+	}
+	return enqueue(e.App, rec, reasons)
+}
+
+// enqueue puts content up for review, again if it was reviewed before
+func enqueue(app core.App, rec *core.Record, reasons []string) error {
+	item, err := app.FindFirstRecordByFilter("moderation_queue", "collection = {:collection} && record = {:record}", dbx.Params{
+		"collection": rec.Collection().Name,
+		"record":     rec.Id,
+	})
+	if err != nil {
+		collection, err := app.FindCollectionByNameOrId("moderation_queue")
+		if err != nil {
+			return err
+		}
+		item = core.NewRecord(collection)
+		item.Set("collection", rec.Collection().Name)
+		item.Set("record", rec.Id)
+	}
+	item.Set("user", rec.GetString("user"))
+	item.Set("reasons", reasons)
+	item.Set("status", StatusPending)
+	return app.Save(item)
+}
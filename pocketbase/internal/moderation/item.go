@@ -0,0 +1,44 @@
+package moderation
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Statuses of queued content
+const (
+	StatusPending  = "pending"
+	StatusApproved = "approved"
+	StatusRemoved  = "removed"
+)
+
+// Item is public content the heuristics held back for a superuser to review
+type Item struct {
+	Id         string   `json:"id"`
+	Collection string   `json:"collection"`
+	Record     string   `json:"record"`
+	User       string   `json:"user"`
+	Reasons    []string `json:"reasons"`
+	Status     string   `json:"status"`
+
+	// Text is what the content says, empty once it's removed
+	Text string `json:"text"`
+
+	Created types.DateTime `json:"created"`
+	Updated types.DateTime `json:"updated"`
+}
+
+func FromRecord(rec *core.Record) Item {
+	var reasons []string
+	_ = rec.UnmarshalJSONField("reasons", &reasons)
+	return Item{
+		Id:         rec.Id,
+		Collection: rec.GetString("collection"),
+		Record:     rec.GetString("record"),
+		User:       rec.GetString("user"),
+		Reasons:    reasons,
+		Status:     rec.GetString("status"),
+		Created:    rec.GetDateTime("created"),
+		Updated:    rec.GetDateTime("updated"),
+	}
+}
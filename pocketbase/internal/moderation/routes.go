@@ -0,0 +1,55 @@
+package moderation
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// RegisterRoutes adds the moderation queue, for superusers and moderators
+func RegisterRoutes(g *api.Group, moderationService Service) {
+	g.GET("/moderation", "Public content held back as likely spam, pending review unless ?status= is approved or removed", []Item{}, func(e *core.RequestEvent) error {
+		status := e.Request.URL.Query().Get("status")
+		switch status {
+		case "":
+			status = StatusPending
+		case StatusPending, StatusApproved, StatusRemoved:
+		default:
+			return e.BadRequestError("Invalid status.", validation.Errors{
+				"status": validation.NewError("validation_invalid_value", "Invalid value."),
+			})
+		}
+
+		items, err := moderationService.List(status)
+		if err != nil {
+			return e.InternalServerError("Failed to load the moderation queue.", err)
+		}
+		return e.JSON(200, items)
+	})
+
+	g.POST("/moderation/{id}/approve", "Show held content to others again", nil, Item{}, func(e *core.RequestEvent) error {
+		item, err := moderationService.Approve(e.Request.PathValue("id"))
+		if errors.Is(err, sql.ErrNoRows) {
+			return e.NotFoundError("", err)
+		}
+		if err != nil {
+			return e.InternalServerError("Failed to approve the content.", err)
+		}
+		return e.JSON(200, item)
+	})
+
+	g.POST("/moderation/{id}/remove", "Delete held content", nil, Item{}, func(e *core.RequestEvent) error {
+		item, err := moderationService.Remove(e.Request.PathValue("id"))
+		if errors.Is(err, sql.ErrNoRows) {
+			return e.NotFoundError("", err)
+		}
+		if err != nil {
+			return e.InternalServerError("Failed to remove the content.", err)
+		}
+		return e.JSON(200, item)
+	})
+}
@@ -0,0 +1,99 @@
+package moderation
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+type Service interface {
+	// List returns the queue's items with a status, oldest first
+	List(status string) ([]Item, error)
+
+	// Approve shows held content to others and marks its item approved
+	Approve(id string) (Item, error)
+
+	// Remove deletes held content and marks its item removed
+	Remove(id string) (Item, error)
+}
+
+type service struct {
+	app core.App
+}
+
+func NewService(app core.App) Service {
+	return &service{app: app}
+}
+
+func (s *service) List(status string) ([]Item, error) {
+	records, err := s.app.FindRecordsByFilter("moderation_queue", "status = {:status}", "created", 0, 0, dbx.Params{"status": status})
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]Item, 0, len(records))
+	for _, rec := range records {
+		items = append(items, s.withText(FromRecord(rec)))
+	}
+	return items, nil
+}
+
+func (s *service) Approve(id string) (Item, error) {
+	return s.review(id, StatusApproved, func(txApp core.App, content *core.Record) error {
+		content.Set("held", false)
+		return txApp.Save(content)
+	})
+}
+
+func (s *service) Remove(id string) (Item, error) {
+	return s.review(id, StatusRemoved, func(txApp core.App, content *core.Record) error {
+		return txApp.Delete(content)
+	})
+}
+
+// review settles an item, doing what the status means to its content unless
+// the user deleted it already
+func (s *service) review(id string, status string, apply func(txApp core.App, content *core.Record) error) (Item, error) {
+	var saved *core.Record
+	err := s.app.RunInTransaction(func(txApp core.App) error {
+		rec, err := txApp.FindRecordById("moderation_queue", id)
+		if err != nil {
+			return err
+		}
+
+		content, err := txApp.FindRecordById(rec.GetString("collection"), rec.GetString("record"))
+		switch {
+		case err == nil:
+			if err := apply(txApp, content); err != nil {
+				return err
+			}
+		case !errors.Is(err, sql.ErrNoRows):
+			return err
+		}
+
+		rec.Set("status", status)
+		if err := txApp.Save(rec); err != nil {
+			return err
+		}
+		saved = rec
+		return nil
+	})
+	if err != nil {
+		return Item{}, err
+	}
+	return s.withText(FromRecord(saved)), nil
+}
+
+// withText fills in what the item's content says, if it's still there
+func (s *service) withText(item Item) Item {
+	c, ok := collections[item.Collection]
+	if !ok {
+		return item
+	}
+	if content, err := s.app.FindRecordById(item.Collection, item.Record); err == nil {
+		item.Text = c.text(content)
+	}
+	return item
+}
@@ -0,0 +1,150 @@
+package notifications
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	apnsProductionHost  = "https://api.push.apple.com"
+	apnsDevelopmentHost = "https://api.sandbox.push.apple.com"
+
+	// Apple rejects provider tokens older than an hour and throttles ones
+	// refreshed more often than every 20 minutes
+	apnsTokenLifetime = 40 * time.Minute
+)
+
+// errAPNsUnregistered means the device token is no longer valid and should be
+// forgotten
+var errAPNsUnregistered = errors.New("apns device token is no longer registered")
+
+type apnsClient struct {
+	host   string
+	topic  string
+	keyId  string
+	teamId string
+	key    *ecdsa.PrivateKey
+	http   *http.Client
+
+	mu        sync.Mutex
+	token     string
+	tokenTime time.Time
+}
+
+// newAPNsClientFromEnv returns nil when push notifications are not configured
+func newAPNsClientFromEnv() (*apnsClient, error) {
+	keyFile := os.Getenv("APNS_KEY_FILE")
+	if keyFile == "" {
+		return nil, nil
+	}
+
+	raw, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, fmt.Errorf("apns key %s is not PEM encoded", keyFile)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := parsed.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("apns key %s is not an ECDSA key", keyFile)
+	}
+
+	host := apnsDevelopmentHost
+	if os.Getenv("IS_PROD") == "true" {
+		host = apnsProductionHost
+	}
+
+	return &apnsClient{
+		host:   host,
+		topic:  os.Getenv("APNS_TOPIC"),
+		keyId:  os.Getenv("APNS_KEY_ID"),
+		teamId: os.Getenv("APNS_TEAM_ID"),
+		key:    key,
+		http:   &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+func (c *apnsClient) providerToken() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" && time.Since(c.tokenTime) < apnsTokenLifetime {
+		return c.token, nil
+	}
+
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iss": c.teamId,
+		"iat": now.Unix(),
+	})
+	token.Header["kid"] = c.keyId
+
+	signed, err := token.SignedString(c.key)
+	if err != nil {
+		return "", err
+	}
+	c.token = signed
+	c.tokenTime = now
+	return signed, nil
+}
+
+// pushBadge sets the app icon badge without showing an alert or playing a sound
+func (c *apnsClient) pushBadge(deviceToken string, badge int) error {
+	payload, err := json.Marshal(map[string]any{
+		"aps": map[string]any{"badge": badge},
+	})
+	if err != nil {
+		return err
+	}
+
+	token, err := c.providerToken()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.host+"/3/device/"+deviceToken, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("authorization", "bearer "+token)
+	req.Header.Set("apns-topic", c.topic)
+	req.Header.Set("apns-push-type", "alert")
+	req.Header.Set("apns-priority", "5")
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	var body struct {
+		Reason string `json:"reason"`
+	}
+	_ = json.NewDecoder(res.Body).Decode(&body)
+
+	if res.StatusCode == http.StatusGone || body.Reason == "BadDeviceToken" || body.Reason == "Unregistered" {
+		return errAPNsUnregistered
+	}
+	return fmt.Errorf("apns push failed with status %d: %s", res.StatusCode, body.Reason)
+}
@@ -0,0 +1,76 @@
+package notifications
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPushBadge(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		status       int
+		body         string
+		unregistered bool
+		fails        bool
+	}{
+		{"pushed", http.StatusOK, "", false, false},
+		{"token gone", http.StatusGone, `{"reason":"Unregistered"}`, true, true},
+		{"bad token", http.StatusBadRequest, `{"reason":"BadDeviceToken"}`, true, true},
+		{"other failure", http.StatusInternalServerError, `{"reason":"InternalServerError"}`, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/3/device/device-token" {
+					t.Errorf("pushed to %s", r.URL.Path)
+				}
+				if r.Header.Get("apns-topic") != "tech.bunkbed.fushigi" || !strings.HasPrefix(r.Header.Get("authorization"), "bearer ") {
+					t.Errorf("pushed without the topic or provider token: %v", r.Header)
+				}
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			client := &apnsClient{host: server.URL, topic: "tech.bunkbed.fushigi", keyId: "key", teamId: "team", key: key, http: server.Client()}
+			err := client.pushBadge("device-token", 3)
+			if (err != nil) != tt.fails {
+				t.Fatalf("pushBadge() error = %v, want failure %v", err, tt.fails)
+			}
+			if errors.Is(err, errAPNsUnregistered) != tt.unregistered {
+				t.Errorf("pushBadge() error = %v, want unregistered %v", err, tt.unregistered)
+			}
+		})
+	}
+}
+
+func TestProviderTokenIsReused(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &apnsClient{keyId: "key", teamId: "team", key: key}
+
+	first, err := client.providerToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := client.providerToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == "" || first != second {
+		t.Errorf("got tokens %q and %q, want the same one", first, second)
+	}
+}
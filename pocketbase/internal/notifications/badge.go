@@ -0,0 +1,83 @@
+package notifications
+
+import (
+	"errors"
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/srs"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// BadgePusher keeps the app icon badge of the user's Apple devices in sync
+// with their due count
+type BadgePusher struct {
+	app    core.App
+	srs    srs.Service
+	client *apnsClient
+}
+
+func NewBadgePusher(app core.App, srsService srs.Service, client *apnsClient) *BadgePusher {
+	return &BadgePusher{app: app, srs: srsService, client: client}
+}
+
+// Pushing talks to Apple, so keep it off the request that saved the review
+func (b *BadgePusher) DueCountChanged(userId string) {
+	go b.push(userId)
+}
+
+func (b *BadgePusher) Rollover() {
+	devices, err := b.app.FindAllRecords("devices")
+	if err != nil {
+		b.app.Logger().Error("Failed to load devices for badge rollover", "error", err)
+		return
+	}
+
+	seen := map[string]bool{}
+	for _, device := range devices {
+		userId := device.GetString("user")
+		if seen[userId] {
+			continue
+		}
+		seen[userId] = true
+		b.push(userId)
+	}
+}
+
+// push sends the user's due count to each of their devices, skipping devices
+// that already show the right number
+func (b *BadgePusher) push(userId string) {
+	devices, err := b.app.FindRecordsByFilter("devices", "user = {:user}", "", 0, 0, map[string]any{"user": userId})
+	if err != nil || len(devices) == 0 {
+		return
+	}
+
+	due, err := b.srs.CountDue(userId, time.Now())
+	if err != nil {
+		b.app.Logger().Error("Failed to count due cards", "user", userId, "error", err)
+		return
+	}
+
+	for _, device := range devices {
+		if device.GetInt("badge") == due {
+			continue
+		}
+
+		err := b.client.pushBadge(device.GetString("token"), due)
+		if errors.Is(err, errAPNsUnregistered) {
+			if err := b.app.Delete(device); err != nil {
+				b.app.Logger().Error("Failed to delete unregistered device", "device", device.Id, "error", err)
+			}
+			continue
+		}
+		if err != nil {
+			b.app.Logger().Error("Failed to push badge count", "device", device.Id, "error", err)
+			continue
+		}
+
+		device.Set("badge", due)
+		if err := b.app.Save(device); err != nil {
+			b.app.Logger().Error("Failed to store pushed badge count", "device", device.Id, "error", err)
+		}
+	}
+}
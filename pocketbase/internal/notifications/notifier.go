@@ -0,0 +1,53 @@
+package notifications
+
+import (
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/events"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/srs"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// Notifier is told whenever a user's due count may have changed
+type Notifier interface {
+	// DueCountChanged is called after one of the user's cards changed
+	DueCountChanged(userId string)
+
+	// Rollover is called periodically since cards also fall due as time passes
+	Rollover()
+}
+
+// BindHooks wires the realtime and (when configured) APNs notifiers to the
+// card events and to the hourly rollover
+func BindHooks(app core.App, bus *events.Bus, srsService srs.Service) {
+	realtime := NewRealtime(app, srsService)
+	realtime.bindSubscribeHook()
+
+	notifiers := []Notifier{realtime}
+
+	client, err := newAPNsClientFromEnv()
+	if err != nil {
+		app.Logger().Error("Failed to configure APNs, badge pushes are disabled", "error", err)
+	}
+	if client != nil {
+		notifiers = append(notifiers, NewBadgePusher(app, srsService, client))
+	}
+
+	// reviews reschedule the card they're of, so they're counted here too
+	changed := func(e *events.Event) error {
+		for _, n := range notifiers {
+			n.DueCountChanged(e.User)
+		}
+		return e.Next()
+	}
+	bus.On(events.CardAdded).BindFunc(changed)
+	bus.On(events.CardRescheduled).BindFunc(changed)
+	bus.On(events.CardRemoved).BindFunc(changed)
+
+	// Users live in different time zones so roll over every hour instead of
+	// at midnight
+	app.Cron().MustAdd("fushigiDueCountRollover", "0 * * * *", func() {
+		for _, n := range notifiers {
+			n.Rollover()
+		}
+	})
+}
@@ -0,0 +1,86 @@
+package notifications
+
+import (
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/srs"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/subscriptions"
+)
+
+// Clients subscribe to this topic through the regular /api/realtime endpoint
+const DueCountTopic = "fushigi/due_count"
+
+// Realtime publishes due counts to the user's subscribed realtime clients
+type Realtime struct {
+	app core.App
+	srs srs.Service
+}
+
+func NewRealtime(app core.App, srsService srs.Service) *Realtime {
+	return &Realtime{app: app, srs: srsService}
+}
+
+func (r *Realtime) DueCountChanged(userId string) {
+	r.publish(userId, r.subscribers()[userId])
+}
+
+func (r *Realtime) Rollover() {
+	for userId, clients := range r.subscribers() {
+		r.publish(userId, clients)
+	}
+}
+
+// Send the current count right away so clients don't wait for the next change
+func (r *Realtime) bindSubscribeHook() {
+	r.app.OnRealtimeSubscribeRequest().BindFunc(func(e *core.RealtimeSubscribeRequestEvent) error {
+		if err := e.Next(); err != nil {
+			return err
+		}
+		if e.Auth != nil && slices.Contains(e.Subscriptions, DueCountTopic) {
+			r.DueCountChanged(e.Auth.Id)
+		}
+		return nil
+	})
+}
+
+func (r *Realtime) publish(userId string, clients []subscriptions.Client) {
+	if len(clients) == 0 {
+		return
+	}
+
+	due, err := r.srs.CountDue(userId, time.Now())
+	if err != nil {
+		r.app.Logger().Error("Failed to count due cards", "user", userId, "error", err)
+		return
+	}
+
+	data, err := json.Marshal(map[string]any{"due": due})
+	if err != nil {
+		return
+	}
+
+	message := subscriptions.Message{Name: DueCountTopic, Data: data}
+	for _, client := range clients {
+		client.Send(message)
+	}
+}
+
+func (r *Realtime) subscribers() map[string][]subscriptions.Client {
+	byUser := map[string][]subscriptions.Client{}
+	for _, client := range r.app.SubscriptionsBroker().Clients() {
+		if !client.HasSubscription(DueCountTopic) {
+			continue
+		}
+		auth, _ := client.Get(apis.RealtimeClientAuthKey).(*core.Record)
+		if auth == nil || auth.Collection().Name != "users" {
+			continue
+		}
+		byUser[auth.Id] = append(byUser[auth.Id], client)
+	}
+	return byUser
+}
@@ -0,0 +1,25 @@
+package notifications
+
+import (
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/srs"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+type badgeResponse struct {
+	Badge int `json:"badge"`
+}
+
+func RegisterRoutes(g *api.Group, srsService srs.Service) {
+	// Lets the app set its own badge on launch, matching what pushes would send
+	g.GET("/badge", "Current app icon badge count", badgeResponse{}, func(e *core.RequestEvent) error {
+		due, err := srsService.CountDue(e.Auth.Id, time.Now())
+		if err != nil {
+			return e.InternalServerError("Failed to count due cards.", err)
+		}
+		return e.JSON(200, badgeResponse{Badge: due})
+	})
+}
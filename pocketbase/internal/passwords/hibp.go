@@ -0,0 +1,53 @@
+package passwords
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const pwnedRangeURL = "https://api.pwnedpasswords.com/range/"
+
+var pwnedClient = &http.Client{Timeout: 3 * time.Second}
+
+// Breaches returns how many times password appears in known breaches, using
+// the k-anonymity range API so the password itself is never sent
+func Breaches(ctx context.Context, password string) (int, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pwnedRangeURL+prefix, nil)
+	if err != nil {
+		return 0, err
+	}
+	// Padding hides how many suffixes share the prefix from anyone watching
+	req.Header.Set("Add-Padding", "true")
+
+	res, err := pwnedClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("pwned passwords returned %s", res.Status)
+	}
+
+	scanner := bufio.NewScanner(res.Body)
+	for scanner.Scan() {
+		candidate, count, found := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if !found || candidate != suffix {
+			continue
+		}
+		// padding entries have a count of 0
+		return strconv.Atoi(count)
+	}
+	return 0, scanner.Err()
+}
@@ -0,0 +1,69 @@
+package passwords
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/spf13/cast"
+)
+
+// BindHooks holds every password a user sets through the API to the policy.
+// Checks run on the requests rather than on save so that seeded and
+// migrated accounts aren't affected.
+func BindHooks(app core.App, policy Policy) {
+	app.OnRecordCreateRequest("users").BindFunc(func(e *core.RecordRequestEvent) error {
+		if err := check(e.RequestEvent, e.Record, policy); err != nil {
+			return err
+		}
+		return e.Next()
+	})
+
+	app.OnRecordUpdateRequest("users").BindFunc(func(e *core.RecordRequestEvent) error {
+		if err := check(e.RequestEvent, e.Record, policy); err != nil {
+			return err
+		}
+		return e.Next()
+	})
+
+	app.OnRecordConfirmPasswordResetRequest("users").BindFunc(func(e *core.RecordConfirmPasswordResetRequestEvent) error {
+		if err := check(e.RequestEvent, e.Record, policy); err != nil {
+			return err
+		}
+		return e.Next()
+	})
+}
+
+func check(e *core.RequestEvent, user *core.Record, policy Policy) error {
+	info, err := e.RequestInfo()
+	if err != nil {
+		return err
+	}
+	// OAuth2 signups get a random password PocketBase makes up, which the
+	// user never types and which shouldn't be sent anywhere
+	if info.Context == core.RequestInfoContextOAuth2 {
+		return nil
+	}
+	password := cast.ToString(info.Body["password"])
+	if password == "" {
+		return nil
+	}
+
+	// the user's own details are the first thing an attacker would try
+	var userInputs []string
+	for _, field := range []string{"email", "name", "username"} {
+		if value := cast.ToString(info.Body[field]); value != "" {
+			userInputs = append(userInputs, value)
+		}
+		if value := user.GetString(field); value != "" {
+			userInputs = append(userInputs, value)
+		}
+	}
+
+	errs, err := policy.Validate(e.Request.Context(), password, userInputs...)
+	if err != nil {
+		// an outage shouldn't block signups, the strength check still applies
+		e.App.Logger().Warn("Failed to check password breaches", "error", err)
+	}
+	if errs != nil {
+		return e.BadRequestError("The password doesn't meet the password policy.", errs)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package passwords
+
+import (
+	"context"
+	"os"
+	"strconv"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/nbutton23/zxcvbn-go"
+)
+
+// Policy is what a new password must satisfy. Self-hosters tune it with
+// PASSWORD_MIN_SCORE and PASSWORD_CHECK_BREACHES.
+type Policy struct {
+	// zxcvbn score from 0 (guessable in a few tries) to 4 (very unguessable)
+	MinScore int
+
+	// Whether to look the password up in the HaveIBeenPwned breach corpus.
+	// Only the first 5 characters of its SHA-1 hash ever leave the server.
+	CheckBreaches bool
+}
+
+var DefaultPolicy = Policy{MinScore: 3, CheckBreaches: true}
+
+func PolicyFromEnv() Policy {
+	policy := DefaultPolicy
+	if score, err := strconv.Atoi(os.Getenv("PASSWORD_MIN_SCORE")); err == nil && score >= 0 && score <= 4 {
+		policy.MinScore = score
+	}
+	if check, err := strconv.ParseBool(os.Getenv("PASSWORD_CHECK_BREACHES")); err == nil {
+		policy.CheckBreaches = check
+	}
+	return policy
+}
+
+// Validate holds password to the policy, returning the errors of the
+// password field when it falls short. The error is the breach check's,
+// which is skipped when the corpus can't be reached.
+func (p Policy) Validate(ctx context.Context, password string, userInputs ...string) (validation.Errors, error) {
+	if Score(password, userInputs...) < p.MinScore {
+		return validation.Errors{
+			"password": validation.NewError("validation_password_too_weak", "The password is too easy to guess."),
+		}, nil
+	}
+
+	if !p.CheckBreaches {
+		return nil, nil
+	}
+	count, err := Breaches(ctx, password)
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return validation.Errors{
+			"password": validation.NewError("validation_password_breached", "This password has appeared in a data breach."),
+		}, nil
+	}
+	return nil, nil
+}
+
+// Score estimates how hard password is to guess, penalizing passwords built
+// from the user's own details
+func Score(password string, userInputs ...string) int {
+	return zxcvbn.PasswordStrength(password, append(userInputs, "fushigi")).Score
+}
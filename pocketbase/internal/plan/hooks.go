@@ -0,0 +1,27 @@
+package plan
+
+import (
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/events"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// BindHooks works out each user's queue for the new day at their midnight,
+// and drops it when cards are added so they can be studied right away
+func BindHooks(app core.App, bus *events.Bus, planService Service) {
+	// Users live in different time zones so roll over every hour
+	app.Cron().MustAdd("fushigiQueueRollover", "0 * * * *", func() {
+		if _, err := planService.Rollover(time.Now()); err != nil {
+			app.Logger().Error("Failed to roll over the review queues", "error", err)
+		}
+	})
+
+	bus.On(events.CardAdded).BindFunc(func(e *events.Event) error {
+		if err := planService.Invalidate(e.User); err != nil {
+			e.App.Logger().Error("Failed to drop the review queue", "user", e.User, "error", err)
+		}
+		return e.Next()
+	})
+}
@@ -0,0 +1,50 @@
+package plan
+
+import (
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/srs"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// rolloverHour is the hour of the night, in each user's time zone, their
+// queue for the new day is worked out
+const rolloverHour = 0
+
+// Queue is what the user has to study today, due reviews most overdue first
+// and new cards up to the day's limit
+type Queue struct {
+	// Date is the day the queue is for in the user's timezone
+	Date    string     `json:"date"`
+	Reviews []srs.Card `json:"reviews"`
+	New     []srs.Card `json:"new"`
+
+	// Updated is when the queue was worked out, cards studied since then
+	// are left out
+	Updated types.DateTime `json:"updated"`
+}
+
+// storedQueue is a queue as saved, by srs record id
+type storedQueue struct {
+	Date    string
+	Reviews []string
+	New     []string
+	Updated types.DateTime
+}
+
+func storedQueueFromRecord(rec *core.Record) storedQueue {
+	queue := storedQueue{
+		Date:    rec.GetString("date"),
+		Updated: rec.GetDateTime("updated"),
+	}
+	rec.UnmarshalJSONField("reviews", &queue.Reviews)
+	rec.UnmarshalJSONField("new", &queue.New)
+	return queue
+}
+
+// rollingOver reports whether it's the rollover hour in loc
+func rollingOver(loc *time.Location, now time.Time) bool {
+	return now.In(loc).Hour() == rolloverHour
+}
@@ -0,0 +1,44 @@
+package plan
+
+import (
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/i18n"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+func RegisterRoutes(g *api.Group, planService Service) {
+	g.POST("/sessions/plan", "Plan and start a guided study session of due reviews and new grammar, stopped like any other session", SessionRequest{}, Session{}, func(e *core.RequestEvent) error {
+		var req SessionRequest
+		if err := e.BindBody(&req); err != nil {
+			return e.BadRequestError("Invalid request body.", err)
+		}
+		if err := req.Validate(); err != nil {
+			return e.BadRequestError("Invalid study session.", err)
+		}
+
+		session, err := planService.StartSession(e.Auth.Id, req)
+		if err != nil {
+			return e.InternalServerError("Failed to plan the study session.", err)
+		}
+		return e.JSON(200, session)
+	})
+
+	g.GET("/plan/today", "What to do today: reviews, the new grammar the daily goal has time for, a journal prompt, and a known grammar to use", Today{}, func(e *core.RequestEvent) error {
+		today, err := planService.Today(e.Auth.Id, i18n.FromRequest(e), time.Now())
+		if err != nil {
+			return e.InternalServerError("Failed to plan the day.", err)
+		}
+		return e.JSON(200, today)
+	})
+
+	g.GET("/plan/queue", "Today's review queue: the due reviews, most overdue first, and the new cards the day brings in", Queue{}, func(e *core.RequestEvent) error {
+		queue, err := planService.Queue(e.Auth.Id, time.Now())
+		if err != nil {
+			return e.InternalServerError("Failed to load the review queue.", err)
+		}
+		return e.JSON(200, queue)
+	})
+}
@@ -0,0 +1,374 @@
+package plan
+
+import (
+	"slices"
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/grammar"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/i18n"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/journal"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/sessions"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/settings"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/srs"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+type Service interface {
+	// StartSession plans a guided study session from the user's cards and
+	// starts it
+	StartSession(userId string, req SessionRequest) (Session, error)
+
+	// Today composes the user's plan for the day, with the prompt in locale
+	Today(userId string, locale string, now time.Time) (Today, error)
+
+	// Queue returns the user's queue for the day, the one the rollover saved
+	// when there's one and working it out otherwise
+	Queue(userId string, now time.Time) (Queue, error)
+
+	// Rollover works out the next day's queue of the users with cards whose
+	// night it is, returning how many it saved
+	Rollover(now time.Time) (int, error)
+
+	// Invalidate drops the user's saved queue, worked out again when it's
+	// next asked for
+	Invalidate(userId string) error
+}
+
+type service struct {
+	app             core.App
+	srsService      srs.Service
+	sessionsService sessions.Service
+	grammarService  grammar.Service
+	journalService  journal.Service
+	settingsService settings.Service
+}
+
+func NewService(app core.App, srsService srs.Service, sessionsService sessions.Service, grammarService grammar.Service, journalService journal.Service, settingsService settings.Service) Service {
+	return &service{
+		app:             app,
+		srsService:      srsService,
+		sessionsService: sessionsService,
+		grammarService:  grammarService,
+		journalService:  journalService,
+		settingsService: settingsService,
+	}
+}
+
+func (s *service) StartSession(userId string, req SessionRequest) (Session, error) {
+	cards, err := s.srsService.Cards(userId)
+	if err != nil {
+		return Session{}, err
+	}
+
+	ranks, err := s.frequencyRanks(cards)
+	if err != nil {
+		return Session{}, err
+	}
+
+	now := time.Now()
+	dayStart, err := s.dayStart(userId, now)
+	if err != nil {
+		return Session{}, err
+	}
+	newLeft, reviewsLeft, err := s.left(userId, dayStart)
+	if err != nil {
+		return Session{}, err
+	}
+
+	target := req.target()
+	reviews, learn := srs.SelectDue(cards, ranks, newLeft, reviewsLeft, now)
+	reviews, learn = planSession(reviews, learn, target, req.newShare())
+
+	session, err := s.sessionsService.StartPlanned(userId, target, sessions.Plan{Reviews: ids(reviews), New: ids(learn)})
+	if err != nil {
+		return Session{}, err
+	}
+	return Session{
+		Session:  session,
+		Reviews:  reviews,
+		New:      learn,
+		Estimate: int(estimate(reviews, learn).Seconds()),
+	}, nil
+}
+
+func (s *service) Today(userId string, locale string, now time.Time) (Today, error) {
+	dayStart, err := s.dayStart(userId, now)
+	if err != nil {
+		return Today{}, err
+	}
+
+	cards, err := s.srsService.Cards(userId)
+	if err != nil {
+		return Today{}, err
+	}
+	reviews, learn, lastEntry, err := s.dayQueue(userId, cards, dayStart, now)
+	if err != nil {
+		return Today{}, err
+	}
+
+	goal, err := s.goal(userId, dayStart)
+	if err != nil {
+		return Today{}, err
+	}
+	learn = learn[:goalNew(reviews, len(learn), goal)]
+
+	newGrammar, err := s.findGrammar(grammarIds(learn))
+	if err != nil {
+		return Today{}, err
+	}
+
+	lastUsed, err := s.journalService.LastUsed(userId)
+	if err != nil {
+		return Today{}, err
+	}
+	var stale *grammar.Grammar
+	if id := staleGrammar(cards, lastUsed, now); id != "" {
+		found, err := s.findGrammar([]string{id})
+		if err != nil {
+			return Today{}, err
+		}
+		if len(found) > 0 {
+			stale = &found[0]
+		}
+	}
+
+	return Today{
+		Date:    dayStart.Format(time.DateOnly),
+		Reviews: len(reviews),
+		New:     newGrammar,
+		Prompt: Prompt{
+			Text:    i18n.T(locale, DailyPrompt(dayStart)),
+			Written: !lastEntry.IsZero() && !lastEntry.Time().Before(dayStart),
+		},
+		Stale:    stale,
+		Estimate: int(estimate(reviews, learn).Seconds()),
+		Goal:     goal,
+	}, nil
+}
+
+// goal is the user's daily goal with what they studied since dayStart, nil
+// when they have none
+func (s *service) goal(userId string, dayStart time.Time) (*Goal, error) {
+	userSettings, err := s.settingsService.ForUser(userId)
+	if err != nil || userSettings.DailyGoalMinutes == 0 {
+		return nil, err
+	}
+	studied, err := s.sessionsService.Studied(userId, dayStart)
+	if err != nil {
+		return nil, err
+	}
+	return &Goal{Target: userSettings.DailyGoalMinutes * 60, Studied: studied}, nil
+}
+
+func (s *service) Queue(userId string, now time.Time) (Queue, error) {
+	dayStart, err := s.dayStart(userId, now)
+	if err != nil {
+		return Queue{}, err
+	}
+	date := dayStart.Format(time.DateOnly)
+
+	rec, err := s.app.FindFirstRecordByData("review_queues", "user", userId)
+	if err != nil || storedQueueFromRecord(rec).Date != date {
+		if rec, err = s.save(userId, dayStart, now); err != nil {
+			return Queue{}, err
+		}
+	}
+	stored := storedQueueFromRecord(rec)
+
+	records, err := s.app.FindRecordsByIds("srs", append(slices.Clone(stored.Reviews), stored.New...))
+	if err != nil {
+		return Queue{}, err
+	}
+	byId := make(map[string]srs.Card, len(records))
+	for _, r := range records {
+		if card := srs.FromRecord(r); card.User == userId {
+			byId[card.Id] = card
+		}
+	}
+
+	// cards studied since the queue was worked out are done for the day
+	dayEnd := dayStart.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	queue := Queue{Date: stored.Date, Reviews: []srs.Card{}, New: []srs.Card{}, Updated: stored.Updated}
+	for _, id := range stored.Reviews {
+		if card, ok := byId[id]; ok && !card.IsNew() && card.IsDue(dayEnd) {
+			queue.Reviews = append(queue.Reviews, card)
+		}
+	}
+	for _, id := range stored.New {
+		if card, ok := byId[id]; ok && card.IsNew() {
+			queue.New = append(queue.New, card)
+		}
+	}
+	return queue, nil
+}
+
+func (s *service) Rollover(now time.Time) (int, error) {
+	var timezones []string
+	err := s.app.RecordQuery("user_settings").Select("timezone").Distinct(true).Column(&timezones)
+	if err != nil {
+		return 0, err
+	}
+	var night []any
+	for _, name := range timezones {
+		if loc, err := time.LoadLocation(name); err == nil && rollingOver(loc, now) {
+			night = append(night, name)
+		}
+	}
+	if len(night) == 0 {
+		return 0, nil
+	}
+
+	var userIds []string
+	err = s.app.RecordQuery("user_settings").
+		Select("user").
+		AndWhere(dbx.In("timezone", night...)).
+		AndWhere(dbx.NewExp("user IN (SELECT user FROM srs)")).
+		Column(&userIds)
+	if err != nil {
+		return 0, err
+	}
+
+	saved := 0
+	for _, userId := range userIds {
+		dayStart, err := s.dayStart(userId, now)
+		if err == nil {
+			_, err = s.save(userId, dayStart, now)
+		}
+		if err != nil {
+			s.app.Logger().Error("Failed to work out the review queue", "user", userId, "error", err)
+			continue
+		}
+		saved++
+	}
+	return saved, nil
+}
+
+func (s *service) Invalidate(userId string) error {
+	_, err := s.app.DB().Delete("review_queues", dbx.HashExp{"user": userId}).Execute()
+	return err
+}
+
+// save works out the user's queue for the day starting at dayStart, in place
+// of the one saved before
+func (s *service) save(userId string, dayStart time.Time, now time.Time) (*core.Record, error) {
+	cards, err := s.srsService.Cards(userId)
+	if err != nil {
+		return nil, err
+	}
+	reviews, learn, _, err := s.dayQueue(userId, cards, dayStart, now)
+	if err != nil {
+		return nil, err
+	}
+
+	rec, err := s.app.FindFirstRecordByData("review_queues", "user", userId)
+	if err != nil {
+		collection, err := s.app.FindCollectionByNameOrId("review_queues")
+		if err != nil {
+			return nil, err
+		}
+		rec = core.NewRecord(collection)
+		rec.Set("user", userId)
+	}
+	rec.Set("date", dayStart.Format(time.DateOnly))
+	rec.Set("reviews", ids(reviews))
+	rec.Set("new", ids(learn))
+	if err := s.app.Save(rec); err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+// dayQueue picks the cards due by the end of the day starting at dayStart,
+// most overdue first, and the new cards the day brings in, within the user's
+// daily limits as the due list does. It also returns when the user last
+// wrote, which the new cards depend on.
+func (s *service) dayQueue(userId string, cards []srs.Card, dayStart time.Time, now time.Time) (reviews []srs.Card, learn []srs.Card, lastEntry types.DateTime, err error) {
+	ranks, err := s.frequencyRanks(cards)
+	if err != nil {
+		return nil, nil, types.DateTime{}, err
+	}
+	newLeft, reviewsLeft, err := s.left(userId, dayStart)
+	if err != nil {
+		return nil, nil, types.DateTime{}, err
+	}
+	// due by the end of the day, not just now
+	reviews, learn = srs.SelectDue(cards, ranks, newLeft, reviewsLeft, dayStart.AddDate(0, 0, 1).Add(-time.Nanosecond))
+
+	from, _ := types.ParseDateTime(now.Add(-inactiveAfter))
+	recent, err := s.journalService.EntriesBetween(userId, from, types.DateTime{})
+	if err != nil {
+		return nil, nil, types.DateTime{}, err
+	}
+	if len(recent) > 0 {
+		lastEntry = recent[len(recent)-1].Created
+	}
+
+	learn = learn[:dailyNew(len(learn), len(reviews), isActive(cards, lastEntry, now))]
+	return reviews, learn, lastEntry, nil
+}
+
+// dayStart is the start of the user's day in their timezone
+func (s *service) dayStart(userId string, now time.Time) (time.Time, error) {
+	userSettings, err := s.settingsService.ForUser(userId)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return srs.DayStart(userSettings, now), nil
+}
+
+// left is how many new cards and reviews the user's daily limits still allow
+// in the day starting at dayStart, the same as the due list's
+func (s *service) left(userId string, dayStart time.Time) (newLeft int, reviewsLeft int, err error) {
+	userSettings, err := s.settingsService.ForUser(userId)
+	if err != nil {
+		return 0, 0, err
+	}
+	return srs.Left(s.srsService, userSettings, userId, dayStart)
+}
+
+// frequencyRanks maps the grammar of the new cards to its frequency rank
+func (s *service) frequencyRanks(cards []srs.Card) (map[string]int, error) {
+	ids := []string{}
+	for _, card := range cards {
+		if card.IsNew() {
+			ids = append(ids, card.Grammar)
+		}
+	}
+
+	found, err := s.findGrammar(ids)
+	if err != nil {
+		return nil, err
+	}
+	ranks := make(map[string]int, len(found))
+	for _, g := range found {
+		ranks[g.Id] = g.FrequencyRank
+	}
+	return ranks, nil
+}
+
+// findGrammar returns the grammar with the given ids in the same order
+func (s *service) findGrammar(ids []string) ([]grammar.Grammar, error) {
+	if len(ids) == 0 {
+		return []grammar.Grammar{}, nil
+	}
+
+	found, err := s.grammarService.FindByIds(ids)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(found, func(a, b grammar.Grammar) int {
+		return slices.Index(ids, a.Id) - slices.Index(ids, b.Id)
+	})
+	return found, nil
+}
+
+func grammarIds(cards []srs.Card) []string {
+	ids := make([]string, 0, len(cards))
+	for _, card := range cards {
+		ids = append(ids, card.Grammar)
+	}
+	return ids
+}
@@ -0,0 +1,107 @@
+package plan
+
+import (
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/sessions"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/srs"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+const (
+	// DefaultTargetMinutes is one pomodoro
+	DefaultTargetMinutes = 25
+	MinTargetMinutes     = 5
+	MaxTargetMinutes     = 120
+
+	// DefaultNewShare is the part of a session spent on new grammar
+	DefaultNewShare = 0.2
+
+	// How long a card takes on average, learning grammar means reading up on
+	// it first
+	reviewTime = 20 * time.Second
+	newTime    = 90 * time.Second
+)
+
+// SessionRequest asks for a guided study session
+type SessionRequest struct {
+	// TargetMinutes defaults to DefaultTargetMinutes
+	TargetMinutes int `json:"target_minutes"`
+
+	// NewShare is the part of the time to spend on new grammar, from 0 to 1,
+	// DefaultNewShare when missing. Time left over from one side goes to the
+	// other.
+	NewShare *float64 `json:"new_share"`
+}
+
+func (r SessionRequest) Validate() error {
+	errs := validation.Errors{}
+	if r.TargetMinutes != 0 && (r.TargetMinutes < MinTargetMinutes || r.TargetMinutes > MaxTargetMinutes) {
+		errs["target_minutes"] = validation.NewError("validation_out_of_range", "Must be between {{.min}} and {{.max}}.").
+			SetParams(map[string]any{"min": MinTargetMinutes, "max": MaxTargetMinutes})
+	}
+	if r.NewShare != nil && (*r.NewShare < 0 || *r.NewShare > 1) {
+		errs["new_share"] = validation.NewError("validation_out_of_range", "Must be between {{.min}} and {{.max}}.").
+			SetParams(map[string]any{"min": 0, "max": 1})
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (r SessionRequest) target() time.Duration {
+	if r.TargetMinutes == 0 {
+		return DefaultTargetMinutes * time.Minute
+	}
+	return time.Duration(r.TargetMinutes) * time.Minute
+}
+
+func (r SessionRequest) newShare() float64 {
+	if r.NewShare == nil {
+		return DefaultNewShare
+	}
+	return *r.NewShare
+}
+
+// Session is a started guided session with the cards planned for it, in the
+// order to go through them
+type Session struct {
+	Session sessions.Session `json:"session"`
+	Reviews []srs.Card       `json:"reviews"`
+	New     []srs.Card       `json:"new"`
+
+	// Estimate is how long the cards should take in seconds, short of the
+	// target when there isn't enough to do
+	Estimate int `json:"estimate"`
+}
+
+// planSession fills target with the first of the reviews and new cards
+// picked for the day, splitting the time by newShare
+func planSession(reviews []srs.Card, learn []srs.Card, target time.Duration, newShare float64) ([]srs.Card, []srs.Card) {
+	newBudget := time.Duration(float64(target) * newShare)
+	reviewBudget := target - newBudget
+
+	// time one side can't use goes to the other
+	reviewCount := min(len(reviews), int(reviewBudget/reviewTime))
+	newBudget += reviewBudget - time.Duration(reviewCount)*reviewTime
+	newCount := min(len(learn), int(newBudget/newTime))
+	left := newBudget - time.Duration(newCount)*newTime
+	reviewCount = min(len(reviews), reviewCount+int(left/reviewTime))
+
+	return reviews[:reviewCount], learn[:newCount]
+}
+
+func estimate(reviews []srs.Card, learn []srs.Card) time.Duration {
+	return time.Duration(len(reviews))*reviewTime + time.Duration(len(learn))*newTime
+}
+
+func ids(cards []srs.Card) []string {
+	ids := make([]string, 0, len(cards))
+	for _, card := range cards {
+		ids = append(ids, card.Id)
+	}
+	return ids
+}
@@ -0,0 +1,138 @@
+package plan
+
+import (
+	"slices"
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/grammar"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/srs"
+
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+const (
+	// backlogReviews is the review load past which a day is spent catching up
+	// rather than learning more
+	backlogReviews = 100
+
+	// inactiveAfter is how long without reviewing or writing a user counts as
+	// coming back, and gets less new grammar to ease back in
+	inactiveAfter = 7 * 24 * time.Hour
+
+	// staleAfter is how long a grammar goes without being used in a sentence
+	// before the plan suggests it
+	staleAfter = 14 * 24 * time.Hour
+)
+
+// prompts are topics for a journal entry, one a day in turn
+var prompts = []string{
+	"What did you eat today, and who with?",
+	"Describe something you noticed on your way somewhere today.",
+	"What are you looking forward to this week?",
+	"Write about a conversation you had recently.",
+	"What is something new you learned today?",
+	"Describe a place you would like to visit, and why.",
+	"What made you laugh recently?",
+}
+
+// Today is what the user should do today
+type Today struct {
+	// Date is today in the user's timezone
+	Date string `json:"date"`
+
+	// Reviews counts the cards due by the end of the day
+	Reviews int `json:"reviews"`
+
+	// New is the grammar to learn, the most frequent first, as much as the
+	// daily goal leaves time for after the reviews
+	New []grammar.Grammar `json:"new"`
+
+	Prompt Prompt `json:"prompt"`
+
+	// Stale is a known grammar to use in today's entry, the one unused for
+	// the longest, nil when every one was used lately
+	Stale *grammar.Grammar `json:"stale"`
+
+	// Estimate is how long the reviews and new grammar should take in seconds
+	Estimate int `json:"estimate"`
+
+	// Goal is the user's daily study goal, nil when they haven't set one
+	Goal *Goal `json:"goal"`
+}
+
+// Goal is how long the user means to study today and how long they have so
+// far, in seconds
+type Goal struct {
+	Target  int `json:"target"`
+	Studied int `json:"studied"`
+}
+
+// Prompt is the topic of today's journal entry
+type Prompt struct {
+	Text string `json:"text"`
+
+	// Written is set once the user wrote an entry today
+	Written bool `json:"written"`
+}
+
+// dailyNew is how many of the new cards the user's daily limit leaves to
+// bring in given the day's reviews and whether the user was active lately
+func dailyNew(limit int, reviews int, active bool) int {
+	switch {
+	case reviews >= backlogReviews:
+		return 0
+	case !active:
+		return limit / 2
+	}
+	return limit
+}
+
+// goalNew is how many of the new cards fit in what's left of the goal once
+// the reviews are done, every one of them without a goal
+func goalNew(reviews []srs.Card, learn int, goal *Goal) int {
+	if goal == nil {
+		return learn
+	}
+	left := time.Duration(goal.Target-goal.Studied)*time.Second - time.Duration(len(reviews))*reviewTime
+	return min(learn, max(0, int(left/newTime)))
+}
+
+// isActive reports whether the user reviewed or wrote lately
+func isActive(cards []srs.Card, lastEntry types.DateTime, now time.Time) bool {
+	since := now.Add(-inactiveAfter)
+	if lastEntry.Time().After(since) {
+		return true
+	}
+	return slices.ContainsFunc(cards, func(card srs.Card) bool {
+		return card.LastReviewed.After(since)
+	})
+}
+
+// staleGrammar picks the grammar among the cards the user studied that went
+// the longest without being used in a sentence, never used first, the most
+// mature of those first
+func staleGrammar(cards []srs.Card, lastUsed map[string]types.DateTime, now time.Time) string {
+	candidates := []srs.Card{}
+	for _, card := range cards {
+		used := lastUsed[card.Grammar]
+		if !card.IsNew() && !used.Time().After(now.Add(-staleAfter)) {
+			candidates = append(candidates, card)
+		}
+	}
+	if len(candidates) == 0 {
+		return ""
+	}
+
+	stale := slices.MinFunc(candidates, func(a, b srs.Card) int {
+		if c := lastUsed[a.Grammar].Time().Compare(lastUsed[b.Grammar].Time()); c != 0 {
+			return c
+		}
+		return b.IntervalDays - a.IntervalDays
+	})
+	return stale.Grammar
+}
+
+// DailyPrompt picks the day's topic, in English
+func DailyPrompt(day time.Time) string {
+	return prompts[day.YearDay()%len(prompts)]
+}
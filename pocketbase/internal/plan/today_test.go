@@ -0,0 +1,32 @@
+package plan
+
+import (
+	"testing"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/srs"
+)
+
+func TestGoalNew(t *testing.T) {
+	// 30 reviews take 10 minutes, new cards 90 seconds each
+	reviews := make([]srs.Card, 30)
+
+	tests := []struct {
+		name string
+		goal *Goal
+		want int
+	}{
+		{"no goal", nil, 20},
+		{"time for some", &Goal{Target: 15 * 60}, 3},
+		{"partly studied", &Goal{Target: 15 * 60, Studied: 3 * 60}, 1},
+		{"reviews take it all", &Goal{Target: 10 * 60}, 0},
+		{"already met", &Goal{Target: 15 * 60, Studied: 20 * 60}, 0},
+		{"more time than cards", &Goal{Target: 120 * 60}, 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := goalNew(reviews, 20, tt.goal); got != tt.want {
+				t.Errorf("goalNew() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
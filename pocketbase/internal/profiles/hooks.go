@@ -0,0 +1,38 @@
+package profiles
+
+import (
+	"strings"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/hook"
+)
+
+// Middleware has custom routes act on the profile picked by the
+// Fushigi-Profile header or the ?profile= parameter, one of the logged in
+// account's
+func Middleware(profilesService Service) *hook.Handler[*core.RequestEvent] {
+	return &hook.Handler[*core.RequestEvent]{
+		Id: "fushigiProfiles",
+		Func: func(e *core.RequestEvent) error {
+			if e.Auth == nil || e.Auth.Collection().Name != "users" || !strings.HasPrefix(e.Request.URL.Path, api.BasePath+"/") {
+				return e.Next()
+			}
+			id := e.Request.Header.Get(Header)
+			if id == "" {
+				id = e.Request.URL.Query().Get("profile")
+			}
+			if id == "" || id == e.Auth.Id {
+				return e.Next()
+			}
+
+			profile, err := profilesService.Resolve(e.Auth, id)
+			if err != nil {
+				return e.NotFoundError("No such profile.", err)
+			}
+			e.Auth = profile
+			return e.Next()
+		},
+	}
+}
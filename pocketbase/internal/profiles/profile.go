@@ -0,0 +1,104 @@
+package profiles
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Header picks the study profile custom routes act on, the ?profile= query
+// parameter doing the same for clients that can't set headers. Without
+// either they act on the account's own.
+const Header = api.ProfileHeader
+
+const (
+	// MaxProfiles bounds the profiles of an account, its own included
+	MaxProfiles = 10
+
+	// DefaultName is the account's own profile's until it's renamed
+	DefaultName = "Default"
+
+	maxNameLength = 100
+)
+
+// Profile is one study profile of an account, with its own grammar, reviews
+// and stats
+type Profile struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+
+	// Default is whether this is the account's own profile, which custom
+	// routes act on without a Fushigi-Profile header
+	Default bool `json:"default"`
+
+	Created types.DateTime `json:"created"`
+}
+
+func FromRecord(rec *core.Record) Profile {
+	profile := Profile{
+		Id:      rec.Id,
+		Name:    rec.GetString("profile_name"),
+		Default: rec.GetString("profile_of") == "",
+		Created: rec.GetDateTime("created"),
+	}
+	if profile.Name == "" {
+		profile.Name = DefaultName
+	}
+	return profile
+}
+
+// OwnerOf returns the id of the account behind a profile, its own for the
+// account's profile
+func OwnerOf(user *core.Record) string {
+	if owner := user.GetString("profile_of"); owner != "" {
+		return owner
+	}
+	return user.Id
+}
+
+// CreateRequest makes a profile studying Language, the account's default
+// language when empty
+type CreateRequest struct {
+	Name     string `json:"name"`
+	Language string `json:"language"`
+}
+
+func (r CreateRequest) Validate() error {
+	errs := validation.Errors{}
+
+	if err := validateName(r.Name); err != nil {
+		errs["name"] = err
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+type RenameRequest struct {
+	Name string `json:"name"`
+}
+
+func (r RenameRequest) Validate() error {
+	if err := validateName(r.Name); err != nil {
+		return validation.Errors{"name": err}
+	}
+	return nil
+}
+
+func validateName(name string) error {
+	switch name = strings.TrimSpace(name); {
+	case name == "":
+		return validation.NewError("validation_required", "Cannot be blank.")
+	case utf8.RuneCountInString(name) > maxNameLength:
+		return validation.NewError("validation_length_too_long", "The length must be no more than {{.max}}.").
+			SetParams(map[string]any{"max": maxNameLength})
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package profiles
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+func RegisterRoutes(g *api.Group, profilesService Service) {
+	g.GET("/profiles", "The study profiles of the account, its own first, any of which the Fushigi-Profile header picks", []Profile{}, func(e *core.RequestEvent) error {
+		profiles, err := profilesService.List(e.Auth)
+		if err != nil {
+			return e.InternalServerError("Failed to load the profiles.", err)
+		}
+		return e.JSON(200, profiles)
+	})
+
+	g.POST("/profiles", "Add a study profile, with its own grammar, reviews and stats", CreateRequest{}, Profile{}, func(e *core.RequestEvent) error {
+		var req CreateRequest
+		if err := e.BindBody(&req); err != nil {
+			return e.BadRequestError("Invalid request body.", err)
+		}
+		if err := req.Validate(); err != nil {
+			return e.BadRequestError("Invalid profile.", err)
+		}
+
+		profile, err := profilesService.Create(e.Auth, req)
+		if err := profileError(e, err); err != nil {
+			return err
+		}
+		return e.JSON(200, profile)
+	})
+
+	g.POST("/profiles/{id}", "Rename a study profile, the account's own included", RenameRequest{}, Profile{}, func(e *core.RequestEvent) error {
+		var req RenameRequest
+		if err := e.BindBody(&req); err != nil {
+			return e.BadRequestError("Invalid request body.", err)
+		}
+		if err := req.Validate(); err != nil {
+			return e.BadRequestError("Invalid profile.", err)
+		}
+
+		profile, err := profilesService.Rename(e.Auth, e.Request.PathValue("id"), req)
+		if err := profileError(e, err); err != nil {
+			return err
+		}
+		return e.JSON(200, profile)
+	})
+
+	g.DELETE("/profiles/{id}", "Delete a study profile and everything studied in it", func(e *core.RequestEvent) error {
+		err := profilesService.Delete(e.Auth, e.Request.PathValue("id"))
+		if err := profileError(e, err); err != nil {
+			return err
+		}
+		return e.NoContent(204)
+	})
+}
+
+// profileError turns what the service returned into the response, nil when
+// it succeeded
+func profileError(e *core.RequestEvent, err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, ErrTooMany):
+		return e.BadRequestError("You have as many profiles as you can.", err)
+	case errors.Is(err, ErrTaken):
+		return e.BadRequestError("Invalid profile.", validation.Errors{
+			"name": validation.NewError("validation_not_unique", "You already have a profile with this name."),
+		})
+	case errors.Is(err, ErrNoLanguage):
+		return e.BadRequestError("Invalid profile.", validation.Errors{
+			"language": validation.NewError("validation_invalid_value", "No such language."),
+		})
+	case errors.Is(err, ErrDefault):
+		return e.BadRequestError("Your account's own profile can't be deleted.", err)
+	case errors.Is(err, sql.ErrNoRows):
+		return e.NotFoundError("", err)
+	}
+	return e.InternalServerError("Failed to save the profile.", err)
+}
@@ -0,0 +1,192 @@
+package profiles
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/settings"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/security"
+)
+
+// emailDomain is where profiles' addresses are, reserved so nothing is ever
+// delivered there (RFC 2606)
+const emailDomain = "profiles.fushigi.invalid"
+
+var (
+	// ErrTooMany means the account has MaxProfiles already
+	ErrTooMany = errors.New("too many profiles")
+
+	// ErrTaken means another of the account's profiles has the name
+	ErrTaken = errors.New("profile name taken")
+
+	// ErrDefault means the account's own profile was asked to be deleted
+	ErrDefault = errors.New("the account's own profile can't be deleted")
+
+	// ErrNoLanguage means a profile was asked to study a language that
+	// doesn't exist
+	ErrNoLanguage = errors.New("no such language")
+)
+
+type Service interface {
+	// List returns the profiles of user's account, its own first
+	List(user *core.Record) ([]Profile, error)
+
+	// Create adds a profile to user's account, with the account's settings
+	// but without notifications
+	Create(user *core.Record, req CreateRequest) (Profile, error)
+
+	// Rename renames one of the profiles of user's account, its own included
+	Rename(user *core.Record, id string, req RenameRequest) (Profile, error)
+
+	// Delete deletes one of the profiles of user's account along with its
+	// grammar, reviews and everything else made in it
+	Delete(user *core.Record, id string) error
+
+	// Resolve returns the profile of user's account custom routes act on
+	Resolve(user *core.Record, id string) (*core.Record, error)
+}
+
+type service struct {
+	app core.App
+}
+
+func NewService(app core.App) Service {
+	return &service{app: app}
+}
+
+func (s *service) List(user *core.Record) ([]Profile, error) {
+	records, err := s.records(OwnerOf(user))
+	if err != nil {
+		return nil, err
+	}
+
+	profiles := make([]Profile, 0, len(records))
+	for _, rec := range records {
+		profiles = append(profiles, FromRecord(rec))
+	}
+	return profiles, nil
+}
+
+func (s *service) Create(user *core.Record, req CreateRequest) (Profile, error) {
+	owner, err := s.app.FindRecordById("users", OwnerOf(user))
+	if err != nil {
+		return Profile{}, err
+	}
+	if req.Language != "" {
+		if _, err := s.app.FindRecordById("languages", req.Language); err != nil {
+			return Profile{}, ErrNoLanguage
+		}
+	}
+	name := strings.TrimSpace(req.Name)
+
+	var profile *core.Record
+	err = s.app.RunInTransaction(func(txApp core.App) error {
+		existing, err := txApp.FindAllRecords("users", dbx.Or(dbx.HashExp{"id": owner.Id}, dbx.HashExp{"profile_of": owner.Id}))
+		if err != nil {
+			return err
+		}
+		if len(existing) >= MaxProfiles {
+			return ErrTooMany
+		}
+		if taken(existing, "", name) {
+			return ErrTaken
+		}
+
+		profile = core.NewRecord(owner.Collection())
+		profile.SetEmail("profile+" + security.RandomStringWithAlphabet(15, "abcdefghijklmnopqrstuvwxyz0123456789") + "@" + emailDomain)
+		profile.SetEmailVisibility(false)
+		profile.SetVerified(false)
+		profile.SetRandomPassword()
+		profile.Set("name", owner.GetString("name"))
+		profile.Set("profile_of", owner.Id)
+		profile.Set("profile_name", name)
+		profile.Set("has_password", false)
+		profile.Set("rate_tier", owner.GetString("rate_tier"))
+		profile.Set("storage_quota", owner.GetInt("storage_quota"))
+		if err := txApp.Save(profile); err != nil {
+			return err
+		}
+
+		// the profile gets the defaults like any new account if the owner
+		// has no settings
+		if _, err := settings.CreateLinked(txApp, owner.Id, profile.Id, req.Language); err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return Profile{}, err
+	}
+	return FromRecord(profile), nil
+}
+
+func (s *service) Rename(user *core.Record, id string, req RenameRequest) (Profile, error) {
+	records, err := s.records(OwnerOf(user))
+	if err != nil {
+		return Profile{}, err
+	}
+	name := strings.TrimSpace(req.Name)
+	if taken(records, id, name) {
+		return Profile{}, ErrTaken
+	}
+
+	for _, rec := range records {
+		if rec.Id != id {
+			continue
+		}
+		rec.Set("profile_name", name)
+		if err := s.app.Save(rec); err != nil {
+			return Profile{}, err
+		}
+		return FromRecord(rec), nil
+	}
+	return Profile{}, sql.ErrNoRows
+}
+
+func (s *service) Delete(user *core.Record, id string) error {
+	owner := OwnerOf(user)
+	if id == owner {
+		return ErrDefault
+	}
+	profile, err := s.app.FindFirstRecordByFilter("users", "id = {:id} && profile_of = {:owner}", dbx.Params{"id": id, "owner": owner})
+	if err != nil {
+		return err
+	}
+	// what the profile made goes with it, every relation to users cascading
+	return s.app.Delete(profile)
+}
+
+func (s *service) Resolve(user *core.Record, id string) (*core.Record, error) {
+	owner := OwnerOf(user)
+	if id == owner {
+		return s.app.FindRecordById("users", owner)
+	}
+	return s.app.FindFirstRecordByFilter("users", "id = {:id} && profile_of = {:owner}", dbx.Params{"id": id, "owner": owner})
+}
+
+// records loads the account's own profile and its others, oldest first
+func (s *service) records(ownerId string) ([]*core.Record, error) {
+	records, err := s.app.FindRecordsByFilter("users", "id = {:owner} || profile_of = {:owner}", "created", 0, 0, dbx.Params{"owner": ownerId})
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return records, nil
+}
+
+// taken reports whether a profile but the one with id has the name, whatever
+// the case
+func taken(records []*core.Record, id string, name string) bool {
+	for _, rec := range records {
+		if rec.Id != id && strings.EqualFold(FromRecord(rec).Name, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,188 @@
+package profiles
+
+import (
+	"errors"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	_ "github.com/bunkbed-tech/fushigi/pocketbase/migrations"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+// newTestApp is a migrated app
+func newTestApp(t *testing.T) *tests.TestApp {
+	t.Helper()
+	// the initial migration creates a superuser from these
+	t.Setenv("ADMIN_EMAIL", "admin@example.com")
+	t.Setenv("ADMIN_PASSWORD", "correct horse battery")
+	app, err := tests.NewTestApp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(app.Cleanup)
+	if err := app.RunAllMigrations(); err != nil {
+		t.Fatal(err)
+	}
+
+	// PocketBase's test data indexes every username, empty or not, and
+	// profiles have none
+	users, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	users.RemoveIndex("__pb_users_auth__username_idx")
+	if err := app.Save(users); err != nil {
+		t.Fatal(err)
+	}
+	return app
+}
+
+func saveUser(t *testing.T, app core.App, email string) *core.Record {
+	t.Helper()
+	users, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := core.NewRecord(users)
+	user.SetEmail(email)
+	user.SetPassword("correct horse battery")
+	if err := app.Save(user); err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func names(profiles []Profile) []string {
+	names := make([]string, 0, len(profiles))
+	for _, profile := range profiles {
+		names = append(names, profile.Name)
+	}
+	return names
+}
+
+func TestProfiles(t *testing.T) {
+	app := newTestApp(t)
+	service := NewService(app)
+	user := saveUser(t, app, "user@example.com")
+
+	jlpt, err := service.Create(user, CreateRequest{Name: " JLPT prep "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if jlpt.Name != "JLPT prep" || jlpt.Default {
+		t.Errorf("Create() = %+v, want JLPT prep that isn't the default", jlpt)
+	}
+	if _, err := service.Create(user, CreateRequest{Name: "jlpt PREP"}); !errors.Is(err, ErrTaken) {
+		t.Errorf("Create() with a taken name error = %v, want %v", err, ErrTaken)
+	}
+	if _, err := service.Create(user, CreateRequest{Name: "German", Language: "nope"}); !errors.Is(err, ErrNoLanguage) {
+		t.Errorf("Create() with an unknown language error = %v, want %v", err, ErrNoLanguage)
+	}
+
+	// a profile acts for the whole account
+	profile, err := app.FindRecordById("users", jlpt.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.Create(profile, CreateRequest{Name: "Casual"}); err != nil {
+		t.Fatal(err)
+	}
+	listed, err := service.List(profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{DefaultName, "JLPT prep", "Casual"}; !slices.Equal(names(listed), want) {
+		t.Errorf("List() = %v, want %v", names(listed), want)
+	}
+
+	if _, err := service.Rename(user, user.Id, RenameRequest{Name: "Casual"}); !errors.Is(err, ErrTaken) {
+		t.Errorf("Rename() to a taken name error = %v, want %v", err, ErrTaken)
+	}
+	renamed, err := service.Rename(user, user.Id, RenameRequest{Name: "Everyday"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renamed.Name != "Everyday" || !renamed.Default {
+		t.Errorf("Rename() of the account's own = %+v, want the default named Everyday", renamed)
+	}
+
+	if err := service.Delete(profile, user.Id); !errors.Is(err, ErrDefault) {
+		t.Errorf("Delete() of the account's own error = %v, want %v", err, ErrDefault)
+	}
+	if err := service.Delete(user, jlpt.Id); err != nil {
+		t.Fatal(err)
+	}
+	listed, err = service.List(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Everyday", "Casual"}; !slices.Equal(names(listed), want) {
+		t.Errorf("List() after deleting = %v, want %v", names(listed), want)
+	}
+}
+
+func TestProfilesLimit(t *testing.T) {
+	app := newTestApp(t)
+	service := NewService(app)
+	user := saveUser(t, app, "user@example.com")
+
+	for i := 1; i < MaxProfiles; i++ {
+		if _, err := service.Create(user, CreateRequest{Name: string(rune('A' + i))}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := service.Create(user, CreateRequest{Name: "One too many"}); !errors.Is(err, ErrTooMany) {
+		t.Errorf("Create() past the limit error = %v, want %v", err, ErrTooMany)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	app := newTestApp(t)
+	service := NewService(app)
+	user := saveUser(t, app, "user@example.com")
+	other := saveUser(t, app, "other@example.com")
+
+	own, err := service.Create(user, CreateRequest{Name: "JLPT prep"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	others, err := service.Create(other, CreateRequest{Name: "JLPT prep"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		target  string
+		header  string
+		want    string
+		wantErr bool
+	}{
+		{"no profile", "/api/fushigi/v1/stats", "", user.Id, false},
+		{"by header", "/api/fushigi/v1/stats", own.Id, own.Id, false},
+		{"by parameter", "/api/fushigi/v1/stats?profile=" + own.Id, "", own.Id, false},
+		{"the account's own", "/api/fushigi/v1/stats", user.Id, user.Id, false},
+		{"another account's", "/api/fushigi/v1/stats", others.Id, "", true},
+		{"outside the custom routes", "/api/collections/grammar/records", own.Id, user.Id, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &core.RequestEvent{App: app}
+			e.Request = httptest.NewRequest("GET", tt.target, nil)
+			e.Request.Header.Set(Header, tt.header)
+			e.Response = httptest.NewRecorder()
+			e.Auth = user
+
+			err := Middleware(service).Func(e)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Middleware() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && e.Auth.Id != tt.want {
+				t.Errorf("request acts on %s, want %s", e.Auth.Id, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package prompts
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// sample is what templates are tried with before they're saved
+var sample = Data{Language: "Japanese", Explained: "English", Setting: "You are a waiter at a small restaurant.", Input: "今日は公園に行きました。"}
+
+// BindHooks keeps templates versioned: an edit is saved as a new version of
+// the template rather than over it, so what earlier output was rendered from
+// stays around. Templates that don't render are refused.
+func BindHooks(app core.App) {
+	app.OnRecordCreate("ai_prompts").BindFunc(func(e *core.RecordEvent) error {
+		tmpl := FromRecord(e.Record)
+		if _, ok := Defaults[tmpl.Key]; !ok {
+			return validation.Errors{"key": validation.NewError("validation_invalid_value", "No such prompt template.")}
+		}
+		if _, err := execute(tmpl.System, sample); err != nil {
+			return validation.Errors{"system": validation.NewError("validation_invalid_value", err.Error())}
+		}
+		if _, err := execute(tmpl.User, sample); err != nil {
+			return validation.Errors{"user": validation.NewError("validation_invalid_value", err.Error())}
+		}
+
+		var latest struct {
+			Version int `db:"version"`
+		}
+		err := e.App.RecordQuery("ai_prompts").
+			Select("COALESCE(MAX(version), 0) AS version").
+			AndWhere(dbx.HashExp{"key": tmpl.Key, "language": tmpl.Language}).
+			One(&latest)
+		if err != nil {
+			return err
+		}
+		e.Record.Set("version", latest.Version+1)
+		return e.Next()
+	})
+
+	app.OnRecordUpdate("ai_prompts").BindFunc(func(e *core.RecordEvent) error {
+		original := e.Record.Original()
+		for _, field := range []string{"key", "language", "version", "system", "user"} {
+			if e.Record.GetString(field) != original.GetString(field) {
+				return validation.Errors{field: validation.NewError("validation_invalid_value", "Templates can't be changed, save a new version instead.")}
+			}
+		}
+		return e.Next()
+	})
+}
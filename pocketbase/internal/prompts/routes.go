@@ -0,0 +1,73 @@
+package prompts
+
+import (
+	"errors"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+type RenderRequest struct {
+	Key      string `json:"key"`
+	Language string `json:"language"`
+
+	// Optional, sample values are used for anything left empty
+	Data Data `json:"data"`
+}
+
+type RenderResponse struct {
+	Template Template `json:"template"`
+	Prompt   Prompt   `json:"prompt"`
+}
+
+// RegisterRoutes adds the superuser tools for looking over edited templates
+func RegisterRoutes(g *api.Group, promptsService Service) {
+	g.GET("/ai-prompts/{key}/versions", "Every saved version of an AI prompt template, newest first, for ?language= or any language", []Template{}, func(e *core.RequestEvent) error {
+		versions, err := promptsService.Versions(e.Request.PathValue("key"), e.Request.URL.Query().Get("language"))
+		if errors.Is(err, ErrUnknownKey) {
+			return e.NotFoundError("No such prompt template.", err)
+		}
+		if err != nil {
+			return e.InternalServerError("Failed to load the prompt template versions.", err)
+		}
+		return e.JSON(200, versions)
+	})
+
+	g.POST("/ai-prompts/render", "Render the AI prompt template in use for a language with sample data", RenderRequest{}, RenderResponse{}, func(e *core.RequestEvent) error {
+		var req RenderRequest
+		if err := e.BindBody(&req); err != nil {
+			return e.BadRequestError("Invalid request body.", err)
+		}
+
+		tmpl, err := promptsService.Find(req.Key, req.Language)
+		if errors.Is(err, ErrUnknownKey) {
+			return e.NotFoundError("No such prompt template.", err)
+		}
+		if err != nil {
+			return e.InternalServerError("Failed to load the prompt template.", err)
+		}
+		prompt, err := render(tmpl, withSamples(req.Data))
+		if err != nil {
+			return e.BadRequestError("Failed to render the template.", err)
+		}
+
+		return e.JSON(200, RenderResponse{Template: tmpl, Prompt: prompt})
+	})
+}
+
+func withSamples(data Data) Data {
+	if data.Language == "" {
+		data.Language = sample.Language
+	}
+	if data.Explained == "" {
+		data.Explained = sample.Explained
+	}
+	if data.Setting == "" {
+		data.Setting = sample.Setting
+	}
+	if data.Input == "" {
+		data.Input = sample.Input
+	}
+	return data
+}
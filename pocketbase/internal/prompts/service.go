@@ -0,0 +1,121 @@
+package prompts
+
+import (
+	"bytes"
+	"errors"
+	"text/template"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+var ErrUnknownKey = errors.New("no such prompt")
+
+type Service interface {
+	// Find returns the newest version of the template for language, falling
+	// back to the one for any language and then the built in default
+	Find(key string, language string) (Template, error)
+
+	// Render executes the template Find returns with data
+	Render(key string, language string, data Data) (Prompt, error)
+
+	// Versions lists every saved version of the template for language,
+	// newest first
+	Versions(key string, language string) ([]Template, error)
+}
+
+type service struct {
+	app core.App
+}
+
+func NewService(app core.App) Service {
+	return &service{app: app}
+}
+
+func (s *service) Find(key string, language string) (Template, error) {
+	system, ok := Defaults[key]
+	if !ok {
+		return Template{}, ErrUnknownKey
+	}
+
+	candidates := []string{""}
+	if language != "" {
+		candidates = []string{language, ""}
+	}
+	for _, candidate := range candidates {
+		records, err := s.versions(key, candidate, 1)
+		if err != nil {
+			return Template{}, err
+		}
+		if len(records) > 0 {
+			return FromRecord(records[0]), nil
+		}
+	}
+	return Template{Key: key, System: system}, nil
+}
+
+func (s *service) Render(key string, language string, data Data) (Prompt, error) {
+	tmpl, err := s.Find(key, language)
+	if err != nil {
+		return Prompt{}, err
+	}
+	return render(tmpl, data)
+}
+
+func (s *service) Versions(key string, language string) ([]Template, error) {
+	if _, ok := Defaults[key]; !ok {
+		return nil, ErrUnknownKey
+	}
+	records, err := s.versions(key, language, 0)
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]Template, 0, len(records))
+	for _, rec := range records {
+		versions = append(versions, FromRecord(rec))
+	}
+	return versions, nil
+}
+
+// versions loads up to limit of the template's versions, newest first, or all
+// of them for no limit. It queries directly, as filters can't match an empty
+// language.
+func (s *service) versions(key string, language string, limit int64) ([]*core.Record, error) {
+	query := s.app.RecordQuery("ai_prompts").
+		AndWhere(dbx.HashExp{"key": key, "language": language}).
+		OrderBy("version DESC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	var records []*core.Record
+	if err := query.All(&records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func render(tmpl Template, data Data) (Prompt, error) {
+	system, err := execute(tmpl.System, data)
+	if err != nil {
+		return Prompt{}, err
+	}
+	user := data.Input
+	if tmpl.User != "" {
+		if user, err = execute(tmpl.User, data); err != nil {
+			return Prompt{}, err
+		}
+	}
+	return Prompt{System: system, User: user, Template: tmpl.Id, Version: tmpl.Version}, nil
+}
+
+func execute(text string, data Data) (string, error) {
+	tmpl, err := template.New("prompt").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,103 @@
+package prompts
+
+import (
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// Template keys, one per prompt AI features send
+const (
+	KeyGrammarTags          = "grammar_tags"
+	KeyJournalTopics        = "journal_topics"
+	KeyWeeklyFeedback       = "weekly_feedback"
+	KeyConversationPartner  = "conversation_partner"
+	KeyConversationAnalysis = "conversation_analysis"
+	KeySpokenCorrection     = "spoken_correction"
+	KeyGrammarComparison    = "grammar_comparison"
+)
+
+// Template is one version of a prompt, for one language or, with no language,
+// for any. Both parts are text/templates executed with Data, the user part
+// may be left empty to send the input as is.
+type Template struct {
+	Id       string    `json:"id"`
+	Key      string    `json:"key"`
+	Language string    `json:"language"`
+	Version  int       `json:"version"`
+	System   string    `json:"system"`
+	User     string    `json:"user"`
+	Created  time.Time `json:"created"`
+}
+
+// Data is everything a template can reference
+type Data struct {
+	// Language is the language the learner practices, e.g. Japanese
+	Language string `json:"language"`
+
+	// Explained is the language explanations are written in, the user's
+	// locale's
+	Explained string `json:"explained"`
+
+	// Setting describes the scene of a role play
+	Setting string `json:"setting"`
+
+	// Input is what the feature built for the model to read, the user's
+	// writing, the grammar to tag and so on
+	Input string `json:"input"`
+}
+
+// Prompt is a template rendered for one request to the model
+type Prompt struct {
+	System string `json:"system"`
+	User   string `json:"user"`
+
+	// Template and Version identify what the prompt was rendered from, the
+	// template is empty for the built in defaults
+	Template string `json:"template"`
+	Version  int    `json:"version"`
+}
+
+// Defaults are the prompts used for keys no template was saved for, and
+// what the collection starts out with
+var Defaults = map[string]string{
+	KeyGrammarTags: `You tag grammar points for language learners. Pick the tags that fit the grammar point from the given list only, at most five, best fitting first. Answer with a JSON object like {"tags": ["tag"]}, with an empty list when none fit.`,
+
+	KeyJournalTopics: `You read journal entries language learners write and say what they are about. Pick the topics the entry is mostly about from the given list only, at most three, most prominent first. Answer with a JSON object like {"topics": ["topic"]}, with an empty list when none fit.`,
+
+	KeyWeeklyFeedback: `You are a kind, precise language tutor reviewing a learner's journal for the past week. Answer with a JSON object with these keys:
+"summary": two or three encouraging sentences on the week,
+"recurring_errors": mistakes the learner made more than once, as [{"pattern": "what goes wrong, short", "explanation": "why and how to fix it", "examples": [{"original": "their sentence", "corrected": "the fix"}]}],
+"overused_patterns": grammar patterns they lean on too much, as [{"pattern": "the pattern as written in the target language", "count": times used}],
+"suggested_grammar": grammar to try next week, preferably from the learner's candidates, as [{"usage": "the pattern as written in the target language", "reason": "why it fits their writing"}].
+At most five items a list. Write the summary, explanations and reasons in {{.Explained}}.`,
+
+	KeyConversationPartner: `You are helping a learner practice speaking {{.Language}} through role play. {{.Setting}}
+Reply only in {{.Language}}, as you would speak, in one to three short sentences a learner can follow. Stay in character and keep the conversation going; don't correct the learner's mistakes, that comes after.`,
+
+	KeyConversationAnalysis: `You are a language tutor reviewing a role play conversation a learner had in {{.Language}}. Answer with a JSON object with these keys:
+"summary": one or two encouraging sentences on how the learner did,
+"grammar": grammar the learner got wrong or could have used, as [{"usage": "the pattern as written in {{.Language}}", "reason": "why it fits what they were saying", "example": "one of their lines said with it"}],
+"vocabulary": words that came up that are worth saving, or that the learner was missing, as [{"word": "the word", "reading": "its reading in kana, empty when obvious", "meaning": "what it means"}].
+At most five items a list. Write the summary, reasons and meanings in {{.Explained}}.`,
+
+	KeySpokenCorrection: `You are a language tutor correcting the transcript of a learner speaking {{.Language}}. The transcript comes from speech recognition, so ignore punctuation and recognition slips and only fix what the learner actually got wrong. Answer with a JSON object with the key "corrections": [{"original": "the sentence as transcribed", "corrected": "the sentence fixed", "comment": "a short explanation in English"}], leaving out sentences that are fine. At most ten corrections.`,
+
+	KeyGrammarComparison: `You are a language tutor explaining the differences between {{.Language}} grammar points learners easily confuse. Compare the numbered grammar points given. Answer with a JSON object with these keys:
+"summary": one or two sentences on what sets them apart,
+"aspects": how they differ, as [{"aspect": "what is compared, like formality or certainty", "values": ["how the first grammar point behaves", "how the second does", one for each grammar point in the given order]}],
+"examples": pairs of sentences contrasting them in one situation, as [{"situation": "the situation", "sentences": ["the situation said with the first grammar point", one for each in order, empty when one can't be used there], "translations": ["what each sentence means", one for each], "note": "what changes between the sentences"}].
+Three to six aspects and two to four examples. Write everything but the {{.Language}} sentences in {{.Explained}}.`,
+}
+
+func FromRecord(rec *core.Record) Template {
+	return Template{
+		Id:       rec.Id,
+		Key:      rec.GetString("key"),
+		Language: rec.GetString("language"),
+		Version:  rec.GetInt("version"),
+		System:   rec.GetString("system"),
+		User:     rec.GetString("user"),
+		Created:  rec.GetDateTime("created").Time(),
+	}
+}
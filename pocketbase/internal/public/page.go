@@ -0,0 +1,77 @@
+package public
+
+import (
+	"bytes"
+	"html/template"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/i18n"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// deckPage is a self-contained page, styles inline, so it renders the same
+// in an iframe on any site. The Open Graph tags give social media links a
+// preview card.
+var deckPage = template.Must(template.New("deck").Parse(`<!DOCTYPE html>
+<html lang="{{.Locale}}">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Deck.Name}} · {{.AppName}}</title>
+<meta property="og:type" content="website">
+<meta property="og:site_name" content="{{.AppName}}">
+<meta property="og:title" content="{{.Deck.Name}}">
+<meta property="og:description" content="{{.Summary}}">
+<meta name="twitter:card" content="summary">
+<style>
+body{font-family:system-ui,"Hiragino Sans","Noto Sans JP",sans-serif;margin:0;padding:1rem;color:#222;background:#fff;line-height:1.5}
+h1{font-size:1.4rem;margin:0 0 .25rem}
+.summary{color:#666;margin:0 0 1rem}
+ul{list-style:none;padding:0;margin:0}
+li{border-top:1px solid #eee;padding:.5rem 0}
+.usage{font-weight:600;font-size:1.1rem}
+.example{color:#555;font-size:.9rem}
+footer{margin-top:1rem;color:#999;font-size:.8rem}
+</style>
+</head>
+<body>
+<h1>{{.Deck.Name}}</h1>
+<p class="summary">{{.Summary}}</p>
+{{with .Deck.Description}}<p>{{.}}</p>{{end}}
+<ul>
+{{range .Deck.Grammar}}<li>
+<div class="usage">{{.Usage}}</div>
+<div>{{.Meaning}}</div>
+{{with .Examples}}<div class="example">{{(index . 0).Japanese}}</div>{{end}}
+</li>
+{{end}}</ul>
+<footer>{{.Footer}}</footer>
+</body>
+</html>
+`))
+
+type deckPageData struct {
+	Locale  string
+	AppName string
+	Deck    deckResponse
+	Summary string
+	Footer  string
+}
+
+func renderDeckPage(e *core.RequestEvent, deck deckResponse) (string, error) {
+	locale := i18n.FromRequest(e)
+	appName := e.App.Settings().Meta.AppName
+
+	var buf bytes.Buffer
+	err := deckPage.Execute(&buf, deckPageData{
+		Locale:  locale,
+		AppName: appName,
+		Deck:    deck,
+		Summary: i18n.T(locale, "%d grammar points", len(deck.Grammar)),
+		Footer:  i18n.T(locale, "Shared from %s", appName),
+	})
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,244 @@
+package public
+
+import (
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/grammar"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/i18n"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/journal"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Published content changes rarely, so a short cache absorbs link previews and
+// crawlers without making edits feel stuck
+const cacheTTL = 5 * time.Minute
+
+var cache = api.NewCache(cacheTTL)
+
+type deckSummary struct {
+	Id      string         `json:"id"`
+	Name    string         `json:"name"`
+	Updated types.DateTime `json:"updated"`
+}
+
+type entrySummary struct {
+	Id      string         `json:"id"`
+	Title   string         `json:"title"`
+	Created types.DateTime `json:"created"`
+}
+
+type profileResponse struct {
+	Id      string         `json:"id"`
+	Name    string         `json:"name"`
+	Avatar  string         `json:"avatar"`
+	Thumb   string         `json:"thumb"`
+	Created types.DateTime `json:"created"`
+	Decks   []deckSummary  `json:"decks"`
+	Entries []entrySummary `json:"entries"`
+}
+
+type deckResponse struct {
+	Id          string            `json:"id"`
+	User        string            `json:"user"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Grammar     []grammar.Grammar `json:"grammar"`
+	Created     types.DateTime    `json:"created"`
+	Updated     types.DateTime    `json:"updated"`
+
+	// Language ids differ between instances, so the names of the deck's
+	// languages come along for other instances importing it
+	Languages map[string]string `json:"languages"`
+}
+
+type entryResponse struct {
+	Id      string         `json:"id"`
+	User    string         `json:"user"`
+	Title   string         `json:"title"`
+	Content string         `json:"content"`
+	Created types.DateTime `json:"created"`
+	Updated types.DateTime `json:"updated"`
+}
+
+func RegisterRoutes(app core.App, g *api.Group, grammarService grammar.Service, journalService journal.Service) {
+	g.GET("/profiles/{id}", "Published profile with its published decks and entries", profileResponse{}, func(e *core.RequestEvent) error {
+		return serve(e, func() (any, error) {
+			user, err := app.FindFirstRecordByFilter("users", "id = {:id} && published = true", map[string]any{"id": e.Request.PathValue("id")})
+			if err != nil {
+				return nil, err
+			}
+
+			decks, err := grammarService.Decks(user.Id, true)
+			if err != nil {
+				return nil, err
+			}
+			entries, err := journalService.PublishedEntries(user.Id)
+			if err != nil {
+				return nil, err
+			}
+
+			profile := newProfile(user)
+			for _, deck := range decks {
+				profile.Decks = append(profile.Decks, deckSummary{Id: deck.Id, Name: deck.Name, Updated: deck.Updated})
+			}
+			for _, entry := range entries {
+				profile.Entries = append(profile.Entries, entrySummary{Id: entry.Id, Title: entry.Title, Created: entry.Created})
+			}
+			return profile, nil
+		})
+	})
+
+	g.GET("/decks/{id}", "Published deck with its grammar", deckResponse{}, func(e *core.RequestEvent) error {
+		return serve(e, func() (any, error) {
+			deck, err := grammarService.FindPublishedDeck(e.Request.PathValue("id"))
+			if err != nil {
+				return nil, err
+			}
+			return newDeckResponse(grammarService, deck)
+		})
+	})
+
+	g.GET("/shared/{token}", "Deck shared by link, published or not, with its grammar", deckResponse{}, func(e *core.RequestEvent) error {
+		return serve(e, func() (any, error) {
+			deck, err := grammarService.FindSharedDeck(e.Request.PathValue("token"))
+			if err != nil {
+				return nil, err
+			}
+			return newDeckResponse(grammarService, deck)
+		})
+	})
+
+	g.GET("/shared/{token}/embed", "Deck shared by link as an HTML page, for link previews and iframes", nil, func(e *core.RequestEvent) error {
+		return serveHTML(e, func() (string, error) {
+			deck, err := grammarService.FindSharedDeck(e.Request.PathValue("token"))
+			if err != nil {
+				return "", err
+			}
+			res, err := newDeckResponse(grammarService, deck)
+			if err != nil {
+				return "", err
+			}
+			return renderDeckPage(e, res)
+		})
+	})
+
+	g.GET("/entries/{id}", "Published journal entry", entryResponse{}, func(e *core.RequestEvent) error {
+		return serve(e, func() (any, error) {
+			entry, err := journalService.FindPublished(e.Request.PathValue("id"))
+			if err != nil {
+				return nil, err
+			}
+
+			return entryResponse{
+				Id:      entry.Id,
+				User:    entry.User,
+				Title:   entry.Title,
+				Content: entry.Content,
+				Created: entry.Created,
+				Updated: entry.Updated,
+			}, nil
+		})
+	})
+}
+
+// BindHooks clears the cached pages whenever what they show changes, new
+// records included since pages list them
+func BindHooks(app core.App) {
+	invalidate := func(e *core.RecordEvent) error {
+		cache.Clear()
+		return e.Next()
+	}
+	for _, name := range []string{"users", "decks", "journal_entry", "grammar"} {
+		app.OnRecordAfterCreateSuccess(name).BindFunc(invalidate)
+		app.OnRecordAfterUpdateSuccess(name).BindFunc(invalidate)
+		app.OnRecordAfterDeleteSuccess(name).BindFunc(invalidate)
+	}
+}
+
+// serve answers from the cache when possible and otherwise builds the
+// response, treating any lookup failure as "not published"
+func serve(e *core.RequestEvent, build func() (any, error)) error {
+	key := e.Request.URL.Path
+
+	data, ok := cache.Get(key)
+	if !ok {
+		var err error
+		data, err = build()
+		if err != nil {
+			return e.NotFoundError("", err)
+		}
+		cache.Set(key, data)
+	}
+
+	e.Response.Header().Set("Cache-Control", "public, max-age=300")
+	return e.JSON(200, data)
+}
+
+// serveHTML is serve for pages, which are also meant to be embedded on other
+// sites
+func serveHTML(e *core.RequestEvent, build func() (string, error)) error {
+	key := e.Request.URL.Path + "|" + i18n.FromRequest(e)
+
+	data, ok := cache.Get(key)
+	if !ok {
+		page, err := build()
+		if err != nil {
+			return e.NotFoundError("", err)
+		}
+		data = page
+		cache.Set(key, data)
+	}
+
+	e.Response.Header().Set("Cache-Control", "public, max-age=300")
+	e.Response.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; frame-ancestors *")
+	e.Response.Header().Del("X-Frame-Options")
+	return e.HTML(200, data.(string))
+}
+
+func newDeckResponse(grammarService grammar.Service, deck grammar.Deck) (deckResponse, error) {
+	items, err := grammarService.DeckGrammar(deck)
+	if err != nil {
+		return deckResponse{}, err
+	}
+	names, err := grammarService.Languages()
+	if err != nil {
+		return deckResponse{}, err
+	}
+
+	languages := map[string]string{}
+	for _, item := range items {
+		languages[item.Language] = names[item.Language]
+	}
+
+	return deckResponse{
+		Id:          deck.Id,
+		User:        deck.User,
+		Name:        deck.Name,
+		Description: deck.Description,
+		Grammar:     items,
+		Created:     deck.Created,
+		Updated:     deck.Updated,
+		Languages:   languages,
+	}, nil
+}
+
+func newProfile(user *core.Record) profileResponse {
+	avatar, thumb := "", ""
+	if file := user.GetString("avatar"); file != "" {
+		avatar = "/api/files/" + user.Collection().Id + "/" + user.Id + "/" + file
+		thumb = avatar + "?thumb=128x128"
+	}
+
+	return profileResponse{
+		Id:      user.Id,
+		Name:    user.GetString("name"),
+		Avatar:  avatar,
+		Thumb:   thumb,
+		Created: user.GetDateTime("created"),
+		Decks:   []deckSummary{},
+		Entries: []entrySummary{},
+	}
+}
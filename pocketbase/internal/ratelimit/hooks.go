@@ -0,0 +1,75 @@
+package ratelimit
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/hook"
+)
+
+// versionPrefix is the version segment of versioned paths
+var versionPrefix = regexp.MustCompile(`^/v\d+`)
+
+// Middleware refuses the expensive requests of users over their tier's
+// limit. Superusers aren't limited.
+func Middleware(limiter *Limiter) *hook.Handler[*core.RequestEvent] {
+	return &hook.Handler[*core.RequestEvent]{
+		Id: "fushigiRateLimitTiers",
+		Func: func(e *core.RequestEvent) error {
+			if e.Auth == nil || e.Auth.Collection().Name != "users" {
+				return e.Next()
+			}
+			class, ok := ClassOf(e.Request.Pattern)
+			if !ok {
+				return e.Next()
+			}
+
+			// sandboxes, study profiles and children count against their
+			// owner, or they'd multiply anyone's limits
+			user := e.Auth
+			owner := user.GetString("sandbox_of")
+			if owner == "" {
+				owner = user.GetString("profile_of")
+			}
+			if owner == "" {
+				owner = user.GetString("parent")
+			}
+			if owner != "" {
+				if rec, err := e.App.FindRecordById("users", owner); err == nil {
+					user = rec
+				}
+			}
+
+			// referrals earn extra AI requests
+			extra := 0
+			if class == ClassAI {
+				extra = user.GetInt("ai_bonus")
+			}
+			allowed, retryAfter := limiter.Allow(user.Id, user.GetString("rate_tier"), class, extra, time.Now())
+			if !allowed {
+				e.Response.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+				return e.TooManyRequestsError("You've made too many of these requests, try again later.", nil)
+			}
+			return e.Next()
+		},
+	}
+}
+
+// ClassOf finds the class of a route by its pattern, e.g.
+// "POST /api/fushigi/v1/reports"
+func ClassOf(pattern string) (string, bool) {
+	method, path, ok := strings.Cut(pattern, " ")
+	if !ok || !strings.HasPrefix(path, api.BasePath) {
+		return "", false
+	}
+	path = versionPrefix.ReplaceAllString(strings.TrimPrefix(path, api.BasePath), "")
+
+	class, ok := routes[method+" "+path]
+	return class, ok
+}
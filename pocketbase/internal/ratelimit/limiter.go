@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// window counts a user's requests of one class since it started
+type window struct {
+	start time.Time
+	count int
+}
+
+// Limiter counts requests in memory, which is enough for the single process
+// an instance runs as. Counts start over when it restarts.
+type Limiter struct {
+	limits Limits
+
+	mu      sync.Mutex
+	windows map[string]*window
+	pruned  time.Time
+}
+
+func NewLimiter(limits Limits) *Limiter {
+	return &Limiter{limits: limits, windows: map[string]*window{}}
+}
+
+// Allow counts a request of the user to class, reporting whether their tier
+// allows it with extra requests on top and if not, how long until it does
+func (l *Limiter) Allow(userId string, tier string, class string, extra int, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.limits.PerTier[tier]; !ok {
+		tier = l.limits.DefaultTier
+	}
+	max := l.limits.PerTier[tier][class]
+	if max == 0 {
+		return true, 0
+	}
+	max += extra
+
+	l.prune(now)
+
+	key := userId + "|" + class
+	w, ok := l.windows[key]
+	if !ok || now.Sub(w.start) >= Window {
+		w = &window{start: now}
+		l.windows[key] = w
+	}
+	if w.count >= max {
+		return false, w.start.Add(Window).Sub(now)
+	}
+	w.count++
+	return true, 0
+}
+
+// SetLimits applies to the requests counted from then on, the ones already
+// counted still count
+func (l *Limiter) SetLimits(limits Limits) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limits = limits
+}
+
+// prune forgets windows that ended, at most once a Window
+func (l *Limiter) prune(now time.Time) {
+	if now.Sub(l.pruned) < Window {
+		return
+	}
+	l.pruned = now
+	for key, w := range l.windows {
+		if now.Sub(w.start) >= Window {
+			delete(l.windows, key)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiterAllow(t *testing.T) {
+	now := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	limits := Limits{
+		PerTier: map[string]map[string]int{
+			TierDemo:     {ClassAI: 2, ClassExports: 1},
+			TierStandard: {ClassAI: 3},
+			TierTrusted:  {ClassAI: 0},
+		},
+		DefaultTier: TierDemo,
+	}
+
+	tests := []struct {
+		name    string
+		tier    string
+		class   string
+		extra   int
+		allowed int
+	}{
+		{"within the tier's limit", TierStandard, ClassAI, 0, 3},
+		{"limited per class", TierDemo, ClassExports, 0, 1},
+		{"no limit", TierTrusted, ClassAI, 0, 10},
+		{"class without a limit", TierStandard, ClassExports, 0, 10},
+		{"no tier gets the default", "", ClassAI, 0, 2},
+		{"unknown tier gets the default", "gold", ClassAI, 0, 2},
+		{"extra on top of the tier's limit", TierStandard, ClassAI, 4, 7},
+		{"extra doesn't limit what has no limit", TierTrusted, ClassAI, 4, 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := NewLimiter(limits)
+			allowed := 0
+			for range 10 {
+				if ok, _ := limiter.Allow("u1", tt.tier, tt.class, tt.extra, now); ok {
+					allowed++
+				}
+			}
+			if allowed != tt.allowed {
+				t.Errorf("allowed %d of 10 requests, want %d", allowed, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestLimiterWindow(t *testing.T) {
+	start := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	limiter := NewLimiter(Limits{PerTier: map[string]map[string]int{TierDemo: {ClassAI: 1}}, DefaultTier: TierDemo})
+
+	if ok, _ := limiter.Allow("u1", TierDemo, ClassAI, 0, start); !ok {
+		t.Fatal("first request refused")
+	}
+	ok, retryAfter := limiter.Allow("u1", TierDemo, ClassAI, 0, start.Add(20*time.Minute))
+	if ok || retryAfter != 40*time.Minute {
+		t.Errorf("request over the limit = %v, retry after %v, want refused for 40m", ok, retryAfter)
+	}
+	if ok, _ := limiter.Allow("u2", TierDemo, ClassAI, 0, start.Add(20*time.Minute)); !ok {
+		t.Error("another user's request refused, want users counted apart")
+	}
+	if ok, _ := limiter.Allow("u1", TierDemo, ClassAI, 0, start.Add(Window)); !ok {
+		t.Error("request in the next window refused")
+	}
+}
@@ -0,0 +1,122 @@
+package ratelimit
+
+import (
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Tiers are how much of the expensive routes a user may use, set on their
+// user record by a superuser
+const (
+	TierDemo     = "demo"
+	TierStandard = "standard"
+	TierTrusted  = "trusted"
+)
+
+var Tiers = []string{TierDemo, TierStandard, TierTrusted}
+
+// Classes group the expensive routes limited together
+const (
+	ClassAI      = "ai"
+	ClassExports = "exports"
+)
+
+var Classes = []string{ClassAI, ClassExports}
+
+// Window is the period limits count requests over
+const Window = time.Hour
+
+// Limits is how many requests of each class a user of each tier may make per
+// Window, 0 meaning no limit
+type Limits struct {
+	PerTier map[string]map[string]int
+
+	// DefaultTier applies to users without a tier of their own
+	DefaultTier string
+}
+
+var DefaultLimits = Limits{
+	PerTier: map[string]map[string]int{
+		TierDemo:     {ClassAI: 10, ClassExports: 2},
+		TierStandard: {ClassAI: 100, ClassExports: 20},
+		TierTrusted:  {ClassAI: 0, ClassExports: 0},
+	},
+	DefaultTier: TierStandard,
+}
+
+// LimitsFromEnv reads RATE_LIMIT_AI and RATE_LIMIT_EXPORTS, each a list like
+// "demo=10,standard=100,trusted=0", and RATE_LIMIT_DEFAULT_TIER. Tiers left
+// out keep their default limit.
+func LimitsFromEnv() Limits {
+	limits := DefaultLimits.clone()
+
+	for class, name := range map[string]string{ClassAI: "RATE_LIMIT_AI", ClassExports: "RATE_LIMIT_EXPORTS"} {
+		for _, pair := range strings.Split(os.Getenv(name), ",") {
+			tier, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			n, err := strconv.Atoi(strings.TrimSpace(value))
+			if !ok || err != nil || n < 0 || !slices.Contains(Tiers, strings.TrimSpace(tier)) {
+				continue
+			}
+			limits.PerTier[strings.TrimSpace(tier)][class] = n
+		}
+	}
+
+	if tier := os.Getenv("RATE_LIMIT_DEFAULT_TIER"); slices.Contains(Tiers, tier) {
+		limits.DefaultTier = tier
+	}
+	return limits
+}
+
+// With returns the limits with those of perClass, by class then tier, in
+// place of these, and defaultTier unless it's empty. Unknown classes and
+// tiers and negative limits are left out.
+func (l Limits) With(perClass map[string]map[string]int, defaultTier string) Limits {
+	limits := l.clone()
+	for class, tiers := range perClass {
+		if !slices.Contains(Classes, class) {
+			continue
+		}
+		for tier, n := range tiers {
+			if n < 0 || !slices.Contains(Tiers, tier) {
+				continue
+			}
+			limits.PerTier[tier][class] = n
+		}
+	}
+	if slices.Contains(Tiers, defaultTier) {
+		limits.DefaultTier = defaultTier
+	}
+	return limits
+}
+
+func (l Limits) clone() Limits {
+	limits := Limits{PerTier: map[string]map[string]int{}, DefaultTier: l.DefaultTier}
+	for _, tier := range Tiers {
+		limits.PerTier[tier] = map[string]int{}
+	}
+	for tier, classes := range l.PerTier {
+		for class, n := range classes {
+			limits.PerTier[tier][class] = n
+		}
+	}
+	return limits
+}
+
+// routes are the expensive routes by their path under the API version, the
+// AI ones calling a model and the exports building files
+var routes = map[string]string{
+	"POST /grammar/comparisons":         ClassAI,
+	"POST /grammar/{id}/suggested-tags": ClassAI,
+	"POST /conversations":               ClassAI,
+	"POST /conversations/{id}/messages": ClassAI,
+	"POST /conversations/{id}/end":      ClassAI,
+	"GET /speaking/prompt/audio":        ClassAI,
+	"POST /speaking/entries":            ClassAI,
+	"POST /reports":                     ClassAI,
+	"GET /search/semantic":              ClassAI,
+	"POST /exports/journal":             ClassExports,
+	"POST /exports/grammar":             ClassExports,
+}
@@ -0,0 +1,55 @@
+package ratelimit
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestLimitsFromEnv(t *testing.T) {
+	t.Setenv("RATE_LIMIT_AI", "demo=5, trusted=50,gold=7,standard=-1,standard=lots")
+	t.Setenv("RATE_LIMIT_EXPORTS", "")
+	t.Setenv("RATE_LIMIT_DEFAULT_TIER", TierDemo)
+
+	limits := LimitsFromEnv()
+	want := map[string]map[string]int{
+		TierDemo:     {ClassAI: 5, ClassExports: 2},
+		TierStandard: {ClassAI: 100, ClassExports: 20},
+		TierTrusted:  {ClassAI: 50, ClassExports: 0},
+	}
+	for tier, classes := range want {
+		if !maps.Equal(limits.PerTier[tier], classes) {
+			t.Errorf("%s limits = %v, want %v", tier, limits.PerTier[tier], classes)
+		}
+	}
+	if limits.DefaultTier != TierDemo {
+		t.Errorf("default tier = %q, want %q", limits.DefaultTier, TierDemo)
+	}
+	if DefaultLimits.PerTier[TierDemo][ClassAI] != 10 {
+		t.Errorf("default demo AI limit = %d, want it left at 10", DefaultLimits.PerTier[TierDemo][ClassAI])
+	}
+}
+
+func TestClassOf(t *testing.T) {
+	tests := []struct {
+		pattern string
+		class   string
+		ok      bool
+	}{
+		{"POST /api/fushigi/v1/reports", ClassAI, true},
+		{"POST /api/fushigi/reports", ClassAI, true},
+		{"POST /api/fushigi/v2/conversations/{id}/messages", ClassAI, true},
+		{"POST /api/fushigi/v1/exports/journal", ClassExports, true},
+		{"GET /api/fushigi/v1/reports", "", false},
+		{"GET /api/fushigi/v1/stats", "", false},
+		{"POST /api/collections/reports/records", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			class, ok := ClassOf(tt.pattern)
+			if class != tt.class || ok != tt.ok {
+				t.Errorf("ClassOf() = %q, %v, want %q, %v", class, ok, tt.class, tt.ok)
+			}
+		})
+	}
+}
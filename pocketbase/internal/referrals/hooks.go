@@ -0,0 +1,57 @@
+package referrals
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/spf13/cast"
+)
+
+// BindHooks attributes new users to whoever referred them and rewards the
+// referrer as the users they referred verify their address. Unknown codes
+// are ignored, a stale link shouldn't keep anyone from signing up.
+func BindHooks(app core.App, referralsService Service) {
+	app.OnRecordCreateRequest("users").BindFunc(func(e *core.RecordRequestEvent) error {
+		info, err := e.RequestInfo()
+		if err != nil {
+			return err
+		}
+		if code := cast.ToString(info.Body[BodyField]); code != "" {
+			if referrerId, err := referralsService.Referrer(code); err == nil {
+				e.Record.Set("referred_by", referrerId)
+			}
+		}
+		return e.Next()
+	})
+
+	app.OnRecordAuthWithOAuth2Request("users").BindFunc(func(e *core.RecordAuthWithOAuth2RequestEvent) error {
+		if !e.IsNewRecord {
+			return e.Next()
+		}
+		code := cast.ToString(e.CreateData[BodyField])
+		delete(e.CreateData, BodyField)
+		if err := e.Next(); err != nil || code == "" || e.Record == nil {
+			return err
+		}
+
+		referrerId, err := referralsService.Referrer(code)
+		if err != nil {
+			return nil
+		}
+		e.Record.Set("referred_by", referrerId)
+		if err := e.App.Save(e.Record); err != nil {
+			e.App.Logger().Error("Failed to attribute a referral", "user", e.Record.Id, "error", err)
+		}
+		return nil
+	})
+
+	reward := func(e *core.RecordEvent) error {
+		if referrerId := e.Record.GetString("referred_by"); referrerId != "" {
+			if err := referralsService.Reward(referrerId); err != nil {
+				e.App.Logger().Error("Failed to reward a referral", "referrer", referrerId, "error", err)
+			}
+		}
+		return e.Next()
+	}
+	app.OnRecordAfterCreateSuccess("users").BindFunc(reward)
+	app.OnRecordAfterUpdateSuccess("users").BindFunc(reward)
+	app.OnRecordAfterDeleteSuccess("users").BindFunc(reward)
+}
@@ -0,0 +1,110 @@
+package referrals
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// BodyField is the field of signup requests, or of the createData of OAuth2
+// logins, that carries the code of whoever referred the new user
+const BodyField = "referral"
+
+const (
+	codeLength = 8
+
+	// codeAlphabet leaves out characters easily mistaken for one another
+	codeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+)
+
+// Config sets the perks of referring users, none by default
+type Config struct {
+	// AIBonus is how many extra AI requests an hour each referred user who
+	// verified their address earns
+	AIBonus int
+
+	// MaxAIBonus caps the extra AI requests referrals can earn
+	MaxAIBonus int
+}
+
+var DefaultConfig = Config{
+	AIBonus:    0,
+	MaxAIBonus: 50,
+}
+
+// ConfigFromEnv reads REFERRAL_AI_BONUS and REFERRAL_AI_BONUS_MAX over the
+// defaults
+func ConfigFromEnv() Config {
+	config := DefaultConfig
+	if n, err := strconv.Atoi(os.Getenv("REFERRAL_AI_BONUS")); err == nil && n >= 0 {
+		config.AIBonus = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("REFERRAL_AI_BONUS_MAX")); err == nil && n >= 0 {
+		config.MaxAIBonus = n
+	}
+	return config
+}
+
+// bonus is what verified referrals earn
+func (c Config) bonus(verified int) int {
+	return min(verified*c.AIBonus, c.MaxAIBonus)
+}
+
+// Stats are how the user's referrals went
+type Stats struct {
+	// Code is what the users they refer sign up with
+	Code string `json:"code"`
+
+	Signups  int `json:"signups"`
+	Verified int `json:"verified"`
+
+	// Active are the referred users who reviewed or wrote in the last 30
+	// days
+	Active int `json:"active"`
+
+	// AIBonus is the extra AI requests an hour the referrals earned, and
+	// AIBonusPerReferral what each verified one earns, 0 when referrals
+	// earn nothing
+	AIBonus            int `json:"ai_bonus"`
+	AIBonusPerReferral int `json:"ai_bonus_per_referral"`
+}
+
+// Overview is how referrals went across the instance
+type Overview struct {
+	Signups  int `json:"signups"`
+	Verified int `json:"verified"`
+
+	// Referrers are those who referred the most users, most first
+	Referrers []Referrer `json:"referrers"`
+}
+
+type Referrer struct {
+	Id       string `json:"id"`
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Signups  int    `json:"signups"`
+	Verified int    `json:"verified"`
+}
+
+// normalizeCode forgives codes typed with the wrong case or spaces around
+func normalizeCode(code string) string {
+	return strings.ToLower(strings.TrimSpace(code))
+}
+
+// referrerOf returns the id of the account user refers others as, that of
+// their real account for sandboxes and study profiles. Children and guests
+// can't refer anyone.
+func referrerOf(user *core.Record) (string, bool) {
+	if user.GetString("parent") != "" || !user.GetDateTime("guest_expires").IsZero() {
+		return "", false
+	}
+	if owner := user.GetString("sandbox_of"); owner != "" {
+		return owner, true
+	}
+	if owner := user.GetString("profile_of"); owner != "" {
+		return owner, true
+	}
+	return user.Id, true
+}
@@ -0,0 +1,36 @@
+package referrals
+
+import (
+	"errors"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// topReferrers is how many referrers the overview lists
+const topReferrers = 20
+
+func RegisterRoutes(g *api.Group, referralsService Service) {
+	g.GET("/referrals", "The user's referral code, which new users sign up with as \"referral\", and how their referrals went", Stats{}, func(e *core.RequestEvent) error {
+		stats, err := referralsService.Stats(e.Auth)
+		switch {
+		case errors.Is(err, ErrNotReferrer):
+			return e.ForbiddenError("This account can't refer others.", err)
+		case err != nil:
+			return e.InternalServerError("Failed to load referrals.", err)
+		}
+		return e.JSON(200, stats)
+	})
+}
+
+// RegisterAdminRoutes adds the overview of referrals across the instance
+func RegisterAdminRoutes(g *api.Group, referralsService Service) {
+	g.GET("/referrals", "How many users signed up with a referral, and who referred the most", Overview{}, func(e *core.RequestEvent) error {
+		overview, err := referralsService.Overview(topReferrers)
+		if err != nil {
+			return e.InternalServerError("Failed to load referrals.", err)
+		}
+		return e.JSON(200, overview)
+	})
+}
@@ -0,0 +1,165 @@
+package referrals
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/security"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// activeWindow is how recently referred users studied to count as active
+const activeWindow = 30 * 24 * time.Hour
+
+// ErrNotReferrer means a child or a guest asked for a referral code
+var ErrNotReferrer = errors.New("only real accounts can refer users")
+
+type Service interface {
+	// Stats returns how the referrals of user's account went, making its
+	// code on first use
+	Stats(user *core.Record) (Stats, error)
+
+	// Referrer returns the id of the account a code belongs to
+	Referrer(code string) (string, error)
+
+	// Reward sets the extra AI requests the referrer earned from the users
+	// they referred who verified their address
+	Reward(referrerId string) error
+
+	// Overview returns how referrals went across the instance, with the
+	// limit users who referred the most
+	Overview(limit int) (Overview, error)
+}
+
+type service struct {
+	app    core.App
+	config Config
+}
+
+func NewService(app core.App, config Config) Service {
+	return &service{app: app, config: config}
+}
+
+func (s *service) Stats(user *core.Record) (Stats, error) {
+	referrerId, ok := referrerOf(user)
+	if !ok {
+		return Stats{}, ErrNotReferrer
+	}
+	referrer, err := s.app.FindRecordById("users", referrerId)
+	if err != nil {
+		return Stats{}, err
+	}
+	if err := s.ensureCode(referrer); err != nil {
+		return Stats{}, err
+	}
+
+	stats := Stats{
+		Code:               referrer.GetString("referral_code"),
+		AIBonus:            referrer.GetInt("ai_bonus"),
+		AIBonusPerReferral: s.config.AIBonus,
+	}
+	if stats.Signups, stats.Verified, err = s.counts(referrer.Id); err != nil {
+		return Stats{}, err
+	}
+
+	since, err := types.ParseDateTime(time.Now().Add(-activeWindow))
+	if err != nil {
+		return Stats{}, err
+	}
+	err = s.app.DB().NewQuery(`
+		SELECT COUNT(DISTINCT active.user) FROM (
+			SELECT user FROM srs WHERE updated >= {:since}
+			UNION
+			SELECT user FROM journal_entry WHERE updated >= {:since}
+		) active JOIN users ON users.id = active.user
+		WHERE users.referred_by = {:referrer}
+	`).Bind(dbx.Params{"since": since.String(), "referrer": referrer.Id}).Row(&stats.Active)
+	if err != nil {
+		return Stats{}, err
+	}
+	return stats, nil
+}
+
+// ensureCode gives the referrer a code if they have none yet, trying again
+// on the rare collision
+func (s *service) ensureCode(referrer *core.Record) error {
+	if referrer.GetString("referral_code") != "" {
+		return nil
+	}
+	var err error
+	for range 3 {
+		referrer.Set("referral_code", security.RandomStringWithAlphabet(codeLength, codeAlphabet))
+		if err = s.app.Save(referrer); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+func (s *service) Referrer(code string) (string, error) {
+	code = normalizeCode(code)
+	if code == "" {
+		return "", sql.ErrNoRows
+	}
+	referrer, err := s.app.FindFirstRecordByData("users", "referral_code", code)
+	if err != nil {
+		return "", err
+	}
+	return referrer.Id, nil
+}
+
+func (s *service) Reward(referrerId string) error {
+	referrer, err := s.app.FindRecordById("users", referrerId)
+	if err != nil {
+		return err
+	}
+	_, verified, err := s.counts(referrer.Id)
+	if err != nil {
+		return err
+	}
+
+	// bonuses earned stay when perks are turned off later, only a
+	// superuser takes them back
+	bonus := s.config.bonus(verified)
+	if s.config.AIBonus == 0 || bonus == referrer.GetInt("ai_bonus") {
+		return nil
+	}
+	referrer.Set("ai_bonus", bonus)
+	return s.app.Save(referrer)
+}
+
+func (s *service) Overview(limit int) (Overview, error) {
+	var overview Overview
+	err := s.app.DB().NewQuery(`
+		SELECT COUNT(*), COALESCE(SUM(verified), 0) FROM users WHERE referred_by != ''
+	`).Row(&overview.Signups, &overview.Verified)
+	if err != nil {
+		return Overview{}, err
+	}
+
+	overview.Referrers = []Referrer{}
+	err = s.app.DB().NewQuery(`
+		SELECT referrer.id, referrer.name, referrer.email,
+			COUNT(*) AS signups, SUM(referred.verified) AS verified
+		FROM users referred JOIN users referrer ON referrer.id = referred.referred_by
+		GROUP BY referrer.id
+		ORDER BY signups DESC, verified DESC, referrer.created
+		LIMIT {:limit}
+	`).Bind(dbx.Params{"limit": limit}).All(&overview.Referrers)
+	if err != nil {
+		return Overview{}, err
+	}
+	return overview, nil
+}
+
+// counts counts the users the referrer referred, and those of them who
+// verified their address
+func (s *service) counts(referrerId string) (signups int, verified int, err error) {
+	err = s.app.DB().NewQuery(`
+		SELECT COUNT(*), COALESCE(SUM(verified), 0) FROM users WHERE referred_by = {:referrer}
+	`).Bind(dbx.Params{"referrer": referrerId}).Row(&signups, &verified)
+	return signups, verified, err
+}
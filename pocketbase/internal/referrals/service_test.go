@@ -0,0 +1,121 @@
+package referrals
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	_ "github.com/bunkbed-tech/fushigi/pocketbase/migrations"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+// newTestApp is a migrated app
+func newTestApp(t *testing.T) *tests.TestApp {
+	t.Helper()
+	// the initial migration creates a superuser from these
+	t.Setenv("ADMIN_EMAIL", "admin@example.com")
+	t.Setenv("ADMIN_PASSWORD", "correct horse battery")
+	app, err := tests.NewTestApp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(app.Cleanup)
+	if err := app.RunAllMigrations(); err != nil {
+		t.Fatal(err)
+	}
+	return app
+}
+
+func saveUser(t *testing.T, app core.App, email string, data map[string]any) *core.Record {
+	t.Helper()
+	users, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := core.NewRecord(users)
+	user.SetEmail(email)
+	// usernames are unique when set, so every user gets one
+	user.Set("username", strings.Split(email, "@")[0])
+	user.SetPassword("correct horse battery")
+	user.Load(data)
+	if err := app.Save(user); err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func TestStats(t *testing.T) {
+	app := newTestApp(t)
+	service := NewService(app, Config{AIBonus: 5, MaxAIBonus: 50})
+
+	referrer := saveUser(t, app, "referrer@example.com", nil)
+	profile := saveUser(t, app, "profile@example.com", map[string]any{"profile_of": referrer.Id})
+	child := saveUser(t, app, "child@example.com", map[string]any{"parent": referrer.Id})
+
+	stats, err := service.Stats(referrer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats.Code) != codeLength || stats.AIBonusPerReferral != 5 {
+		t.Errorf("Stats() = %+v, want a new code and 5 requests per referral", stats)
+	}
+	if again, err := service.Stats(profile); err != nil || again.Code != stats.Code {
+		t.Errorf("profile's Stats() = %+v, %v, want the account's code %s", again, err, stats.Code)
+	}
+	if _, err := service.Stats(child); !errors.Is(err, ErrNotReferrer) {
+		t.Errorf("child's Stats() error = %v, want %v", err, ErrNotReferrer)
+	}
+
+	if id, err := service.Referrer(" " + strings.ToUpper(stats.Code) + " "); err != nil || id != referrer.Id {
+		t.Errorf("Referrer() = %s, %v, want %s", id, err, referrer.Id)
+	}
+	if _, err := service.Referrer("unknown"); err == nil {
+		t.Error("Referrer() of an unknown code succeeded, want an error")
+	}
+}
+
+func TestReward(t *testing.T) {
+	app := newTestApp(t)
+	service := NewService(app, Config{AIBonus: 5, MaxAIBonus: 12})
+	BindHooks(app, service)
+
+	referrer := saveUser(t, app, "referrer@example.com", nil)
+	bonus := func() int {
+		t.Helper()
+		record, err := app.FindRecordById("users", referrer.Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return record.GetInt("ai_bonus")
+	}
+
+	first := saveUser(t, app, "first@example.com", map[string]any{"referred_by": referrer.Id})
+	if got := bonus(); got != 0 {
+		t.Errorf("bonus before anyone verified = %d, want 0", got)
+	}
+
+	first.SetVerified(true)
+	if err := app.Save(first); err != nil {
+		t.Fatal(err)
+	}
+	if got := bonus(); got != 5 {
+		t.Errorf("bonus with one verified = %d, want 5", got)
+	}
+
+	for _, email := range []string{"second@example.com", "third@example.com"} {
+		saveUser(t, app, email, map[string]any{"referred_by": referrer.Id, "verified": true})
+	}
+	if got := bonus(); got != 12 {
+		t.Errorf("bonus with three verified = %d, want the cap of 12", got)
+	}
+
+	overview, err := service.Overview(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if overview.Signups != 3 || overview.Verified != 3 || len(overview.Referrers) != 1 || overview.Referrers[0].Id != referrer.Id {
+		t.Errorf("Overview() = %+v, want 3 verified signups by the referrer", overview)
+	}
+}
@@ -0,0 +1,150 @@
+package reports
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/grammar"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/i18n"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/journal"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/mistakes"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/srs"
+
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+const (
+	// maxFindings bounds each list of a report, it's read in one sitting
+	maxFindings = 5
+
+	// maxExamples bounds the corrections shown for a recurring error
+	maxExamples = 3
+
+	// maxPatternLength is how long a correction's change may be to be
+	// grouped with others, longer ones are rewrites rather than slips
+	maxPatternLength = 8
+
+	// A pattern is overused when it's in at least minOveruse sentences and
+	// overuseShare of the week's
+	minOveruse   = 3
+	overuseShare = 0.25
+
+	// staleAfter is how long grammar the user is learning can go unused in
+	// the journal before it's suggested
+	staleAfter = 30 * 24 * time.Hour
+)
+
+// recurringErrors groups corrections by what they changed, keeping changes
+// made more than once, most frequent first
+func recurringErrors(corrections []Correction) []RecurringError {
+	byPattern := map[string]*RecurringError{}
+	for _, c := range corrections {
+		pattern := correctionPattern(c.Original, c.Corrected)
+		if pattern == "" {
+			continue
+		}
+		found, ok := byPattern[pattern]
+		if !ok {
+			found = &RecurringError{Pattern: pattern, Examples: []Correction{}}
+			byPattern[pattern] = found
+		}
+		found.Count++
+		if len(found.Examples) < maxExamples {
+			found.Examples = append(found.Examples, c)
+		}
+	}
+
+	errs := []RecurringError{}
+	for _, found := range byPattern {
+		if found.Count > 1 {
+			errs = append(errs, *found)
+		}
+	}
+	slices.SortFunc(errs, func(a, b RecurringError) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Pattern, b.Pattern)
+	})
+	return errs[:min(len(errs), maxFindings)]
+}
+
+// correctionPattern is what a correction changed, e.g. "は → が", empty when
+// it's not a small change
+func correctionPattern(original string, corrected string) string {
+	_, removed, added, _ := mistakes.Diff(original, corrected)
+	if removed == added || utf8.RuneCountInString(removed) > maxPatternLength || utf8.RuneCountInString(added) > maxPatternLength {
+		return ""
+	}
+	return orNone(removed) + " → " + orNone(added)
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "∅"
+	}
+	return s
+}
+
+// overusedPatterns finds the grammar the week's sentences lean on
+func overusedPatterns(sentences []journal.Sentence, usages map[string]string) []Overuse {
+	counts := map[string]int{}
+	for _, sentence := range sentences {
+		if sentence.Grammar != "" {
+			counts[sentence.Grammar]++
+		}
+	}
+
+	overused := []Overuse{}
+	for id, n := range counts {
+		if n >= minOveruse && float64(n) >= overuseShare*float64(len(sentences)) {
+			overused = append(overused, Overuse{Grammar: id, Pattern: usages[id], Count: n})
+		}
+	}
+	slices.SortFunc(overused, func(a, b Overuse) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Pattern, b.Pattern)
+	})
+	return overused[:min(len(overused), maxFindings)]
+}
+
+// suggestionCandidates are the grammar the user is learning but hasn't used
+// in the journal lately, never used first, then longest unused
+func suggestionCandidates(cards []srs.Card, lastUsed map[string]types.DateTime, now time.Time) []string {
+	var ids []string
+	for _, card := range cards {
+		if card.IsNew() || card.Grammar == "" {
+			continue
+		}
+		if stage := card.DerivedStage(); stage != srs.StageLearning && stage != srs.StageYoung {
+			continue
+		}
+		if used, ok := lastUsed[card.Grammar]; ok && now.Sub(used.Time()) < staleAfter {
+			continue
+		}
+		ids = append(ids, card.Grammar)
+	}
+
+	slices.SortFunc(ids, func(a, b string) int {
+		return lastUsed[a].Time().Compare(lastUsed[b].Time())
+	})
+	return ids
+}
+
+// suggestGrammar explains the first few candidates in the user's locale
+func suggestGrammar(candidates []grammar.Grammar, lastUsed map[string]types.DateTime, locale string) []Suggestion {
+	suggestions := make([]Suggestion, 0, maxFindings)
+	for _, g := range candidates[:min(len(candidates), maxFindings)] {
+		reason := i18n.T(locale, "You're learning it but haven't used it in your journal yet.")
+		if _, ok := lastUsed[g.Id]; ok {
+			reason = i18n.T(locale, "You're learning it but haven't used it in your journal lately.")
+		}
+		suggestions = append(suggestions, Suggestion{Grammar: g.Id, Usage: g.Usage, Reason: reason})
+	}
+	return suggestions
+}
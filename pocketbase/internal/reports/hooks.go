@@ -0,0 +1,17 @@
+package reports
+
+import (
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// BindHooks writes last week's reports early every Monday, emailing the
+// digests along
+func BindHooks(app core.App, reportsService Service) {
+	app.Cron().MustAdd("fushigiWeeklyReports", "0 6 * * 1", func() {
+		if err := reportsService.SendDigests(time.Now()); err != nil {
+			app.Logger().Error("Failed to start the weekly reports", "error", err)
+		}
+	})
+}
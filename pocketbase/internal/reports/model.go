@@ -0,0 +1,96 @@
+package reports
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/ai"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/grammar"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/i18n"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/journal"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/prompts"
+)
+
+const (
+	// maxPromptEntries and maxEntryLength bound how much of the week is sent
+	// to the model
+	maxPromptEntries = 20
+	maxEntryLength   = 1500
+)
+
+// weekInput is what feedback on a week is written from
+type weekInput struct {
+	entries     []journal.Entry
+	corrections []Correction
+	candidates  []grammar.Grammar
+}
+
+// feedbackByModel has the model write the week's feedback, looking up the
+// grammar it names among what the user knows, and the prompt template it was
+// asked with
+func feedbackByModel(ctx context.Context, client ai.Client, promptsService prompts.Service, in weekInput, known []grammar.Grammar, locale string) (Feedback, string, error) {
+	language := i18n.LanguageNames[locale]
+	if language == "" {
+		language = i18n.LanguageNames[i18n.DefaultLocale]
+	}
+
+	var prompt strings.Builder
+	for i, entry := range in.entries[:min(len(in.entries), maxPromptEntries)] {
+		content := []rune(entry.Content)
+		fmt.Fprintf(&prompt, "Entry %d: %s\n%s\n\n", i+1, entry.Title, string(content[:min(len(content), maxEntryLength)]))
+	}
+	for _, c := range in.corrections {
+		fmt.Fprintf(&prompt, "Correction: %s → %s\n", c.Original, c.Corrected)
+	}
+	if len(in.candidates) > 0 {
+		usages := make([]string, 0, len(in.candidates))
+		for _, g := range in.candidates {
+			usages = append(usages, g.Usage)
+		}
+		fmt.Fprintf(&prompt, "\nCandidates the learner is studying: %s\n", strings.Join(usages, ", "))
+	}
+
+	rendered, err := promptsService.Render(prompts.KeyWeeklyFeedback, "", prompts.Data{Explained: language, Input: prompt.String()})
+	if err != nil {
+		return Feedback{}, "", err
+	}
+	answer, err := client.Complete(ctx, ai.Request{
+		System: rendered.System,
+		Prompt: rendered.User,
+		JSON:   true,
+	})
+	if err != nil {
+		return Feedback{}, "", err
+	}
+
+	var feedback Feedback
+	if err := json.Unmarshal([]byte(answer), &feedback); err != nil {
+		return Feedback{}, "", fmt.Errorf("unexpected feedback from the model: %w", err)
+	}
+	feedback.normalize()
+
+	byUsage := map[string]string{}
+	for _, g := range known {
+		byUsage[g.Usage] = g.Id
+	}
+	feedback.RecurringErrors = feedback.RecurringErrors[:min(len(feedback.RecurringErrors), maxFindings)]
+	for i := range feedback.RecurringErrors {
+		found := &feedback.RecurringErrors[i]
+		if found.Examples == nil {
+			found.Examples = []Correction{}
+		}
+		found.Examples = found.Examples[:min(len(found.Examples), maxExamples)]
+		found.Count = max(found.Count, len(found.Examples))
+	}
+	feedback.OverusedPatterns = feedback.OverusedPatterns[:min(len(feedback.OverusedPatterns), maxFindings)]
+	for i := range feedback.OverusedPatterns {
+		feedback.OverusedPatterns[i].Grammar = byUsage[feedback.OverusedPatterns[i].Pattern]
+	}
+	feedback.SuggestedGrammar = feedback.SuggestedGrammar[:min(len(feedback.SuggestedGrammar), maxFindings)]
+	for i := range feedback.SuggestedGrammar {
+		feedback.SuggestedGrammar[i].Grammar = byUsage[feedback.SuggestedGrammar[i].Usage]
+	}
+	return feedback, rendered.Template, nil
+}
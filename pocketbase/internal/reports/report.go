@@ -0,0 +1,93 @@
+package reports
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Who wrote a report's feedback
+const (
+	GeneratedByRules = "rules"
+	GeneratedByModel = "model"
+)
+
+// Report is the feedback on a week of a user's journal
+type Report struct {
+	Id   string `json:"id"`
+	User string `json:"user"`
+
+	// WeekStart is when the week the report covers started, in the user's
+	// time zone
+	WeekStart types.DateTime `json:"week_start"`
+	Entries   int            `json:"entries"`
+	Feedback  Feedback       `json:"feedback"`
+
+	GeneratedBy string         `json:"generated_by"`
+	Created     types.DateTime `json:"created"`
+}
+
+// Feedback is what a report tells the user about their week
+type Feedback struct {
+	// Summary is a few sentences on the week, only written by a model
+	Summary string `json:"summary"`
+
+	RecurringErrors  []RecurringError `json:"recurring_errors"`
+	OverusedPatterns []Overuse        `json:"overused_patterns"`
+	SuggestedGrammar []Suggestion     `json:"suggested_grammar"`
+}
+
+// RecurringError is a mistake the user's corrections keep pointing out
+type RecurringError struct {
+	// Pattern is what was corrected, e.g. "は → が"
+	Pattern     string       `json:"pattern"`
+	Count       int          `json:"count"`
+	Explanation string       `json:"explanation"`
+	Examples    []Correction `json:"examples"`
+}
+
+type Correction struct {
+	Original  string `json:"original"`
+	Corrected string `json:"corrected"`
+}
+
+// Overuse is a grammar pattern the user leaned on over the week
+type Overuse struct {
+	// Grammar is empty for patterns that aren't in the user's collection
+	Grammar string `json:"grammar"`
+	Pattern string `json:"pattern"`
+	Count   int    `json:"count"`
+}
+
+// Suggestion is a grammar worth trying in next week's entries
+type Suggestion struct {
+	Grammar string `json:"grammar"`
+	Usage   string `json:"usage"`
+	Reason  string `json:"reason"`
+}
+
+func FromRecord(rec *core.Record) Report {
+	report := Report{
+		Id:          rec.Id,
+		User:        rec.GetString("user"),
+		WeekStart:   rec.GetDateTime("week_start"),
+		Entries:     rec.GetInt("entries"),
+		GeneratedBy: rec.GetString("generated_by"),
+		Created:     rec.GetDateTime("created"),
+	}
+	_ = rec.UnmarshalJSONField("feedback", &report.Feedback)
+	report.Feedback.normalize()
+	return report
+}
+
+// normalize makes empty lists come out as [] rather than null
+func (f *Feedback) normalize() {
+	if f.RecurringErrors == nil {
+		f.RecurringErrors = []RecurringError{}
+	}
+	if f.OverusedPatterns == nil {
+		f.OverusedPatterns = []Overuse{}
+	}
+	if f.SuggestedGrammar == nil {
+		f.SuggestedGrammar = []Suggestion{}
+	}
+}
@@ -0,0 +1,34 @@
+package reports
+
+import (
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/jobs"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+func RegisterRoutes(g *api.Group, reportsService Service) {
+	g.GET("/reports", "The user's weekly feedback reports, newest week first", []Report{}, func(e *core.RequestEvent) error {
+		reports, err := reportsService.List(e.Auth.Id)
+		if err != nil {
+			return e.InternalServerError("Failed to load reports.", err)
+		}
+		return e.JSON(200, reports)
+	})
+
+	g.GET("/reports/{id}", "One of the user's weekly feedback reports", Report{}, func(e *core.RequestEvent) error {
+		report, err := reportsService.Find(e.Auth.Id, e.Request.PathValue("id"))
+		if err != nil {
+			return e.NotFoundError("", err)
+		}
+		return e.JSON(200, report)
+	})
+
+	g.POST("/reports", "Write the feedback report of last week again, in a background job", nil, jobs.Job{}, func(e *core.RequestEvent) error {
+		job, err := reportsService.Generate(e.Auth.Id)
+		if err != nil {
+			return e.InternalServerError("Failed to start the report.", err)
+		}
+		return e.JSON(200, job)
+	})
+}
@@ -0,0 +1,328 @@
+package reports
+
+import (
+	"context"
+	"net/mail"
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/ai"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/emails"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/grammar"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/jobs"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/journal"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/prompts"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/settings"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/srs"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+const JobKindWeekly = "weekly_report"
+
+// reportsListed bounds the reports listed, a year of weeks
+const reportsListed = 52
+
+type Service interface {
+	// List returns the user's reports, newest week first
+	List(userId string) ([]Report, error)
+
+	// Find returns one of the user's reports
+	Find(userId string, id string) (Report, error)
+
+	// Generate writes the report of the user's last full week in a
+	// background job, replacing any previous one for that week
+	Generate(userId string) (jobs.Job, error)
+
+	// SendDigests generates last week's report of every user who wrote in
+	// their journal and emails those who want it a digest linking to it
+	SendDigests(now time.Time) error
+}
+
+type service struct {
+	app             core.App
+	jobsService     jobs.Service
+	journalService  journal.Service
+	grammarService  grammar.Service
+	srsService      srs.Service
+	settingsService settings.Service
+	emailsService   emails.Service
+	promptsService  prompts.Service
+
+	// nil when AI features aren't configured, leaving the built-in analysis
+	client ai.Client
+}
+
+func NewService(app core.App, jobsService jobs.Service, journalService journal.Service, grammarService grammar.Service, srsService srs.Service, settingsService settings.Service, emailsService emails.Service, promptsService prompts.Service, client ai.Client) Service {
+	return &service{
+		app:             app,
+		jobsService:     jobsService,
+		journalService:  journalService,
+		grammarService:  grammarService,
+		srsService:      srsService,
+		settingsService: settingsService,
+		emailsService:   emailsService,
+		promptsService:  promptsService,
+		client:          client,
+	}
+}
+
+func (s *service) List(userId string) ([]Report, error) {
+	records, err := s.app.FindRecordsByFilter("reports", "user = {:user}", "-week_start", reportsListed, 0, dbx.Params{"user": userId})
+	if err != nil {
+		return nil, err
+	}
+
+	reports := make([]Report, 0, len(records))
+	for _, rec := range records {
+		reports = append(reports, FromRecord(rec))
+	}
+	return reports, nil
+}
+
+func (s *service) Find(userId string, id string) (Report, error) {
+	rec, err := s.app.FindFirstRecordByFilter("reports", "id = {:id} && user = {:user}", dbx.Params{"id": id, "user": userId})
+	if err != nil {
+		return Report{}, err
+	}
+	return FromRecord(rec), nil
+}
+
+func (s *service) Generate(userId string) (jobs.Job, error) {
+	userSettings, err := s.settingsService.ForUser(userId)
+	if err != nil {
+		return jobs.Job{}, err
+	}
+	return s.enqueue(userId, userSettings, LastWeek(time.Now(), userSettings.Timezone), false)
+}
+
+// digestWindow is how far back SendDigests looks for users who wrote, a
+// little over a week to cover every time zone
+const digestWindow = 8 * 24 * time.Hour
+
+func (s *service) SendDigests(now time.Time) error {
+	var userIds []string
+	err := s.app.RecordQuery("journal_entry").
+		Select("user").
+		Distinct(true).
+		AndWhere(dbx.NewExp("created >= {:from}", dbx.Params{"from": now.Add(-digestWindow).UTC().Format(types.DefaultDateLayout)})).
+		// sandboxes and guests are asked for reports, not sent them weekly
+		AndWhere(dbx.NewExp("user NOT IN (SELECT id FROM users WHERE sandbox_of != '' OR guest_expires != '')")).
+		Column(&userIds)
+	if err != nil {
+		return err
+	}
+
+	for _, userId := range userIds {
+		userSettings, err := s.settingsService.ForUser(userId)
+		if err != nil {
+			s.app.Logger().Error("Failed to load settings for the weekly report", "user", userId, "error", err)
+			continue
+		}
+
+		weekStart := LastWeek(now, userSettings.Timezone)
+		if _, err := s.findWeek(userId, weekStart); err == nil {
+			continue
+		}
+		if _, err := s.enqueue(userId, userSettings, weekStart, userSettings.NotifyEmail); err != nil {
+			s.app.Logger().Error("Failed to start the weekly report", "user", userId, "error", err)
+		}
+	}
+	return nil
+}
+
+func (s *service) enqueue(userId string, userSettings settings.Settings, weekStart time.Time, notify bool) (jobs.Job, error) {
+	return s.jobsService.Enqueue(userId, JobKindWeekly, func(ctx context.Context, jobId string, progress jobs.Progress) (any, error) {
+		weekEnd := weekStart.AddDate(0, 0, 7)
+		from, _ := types.ParseDateTime(weekStart)
+		to, _ := types.ParseDateTime(weekEnd)
+		entries, err := s.journalService.EntriesBetween(userId, from, to)
+		if err != nil {
+			return nil, err
+		}
+
+		feedback, generatedBy, template, err := s.feedback(ctx, userId, userSettings.Locale, entries, weekEnd)
+		if err != nil {
+			return nil, err
+		}
+		progress.Report(80, "Saving the report")
+
+		rec, err := s.findWeek(userId, weekStart)
+		if err != nil {
+			collection, err := s.app.FindCollectionByNameOrId("reports")
+			if err != nil {
+				return nil, err
+			}
+			rec = core.NewRecord(collection)
+			rec.Set("user", userId)
+			rec.Set("week_start", from)
+		}
+		rec.Set("entries", len(entries))
+		rec.Set("feedback", feedback)
+		rec.Set("generated_by", generatedBy)
+		rec.Set("prompt", template)
+		if err := s.app.Save(rec); err != nil {
+			return nil, err
+		}
+		report := FromRecord(rec)
+
+		if notify && len(entries) > 0 {
+			if err := s.sendDigest(report, userSettings.Locale); err != nil {
+				// the report is there for the user either way
+				s.app.Logger().Error("Failed to send the weekly digest", "user", userId, "error", err)
+			}
+		}
+		return report, nil
+	})
+}
+
+// feedback analyzes the week, with the model when there is one, falling back
+// to the built-in analysis when it fails. The model's feedback comes with the
+// prompt template it was asked with.
+func (s *service) feedback(ctx context.Context, userId string, locale string, entries []journal.Entry, weekEnd time.Time) (Feedback, string, string, error) {
+	entryIds := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		entryIds = append(entryIds, entry.Id)
+	}
+
+	bySentence, err := s.journalService.Sentences(entryIds)
+	if err != nil {
+		return Feedback{}, "", "", err
+	}
+	var sentences []journal.Sentence
+	for _, list := range bySentence {
+		sentences = append(sentences, list...)
+	}
+
+	corrections, err := s.corrections(entryIds)
+	if err != nil {
+		return Feedback{}, "", "", err
+	}
+
+	cards, err := s.srsService.Cards(userId)
+	if err != nil {
+		return Feedback{}, "", "", err
+	}
+	lastUsed, err := s.journalService.LastUsed(userId)
+	if err != nil {
+		return Feedback{}, "", "", err
+	}
+	candidateIds := suggestionCandidates(cards, lastUsed, weekEnd)
+
+	library, err := s.grammarService.Library()
+	if err != nil {
+		return Feedback{}, "", "", err
+	}
+	owned, err := s.grammarService.Owned(userId)
+	if err != nil {
+		return Feedback{}, "", "", err
+	}
+	known := append(library, owned...)
+	byId := make(map[string]grammar.Grammar, len(known))
+	usages := make(map[string]string, len(known))
+	for _, g := range known {
+		byId[g.Id] = g
+		usages[g.Id] = g.Usage
+	}
+	candidates := make([]grammar.Grammar, 0, len(candidateIds))
+	for _, id := range candidateIds {
+		if g, ok := byId[id]; ok {
+			candidates = append(candidates, g)
+		}
+	}
+
+	if s.client != nil && len(entries) > 0 {
+		in := weekInput{entries: entries, corrections: corrections, candidates: candidates}
+		// the whole week goes out, no one entry
+		ctx = ai.WithSubject(ctx, ai.Subject{User: userId, Collection: "journal_entry"})
+		feedback, template, err := feedbackByModel(ctx, s.client, s.promptsService, in, known, locale)
+		if err == nil {
+			return feedback, GeneratedByModel, template, nil
+		}
+		s.app.Logger().Warn("Failed to write the weekly feedback with the model", "user", userId, "error", err)
+	}
+
+	return Feedback{
+		RecurringErrors:  recurringErrors(corrections),
+		OverusedPatterns: overusedPatterns(sentences, usages),
+		SuggestedGrammar: suggestGrammar(candidates, lastUsed, locale),
+	}, GeneratedByRules, "", nil
+}
+
+// corrections are the sentence corrections the given entries received
+func (s *service) corrections(entryIds []string) ([]Correction, error) {
+	corrections := []Correction{}
+	if len(entryIds) == 0 {
+		return corrections, nil
+	}
+
+	ids := make([]any, len(entryIds))
+	for i, id := range entryIds {
+		ids[i] = id
+	}
+	var records []*core.Record
+	err := s.app.RecordQuery("corrections").
+		AndWhere(dbx.In("journal_entry", ids...)).
+		AndWhere(dbx.NewExp("original != ''")).
+		OrderBy("created ASC").
+		All(&records)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rec := range records {
+		corrections = append(corrections, Correction{Original: rec.GetString("original"), Corrected: rec.GetString("corrected")})
+	}
+	return corrections, nil
+}
+
+func (s *service) sendDigest(report Report, locale string) error {
+	user, err := s.app.FindRecordById("users", report.User)
+	if err != nil {
+		return err
+	}
+	if user.Email() == "" {
+		return nil
+	}
+
+	due, err := s.srsService.CountDue(report.User, time.Now())
+	if err != nil {
+		return err
+	}
+
+	meta := s.app.Settings().Meta
+	return s.emailsService.Send(
+		mail.Address{Name: user.GetString("name"), Address: user.Email()},
+		emails.KeyWeeklyDigest,
+		locale,
+		emails.Data{
+			AppName:    meta.AppName,
+			AppURL:     meta.AppURL,
+			ActionURL:  meta.AppURL + "/reports/" + report.Id,
+			Name:       user.GetString("name"),
+			Email:      user.Email(),
+			DueCount:   due,
+			EntryCount: report.Entries,
+			Summary:    report.Feedback.Summary,
+		},
+	)
+}
+
+func (s *service) findWeek(userId string, weekStart time.Time) (*core.Record, error) {
+	week, _ := types.ParseDateTime(weekStart)
+	return s.app.FindFirstRecordByFilter("reports", "user = {:user} && week_start = {:week}", dbx.Params{"user": userId, "week": week.String()})
+}
+
+// LastWeek is when the last full week, Monday to Sunday, started in the time
+// zone
+func LastWeek(now time.Time, timezone string) time.Time {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	local := now.In(loc)
+	sinceMonday := (int(local.Weekday()) + 6) % 7
+	thisWeek := time.Date(local.Year(), local.Month(), local.Day()-sinceMonday, 0, 0, 0, 0, loc)
+	return thisWeek.AddDate(0, 0, -7)
+}
@@ -0,0 +1,35 @@
+package sandbox
+
+import (
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+
+	"github.com/pocketbase/pocketbase/apis"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// RegisterRoutes adds the sandbox routes. Clients keep the real account's
+// token to go back to it, the sandbox's token never leading anywhere else.
+func RegisterRoutes(g *api.Group, sandboxService Service) {
+	g.GET("/sandbox", "Whether the user has a sandbox and whether the request came from it", Status{}, func(e *core.RequestEvent) error {
+		status, err := sandboxService.Status(e.Auth)
+		if err != nil {
+			return e.InternalServerError("Failed to load the sandbox.", err)
+		}
+		return e.JSON(200, status)
+	})
+
+	g.POST("/sandbox", "Enter the user's sandbox, a separate account to try imports, AI features and decks in without touching real reviews, getting its auth token", nil, authResponse{}, func(e *core.RequestEvent) error {
+		sandbox, err := sandboxService.Enter(e.Auth)
+		if err != nil {
+			return e.InternalServerError("Failed to open the sandbox.", err)
+		}
+		return apis.RecordAuthResponse(e, sandbox, "", nil)
+	})
+
+	g.DELETE("/sandbox", "Delete the user's sandbox and everything made in it, from either account", func(e *core.RequestEvent) error {
+		if err := sandboxService.Purge(e.Auth); err != nil {
+			return e.InternalServerError("Failed to purge the sandbox.", err)
+		}
+		return e.NoContent(204)
+	})
+}
@@ -0,0 +1,35 @@
+package sandbox
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Status is whether the user has a sandbox and whether they're in it
+type Status struct {
+	// Active is whether the request was made from the sandbox
+	Active bool `json:"active"`
+
+	Exists  bool           `json:"exists"`
+	Created types.DateTime `json:"created"`
+}
+
+// authResponse is what entering the sandbox returns, as logging in does
+type authResponse struct {
+	Token  string         `json:"token"`
+	Record map[string]any `json:"record"`
+}
+
+// IsSandbox reports whether user is a sandbox rather than a real account
+func IsSandbox(user *core.Record) bool {
+	return user.GetString("sandbox_of") != ""
+}
+
+// OwnerOf returns the id of the real account behind user, its own unless
+// it's a sandbox
+func OwnerOf(user *core.Record) string {
+	if owner := user.GetString("sandbox_of"); owner != "" {
+		return owner
+	}
+	return user.Id
+}
@@ -0,0 +1,104 @@
+package sandbox
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/settings"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// emailDomain is where sandboxes' addresses are, reserved so nothing is
+// ever delivered there (RFC 2606)
+const emailDomain = "sandbox.fushigi.invalid"
+
+type Service interface {
+	// Status tells whether user, the real account or its sandbox, has a
+	// sandbox
+	Status(user *core.Record) (Status, error)
+
+	// Enter returns the sandbox of user's real account, made on first use
+	// with the account's settings but without notifications
+	Enter(user *core.Record) (*core.Record, error)
+
+	// Purge deletes the sandbox of user's real account along with everything
+	// made in it, if there's one
+	Purge(user *core.Record) error
+}
+
+type service struct {
+	app core.App
+}
+
+func NewService(app core.App) Service {
+	return &service{app: app}
+}
+
+func (s *service) Status(user *core.Record) (Status, error) {
+	status := Status{Active: IsSandbox(user)}
+	sandbox, err := s.find(OwnerOf(user))
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return status, nil
+	case err != nil:
+		return Status{}, err
+	}
+	status.Exists = true
+	status.Created = sandbox.GetDateTime("created")
+	return status, nil
+}
+
+func (s *service) Enter(user *core.Record) (*core.Record, error) {
+	if IsSandbox(user) {
+		return user, nil
+	}
+	sandbox, err := s.find(user.Id)
+	if !errors.Is(err, sql.ErrNoRows) {
+		return sandbox, err
+	}
+
+	err = s.app.RunInTransaction(func(txApp core.App) error {
+		sandbox = core.NewRecord(user.Collection())
+		sandbox.SetEmail("sandbox+" + user.Id + "@" + emailDomain)
+		sandbox.SetEmailVisibility(false)
+		sandbox.SetVerified(false)
+		sandbox.SetRandomPassword()
+		sandbox.Set("name", user.GetString("name"))
+		sandbox.Set("sandbox_of", user.Id)
+		sandbox.Set("has_password", false)
+
+		// trying things out doesn't get anyone more of the instance
+		sandbox.Set("rate_tier", user.GetString("rate_tier"))
+		sandbox.Set("storage_quota", user.GetInt("storage_quota"))
+		if err := txApp.Save(sandbox); err != nil {
+			return err
+		}
+		// the sandbox gets the defaults like any new account if the owner
+		// has no settings
+		if _, err := settings.CreateLinked(txApp, user.Id, sandbox.Id, ""); err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sandbox, nil
+}
+
+func (s *service) Purge(user *core.Record) error {
+	sandbox, err := s.find(OwnerOf(user))
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil
+	case err != nil:
+		return err
+	}
+	// what the sandbox made goes with it, every relation to users cascading
+	return s.app.Delete(sandbox)
+}
+
+func (s *service) find(ownerId string) (*core.Record, error) {
+	return s.app.FindFirstRecordByData("users", "sandbox_of", ownerId)
+}
@@ -0,0 +1,40 @@
+package sessions
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+func RegisterRoutes(g *api.Group, sessionsService Service) {
+	g.POST("/sessions", "Start a writing or review session, or record one timed offline", StartRequest{}, Session{}, func(e *core.RequestEvent) error {
+		var req StartRequest
+		if err := e.BindBody(&req); err != nil {
+			return e.BadRequestError("Invalid request body.", err)
+		}
+		if err := req.Validate(time.Now()); err != nil {
+			return e.BadRequestError("Invalid study session.", err)
+		}
+
+		session, err := sessionsService.Start(e.Auth.Id, req)
+		if err != nil {
+			return e.NotFoundError("", err)
+		}
+		return e.JSON(200, session)
+	})
+
+	g.POST("/sessions/{id}/stop", "Stop a running session", nil, Session{}, func(e *core.RequestEvent) error {
+		session, err := sessionsService.Stop(e.Auth.Id, e.Request.PathValue("id"))
+		if errors.Is(err, ErrStopped) {
+			return e.Error(http.StatusConflict, "This study session was already stopped.", err)
+		}
+		if err != nil {
+			return e.NotFoundError("", err)
+		}
+		return e.JSON(200, session)
+	})
+}
@@ -0,0 +1,207 @@
+package sessions
+
+import (
+	"errors"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+const day = 24 * time.Hour
+
+var ErrStopped = errors.New("session already stopped")
+
+// Totals is the time spent studying in seconds, over the last day, week and
+// month and all time. Only stopped sessions count.
+type Totals struct {
+	Day      int            `json:"day"`
+	Week     int            `json:"week"`
+	Month    int            `json:"month"`
+	Total    int            `json:"total"`
+	ByKind   map[string]int `json:"by_kind"`
+	Sessions int            `json:"sessions"`
+
+	// Completed counts the planned sessions the user saw through
+	Completed int `json:"completed"`
+}
+
+type Service interface {
+	// Start records a new session, already stopped when the request has both
+	// ends
+	Start(userId string, req StartRequest) (Session, error)
+
+	// StartPlanned starts a guided study session aiming to get through plan
+	// in target
+	StartPlanned(userId string, target time.Duration, plan Plan) (Session, error)
+
+	// Stop ends one of the user's running sessions now, planned ones count as
+	// completed when they reached their target
+	Stop(userId string, id string) (Session, error)
+
+	// Find returns one of the user's sessions
+	Find(userId string, id string) (Session, error)
+
+	// Totals sums up the time the user spent studying
+	Totals(userId string, now time.Time) (Totals, error)
+
+	// Studied is the time in seconds the user spent studying in the sessions
+	// they started from a moment on and stopped
+	Studied(userId string, from time.Time) (int, error)
+}
+
+type service struct {
+	app core.App
+}
+
+func NewService(app core.App) Service {
+	return &service{app: app}
+}
+
+func (s *service) Start(userId string, req StartRequest) (Session, error) {
+	collection, err := s.app.FindCollectionByNameOrId("sessions")
+	if err != nil {
+		return Session{}, err
+	}
+
+	if req.JournalEntry != "" {
+		if _, err := s.app.FindFirstRecordByFilter("journal_entry", "id = {:id} && user = {:user}", map[string]any{"id": req.JournalEntry, "user": userId}); err != nil {
+			return Session{}, err
+		}
+	}
+
+	started := req.Started
+	if started.IsZero() {
+		started = types.NowDateTime()
+	}
+
+	record := core.NewRecord(collection)
+	record.Set("user", userId)
+	record.Set("kind", req.Kind)
+	record.Set("started", started)
+	record.Set("journal_entry", req.JournalEntry)
+	if !req.Ended.IsZero() {
+		record.Set("ended", req.Ended)
+		record.Set("duration", duration(started.Time(), req.Ended.Time()))
+	}
+	if err := s.app.Save(record); err != nil {
+		return Session{}, err
+	}
+	return FromRecord(record), nil
+}
+
+func (s *service) StartPlanned(userId string, target time.Duration, plan Plan) (Session, error) {
+	collection, err := s.app.FindCollectionByNameOrId("sessions")
+	if err != nil {
+		return Session{}, err
+	}
+
+	record := core.NewRecord(collection)
+	record.Set("user", userId)
+	record.Set("kind", KindStudy)
+	record.Set("started", types.NowDateTime())
+	record.Set("target_duration", int(target.Seconds()))
+	record.Set("plan", plan)
+	if err := s.app.Save(record); err != nil {
+		return Session{}, err
+	}
+	return FromRecord(record), nil
+}
+
+func (s *service) Stop(userId string, id string) (Session, error) {
+	record, err := s.app.FindFirstRecordByFilter("sessions", "id = {:id} && user = {:user}", map[string]any{"id": id, "user": userId})
+	if err != nil {
+		return Session{}, err
+	}
+	if !record.GetDateTime("ended").IsZero() {
+		return Session{}, ErrStopped
+	}
+
+	now := types.NowDateTime()
+	record.Set("ended", now)
+	record.Set("duration", duration(record.GetDateTime("started").Time(), now.Time()))
+	if target := record.GetInt("target_duration"); target > 0 {
+		record.Set("completed", record.GetInt("duration") >= target)
+	}
+	if err := s.app.Save(record); err != nil {
+		return Session{}, err
+	}
+	return FromRecord(record), nil
+}
+
+func (s *service) Find(userId string, id string) (Session, error) {
+	record, err := s.app.FindFirstRecordByFilter("sessions", "id = {:id} && user = {:user}", map[string]any{"id": id, "user": userId})
+	if err != nil {
+		return Session{}, err
+	}
+	return FromRecord(record), nil
+}
+
+func (s *service) Totals(userId string, now time.Time) (Totals, error) {
+	var rows []struct {
+		Kind      string `db:"kind"`
+		Day       int    `db:"day"`
+		Week      int    `db:"week"`
+		Month     int    `db:"month"`
+		Total     int    `db:"total"`
+		Sessions  int    `db:"sessions"`
+		Completed int    `db:"completed"`
+	}
+	err := s.app.RecordQuery("sessions").
+		Select(
+			"kind",
+			"COALESCE(SUM(CASE WHEN started >= {:day} THEN duration END), 0) AS day",
+			"COALESCE(SUM(CASE WHEN started >= {:week} THEN duration END), 0) AS week",
+			"COALESCE(SUM(CASE WHEN started >= {:month} THEN duration END), 0) AS month",
+			"COALESCE(SUM(duration), 0) AS total",
+			"COUNT(*) AS sessions",
+			"COALESCE(SUM(completed), 0) AS completed",
+		).
+		AndWhere(dbx.HashExp{"user": userId}).
+		AndWhere(dbx.NewExp("ended != ''")).
+		Bind(dbx.Params{
+			"day":   since(now, day),
+			"week":  since(now, 7*day),
+			"month": since(now, 30*day),
+		}).
+		GroupBy("kind").
+		All(&rows)
+	if err != nil {
+		return Totals{}, err
+	}
+
+	totals := Totals{ByKind: map[string]int{}}
+	for _, row := range rows {
+		totals.Day += row.Day
+		totals.Week += row.Week
+		totals.Month += row.Month
+		totals.Total += row.Total
+		totals.Sessions += row.Sessions
+		totals.Completed += row.Completed
+		totals.ByKind[row.Kind] = row.Total
+	}
+	return totals, nil
+}
+
+func (s *service) Studied(userId string, from time.Time) (int, error) {
+	start, err := types.ParseDateTime(from)
+	if err != nil {
+		return 0, err
+	}
+
+	var studied int
+	err = s.app.RecordQuery("sessions").
+		Select("COALESCE(SUM(duration), 0)").
+		AndWhere(dbx.HashExp{"user": userId}).
+		AndWhere(dbx.NewExp("ended != ''")).
+		AndWhere(dbx.NewExp("started >= {:from}", dbx.Params{"from": start.String()})).
+		Row(&studied)
+	return studied, err
+}
+
+// since formats the moment d before now the way PocketBase stores dates
+func since(now time.Time, d time.Duration) string {
+	at, _ := types.ParseDateTime(now.Add(-d))
+	return at.String()
+}
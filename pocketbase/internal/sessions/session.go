@@ -0,0 +1,121 @@
+package sessions
+
+import (
+	"slices"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+const (
+	KindWriting = "writing"
+	KindReview  = "review"
+
+	// KindStudy sessions are planned by the server, see StartPlanned
+	KindStudy = "study"
+)
+
+// Kinds are the sessions clients can start on their own
+var Kinds = []string{KindWriting, KindReview}
+
+const (
+	// MaxDuration caps what one session counts for, so one left running
+	// overnight doesn't inflate the time spent studying
+	MaxDuration = 4 * time.Hour
+
+	// clockSkew is how far in the future a client's clock may put a session
+	clockSkew = 5 * time.Minute
+)
+
+// Plan is what a guided study session sets out to do, by srs record
+type Plan struct {
+	Reviews []string `json:"reviews"`
+	New     []string `json:"new"`
+}
+
+// Session is a stretch of time spent writing in the journal or reviewing
+type Session struct {
+	Id           string         `json:"id"`
+	User         string         `json:"user"`
+	Kind         string         `json:"kind"`
+	Started      types.DateTime `json:"started"`
+	Ended        types.DateTime `json:"ended"`
+	Duration     int            `json:"duration"`
+	JournalEntry string         `json:"journal_entry"`
+
+	// Only set for planned sessions, TargetDuration in seconds
+	TargetDuration int   `json:"target_duration"`
+	Plan           *Plan `json:"plan"`
+	Completed      bool  `json:"completed"`
+
+	Created types.DateTime `json:"created"`
+}
+
+func FromRecord(rec *core.Record) Session {
+	session := Session{
+		Id:             rec.Id,
+		User:           rec.GetString("user"),
+		Kind:           rec.GetString("kind"),
+		Started:        rec.GetDateTime("started"),
+		Ended:          rec.GetDateTime("ended"),
+		Duration:       rec.GetInt("duration"),
+		JournalEntry:   rec.GetString("journal_entry"),
+		TargetDuration: rec.GetInt("target_duration"),
+		Completed:      rec.GetBool("completed"),
+		Created:        rec.GetDateTime("created"),
+	}
+
+	var plan Plan
+	if session.TargetDuration > 0 && rec.UnmarshalJSONField("plan", &plan) == nil {
+		session.Plan = &plan
+	}
+	return session
+}
+
+func (s Session) IsRunning() bool {
+	return s.Ended.IsZero()
+}
+
+// StartRequest starts a session now, or records one a client timed offline
+// when it has both ends
+type StartRequest struct {
+	Kind string `json:"kind"`
+
+	// Both optional, Started defaults to now and Ended to still running
+	Started types.DateTime `json:"started"`
+	Ended   types.DateTime `json:"ended"`
+
+	JournalEntry string `json:"journal_entry"`
+}
+
+func (r StartRequest) Validate(now time.Time) error {
+	errs := validation.Errors{}
+	if !slices.Contains(Kinds, r.Kind) {
+		errs["kind"] = validation.NewError("validation_invalid_value", "Invalid value.")
+	}
+	if !r.Started.IsZero() && r.Started.Time().After(now.Add(clockSkew)) {
+		errs["started"] = validation.NewError("validation_date_in_future", "Can't be in the future.")
+	}
+	if !r.Ended.IsZero() {
+		switch {
+		case r.Started.IsZero():
+			errs["started"] = validation.NewError("validation_required", "Cannot be blank.")
+		case r.Ended.Time().Before(r.Started.Time()):
+			errs["ended"] = validation.NewError("validation_ended_before_started", "Must be after the start.")
+		case r.Ended.Time().After(now.Add(clockSkew)):
+			errs["ended"] = validation.NewError("validation_date_in_future", "Can't be in the future.")
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// duration is how long a session counts for, in seconds
+func duration(started time.Time, ended time.Time) int {
+	return int(min(max(ended.Sub(started), 0), MaxDuration).Seconds())
+}
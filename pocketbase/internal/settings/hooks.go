@@ -0,0 +1,37 @@
+package settings
+
+import (
+	"time"
+	_ "time/tzdata" // the alpine images ship without zoneinfo
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+func BindHooks(app core.App) {
+	app.OnRecordAfterCreateSuccess("users").BindFunc(func(e *core.RecordEvent) error {
+		// users created in a migration only get here once its transaction
+		// commits, by which point the migration may have created their settings
+		if _, err := e.App.FindFirstRecordByData("user_settings", "user", e.Record.Id); err == nil {
+			return e.Next()
+		}
+
+		if _, err := createDefaults(e.App, e.Record.Id); err != nil {
+			// the account exists either way, ForUser recreates the defaults lazily
+			e.App.Logger().Error("Failed to create user settings", "user", e.Record.Id, "error", err)
+		}
+		return e.Next()
+	})
+
+	// Catch typos before they break date math in reminders and rollovers
+	app.OnRecordValidate("user_settings").BindFunc(func(e *core.RecordEvent) error {
+		if tz := e.Record.GetString("timezone"); tz != "" {
+			if _, err := time.LoadLocation(tz); err != nil {
+				return validation.Errors{
+					"timezone": validation.NewError("validation_invalid_timezone", "Unknown time zone."),
+				}
+			}
+		}
+		return e.Next()
+	})
+}
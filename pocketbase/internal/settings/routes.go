@@ -0,0 +1,18 @@
+package settings
+
+import (
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+func RegisterRoutes(g *api.Group, settingsService Service) {
+	// Updates go through the regular user_settings collection API
+	g.GET("/settings", "The user's settings", Settings{}, func(e *core.RequestEvent) error {
+		settings, err := settingsService.ForUser(e.Auth.Id)
+		if err != nil {
+			return e.InternalServerError("Failed to load settings.", err)
+		}
+		return e.JSON(200, settings)
+	})
+}
@@ -0,0 +1,140 @@
+package settings
+
+import (
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+type Service interface {
+	// ForUser returns the user's settings, creating the defaults if missing
+	ForUser(userId string) (Settings, error)
+
+	// Visible reports whether viewer may see what a user shows to visibility,
+	// which they always can of their own
+	Visible(viewerId string, userId string, visibility string) (bool, error)
+}
+
+type service struct {
+	app core.App
+}
+
+func NewService(app core.App) Service {
+	return &service{app: app}
+}
+
+func (s *service) ForUser(userId string) (Settings, error) {
+	record, err := s.app.FindFirstRecordByData("user_settings", "user", userId)
+	if err == nil {
+		return FromRecord(record), nil
+	}
+
+	record, err = createDefaults(s.app, userId)
+	if err != nil {
+		return Settings{}, err
+	}
+	return FromRecord(record), nil
+}
+
+func (s *service) Visible(viewerId string, userId string, visibility string) (bool, error) {
+	switch {
+	case viewerId == userId || visibility == VisibilityPublic:
+		return true, nil
+	case visibility != VisibilityFollowers || viewerId == "":
+		return false, nil
+	}
+
+	// followers count once the user approved them
+	following, err := s.app.CountRecords("follows", dbx.HashExp{"user": viewerId, "following": userId, "status": "approved"})
+	if err != nil {
+		return false, err
+	}
+	return following > 0, nil
+}
+
+// CreateQuiet gives an account no one reads mail for, e.g. a guest, the
+// defaults without notifications, in locale when set
+func CreateQuiet(app core.App, userId string, locale string) (*core.Record, error) {
+	record, err := newDefaults(app, userId)
+	if err != nil {
+		return nil, err
+	}
+	if locale != "" {
+		record.Set("locale", locale)
+	}
+	record.Set("notify_email", false)
+	record.Set("notify_push", false)
+	record.Set("login_alerts", false)
+
+	if err := app.Save(record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+func createDefaults(app core.App, userId string) (*core.Record, error) {
+	record, err := newDefaults(app, userId)
+	if err != nil {
+		return nil, err
+	}
+	if err := app.Save(record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// newDefaults builds the settings new accounts get, unsaved
+func newDefaults(app core.App, userId string) (*core.Record, error) {
+	collection, err := app.FindCollectionByNameOrId("user_settings")
+	if err != nil {
+		return nil, err
+	}
+
+	record := core.NewRecord(collection)
+	record.Set("user", userId)
+	record.Set("locale", DefaultLocale)
+	record.Set("timezone", DefaultTimezone)
+	record.Set("theme", DefaultTheme)
+	record.Set("notify_email", true)
+	record.Set("notify_push", true)
+	record.Set("login_alerts", true)
+	record.Set("default_audience", DefaultAudience)
+	record.Set("profile_visibility", VisibilityPrivate)
+	record.Set("stats_visibility", VisibilityPrivate)
+	record.Set("daily_new_limit", DefaultDailyNewLimit)
+	record.Set("daily_review_limit", DefaultDailyReviewLimit)
+
+	if japanese, _ := app.FindFirstRecordByFilter("languages", "name = 'Japanese'"); japanese != nil {
+		record.Set("default_language", japanese.Id)
+	}
+	return record, nil
+}
+
+// CreateLinked gives an account linked to another, e.g. a sandbox, the
+// other's locale, time zone, theme and language, keeping it quiet and private
+// whatever the other's are. language, when set, is the one it studies instead.
+func CreateLinked(app core.App, fromUserId string, userId string, language string) (*core.Record, error) {
+	from, err := app.FindFirstRecordByData("user_settings", "user", fromUserId)
+	if err != nil {
+		return nil, err
+	}
+
+	record := core.NewRecord(from.Collection())
+	record.Set("user", userId)
+	for _, field := range []string{"locale", "timezone", "theme", "default_language", "daily_new_limit", "daily_review_limit", "daily_goal_minutes"} {
+		record.Set(field, from.Get(field))
+	}
+	if language != "" {
+		record.Set("default_language", language)
+	}
+	record.Set("notify_email", false)
+	record.Set("notify_push", false)
+	record.Set("login_alerts", false)
+	record.Set("default_audience", DefaultAudience)
+	record.Set("profile_visibility", VisibilityPrivate)
+	record.Set("stats_visibility", VisibilityPrivate)
+
+	if err := app.Save(record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
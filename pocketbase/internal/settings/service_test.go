@@ -0,0 +1,149 @@
+package settings_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/settings"
+	_ "github.com/bunkbed-tech/fushigi/pocketbase/migrations"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+// newTestApp is a migrated app
+func newTestApp(t *testing.T) *tests.TestApp {
+	t.Helper()
+	// the initial migration creates a superuser from these
+	t.Setenv("ADMIN_EMAIL", "admin@example.com")
+	t.Setenv("ADMIN_PASSWORD", "correct horse battery")
+	app, err := tests.NewTestApp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(app.Cleanup)
+	if err := app.RunAllMigrations(); err != nil {
+		t.Fatal(err)
+	}
+	return app
+}
+
+func saveUser(t *testing.T, app core.App, email string) *core.Record {
+	t.Helper()
+	users, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := core.NewRecord(users)
+	user.SetEmail(email)
+	// usernames are unique when set, so every user gets one
+	user.Set("username", strings.Split(email, "@")[0])
+	user.SetPassword("correct horse battery")
+	if err := app.Save(user); err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func follow(t *testing.T, app core.App, userId string, followingId string, status string) {
+	t.Helper()
+	follows, err := app.FindCollectionByNameOrId("follows")
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := core.NewRecord(follows)
+	record.Set("user", userId)
+	record.Set("following", followingId)
+	record.Set("status", status)
+	if err := app.Save(record); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVisible(t *testing.T) {
+	app := newTestApp(t)
+	service := settings.NewService(app)
+
+	user := saveUser(t, app, "user@example.com")
+	approved := saveUser(t, app, "approved@example.com")
+	pending := saveUser(t, app, "pending@example.com")
+	stranger := saveUser(t, app, "stranger@example.com")
+	follow(t, app, approved.Id, user.Id, "approved")
+	follow(t, app, pending.Id, user.Id, "pending")
+
+	tests := []struct {
+		name       string
+		viewerId   string
+		visibility string
+		want       bool
+	}{
+		{"own private", user.Id, settings.VisibilityPrivate, true},
+		{"private", approved.Id, settings.VisibilityPrivate, false},
+		{"public", stranger.Id, settings.VisibilityPublic, true},
+		{"public while logged out", "", settings.VisibilityPublic, true},
+		{"followers to an approved follower", approved.Id, settings.VisibilityFollowers, true},
+		{"followers to a pending follower", pending.Id, settings.VisibilityFollowers, false},
+		{"followers to a stranger", stranger.Id, settings.VisibilityFollowers, false},
+		{"followers while logged out", "", settings.VisibilityFollowers, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := service.Visible(tt.viewerId, user.Id, tt.visibility)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Visible() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProfileVisibility(t *testing.T) {
+	app := newTestApp(t)
+	service := settings.NewService(app)
+
+	user := saveUser(t, app, "user@example.com")
+	approved := saveUser(t, app, "approved@example.com")
+	pending := saveUser(t, app, "pending@example.com")
+	follow(t, app, approved.Id, user.Id, "approved")
+	follow(t, app, pending.Id, user.Id, "pending")
+
+	if _, err := service.ForUser(user.Id); err != nil {
+		t.Fatal(err)
+	}
+	userSettings, err := app.FindFirstRecordByData("user_settings", "user", user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		visibility string
+		viewer     *core.Record
+		want       bool
+	}{
+		{settings.VisibilityPrivate, user, true},
+		{settings.VisibilityPrivate, approved, false},
+		{settings.VisibilityFollowers, approved, true},
+		{settings.VisibilityFollowers, pending, false},
+		{settings.VisibilityPublic, pending, true},
+		{settings.VisibilityPublic, nil, false},
+	}
+	for _, tt := range tests {
+		userSettings.Set("profile_visibility", tt.visibility)
+		if err := app.Save(userSettings); err != nil {
+			t.Fatal(err)
+		}
+		viewer := "logged out"
+		if tt.viewer != nil {
+			viewer = tt.viewer.Email()
+		}
+		ok, err := app.CanAccessRecord(user, &core.RequestInfo{Auth: tt.viewer}, user.Collection().ViewRule)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok != tt.want {
+			t.Errorf("%s profile seen by %s = %v, want %v", tt.visibility, viewer, ok, tt.want)
+		}
+	}
+}
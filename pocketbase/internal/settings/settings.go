@@ -0,0 +1,80 @@
+package settings
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+)
+
+const (
+	DefaultLocale   = "en"
+	DefaultTimezone = "UTC"
+	DefaultTheme    = "system"
+
+	// DefaultAudience is who new entries are shown to unless the user picks
+	// otherwise, only them
+	DefaultAudience = "private"
+
+	// How many new cards and reviews the due list hands out a day unless
+	// the user picks otherwise, Anki's defaults
+	DefaultDailyNewLimit    = 20
+	DefaultDailyReviewLimit = 200
+)
+
+// Who can see a user's profile or stats besides them
+const (
+	VisibilityPrivate   = "private"
+	VisibilityFollowers = "followers"
+	VisibilityPublic    = "public"
+)
+
+// Settings are a user's preferences shared by every client
+type Settings struct {
+	Id              string `json:"id"`
+	User            string `json:"user"`
+	Locale          string `json:"locale"`
+	Timezone        string `json:"timezone"`
+	Theme           string `json:"theme"`
+	DefaultLanguage string `json:"default_language"`
+	NotifyEmail     bool   `json:"notify_email"`
+	NotifyPush      bool   `json:"notify_push"`
+	LoginAlerts     bool   `json:"login_alerts"`
+
+	// DefaultAudience is the audience of the entries the user writes without
+	// picking one
+	DefaultAudience string `json:"default_audience"`
+
+	// ProfileVisibility and StatsVisibility are who among logged in users
+	// can see the user's profile and study stats
+	ProfileVisibility string `json:"profile_visibility"`
+	StatsVisibility   string `json:"stats_visibility"`
+
+	// DailyNewLimit and DailyReviewLimit cap the new cards and reviews the
+	// due list hands out a day, 0 for none
+	DailyNewLimit    int `json:"daily_new_limit"`
+	DailyReviewLimit int `json:"daily_review_limit"`
+
+	// DailyGoalMinutes is how long the user means to study a day, 0 for no
+	// goal
+	DailyGoalMinutes int `json:"daily_goal_minutes"`
+}
+
+func FromRecord(rec *core.Record) Settings {
+	return Settings{
+		Id:              rec.Id,
+		User:            rec.GetString("user"),
+		Locale:          rec.GetString("locale"),
+		Timezone:        rec.GetString("timezone"),
+		Theme:           rec.GetString("theme"),
+		DefaultLanguage: rec.GetString("default_language"),
+		NotifyEmail:     rec.GetBool("notify_email"),
+		NotifyPush:      rec.GetBool("notify_push"),
+		LoginAlerts:     rec.GetBool("login_alerts"),
+
+		DefaultAudience:   rec.GetString("default_audience"),
+		ProfileVisibility: rec.GetString("profile_visibility"),
+		StatsVisibility:   rec.GetString("stats_visibility"),
+
+		DailyNewLimit:    rec.GetInt("daily_new_limit"),
+		DailyReviewLimit: rec.GetInt("daily_review_limit"),
+		DailyGoalMinutes: rec.GetInt("daily_goal_minutes"),
+	}
+}
@@ -0,0 +1,23 @@
+package setup
+
+import (
+	"fmt"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// BindHooks prints the setup token on a fresh instance, whoever runs the
+// server is the one who can read it
+func BindHooks(app core.App, setupService Service) {
+	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
+		firstRun, err := setupService.FirstRun()
+		if err != nil {
+			return err
+		}
+		if firstRun {
+			// in the message, the console leaves out attributes outside dev mode
+			app.Logger().Warn(fmt.Sprintf("No superuser yet, finish setting up the instance with the setup token %s", setupService.Token()))
+		}
+		return se.Next()
+	})
+}
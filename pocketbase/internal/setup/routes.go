@@ -0,0 +1,110 @@
+package setup
+
+import (
+	"errors"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// firstRun is all a fresh instance tells anyone about itself
+type firstRun struct {
+	FirstRun bool `json:"first_run"`
+}
+
+// RegisterPublicRoutes adds the routes the setup page starts with, before
+// there's anyone to log in as
+func RegisterPublicRoutes(g *api.Group, setupService Service) {
+	g.GET("/setup", "Whether the instance still needs its first superuser", firstRun{}, func(e *core.RequestEvent) error {
+		first, err := setupService.FirstRun()
+		if err != nil {
+			return e.InternalServerError("Failed to check the instance's setup.", err)
+		}
+		return e.JSON(200, firstRun{FirstRun: first})
+	})
+
+	g.POST("/setup/admin", "Make the first superuser with the setup token from the server's log, signing them in", AdminRequest{}, AdminResult{}, func(e *core.RequestEvent) error {
+		var req AdminRequest
+		if err := e.BindBody(&req); err != nil {
+			return e.BadRequestError("Invalid request body.", err)
+		}
+		if err := req.Validate(); err != nil {
+			return e.BadRequestError("Invalid superuser.", err)
+		}
+
+		result, err := setupService.CreateAdmin(req)
+		switch {
+		case errors.Is(err, ErrBadToken):
+			return e.BadRequestError("Invalid superuser.", validation.Errors{
+				"token": validation.NewError("validation_invalid_value", "Not the setup token in the server's log."),
+			})
+		case errors.Is(err, ErrSetUp):
+			return e.ForbiddenError("The instance is already set up.", err)
+		case err != nil:
+			return e.InternalServerError("Failed to create the superuser.", err)
+		}
+		return e.JSON(200, result)
+	})
+}
+
+// RegisterAdminRoutes adds the rest of the setup, for the new superuser
+func RegisterAdminRoutes(g *api.Group, setupService Service) {
+	g.GET("/setup", "How far the instance's setup got", Status{}, func(e *core.RequestEvent) error {
+		return status(e, setupService)
+	})
+
+	g.POST("/setup/smtp", "Send emails through an SMTP server", SMTPRequest{}, Status{}, func(e *core.RequestEvent) error {
+		var req SMTPRequest
+		if err := e.BindBody(&req); err != nil {
+			return e.BadRequestError("Invalid request body.", err)
+		}
+		if err := req.Validate(); err != nil {
+			return e.BadRequestError("Invalid SMTP settings.", err)
+		}
+		if err := setupService.ConfigureSMTP(req); err != nil {
+			return e.InternalServerError("Failed to save the SMTP settings.", err)
+		}
+		return status(e, setupService)
+	})
+
+	g.POST("/setup/languages", "Choose the languages users can study", LanguagesRequest{}, Status{}, func(e *core.RequestEvent) error {
+		var req LanguagesRequest
+		if err := e.BindBody(&req); err != nil {
+			return e.BadRequestError("Invalid request body.", err)
+		}
+		err := setupService.SetLanguages(req.Enabled)
+		switch {
+		case errors.Is(err, ErrNoLanguages):
+			return e.BadRequestError("Invalid languages.", validation.Errors{
+				"enabled": validation.NewError("validation_required", "Choose at least one language."),
+			})
+		case err != nil:
+			return e.InternalServerError("Failed to save the languages.", err)
+		}
+		return status(e, setupService)
+	})
+
+	g.POST("/setup/demo", "Add or remove the demo account the apps sign into in demo mode", DemoRequest{}, Status{}, func(e *core.RequestEvent) error {
+		var req DemoRequest
+		if err := e.BindBody(&req); err != nil {
+			return e.BadRequestError("Invalid request body.", err)
+		}
+		if err := req.Validate(); err != nil {
+			return e.BadRequestError("Invalid demo account.", err)
+		}
+		if err := setupService.SetDemo(req.Enabled, req.Password); err != nil {
+			return e.InternalServerError("Failed to save the demo account.", err)
+		}
+		return status(e, setupService)
+	})
+}
+
+func status(e *core.RequestEvent, setupService Service) error {
+	status, err := setupService.Status()
+	if err != nil {
+		return e.InternalServerError("Failed to load the instance's setup.", err)
+	}
+	return e.JSON(200, status)
+}
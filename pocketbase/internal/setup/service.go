@@ -0,0 +1,212 @@
+package setup
+
+import (
+	"errors"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/security"
+)
+
+// tokenLength is long enough that the setup token can't be guessed
+const tokenLength = 32
+
+var (
+	// ErrSetUp means the instance already has a superuser
+	ErrSetUp = errors.New("the instance is already set up")
+
+	// ErrBadToken means the setup token given isn't the server's
+	ErrBadToken = errors.New("wrong setup token")
+
+	// ErrNoLanguages means setup would turn every language off
+	ErrNoLanguages = errors.New("no language enabled")
+)
+
+type Service interface {
+	// FirstRun reports whether the instance has no superuser yet, PocketBase's
+	// own installer account aside
+	FirstRun() (bool, error)
+
+	// Token is what makes the first superuser, SETUP_TOKEN or one made up
+	// at start
+	Token() string
+
+	// CreateAdmin makes the first superuser and signs them in
+	CreateAdmin(req AdminRequest) (AdminResult, error)
+
+	// Status reports how far setup got
+	Status() (Status, error)
+
+	// ConfigureSMTP saves how the instance sends emails
+	ConfigureSMTP(req SMTPRequest) error
+
+	// SetLanguages turns on the languages given and off the others
+	SetLanguages(ids []string) error
+
+	// SetDemo adds the demo account, or sets its password when it's there
+	// already, or removes it
+	SetDemo(enabled bool, password string) error
+}
+
+type service struct {
+	app   core.App
+	token string
+}
+
+func NewService(app core.App) Service {
+	token := os.Getenv("SETUP_TOKEN")
+	if token == "" {
+		token = security.RandomString(tokenLength)
+	}
+	return &service{app: app, token: token}
+}
+
+func (s *service) FirstRun() (bool, error) {
+	total, err := s.app.CountRecords(core.CollectionNameSuperusers, dbx.Not(dbx.HashExp{"email": core.DefaultInstallerEmail}))
+	if err != nil {
+		return false, err
+	}
+	return total == 0, nil
+}
+
+func (s *service) Token() string {
+	return s.token
+}
+
+func (s *service) CreateAdmin(req AdminRequest) (AdminResult, error) {
+	if !security.Equal(req.Token, s.token) {
+		return AdminResult{}, ErrBadToken
+	}
+
+	var admin *core.Record
+	err := s.app.RunInTransaction(func(txApp core.App) error {
+		// checked again inside, two tabs can't both make the first one
+		total, err := txApp.CountRecords(core.CollectionNameSuperusers, dbx.Not(dbx.HashExp{"email": core.DefaultInstallerEmail}))
+		if err != nil {
+			return err
+		}
+		if total > 0 {
+			return ErrSetUp
+		}
+
+		collection, err := txApp.FindCollectionByNameOrId(core.CollectionNameSuperusers)
+		if err != nil {
+			return err
+		}
+		admin = core.NewRecord(collection)
+		admin.SetEmail(strings.TrimSpace(req.Email))
+		admin.SetPassword(req.Password)
+		return txApp.Save(admin)
+	})
+	if err != nil {
+		return AdminResult{}, err
+	}
+
+	token, err := admin.NewAuthToken()
+	if err != nil {
+		return AdminResult{}, err
+	}
+	return AdminResult{Id: admin.Id, Email: admin.Email(), Token: token}, nil
+}
+
+func (s *service) Status() (Status, error) {
+	firstRun, err := s.FirstRun()
+	if err != nil {
+		return Status{}, err
+	}
+
+	records, err := s.app.FindRecordsByFilter("languages", "", "name", 0, 0)
+	if err != nil {
+		return Status{}, err
+	}
+	languages := make([]Language, 0, len(records))
+	for _, rec := range records {
+		languages = append(languages, Language{Id: rec.Id, Name: rec.GetString("name"), Enabled: rec.GetBool("enabled")})
+	}
+
+	_, err = s.app.FindAuthRecordByEmail("users", DemoEmail)
+	demo := err == nil
+
+	smtp := s.app.Settings().SMTP
+	return Status{
+		Admin:     !firstRun,
+		SMTP:      smtp.Enabled && smtp.Host != "",
+		Languages: languages,
+		Demo:      demo,
+	}, nil
+}
+
+func (s *service) ConfigureSMTP(req SMTPRequest) error {
+	settings, err := s.app.Settings().Clone()
+	if err != nil {
+		return err
+	}
+
+	settings.SMTP.Enabled = true
+	settings.SMTP.Host = strings.TrimSpace(req.Host)
+	settings.SMTP.Port = req.Port
+	settings.SMTP.Username = req.Username
+	settings.SMTP.Password = req.Password
+	settings.SMTP.TLS = req.TLS
+	settings.Meta.SenderName = strings.TrimSpace(req.SenderName)
+	settings.Meta.SenderAddress = strings.TrimSpace(req.SenderAddress)
+	if req.AppName != "" {
+		settings.Meta.AppName = strings.TrimSpace(req.AppName)
+	}
+	if req.AppURL != "" {
+		settings.Meta.AppURL = strings.TrimRight(strings.TrimSpace(req.AppURL), "/")
+	}
+	return s.app.Save(settings)
+}
+
+func (s *service) SetLanguages(ids []string) error {
+	return s.app.RunInTransaction(func(txApp core.App) error {
+		records, err := txApp.FindAllRecords("languages")
+		if err != nil {
+			return err
+		}
+		enabled := 0
+		for _, rec := range records {
+			on := slices.Contains(ids, rec.Id)
+			if on {
+				enabled++
+			}
+			if rec.GetBool("enabled") == on {
+				continue
+			}
+			rec.Set("enabled", on)
+			if err := txApp.Save(rec); err != nil {
+				return err
+			}
+		}
+		if enabled == 0 {
+			return ErrNoLanguages
+		}
+		return nil
+	})
+}
+
+func (s *service) SetDemo(enabled bool, password string) error {
+	demo, err := s.app.FindAuthRecordByEmail("users", DemoEmail)
+	exists := err == nil
+	switch {
+	case enabled && !exists:
+		users, err := s.app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+		demo = core.NewRecord(users)
+		demo.SetEmail(DemoEmail)
+		demo.SetVerified(true)
+		fallthrough
+	case enabled:
+		demo.SetPassword(password)
+		return s.app.Save(demo)
+	case exists:
+		return s.app.Delete(demo)
+	}
+	return nil
+}
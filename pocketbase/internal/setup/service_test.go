@@ -0,0 +1,216 @@
+package setup
+
+import (
+	"errors"
+	"testing"
+
+	_ "github.com/bunkbed-tech/fushigi/pocketbase/migrations"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+// newTestApp is a migrated app without superusers, the way a fresh
+// instance starts
+func newTestApp(t *testing.T) *tests.TestApp {
+	t.Helper()
+	app, err := tests.NewTestApp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(app.Cleanup)
+	if err := app.RunAllMigrations(); err != nil {
+		t.Fatal(err)
+	}
+
+	// PocketBase won't delete the last superuser through its hooks
+	if _, err := app.DB().Delete(core.CollectionNameSuperusers, nil).Execute(); err != nil {
+		t.Fatal(err)
+	}
+	return app
+}
+
+func TestCreateAdmin(t *testing.T) {
+	t.Setenv("SETUP_TOKEN", "printed in the log")
+	app := newTestApp(t)
+	service := NewService(app)
+
+	if firstRun, err := service.FirstRun(); err != nil || !firstRun {
+		t.Fatalf("FirstRun() = %v, %v, want true", firstRun, err)
+	}
+
+	req := AdminRequest{Token: "guessed", Email: "admin@example.com", Password: "correct horse battery"}
+	if _, err := service.CreateAdmin(req); !errors.Is(err, ErrBadToken) {
+		t.Errorf("CreateAdmin() with the wrong token error = %v, want %v", err, ErrBadToken)
+	}
+
+	req.Token = "printed in the log"
+	admin, err := service.CreateAdmin(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if admin.Email != req.Email || admin.Token == "" {
+		t.Errorf("CreateAdmin() = %+v, want %s signed in", admin, req.Email)
+	}
+	if firstRun, err := service.FirstRun(); err != nil || firstRun {
+		t.Errorf("FirstRun() after setup = %v, %v, want false", firstRun, err)
+	}
+
+	req.Email = "another@example.com"
+	if _, err := service.CreateAdmin(req); !errors.Is(err, ErrSetUp) {
+		t.Errorf("second CreateAdmin() error = %v, want %v", err, ErrSetUp)
+	}
+}
+
+func TestSetLanguages(t *testing.T) {
+	app := newTestApp(t)
+	service := NewService(app)
+
+	languages, err := app.FindAllRecords("languages")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(languages) == 0 {
+		t.Fatal("no languages to enable")
+	}
+
+	if err := service.SetLanguages(nil); !errors.Is(err, ErrNoLanguages) {
+		t.Errorf("SetLanguages() of none error = %v, want %v", err, ErrNoLanguages)
+	}
+	if err := service.SetLanguages([]string{languages[0].Id}); err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := service.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, language := range status.Languages {
+		if want := language.Id == languages[0].Id; language.Enabled != want {
+			t.Errorf("%s enabled = %v, want %v", language.Name, language.Enabled, want)
+		}
+	}
+}
+
+func TestSetDemo(t *testing.T) {
+	app := newTestApp(t)
+	service := NewService(app)
+
+	tests := []struct {
+		enabled  bool
+		password string
+	}{
+		{true, "correct horse battery"},
+		{true, "battery staple"},
+		{false, ""},
+		{false, ""},
+	}
+	for _, tt := range tests {
+		if err := service.SetDemo(tt.enabled, tt.password); err != nil {
+			t.Fatal(err)
+		}
+		status, err := service.Status()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status.Demo != tt.enabled {
+			t.Errorf("demo account after SetDemo(%v) = %v, want %v", tt.enabled, status.Demo, tt.enabled)
+		}
+		if tt.enabled {
+			demo, err := app.FindAuthRecordByEmail("users", DemoEmail)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !demo.ValidatePassword(tt.password) {
+				t.Errorf("demo account doesn't sign in with %q", tt.password)
+			}
+		}
+	}
+}
+
+func TestDemoRequestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     DemoRequest
+		invalid []string
+	}{
+		{"add", DemoRequest{Enabled: true, Password: "12345678"}, nil},
+		{"add without a password", DemoRequest{Enabled: true}, []string{"password"}},
+		{"short password", DemoRequest{Enabled: true, Password: "1234567"}, []string{"password"}},
+		{"remove", DemoRequest{}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkInvalid(t, tt.req.Validate(), tt.invalid)
+		})
+	}
+}
+
+func TestAdminRequestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     AdminRequest
+		invalid []string
+	}{
+		{"valid", AdminRequest{Token: "t", Email: "admin@example.com", Password: "12345678"}, nil},
+		{"missing token", AdminRequest{Email: "admin@example.com", Password: "12345678"}, []string{"token"}},
+		{"bad email", AdminRequest{Token: "t", Email: "admin", Password: "12345678"}, []string{"email"}},
+		{"short password", AdminRequest{Token: "t", Email: "admin@example.com", Password: "1234567"}, []string{"password"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkInvalid(t, tt.req.Validate(), tt.invalid)
+		})
+	}
+}
+
+func TestSMTPRequestValidate(t *testing.T) {
+	valid := SMTPRequest{Host: "smtp.example.com", Port: 587, SenderAddress: "noreply@example.com"}
+	with := func(change func(r *SMTPRequest)) SMTPRequest {
+		r := valid
+		change(&r)
+		return r
+	}
+
+	tests := []struct {
+		name    string
+		req     SMTPRequest
+		invalid []string
+	}{
+		{"valid", valid, nil},
+		{"with an app URL", with(func(r *SMTPRequest) { r.AppURL = "https://fushigi.example.com" }), nil},
+		{"blank host", with(func(r *SMTPRequest) { r.Host = " " }), []string{"host"}},
+		{"port out of range", with(func(r *SMTPRequest) { r.Port = 70000 }), []string{"port"}},
+		{"bad sender", with(func(r *SMTPRequest) { r.SenderAddress = "noreply" }), []string{"sender_address"}},
+		{"app URL without a scheme", with(func(r *SMTPRequest) { r.AppURL = "fushigi.example.com" }), []string{"app_url"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkInvalid(t, tt.req.Validate(), tt.invalid)
+		})
+	}
+}
+
+// checkInvalid checks err names exactly the invalid fields
+func checkInvalid(t *testing.T, err error, invalid []string) {
+	t.Helper()
+	if len(invalid) == 0 {
+		if err != nil {
+			t.Errorf("Validate() = %v, want valid", err)
+		}
+		return
+	}
+	errs, ok := err.(validation.Errors)
+	if !ok {
+		t.Fatalf("Validate() = %v, want %v invalid", err, invalid)
+	}
+	if len(errs) != len(invalid) {
+		t.Errorf("Validate() = %v, want only %v invalid", err, invalid)
+	}
+	for _, field := range invalid {
+		if _, ok := errs[field]; !ok {
+			t.Errorf("Validate() = %v, want %s invalid", err, field)
+		}
+	}
+}
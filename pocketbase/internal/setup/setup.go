@@ -0,0 +1,132 @@
+package setup
+
+import (
+	"net/mail"
+	"net/url"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// DemoEmail is the demo account the apps sign into in demo mode
+const DemoEmail = "tester@example.com"
+
+// minPasswordLength matches what PocketBase asks of superusers
+const minPasswordLength = 8
+
+// Status is how far an instance's setup got
+type Status struct {
+	// Admin is whether there is a superuser yet, setup starts without one
+	Admin bool `json:"admin"`
+
+	// SMTP is whether the instance can send emails
+	SMTP bool `json:"smtp"`
+
+	Languages []Language `json:"languages"`
+
+	// Demo is whether the demo account exists
+	Demo bool `json:"demo"`
+}
+
+// Language is one the instance has grammar for
+type Language struct {
+	Id      string `json:"id"`
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+}
+
+// AdminRequest makes the first superuser. Token is the one printed in the
+// server's log, so the first one to find a fresh instance can't take it.
+type AdminRequest struct {
+	Token    string `json:"token"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+func (r AdminRequest) Validate() error {
+	errs := validation.Errors{}
+
+	if r.Token == "" {
+		errs["token"] = validation.NewError("validation_required", "Cannot be blank.")
+	}
+	if _, err := mail.ParseAddress(r.Email); err != nil {
+		errs["email"] = validation.NewError("validation_is_email", "Must be a valid email address.")
+	}
+	if len(r.Password) < minPasswordLength {
+		errs["password"] = validation.NewError("validation_min_text_constraint", "Must be at least {{.min}} character(s).").
+			SetParams(map[string]any{"min": minPasswordLength})
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// AdminResult signs the new superuser in for the rest of the setup
+type AdminResult struct {
+	Id    string `json:"id"`
+	Email string `json:"email"`
+	Token string `json:"token"`
+}
+
+// SMTPRequest is how the instance sends emails, and where their links go
+type SMTPRequest struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	TLS      bool   `json:"tls"`
+
+	SenderName    string `json:"sender_name"`
+	SenderAddress string `json:"sender_address"`
+
+	// AppName and AppURL are left as they are when empty
+	AppName string `json:"app_name"`
+	AppURL  string `json:"app_url"`
+}
+
+func (r SMTPRequest) Validate() error {
+	errs := validation.Errors{}
+
+	if strings.TrimSpace(r.Host) == "" {
+		errs["host"] = validation.NewError("validation_required", "Cannot be blank.")
+	}
+	if r.Port < 1 || r.Port > 65535 {
+		errs["port"] = validation.NewError("validation_out_of_range", "Must be between {{.min}} and {{.max}}.").
+			SetParams(map[string]any{"min": 1, "max": 65535})
+	}
+	if _, err := mail.ParseAddress(r.SenderAddress); err != nil {
+		errs["sender_address"] = validation.NewError("validation_is_email", "Must be a valid email address.")
+	}
+	if r.AppURL != "" {
+		if u, err := url.Parse(r.AppURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs["app_url"] = validation.NewError("validation_invalid_format", "Invalid format.")
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// LanguagesRequest names the languages users can study, by id
+type LanguagesRequest struct {
+	Enabled []string `json:"enabled"`
+}
+
+// DemoRequest adds or removes the demo account. Password is the one it
+// signs in with, which the operator chooses since anyone can use it.
+type DemoRequest struct {
+	Enabled  bool   `json:"enabled"`
+	Password string `json:"password"`
+}
+
+func (r DemoRequest) Validate() error {
+	if r.Enabled && len(r.Password) < minPasswordLength {
+		return validation.Errors{"password": validation.NewError("validation_min_text_constraint", "Must be at least {{.min}} character(s).").
+			SetParams(map[string]any{"min": minPasswordLength})}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package speaking
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/ai"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/prompts"
+)
+
+// maxCorrections bounds the corrections kept of one entry
+const maxCorrections = 10
+
+type corrections struct {
+	Corrections []Correction `json:"corrections"`
+}
+
+// correctByModel has the model correct the sentences of a transcript, along
+// with the prompt template it was asked with
+func correctByModel(ctx context.Context, client ai.Client, promptsService prompts.Service, language string, transcript string) ([]Correction, string, error) {
+	rendered, err := promptsService.Render(prompts.KeySpokenCorrection, language, prompts.Data{Language: language, Input: transcript})
+	if err != nil {
+		return nil, "", err
+	}
+	answer, err := client.Complete(ctx, ai.Request{
+		System: rendered.System,
+		Prompt: rendered.User,
+		JSON:   true,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	var found corrections
+	if err := json.Unmarshal([]byte(answer), &found); err != nil {
+		return nil, "", fmt.Errorf("unexpected corrections from the model: %w", err)
+	}
+
+	kept := []Correction{}
+	for _, c := range found.Corrections {
+		c.Original, c.Corrected = strings.TrimSpace(c.Original), strings.TrimSpace(c.Corrected)
+		if c.Original == "" || c.Corrected == "" || c.Original == c.Corrected {
+			continue
+		}
+		kept = append(kept, c)
+		if len(kept) == maxCorrections {
+			break
+		}
+	}
+	return kept, rendered.Template, nil
+}
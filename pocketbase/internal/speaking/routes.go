@@ -0,0 +1,82 @@
+package speaking
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/i18n"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/jobs"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+const (
+	// maxAudioSize is what transcription APIs take in one go
+	maxAudioSize = 25 << 20
+
+	maxTitleLength = 200
+)
+
+func RegisterRoutes(g *api.Group, speakingService Service) {
+	g.GET("/speaking/prompt", "The topic of the user's day to speak about, in the language they practice and the request's locale", Prompt{}, func(e *core.RequestEvent) error {
+		prompt, err := speakingService.Prompt(e.Auth.Id, i18n.FromRequest(e), time.Now())
+		if err != nil {
+			return e.InternalServerError("Failed to load the prompt.", err)
+		}
+		return e.JSON(200, prompt)
+	})
+
+	g.GET("/speaking/prompt/audio", "The topic of the user's day read out, as mp3", nil, func(e *core.RequestEvent) error {
+		audio, err := speakingService.PromptAudio(e.Request.Context(), e.Auth.Id, time.Now())
+		if errors.Is(err, ErrUnavailable) || errors.Is(err, ErrNoAudio) {
+			return e.BadRequestError("Listening to prompts isn't set up on this server.", err)
+		}
+		if err != nil {
+			return e.InternalServerError("Failed to read the prompt out.", err)
+		}
+		return e.Blob(http.StatusOK, "audio/mpeg", audio)
+	})
+
+	// Multipart with the recording as "audio" and an optional "title"
+	g.POST("/speaking/entries", "Transcribe a recording as \"audio\" into a spoken journal entry with corrections, in a background job", nil, jobs.Job{}, func(e *core.RequestEvent) error {
+		files, err := e.FindUploadedFiles("audio")
+		if err != nil || len(files) == 0 {
+			return e.BadRequestError("Upload the recording as audio.", err)
+		}
+		if files[0].Size > maxAudioSize {
+			return e.BadRequestError("The recording is too large.", nil)
+		}
+		title := strings.TrimSpace(e.Request.FormValue("title"))
+		if utf8.RuneCountInString(title) > maxTitleLength {
+			return e.BadRequestError("Invalid spoken entry.", validation.Errors{
+				"title": validation.NewError("validation_length_too_long", "The length must be no more than {{.max}}.").
+					SetParams(map[string]any{"max": maxTitleLength}),
+			})
+		}
+
+		f, err := files[0].Reader.Open()
+		if err != nil {
+			return e.BadRequestError("Upload the recording as audio.", err)
+		}
+		defer f.Close()
+		audio, err := io.ReadAll(io.LimitReader(f, maxAudioSize))
+		if err != nil {
+			return e.BadRequestError("Upload the recording as audio.", err)
+		}
+
+		job, err := speakingService.Submit(e.Auth.Id, audio, files[0].OriginalName, title)
+		if errors.Is(err, ErrUnavailable) {
+			return e.BadRequestError("Speaking journal isn't set up on this server.", err)
+		}
+		if err != nil {
+			return e.InternalServerError("Failed to start the transcription.", err)
+		}
+		return e.JSON(200, job)
+	})
+}
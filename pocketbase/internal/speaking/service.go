@@ -0,0 +1,284 @@
+package speaking
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/ai"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/grammar"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/i18n"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/jobs"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/journal"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/plan"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/prompts"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/settings"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/filesystem"
+)
+
+const (
+	JobKindSpokenEntry = "spoken_entry"
+
+	// defaultLanguage is what users who haven't picked a default language
+	// speak
+	defaultLanguage = "Japanese"
+)
+
+var (
+	// ErrUnavailable means the server has no transcription model
+	ErrUnavailable = errors.New("speech features are not configured")
+
+	// ErrNoAudio means the server can transcribe but not speak
+	ErrNoAudio = errors.New("speech synthesis is not configured")
+)
+
+type Service interface {
+	// Prompt is the topic of the user's day in their time zone, translated
+	// into locale
+	Prompt(userId string, locale string, now time.Time) (Prompt, error)
+
+	// PromptAudio reads the topic of the user's day out, as mp3
+	PromptAudio(ctx context.Context, userId string, now time.Time) ([]byte, error)
+
+	// Submit transcribes a recording of the user into a spoken journal entry
+	// in a background job, corrected by the model when there is one. The
+	// entry's title is the day's topic unless one is given. The job's result
+	// is a Result.
+	Submit(userId string, audio []byte, filename string, title string) (jobs.Job, error)
+
+	// Retranscribe transcribes the recording of a spoken entry again, after
+	// the transcription model changed. The entry keeps what the user wrote
+	// once they edited it, retranscribed is false then.
+	Retranscribe(ctx context.Context, entryId string, audio []byte, filename string) (retranscribed bool, err error)
+}
+
+type service struct {
+	app             core.App
+	jobsService     jobs.Service
+	grammarService  grammar.Service
+	settingsService settings.Service
+	promptsService  prompts.Service
+
+	// speech is nil when speech features aren't configured, client when AI
+	// features aren't, which leaves spoken entries uncorrected
+	speech ai.Speech
+	client ai.Client
+}
+
+func NewService(app core.App, jobsService jobs.Service, grammarService grammar.Service, settingsService settings.Service, promptsService prompts.Service, speech ai.Speech, client ai.Client) Service {
+	return &service{
+		app:             app,
+		jobsService:     jobsService,
+		grammarService:  grammarService,
+		settingsService: settingsService,
+		promptsService:  promptsService,
+		speech:          speech,
+		client:          client,
+	}
+}
+
+func (s *service) Prompt(userId string, locale string, now time.Time) (Prompt, error) {
+	day, err := s.today(userId, now)
+	if err != nil {
+		return Prompt{}, err
+	}
+	language, err := s.language(userId)
+	if err != nil {
+		return Prompt{}, err
+	}
+
+	topic := plan.DailyPrompt(day)
+	return Prompt{
+		Date:        day.Format(time.DateOnly),
+		Text:        i18n.T(languageCodes[language], topic),
+		Translation: i18n.T(locale, topic),
+		Audio:       s.speech != nil,
+	}, nil
+}
+
+func (s *service) PromptAudio(ctx context.Context, userId string, now time.Time) ([]byte, error) {
+	if s.speech == nil {
+		return nil, ErrUnavailable
+	}
+	prompt, err := s.Prompt(userId, i18n.DefaultLocale, now)
+	if err != nil {
+		return nil, err
+	}
+
+	audio, err := s.speech.Synthesize(ai.WithSubject(ctx, ai.Subject{User: userId}), prompt.Text)
+	if errors.Is(err, ai.ErrNoSpeechModel) {
+		return nil, ErrNoAudio
+	}
+	return audio, err
+}
+
+func (s *service) Submit(userId string, audio []byte, filename string, title string) (jobs.Job, error) {
+	if s.speech == nil {
+		return jobs.Job{}, ErrUnavailable
+	}
+	if title == "" {
+		prompt, err := s.Prompt(userId, i18n.DefaultLocale, time.Now())
+		if err != nil {
+			return jobs.Job{}, err
+		}
+		title = prompt.Text
+	}
+
+	return s.jobsService.Enqueue(userId, JobKindSpokenEntry, func(ctx context.Context, jobId string, progress jobs.Progress) (any, error) {
+		language, err := s.language(userId)
+		if err != nil {
+			return nil, err
+		}
+		progress.Report(10, "Transcribing")
+		transcript, err := s.speech.Transcribe(ai.WithSubject(ctx, ai.Subject{User: userId, Collection: "journal_entry"}), audio, filename, languageCodes[language])
+		if err != nil {
+			return nil, err
+		}
+
+		progress.Report(50, "Saving the entry")
+		result := Result{Corrections: []Correction{}}
+		err = s.app.RunInTransaction(func(txApp core.App) error {
+			journalCollection, err := txApp.FindCollectionByNameOrId("journal_entry")
+			if err != nil {
+				return err
+			}
+			entry := core.NewRecord(journalCollection)
+			entry.Set("user", userId)
+			entry.Set("title", title)
+			entry.Set("content", transcript)
+			entry.Set("transcript", transcript)
+			entry.Set("spoken", true)
+			if err := txApp.Save(entry); err != nil {
+				return err
+			}
+
+			mediaCollection, err := txApp.FindCollectionByNameOrId("media")
+			if err != nil {
+				return err
+			}
+			file, err := filesystem.NewFileFromBytes(audio, filename)
+			if err != nil {
+				return err
+			}
+			media := core.NewRecord(mediaCollection)
+			media.Set("user", userId)
+			media.Set("journal_entry", entry.Id)
+			media.Set("kind", "audio")
+			media.Set("file", file)
+			if err := txApp.Save(media); err != nil {
+				return err
+			}
+
+			result.Entry = journal.FromRecord(entry)
+			result.Audio = media.Id
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		// the entry stands without corrections, a failing model only loses
+		// those
+		if s.client == nil {
+			return result, nil
+		}
+		progress.Report(70, "Correcting")
+		ctx = ai.WithSubject(ctx, ai.Subject{User: userId, Collection: "journal_entry", Record: result.Entry.Id})
+		found, template, err := correctByModel(ctx, s.client, s.promptsService, language, transcript)
+		if err != nil {
+			s.app.Logger().Warn("Failed to correct a spoken entry", "entry", result.Entry.Id, "error", err)
+			return result, nil
+		}
+		correctionsCollection, err := s.app.FindCollectionByNameOrId("corrections")
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range found {
+			rec := core.NewRecord(correctionsCollection)
+			rec.Set("user", userId)
+			rec.Set("journal_entry", result.Entry.Id)
+			rec.Set("original", c.Original)
+			rec.Set("corrected", c.Corrected)
+			rec.Set("comment", c.Comment)
+			rec.Set("source", "model")
+			rec.Set("prompt", template)
+			if err := s.app.Save(rec); err != nil {
+				return nil, err
+			}
+			result.Corrections = append(result.Corrections, c)
+		}
+		return result, nil
+	})
+}
+
+func (s *service) Retranscribe(ctx context.Context, entryId string, audio []byte, filename string) (bool, error) {
+	if s.speech == nil {
+		return false, ErrUnavailable
+	}
+	entry, err := s.app.FindRecordById("journal_entry", entryId)
+	if err != nil {
+		return false, err
+	}
+	if !entry.GetBool("spoken") || edited(entry) {
+		return false, nil
+	}
+
+	userId := entry.GetString("user")
+	language, err := s.language(userId)
+	if err != nil {
+		return false, err
+	}
+	transcript, err := s.speech.Transcribe(ai.WithSubject(ctx, ai.Subject{User: userId, Collection: "journal_entry", Record: entryId}), audio, filename, languageCodes[language])
+	if err != nil {
+		return false, err
+	}
+
+	entry.Set("content", transcript)
+	entry.Set("transcript", transcript)
+	if err := s.app.Save(entry); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// edited reports whether the user changed a spoken entry's text from what
+// was transcribed. Entries from before transcripts were kept count as
+// edited once saved again after they were made.
+func edited(entry *core.Record) bool {
+	if transcript := entry.GetString("transcript"); transcript != "" {
+		return entry.GetString("content") != transcript
+	}
+	return entry.GetDateTime("updated").Time().Sub(entry.GetDateTime("created").Time()) > time.Second
+}
+
+// today is the start of the user's day in their time zone
+func (s *service) today(userId string, now time.Time) (time.Time, error) {
+	userSettings, err := s.settingsService.ForUser(userId)
+	if err != nil {
+		return time.Time{}, err
+	}
+	loc, err := time.LoadLocation(userSettings.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	local := now.In(loc)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc), nil
+}
+
+// language names the language the user practices, their default one
+func (s *service) language(userId string) (string, error) {
+	userSettings, err := s.settingsService.ForUser(userId)
+	if err != nil {
+		return "", err
+	}
+	names, err := s.grammarService.Languages()
+	if err != nil {
+		return "", err
+	}
+	if name := names[userSettings.DefaultLanguage]; name != "" {
+		return name, nil
+	}
+	return defaultLanguage, nil
+}
@@ -0,0 +1,44 @@
+package speaking
+
+import (
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/journal"
+)
+
+// languageCodes are the ISO 639-1 codes of the languages grammar is in, by
+// name, which transcription is told to expect
+var languageCodes = map[string]string{
+	"Japanese":   "ja",
+	"German":     "de",
+	"Portuguese": "pt",
+}
+
+// Prompt is the day's topic to speak about
+type Prompt struct {
+	Date string `json:"date"`
+
+	// Text is the topic in the language the user practices when there's a
+	// translation, in English otherwise
+	Text string `json:"text"`
+
+	// Translation is the topic in the request's locale
+	Translation string `json:"translation"`
+
+	// Audio is whether the topic can be listened to
+	Audio bool `json:"audio"`
+}
+
+// Correction is a fix the model suggests for a sentence of a transcript
+type Correction struct {
+	Original  string `json:"original"`
+	Corrected string `json:"corrected"`
+	Comment   string `json:"comment"`
+}
+
+// Result is what a spoken entry job leaves behind
+type Result struct {
+	Entry journal.Entry `json:"entry"`
+
+	// Audio is the id of the media record of the recording
+	Audio       string       `json:"audio"`
+	Corrections []Correction `json:"corrections"`
+}
@@ -0,0 +1,110 @@
+package srs
+
+import (
+	"math/rand/v2"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/grammar"
+)
+
+const (
+	assessmentBatchSize    = 10
+	assessmentMaxQuestions = 40
+
+	// Once a tag has been answered "unknown" this many times without a single
+	// "known", grammar carrying only such tags is no longer worth asking about
+	assessmentTagGiveUp = 2
+)
+
+type AssessmentAnswer struct {
+	Grammar string `json:"grammar"`
+	Known   bool   `json:"known"`
+}
+
+type tagTally struct {
+	known   int
+	unknown int
+}
+
+// NextAssessmentBatch picks the next library grammar to quiz the user on,
+// adapting to the answers given so far. An empty batch means the assessment
+// is over.
+func NextAssessmentBatch(library []grammar.Grammar, answers []AssessmentAnswer) []grammar.Grammar {
+	if len(answers) >= assessmentMaxQuestions {
+		return []grammar.Grammar{}
+	}
+
+	asked := make(map[string]bool, len(answers))
+	for _, a := range answers {
+		asked[a.Grammar] = true
+	}
+	tallies := tallyAnswerTags(library, answers)
+
+	candidates := make([]grammar.Grammar, 0, len(library))
+	for _, item := range library {
+		if asked[item.Id] || isHopeless(item, tallies) {
+			continue
+		}
+		candidates = append(candidates, item)
+	}
+
+	rand.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+
+	remaining := min(assessmentBatchSize, assessmentMaxQuestions-len(answers), len(candidates))
+	return candidates[:remaining]
+}
+
+// KnownGrammar returns the grammar ids the user said they know
+func KnownGrammar(answers []AssessmentAnswer) []string {
+	var known []string
+	for _, a := range answers {
+		if a.Known {
+			known = append(known, a.Grammar)
+		}
+	}
+	return known
+}
+
+func tallyAnswerTags(library []grammar.Grammar, answers []AssessmentAnswer) map[string]*tagTally {
+	byId := make(map[string]grammar.Grammar, len(library))
+	for _, item := range library {
+		byId[item.Id] = item
+	}
+
+	tallies := map[string]*tagTally{}
+	for _, a := range answers {
+		item, ok := byId[a.Grammar]
+		if !ok {
+			continue
+		}
+		for _, tag := range item.Tags {
+			t, ok := tallies[tag]
+			if !ok {
+				t = &tagTally{}
+				tallies[tag] = t
+			}
+			if a.Known {
+				t.known++
+			} else {
+				t.unknown++
+			}
+		}
+	}
+	return tallies
+}
+
+// A grammar is not worth asking about when every one of its tags has only
+// ever been answered "unknown"
+func isHopeless(item grammar.Grammar, tallies map[string]*tagTally) bool {
+	if len(item.Tags) == 0 {
+		return false
+	}
+	for _, tag := range item.Tags {
+		t, ok := tallies[tag]
+		if !ok || t.known > 0 || t.unknown < assessmentTagGiveUp {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,76 @@
+package srs
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/grammar"
+)
+
+func TestNextAssessmentBatch(t *testing.T) {
+	// g0 to g14 tagged n5, g15 to g19 tagged n1
+	library := []grammar.Grammar{}
+	for i := range 20 {
+		tag := "n5"
+		if i >= 15 {
+			tag = "n1"
+		}
+		library = append(library, grammar.Grammar{Id: fmt.Sprintf("g%d", i), Tags: []string{tag}})
+	}
+	answered := func(known bool, ids ...string) []AssessmentAnswer {
+		answers := []AssessmentAnswer{}
+		for _, id := range ids {
+			answers = append(answers, AssessmentAnswer{Grammar: id, Known: known})
+		}
+		return answers
+	}
+	many := []AssessmentAnswer{}
+	for i := range assessmentMaxQuestions {
+		many = append(many, AssessmentAnswer{Grammar: fmt.Sprintf("other%d", i), Known: true})
+	}
+
+	tests := []struct {
+		name     string
+		answers  []AssessmentAnswer
+		size     int
+		excluded []string
+	}{
+		{"first batch", nil, assessmentBatchSize, nil},
+		{"asked grammar isn't asked again", answered(true, "g0", "g1", "g2"), assessmentBatchSize, []string{"g0", "g1", "g2"}},
+		{"unknown tags are given up on", answered(false, "g15", "g16"), assessmentBatchSize, []string{"g15", "g16", "g17", "g18", "g19"}},
+		{"fewer left than a batch", answered(true, "g0", "g1", "g2", "g3", "g4", "g5", "g6", "g7", "g8", "g9", "g10", "g11", "g12", "g13"), 6, nil},
+		{"over after the most questions", many, 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			batch := NextAssessmentBatch(library, tt.answers)
+			if len(batch) != tt.size {
+				t.Fatalf("got %d grammar, want %d", len(batch), tt.size)
+			}
+			for _, item := range batch {
+				if slices.Contains(tt.excluded, item.Id) {
+					t.Errorf("got %s, which should be left out", item.Id)
+				}
+			}
+		})
+	}
+}
+
+func TestKnownGrammar(t *testing.T) {
+	tests := []struct {
+		name    string
+		answers []AssessmentAnswer
+		known   []string
+	}{
+		{"none", nil, nil},
+		{"only known", []AssessmentAnswer{{Grammar: "a", Known: true}, {Grammar: "b"}, {Grammar: "c", Known: true}}, []string{"a", "c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := KnownGrammar(tt.answers); !slices.Equal(got, tt.known) {
+				t.Errorf("KnownGrammar() = %v, want %v", got, tt.known)
+			}
+		})
+	}
+}
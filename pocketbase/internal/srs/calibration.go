@@ -0,0 +1,132 @@
+package srs
+
+import (
+	"slices"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/grammar"
+)
+
+const (
+	MinConfidence = 1
+	MaxConfidence = 5
+
+	// strugglingEase is the ease factor below which a card has lapsed more
+	// than once, SM-2 starts cards at 2.5
+	strugglingEase = 2.0
+
+	// overconfidentShown bounds the overconfident grammar in a report
+	overconfidentShown = 20
+)
+
+// expectedRetention is the retention each confidence rating claims, from "no
+// idea" to "know it cold"
+var expectedRetention = map[int]float64{1: 0.5, 2: 0.65, 3: 0.8, 4: 0.9, 5: 0.97}
+
+// CalibrationLevel compares the retention of the cards rated one confidence
+// level with the retention that rating claims. Reviewed counts their logged
+// reviews, Retention is the share of them that passed. Gap is positive when
+// the user was overconfident.
+type CalibrationLevel struct {
+	Confidence int     `json:"confidence"`
+	Cards      int     `json:"cards"`
+	Reviewed   int     `json:"reviewed"`
+	Expected   float64 `json:"expected"`
+	Retention  float64 `json:"retention"`
+	Gap        float64 `json:"gap"`
+
+	remembered int
+}
+
+// Overconfident is grammar the user rated confidently that keeps slipping.
+// Retention is over its logged reviews, Remembered is whether the last one
+// passed.
+type Overconfident struct {
+	Grammar    grammar.Grammar `json:"grammar"`
+	Confidence int             `json:"confidence"`
+	EaseFactor float64         `json:"ease_factor"`
+	Reviews    int             `json:"reviews"`
+	Retention  float64         `json:"retention"`
+	Remembered bool            `json:"remembered"`
+}
+
+type CalibrationReport struct {
+	Levels        []*CalibrationLevel `json:"levels"`
+	Overconfident []Overconfident     `json:"overconfident"`
+}
+
+// Calibrate compares the confidence ratings of the user's cards against how
+// well they were actually retained over every logged review, grades being
+// the review log by card, and returns the report and the cards whose grammar
+// to highlight as overconfident, worst first. Unrated cards are left out.
+func Calibrate(cards []Card, grades map[string][]int) (CalibrationReport, []Card) {
+	report := CalibrationReport{Levels: make([]*CalibrationLevel, 0, MaxConfidence)}
+	for confidence := MinConfidence; confidence <= MaxConfidence; confidence++ {
+		report.Levels = append(report.Levels, &CalibrationLevel{Confidence: confidence, Expected: expectedRetention[confidence]})
+	}
+
+	overconfident := []Card{}
+	for _, card := range cards {
+		if card.Confidence < MinConfidence || card.Confidence > MaxConfidence {
+			continue
+		}
+
+		level := report.Levels[card.Confidence-MinConfidence]
+		level.Cards++
+		reviews := grades[card.Id]
+		if len(reviews) == 0 {
+			continue
+		}
+		level.Reviewed += len(reviews)
+		level.remembered += remembered(reviews)
+
+		if card.Confidence >= 4 && (retention(reviews) < level.Expected || card.EaseFactor < strugglingEase) {
+			overconfident = append(overconfident, card)
+		}
+	}
+
+	for _, level := range report.Levels {
+		if level.Reviewed > 0 {
+			level.Retention = float64(level.remembered) / float64(level.Reviewed)
+			level.Gap = level.Expected - level.Retention
+		}
+	}
+
+	slices.SortFunc(overconfident, func(a, b Card) int {
+		if a.Confidence != b.Confidence {
+			return b.Confidence - a.Confidence
+		}
+		if ra, rb := retention(grades[a.Id]), retention(grades[b.Id]); ra != rb {
+			if ra < rb {
+				return -1
+			}
+			return 1
+		}
+		if a.EaseFactor < b.EaseFactor {
+			return -1
+		}
+		if a.EaseFactor > b.EaseFactor {
+			return 1
+		}
+		return 0
+	})
+	return report, overconfident[:min(len(overconfident), overconfidentShown)]
+}
+
+// remembered counts the passing grades
+func remembered(grades []int) int {
+	n := 0
+	for _, grade := range grades {
+		if grade >= PassingGrade {
+			n++
+		}
+	}
+	return n
+}
+
+// retention is the share of passing grades, 0 without any
+func retention(grades []int) float64 {
+	if len(grades) == 0 {
+		return 0
+	}
+	return float64(remembered(grades)) / float64(len(grades))
+}
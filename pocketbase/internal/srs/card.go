@@ -0,0 +1,95 @@
+package srs
+
+import (
+	"time"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// Cards with an interval at least this long count as "mature" (Anki's cutoff)
+const MatureIntervalDays = 21
+
+// What a card is studied for, the relation of the same name being the one
+// it fills
+const (
+	ItemGrammar    = "grammar"
+	ItemVocabulary = "vocabulary"
+)
+
+// Card is a user's spaced repetition state for one grammar or vocabulary item
+type Card struct {
+	Id       string `json:"id"`
+	User     string `json:"user"`
+	ItemType string `json:"item_type"`
+
+	// Grammar and Vocabulary are the item studied, only the one of the item
+	// type being set
+	Grammar    string `json:"grammar"`
+	Vocabulary string `json:"vocabulary"`
+
+	EaseFactor   float64   `json:"ease_factor"`
+	IntervalDays int       `json:"interval_days"`
+	Repetition   int       `json:"repetition"`
+	LastReviewed time.Time `json:"last_reviewed"`
+	Stage        string    `json:"stage"`
+	Confidence   int       `json:"confidence"`
+
+	// Expires is when a temporary card is removed, zero for cards that stay
+	Expires time.Time `json:"expires"`
+
+	Created time.Time `json:"created"`
+}
+
+func FromRecord(rec *core.Record) Card {
+	return Card{
+		Id:           rec.Id,
+		User:         rec.GetString("user"),
+		ItemType:     rec.GetString("item_type"),
+		Grammar:      rec.GetString("grammar"),
+		Vocabulary:   rec.GetString("vocabulary"),
+		EaseFactor:   rec.GetFloat("ease_factor"),
+		IntervalDays: rec.GetInt("interval_days"),
+		Repetition:   rec.GetInt("repetition"),
+		LastReviewed: rec.GetDateTime("last_reviewed").Time(),
+		Stage:        rec.GetString("stage"),
+		Confidence:   rec.GetInt("confidence"),
+		Expires:      rec.GetDateTime("expires").Time(),
+		Created:      rec.GetDateTime("created").Time(),
+	}
+}
+
+// Item is the id of the grammar or vocabulary the card is for
+func (c Card) Item() string {
+	if c.ItemType == ItemVocabulary {
+		return c.Vocabulary
+	}
+	return c.Grammar
+}
+
+// DueDate works out when a card is next due from its review state. Cards that
+// were never reviewed are due from the moment they were created.
+func (c Card) DueDate() time.Time {
+	if c.IsNew() {
+		return c.Created
+	}
+	return c.LastReviewed.AddDate(0, 0, c.IntervalDays)
+}
+
+func (c Card) IsDue(at time.Time) bool {
+	return !c.DueDate().After(at)
+}
+
+func (c Card) IsNew() bool {
+	return c.LastReviewed.IsZero()
+}
+
+// Remembered reports whether the card was recalled the last time it came up.
+// SM-2 resets repetition on a failed review, so a reviewed card that still
+// has repetitions was remembered.
+func (c Card) Remembered() bool {
+	return !c.IsNew() && c.Repetition > 0
+}
+
+func (c Card) IsMature() bool {
+	return c.IntervalDays >= MatureIntervalDays
+}
@@ -0,0 +1,69 @@
+package srs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCardDueDate(t *testing.T) {
+	now := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		card Card
+		due  time.Time
+	}{
+		{"new cards are due once created", Card{Created: now}, now},
+		{"reviewed cards are due an interval after", Card{Created: now.AddDate(0, -1, 0), IntervalDays: 6, LastReviewed: now}, now.AddDate(0, 0, 6)},
+		{"failed cards are due the day after", Card{IntervalDays: 1, LastReviewed: now}, now.AddDate(0, 0, 1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.card.DueDate(); !got.Equal(tt.due) {
+				t.Errorf("DueDate() = %v, want %v", got, tt.due)
+			}
+		})
+	}
+}
+
+func TestCardIsDue(t *testing.T) {
+	now := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		card Card
+		at   time.Time
+		due  bool
+	}{
+		{"new", Card{Created: now}, now, true},
+		{"overdue", Card{IntervalDays: 3, LastReviewed: now.AddDate(0, 0, -4)}, now, true},
+		{"due right then", Card{IntervalDays: 3, LastReviewed: now.AddDate(0, 0, -3)}, now, true},
+		{"not yet", Card{IntervalDays: 3, LastReviewed: now.AddDate(0, 0, -2)}, now, false},
+		{"due by a later moment", Card{IntervalDays: 3, LastReviewed: now.AddDate(0, 0, -2)}, now.AddDate(0, 0, 1), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.card.IsDue(tt.at); got != tt.due {
+				t.Errorf("IsDue() = %v, want %v", got, tt.due)
+			}
+		})
+	}
+}
+
+func TestCardIsMature(t *testing.T) {
+	tests := []struct {
+		interval int
+		mature   bool
+	}{
+		{0, false},
+		{MatureIntervalDays - 1, false},
+		{MatureIntervalDays, true},
+		{MatureIntervalDays * 4, true},
+	}
+	for _, tt := range tests {
+		card := Card{IntervalDays: tt.interval, LastReviewed: time.Now()}
+		if got := card.IsMature(); got != tt.mature {
+			t.Errorf("IsMature() with an interval of %d = %v, want %v", tt.interval, got, tt.mature)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package srs
+
+import (
+	"slices"
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/grammar"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/settings"
+)
+
+// DueCard is a card of the due list with its grammar expanded, the way the
+// collection API expands relations
+type DueCard struct {
+	Card
+	New    bool      `json:"new"`
+	Expand DueExpand `json:"expand"`
+}
+
+type DueExpand struct {
+	Grammar grammar.Grammar `json:"grammar"`
+}
+
+// DueList is what's left to study today within the user's daily limits
+type DueList struct {
+	Items []DueCard `json:"items"`
+
+	// NewLeft and ReviewsLeft are how many new cards and reviews the limits
+	// still allow today, counting those listed
+	NewLeft     int `json:"new_left"`
+	ReviewsLeft int `json:"reviews_left"`
+}
+
+// Studied counts the reviews of a day, new cards being those reviewed for the
+// first time
+type Studied struct {
+	New     int `json:"new"`
+	Reviews int `json:"reviews"`
+}
+
+// Due lists what the user has left to study at now, their daily limits
+// counted from the start of their day
+func Due(srsService Service, grammarService grammar.Service, settingsService settings.Service, userId string, now time.Time) (DueList, error) {
+	userSettings, err := settingsService.ForUser(userId)
+	if err != nil {
+		return DueList{}, err
+	}
+
+	var list DueList
+	list.NewLeft, list.ReviewsLeft, err = Left(srsService, userSettings, userId, DayStart(userSettings, now))
+	if err != nil {
+		return DueList{}, err
+	}
+
+	cards, err := srsService.Cards(userId)
+	if err != nil {
+		return DueList{}, err
+	}
+	grammarIds := []string{}
+	for _, card := range cards {
+		if card.IsNew() || card.IsDue(now) {
+			grammarIds = append(grammarIds, card.Grammar)
+		}
+	}
+	found, err := grammarService.FindByIds(grammarIds)
+	if err != nil {
+		return DueList{}, err
+	}
+	byId := make(map[string]grammar.Grammar, len(found))
+	ranks := make(map[string]int, len(found))
+	for _, g := range found {
+		byId[g.Id] = g
+		ranks[g.Id] = g.FrequencyRank
+	}
+
+	due := DueCards(cards, ranks, list.NewLeft, list.ReviewsLeft, now)
+	list.Items = make([]DueCard, 0, len(due))
+	for _, card := range due {
+		list.Items = append(list.Items, DueCard{Card: card, New: card.IsNew(), Expand: DueExpand{Grammar: byId[card.Grammar]}})
+	}
+	return list, nil
+}
+
+// DayStart is the start of the user's day at now, in their timezone
+func DayStart(userSettings settings.Settings, now time.Time) time.Time {
+	loc, err := time.LoadLocation(userSettings.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	local := now.In(loc)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+}
+
+// Left works out how many new cards and reviews the user's daily limits
+// still allow in the day starting at dayStart
+func Left(srsService Service, userSettings settings.Settings, userId string, dayStart time.Time) (newLeft int, reviewsLeft int, err error) {
+	studied, err := srsService.Studied(userId, dayStart)
+	if err != nil {
+		return 0, 0, err
+	}
+	return max(userSettings.DailyNewLimit-studied.New, 0), max(userSettings.DailyReviewLimit-studied.Reviews, 0), nil
+}
+
+// SelectDue picks the reviews due by dueBy, most overdue first, and the new
+// cards by how frequent their grammar is (ranks keyed by grammar) then
+// oldest first, up to how many of each are left for the day. It's what both
+// the due list and the daily queue are made of.
+func SelectDue(cards []Card, ranks map[string]int, newLeft int, reviewsLeft int, dueBy time.Time) (reviews []Card, learn []Card) {
+	reviews, learn = []Card{}, []Card{}
+	for _, card := range cards {
+		switch {
+		case card.IsNew():
+			learn = append(learn, card)
+		case card.IsDue(dueBy):
+			reviews = append(reviews, card)
+		}
+	}
+	slices.SortFunc(reviews, func(a, b Card) int {
+		return a.DueDate().Compare(b.DueDate())
+	})
+	slices.SortFunc(learn, func(a, b Card) int {
+		if c := grammar.CompareRanks(ranks[a.Grammar], ranks[b.Grammar]); c != 0 {
+			return c
+		}
+		return a.Created.Compare(b.Created)
+	})
+
+	return reviews[:min(len(reviews), max(reviewsLeft, 0))], learn[:min(len(learn), max(newLeft, 0))]
+}
+
+// DueCards picks the cards due at now and the new cards as SelectDue does,
+// mixed together
+func DueCards(cards []Card, ranks map[string]int, newLeft int, reviewsLeft int, now time.Time) []Card {
+	return interleave(SelectDue(cards, ranks, newLeft, reviewsLeft, now))
+}
+
+// interleave spreads new cards evenly through the reviews, so a session
+// doesn't end on a run of unfamiliar grammar
+func interleave(reviews []Card, learn []Card) []Card {
+	if len(learn) == 0 {
+		return reviews
+	}
+
+	mixed := make([]Card, 0, len(reviews)+len(learn))
+	every := len(reviews)/len(learn) + 1
+	r := 0
+	for _, card := range learn {
+		for i := 1; i < every && r < len(reviews); i++ {
+			mixed = append(mixed, reviews[r])
+			r++
+		}
+		mixed = append(mixed, card)
+	}
+	return append(mixed, reviews[r:]...)
+}
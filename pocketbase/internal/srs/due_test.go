@@ -0,0 +1,90 @@
+package srs
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func cardIds(cards []Card) []string {
+	ids := make([]string, 0, len(cards))
+	for _, card := range cards {
+		ids = append(ids, card.Id)
+	}
+	return ids
+}
+
+func TestDueCards(t *testing.T) {
+	now := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+
+	// overdue reviews a day apart, and a review not due yet
+	review := func(id string, overdueDays int) Card {
+		return Card{Id: id, Grammar: id, IntervalDays: 1, EaseFactor: 2.5, LastReviewed: now.AddDate(0, 0, -1-overdueDays)}
+	}
+	learn := func(id string, createdDaysAgo int) Card {
+		return Card{Id: id, Grammar: id, EaseFactor: 2.5, Created: now.AddDate(0, 0, -createdDaysAgo)}
+	}
+	cards := []Card{
+		review("r1", 5), review("r2", 1), review("r3", 3),
+		{Id: "later", Grammar: "later", IntervalDays: 6, LastReviewed: now.AddDate(0, 0, -1)},
+		learn("n1", 9), learn("n2", 2), learn("n3", 1), learn("n4", 3),
+	}
+	// n1 is unranked, so comes last however old it is
+	ranks := map[string]int{"n2": 10, "n3": 2, "n4": 10}
+
+	tests := []struct {
+		name        string
+		cards       []Card
+		newLeft     int
+		reviewsLeft int
+		want        []string
+	}{
+		{"new cards spread through the reviews", cards, 2, 3, []string{"r1", "n3", "r3", "n4", "r2"}},
+		{"reviews most overdue first", cards, 0, 10, []string{"r1", "r3", "r2"}},
+		{"new cards by rank then age", cards, 10, 0, []string{"n3", "n4", "n2", "n1"}},
+		{"reviews cut off at the limit", cards, 0, 2, []string{"r1", "r3"}},
+		{"new cards cut off at the limit", cards, 1, 0, []string{"n3"}},
+		{"nothing left for the day", cards, 0, 0, []string{}},
+		{"limits overspent", cards, -2, -1, []string{}},
+		{"no cards", nil, 10, 10, []string{}},
+		{"nothing due", []Card{cards[3]}, 10, 10, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cardIds(DueCards(tt.cards, ranks, tt.newLeft, tt.reviewsLeft, now)); !slices.Equal(got, tt.want) {
+				t.Errorf("DueCards() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInterleave(t *testing.T) {
+	cards := func(ids ...string) []Card {
+		result := make([]Card, 0, len(ids))
+		for _, id := range ids {
+			result = append(result, Card{Id: id})
+		}
+		return result
+	}
+
+	tests := []struct {
+		name    string
+		reviews []Card
+		learn   []Card
+		want    []string
+	}{
+		{"evenly", cards("r1", "r2", "r3", "r4"), cards("n1", "n2"), []string{"r1", "r2", "n1", "r3", "r4", "n2"}},
+		{"leftover reviews go last", cards("r1", "r2", "r3", "r4", "r5"), cards("n1", "n2"), []string{"r1", "r2", "n1", "r3", "r4", "n2", "r5"}},
+		{"more new cards than reviews", cards("r1"), cards("n1", "n2", "n3"), []string{"n1", "n2", "n3", "r1"}},
+		{"only reviews", cards("r1", "r2"), nil, []string{"r1", "r2"}},
+		{"only new cards", nil, cards("n1", "n2"), []string{"n1", "n2"}},
+		{"neither", []Card{}, nil, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cardIds(interleave(tt.reviews, tt.learn)); !slices.Equal(got, tt.want) {
+				t.Errorf("interleave() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package srs
+
+import (
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/clients"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// reviewState is what only the srs routes grade once a card exists, and
+// createdReviewed what a new card can't start with
+var (
+	reviewState     = []string{"ease_factor", "interval_days", "repetition", "last_reviewed", "due_date"}
+	createdReviewed = []string{"last_reviewed"}
+)
+
+func BindHooks(app core.App, clientsService clients.Service) {
+	// Keep the stored stage and due date in step with the review state, which
+	// only the srs routes grade once a card exists
+	derive := func(e *core.RecordEvent) error {
+		card := FromRecord(e.Record)
+		if card.Created.IsZero() {
+			// created is only set once the record is saved
+			card.Created = time.Now()
+		}
+		e.Record.Set("stage", card.DerivedStage())
+		e.Record.Set("due_date", card.DueDate())
+		return e.Next()
+	}
+	app.OnRecordCreate("srs").BindFunc(derive)
+	app.OnRecordUpdate("srs").BindFunc(derive)
+
+	// Cards are for grammar unless they say otherwise, which is all clients
+	// made before vocabulary know to send, and fill the relation of their
+	// item type only
+	app.OnRecordValidate("srs").BindFunc(func(e *core.RecordEvent) error {
+		if e.Record.GetString("item_type") == "" {
+			e.Record.Set("item_type", ItemGrammar)
+		}
+
+		card := FromRecord(e.Record)
+		switch {
+		case card.ItemType == ItemGrammar && card.Grammar == "":
+			return validation.Errors{"grammar": validation.NewError("validation_required", "Cannot be blank.")}
+		case card.ItemType == ItemVocabulary && card.Vocabulary == "":
+			return validation.Errors{"vocabulary": validation.NewError("validation_required", "Cannot be blank.")}
+		case card.ItemType == ItemGrammar && card.Vocabulary != "":
+			return validation.Errors{"vocabulary": validation.NewError("validation_item_type_mismatch", "Must be empty for grammar cards.")}
+		case card.ItemType == ItemVocabulary && card.Grammar != "":
+			return validation.Errors{"grammar": validation.NewError("validation_item_type_mismatch", "Must be empty for vocabulary cards.")}
+		}
+		return e.Next()
+	})
+	app.OnRecordCreateRequest("srs").BindFunc(func(e *core.RecordRequestEvent) error {
+		if err := checkReviewState(e.RequestEvent, clientsService, createdReviewed); err != nil {
+			return e.BadRequestError("Failed to create record.", err)
+		}
+		return e.Next()
+	})
+	app.OnRecordUpdateRequest("srs").BindFunc(func(e *core.RecordRequestEvent) error {
+		if err := checkReviewState(e.RequestEvent, clientsService, reviewState); err != nil {
+			return e.BadRequestError("Failed to update record.", err)
+		}
+		return e.Next()
+	})
+}
+
+// checkReviewState keeps clients from saving the given review state fields
+// themselves. iOS builds from before the review routes graded cards on the
+// device and still may, until the iOS minimum version retires them; they're
+// the ones that don't send their version.
+func checkReviewState(e *core.RequestEvent, clientsService clients.Service, fields []string) error {
+	if e.HasSuperuserAuth() {
+		return nil
+	}
+	if _, ok := clients.FromRequest(e.Request); !ok {
+		if req, ok := clientsService.Requirement(clients.PlatformIOS); !ok || req.MinVersion == "" {
+			return nil
+		}
+	}
+
+	info, err := e.RequestInfo()
+	if err != nil {
+		return err
+	}
+	errs := validation.Errors{}
+	for _, field := range fields {
+		if _, ok := info.Body[field]; ok {
+			errs[field] = validation.NewError("validation_graded_by_server", "Graded by the review routes.")
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package srs
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/clients"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// requirements is a clients.Service with fixed requirements
+type requirements map[string]clients.Requirement
+
+func (r requirements) Handshake(client *clients.Client) clients.Handshake {
+	return clients.Handshake{}
+}
+
+func (r requirements) Requirement(platform string) (clients.Requirement, bool) {
+	req, ok := r[platform]
+	return req, ok
+}
+
+func (r requirements) Load(app core.App) error {
+	return nil
+}
+
+func TestCheckReviewState(t *testing.T) {
+	app := newTestApp(t)
+	retired := requirements{clients.PlatformIOS: {Platform: clients.PlatformIOS, MinVersion: "2.0"}}
+
+	tests := []struct {
+		name     string
+		client   string
+		body     string
+		required requirements
+		wantErr  bool
+	}{
+		{"old build", "", `{"ease_factor":2.6,"interval_days":6}`, requirements{}, false},
+		{"old build once retired", "", `{"ease_factor":2.6,"interval_days":6}`, retired, true},
+		{"build that sends its version", "ios/2.0", `{"ease_factor":2.6,"interval_days":6}`, requirements{}, true},
+		{"other fields", "ios/2.0", `{"confidence":4}`, retired, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &core.RequestEvent{App: app}
+			e.Request = httptest.NewRequest("PATCH", "/api/collections/srs/records/card", strings.NewReader(tt.body))
+			e.Request.Header.Set("Content-Type", "application/json")
+			e.Request.Header.Set(clients.Header, tt.client)
+			e.Response = httptest.NewRecorder()
+
+			if err := checkReviewState(e, tt.required, reviewState); (err != nil) != tt.wantErr {
+				t.Errorf("checkReviewState() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
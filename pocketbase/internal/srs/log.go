@@ -0,0 +1,57 @@
+package srs
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// logEntry is one review as the review log keeps it, intervals in days
+type logEntry struct {
+	card             string
+	grade            int
+	reviewedAt       types.DateTime
+	previousInterval int
+	newInterval      int
+	latencyMs        int
+}
+
+// newLogEntry logs a review of a card from its state before and after,
+// a card never reviewed before having no previous interval
+func newLogEntry(before Card, after Card, grade int, latencyMs int) logEntry {
+	entry := logEntry{
+		card:        before.Id,
+		grade:       grade,
+		newInterval: after.IntervalDays,
+		latencyMs:   latencyMs,
+	}
+	entry.reviewedAt, _ = types.ParseDateTime(after.LastReviewed)
+	if !before.IsNew() {
+		entry.previousInterval = before.IntervalDays
+	}
+	return entry
+}
+
+// writeLog saves reviews of the user's cards to the review log. Grade and
+// Review are the ways a card gets reviewed, the srs hooks keeping clients
+// from saving review state themselves but for old iOS builds, so reviews
+// pass through here.
+func writeLog(app core.App, userId string, entries []logEntry) error {
+	collection, err := app.FindCollectionByNameOrId("review_log")
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		rec := core.NewRecord(collection)
+		rec.Set("user", userId)
+		rec.Set("srs", entry.card)
+		rec.Set("grade", entry.grade)
+		rec.Set("reviewed_at", entry.reviewedAt)
+		rec.Set("previous_interval", entry.previousInterval)
+		rec.Set("new_interval", entry.newInterval)
+		rec.Set("latency_ms", entry.latencyMs)
+		if err := app.Save(rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
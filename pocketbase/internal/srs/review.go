@@ -0,0 +1,149 @@
+package srs
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/sessions"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+const (
+	// MaxBatch bounds the reviews saved at once
+	MaxBatch = 500
+
+	// MaxGrade is SM-2's best answer quality, PassingGrade the least it
+	// counts as recalled
+	MaxGrade     = 5
+	PassingGrade = 3
+
+	// minEaseFactor is the floor SM-2 keeps ease factors at
+	minEaseFactor = 1.3
+
+	// clockSkew is how far in the future a client's clock may put a review
+	clockSkew = 5 * time.Minute
+)
+
+// Review is one answer of a batch. The card's next state is worked out from
+// its grade here, whatever state a client sends along with it.
+type Review struct {
+	Card string `json:"card"`
+
+	// Grade is SM-2's answer quality, 0 to 5
+	Grade int `json:"grade"`
+
+	// ReviewedAt defaults to now, clients set it for reviews made offline
+	ReviewedAt types.DateTime `json:"reviewed_at"`
+
+	// LatencyMs is how long the user took to answer, optional
+	LatencyMs int `json:"latency_ms"`
+}
+
+// ReviewBatch is the reviews of a session, graded in the order they were
+// answered by their ReviewedAt
+type ReviewBatch struct {
+	// Session is the review session the batch ends, optional
+	Session string   `json:"session"`
+	Reviews []Review `json:"reviews"`
+}
+
+func (b ReviewBatch) Validate(now time.Time) error {
+	switch {
+	case len(b.Reviews) == 0:
+		return validation.Errors{"reviews": validation.NewError("validation_required", "Cannot be blank.")}
+	case len(b.Reviews) > MaxBatch:
+		return validation.Errors{"reviews": validation.NewError("validation_too_many", "Must have at most {{.max}} items.").SetParams(map[string]any{"max": MaxBatch})}
+	}
+
+	for i, r := range b.Reviews {
+		errs := validation.Errors{}
+		if r.Card == "" {
+			errs["card"] = validation.NewError("validation_required", "Cannot be blank.")
+		}
+		if r.Grade < 0 || r.Grade > MaxGrade {
+			errs["grade"] = validation.NewError("validation_out_of_range", "Must be between {{.min}} and {{.max}}.").SetParams(map[string]any{"min": 0, "max": MaxGrade})
+		}
+		if r.LatencyMs < 0 {
+			errs["latency_ms"] = validation.NewError("validation_invalid_value", "Invalid value.")
+		}
+		if r.ReviewedAt.Time().After(now.Add(clockSkew)) {
+			errs["reviewed_at"] = validation.NewError("validation_date_in_future", "Can't be in the future.")
+		}
+		if len(errs) > 0 {
+			return validation.Errors{"reviews": validation.Errors{strconv.Itoa(i): errs}}
+		}
+	}
+	return nil
+}
+
+// Summary recaps a batch of reviews, for the screen at the end of a session
+type Summary struct {
+	Id       string  `json:"id"`
+	Session  string  `json:"session"`
+	Reviewed int     `json:"reviewed"`
+	Correct  int     `json:"correct"`
+	Accuracy float64 `json:"accuracy"`
+
+	// BestStreak is the longest run of correct answers in a row
+	BestStreak int `json:"best_streak"`
+
+	// Duration in seconds, the session's when there's one, otherwise from
+	// the first review to the last
+	Duration int `json:"duration"`
+
+	Started types.DateTime `json:"started"`
+	Ended   types.DateTime `json:"ended"`
+	Created types.DateTime `json:"created"`
+}
+
+func SummaryFromRecord(rec *core.Record) Summary {
+	summary := Summary{
+		Id:         rec.Id,
+		Session:    rec.GetString("session"),
+		Reviewed:   rec.GetInt("reviewed"),
+		Correct:    rec.GetInt("correct"),
+		BestStreak: rec.GetInt("best_streak"),
+		Duration:   rec.GetInt("duration"),
+		Started:    rec.GetDateTime("started"),
+		Ended:      rec.GetDateTime("ended"),
+		Created:    rec.GetDateTime("created"),
+	}
+	if summary.Reviewed > 0 {
+		summary.Accuracy = float64(summary.Correct) / float64(summary.Reviewed)
+	}
+	return summary
+}
+
+// ReviewResult is the cards a batch saved and its summary
+type ReviewResult struct {
+	Cards   []Card  `json:"cards"`
+	Summary Summary `json:"summary"`
+}
+
+// summarize counts up reviews in the order they were answered, their times
+// already set
+func summarize(reviews []Review) Summary {
+	summary := Summary{Reviewed: len(reviews)}
+	streak := 0
+	for _, r := range reviews {
+		if r.Grade >= PassingGrade {
+			summary.Correct++
+			streak++
+			summary.BestStreak = max(summary.BestStreak, streak)
+		} else {
+			streak = 0
+		}
+
+		if summary.Started.IsZero() || r.ReviewedAt.Time().Before(summary.Started.Time()) {
+			summary.Started = r.ReviewedAt
+		}
+		if r.ReviewedAt.Time().After(summary.Ended.Time()) {
+			summary.Ended = r.ReviewedAt
+		}
+	}
+	summary.Duration = int(min(summary.Ended.Time().Sub(summary.Started.Time()), sessions.MaxDuration).Seconds())
+	return summary
+}
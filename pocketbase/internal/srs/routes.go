@@ -0,0 +1,301 @@
+package srs
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/grammar"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/sessions"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/settings"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+type assessmentRequest struct {
+	Answers []AssessmentAnswer `json:"answers"`
+}
+
+type assessmentNextResponse struct {
+	Items []grammar.Grammar `json:"items"`
+	Done  bool              `json:"done"`
+}
+
+type assessmentCompleteResponse struct {
+	Created int `json:"created"`
+}
+
+// resurrectRequest picks the cards to resurrect by id or by grammar tag
+type resurrectRequest struct {
+	Ids []string `json:"ids"`
+	Tag string   `json:"tag"`
+}
+
+func (r resurrectRequest) Validate() error {
+	if (len(r.Ids) == 0) == (r.Tag == "") {
+		return validation.Errors{
+			"ids": validation.NewError("validation_ids_or_tag", "Give either ids or a tag."),
+		}
+	}
+	return nil
+}
+
+type resurrectResponse struct {
+	Cards []Card `json:"cards"`
+}
+
+// gradeRequest answers a card with an SM-2 grade, from 0 (blackout) to 5
+// (perfect recall)
+type gradeRequest struct {
+	Grade *int `json:"grade"`
+
+	// LatencyMs is how long the user took to answer, optional
+	LatencyMs int `json:"latency_ms"`
+}
+
+func (r gradeRequest) Validate() error {
+	switch {
+	case r.Grade == nil:
+		return validation.Errors{"grade": validation.NewError("validation_required", "Cannot be blank.")}
+	case *r.Grade < 0 || *r.Grade > MaxGrade:
+		return validation.Errors{"grade": validation.NewError("validation_out_of_range", "Must be between {{.min}} and {{.max}}.").SetParams(map[string]any{"min": 0, "max": MaxGrade})}
+	case r.LatencyMs < 0:
+		return validation.Errors{"latency_ms": validation.NewError("validation_invalid_value", "Invalid value.")}
+	}
+	return nil
+}
+
+// gradeResponse is the graded card with when it's next due
+type gradeResponse struct {
+	Card
+	DueDate time.Time `json:"due_date"`
+}
+
+// summariesShown bounds the summaries listed at once
+const summariesShown = 50
+
+// statsMaxAge is how long clients may reuse stats, which also change as
+// cards fall due
+const statsMaxAge = time.Minute
+
+func RegisterRoutes(g *api.Group, srsService Service, grammarService grammar.Service, sessionsService sessions.Service, settingsService settings.Service, conditional *api.Conditional) {
+	// Return the next batch of library grammar to quiz the user on (the client
+	// keeps the running list of answers)
+	g.POST("/assessment/next", "Next batch of placement assessment grammar", assessmentRequest{}, assessmentNextResponse{}, func(e *core.RequestEvent) error {
+		var body assessmentRequest
+		if err := e.BindBody(&body); err != nil {
+			return e.BadRequestError("Invalid assessment payload.", err)
+		}
+
+		library, err := grammarService.Library()
+		if err != nil {
+			return e.InternalServerError("Failed to load grammar library.", err)
+		}
+
+		items := NextAssessmentBatch(library, body.Answers)
+		return e.JSON(200, assessmentNextResponse{Items: items, Done: len(items) == 0})
+	})
+
+	g.POST("/assessment/complete", "Seed srs records for grammar the user already knows", assessmentRequest{}, assessmentCompleteResponse{}, func(e *core.RequestEvent) error {
+		var body assessmentRequest
+		if err := e.BindBody(&body); err != nil {
+			return e.BadRequestError("Invalid assessment payload.", err)
+		}
+
+		created, err := srsService.SeedKnown(e.Auth.Id, KnownGrammar(body.Answers))
+		if err != nil {
+			return e.InternalServerError("Failed to seed known grammar.", err)
+		}
+
+		return e.JSON(200, assessmentCompleteResponse{Created: created})
+	})
+
+	g.POST("/srs/resurrect", "Put mastered or burned cards back in the review queue, by id or by grammar tag", resurrectRequest{}, resurrectResponse{}, func(e *core.RequestEvent) error {
+		var body resurrectRequest
+		if err := e.BindBody(&body); err != nil {
+			return e.BadRequestError("Invalid request body.", err)
+		}
+		if err := body.Validate(); err != nil {
+			return e.BadRequestError("Invalid resurrect request.", err)
+		}
+
+		ids := body.Ids
+		if body.Tag != "" {
+			cards, err := srsService.Cards(e.Auth.Id)
+			if err != nil {
+				return e.InternalServerError("Failed to load srs records.", err)
+			}
+
+			grammarIds := make([]string, 0, len(cards))
+			for _, card := range cards {
+				grammarIds = append(grammarIds, card.Grammar)
+			}
+			tags, err := grammarService.TagsByGrammar(grammarIds)
+			if err != nil {
+				return e.InternalServerError("Failed to load grammar tags.", err)
+			}
+
+			for _, card := range cards {
+				if IsResurrectable(card) && slices.Contains(tags[card.Grammar], body.Tag) {
+					ids = append(ids, card.Id)
+				}
+			}
+		}
+
+		cards, err := srsService.Resurrect(e.Auth.Id, ids)
+		if err != nil {
+			return e.InternalServerError("Failed to resurrect the cards.", err)
+		}
+		return e.JSON(200, resurrectResponse{Cards: cards})
+	})
+
+	g.GET("/srs/due", "The user's due reviews and new cards within their daily limits, new cards spread among the reviews, with their grammar expanded", DueList{}, func(e *core.RequestEvent) error {
+		list, err := Due(srsService, grammarService, settingsService, e.Auth.Id, time.Now())
+		if err != nil {
+			return e.InternalServerError("Failed to load srs records.", err)
+		}
+		return e.JSON(200, list)
+	})
+
+	g.POST("/srs/{id}/review", "Grade a review of a card and save the review state SM-2 works out from it", gradeRequest{}, gradeResponse{}, func(e *core.RequestEvent) error {
+		var body gradeRequest
+		if err := e.BindBody(&body); err != nil {
+			return e.BadRequestError("Invalid request body.", err)
+		}
+		if err := body.Validate(); err != nil {
+			return e.BadRequestError("Invalid review.", err)
+		}
+
+		card, err := srsService.Grade(e.Auth.Id, e.Request.PathValue("id"), *body.Grade, body.LatencyMs)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return e.NotFoundError("", err)
+		case err != nil:
+			return e.InternalServerError("Failed to save the review.", err)
+		}
+		return e.JSON(200, gradeResponse{Card: card, DueDate: card.DueDate()})
+	})
+
+	g.POST("/srs/reviews", "Grade a batch of reviews, ending its session if given, and return the cards with a recap of the batch", ReviewBatch{}, ReviewResult{}, func(e *core.RequestEvent) error {
+		var batch ReviewBatch
+		if err := e.BindBody(&batch); err != nil {
+			return e.BadRequestError("Invalid request body.", err)
+		}
+		if err := batch.Validate(time.Now()); err != nil {
+			return e.BadRequestError("Invalid reviews.", err)
+		}
+
+		var session *sessions.Session
+		if batch.Session != "" {
+			found, err := sessionsService.Find(e.Auth.Id, batch.Session)
+			if err != nil {
+				return e.BadRequestError("Invalid reviews.", validation.Errors{
+					"session": validation.NewError("validation_invalid_value", "Invalid value."),
+				})
+			}
+			if found.IsRunning() {
+				if found, err = sessionsService.Stop(e.Auth.Id, found.Id); err != nil {
+					return e.InternalServerError("Failed to stop the session.", err)
+				}
+			}
+			session = &found
+		}
+
+		result, err := srsService.Review(e.Auth.Id, batch.Reviews, session)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return e.NotFoundError("", err)
+		case err != nil:
+			return e.InternalServerError("Failed to save the reviews.", err)
+		}
+		return e.JSON(200, result)
+	})
+
+	g.GET("/srs/summaries", "The user's latest review session recaps, newest first", []Summary{}, func(e *core.RequestEvent) error {
+		summaries, err := srsService.Summaries(e.Auth.Id, summariesShown)
+		if err != nil {
+			return e.InternalServerError("Failed to load review summaries.", err)
+		}
+		return e.JSON(200, summaries)
+	})
+
+	g.GET("/stats", "Retention, workload, and maturity overall and per deck and tag, and time spent studying, of the user or of the ?user= who shows their stats to them", StatsReport{}, func(e *core.RequestEvent) error {
+		userId := e.Auth.Id
+		if other := e.Request.URL.Query().Get("user"); other != "" && other != userId {
+			if _, err := e.App.FindRecordById("users", other); err != nil {
+				return e.NotFoundError("", err)
+			}
+			otherSettings, err := settingsService.ForUser(other)
+			if err != nil {
+				return e.InternalServerError("Failed to load settings.", err)
+			}
+			visible, err := settingsService.Visible(userId, other, otherSettings.StatsVisibility)
+			if err != nil {
+				return e.InternalServerError("Failed to load settings.", err)
+			}
+			if !visible {
+				return e.NotFoundError("", nil)
+			}
+			userId = other
+		}
+
+		if conditional.NotModified(e, statsMaxAge, api.UserScope(userId), api.SharedScope) {
+			return e.NoContent(http.StatusNotModified)
+		}
+
+		report, err := Report(srsService, grammarService, sessionsService, userId, e.Auth.Id, time.Now())
+		if err != nil {
+			return e.InternalServerError("Failed to load srs records.", err)
+		}
+		return conditional.JSON(e, statsMaxAge, report)
+	})
+
+	g.GET("/stats/calibration", "How the confidence the user rated their grammar with compares to its retention, and the grammar they were overconfident about", CalibrationReport{}, func(e *core.RequestEvent) error {
+		if conditional.NotModified(e, statsMaxAge, api.UserScope(e.Auth.Id), api.SharedScope) {
+			return e.NoContent(http.StatusNotModified)
+		}
+
+		cards, err := srsService.Cards(e.Auth.Id)
+		if err != nil {
+			return e.InternalServerError("Failed to load srs records.", err)
+		}
+		grades, err := srsService.Grades(e.Auth.Id)
+		if err != nil {
+			return e.InternalServerError("Failed to load srs records.", err)
+		}
+		report, overconfident := Calibrate(cards, grades)
+
+		grammarIds := make([]string, 0, len(overconfident))
+		for _, card := range overconfident {
+			grammarIds = append(grammarIds, card.Grammar)
+		}
+		found, err := grammarService.FindByIds(grammarIds)
+		if err != nil {
+			return e.InternalServerError("Failed to load grammar.", err)
+		}
+		byId := make(map[string]grammar.Grammar, len(found))
+		for _, g := range found {
+			byId[g.Id] = g
+		}
+
+		report.Overconfident = make([]Overconfident, 0, len(overconfident))
+		for _, card := range overconfident {
+			if g, ok := byId[card.Grammar]; ok {
+				reviews := grades[card.Id]
+				report.Overconfident = append(report.Overconfident, Overconfident{
+					Grammar:    g,
+					Confidence: card.Confidence,
+					EaseFactor: card.EaseFactor,
+					Reviews:    len(reviews),
+					Retention:  retention(reviews),
+					Remembered: reviews[len(reviews)-1] >= PassingGrade,
+				})
+			}
+		}
+		return conditional.JSON(e, statsMaxAge, report)
+	})
+}
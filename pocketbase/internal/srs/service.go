@@ -0,0 +1,343 @@
+package srs
+
+import (
+	"database/sql"
+	"slices"
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/sessions"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Grammar the user already knows is seeded as if it had been reviewed a few
+// times already so it doesn't flood the queue on day one
+const (
+	knownEaseFactor   = 2.5
+	knownIntervalDays = 60
+	knownRepetition   = 3
+)
+
+// Resurrected cards start over from SM-2's first interval
+const (
+	resurrectedIntervalDays = 1
+	resurrectedRepetition   = 1
+)
+
+type Service interface {
+	// Cards returns every grammar card of a user. Vocabulary cards are graded
+	// and reviewed like the others but have no due list or stats yet.
+	Cards(userId string) ([]Card, error)
+
+	// CountDue counts how many of a user's grammar cards are due at the given
+	// moment
+	CountDue(userId string, at time.Time) (int, error)
+
+	// SeedKnown creates already-learned cards for the given library grammar,
+	// skipping grammar the user has a card for, and returns how many it made
+	SeedKnown(userId string, grammarIds []string) (int, error)
+
+	// Resurrect puts the given mastered or burned cards of a user back in the
+	// review queue, due now, skipping the others. It returns the cards it
+	// brought back.
+	Resurrect(userId string, ids []string) ([]Card, error)
+
+	// Grade answers one of the user's cards with an SM-2 grade now and saves
+	// its new review state, logging the review with how long the user took
+	// to answer in milliseconds, 0 when not timed
+	Grade(userId string, id string, grade int, latencyMs int) (Card, error)
+
+	// Review grades the cards of a batch of reviews in the order they were
+	// answered, logs them, and saves a summary of it, timed by the session it
+	// ended when there's one. Reviews from before a card's last review, e.g.
+	// made offline on another device, are skipped. Cards that aren't the
+	// user's fail the whole batch.
+	Review(userId string, reviews []Review, session *sessions.Session) (ReviewResult, error)
+
+	// Studied counts the user's logged reviews of grammar cards since a
+	// moment, vocabulary having no due list for daily limits to apply to yet
+	Studied(userId string, since time.Time) (Studied, error)
+
+	// Summaries returns the user's latest review summaries, newest first
+	Summaries(userId string, limit int) ([]Summary, error)
+
+	// Grades returns the grades of every logged review of the user's cards,
+	// keyed by card, oldest first
+	Grades(userId string) (map[string][]int, error)
+}
+
+type service struct {
+	app core.App
+}
+
+func NewService(app core.App) Service {
+	return &service{app: app}
+}
+
+func (s *service) Cards(userId string) ([]Card, error) {
+	records, err := s.app.FindRecordsByFilter("srs", "user = {:user} && item_type = {:type}", "", 0, 0, map[string]any{"user": userId, "type": ItemGrammar})
+	if err != nil {
+		return nil, err
+	}
+
+	cards := make([]Card, 0, len(records))
+	for _, rec := range records {
+		cards = append(cards, FromRecord(rec))
+	}
+	return cards, nil
+}
+
+func (s *service) CountDue(userId string, at time.Time) (int, error) {
+	dueBy, err := types.ParseDateTime(at)
+	if err != nil {
+		return 0, err
+	}
+
+	// due_date is kept in step with Card.DueDate, and indexed
+	var due int
+	err = s.app.RecordQuery("srs").
+		Select("COUNT(*)").
+		AndWhere(dbx.HashExp{"user": userId, "item_type": ItemGrammar}).
+		AndWhere(dbx.NewExp("due_date <= {:at}", dbx.Params{"at": dueBy.String()})).
+		Row(&due)
+	if err != nil {
+		return 0, err
+	}
+	return due, nil
+}
+
+func (s *service) SeedKnown(userId string, grammarIds []string) (int, error) {
+	collection, err := s.app.FindCollectionByNameOrId("srs")
+	if err != nil {
+		return 0, err
+	}
+
+	created := 0
+	err = s.app.RunInTransaction(func(txApp core.App) error {
+		for _, grammarId := range grammarIds {
+			grammar, err := txApp.FindRecordById("grammar", grammarId)
+			if err != nil || grammar.GetString("user") != "" {
+				continue // only library grammar can be seeded
+			}
+
+			existing, _ := txApp.FindFirstRecordByFilter(
+				"srs",
+				"user = {:user} && grammar = {:grammar}",
+				map[string]any{"user": userId, "grammar": grammar.Id},
+			)
+			if existing != nil {
+				continue
+			}
+
+			record := core.NewRecord(collection)
+			record.Set("user", userId)
+			record.Set("grammar", grammar.Id)
+			record.Set("ease_factor", knownEaseFactor)
+			record.Set("interval_days", knownIntervalDays)
+			record.Set("repetition", knownRepetition)
+			record.Set("last_reviewed", time.Now())
+			if err := txApp.Save(record); err != nil {
+				return err
+			}
+			created++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return created, nil
+}
+
+func (s *service) Resurrect(userId string, ids []string) ([]Card, error) {
+	resurrected := []Card{}
+	err := s.app.RunInTransaction(func(txApp core.App) error {
+		for _, id := range ids {
+			record, err := txApp.FindFirstRecordByFilter("srs", "id = {:id} && user = {:user}", map[string]any{"id": id, "user": userId})
+			if err != nil {
+				continue
+			}
+			if !IsResurrectable(FromRecord(record)) {
+				continue
+			}
+
+			// as if it had been learned yesterday, so it comes up right away
+			// and climbs back from the shortest intervals
+			record.Set("interval_days", resurrectedIntervalDays)
+			record.Set("repetition", resurrectedRepetition)
+			record.Set("last_reviewed", time.Now().AddDate(0, 0, -resurrectedIntervalDays))
+			if err := txApp.Save(record); err != nil {
+				return err
+			}
+			resurrected = append(resurrected, FromRecord(record))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resurrected, nil
+}
+
+func (s *service) Grade(userId string, id string, grade int, latencyMs int) (Card, error) {
+	var graded Card
+	err := s.app.RunInTransaction(func(txApp core.App) error {
+		record, err := txApp.FindFirstRecordByFilter("srs", "id = {:id} && user = {:user}", map[string]any{"id": id, "user": userId})
+		if err != nil {
+			return err
+		}
+
+		before := FromRecord(record)
+		card := Grade(before, grade, time.Now())
+		record.Set("ease_factor", card.EaseFactor)
+		record.Set("interval_days", card.IntervalDays)
+		record.Set("repetition", card.Repetition)
+		record.Set("last_reviewed", card.LastReviewed)
+		if err := txApp.Save(record); err != nil {
+			return err
+		}
+		graded = FromRecord(record)
+		return writeLog(txApp, userId, []logEntry{newLogEntry(before, graded, grade, latencyMs)})
+	})
+	if err != nil {
+		return Card{}, err
+	}
+	return graded, nil
+}
+
+func (s *service) Review(userId string, reviews []Review, session *sessions.Session) (ReviewResult, error) {
+	now := types.NowDateTime()
+	for i := range reviews {
+		if reviews[i].ReviewedAt.IsZero() {
+			reviews[i].ReviewedAt = now
+		}
+	}
+	// batches made offline may come in any order
+	slices.SortStableFunc(reviews, func(a, b Review) int {
+		return a.ReviewedAt.Time().Compare(b.ReviewedAt.Time())
+	})
+
+	summary := summarize(reviews)
+	if session != nil {
+		summary.Session = session.Id
+		summary.Duration = session.Duration
+		summary.Started, summary.Ended = session.Started, session.Ended
+	}
+
+	result := ReviewResult{Cards: []Card{}}
+	err := s.app.RunInTransaction(func(txApp core.App) error {
+		// a card reviewed twice in a batch keeps its last state
+		var records []*core.Record
+		byId := map[string]*core.Record{}
+		entries := make([]logEntry, 0, len(reviews))
+		for _, r := range reviews {
+			record, ok := byId[r.Card]
+			if !ok {
+				var err error
+				record, err = txApp.FindFirstRecordByFilter("srs", "id = {:id} && user = {:user}", map[string]any{"id": r.Card, "user": userId})
+				if err != nil {
+					return sql.ErrNoRows
+				}
+				byId[r.Card] = record
+				records = append(records, record)
+			}
+			before := FromRecord(record)
+			if r.ReviewedAt.Time().Before(before.LastReviewed) {
+				continue
+			}
+			card := Grade(before, r.Grade, r.ReviewedAt.Time())
+			record.Set("ease_factor", card.EaseFactor)
+			record.Set("interval_days", card.IntervalDays)
+			record.Set("repetition", card.Repetition)
+			record.Set("last_reviewed", card.LastReviewed)
+			entries = append(entries, newLogEntry(before, FromRecord(record), r.Grade, r.LatencyMs))
+		}
+		for _, record := range records {
+			if err := txApp.Save(record); err != nil {
+				return err
+			}
+			result.Cards = append(result.Cards, FromRecord(record))
+		}
+		if err := writeLog(txApp, userId, entries); err != nil {
+			return err
+		}
+
+		collection, err := txApp.FindCollectionByNameOrId("review_summaries")
+		if err != nil {
+			return err
+		}
+		record := core.NewRecord(collection)
+		record.Set("user", userId)
+		record.Set("session", summary.Session)
+		record.Set("reviewed", summary.Reviewed)
+		record.Set("correct", summary.Correct)
+		record.Set("best_streak", summary.BestStreak)
+		record.Set("duration", summary.Duration)
+		record.Set("started", summary.Started)
+		record.Set("ended", summary.Ended)
+		if err := txApp.Save(record); err != nil {
+			return err
+		}
+		result.Summary = SummaryFromRecord(record)
+		return nil
+	})
+	if err != nil {
+		return ReviewResult{}, err
+	}
+	return result, nil
+}
+
+func (s *service) Summaries(userId string, limit int) ([]Summary, error) {
+	records, err := s.app.FindRecordsByFilter("review_summaries", "user = {:user}", "-started", limit, 0, map[string]any{"user": userId})
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]Summary, 0, len(records))
+	for _, rec := range records {
+		summaries = append(summaries, SummaryFromRecord(rec))
+	}
+	return summaries, nil
+}
+
+func (s *service) Studied(userId string, since time.Time) (Studied, error) {
+	from, err := types.ParseDateTime(since)
+	if err != nil {
+		return Studied{}, err
+	}
+
+	var studied Studied
+	err = s.app.RecordQuery("review_log").
+		Select("COALESCE(SUM(previous_interval = 0), 0) AS new", "COALESCE(SUM(previous_interval > 0), 0) AS reviews").
+		AndWhere(dbx.HashExp{"user": userId}).
+		AndWhere(dbx.NewExp("reviewed_at >= {:from}", dbx.Params{"from": from.String()})).
+		AndWhere(dbx.NewExp("srs IN (SELECT id FROM srs WHERE user = {:user} AND item_type = {:type})", dbx.Params{"user": userId, "type": ItemGrammar})).
+		Row(&studied.New, &studied.Reviews)
+	if err != nil {
+		return Studied{}, err
+	}
+	return studied, nil
+}
+
+func (s *service) Grades(userId string) (map[string][]int, error) {
+	var rows []struct {
+		Card  string `db:"srs"`
+		Grade int    `db:"grade"`
+	}
+	err := s.app.RecordQuery("review_log").
+		Select("srs", "grade").
+		AndWhere(dbx.HashExp{"user": userId}).
+		OrderBy("reviewed_at ASC").
+		All(&rows)
+	if err != nil {
+		return nil, err
+	}
+
+	grades := map[string][]int{}
+	for _, row := range rows {
+		grades[row.Card] = append(grades[row.Card], row.Grade)
+	}
+	return grades, nil
+}
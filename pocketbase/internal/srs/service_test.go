@@ -0,0 +1,116 @@
+package srs
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/bunkbed-tech/fushigi/pocketbase/migrations"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// newTestApp is a migrated app
+func newTestApp(t *testing.T) *tests.TestApp {
+	t.Helper()
+	// the initial migration creates a superuser from these
+	t.Setenv("ADMIN_EMAIL", "admin@example.com")
+	t.Setenv("ADMIN_PASSWORD", "correct horse battery")
+	app, err := tests.NewTestApp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(app.Cleanup)
+	if err := app.RunAllMigrations(); err != nil {
+		t.Fatal(err)
+	}
+	return app
+}
+
+func saveUser(t *testing.T, app core.App, email string) *core.Record {
+	t.Helper()
+	users, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := core.NewRecord(users)
+	user.SetEmail(email)
+	// usernames are unique when set, so every user gets one
+	user.Set("username", strings.Split(email, "@")[0])
+	user.SetPassword("correct horse battery")
+	if err := app.Save(user); err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+// saveCard puts seeded grammar in the user's reviews, last reviewed then
+func saveCard(t *testing.T, app core.App, userId string, lastReviewed time.Time) *core.Record {
+	t.Helper()
+	grammar, err := app.FindFirstRecordByFilter("grammar", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	collection, err := app.FindCollectionByNameOrId("srs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	card := core.NewRecord(collection)
+	card.Set("user", userId)
+	card.Set("grammar", grammar.Id)
+	card.Set("ease_factor", 2.5)
+	card.Set("interval_days", 1)
+	card.Set("repetition", 1)
+	card.Set("last_reviewed", lastReviewed)
+	if err := app.Save(card); err != nil {
+		t.Fatal(err)
+	}
+	return card
+}
+
+func TestReview(t *testing.T) {
+	app := newTestApp(t)
+	service := NewService(app)
+	user := saveUser(t, app, "user@example.com")
+
+	lastReviewed := time.Now().Add(-time.Hour).UTC().Truncate(time.Millisecond)
+	record := saveCard(t, app, user.Id, lastReviewed)
+	at := func(d time.Duration) types.DateTime {
+		t.Helper()
+		reviewedAt, err := types.ParseDateTime(lastReviewed.Add(d))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return reviewedAt
+	}
+
+	// answered offline and sent newest first, with one answered on another
+	// device before the card's last review
+	result, err := service.Review(user.Id, []Review{
+		{Card: record.Id, Grade: 5, ReviewedAt: at(30 * time.Minute)},
+		{Card: record.Id, Grade: 1, ReviewedAt: at(10 * time.Minute)},
+		{Card: record.Id, Grade: 5, ReviewedAt: at(-30 * time.Minute)},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Grade(Grade(FromRecord(record), 1, at(10*time.Minute).Time()), 5, at(30*time.Minute).Time())
+	if len(result.Cards) != 1 {
+		t.Fatalf("Review() = %d cards, want 1", len(result.Cards))
+	}
+	got := result.Cards[0]
+	if got.EaseFactor != want.EaseFactor || got.IntervalDays != want.IntervalDays || got.Repetition != want.Repetition || !got.LastReviewed.Equal(want.LastReviewed) {
+		t.Errorf("Review() = %+v, want failed then recalled %+v", got, want)
+	}
+
+	logged, err := app.CountRecords("review_log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if logged != 2 {
+		t.Errorf("logged %d reviews, want the 2 after the card's last review", logged)
+	}
+}
@@ -0,0 +1,47 @@
+package srs
+
+import (
+	"math"
+	"time"
+)
+
+// SM-2's first two intervals, in days, after which intervals grow by the
+// card's ease factor
+const (
+	firstIntervalDays  = 1
+	secondIntervalDays = 6
+)
+
+// Grade works out a card's review state after answering it with grade now,
+// as SuperMemo 2 does. A failed card starts over from the first interval, a
+// card never reviewed counts as having no repetitions yet.
+func Grade(card Card, grade int, now time.Time) Card {
+	repetition := card.Repetition
+	if card.IsNew() {
+		repetition = 0
+	}
+
+	switch {
+	case grade < PassingGrade:
+		card.Repetition = 0
+		card.IntervalDays = firstIntervalDays
+	case repetition == 0:
+		card.Repetition = 1
+		card.IntervalDays = firstIntervalDays
+	case repetition == 1:
+		card.Repetition = 2
+		card.IntervalDays = secondIntervalDays
+	default:
+		card.Repetition = repetition + 1
+		card.IntervalDays = int(math.Round(float64(card.IntervalDays) * card.EaseFactor))
+	}
+
+	// every answer moves the ease factor, by more the further it was from
+	// a perfect one
+	miss := float64(MaxGrade - grade)
+	ease := max(card.EaseFactor+0.1-miss*(0.08+miss*0.02), minEaseFactor)
+	card.EaseFactor = math.Round(ease*100) / 100
+
+	card.LastReviewed = now
+	return card
+}
@@ -0,0 +1,86 @@
+package srs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGradeEachGrade(t *testing.T) {
+	now := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	card := Card{Repetition: 2, IntervalDays: 6, EaseFactor: 2.5, LastReviewed: now.AddDate(0, 0, -6)}
+
+	tests := []struct {
+		grade      int
+		repetition int
+		interval   int
+		ease       float64
+	}{
+		{5, 3, 15, 2.6},
+		{4, 3, 15, 2.5},
+		{3, 3, 15, 2.36},
+		{2, 0, 1, 2.18},
+		{1, 0, 1, 1.96},
+		{0, 0, 1, 1.7},
+	}
+	for _, tt := range tests {
+		got := Grade(card, tt.grade, now)
+		if got.Repetition != tt.repetition || got.IntervalDays != tt.interval || got.EaseFactor != tt.ease {
+			t.Errorf("Grade() with grade %d = repetition %d, interval %d, ease %v, want %d, %d, %v", tt.grade, got.Repetition, got.IntervalDays, got.EaseFactor, tt.repetition, tt.interval, tt.ease)
+		}
+		if !got.LastReviewed.Equal(now) {
+			t.Errorf("Grade() with grade %d reviewed at %v, want %v", tt.grade, got.LastReviewed, now)
+		}
+	}
+}
+
+func TestGradeEaseFloor(t *testing.T) {
+	now := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		ease  float64
+		grade int
+		want  float64
+	}{
+		{"falls to the floor", 1.4, 0, minEaseFactor},
+		{"stays on the floor", minEaseFactor, 3, minEaseFactor},
+		{"rises off the floor", minEaseFactor, 5, 1.4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			card := Card{Repetition: 3, IntervalDays: 10, EaseFactor: tt.ease, LastReviewed: now.AddDate(0, 0, -10)}
+			if got := Grade(card, tt.grade, now).EaseFactor; got != tt.want {
+				t.Errorf("Grade() ease factor = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGradeRepetitions(t *testing.T) {
+	now := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	reviewed := now.AddDate(0, 0, -3)
+
+	tests := []struct {
+		name       string
+		card       Card
+		grade      int
+		repetition int
+		interval   int
+	}{
+		{"new cards start at the first interval", Card{EaseFactor: 2.5}, 4, 1, firstIntervalDays},
+		{"new cards ignore stale repetitions", Card{Repetition: 5, IntervalDays: 40, EaseFactor: 2.5}, 4, 1, firstIntervalDays},
+		{"the second pass goes to the second interval", Card{Repetition: 1, IntervalDays: 1, EaseFactor: 2.5, LastReviewed: reviewed}, 4, 2, secondIntervalDays},
+		{"later passes grow by the ease factor", Card{Repetition: 3, IntervalDays: 15, EaseFactor: 2.6, LastReviewed: reviewed}, 4, 4, 39},
+		{"intervals round to whole days", Card{Repetition: 3, IntervalDays: 7, EaseFactor: 2.3, LastReviewed: reviewed}, 5, 4, 16},
+		{"a failure starts over", Card{Repetition: 5, IntervalDays: 40, EaseFactor: 2.5, LastReviewed: reviewed}, 2, 0, firstIntervalDays},
+		{"a pass after a failure starts at the first interval", Card{Repetition: 0, IntervalDays: 1, EaseFactor: 2.18, LastReviewed: reviewed}, 3, 1, firstIntervalDays},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Grade(tt.card, tt.grade, now)
+			if got.Repetition != tt.repetition || got.IntervalDays != tt.interval {
+				t.Errorf("Grade() = repetition %d, interval %d, want %d, %d", got.Repetition, got.IntervalDays, tt.repetition, tt.interval)
+			}
+		})
+	}
+}
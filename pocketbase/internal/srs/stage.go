@@ -0,0 +1,44 @@
+package srs
+
+// Mastery stages a card moves through as its interval grows, like Bunpro's
+// and WaniKani's. They're stored on the srs record so lists can filter by
+// them.
+const (
+	StageLearning = "learning"
+	StageYoung    = "young"
+	StageMature   = "mature"
+	StageMastered = "mastered"
+	StageBurned   = "burned"
+)
+
+var Stages = []string{StageLearning, StageYoung, StageMature, StageMastered, StageBurned}
+
+// The intervals, in days, at which a card reaches each stage past learning
+const (
+	YoungIntervalDays    = 7
+	MasteredIntervalDays = 90
+	BurnedIntervalDays   = 180
+)
+
+// DerivedStage works out the card's mastery stage from its review state. A
+// card that was just failed is back to learning whatever its interval.
+func (c Card) DerivedStage() string {
+	switch {
+	case c.IsNew() || c.Repetition == 0 || c.IntervalDays < YoungIntervalDays:
+		return StageLearning
+	case c.IntervalDays < MatureIntervalDays:
+		return StageYoung
+	case c.IntervalDays < MasteredIntervalDays:
+		return StageMature
+	case c.IntervalDays < BurnedIntervalDays:
+		return StageMastered
+	}
+	return StageBurned
+}
+
+// IsResurrectable reports whether a card went far enough to be brought back
+// into the review queue when the user feels it slipping
+func IsResurrectable(card Card) bool {
+	stage := card.DerivedStage()
+	return stage == StageMastered || stage == StageBurned
+}
@@ -0,0 +1,150 @@
+package srs
+
+import (
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/grammar"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/sessions"
+)
+
+// How far ahead the workload figure looks
+const workloadWindow = 7 * 24 * time.Hour
+
+type Stats struct {
+	Total     int     `json:"total"`
+	New       int     `json:"new"`
+	Young     int     `json:"young"`
+	Mature    int     `json:"mature"`
+	DueNow    int     `json:"due_now"`
+	Workload  int     `json:"workload"`
+	Reviewed  int     `json:"reviewed"`
+	Retention float64 `json:"retention"`
+
+	// Stages counts the cards in each mastery stage, a finer split than
+	// Young and Mature
+	Stages map[string]int `json:"stages"`
+
+	retained int
+}
+
+type DeckStats struct {
+	Id    string `json:"id"`
+	Name  string `json:"name"`
+	Stats *Stats `json:"stats"`
+}
+
+type StatsReport struct {
+	Overall *Stats            `json:"overall"`
+	ByTag   map[string]*Stats `json:"by_tag"`
+	ByDeck  []*DeckStats      `json:"by_deck"`
+
+	// Time is spent in writing and review sessions, filled in by the route
+	Time sessions.Totals `json:"time"`
+}
+
+func newStats() *Stats {
+	stages := make(map[string]int, len(Stages))
+	for _, stage := range Stages {
+		stages[stage] = 0
+	}
+	return &Stats{Stages: stages}
+}
+
+func (s *Stats) add(card Card, now time.Time) {
+	s.Total++
+	s.Stages[card.DerivedStage()]++
+
+	switch {
+	case card.IsNew():
+		s.New++
+	case card.IsMature():
+		s.Mature++
+	default:
+		s.Young++
+	}
+
+	if card.IsDue(now) {
+		s.DueNow++
+	}
+	if card.IsDue(now.Add(workloadWindow)) {
+		s.Workload++
+	}
+
+	if !card.IsNew() {
+		s.Reviewed++
+		if card.Remembered() {
+			s.retained++
+		}
+		s.Retention = float64(s.retained) / float64(s.Reviewed)
+	}
+}
+
+// Report computes the stats of a user as viewer sees them, the decks
+// being only the user's to see
+func Report(srsService Service, grammarService grammar.Service, sessionsService sessions.Service, userId string, viewerId string, now time.Time) (StatsReport, error) {
+	cards, err := srsService.Cards(userId)
+	if err != nil {
+		return StatsReport{}, err
+	}
+
+	grammarIds := make([]string, 0, len(cards))
+	for _, card := range cards {
+		grammarIds = append(grammarIds, card.Grammar)
+	}
+	tags, err := grammarService.TagsByGrammar(grammarIds)
+	if err != nil {
+		return StatsReport{}, err
+	}
+
+	var decks []grammar.Deck
+	if userId == viewerId {
+		if decks, err = grammarService.Decks(userId, false); err != nil {
+			return StatsReport{}, err
+		}
+	}
+
+	report := ComputeStats(cards, tags, decks, now)
+	if report.Time, err = sessionsService.Totals(userId, now); err != nil {
+		return StatsReport{}, err
+	}
+	return report, nil
+}
+
+// ComputeStats breaks retention, workload, and maturity down overall, per tag,
+// and per deck. A grammar can live in several decks so its card counts
+// towards each of them.
+func ComputeStats(cards []Card, tagsByGrammar map[string][]string, decks []grammar.Deck, now time.Time) StatsReport {
+	report := StatsReport{
+		Overall: newStats(),
+		ByTag:   map[string]*Stats{},
+		ByDeck:  make([]*DeckStats, 0, len(decks)),
+	}
+
+	decksByGrammar := map[string][]*DeckStats{}
+	for _, deck := range decks {
+		ds := &DeckStats{Id: deck.Id, Name: deck.Name, Stats: newStats()}
+		report.ByDeck = append(report.ByDeck, ds)
+		for _, grammarId := range deck.Grammar {
+			decksByGrammar[grammarId] = append(decksByGrammar[grammarId], ds)
+		}
+	}
+
+	for _, card := range cards {
+		report.Overall.add(card, now)
+
+		for _, ds := range decksByGrammar[card.Grammar] {
+			ds.Stats.add(card, now)
+		}
+
+		for _, tag := range tagsByGrammar[card.Grammar] {
+			s, ok := report.ByTag[tag]
+			if !ok {
+				s = newStats()
+				report.ByTag[tag] = s
+			}
+			s.add(card, now)
+		}
+	}
+
+	return report
+}
@@ -0,0 +1,61 @@
+package srs
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/grammar"
+)
+
+func TestComputeStats(t *testing.T) {
+	now := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	cards := []Card{
+		// new, due since yesterday
+		{Id: "new", Grammar: "g1", Created: now.AddDate(0, 0, -1)},
+		// young and overdue
+		{Id: "young", Grammar: "g2", EaseFactor: 2.5, IntervalDays: 3, Repetition: 1, LastReviewed: now.AddDate(0, 0, -4)},
+		// mature, due in a month
+		{Id: "mature", Grammar: "g3", EaseFactor: 2.5, IntervalDays: 30, Repetition: 3, LastReviewed: now.AddDate(0, 0, -1)},
+		// just failed, due tomorrow
+		{Id: "failed", Grammar: "g4", EaseFactor: 2.1, IntervalDays: 1, LastReviewed: now},
+	}
+	tags := map[string][]string{"g1": {"n5"}, "g2": {"n5"}, "g3": {"n4"}}
+	decks := []grammar.Deck{
+		{Id: "d1", Name: "Basics", Grammar: []string{"g1", "g3"}},
+		{Id: "d2", Name: "Empty"},
+	}
+
+	stages := func(learning, mature int) map[string]int {
+		return map[string]int{StageLearning: learning, StageYoung: 0, StageMature: mature, StageMastered: 0, StageBurned: 0}
+	}
+
+	report := ComputeStats(cards, tags, decks, now)
+	if len(report.ByDeck) != len(decks) {
+		t.Fatalf("got %d decks, want %d", len(report.ByDeck), len(decks))
+	}
+
+	tests := []struct {
+		name  string
+		stats *Stats
+		want  Stats
+	}{
+		{"overall", report.Overall, Stats{Total: 4, New: 1, Young: 2, Mature: 1, DueNow: 2, Workload: 3, Reviewed: 3, Retention: 2.0 / 3, Stages: stages(3, 1)}},
+		{"tag n5", report.ByTag["n5"], Stats{Total: 2, New: 1, Young: 1, DueNow: 2, Workload: 2, Reviewed: 1, Retention: 1, Stages: stages(2, 0)}},
+		{"tag n4", report.ByTag["n4"], Stats{Total: 1, Mature: 1, Reviewed: 1, Retention: 1, Stages: stages(0, 1)}},
+		{"deck with grammar", report.ByDeck[0].Stats, Stats{Total: 2, New: 1, Mature: 1, DueNow: 1, Workload: 1, Reviewed: 1, Retention: 1, Stages: stages(1, 1)}},
+		{"empty deck", report.ByDeck[1].Stats, Stats{Stages: stages(0, 0)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.stats == nil {
+				t.Fatal("missing stats")
+			}
+			got := *tt.stats
+			got.retained = 0
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/filesystem"
+)
+
+// hashFile is the sha256 of an upload's content, which identifies its blob
+func hashFile(file *filesystem.File) (string, error) {
+	r, err := file.Reader.Open()
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// findOrCreateBlob returns the blob holding content with the given hash,
+// storing file as a new blob when there is none yet
+func findOrCreateBlob(app core.App, hash string, file *filesystem.File) (*core.Record, error) {
+	blob, err := app.FindFirstRecordByData("blobs", "hash", hash)
+	if err == nil {
+		return blob, nil
+	}
+
+	collection, err := app.FindCollectionByNameOrId("blobs")
+	if err != nil {
+		return nil, err
+	}
+
+	blob = core.NewRecord(collection)
+	blob.Set("hash", hash)
+	blob.Set("file", file)
+	blob.Set("size", file.Size)
+	if err := app.Save(blob); err != nil {
+		// the same content was uploaded at the same time and won the race
+		if existing, findErr := app.FindFirstRecordByData("blobs", "hash", hash); findErr == nil {
+			return existing, nil
+		}
+		return nil, err
+	}
+	return blob, nil
+}
+
+// releaseBlob deletes a blob once no media points at it anymore
+func releaseBlob(app core.App, blobId string) error {
+	if blobId == "" {
+		return nil
+	}
+
+	var refs int
+	err := app.RecordQuery("media").
+		Select("COUNT(*)").
+		AndWhere(dbx.HashExp{"blob": blobId}).
+		Row(&refs)
+	if err != nil || refs > 0 {
+		return err
+	}
+
+	blob, err := app.FindRecordById("blobs", blobId)
+	if err != nil {
+		// already gone
+		return nil
+	}
+	return app.Delete(blob)
+}
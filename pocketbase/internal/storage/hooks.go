@@ -0,0 +1,107 @@
+package storage
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// BindHooks records the size of every upload and rejects the ones that would
+// take a user over their quota. It runs on every save, not just API requests,
+// so imports are held to the same limit.
+//
+// Uploads are then moved into the blob of their content, so the same file
+// uploaded twice, by anyone, is only stored once. A blob goes away with the
+// last media using it.
+//
+// A media's size only ever comes from its upload, writes of it without one
+// are undone so usage can't be talked down.
+//
+// Only superusers set a user's quota, which signups are also kept from
+// doing here in case the users collection's create rule stops guarding it.
+func BindHooks(app core.App) {
+	enforce := func(e *core.RecordEvent) error {
+		files := e.Record.GetUnsavedFiles("file")
+		if len(files) == 0 {
+			if e.Record.IsNew() {
+				e.Record.Set("size", 0)
+			} else {
+				e.Record.Set("size", e.Record.Original().GetInt("size"))
+			}
+			return e.Next()
+		}
+
+		var size int64
+		for _, file := range files {
+			size += file.Size
+		}
+		e.Record.Set("size", size)
+
+		usage, err := usage(e.App, e.Record.GetString("user"), e.Record.Id)
+		if err != nil {
+			return err
+		}
+		if size > usage.Remaining() {
+			return validation.Errors{
+				"file": validation.NewError("validation_storage_quota_exceeded", "The upload would exceed your storage quota.").
+					SetParams(map[string]any{"quota": usage.Quota >> 20, "remaining": usage.Remaining()}),
+			}
+		}
+
+		return e.Next()
+	}
+
+	dedupe := func(e *core.RecordEvent) error {
+		files := e.Record.GetUnsavedFiles("file")
+		if len(files) == 0 {
+			if e.Record.IsNew() {
+				return validation.Errors{"file": validation.ErrRequired}
+			}
+			return e.Next()
+		}
+
+		hash, err := hashFile(files[0])
+		if err != nil {
+			return err
+		}
+		blob, err := findOrCreateBlob(e.App, hash, files[0])
+		if err != nil {
+			return err
+		}
+
+		previous := e.Record.Original().GetString("blob")
+		e.Record.Set("blob", blob.Id)
+		e.Record.Set("hash", hash)
+		e.Record.Set("file", nil)
+
+		if err := e.Next(); err != nil {
+			// a new blob nothing ended up using
+			if releaseErr := releaseBlob(e.App, blob.Id); releaseErr != nil {
+				e.App.Logger().Error("Failed to release blob", "blob", blob.Id, "error", releaseErr)
+			}
+			return err
+		}
+		if previous != blob.Id {
+			return releaseBlob(e.App, previous)
+		}
+		return nil
+	}
+
+	app.OnRecordCreateRequest("users").BindFunc(func(e *core.RecordRequestEvent) error {
+		if !e.HasSuperuserAuth() && e.Record.GetInt("storage_quota") != 0 {
+			return e.ForbiddenError("Only superusers can set a storage quota.", nil)
+		}
+		return e.Next()
+	})
+
+	app.OnRecordCreate("media").BindFunc(enforce)
+	app.OnRecordUpdate("media").BindFunc(enforce)
+	app.OnRecordCreate("media").BindFunc(dedupe)
+	app.OnRecordUpdate("media").BindFunc(dedupe)
+
+	app.OnRecordAfterDeleteSuccess("media").BindFunc(func(e *core.RecordEvent) error {
+		if err := releaseBlob(e.App, e.Record.GetString("blob")); err != nil {
+			e.App.Logger().Error("Failed to release blob", "blob", e.Record.GetString("blob"), "error", err)
+		}
+		return e.Next()
+	})
+}
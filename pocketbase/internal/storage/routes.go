@@ -0,0 +1,17 @@
+package storage
+
+import (
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+func RegisterRoutes(g *api.Group, storageService Service) {
+	g.GET("/storage", "The user's storage usage and quota", Usage{}, func(e *core.RequestEvent) error {
+		usage, err := storageService.Usage(e.Auth.Id)
+		if err != nil {
+			return e.InternalServerError("Failed to load storage usage.", err)
+		}
+		return e.JSON(200, usage)
+	})
+}
@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+type Service interface {
+	// Usage sums the sizes of the user's uploads against their quota
+	Usage(userId string) (Usage, error)
+}
+
+type service struct {
+	app core.App
+}
+
+func NewService(app core.App) Service {
+	return &service{app: app}
+}
+
+func (s *service) Usage(userId string) (Usage, error) {
+	return usage(s.app, userId, "")
+}
+
+// usage leaves out the media record excludeId, so replacing a file only
+// counts the new one
+func usage(app core.App, userId string, excludeId string) (Usage, error) {
+	user, err := app.FindRecordById("users", userId)
+	if err != nil {
+		return Usage{}, err
+	}
+
+	quotaMB := int64(user.GetInt("storage_quota"))
+	if quotaMB <= 0 {
+		quotaMB = instanceQuotaMB()
+	}
+
+	var used int64
+	err = app.RecordQuery("media").
+		Select("COALESCE(SUM(size), 0)").
+		AndWhere(dbx.HashExp{"user": userId}).
+		AndWhere(dbx.Not(dbx.HashExp{"id": excludeId})).
+		Row(&used)
+	if err != nil {
+		return Usage{}, err
+	}
+
+	return Usage{Used: used, Quota: quotaMB << 20}, nil
+}
@@ -0,0 +1,27 @@
+package storage
+
+import (
+	"os"
+	"strconv"
+)
+
+// DefaultQuotaMB applies to users without a storage_quota of their own
+const DefaultQuotaMB = 1024
+
+// Usage is how much of their quota a user's uploads take, in bytes
+type Usage struct {
+	Used  int64 `json:"used"`
+	Quota int64 `json:"quota"`
+}
+
+func (u Usage) Remaining() int64 {
+	return max(u.Quota-u.Used, 0)
+}
+
+// instanceQuotaMB reads the quota self-hosters configure with STORAGE_QUOTA_MB
+func instanceQuotaMB() int64 {
+	if mb, err := strconv.ParseInt(os.Getenv("STORAGE_QUOTA_MB"), 10, 64); err == nil && mb > 0 {
+		return mb
+	}
+	return DefaultQuotaMB
+}
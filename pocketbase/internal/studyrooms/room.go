@@ -0,0 +1,199 @@
+package studyrooms
+
+import (
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+const (
+	codeLength = 6
+
+	// codeAlphabet leaves out characters easily mistaken for one another, in
+	// capitals so codes read out well
+	codeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+	maxNameLength   = 100
+	maxPromptLength = 2000
+	maxMembers      = 30
+	maxTimerMinutes = 180
+
+	// maxCharacters bounds the progress members report
+	maxCharacters = 1000000
+)
+
+// Member statuses
+const (
+	StatusWriting = "writing"
+	StatusBreak   = "break"
+	StatusDone    = "done"
+)
+
+var statuses = []string{StatusWriting, StatusBreak, StatusDone}
+
+// Room is a study room as its members see it. Rooms live in memory only and
+// are gone once everyone has left for a while.
+type Room struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+
+	// Host controls the timer and prompt, passing to whoever has been there
+	// longest when they leave
+	Host string `json:"host"`
+
+	// Group limits the room to the members of one of the host's groups,
+	// empty for anyone with the code
+	Group string `json:"group"`
+
+	Prompt  string    `json:"prompt"`
+	Timer   *Timer    `json:"timer"`
+	Members []Member  `json:"members"`
+	Created time.Time `json:"created"`
+}
+
+// Timer is a writing sprint the host started
+type Timer struct {
+	Minutes int       `json:"minutes"`
+	Started time.Time `json:"started"`
+	Ends    time.Time `json:"ends"`
+}
+
+// Member is someone connected to the room
+type Member struct {
+	Id     string `json:"id"`
+	Name   string `json:"name"`
+	Status string `json:"status"`
+
+	// Characters is how much they've written this sprint, as they report it
+	Characters int       `json:"characters"`
+	Joined     time.Time `json:"joined"`
+}
+
+type CreateRequest struct {
+	Name   string `json:"name"`
+	Group  string `json:"group"`
+	Prompt string `json:"prompt"`
+}
+
+func (r CreateRequest) Validate() error {
+	errs := validation.Errors{}
+	tooLong := func(max int) error {
+		return validation.NewError("validation_length_too_long", "The length must be no more than {{.max}}.").
+			SetParams(map[string]any{"max": max})
+	}
+	if utf8.RuneCountInString(r.Name) > maxNameLength {
+		errs["name"] = tooLong(maxNameLength)
+	}
+	if utf8.RuneCountInString(r.Prompt) > maxPromptLength {
+		errs["prompt"] = tooLong(maxPromptLength)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Joined is a room joined, with the ticket its socket is opened with
+type Joined struct {
+	Room Room `json:"room"`
+
+	// Ticket opens the room's socket once, within a minute
+	Ticket string `json:"ticket"`
+}
+
+// Ticket is who a ticket lets into which room
+type Ticket struct {
+	User    string
+	Room    string
+	expires time.Time
+}
+
+// Messages clients send over the socket
+const (
+	// MessageStatus sets the member's own status and progress
+	MessageStatus = "status"
+
+	// MessageTimer starts a sprint of Minutes, or stops it with none. Host
+	// only.
+	MessageTimer = "timer"
+
+	// MessagePrompt sets the shared prompt, or clears it. Host only.
+	MessagePrompt = "prompt"
+
+	// MessageClose closes the room for everyone. Host only.
+	MessageClose = "close"
+)
+
+type Message struct {
+	Type       string `json:"type"`
+	Status     string `json:"status"`
+	Characters int    `json:"characters"`
+	Minutes    int    `json:"minutes"`
+	Prompt     string `json:"prompt"`
+}
+
+func (m Message) Validate() error {
+	errs := validation.Errors{}
+	outOfRange := func(min, max int) error {
+		return validation.NewError("validation_out_of_range", "Must be between {{.min}} and {{.max}}.").
+			SetParams(map[string]any{"min": min, "max": max})
+	}
+	switch m.Type {
+	case MessageStatus:
+		if m.Status != "" && !slices.Contains(statuses, m.Status) {
+			errs["status"] = validation.NewError("validation_invalid_value", "Invalid value.")
+		}
+		if m.Characters < 0 || m.Characters > maxCharacters {
+			errs["characters"] = outOfRange(0, maxCharacters)
+		}
+	case MessageTimer:
+		if m.Minutes < 0 || m.Minutes > maxTimerMinutes {
+			errs["minutes"] = outOfRange(0, maxTimerMinutes)
+		}
+	case MessagePrompt:
+		if utf8.RuneCountInString(m.Prompt) > maxPromptLength {
+			errs["prompt"] = validation.NewError("validation_length_too_long", "The length must be no more than {{.max}}.").
+				SetParams(map[string]any{"max": maxPromptLength})
+		}
+	case MessageClose:
+	default:
+		errs["type"] = validation.NewError("validation_invalid_value", "Invalid value.")
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Events the server sends over the socket
+const (
+	// EventRoom is the room as it is now, sent on joining and every change
+	EventRoom = "room"
+
+	// EventTimerEnded is sent when a sprint runs out, along with the room
+	EventTimerEnded = "timer_ended"
+
+	// EventClosed is sent before the socket closes because the host closed
+	// the room
+	EventClosed = "closed"
+
+	// EventError answers a message that couldn't be handled
+	EventError = "error"
+)
+
+type Event struct {
+	Type    string `json:"type"`
+	Room    *Room  `json:"room,omitempty"`
+	Message string `json:"message,omitempty"`
+
+	// Time is the server's clock, for clients to count sprints down by
+	Time time.Time `json:"time"`
+}
+
+// normalizeCode accepts codes typed in any case and with spaces
+func normalizeCode(code string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(code), ""))
+}
@@ -0,0 +1,88 @@
+package studyrooms
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/i18n"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/gorilla/websocket"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  4096,
+	WriteBufferSize: 4096,
+
+	// the ticket is what lets a socket in, and only the user it was issued
+	// to holds it, whatever page opens the socket
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// Rooms are created and joined with the user's token, which answers with a
+// ticket: browsers can't send headers when opening a WebSocket, so the
+// socket takes the ticket instead
+func RegisterRoutes(g *api.Group, roomsService Service) {
+	g.POST("/study-rooms", "Open a study room to write in together, getting its join code and a ticket to its socket", CreateRequest{}, Joined{}, func(e *core.RequestEvent) error {
+		var req CreateRequest
+		if err := e.BindBody(&req); err != nil {
+			return e.BadRequestError("Invalid request body.", err)
+		}
+		if err := req.Validate(); err != nil {
+			return e.BadRequestError("Invalid study room.", err)
+		}
+
+		joined, err := roomsService.Create(e.Auth.Id, req)
+		switch {
+		case errors.Is(err, ErrNotInGroup):
+			return e.BadRequestError("Invalid study room.", validation.Errors{
+				"group": validation.NewError("validation_invalid_value", "Not one of your groups."),
+			})
+		case errors.Is(err, ErrTooManyRooms):
+			return e.TooManyRequestsError("Too many study rooms are open, close one first.", err)
+		case err != nil:
+			return e.InternalServerError("Failed to open the study room.", err)
+		}
+		return e.JSON(200, joined)
+	})
+
+	g.POST("/study-rooms/{code}/join", "Join a study room by its code, getting a ticket to its socket", nil, Joined{}, func(e *core.RequestEvent) error {
+		joined, err := roomsService.Join(e.Auth.Id, e.Request.PathValue("code"))
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return e.NotFoundError("", err)
+		case errors.Is(err, ErrNotInGroup):
+			return e.ForbiddenError("The study room is for members of its group.", err)
+		case errors.Is(err, ErrFull):
+			return e.Error(http.StatusConflict, "The room is full.", err)
+		case err != nil:
+			return e.InternalServerError("Failed to join the study room.", err)
+		}
+		return e.JSON(200, joined)
+	})
+}
+
+// RegisterPublicRoutes adds the rooms' WebSocket, which sends Event and takes
+// Message as JSON text frames
+func RegisterPublicRoutes(g *api.Group, roomsService Service) {
+	g.GET("/study-rooms/socket", "Connect to a study room's WebSocket with ?ticket= from joining it", nil, func(e *core.RequestEvent) error {
+		if !websocket.IsWebSocketUpgrade(e.Request) {
+			return e.BadRequestError("Open this as a WebSocket.", nil)
+		}
+		ticket, err := roomsService.Redeem(e.Request.URL.Query().Get("ticket"))
+		if err != nil {
+			return e.UnauthorizedError("The ticket is invalid or expired, join the room again.", err)
+		}
+
+		conn, err := upgrader.Upgrade(e.Response, e.Request, nil)
+		if err != nil {
+			// the upgrader answered already
+			return nil
+		}
+		Serve(roomsService, ticket, NewClient(conn, i18n.FromRequest(e)))
+		return nil
+	})
+}
@@ -0,0 +1,405 @@
+package studyrooms
+
+import (
+	"database/sql"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/security"
+)
+
+const (
+	ticketLength = 40
+	ticketTTL    = time.Minute
+
+	// emptyTimeout is how long a room nobody is connected to stays open, for
+	// members to come back after a dropped connection
+	emptyTimeout = 10 * time.Minute
+
+	maxRooms        = 1000
+	maxRoomsPerHost = 3
+)
+
+var (
+	// ErrNotInGroup means the room is for a group the user isn't in
+	ErrNotInGroup = errors.New("not in the room's group")
+
+	// ErrFull means the room has as many members as it can take
+	ErrFull = errors.New("room full")
+
+	// ErrTooManyRooms means the user, or the instance, has as many rooms open
+	// as it can
+	ErrTooManyRooms = errors.New("too many rooms")
+
+	// ErrNotHost means a member tried what only the host can do
+	ErrNotHost = errors.New("not the host")
+)
+
+type Service interface {
+	// Create opens a room the user hosts, joining it
+	Create(userId string, req CreateRequest) (Joined, error)
+
+	// Join issues the user a ticket into the room with the code
+	Join(userId string, code string) (Joined, error)
+
+	// Redeem takes a ticket's place in its room, once
+	Redeem(ticket string) (Ticket, error)
+
+	// Connect adds a client of a redeemed ticket to its room, sending it the
+	// room's events until it leaves
+	Connect(ticket Ticket, client *Client) error
+
+	// Disconnect takes a client out of its room
+	Disconnect(client *Client)
+
+	// Handle applies a message of the client's to its room
+	Handle(client *Client, msg Message) error
+}
+
+// room is a Room with who's connected to it
+type room struct {
+	Room
+
+	// members holds presence by user, in the order they joined
+	members []*presence
+	clients map[*Client]bool
+
+	timer *time.Timer
+	empty *time.Timer
+}
+
+type presence struct {
+	Member
+	connections int
+}
+
+type service struct {
+	app core.App
+
+	mu      sync.Mutex
+	rooms   map[string]*room
+	tickets map[string]Ticket
+}
+
+func NewService(app core.App) Service {
+	return &service{app: app, rooms: map[string]*room{}, tickets: map[string]Ticket{}}
+}
+
+func (s *service) Create(userId string, req CreateRequest) (Joined, error) {
+	if req.Group != "" {
+		if err := s.checkGroup(userId, req.Group); err != nil {
+			return Joined{}, err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hosted := 0
+	for _, r := range s.rooms {
+		if r.Host == userId {
+			hosted++
+		}
+	}
+	if len(s.rooms) >= maxRooms || hosted >= maxRoomsPerHost {
+		return Joined{}, ErrTooManyRooms
+	}
+
+	code := security.RandomStringWithAlphabet(codeLength, codeAlphabet)
+	for s.rooms[code] != nil {
+		code = security.RandomStringWithAlphabet(codeLength, codeAlphabet)
+	}
+	r := &room{
+		Room: Room{
+			Code:    code,
+			Name:    req.Name,
+			Host:    userId,
+			Group:   req.Group,
+			Prompt:  req.Prompt,
+			Created: time.Now(),
+		},
+		clients: map[*Client]bool{},
+	}
+	s.rooms[code] = r
+	s.closeWhenEmpty(r)
+
+	return Joined{Room: r.snapshot(), Ticket: s.issue(userId, code)}, nil
+}
+
+func (s *service) Join(userId string, code string) (Joined, error) {
+	code = normalizeCode(code)
+
+	s.mu.Lock()
+	r, ok := s.rooms[code]
+	var group string
+	if ok {
+		group = r.Group
+	}
+	s.mu.Unlock()
+	if !ok {
+		return Joined{}, sql.ErrNoRows
+	}
+	if group != "" {
+		if err := s.checkGroup(userId, group); err != nil {
+			return Joined{}, err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.rooms[code] != r {
+		return Joined{}, sql.ErrNoRows
+	}
+	if len(r.members) >= maxMembers && r.presence(userId) == nil {
+		return Joined{}, ErrFull
+	}
+	return Joined{Room: r.snapshot(), Ticket: s.issue(userId, code)}, nil
+}
+
+func (s *service) Redeem(ticket string) (Ticket, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tickets[ticket]
+	delete(s.tickets, ticket)
+	if !ok || time.Now().After(t.expires) {
+		return Ticket{}, sql.ErrNoRows
+	}
+	return t, nil
+}
+
+func (s *service) Connect(ticket Ticket, client *Client) error {
+	name := ""
+	if user, err := s.app.FindRecordById("users", ticket.User); err == nil {
+		name = user.GetString("name")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.rooms[ticket.Room]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	p := r.presence(ticket.User)
+	if p == nil {
+		if len(r.members) >= maxMembers {
+			return ErrFull
+		}
+		p = &presence{Member: Member{Id: ticket.User, Name: name, Status: StatusWriting, Joined: time.Now()}}
+		r.members = append(r.members, p)
+	}
+	p.connections++
+
+	client.user, client.room = ticket.User, r.Code
+	r.clients[client] = true
+	if r.empty != nil {
+		r.empty.Stop()
+		r.empty = nil
+	}
+
+	s.broadcast(r, EventRoom)
+	return nil
+}
+
+func (s *service) Disconnect(client *Client) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.rooms[client.room]
+	if !ok || !r.clients[client] {
+		return
+	}
+	s.drop(r, client)
+	s.broadcast(r, EventRoom)
+}
+
+func (s *service) Handle(client *Client, msg Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.rooms[client.room]
+	if !ok || !r.clients[client] {
+		return sql.ErrNoRows
+	}
+	if msg.Type != MessageStatus && client.user != r.Host {
+		return ErrNotHost
+	}
+
+	switch msg.Type {
+	case MessageStatus:
+		p := r.presence(client.user)
+		if msg.Status != "" {
+			p.Status = msg.Status
+		}
+		p.Characters = msg.Characters
+
+	case MessageTimer:
+		s.startTimer(r, msg.Minutes)
+
+	case MessagePrompt:
+		r.Prompt = msg.Prompt
+
+	case MessageClose:
+		s.close(r)
+		return nil
+	}
+
+	s.broadcast(r, EventRoom)
+	return nil
+}
+
+// startTimer starts a sprint everyone's progress counts from, or stops the
+// running one with no minutes
+func (s *service) startTimer(r *room, minutes int) {
+	if r.timer != nil {
+		r.timer.Stop()
+		r.timer = nil
+	}
+	r.Timer = nil
+	if minutes == 0 {
+		return
+	}
+
+	now := time.Now()
+	sprint := &Timer{Minutes: minutes, Started: now, Ends: now.Add(time.Duration(minutes) * time.Minute)}
+	r.Timer = sprint
+	for _, p := range r.members {
+		p.Status, p.Characters = StatusWriting, 0
+	}
+	r.timer = time.AfterFunc(time.Until(sprint.Ends), func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.rooms[r.Code] != r || r.Timer != sprint {
+			return
+		}
+		r.Timer, r.timer = nil, nil
+		s.broadcast(r, EventTimerEnded)
+	})
+}
+
+// drop takes a client out of the room, the member with it once their last
+// client is gone. Must hold s.mu.
+func (s *service) drop(r *room, client *Client) {
+	delete(r.clients, client)
+	client.close()
+
+	i := slices.IndexFunc(r.members, func(p *presence) bool { return p.Id == client.user })
+	if i < 0 {
+		return
+	}
+	r.members[i].connections--
+	if r.members[i].connections > 0 {
+		return
+	}
+	r.members = slices.Delete(r.members, i, i+1)
+
+	if r.Host == client.user && len(r.members) > 0 {
+		r.Host = r.members[0].Id
+	}
+	if len(r.clients) == 0 {
+		s.closeWhenEmpty(r)
+	}
+}
+
+// closeWhenEmpty closes the room if nobody has connected to it again by the
+// time it times out. Must hold s.mu.
+func (s *service) closeWhenEmpty(r *room) {
+	if r.empty != nil {
+		r.empty.Stop()
+	}
+	r.empty = time.AfterFunc(emptyTimeout, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.rooms[r.Code] == r && len(r.clients) == 0 {
+			s.close(r)
+		}
+	})
+}
+
+// close removes the room, telling whoever is still connected. Must hold
+// s.mu.
+func (s *service) close(r *room) {
+	delete(s.rooms, r.Code)
+	for _, t := range []*time.Timer{r.timer, r.empty} {
+		if t != nil {
+			t.Stop()
+		}
+	}
+	event := Event{Type: EventClosed, Time: time.Now()}
+	for client := range r.clients {
+		client.send(event)
+		client.close()
+	}
+	r.clients = nil
+}
+
+// broadcast sends the room to everyone connected, dropping clients too slow
+// to keep up. Must hold s.mu.
+func (s *service) broadcast(r *room, eventType string) {
+	snapshot := r.snapshot()
+	event := Event{Type: eventType, Room: &snapshot, Time: time.Now()}
+	var slow []*Client
+	for client := range r.clients {
+		if !client.send(event) {
+			slow = append(slow, client)
+		}
+	}
+	for _, client := range slow {
+		s.drop(r, client)
+	}
+}
+
+// issue makes a ticket into the room, clearing out expired ones. Must hold
+// s.mu.
+func (s *service) issue(userId string, code string) string {
+	now := time.Now()
+	for token, t := range s.tickets {
+		if now.After(t.expires) {
+			delete(s.tickets, token)
+		}
+	}
+	token := security.RandomString(ticketLength)
+	s.tickets[token] = Ticket{User: userId, Room: code, expires: now.Add(ticketTTL)}
+	return token
+}
+
+// checkGroup makes sure the user owns or is a member of the group
+func (s *service) checkGroup(userId string, groupId string) error {
+	_, err := s.app.FindFirstRecordByFilter(
+		"groups",
+		"id = {:id} && (owner = {:user} || members.id ?= {:user})",
+		dbx.Params{"id": groupId, "user": userId},
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotInGroup
+	}
+	return err
+}
+
+func (r *room) presence(userId string) *presence {
+	for _, p := range r.members {
+		if p.Id == userId {
+			return p
+		}
+	}
+	return nil
+}
+
+// snapshot copies the room for sending
+func (r *room) snapshot() Room {
+	room := r.Room
+	if r.Timer != nil {
+		sprint := *r.Timer
+		room.Timer = &sprint
+	}
+	room.Members = make([]Member, 0, len(r.members))
+	for _, p := range r.members {
+		room.Members = append(room.Members, p.Member)
+	}
+	return room
+}
@@ -0,0 +1,154 @@
+package studyrooms
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/i18n"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	writeWait      = 10 * time.Second
+	pongWait       = 60 * time.Second
+	pingPeriod     = pongWait * 9 / 10
+	maxMessageSize = 8 << 10
+
+	// eventBuffer is how many events a client can fall behind by before
+	// it's dropped
+	eventBuffer = 32
+)
+
+// Client is one socket connected to a room. A member can have several, e.g.
+// the app on a phone and a browser tab.
+type Client struct {
+	conn   *websocket.Conn
+	locale string
+
+	// set on connecting
+	user string
+	room string
+
+	events chan Event
+	done   chan struct{}
+	once   sync.Once
+}
+
+func NewClient(conn *websocket.Conn, locale string) *Client {
+	return &Client{conn: conn, locale: locale, events: make(chan Event, eventBuffer), done: make(chan struct{})}
+}
+
+// send queues an event, reporting false when the client has fallen too far
+// behind to take it
+func (c *Client) send(event Event) bool {
+	select {
+	case <-c.done:
+		return true
+	case c.events <- event:
+		return true
+	default:
+		return false
+	}
+}
+
+// close ends the socket once the events queued are written
+func (c *Client) close() {
+	c.once.Do(func() { close(c.done) })
+}
+
+func (c *Client) fail(message string) {
+	c.send(Event{Type: EventError, Message: i18n.T(c.locale, message), Time: time.Now()})
+}
+
+// Serve connects the client to the ticket's room and handles its messages
+// until either side goes away
+func Serve(roomsService Service, ticket Ticket, client *Client) {
+	go client.write()
+	defer client.close()
+
+	switch err := roomsService.Connect(ticket, client); {
+	case errors.Is(err, ErrFull):
+		client.fail("The room is full.")
+		return
+	case err != nil:
+		client.fail("The room was closed.")
+		return
+	}
+	defer roomsService.Disconnect(client)
+
+	conn := client.conn
+	conn.SetReadLimit(maxMessageSize)
+	conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		conn.SetReadDeadline(time.Now().Add(pongWait))
+
+		var msg Message
+		if err := json.Unmarshal(data, &msg); err != nil {
+			client.fail("Invalid message.")
+			continue
+		}
+		if err := msg.Validate(); err != nil {
+			client.fail("Invalid message.")
+			continue
+		}
+
+		switch err := roomsService.Handle(client, msg); {
+		case errors.Is(err, ErrNotHost):
+			client.fail("Only the host can do that.")
+		case errors.Is(err, sql.ErrNoRows):
+			// dropped, or the room closed
+			return
+		}
+	}
+}
+
+// write sends the client's events and keeps the connection alive, the only
+// goroutine writing to it
+func (c *Client) write() {
+	ping := time.NewTicker(pingPeriod)
+	defer func() {
+		ping.Stop()
+		c.conn.Close()
+	}()
+
+	for {
+		select {
+		case event := <-c.events:
+			if err := c.writeEvent(event); err != nil {
+				c.close()
+				return
+			}
+		case <-ping.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+				c.close()
+				return
+			}
+		case <-c.done:
+			// flush what was queued before closing, e.g. why
+			for len(c.events) > 0 {
+				if err := c.writeEvent(<-c.events); err != nil {
+					return
+				}
+			}
+			c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(writeWait))
+			return
+		}
+	}
+}
+
+func (c *Client) writeEvent(event Event) error {
+	c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	return c.conn.WriteJSON(event)
+}
@@ -0,0 +1,30 @@
+package tagging
+
+import (
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/events"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// BindHooks proposes tags for grammar users add without any, and has the
+// model read the topics of journal entries when it's configured
+func BindHooks(app core.App, bus *events.Bus, taggingService Service) {
+	bus.On(events.GrammarCreated).BindFunc(func(e *events.Event) error {
+		var tags []string
+		_ = e.Record.UnmarshalJSONField("tags", &tags)
+
+		if len(tags) == 0 {
+			if _, err := taggingService.Suggest(e.User, e.Record.Id); err != nil {
+				e.App.Logger().Error("Failed to start tag suggestions", "grammar", e.Record.Id, "error", err)
+			}
+		}
+		return e.Next()
+	})
+
+	bus.On(events.EntryWritten).BindFunc(func(e *events.Event) error {
+		if _, err := taggingService.ExtractTopics(e.User, e.Record.Id); err != nil {
+			e.App.Logger().Error("Failed to start topic extraction", "entry", e.Record.Id, "error", err)
+		}
+		return e.Next()
+	})
+}
@@ -0,0 +1,90 @@
+package tagging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/ai"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/grammar"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/journal"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/prompts"
+)
+
+// maxEntryLength bounds how much of an entry is sent to the model
+const maxEntryLength = 4000
+
+// suggestByModel asks the model to pick tags for a grammar out of the
+// vocabulary, dropping whatever it makes up
+func suggestByModel(ctx context.Context, client ai.Client, promptsService prompts.Service, g grammar.Grammar, vocabulary []string) ([]string, error) {
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "Tags: %s\n\nGrammar: %s\nMeaning: %s\n", strings.Join(vocabulary, ", "), g.Usage, g.Meaning)
+	if g.Context != "" {
+		fmt.Fprintf(&prompt, "Context: %s\n", g.Context)
+	}
+	for _, ex := range g.Examples {
+		fmt.Fprintf(&prompt, "Example: %s\n", strings.TrimSpace(ex.Japanese+" "+ex.English))
+	}
+
+	rendered, err := promptsService.Render(prompts.KeyGrammarTags, "", prompts.Data{Input: prompt.String()})
+	if err != nil {
+		return nil, err
+	}
+	answer, err := client.Complete(ctx, ai.Request{System: rendered.System, Prompt: rendered.User, JSON: true})
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed struct {
+		Tags []string `json:"tags"`
+	}
+	if err := json.Unmarshal([]byte(answer), &parsed); err != nil {
+		return nil, fmt.Errorf("unexpected tag suggestions from the model: %w", err)
+	}
+
+	tags := make([]string, 0, len(parsed.Tags))
+	for _, tag := range parsed.Tags {
+		if slices.Contains(vocabulary, tag) && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) > maxSuggestions {
+		tags = tags[:maxSuggestions]
+	}
+	return tags, nil
+}
+
+// topicsByModel asks the model what a journal entry is about out of the
+// journal topics, dropping whatever it makes up
+func topicsByModel(ctx context.Context, client ai.Client, promptsService prompts.Service, text string) ([]string, error) {
+	if runes := []rune(text); len(runes) > maxEntryLength {
+		text = string(runes[:maxEntryLength])
+	}
+	prompt := fmt.Sprintf("Topics: %s\n\nEntry:\n%s", strings.Join(journal.Topics, ", "), text)
+
+	rendered, err := promptsService.Render(prompts.KeyJournalTopics, "", prompts.Data{Input: prompt})
+	if err != nil {
+		return nil, err
+	}
+	answer, err := client.Complete(ctx, ai.Request{System: rendered.System, Prompt: rendered.User, JSON: true})
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed struct {
+		Topics []string `json:"topics"`
+	}
+	if err := json.Unmarshal([]byte(answer), &parsed); err != nil {
+		return nil, fmt.Errorf("unexpected topics from the model: %w", err)
+	}
+
+	topics := make([]string, 0, len(parsed.Topics))
+	for _, topic := range parsed.Topics {
+		if slices.Contains(journal.Topics, topic) && !slices.Contains(topics, topic) {
+			topics = append(topics, topic)
+		}
+	}
+	return topics, nil
+}
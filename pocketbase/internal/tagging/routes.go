@@ -0,0 +1,51 @@
+package tagging
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/grammar"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/jobs"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// acceptRequest picks which of the suggested tags to add, all of them when
+// empty
+type acceptRequest struct {
+	Tags []string `json:"tags"`
+}
+
+func RegisterRoutes(g *api.Group, taggingService Service) {
+	g.POST("/grammar/{id}/suggested-tags", "Propose tags for one of your grammar again, in a background job", nil, jobs.Job{}, func(e *core.RequestEvent) error {
+		job, err := taggingService.Suggest(e.Auth.Id, e.Request.PathValue("id"))
+		if err != nil {
+			return e.NotFoundError("", err)
+		}
+		return e.JSON(200, job)
+	})
+
+	g.POST("/grammar/{id}/suggested-tags/accept", "Add the suggested tags, or the given ones of them, to your grammar", acceptRequest{}, grammar.Grammar{}, func(e *core.RequestEvent) error {
+		var body acceptRequest
+		if err := e.BindBody(&body); err != nil {
+			return e.BadRequestError("Invalid request body.", err)
+		}
+
+		g, err := taggingService.Accept(e.Auth.Id, e.Request.PathValue("id"), body.Tags)
+		if errors.Is(err, ErrNoSuggestions) {
+			return e.Error(http.StatusConflict, "This grammar has no tag suggestions.", err)
+		}
+		if err != nil {
+			return e.NotFoundError("", err)
+		}
+		return e.JSON(200, g)
+	})
+
+	g.DELETE("/grammar/{id}/suggested-tags", "Dismiss the tags suggested for your grammar", func(e *core.RequestEvent) error {
+		if err := taggingService.Dismiss(e.Auth.Id, e.Request.PathValue("id")); err != nil {
+			return e.NotFoundError("", err)
+		}
+		return e.NoContent(204)
+	})
+}
@@ -0,0 +1,130 @@
+package tagging
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/grammar"
+)
+
+const (
+	// maxSuggestions keeps suggestions to what a user will actually read
+	maxSuggestions = 5
+
+	// minScore is what a tag needs to be suggested: one keyword hit, or
+	// meaning words shared with a couple of grammar carrying it
+	minScore = 2
+
+	keywordScore = 3
+)
+
+// keywordTags are the library's tags with words that give them away in the
+// English meaning or context of a grammar
+var keywordTags = map[string][]string{
+	"conditional":          {"if", "when", "unless", "provided", "once", "whenever"},
+	"temporal":             {"before", "after", "while", "during", "until", "since", "when", "time"},
+	"causal":               {"because", "since", "reason", "cause", "due", "therefore"},
+	"comparative":          {"than", "more", "less", "compared", "rather", "most"},
+	"giving-receiving":     {"give", "gives", "receive", "receives", "favor", "favour"},
+	"appearance":           {"seem", "seems", "look", "looks", "appear", "appears", "apparently"},
+	"likelihood":           {"probably", "likely", "maybe", "might", "perhaps", "possibly"},
+	"limitation":           {"only", "just", "nothing", "merely"},
+	"explanation":          {"explain", "explanation", "explaining"},
+	"change":               {"become", "becomes", "change", "changes", "turn"},
+	"polite":               {"polite", "politely"},
+	"respectful-honorific": {"honorific", "respectful", "respect"},
+	"humble-honorific":     {"humble", "humbly"},
+	"nominalization":       {"nominalize", "nominalizes", "nominalizer"},
+	"question-form":        {"question", "ask", "asking", "whether"},
+}
+
+// stopWords are too common in meanings to say anything about the grammar
+var stopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "that": true, "this": true,
+	"something": true, "someone": true, "one": true, "not": true, "can": true,
+	"used": true, "use": true, "also": true, "very": true, "are": true, "was": true,
+	"from": true, "into": true, "about": true, "verb": true, "noun": true,
+	"form": true, "expression": true, "sentence": true,
+}
+
+// suggestByRules scores the known tags for a grammar by keywords in its
+// meaning and by the tags of known grammar with a similar meaning
+func suggestByRules(g grammar.Grammar, known []grammar.Grammar, vocabulary []string) []string {
+	words := meaningWords(g)
+	scores := map[string]int{}
+
+	for tag, keywords := range keywordTags {
+		for _, keyword := range keywords {
+			if words[keyword] {
+				scores[tag] += keywordScore
+				break
+			}
+		}
+	}
+
+	for _, other := range known {
+		if other.Id == g.Id || len(other.Tags) == 0 {
+			continue
+		}
+		shared := 0
+		for word := range meaningWords(other) {
+			if words[word] {
+				shared++
+			}
+		}
+		for _, tag := range other.Tags {
+			scores[tag] += shared
+		}
+	}
+
+	return topTags(scores, vocabulary)
+}
+
+// topTags keeps the best scoring tags of the vocabulary, best first
+func topTags(scores map[string]int, vocabulary []string) []string {
+	tags := make([]string, 0, len(scores))
+	for tag, score := range scores {
+		if score >= minScore && slices.Contains(vocabulary, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	slices.SortFunc(tags, func(a, b string) int {
+		if c := cmp.Compare(scores[b], scores[a]); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	})
+	if len(tags) > maxSuggestions {
+		tags = tags[:maxSuggestions]
+	}
+	return tags
+}
+
+func meaningWords(g grammar.Grammar) map[string]bool {
+	words := map[string]bool{}
+	fields := strings.FieldsFunc(strings.ToLower(g.Meaning+" "+g.Context), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	for _, word := range fields {
+		if len([]rune(word)) > 1 && !stopWords[word] {
+			words[word] = true
+		}
+	}
+	return words
+}
+
+// vocabularyOf is every tag in use by the given grammar, sorted
+func vocabularyOf(items []grammar.Grammar) []string {
+	var tags []string
+	for _, item := range items {
+		for _, tag := range item.Tags {
+			if !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	slices.Sort(tags)
+	return tags
+}
@@ -0,0 +1,219 @@
+package tagging
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/ai"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/grammar"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/jobs"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/journal"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/prompts"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+const (
+	JobKindSuggestions = "tag_suggestions"
+	JobKindTopics      = "journal_topics"
+)
+
+var ErrNoSuggestions = errors.New("grammar has no tag suggestions")
+
+// SuggestionResult is the result of a tag suggestion job
+type SuggestionResult struct {
+	Grammar string   `json:"grammar"`
+	Tags    []string `json:"tags"`
+}
+
+type Service interface {
+	// Suggest proposes tags for one of the user's grammar in a background job
+	Suggest(userId string, grammarId string) (jobs.Job, error)
+
+	// Accept adds the suggested tags, or only the given ones of them, to the
+	// grammar and clears its suggestions
+	Accept(userId string, grammarId string, tags []string) (grammar.Grammar, error)
+
+	// Dismiss clears the suggestions of the grammar, leaving its tags be
+	Dismiss(userId string, grammarId string) error
+
+	// ExtractTopics has the model tag one of the user's journal entries with
+	// its topics in a background job, in place of the keyword extracted ones.
+	// Without a model it does nothing, returning a zero job.
+	ExtractTopics(userId string, entryId string) (jobs.Job, error)
+}
+
+type service struct {
+	app            core.App
+	jobsService    jobs.Service
+	grammarService grammar.Service
+	promptsService prompts.Service
+
+	// nil when AI features aren't configured, leaving the rules alone
+	client ai.Client
+}
+
+func NewService(app core.App, jobsService jobs.Service, grammarService grammar.Service, promptsService prompts.Service, client ai.Client) Service {
+	return &service{app: app, jobsService: jobsService, grammarService: grammarService, promptsService: promptsService, client: client}
+}
+
+func (s *service) Suggest(userId string, grammarId string) (jobs.Job, error) {
+	if _, err := s.findOwned(userId, grammarId); err != nil {
+		return jobs.Job{}, err
+	}
+
+	return s.jobsService.Enqueue(userId, JobKindSuggestions, func(ctx context.Context, jobId string, progress jobs.Progress) (any, error) {
+		rec, err := s.findOwned(userId, grammarId)
+		if err != nil {
+			return nil, err
+		}
+		g := grammar.FromRecord(rec)
+
+		known, err := s.knownGrammar(userId, g.Language)
+		if err != nil {
+			return nil, err
+		}
+		vocabulary := vocabularyOf(known)
+
+		tags := suggestByRules(g, known, vocabulary)
+		if s.client != nil && len(vocabulary) > 0 {
+			progress.Report(50, "Asking the model")
+			ctx = ai.WithSubject(ctx, ai.Subject{User: userId, Collection: "grammar", Record: grammarId})
+			picked, err := suggestByModel(ctx, s.client, s.promptsService, g, vocabulary)
+			if err != nil {
+				// the rules still make for suggestions
+				s.app.Logger().Warn("Failed to suggest tags with the model", "grammar", g.Id, "error", err)
+			}
+			tags = merge(picked, tags)
+		}
+		if len(tags) > maxSuggestions {
+			tags = tags[:maxSuggestions]
+		}
+
+		// the user may have tagged it themselves in the meantime
+		rec, err = s.findOwned(userId, grammarId)
+		if err != nil {
+			return nil, err
+		}
+		if len(grammar.FromRecord(rec).Tags) > 0 {
+			tags = []string{}
+		}
+		rec.Set("suggested_tags", tags)
+		if err := s.app.Save(rec); err != nil {
+			return nil, err
+		}
+		return SuggestionResult{Grammar: g.Id, Tags: tags}, nil
+	})
+}
+
+func (s *service) Accept(userId string, grammarId string, tags []string) (grammar.Grammar, error) {
+	rec, err := s.findOwned(userId, grammarId)
+	if err != nil {
+		return grammar.Grammar{}, err
+	}
+	g := grammar.FromRecord(rec)
+	if len(g.SuggestedTags) == 0 {
+		return grammar.Grammar{}, ErrNoSuggestions
+	}
+
+	accepted := g.SuggestedTags
+	if len(tags) > 0 {
+		accepted = slices.DeleteFunc(slices.Clone(tags), func(tag string) bool {
+			return !slices.Contains(g.SuggestedTags, tag)
+		})
+	}
+
+	rec.Set("tags", merge(g.Tags, accepted))
+	rec.Set("suggested_tags", []string{})
+	if err := s.app.Save(rec); err != nil {
+		return grammar.Grammar{}, err
+	}
+	return grammar.FromRecord(rec), nil
+}
+
+func (s *service) Dismiss(userId string, grammarId string) error {
+	rec, err := s.findOwned(userId, grammarId)
+	if err != nil {
+		return err
+	}
+	rec.Set("suggested_tags", []string{})
+	return s.app.Save(rec)
+}
+
+func (s *service) ExtractTopics(userId string, entryId string) (jobs.Job, error) {
+	if s.client == nil {
+		return jobs.Job{}, nil
+	}
+
+	return s.jobsService.Enqueue(userId, JobKindTopics, func(ctx context.Context, jobId string, progress jobs.Progress) (any, error) {
+		rec, err := s.findEntry(userId, entryId)
+		if err != nil {
+			return nil, err
+		}
+		text := journal.Text(rec)
+
+		ctx = ai.WithSubject(ctx, ai.Subject{User: userId, Collection: "journal_entry", Record: entryId})
+		topics, err := topicsByModel(ctx, s.client, s.promptsService, text)
+		if err != nil {
+			return nil, err
+		}
+
+		// leave the entry be if it was edited while the model was reading it
+		rec, err = s.findEntry(userId, entryId)
+		if err != nil {
+			return nil, err
+		}
+		if len(topics) == 0 || journal.Text(rec) != text {
+			return journal.FromRecord(rec).Topics, nil
+		}
+		rec.Set("topics", topics)
+		if err := s.app.Save(rec); err != nil {
+			return nil, err
+		}
+		return topics, nil
+	})
+}
+
+// knownGrammar is the grammar whose tags suggestions are drawn from, the
+// library's and the user's own, in the language of the grammar when it has one
+func (s *service) knownGrammar(userId string, language string) ([]grammar.Grammar, error) {
+	library, err := s.grammarService.Library()
+	if err != nil {
+		return nil, err
+	}
+	owned, err := s.grammarService.Owned(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	known := append(library, owned...)
+	if language != "" {
+		known = slices.DeleteFunc(known, func(g grammar.Grammar) bool {
+			return g.Language != "" && g.Language != language
+		})
+	}
+	return known, nil
+}
+
+func (s *service) findOwned(userId string, grammarId string) (*core.Record, error) {
+	return s.app.FindFirstRecordByFilter("grammar", "id = {:id} && user = {:user}", map[string]any{"id": grammarId, "user": userId})
+}
+
+func (s *service) findEntry(userId string, entryId string) (*core.Record, error) {
+	return s.app.FindFirstRecordByFilter("journal_entry", "id = {:id} && user = {:user}", map[string]any{"id": entryId, "user": userId})
+}
+
+// merge appends the tags of b missing from a
+func merge(a []string, b []string) []string {
+	merged := slices.Clone(a)
+	if merged == nil {
+		merged = []string{}
+	}
+	for _, tag := range b {
+		if !slices.Contains(merged, tag) {
+			merged = append(merged, tag)
+		}
+	}
+	return merged
+}
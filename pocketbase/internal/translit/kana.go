@@ -0,0 +1,124 @@
+package translit
+
+import (
+	"strings"
+)
+
+// syllables romanizes single hiragana, modified Hepburn but spelling を as
+// "wo" like IMEs do
+var syllables = map[rune]string{
+	'あ': "a", 'い': "i", 'う': "u", 'え': "e", 'お': "o",
+	'か': "ka", 'き': "ki", 'く': "ku", 'け': "ke", 'こ': "ko",
+	'が': "ga", 'ぎ': "gi", 'ぐ': "gu", 'げ': "ge", 'ご': "go",
+	'さ': "sa", 'し': "shi", 'す': "su", 'せ': "se", 'そ': "so",
+	'ざ': "za", 'じ': "ji", 'ず': "zu", 'ぜ': "ze", 'ぞ': "zo",
+	'た': "ta", 'ち': "chi", 'つ': "tsu", 'て': "te", 'と': "to",
+	'だ': "da", 'ぢ': "ji", 'づ': "zu", 'で': "de", 'ど': "do",
+	'な': "na", 'に': "ni", 'ぬ': "nu", 'ね': "ne", 'の': "no",
+	'は': "ha", 'ひ': "hi", 'ふ': "fu", 'へ': "he", 'ほ': "ho",
+	'ば': "ba", 'び': "bi", 'ぶ': "bu", 'べ': "be", 'ぼ': "bo",
+	'ぱ': "pa", 'ぴ': "pi", 'ぷ': "pu", 'ぺ': "pe", 'ぽ': "po",
+	'ま': "ma", 'み': "mi", 'む': "mu", 'め': "me", 'も': "mo",
+	'や': "ya", 'ゆ': "yu", 'よ': "yo",
+	'ら': "ra", 'り': "ri", 'る': "ru", 'れ': "re", 'ろ': "ro",
+	'わ': "wa", 'ゐ': "i", 'ゑ': "e", 'を': "wo", 'ん': "n",
+	'ゔ': "vu",
+	'ぁ': "a", 'ぃ': "i", 'ぅ': "u", 'ぇ': "e", 'ぉ': "o",
+	'ゃ': "ya", 'ゅ': "yu", 'ょ': "yo", 'ゎ': "wa",
+	'〜': "~", '～': "~",
+	'、': ", ", '。': ". ", '・': " ", '「': "\"", '」': "\"", '　': " ",
+}
+
+// compounds romanizes a kana followed by a small kana, which together make
+// one syllable
+var compounds = map[string]string{
+	"しゃ": "sha", "しゅ": "shu", "しょ": "sho", "しぇ": "she",
+	"ちゃ": "cha", "ちゅ": "chu", "ちょ": "cho", "ちぇ": "che",
+	"じゃ": "ja", "じゅ": "ju", "じょ": "jo", "じぇ": "je",
+	"ぢゃ": "ja", "ぢゅ": "ju", "ぢょ": "jo",
+	"ふぁ": "fa", "ふぃ": "fi", "ふぇ": "fe", "ふぉ": "fo",
+	"てぃ": "ti", "でぃ": "di", "とぅ": "tu", "どぅ": "du",
+	"うぃ": "wi", "うぇ": "we", "うぉ": "wo",
+	"ゔぁ": "va", "ゔぃ": "vi", "ゔぇ": "ve", "ゔぉ": "vo",
+	"つぁ": "tsa", "つぃ": "tsi", "つぇ": "tse", "つぉ": "tso",
+}
+
+// katakanaOffset turns katakana into the matching hiragana
+const katakanaOffset = 'ア' - 'あ'
+
+func toHiragana(r rune) rune {
+	if r >= 'ァ' && r <= 'ヶ' {
+		return r - katakanaOffset
+	}
+	return r
+}
+
+func isSmall(r rune) bool {
+	return strings.ContainsRune("ぁぃぅぇぉゃゅょゎ", r)
+}
+
+// RomanizeKana writes hiragana and katakana in romaji, leaving kanji and
+// anything else as is. Long vowel marks repeat the vowel before them, so
+// ラーメン is "raamen".
+func RomanizeKana(text string) string {
+	runes := []rune(text)
+	for i, r := range runes {
+		runes[i] = toHiragana(r)
+	}
+
+	var out strings.Builder
+	geminate := false
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		var syllable string
+		switch {
+		case r == 'っ':
+			geminate = true
+			continue
+		case r == 'ー':
+			syllable = lastVowel(out.String())
+		case i+1 < len(runes) && isSmall(runes[i+1]) && compounds[string(runes[i:i+2])] != "":
+			syllable = compounds[string(runes[i:i+2])]
+			i++
+		case i+1 < len(runes) && strings.ContainsRune("ゃゅょ", runes[i+1]) && strings.HasSuffix(syllables[r], "i") && len(syllables[r]) > 1:
+			// きゃ is kya: the consonant of き and the small kana
+			syllable = strings.TrimSuffix(syllables[r], "i") + syllables[runes[i+1]]
+			i++
+		case r == 'ん':
+			syllable = "n"
+			// ん before a vowel or y is written n' so it reads apart
+			if i+1 < len(runes) {
+				if next := syllables[runes[i+1]]; next != "" && strings.ContainsRune("aiueoy", rune(next[0])) {
+					syllable = "n'"
+				}
+			}
+		default:
+			var ok bool
+			if syllable, ok = syllables[r]; !ok {
+				syllable = string(r)
+			}
+		}
+
+		if geminate {
+			geminate = false
+			// っ doubles the consonant after it, and is a t before ch
+			if strings.HasPrefix(syllable, "ch") {
+				out.WriteByte('t')
+			} else if syllable != "" && strings.ContainsRune("bcdfghjkmpqrstvwz", rune(syllable[0])) {
+				out.WriteByte(syllable[0])
+			}
+		}
+		out.WriteString(syllable)
+	}
+	return strings.TrimSpace(out.String())
+}
+
+func lastVowel(romanized string) string {
+	for i := len(romanized) - 1; i >= 0; i-- {
+		if strings.ContainsRune("aiueo", rune(romanized[i])) {
+			return romanized[i : i+1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,36 @@
+package translit
+
+import "testing"
+
+func TestRomanizeKana(t *testing.T) {
+	tests := []struct {
+		name string
+		kana string
+		want string
+	}{
+		{"plain", "すし", "sushi"},
+		{"long vowels spelled out", "とうきょう", "toukyou"},
+		{"long vowel marks", "ラーメン", "raamen"},
+		{"long vowel marks after small kana", "パーティー", "paatii"},
+		{"sokuon", "がっこう", "gakkou"},
+		{"sokuon before ch", "まっちゃ", "matcha"},
+		{"sokuon in a compound", "ちょっと", "chotto"},
+		{"trailing sokuon", "あっ", "a"},
+		{"ん before a consonant", "しんぶん", "shinbun"},
+		{"ん before a vowel", "きんえん", "kin'en"},
+		{"ん before y", "こんや", "kon'ya"},
+		{"compounds", "しゃしん", "shashin"},
+		{"katakana", "カタカナ", "katakana"},
+		{"katakana compounds", "ファン", "fan"},
+		{"を", "ほんをよむ", "honwoyomu"},
+		{"kanji kept", "日本ご", "日本go"},
+		{"punctuation", "はい。", "hai."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RomanizeKana(tt.kana); got != tt.want {
+				t.Errorf("RomanizeKana(%q) = %q, want %q", tt.kana, got, tt.want)
+			}
+		})
+	}
+}
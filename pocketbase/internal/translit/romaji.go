@@ -0,0 +1,114 @@
+package translit
+
+import (
+	"strings"
+)
+
+// romaji maps what IMEs take as input to the hiragana it becomes, up to
+// four letters long
+var romaji = map[string]string{
+	"a": "あ", "i": "い", "u": "う", "e": "え", "o": "お",
+	"ka": "か", "ki": "き", "ku": "く", "ke": "け", "ko": "こ",
+	"ga": "が", "gi": "ぎ", "gu": "ぐ", "ge": "げ", "go": "ご",
+	"sa": "さ", "si": "し", "shi": "し", "su": "す", "se": "せ", "so": "そ",
+	"za": "ざ", "zi": "じ", "ji": "じ", "zu": "ず", "ze": "ぜ", "zo": "ぞ",
+	"ta": "た", "ti": "ち", "chi": "ち", "tu": "つ", "tsu": "つ", "te": "て", "to": "と",
+	"da": "だ", "di": "ぢ", "du": "づ", "de": "で", "do": "ど",
+	"na": "な", "ni": "に", "nu": "ぬ", "ne": "ね", "no": "の",
+	"ha": "は", "hi": "ひ", "hu": "ふ", "fu": "ふ", "he": "へ", "ho": "ほ",
+	"ba": "ば", "bi": "び", "bu": "ぶ", "be": "べ", "bo": "ぼ",
+	"pa": "ぱ", "pi": "ぴ", "pu": "ぷ", "pe": "ぺ", "po": "ぽ",
+	"ma": "ま", "mi": "み", "mu": "む", "me": "め", "mo": "も",
+	"ya": "や", "yu": "ゆ", "yo": "よ",
+	"ra": "ら", "ri": "り", "ru": "る", "re": "れ", "ro": "ろ",
+	"la": "ら", "li": "り", "lu": "る", "le": "れ", "lo": "ろ",
+	"wa": "わ", "wo": "を", "nn": "ん", "n'": "ん", "vu": "ゔ",
+	"kya": "きゃ", "kyu": "きゅ", "kyo": "きょ",
+	"gya": "ぎゃ", "gyu": "ぎゅ", "gyo": "ぎょ",
+	"sya": "しゃ", "syu": "しゅ", "syo": "しょ", "sha": "しゃ", "shu": "しゅ", "sho": "しょ", "she": "しぇ",
+	"ja": "じゃ", "ju": "じゅ", "jo": "じょ", "je": "じぇ", "zya": "じゃ", "zyu": "じゅ", "zyo": "じょ", "jya": "じゃ", "jyu": "じゅ", "jyo": "じょ",
+	"tya": "ちゃ", "tyu": "ちゅ", "tyo": "ちょ", "cha": "ちゃ", "chu": "ちゅ", "cho": "ちょ", "che": "ちぇ",
+	"nya": "にゃ", "nyu": "にゅ", "nyo": "にょ",
+	"hya": "ひゃ", "hyu": "ひゅ", "hyo": "ひょ",
+	"bya": "びゃ", "byu": "びゅ", "byo": "びょ",
+	"pya": "ぴゃ", "pyu": "ぴゅ", "pyo": "ぴょ",
+	"mya": "みゃ", "myu": "みゅ", "myo": "みょ",
+	"rya": "りゃ", "ryu": "りゅ", "ryo": "りょ",
+	"fa": "ふぁ", "fi": "ふぃ", "fe": "ふぇ", "fo": "ふぉ",
+	"thi": "てぃ", "dhi": "でぃ", "wi": "うぃ", "we": "うぇ",
+	"va": "ゔぁ", "vi": "ゔぃ", "ve": "ゔぇ", "vo": "ゔぉ",
+	"xa": "ぁ", "xi": "ぃ", "xu": "ぅ", "xe": "ぇ", "xo": "ぉ",
+	"xya": "ゃ", "xyu": "ゅ", "xyo": "ょ", "xtu": "っ", "xtsu": "っ",
+	"-": "ー", "~": "〜",
+}
+
+const maxRomajiLength = 4
+
+func isConsonant(c byte) bool {
+	return strings.IndexByte("bcdfghjklmpqrstvwxyz", c) >= 0
+}
+
+// ToKana converts romaji the way an IME would, into hiragana. Letters that
+// don't make a syllable, and anything that isn't romaji, are kept as typed.
+func ToKana(text string) string {
+	lower := strings.ToLower(text)
+
+	var out strings.Builder
+	for i := 0; i < len(lower); {
+		c := lower[i]
+
+		// a doubled consonant is a small tsu, like in "matte"
+		if i+1 < len(lower) && c == lower[i+1] && isConsonant(c) && c != 'n' {
+			out.WriteString("っ")
+			i++
+			continue
+		}
+		// so is a t before ch, like in "matcha"
+		if strings.HasPrefix(lower[i:], "tch") {
+			out.WriteString("っ")
+			i++
+			continue
+		}
+
+		// "nn" before a vowel is ん and the n of the next syllable, as
+		// people type こんにちは "konnichiha"
+		if strings.HasPrefix(lower[i:], "nn") && i+2 < len(lower) && strings.IndexByte("aiueoy", lower[i+2]) >= 0 {
+			out.WriteString("ん")
+			i++
+			continue
+		}
+
+		matched := false
+		for n := min(maxRomajiLength, len(lower)-i); n > 0; n-- {
+			if kana, ok := romaji[lower[i:i+n]]; ok {
+				out.WriteString(kana)
+				i += n
+				matched = true
+				break
+			}
+		}
+		if matched {
+			continue
+		}
+
+		// n alone is ん when no vowel or y makes a syllable of it
+		if c == 'n' {
+			out.WriteString("ん")
+		} else {
+			out.WriteByte(c)
+		}
+		i++
+	}
+	return out.String()
+}
+
+// ToKatakana converts hiragana into katakana, leaving anything else as is
+func ToKatakana(text string) string {
+	runes := []rune(text)
+	for i, r := range runes {
+		if r >= 'ぁ' && r <= 'ゖ' {
+			runes[i] = r + katakanaOffset
+		}
+	}
+	return string(runes)
+}
@@ -0,0 +1,51 @@
+package translit
+
+import "testing"
+
+func TestToKana(t *testing.T) {
+	tests := []struct {
+		name   string
+		romaji string
+		want   string
+	}{
+		{"plain", "sushi", "すし"},
+		{"long vowels spelled out", "toukyou", "とうきょう"},
+		{"long vowel marks", "ra-men", "らーめん"},
+		{"sokuon", "gakkou", "がっこう"},
+		{"sokuon before ch", "matcha", "まっちゃ"},
+		{"ん before a consonant", "shinbun", "しんぶん"},
+		{"ん spelled nn before a consonant", "konnnichiha", "こんにちは"},
+		{"nn before a vowel", "konnichiha", "こんにちは"},
+		{"ん before a vowel", "kin'en", "きんえん"},
+		{"ん before j", "kanji", "かんじ"},
+		{"trailing ん", "hon", "ほん"},
+		{"capitals", "Tokyo", "ときょ"},
+		{"other spellings", "tuduku", "つづく"},
+		{"small kana", "xtu", "っ"},
+		{"not romaji kept", "abc 123", "あbc 123"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ToKana(tt.romaji); got != tt.want {
+				t.Errorf("ToKana(%q) = %q, want %q", tt.romaji, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToKatakana(t *testing.T) {
+	tests := []struct {
+		hiragana string
+		want     string
+	}{
+		{"らーめん", "ラーメン"},
+		{"ぱーてぃー", "パーティー"},
+		{"がっこう", "ガッコウ"},
+		{"日本ご", "日本ゴ"},
+	}
+	for _, tt := range tests {
+		if got := ToKatakana(tt.hiragana); got != tt.want {
+			t.Errorf("ToKatakana(%q) = %q, want %q", tt.hiragana, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package translit
+
+import (
+	"unicode/utf8"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// maxTextLength bounds what one request transliterates
+const maxTextLength = 5000
+
+type transliterateResponse struct {
+	Language  string `json:"language"`
+	Text      string `json:"text"`
+	Romanized string `json:"romanized"`
+}
+
+func RegisterRoutes(g *api.Group) {
+	g.GET("/transliterate", "Romanize ?text= of a ?language= by name, e.g. Japanese kana to romaji", transliterateResponse{}, func(e *core.RequestEvent) error {
+		query := e.Request.URL.Query()
+		language, text := query.Get("language"), query.Get("text")
+
+		errs := validation.Errors{}
+		if !Supports(language) {
+			errs["language"] = validation.NewError("validation_invalid_value", "Invalid value.")
+		}
+		if text == "" {
+			errs["text"] = validation.NewError("validation_required", "Cannot be blank.")
+		} else if utf8.RuneCountInString(text) > maxTextLength {
+			errs["text"] = validation.NewError("validation_length_too_long", "The length must be no more than {{.max}}.").
+				SetParams(map[string]any{"max": maxTextLength})
+		}
+		if len(errs) > 0 {
+			return e.BadRequestError("Invalid transliteration request.", errs)
+		}
+
+		return e.JSON(200, transliterateResponse{Language: language, Text: text, Romanized: Romanize(language, text)})
+	})
+}
@@ -0,0 +1,67 @@
+package translit
+
+import (
+	"slices"
+	"sync"
+)
+
+// Transliterator writes text of one language in the latin alphabet, so it can
+// be searched and read without the language's own script
+type Transliterator interface {
+	Romanize(text string) string
+}
+
+// TransliteratorFunc adapts a function to a Transliterator
+type TransliteratorFunc func(text string) string
+
+func (f TransliteratorFunc) Romanize(text string) string {
+	return f(text)
+}
+
+var (
+	mu sync.RWMutex
+
+	// registry is keyed by language name, as in the languages collection
+	registry = map[string]Transliterator{
+		"Japanese": TransliteratorFunc(RomanizeKana),
+	}
+)
+
+// Register adds or replaces the transliterator of a language
+func Register(language string, t Transliterator) {
+	mu.Lock()
+	defer mu.Unlock()
+	registry[language] = t
+}
+
+// Supports reports whether text of a language can be romanized
+func Supports(language string) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	_, ok := registry[language]
+	return ok
+}
+
+// Languages lists the languages with a transliterator
+func Languages() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	languages := make([]string, 0, len(registry))
+	for language := range registry {
+		languages = append(languages, language)
+	}
+	slices.Sort(languages)
+	return languages
+}
+
+// Romanize transliterates text of a language, returning it unchanged when the
+// language has no transliterator
+func Romanize(language string, text string) string {
+	mu.RLock()
+	t, ok := registry[language]
+	mu.RUnlock()
+	if !ok {
+		return text
+	}
+	return t.Romanize(text)
+}
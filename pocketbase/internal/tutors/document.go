@@ -0,0 +1,165 @@
+package tutors
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/i18n"
+)
+
+// The document marks what annotations refer to with lines that survive being
+// pasted into an editor or a chat and back
+var (
+	entryMarker    = regexp.MustCompile(`^<!--\s*entry\s+(\S+)\s*-->$`)
+	sentenceLine   = regexp.MustCompile(`^(\d+)\.\s`)
+	questionLine   = regexp.MustCompile(`^Q(\d+)\.\s`)
+	answerLine     = regexp.MustCompile(`^A:\s?(.*)$`)
+	annotationLine = regexp.MustCompile(`^>\s?(.*)$`)
+)
+
+// sentenceEnds end a sentence, closers right after one still belong to it
+const (
+	sentenceEnds = "。！？!?"
+	closers      = "」』）)\"'”’"
+)
+
+// renderDocument writes a session as markdown a tutor can annotate in any
+// editor, headings in the learner's locale
+func renderDocument(session Session, learner string, date string, locale string) []byte {
+	var md bytes.Buffer
+
+	fmt.Fprintf(&md, "# %s %s\n\n", i18n.T(locale, "Tutoring session"), date)
+	if learner != "" {
+		fmt.Fprintf(&md, "%s: %s\n\n", i18n.T(locale, "Learner"), learner)
+	}
+	fmt.Fprintf(&md, "%s\n", i18n.T(locale, "Write the corrected sentence on a line starting with > right under a sentence, and any comment on more > lines. Answer questions after A:."))
+
+	if len(session.Questions) > 0 {
+		fmt.Fprintf(&md, "\n## %s\n", i18n.T(locale, "Questions"))
+		for i, q := range session.Questions {
+			fmt.Fprintf(&md, "\nQ%d. %s\n", i+1, oneLine(q.Text))
+			fmt.Fprintf(&md, "A: %s\n", oneLine(q.Answer))
+		}
+	}
+
+	for _, entry := range session.Entries {
+		title := entry.Title
+		if title == "" {
+			title = entry.Date
+		}
+		fmt.Fprintf(&md, "\n## %s (%s)\n<!-- entry %s -->\n\n", oneLine(title), entry.Date, entry.Id)
+		for i, sentence := range entry.Sentences {
+			fmt.Fprintf(&md, "%d. %s\n", i+1, sentence)
+		}
+	}
+
+	return md.Bytes()
+}
+
+// parseDocument reads the annotations a tutor wrote on a rendered session
+func parseDocument(data []byte) Annotations {
+	var annotations Annotations
+
+	var (
+		entry   string
+		current *Annotation
+		answer  *Answer
+
+		// the first > line under a sentence is its correction, even left
+		// empty for a comment alone
+		quoted int
+	)
+	flush := func() {
+		if current != nil && (current.Corrected != "" || current.Comment != "") {
+			annotations.Corrections = append(annotations.Corrections, *current)
+		}
+		if answer != nil && answer.Answer != "" {
+			annotations.Answers = append(annotations.Answers, *answer)
+		}
+		current, answer = nil, nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case strings.HasPrefix(line, "#"):
+			flush()
+			entry = ""
+		case entryMarker.MatchString(line):
+			flush()
+			entry = entryMarker.FindStringSubmatch(line)[1]
+		case questionLine.MatchString(line):
+			flush()
+			n, _ := strconv.Atoi(questionLine.FindStringSubmatch(line)[1])
+			answer = &Answer{Question: n}
+		case answer != nil && answerLine.MatchString(line):
+			answer.Answer = strings.TrimSpace(answerLine.FindStringSubmatch(line)[1])
+		case answer != nil && line != "" && answer.Answer != "":
+			// answers can go on over more lines
+			answer.Answer += "\n" + line
+		case entry != "" && sentenceLine.MatchString(line):
+			flush()
+			n, _ := strconv.Atoi(sentenceLine.FindStringSubmatch(line)[1])
+			current, quoted = &Annotation{Entry: entry, Sentence: n}, 0
+		case current != nil && annotationLine.MatchString(line):
+			text := strings.TrimSpace(annotationLine.FindStringSubmatch(line)[1])
+			quoted++
+			switch {
+			case quoted == 1:
+				current.Corrected = text
+			case text == "":
+			case current.Comment == "":
+				current.Comment = text
+			default:
+				current.Comment += "\n" + text
+			}
+		case line == "" && answer != nil:
+			flush()
+		}
+	}
+	flush()
+
+	return annotations
+}
+
+// splitSentences splits entry content into sentences, ending them at
+// Japanese and Western sentence punctuation and at line breaks
+func splitSentences(content string) []string {
+	var sentences []string
+	for _, line := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		runes := []rune(line)
+		start := 0
+		for i := 0; i < len(runes); i++ {
+			r := runes[i]
+			ends := strings.ContainsRune(sentenceEnds, r) ||
+				(r == '.' && (i+1 == len(runes) || unicode.IsSpace(runes[i+1])))
+			if !ends {
+				continue
+			}
+			for i+1 < len(runes) && (strings.ContainsRune(closers, runes[i+1]) || strings.ContainsRune(sentenceEnds, runes[i+1])) {
+				i++
+			}
+			if s := strings.TrimSpace(string(runes[start : i+1])); s != "" {
+				sentences = append(sentences, s)
+			}
+			start = i + 1
+		}
+		if s := strings.TrimSpace(string(runes[start:])); s != "" {
+			sentences = append(sentences, s)
+		}
+	}
+	return sentences
+}
+
+// oneLine keeps text on one line so it can't break the document's structure
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,24 @@
+package tutors
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// BindHooks signs corrections written by a tutor with their name, unless they
+// gave another one
+func BindHooks(app core.App) {
+	app.OnRecordCreate("corrections").BindFunc(func(e *core.RecordEvent) error {
+		author := e.Record.GetString("author")
+		if author == "" || e.Record.GetString("corrector") != "" {
+			return e.Next()
+		}
+
+		user, err := e.App.FindRecordById("users", author)
+		if err != nil {
+			// the relation is validated after this, an unknown author fails there
+			return e.Next()
+		}
+		e.Record.Set("corrector", user.GetString("name"))
+		return e.Next()
+	})
+}
@@ -0,0 +1,195 @@
+package tutors
+
+import (
+	"database/sql"
+	"errors"
+	"io"
+	"mime"
+	"strings"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// maxDocumentSize bounds annotated documents uploaded back
+const maxDocumentSize = 5 << 20
+
+// sharedSession is a session as its share link shows it, without the
+// learner's ids
+type sharedSession struct {
+	Learner   string         `json:"learner"`
+	Entries   []SessionEntry `json:"entries"`
+	Questions []Question     `json:"questions"`
+	Created   string         `json:"created"`
+}
+
+func RegisterRoutes(g *api.Group, tutorsService Service) {
+	g.GET("/tutor-sessions", "The user's tutor sessions, newest first", []Session{}, func(e *core.RequestEvent) error {
+		sessions, err := tutorsService.Sessions(e.Auth.Id)
+		if err != nil {
+			return e.InternalServerError("Failed to load tutor sessions.", err)
+		}
+		return e.JSON(200, sessions)
+	})
+
+	g.POST("/tutor-sessions", "Bundle recent entries and open questions for a tutoring session, shareable with the tutor by link", SessionRequest{}, Session{}, func(e *core.RequestEvent) error {
+		var req SessionRequest
+		if err := e.BindBody(&req); err != nil {
+			return e.BadRequestError("Invalid request body.", err)
+		}
+		if err := req.Validate(); err != nil {
+			return e.BadRequestError("Invalid tutor session.", err)
+		}
+
+		session, err := tutorsService.CreateSession(e.Auth.Id, req)
+		switch {
+		case errors.Is(err, ErrNotTutor):
+			return e.BadRequestError("Invalid tutor session.", validation.Errors{
+				"tutor": validation.NewError("validation_invalid_value", "Not one of your tutors."),
+			})
+		case errors.Is(err, ErrEmpty):
+			return e.BadRequestError("There are no entries or questions to bring to the session.", err)
+		case errors.Is(err, sql.ErrNoRows):
+			return e.NotFoundError("", err)
+		case err != nil:
+			return e.InternalServerError("Failed to create the tutor session.", err)
+		}
+		return e.JSON(200, session)
+	})
+
+	g.GET("/tutor-sessions/{id}/document", "Download a tutor session as a markdown document to annotate", nil, func(e *core.RequestEvent) error {
+		session, err := tutorsService.Find(e.Auth.Id, e.Request.PathValue("id"))
+		if err != nil {
+			return e.NotFoundError("", err)
+		}
+		return serveDocument(e, tutorsService, session)
+	})
+
+	// either JSON annotations, or multipart/form-data with the annotated
+	// document as "file"
+	g.POST("/tutor-sessions/{id}/annotations", "Import the tutor's annotations of a session as corrections and answers", Annotations{}, Session{}, func(e *core.RequestEvent) error {
+		session, err := tutorsService.Find(e.Auth.Id, e.Request.PathValue("id"))
+		if err != nil {
+			return e.NotFoundError("", err)
+		}
+
+		annotations, err := readAnnotations(e, tutorsService)
+		if err != nil {
+			return err
+		}
+		session, err = annotate(e, tutorsService, session, annotations)
+		if err != nil {
+			return err
+		}
+		return e.JSON(200, session)
+	})
+}
+
+// RegisterPublicRoutes adds the routes behind a session's share link, which
+// let the tutor read and annotate it without an account
+func RegisterPublicRoutes(g *api.Group, tutorsService Service) {
+	g.GET("/tutor-sessions/{token}", "Tutor session shared by link", sharedSession{}, func(e *core.RequestEvent) error {
+		session, err := tutorsService.FindShared(e.Request.PathValue("token"))
+		if err != nil {
+			return e.NotFoundError("", err)
+		}
+		return e.JSON(200, newSharedSession(e.App, session))
+	})
+
+	g.GET("/tutor-sessions/{token}/document", "Tutor session shared by link as a markdown document to annotate", nil, func(e *core.RequestEvent) error {
+		session, err := tutorsService.FindShared(e.Request.PathValue("token"))
+		if err != nil {
+			return e.NotFoundError("", err)
+		}
+		return serveDocument(e, tutorsService, session)
+	})
+
+	g.POST("/tutor-sessions/{token}/annotations", "Annotate a tutor session shared by link, as JSON or the annotated document uploaded as \"file\"", Annotations{}, sharedSession{}, func(e *core.RequestEvent) error {
+		session, err := tutorsService.FindShared(e.Request.PathValue("token"))
+		if err != nil {
+			return e.NotFoundError("", err)
+		}
+
+		annotations, err := readAnnotations(e, tutorsService)
+		if err != nil {
+			return err
+		}
+		session, err = annotate(e, tutorsService, session, annotations)
+		if err != nil {
+			return err
+		}
+		// the tutor gets the session as the link shows it, not the learner's
+		return e.JSON(200, newSharedSession(e.App, session))
+	})
+}
+
+// annotate imports annotations checked against the session, returning the
+// session with its questions answered
+func annotate(e *core.RequestEvent, tutorsService Service, session Session, annotations Annotations) (Session, error) {
+	if err := annotations.Validate(session); err != nil {
+		return Session{}, e.BadRequestError("Invalid annotations.", err)
+	}
+	session, err := tutorsService.Annotate(session, annotations)
+	if err != nil {
+		return Session{}, e.InternalServerError("Failed to import the annotations.", err)
+	}
+	return session, nil
+}
+
+// readAnnotations reads annotations sent as JSON, or written on the document
+// uploaded as "file"
+func readAnnotations(e *core.RequestEvent, tutorsService Service) (Annotations, error) {
+	if !strings.HasPrefix(e.Request.Header.Get("Content-Type"), "multipart/form-data") {
+		var annotations Annotations
+		if err := e.BindBody(&annotations); err != nil {
+			return Annotations{}, e.BadRequestError("Invalid request body.", err)
+		}
+		return annotations, nil
+	}
+
+	files, err := e.FindUploadedFiles("file")
+	if err != nil || len(files) == 0 {
+		return Annotations{}, e.BadRequestError("Upload the annotated document as file.", err)
+	}
+	if files[0].Size > maxDocumentSize {
+		return Annotations{}, e.BadRequestError("The file is too large.", nil)
+	}
+	f, err := files[0].Reader.Open()
+	if err != nil {
+		return Annotations{}, e.BadRequestError("Upload the annotated document as file.", err)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, maxDocumentSize))
+	if err != nil {
+		return Annotations{}, e.BadRequestError("Upload the annotated document as file.", err)
+	}
+	annotations := tutorsService.ParseDocument(data)
+	annotations.Corrector = e.Request.FormValue("corrector")
+	return annotations, nil
+}
+
+func serveDocument(e *core.RequestEvent, tutorsService Service, session Session) error {
+	data, err := tutorsService.Document(session)
+	if err != nil {
+		return e.InternalServerError("Failed to write the tutor session.", err)
+	}
+	name := "tutor-session-" + session.Created.Time().Format("2006-01-02") + ".md"
+	e.Response.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+	return e.Blob(200, "text/markdown; charset=utf-8", data)
+}
+
+func newSharedSession(app core.App, session Session) sharedSession {
+	learner := ""
+	if user, err := app.FindRecordById("users", session.User); err == nil {
+		learner = user.GetString("name")
+	}
+	return sharedSession{
+		Learner:   learner,
+		Entries:   session.Entries,
+		Questions: session.Questions,
+		Created:   session.Created.Time().Format("2006-01-02"),
+	}
+}
@@ -0,0 +1,326 @@
+package tutors
+
+import (
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/i18n"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/journal"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/settings"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/security"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// shareTokenLength is long enough that share links can't be guessed
+const shareTokenLength = 32
+
+var (
+	// ErrNotTutor means a session was asked for someone who isn't one of the
+	// learner's tutors
+	ErrNotTutor = errors.New("not one of the learner's tutors")
+
+	// ErrEmpty means a session would have neither entries nor questions
+	ErrEmpty = errors.New("nothing to bring to the session")
+)
+
+type Service interface {
+	// CreateSession bundles the learner's entries and questions, with those
+	// their last session left unanswered, for a session with a tutor
+	CreateSession(userId string, req SessionRequest) (Session, error)
+
+	// Sessions returns the learner's sessions, newest first
+	Sessions(userId string) ([]Session, error)
+
+	// Find returns one of the learner's sessions
+	Find(userId string, id string) (Session, error)
+
+	// FindShared returns the session a share link was made for, as long as
+	// the link works
+	FindShared(token string) (Session, error)
+
+	// Document renders a session as markdown for the tutor to annotate
+	Document(session Session) ([]byte, error)
+
+	// ParseDocument reads the annotations written on a rendered session
+	ParseDocument(data []byte) Annotations
+
+	// Annotate saves the tutor's annotations as corrections of the session's
+	// entries, in place of those of an earlier import, and answers its
+	// questions. Corrections are signed by the session's tutor when it has
+	// one, otherwise by the annotations' corrector.
+	Annotate(session Session, annotations Annotations) (Session, error)
+}
+
+type service struct {
+	app             core.App
+	journalService  journal.Service
+	settingsService settings.Service
+}
+
+func NewService(app core.App, journalService journal.Service, settingsService settings.Service) Service {
+	return &service{app: app, journalService: journalService, settingsService: settingsService}
+}
+
+func (s *service) CreateSession(userId string, req SessionRequest) (Session, error) {
+	if req.Tutor != "" {
+		_, err := s.app.FindFirstRecordByFilter("tutors", "user = {:user} && tutor = {:tutor}", dbx.Params{"user": userId, "tutor": req.Tutor})
+		if err != nil {
+			return Session{}, ErrNotTutor
+		}
+	}
+
+	loc := s.location(userId)
+	entries, err := s.entries(userId, req)
+	if err != nil {
+		return Session{}, err
+	}
+
+	var questions []Question
+	if last, err := s.app.FindRecordsByFilter("tutor_sessions", "user = {:user}", "-created", 1, 0, dbx.Params{"user": userId}); err != nil {
+		return Session{}, err
+	} else if len(last) > 0 {
+		for _, q := range SessionFromRecord(last[0]).Questions {
+			if q.Answer == "" {
+				questions = append(questions, q)
+			}
+		}
+	}
+	for _, text := range req.Questions {
+		questions = append(questions, Question{Text: text})
+	}
+	questions = cleanQuestions(questions)
+	if len(questions) > MaxQuestions {
+		questions = questions[:MaxQuestions]
+	}
+
+	if len(entries) == 0 && len(questions) == 0 {
+		return Session{}, ErrEmpty
+	}
+
+	sessionEntries := make([]SessionEntry, 0, len(entries))
+	for _, entry := range entries {
+		sessionEntries = append(sessionEntries, SessionEntry{
+			Id:        entry.Id,
+			Title:     entry.Title,
+			Date:      entry.Created.Time().In(loc).Format(time.DateOnly),
+			Sentences: splitSentences(entry.Content),
+		})
+	}
+
+	collection, err := s.app.FindCollectionByNameOrId("tutor_sessions")
+	if err != nil {
+		return Session{}, err
+	}
+	rec := core.NewRecord(collection)
+	rec.Set("user", userId)
+	rec.Set("tutor", req.Tutor)
+	rec.Set("entries", sessionEntries)
+	rec.Set("questions", questions)
+	rec.Set("share_token", security.RandomString(shareTokenLength))
+	if err := s.app.Save(rec); err != nil {
+		return Session{}, err
+	}
+	return SessionFromRecord(rec), nil
+}
+
+// entries loads the entries a session brings, oldest first
+func (s *service) entries(userId string, req SessionRequest) ([]journal.Entry, error) {
+	if len(req.Entries) == 0 {
+		days := req.Days
+		if days == 0 {
+			days = DefaultDays
+		}
+		from, err := types.ParseDateTime(time.Now().AddDate(0, 0, -days))
+		if err != nil {
+			return nil, err
+		}
+		entries, err := s.journalService.EntriesBetween(userId, from, types.DateTime{})
+		if err != nil {
+			return nil, err
+		}
+		if len(entries) > MaxEntries {
+			entries = entries[len(entries)-MaxEntries:]
+		}
+		return entries, nil
+	}
+
+	records, err := s.app.FindRecordsByIds("journal_entry", req.Entries)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]journal.Entry, 0, len(records))
+	for _, rec := range records {
+		if rec.GetString("user") != userId {
+			return nil, sql.ErrNoRows
+		}
+		entries = append(entries, journal.FromRecord(rec))
+	}
+	if len(entries) < len(slices.Compact(slices.Sorted(slices.Values(req.Entries)))) {
+		return nil, sql.ErrNoRows
+	}
+	slices.SortFunc(entries, func(a, b journal.Entry) int {
+		return a.Created.Time().Compare(b.Created.Time())
+	})
+	return entries, nil
+}
+
+func (s *service) Sessions(userId string) ([]Session, error) {
+	records, err := s.app.FindRecordsByFilter("tutor_sessions", "user = {:user}", "-created", 0, 0, dbx.Params{"user": userId})
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]Session, 0, len(records))
+	for _, rec := range records {
+		sessions = append(sessions, SessionFromRecord(rec))
+	}
+	return sessions, nil
+}
+
+func (s *service) Find(userId string, id string) (Session, error) {
+	rec, err := s.app.FindFirstRecordByFilter("tutor_sessions", "id = {:id} && user = {:user}", dbx.Params{"id": id, "user": userId})
+	if err != nil {
+		return Session{}, err
+	}
+	return SessionFromRecord(rec), nil
+}
+
+func (s *service) FindShared(token string) (Session, error) {
+	rec, err := s.app.FindFirstRecordByFilter("tutor_sessions", "share_token != '' && share_token = {:token}", dbx.Params{"token": token})
+	if err != nil {
+		return Session{}, err
+	}
+	session := SessionFromRecord(rec)
+	if !session.shared(time.Now()) {
+		return Session{}, sql.ErrNoRows
+	}
+	return session, nil
+}
+
+func (s *service) Document(session Session) ([]byte, error) {
+	learner, err := s.app.FindRecordById("users", session.User)
+	if err != nil {
+		return nil, err
+	}
+
+	locale := i18n.DefaultLocale
+	if userSettings, err := s.settingsService.ForUser(session.User); err == nil {
+		if l := i18n.Negotiate(userSettings.Locale); l != "" {
+			locale = l
+		}
+	}
+	date := session.Created.Time().In(s.location(session.User)).Format(time.DateOnly)
+
+	return renderDocument(session, learner.GetString("name"), date, locale), nil
+}
+
+func (s *service) ParseDocument(data []byte) Annotations {
+	return parseDocument(data)
+}
+
+func (s *service) Annotate(session Session, annotations Annotations) (Session, error) {
+	corrector, author := strings.TrimSpace(annotations.Corrector), ""
+	if session.Tutor != "" {
+		if tutor, err := s.app.FindRecordById("users", session.Tutor); err == nil {
+			corrector, author = tutor.GetString("name"), tutor.Id
+		}
+	}
+
+	var saved *core.Record
+	err := s.app.RunInTransaction(func(txApp core.App) error {
+		rec, err := txApp.FindRecordById("tutor_sessions", session.Id)
+		if err != nil {
+			return err
+		}
+		session = SessionFromRecord(rec)
+
+		// annotating again replaces what the last import saved
+		_, err = txApp.DB().Delete("corrections", dbx.HashExp{"tutor_session": session.Id}).Execute()
+		if err != nil {
+			return err
+		}
+
+		collection, err := txApp.FindCollectionByNameOrId("corrections")
+		if err != nil {
+			return err
+		}
+		for _, a := range annotations.Corrections {
+			entry, ok := session.entry(a.Entry)
+			if !ok || a.Sentence < 1 || a.Sentence > len(entry.Sentences) {
+				continue
+			}
+			original := entry.Sentences[a.Sentence-1]
+			corrected, comment := strings.TrimSpace(a.Corrected), strings.TrimSpace(a.Comment)
+			if corrected == "" {
+				corrected = original
+			}
+			if corrected == original && comment == "" {
+				continue
+			}
+
+			// entries deleted since the session was made have nothing to
+			// correct anymore
+			if _, err := txApp.FindFirstRecordByFilter("journal_entry", "id = {:id} && user = {:user}", dbx.Params{"id": entry.Id, "user": session.User}); err != nil {
+				continue
+			}
+
+			correction := core.NewRecord(collection)
+			correction.Set("user", session.User)
+			correction.Set("journal_entry", entry.Id)
+			correction.Set("original", truncate(original, maxCorrectedLength))
+			correction.Set("corrected", corrected)
+			correction.Set("comment", comment)
+			correction.Set("corrector", corrector)
+			correction.Set("author", author)
+			correction.Set("tutor_session", session.Id)
+			if err := txApp.Save(correction); err != nil {
+				return err
+			}
+		}
+
+		for _, answer := range annotations.Answers {
+			if answer.Question >= 1 && answer.Question <= len(session.Questions) {
+				session.Questions[answer.Question-1].Answer = strings.TrimSpace(answer.Answer)
+			}
+		}
+		rec.Set("questions", session.Questions)
+		rec.Set("annotated", types.NowDateTime())
+		if err := txApp.Save(rec); err != nil {
+			return err
+		}
+		saved = rec
+		return nil
+	})
+	if err != nil {
+		return Session{}, err
+	}
+	return SessionFromRecord(saved), nil
+}
+
+// location is the learner's time zone, which dates in sessions are in
+func (s *service) location(userId string) *time.Location {
+	userSettings, err := s.settingsService.ForUser(userId)
+	if err != nil {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(userSettings.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// truncate cuts s to at most max runes
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max])
+}
@@ -0,0 +1,216 @@
+package tutors
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+const (
+	// DefaultDays is how far back a session looks for entries when none are
+	// picked
+	DefaultDays = 14
+	MaxDays     = 90
+
+	// MaxEntries bounds the entries of a session, the most recent are kept
+	MaxEntries = 20
+
+	// MaxQuestions bounds the questions of a session, open ones included
+	MaxQuestions = 20
+
+	maxQuestionLength = 500
+
+	// the longest annotations corrections can hold
+	maxCorrectedLength = 2000
+	maxCommentLength   = 5000
+	maxCorrectorLength = 100
+	maxAnswerLength    = 5000
+)
+
+// Share links of a session can be read and annotated for this long
+const shareTTL = 30 * 24 * time.Hour
+
+// Session is recent entries and open questions bundled for a tutor
+type Session struct {
+	Id         string         `json:"id"`
+	User       string         `json:"user"`
+	Tutor      string         `json:"tutor"`
+	Entries    []SessionEntry `json:"entries"`
+	Questions  []Question     `json:"questions"`
+	ShareToken string         `json:"share_token"`
+
+	// Annotated is when the tutor's annotations were last imported, zero
+	// until they are
+	Annotated types.DateTime `json:"annotated"`
+
+	Created types.DateTime `json:"created"`
+	Updated types.DateTime `json:"updated"`
+}
+
+// SessionEntry is an entry as the tutor was shown it, split into numbered
+// sentences annotations refer to
+type SessionEntry struct {
+	Id        string   `json:"id"`
+	Title     string   `json:"title"`
+	Date      string   `json:"date"`
+	Sentences []string `json:"sentences"`
+}
+
+// Question is something the learner wants to ask, open until the tutor
+// answers it
+type Question struct {
+	Text   string `json:"text"`
+	Answer string `json:"answer"`
+}
+
+func SessionFromRecord(rec *core.Record) Session {
+	session := Session{
+		Id:         rec.Id,
+		User:       rec.GetString("user"),
+		Tutor:      rec.GetString("tutor"),
+		Entries:    []SessionEntry{},
+		Questions:  []Question{},
+		ShareToken: rec.GetString("share_token"),
+		Annotated:  rec.GetDateTime("annotated"),
+		Created:    rec.GetDateTime("created"),
+		Updated:    rec.GetDateTime("updated"),
+	}
+	_ = rec.UnmarshalJSONField("entries", &session.Entries)
+	_ = rec.UnmarshalJSONField("questions", &session.Questions)
+	return session
+}
+
+// SessionRequest picks what goes into a session. Entries, when given, are
+// the learner's entries to bring, otherwise those of the last Days are.
+// Questions left unanswered in the learner's last session come along too.
+type SessionRequest struct {
+	Days      int      `json:"days"`
+	Entries   []string `json:"entries"`
+	Questions []string `json:"questions"`
+
+	// Tutor is one of the learner's tutors the session is for, whose name
+	// signs the corrections annotated
+	Tutor string `json:"tutor"`
+}
+
+func (r SessionRequest) Validate() error {
+	errs := validation.Errors{}
+
+	if r.Days < 0 || r.Days > MaxDays {
+		errs["days"] = validation.NewError("validation_out_of_range", "Must be between {{.min}} and {{.max}}.").
+			SetParams(map[string]any{"min": 1, "max": MaxDays})
+	}
+	if len(r.Entries) > MaxEntries {
+		errs["entries"] = validation.NewError("validation_too_many", "Must have at most {{.max}} items.").
+			SetParams(map[string]any{"max": MaxEntries})
+	}
+	if len(r.Questions) > MaxQuestions {
+		errs["questions"] = validation.NewError("validation_too_many", "Must have at most {{.max}} items.").
+			SetParams(map[string]any{"max": MaxQuestions})
+	}
+	for _, q := range r.Questions {
+		if utf8.RuneCountInString(q) > maxQuestionLength {
+			errs["questions"] = validation.NewError("validation_length_too_long", "The length must be no more than {{.max}}.").
+				SetParams(map[string]any{"max": maxQuestionLength})
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Annotations are what the tutor wrote on a session
+type Annotations struct {
+	// Corrector is the tutor's name, for corrections of sessions without an
+	// account to sign them with
+	Corrector   string       `json:"corrector"`
+	Corrections []Annotation `json:"corrections"`
+	Answers     []Answer     `json:"answers"`
+}
+
+// Annotation corrects or comments on the Sentence-th sentence, from 1, of an
+// entry of the session
+type Annotation struct {
+	Entry     string `json:"entry"`
+	Sentence  int    `json:"sentence"`
+	Corrected string `json:"corrected"`
+	Comment   string `json:"comment"`
+}
+
+// Answer answers the Question-th question, from 1, of the session
+type Answer struct {
+	Question int    `json:"question"`
+	Answer   string `json:"answer"`
+}
+
+// Validate checks the annotations refer to what the session has
+func (a Annotations) Validate(session Session) error {
+	errs := validation.Errors{}
+	tooLong := func(max int) error {
+		return validation.NewError("validation_length_too_long", "The length must be no more than {{.max}}.").
+			SetParams(map[string]any{"max": max})
+	}
+
+	if utf8.RuneCountInString(a.Corrector) > maxCorrectorLength {
+		errs["corrector"] = tooLong(maxCorrectorLength)
+	}
+	for _, c := range a.Corrections {
+		entry, ok := session.entry(c.Entry)
+		switch {
+		case !ok || c.Sentence < 1 || c.Sentence > len(entry.Sentences):
+			errs["corrections"] = validation.NewError("validation_invalid_value", "Corrections must be of sentences in the session.")
+		case utf8.RuneCountInString(c.Corrected) > maxCorrectedLength:
+			errs["corrections"] = tooLong(maxCorrectedLength)
+		case utf8.RuneCountInString(c.Comment) > maxCommentLength:
+			errs["corrections"] = tooLong(maxCommentLength)
+		}
+	}
+	for _, answer := range a.Answers {
+		switch {
+		case answer.Question < 1 || answer.Question > len(session.Questions):
+			errs["answers"] = validation.NewError("validation_invalid_value", "Answers must be of questions in the session.")
+		case utf8.RuneCountInString(answer.Answer) > maxAnswerLength:
+			errs["answers"] = tooLong(maxAnswerLength)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (s Session) entry(id string) (SessionEntry, bool) {
+	for _, entry := range s.Entries {
+		if entry.Id == id {
+			return entry, true
+		}
+	}
+	return SessionEntry{}, false
+}
+
+// shared reports whether the session's share link still works
+func (s Session) shared(now time.Time) bool {
+	return s.ShareToken != "" && now.Before(s.Created.Time().Add(shareTTL))
+}
+
+// cleanQuestions trims the questions, dropping empty and repeated ones
+func cleanQuestions(questions []Question) []Question {
+	cleaned := []Question{}
+	seen := map[string]bool{}
+	for _, q := range questions {
+		q.Text = strings.TrimSpace(q.Text)
+		if q.Text == "" || seen[q.Text] {
+			continue
+		}
+		seen[q.Text] = true
+		cleaned = append(cleaned, q)
+	}
+	return cleaned
+}
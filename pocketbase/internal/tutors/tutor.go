@@ -0,0 +1,28 @@
+package tutors
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Tutor lets a user read all of a learner's journal entries, whatever their
+// audience, and correct them when the learner allows it
+type Tutor struct {
+	Id         string         `json:"id"`
+	User       string         `json:"user"`
+	Tutor      string         `json:"tutor"`
+	CanCorrect bool           `json:"can_correct"`
+	Created    types.DateTime `json:"created"`
+	Updated    types.DateTime `json:"updated"`
+}
+
+func FromRecord(rec *core.Record) Tutor {
+	return Tutor{
+		Id:         rec.Id,
+		User:       rec.GetString("user"),
+		Tutor:      rec.GetString("tutor"),
+		CanCorrect: rec.GetBool("can_correct"),
+		Created:    rec.GetDateTime("created"),
+		Updated:    rec.GetDateTime("updated"),
+	}
+}
@@ -0,0 +1,52 @@
+package vocabulary
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// libraryMaxAge is how long clients may reuse the library, which only
+// changes when the instance's content does
+const libraryMaxAge = 5 * time.Minute
+
+func RegisterRoutes(g *api.Group, vocabularyService Service, conditional *api.Conditional) {
+	g.GET("/vocabulary/library", "Every word of the shared library, most frequent first with ?sort=frequency", []Vocabulary{}, func(e *core.RequestEvent) error {
+		if conditional.NotModified(e, libraryMaxAge, api.SharedScope) {
+			return e.NoContent(http.StatusNotModified)
+		}
+
+		library, err := vocabularyService.Library()
+		if err != nil {
+			return e.InternalServerError("Failed to load vocabulary library.", err)
+		}
+		if e.Request.URL.Query().Get("sort") == "frequency" {
+			slices.SortStableFunc(library, ByFrequency)
+		}
+		return conditional.JSON(e, libraryMaxAge, library)
+	})
+	g.GET("/vocabulary", "Search the library and the user's own words a page at a time, with ?q=, ?source= for where they came from, ?mine=true, ?cursor= and ?limit=", SearchResult{}, func(e *core.RequestEvent) error {
+		page, err := api.PageFromRequest(e)
+		if err != nil {
+			return e.BadRequestError("Invalid search request.", err)
+		}
+
+		query := e.Request.URL.Query()
+		req := SearchRequest{
+			Query:  strings.TrimSpace(query.Get("q")),
+			Source: query.Get("source"),
+			Mine:   query.Get("mine") == "true",
+		}
+
+		result, err := vocabularyService.Search(e.Auth.Id, req, page)
+		if err != nil {
+			return e.InternalServerError("Failed to search vocabulary.", err)
+		}
+		return e.JSON(200, result)
+	})
+}
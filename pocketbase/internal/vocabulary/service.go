@@ -0,0 +1,85 @@
+package vocabulary
+
+import (
+	"strings"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+)
+
+type Service interface {
+	// Library returns every word of the shared library
+	Library() ([]Vocabulary, error)
+
+	// Search returns a page of the library and the user's own words matching
+	// the request, by term
+	Search(userId string, req SearchRequest, page api.Page) (SearchResult, error)
+
+	// KnownWords returns the terms of the vocabulary the user studied, their
+	// cards having been reviewed at least once
+	KnownWords(userId string) ([]string, error)
+}
+
+type service struct {
+	app core.App
+}
+
+func NewService(app core.App) Service {
+	return &service{app: app}
+}
+
+func (s *service) Library() ([]Vocabulary, error) {
+	records, err := s.app.FindRecordsByFilter("vocabulary", "user = ''", "", 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	return FromRecords(records), nil
+}
+
+func (s *service) Search(userId string, req SearchRequest, page api.Page) (SearchResult, error) {
+	params := map[string]any{"user": userId}
+	filters := []string{"(user = '' || user = {:user})"}
+	if req.Mine {
+		filters = []string{"user = {:user}"}
+	}
+	if req.Query != "" {
+		params["query"] = req.Query
+		filters = append(filters, "(term ~ {:query} || reading ~ {:query} || meaning ~ {:query})")
+	}
+	if req.Source != "" {
+		params["source"] = req.Source
+		filters = append(filters, "source = {:source}")
+	}
+	if keyset := page.KeysetFilter("term", params); keyset != "" {
+		filters = append(filters, keyset)
+	}
+
+	records, err := s.app.FindRecordsByFilter("vocabulary", strings.Join(filters, " && "), "term,id", page.Limit+1, 0, params)
+	if err != nil {
+		return SearchResult{}, err
+	}
+
+	items := FromRecords(records)
+	next := page.Next(len(items), func(i int) api.Cursor {
+		return api.Cursor{Value: items[i].Term, Id: items[i].Id}
+	})
+	if len(items) > page.Limit {
+		items = items[:page.Limit]
+	}
+	return SearchResult{Items: items, Next: next}, nil
+}
+
+func (s *service) KnownWords(userId string) ([]string, error) {
+	terms := []string{}
+	err := s.app.DB().
+		Select("v.term").
+		Distinct(true).
+		From("srs c").
+		InnerJoin("vocabulary v", dbx.NewExp("v.id = c.vocabulary")).
+		Where(dbx.HashExp{"c.user": userId, "c.item_type": "vocabulary"}).
+		AndWhere(dbx.NewExp("c.last_reviewed != ''")).
+		Column(&terms)
+	return terms, err
+}
@@ -0,0 +1,146 @@
+package vocabulary
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+	_ "github.com/bunkbed-tech/fushigi/pocketbase/migrations"
+
+	"github.com/pocketbase/pocketbase/core"
+	"github.com/pocketbase/pocketbase/tests"
+)
+
+// newTestApp is a migrated app with one user
+func newTestApp(t *testing.T) (*tests.TestApp, *core.Record) {
+	t.Helper()
+	// the initial migration creates a superuser from these
+	t.Setenv("ADMIN_EMAIL", "admin@example.com")
+	t.Setenv("ADMIN_PASSWORD", "correct horse battery")
+	app, err := tests.NewTestApp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(app.Cleanup)
+	if err := app.RunAllMigrations(); err != nil {
+		t.Fatal(err)
+	}
+
+	users, err := app.FindCollectionByNameOrId("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := core.NewRecord(users)
+	user.SetEmail("learner@example.com")
+	// usernames are unique when set, so every user gets one
+	user.Set("username", "learner")
+	user.SetPassword("correct horse battery")
+	if err := app.Save(user); err != nil {
+		t.Fatal(err)
+	}
+	return app, user
+}
+
+func saveWord(t *testing.T, app core.App, term string, data map[string]any) *core.Record {
+	t.Helper()
+	japanese, err := app.FindFirstRecordByData("languages", "name", "Japanese")
+	if err != nil {
+		t.Fatal(err)
+	}
+	collection, err := app.FindCollectionByNameOrId("vocabulary")
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := core.NewRecord(collection)
+	record.Set("language", japanese.Id)
+	record.Set("term", term)
+	record.Set("meaning", "test")
+	record.Load(data)
+	if err := app.Save(record); err != nil {
+		t.Fatal(err)
+	}
+	return record
+}
+
+// saveCard saves a vocabulary card, reviewed unless lastReviewed is zero
+func saveCard(t *testing.T, app core.App, userId string, wordId string, lastReviewed time.Time) {
+	t.Helper()
+	collection, err := app.FindCollectionByNameOrId("srs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := core.NewRecord(collection)
+	record.Set("user", userId)
+	record.Set("item_type", "vocabulary")
+	record.Set("vocabulary", wordId)
+	record.Set("ease_factor", 2.5)
+	record.Set("interval_days", 1)
+	if !lastReviewed.IsZero() {
+		record.Set("last_reviewed", lastReviewed)
+		record.Set("repetition", 1)
+	}
+	if err := app.Save(record); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestKnownWords(t *testing.T) {
+	app, user := newTestApp(t)
+	service := NewService(app)
+
+	saveCard(t, app, user.Id, saveWord(t, app, "勉強", nil).Id, time.Now().Add(-time.Hour))
+	saveCard(t, app, user.Id, saveWord(t, app, "図書館", nil).Id, time.Time{})
+	saveWord(t, app, "先生", nil)
+
+	words, err := service.KnownWords(user.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(words, []string{"勉強"}) {
+		t.Errorf("KnownWords() = %v, want the reviewed word only", words)
+	}
+	if words, err := service.KnownWords("nobody"); err != nil || len(words) != 0 {
+		t.Errorf("KnownWords() of a user without cards = %v, %v, want none", words, err)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	app, user := newTestApp(t)
+	service := NewService(app)
+
+	saveWord(t, app, "先生", nil)
+	saveWord(t, app, "勉強", map[string]any{"user": user.Id, "source": "anki", "source_id": "1"})
+	saveWord(t, app, "図書館", map[string]any{"user": user.Id, "source": "anki", "source_id": "2"})
+	saveWord(t, app, "散歩", map[string]any{"user": user.Id})
+
+	tests := []struct {
+		name   string
+		userId string
+		req    SearchRequest
+		want   []string
+	}{
+		{"everything", user.Id, SearchRequest{}, []string{"先生", "勉強", "図書館", "散歩"}},
+		{"mine", user.Id, SearchRequest{Mine: true}, []string{"勉強", "図書館", "散歩"}},
+		{"source", user.Id, SearchRequest{Source: "anki"}, []string{"勉強", "図書館"}},
+		{"another user's source", "nobody", SearchRequest{Source: "anki"}, []string{}},
+		{"query", user.Id, SearchRequest{Query: "図書"}, []string{"図書館"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := service.Search(tt.userId, tt.req, api.Page{Limit: 10})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := []string{}
+			for _, item := range result.Items {
+				got = append(got, item.Term)
+			}
+			slices.Sort(got)
+			want := slices.Sorted(slices.Values(tt.want))
+			if !slices.Equal(got, want) || result.Next != "" {
+				t.Errorf("Search() = %v, next %q, want %v on one page", got, result.Next, want)
+			}
+		})
+	}
+}
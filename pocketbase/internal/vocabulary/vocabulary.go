@@ -0,0 +1,85 @@
+package vocabulary
+
+import (
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/grammar"
+
+	"github.com/pocketbase/pocketbase/core"
+)
+
+// Vocabulary is a word, either from the shared library (no user) or added by
+// a user for themselves
+type Vocabulary struct {
+	Id           string            `json:"id"`
+	User         string            `json:"user"`
+	Language     string            `json:"language"`
+	Term         string            `json:"term"`
+	Reading      string            `json:"reading"`
+	Meaning      string            `json:"meaning"`
+	PartOfSpeech string            `json:"part_of_speech"`
+	Tags         []string          `json:"tags"`
+	Examples     []grammar.Example `json:"examples"`
+	Source       string            `json:"source"`
+	SourceId     string            `json:"source_id"`
+
+	// FrequencyRank is the word's rank in the imported frequency list, 1
+	// being the most frequent, zero when it isn't ranked
+	FrequencyRank int `json:"frequency_rank"`
+}
+
+// SearchRequest narrows a vocabulary search, empty fields match everything
+type SearchRequest struct {
+	// Query is matched against term, reading and meaning
+	Query string
+
+	// Source is where the word came from, e.g. an importer or a capture
+	Source string
+
+	// Mine leaves out the library
+	Mine bool
+}
+
+// SearchResult is one page of a vocabulary search. Next is the cursor of the
+// following page, empty on the last one.
+type SearchResult struct {
+	Items []Vocabulary `json:"items"`
+	Next  string       `json:"next"`
+}
+
+// IsLibrary reports whether the word belongs to the shared library
+func (v Vocabulary) IsLibrary() bool {
+	return v.User == ""
+}
+
+func FromRecord(rec *core.Record) Vocabulary {
+	v := Vocabulary{
+		Id:           rec.Id,
+		User:         rec.GetString("user"),
+		Language:     rec.GetString("language"),
+		Term:         rec.GetString("term"),
+		Reading:      rec.GetString("reading"),
+		Meaning:      rec.GetString("meaning"),
+		PartOfSpeech: rec.GetString("part_of_speech"),
+		Tags:         []string{},
+		Examples:     []grammar.Example{},
+		Source:       rec.GetString("source"),
+		SourceId:     rec.GetString("source_id"),
+
+		FrequencyRank: rec.GetInt("frequency_rank"),
+	}
+	_ = rec.UnmarshalJSONField("tags", &v.Tags)
+	_ = rec.UnmarshalJSONField("examples", &v.Examples)
+	return v
+}
+
+func FromRecords(records []*core.Record) []Vocabulary {
+	items := make([]Vocabulary, 0, len(records))
+	for _, rec := range records {
+		items = append(items, FromRecord(rec))
+	}
+	return items
+}
+
+// ByFrequency orders vocabulary most frequent first, unranked words last
+func ByFrequency(a, b Vocabulary) int {
+	return grammar.CompareRanks(a.FrequencyRank, b.FrequencyRank)
+}
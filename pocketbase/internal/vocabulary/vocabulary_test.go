@@ -0,0 +1,24 @@
+package vocabulary
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestByFrequency(t *testing.T) {
+	words := []Vocabulary{
+		{Term: "猫"},
+		{Term: "試験", FrequencyRank: 30},
+		{Term: "食べる", FrequencyRank: 2},
+		{Term: "犬"},
+	}
+	slices.SortStableFunc(words, ByFrequency)
+
+	var terms []string
+	for _, word := range words {
+		terms = append(terms, word.Term)
+	}
+	if want := []string{"食べる", "試験", "猫", "犬"}; !slices.Equal(terms, want) {
+		t.Errorf("sorted by frequency = %v, want %v", terms, want)
+	}
+}
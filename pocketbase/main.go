@@ -2,11 +2,77 @@ package main
 
 import (
 	"log"
+	"maps"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/achievements"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/activity"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/admin"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/ai"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/aiaudit"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/aifeedback"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/announcements"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/api"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/auth"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/avatars"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/changelog"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/children"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/clients"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/comparisons"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/conversations"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/dbstats"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/drills"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/emails"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/embeddings"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/events"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/exports"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/features"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/federation"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/feedback"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/frequency"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/grammar"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/graph"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/guests"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/i18n"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/imports"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/instance"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/ipfilter"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/jobs"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/journal"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/kanji"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/liveedit"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/maintenance"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/mcp"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/media"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/mistakes"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/mnemonics"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/moderation"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/notifications"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/passwords"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/plan"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/profiles"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/prompts"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/public"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/ratelimit"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/referrals"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/reports"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/sandbox"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/sessions"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/settings"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/setup"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/speaking"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/srs"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/storage"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/studyrooms"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/tagging"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/translit"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/tutors"
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/vocabulary"
 	_ "github.com/bunkbed-tech/fushigi/pocketbase/migrations"
+	"github.com/bunkbed-tech/fushigi/pocketbase/plugins"
 
 	"github.com/pocketbase/pocketbase"
 	"github.com/pocketbase/pocketbase/apis"
@@ -29,10 +95,311 @@ func main() {
 
 	configureAppSettings(app)
 
+	// queries are timed and counted per route for the ops dashboard
+	queries := dbstats.NewTracker(dbstats.ThresholdFromEnv())
+
+	// private instances can keep superusers and signups to some networks, a
+	// list that can't be read stops the instance rather than open it up
+	ipFilter, err := ipfilter.ConfigFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// AI features are off unless self-hosters point them at a model, every
+	// request they send is logged for the user it's for
+	aiauditService := aiaudit.NewService(app)
+	aiClient, err := ai.ClientFromEnv(aiauditService)
+	if err != nil {
+		app.Logger().Error("Failed to configure AI, AI features are disabled", "error", err)
+	}
+	speech, err := ai.SpeechFromEnv(aiauditService)
+	if err != nil {
+		app.Logger().Error("Failed to configure speech, the speaking journal is disabled", "error", err)
+	}
+	embedder, err := ai.EmbedderFromEnv(aiauditService)
+	if err != nil {
+		app.Logger().Error("Failed to configure embeddings, semantic search is disabled", "error", err)
+	}
+
+	// passwords users set for themselves or their children
+	passwordPolicy := passwords.PolicyFromEnv()
+
+	// AI and export routes are limited by the user's tier on top of the
+	// instance wide limits, superusers can change them in the instance settings
+	limits := ratelimit.LimitsFromEnv()
+	limiter := ratelimit.NewLimiter(limits)
+
+	achievementsService := achievements.NewService(app)
+	activityService := activity.NewService(app)
+	adminService := admin.NewService(app, queries)
+	aifeedbackService := aifeedback.NewService(app)
+	authService := auth.NewService(app)
+	childrenService := children.NewService(app)
+	emailsService := emails.NewService(app)
+	featuresService := features.NewService(app)
+	feedbackService := feedback.NewService(app)
+	frequencyService := frequency.NewService(app)
+	grammarService := grammar.NewService(app)
+	guestsService := guests.NewService(app, guests.ConfigFromEnv())
+	jobsService := jobs.NewService(app)
+	journalService := journal.NewService(app)
+	kanjiService := kanji.NewService(app)
+	maintenanceService := maintenance.NewService(app, jobsService)
+	mistakesService := mistakes.NewService(app, journalService)
+	mnemonicsService := mnemonics.NewService(app)
+	moderationService := moderation.NewService(app)
+	profilesService := profiles.NewService(app)
+	promptsService := prompts.NewService(app)
+	referralsService := referrals.NewService(app, referrals.ConfigFromEnv())
+	sandboxService := sandbox.NewService(app)
+	sessionsService := sessions.NewService(app)
+	settingsService := settings.NewService(app)
+	setupService := setup.NewService(app)
+	srsService := srs.NewService(app)
+	storageService := storage.NewService(app)
+	vocabularyService := vocabulary.NewService(app)
+	exportsService := exports.NewService(app, jobsService, journalService, grammarService, srsService, settingsService)
+	instanceService := instance.NewService(app, limiter, limits)
+	announcementsService := announcements.NewService(app, jobsService, settingsService, emailsService)
+	changelogService := changelog.NewService(app, featuresService)
+	// assistants connect to the MCP server only where self-hosters turn it on
+	mcpService := mcp.NewService(app, mcp.EnabledFromEnv(), grammarService, srsService, settingsService)
+	// and the GraphQL endpoint the same
+	graphService := graph.NewService(app, graph.EnabledFromEnv(), grammarService, journalService, srsService, sessionsService, settingsService)
+	clientsService := clients.NewService(app, instanceService, clients.Capabilities{
+		AI:             aiClient != nil,
+		Speech:         speech != nil,
+		SemanticSearch: embedder != nil,
+		GuestTrials:    guestsService.Enabled(),
+		MCP:            mcpService.Enabled(),
+		GraphQL:        graphService.Enabled(),
+	})
+	importsService := imports.NewService(app, jobsService, journalService, settingsService)
+	federationService := federation.NewService(app, grammarService)
+	drillsService := drills.NewService(app, grammarService, settingsService)
+	embeddingsService := embeddings.NewService(app, jobsService, grammarService, embedder)
+	planService := plan.NewService(app, srsService, sessionsService, grammarService, journalService, settingsService)
+	taggingService := tagging.NewService(app, jobsService, grammarService, promptsService, aiClient)
+	comparisonsService := comparisons.NewService(app, jobsService, grammarService, promptsService, aiClient)
+	conversationsService := conversations.NewService(app, jobsService, grammarService, settingsService, promptsService, aiClient)
+	speakingService := speaking.NewService(app, jobsService, grammarService, settingsService, promptsService, speech, aiClient)
+	mediaService := media.NewService(app, jobsService, speakingService, media.SignerFromEnv(), media.FFmpegFromEnv())
+	reportsService := reports.NewService(app, jobsService, journalService, grammarService, srsService, settingsService, emailsService, promptsService, aiClient)
+	tutorsService := tutors.NewService(app, journalService, settingsService)
+	liveeditService := liveedit.NewService(app, tutorsService)
+	studyroomsService := studyrooms.NewService(app)
+
+	// `media reprocess` runs the same job as the admin route from a shell
+	app.RootCmd.AddCommand(media.NewCommand(app, mediaService))
+
+	// `kanji import` fills the kanji dictionary from KANJIDIC2 and KRADFILE
+	app.RootCmd.AddCommand(kanji.NewCommand(kanjiService))
+
+	// expensive responses are revalidated against the records they're built from
+	conditional := api.NewConditional()
+	conditional.BindHooks(app, "srs", "decks", "grammar", "languages", "sessions", "vocabulary")
+
+	// record hooks publish domain events here for subsystems to react to
+	bus := events.NewBus()
+
+	achievements.BindHooks(bus, achievementsService)
+	announcements.BindHooks(app, announcementsService)
+	auth.BindHooks(app, emailsService, settingsService)
+	avatars.BindHooks(app)
+	children.BindHooks(app)
+	clients.BindHooks(app, clientsService)
+	dbstats.BindHooks(app, queries)
+	drills.BindHooks(app, drillsService)
+	emails.BindHooks(app, emailsService, settingsService)
+	embeddings.BindHooks(app, embeddingsService)
+	events.BindHooks(app, bus)
+	exports.BindHooks(app)
+	grammar.BindHooks(app)
+	guests.BindHooks(app, guestsService)
+	imports.BindHooks(app)
+	instance.BindHooks(app, instanceService)
+	ipfilter.BindHooks(app, ipFilter)
+	jobs.BindHooks(app)
+	journal.BindHooks(app, settingsService)
+	liveedit.BindHooks(app, liveeditService)
+	maintenance.BindHooks(app, maintenanceService, maintenance.ScheduleFromEnv())
+	media.BindHooks(app, mediaService)
+	mistakes.BindHooks(app)
+	// after journal, which gives new entries the audience screened by
+	moderation.BindHooks(app, moderation.ConfigFromEnv())
+	notifications.BindHooks(app, bus, srsService)
+	passwords.BindHooks(app, passwordPolicy)
+	plan.BindHooks(app, bus, planService)
+	prompts.BindHooks(app)
+	public.BindHooks(app)
+	referrals.BindHooks(app, referralsService)
+	reports.BindHooks(app, reportsService)
+	settings.BindHooks(app)
+	setup.BindHooks(app, setupService)
+	srs.BindHooks(app, clientsService)
+	storage.BindHooks(app)
+	tagging.BindHooks(app, bus, taggingService)
+	tutors.BindHooks(app)
+
+	// third-party plugins compiled in from plugins.go
+	pluginRoutes, err := plugins.Setup(app, bus)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	app.OnServe().BindFunc(func(se *core.ServeEvent) error {
 		// serves static files from the provided public dir (if exists)
 		se.Router.GET("/{path...}", apis.Static(os.DirFS("./pb_public"), false))
 
+		// bound first so the queries of the other middlewares count too
+		se.Router.Bind(dbstats.Middleware(queries))
+
+		// errors come back in the locale of the client or else the user's settings
+		se.Router.Bind(i18n.Middleware(func(userId string) string {
+			userSettings, err := settingsService.ForUser(userId)
+			if err != nil {
+				return ""
+			}
+			return userSettings.Locale
+		}))
+
+		// tokens of revoked sessions are refused everywhere, not just custom routes
+		se.Router.Bind(auth.Middleware())
+
+		// guests whose trial is over are refused until they're purged
+		se.Router.Bind(guests.Middleware())
+
+		// custom routes act on the study profile the client picked
+		se.Router.Bind(profiles.Middleware(profilesService))
+
+		// children only reach what their parent allows
+		se.Router.Bind(children.Middleware())
+
+		// superusers only work from the networks they're allowed from
+		se.Router.Bind(ipfilter.Middleware(ipFilter))
+
+		// builds older than their platform's minimum can't write anymore
+		se.Router.Bind(clients.Middleware(clientsService))
+
+		// AI routes close while superusers turn AI features off, before they
+		// count against anyone's limit
+		se.Router.Bind(instance.Middleware(instanceService))
+
+		se.Router.Bind(ratelimit.Middleware(limiter))
+
+		registry := api.NewRegistry(se.Router.RouterGroup, "Fushigi API", api.Versions)
+
+		// custom fushigi routes, all of which act on behalf of the logged in user
+		fushigi := registry.Group("", api.Authenticated)
+		achievements.RegisterRoutes(fushigi, achievementsService)
+		activity.RegisterRoutes(fushigi, activityService)
+		aiaudit.RegisterRoutes(fushigi, aiauditService)
+		aifeedback.RegisterRoutes(fushigi, aifeedbackService)
+		announcements.RegisterRoutes(fushigi, announcementsService)
+		auth.RegisterRoutes(fushigi, authService)
+		changelog.RegisterRoutes(fushigi, changelogService)
+		children.RegisterRoutes(fushigi, childrenService, passwordPolicy)
+		comparisons.RegisterRoutes(fushigi, comparisonsService)
+		conversations.RegisterRoutes(fushigi, conversationsService)
+		drills.RegisterRoutes(fushigi, drillsService)
+		embeddings.RegisterRoutes(fushigi, embeddingsService)
+		exports.RegisterRoutes(fushigi, exportsService)
+		features.RegisterRoutes(fushigi, featuresService)
+		feedback.RegisterRoutes(fushigi, feedbackService)
+		federation.RegisterRoutes(fushigi, federationService)
+		graph.RegisterRoutes(fushigi, graphService)
+		grammar.RegisterRoutes(fushigi, grammarService, journalService, mnemonicsService, embeddingsService, vocabularyService, conditional)
+		mistakes.RegisterRoutes(fushigi, mistakesService, settingsService)
+		mnemonics.RegisterRoutes(fushigi, mnemonicsService)
+		imports.RegisterRoutes(fushigi, importsService)
+		jobs.RegisterRoutes(fushigi, jobsService)
+		journal.RegisterRoutes(fushigi, journalService, settingsService)
+		kanji.RegisterRoutes(fushigi, kanjiService)
+		liveedit.RegisterRoutes(fushigi, liveeditService)
+		mcp.RegisterRoutes(fushigi, mcpService)
+		media.RegisterRoutes(fushigi, mediaService)
+		plan.RegisterRoutes(fushigi, planService)
+		profiles.RegisterRoutes(fushigi, profilesService)
+		referrals.RegisterRoutes(fushigi, referralsService)
+		reports.RegisterRoutes(fushigi, reportsService)
+		sandbox.RegisterRoutes(fushigi, sandboxService)
+		sessions.RegisterRoutes(fushigi, sessionsService)
+		settings.RegisterRoutes(fushigi, settingsService)
+		speaking.RegisterRoutes(fushigi, speakingService)
+		srs.RegisterRoutes(fushigi, srsService, grammarService, sessionsService, settingsService, conditional)
+		storage.RegisterRoutes(fushigi, storageService)
+		studyrooms.RegisterRoutes(fushigi, studyroomsService)
+		tagging.RegisterRoutes(fushigi, taggingService)
+		translit.RegisterRoutes(fushigi)
+		tutors.RegisterRoutes(fushigi, tutorsService)
+		vocabulary.RegisterRoutes(fushigi, vocabularyService, conditional)
+		notifications.RegisterRoutes(fushigi, srsService)
+
+		// what clients check at start, before logging in
+		clients.RegisterPublicRoutes(registry.Group("", api.Public), clientsService)
+
+		// trying the app without signing up
+		guests.RegisterPublicRoutes(registry.Group("/public", api.Public), guestsService, instanceService, ipFilter)
+
+		// study room sockets, which take a ticket from joining in place of a login
+		studyrooms.RegisterPublicRoutes(registry.Group("/public", api.Public), studyroomsService)
+
+		// desktop assistants, which authenticate with MCP tokens
+		mcp.RegisterPublicRoutes(registry.Group("", api.Public), mcpService)
+
+		// explicitly published content, readable without logging in
+		public.RegisterRoutes(app, registry.Group("/public", api.Public), grammarService, journalService)
+		tutors.RegisterPublicRoutes(registry.Group("/public", api.Public), tutorsService)
+		media.RegisterPublicRoutes(registry.Group("/public", api.Public), mediaService)
+
+		// first-run setup, the setup token in the log stands in for a login
+		setup.RegisterPublicRoutes(registry.Group("/public", api.Public), setupService)
+
+		// first-run setup, superusers only
+		superuser := registry.Group("/admin", api.Superuser)
+		setup.RegisterAdminRoutes(superuser, setupService)
+
+		// instance administration, for superusers and users given a staff
+		// role, full admins reaching all of it
+		admins := registry.Group("/admin", api.Admin)
+		admin.RegisterStaffRoutes(admins, adminService)
+		announcements.RegisterAdminRoutes(admins, announcementsService)
+		emails.RegisterRoutes(admins, emailsService)
+		frequency.RegisterRoutes(admins, frequencyService)
+		maintenance.RegisterRoutes(admins, maintenanceService)
+		prompts.RegisterRoutes(admins, promptsService)
+		referrals.RegisterAdminRoutes(admins, referralsService)
+
+		moderators := registry.Group("/admin", api.Moderator)
+		moderation.RegisterRoutes(moderators, moderationService)
+
+		support := registry.Group("/admin", api.Support)
+		admin.RegisterRoutes(support, adminService, conditional)
+		aifeedback.RegisterAdminRoutes(support, aifeedbackService)
+		feedback.RegisterAdminRoutes(support, feedbackService)
+		media.RegisterAdminRoutes(support, mediaService)
+
+		for _, name := range slices.Sorted(maps.Keys(pluginRoutes)) {
+			authenticated := registry.Group("/plugins/"+name, api.Authenticated)
+			public := registry.Group("/plugins/"+name, api.Public)
+			for _, route := range pluginRoutes[name] {
+				g := authenticated
+				if route.Public {
+					g = public
+				}
+				g.Add(api.Route{
+					Method:   route.Method,
+					Path:     route.Path,
+					Summary:  route.Summary,
+					Request:  route.Request,
+					Response: route.Response,
+					Handler:  route.Handler,
+				})
+			}
+		}
+
+		registry.ServeSpecs()
+
 		return se.Next()
 	})
 
@@ -44,11 +411,11 @@ func main() {
 func configureAppSettings(app core.App) {
 	settings := app.Settings()
 
-	// Basic Info
-	settings.Meta.AppName = os.Getenv("APP_NAME")
-	settings.Meta.AppURL = os.Getenv("APP_URL")
-	settings.Meta.SenderName = os.Getenv("SENDER_NAME")
-	settings.Meta.SenderAddress = os.Getenv("SENDER_ADDRESS")
+	// Basic Info, left as the setup routes saved it when not set here
+	setFromEnv(&settings.Meta.AppName, "APP_NAME")
+	setFromEnv(&settings.Meta.AppURL, "APP_URL")
+	setFromEnv(&settings.Meta.SenderName, "SENDER_NAME")
+	setFromEnv(&settings.Meta.SenderAddress, "SENDER_ADDRESS")
 
 	// Turn on logs
 	settings.Logs.MaxDays = 7
@@ -56,16 +423,18 @@ func configureAppSettings(app core.App) {
 	settings.Logs.LogIP = true
 
 	// Use SMTP for sending users emails from my SenderAddress
-	settings.SMTP.Enabled = true
-	settings.SMTP.Host = os.Getenv("SMTP_HOST")
-	if portStr := os.Getenv("SMTP_PORT"); portStr != "" {
-		if port, err := strconv.Atoi(portStr); err == nil {
-			settings.SMTP.Port = port
-		}
-	} // If it fails to read port and it's not set... what happens
-	settings.SMTP.Username = os.Getenv("SMTP_EMAIL")
-	settings.SMTP.Password = os.Getenv("SMTP_PASSWORD")
-	settings.SMTP.TLS = true
+	if os.Getenv("SMTP_HOST") != "" {
+		settings.SMTP.Enabled = true
+		settings.SMTP.Host = os.Getenv("SMTP_HOST")
+		if portStr := os.Getenv("SMTP_PORT"); portStr != "" {
+			if port, err := strconv.Atoi(portStr); err == nil {
+				settings.SMTP.Port = port
+			}
+		} // If it fails to read port and it's not set... what happens
+		settings.SMTP.Username = os.Getenv("SMTP_EMAIL")
+		settings.SMTP.Password = os.Getenv("SMTP_PASSWORD")
+		settings.SMTP.TLS = true
+	}
 
 	// Protect against the api getting hammered (idk good values)
 	settings.RateLimits.Enabled = true
@@ -74,9 +443,22 @@ func configureAppSettings(app core.App) {
 		{Label: "*:create", Duration: 60, MaxRequests: 10},
 		{Label: "*:update", Duration: 60, MaxRequests: 10},
 		{Label: "/api/", Duration: 60, MaxRequests: 100},
+		{Label: "GET /api/fushigi/public/", Duration: 60, MaxRequests: 30},
+		{Label: "GET /api/fushigi/v1/public/", Duration: 60, MaxRequests: 30},
+	}
+
+	// Find clients' real IPs behind a reverse proxy, for login alerts and IP
+	// filtering. Traefik puts them in X-Forwarded-For.
+	for _, header := range strings.Split(os.Getenv("TRUSTED_PROXY_HEADERS"), ",") {
+		if header = strings.TrimSpace(header); header != "" {
+			settings.TrustedProxy.Headers = append(settings.TrustedProxy.Headers, header)
+		}
 	}
+}
 
-	// Periodic backups
-	settings.Backups.Cron = "0 0 * * 0" // run every sunday at midnight
-	settings.Backups.CronMaxKeep = 3    // keep three weeks worth
+// setFromEnv sets a setting from the environment variable key, when it's set
+func setFromEnv(setting *string, key string) {
+	if value := os.Getenv(key); value != "" {
+		*setting = value
+	}
 }
@@ -26,8 +26,9 @@ func init() {
 			return err
 		}
 
+		// without one the first superuser is made through the setup routes
 		record, _ := app.FindAuthRecordByEmail(core.CollectionNameSuperusers, adminEmail)
-		if record == nil {
+		if record == nil && adminEmail != "" {
 			record = core.NewRecord(superusers)
 			record.Set("email", adminEmail)
 			record.Set("password", adminPassword)
@@ -0,0 +1,76 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		collection := core.NewBaseCollection("devices")
+
+		collection.ViewRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id")
+		collection.ListRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id")
+		collection.CreateRule = types.Pointer("@request.auth.id != '' && @request.body.user = @request.auth.id")
+		collection.UpdateRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id && (@request.body.user:isset = false || @request.body.user = @request.auth.id)")
+		collection.DeleteRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id")
+
+		usersCollection, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+		collection.Fields.Add(&core.RelationField{
+			Name:          "user",
+			Required:      true,
+			CascadeDelete: true,
+			CollectionId:  usersCollection.Id,
+		})
+
+		collection.Fields.Add(&core.TextField{
+			Name:     "token",
+			Required: true,
+		})
+
+		collection.Fields.Add(&core.SelectField{
+			Name:      "platform",
+			Required:  true,
+			MaxSelect: 1,
+			Values:    []string{"ios", "macos"},
+		})
+
+		// Last badge count pushed to the device, used to skip redundant pushes
+		collection.Fields.Add(&core.NumberField{
+			Name:    "badge",
+			OnlyInt: true,
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "updated",
+			OnCreate: true,
+			OnUpdate: true,
+		})
+
+		collection.AddIndex("idx_devices_by_token", true, "token", "")
+		collection.AddIndex("idx_devices_by_user", false, "user", "")
+
+		err = app.Save(collection)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}, func(app core.App) error { // optional revert operation
+		collection, err := app.FindCollectionByNameOrId("devices")
+		if err != nil {
+			return err
+		}
+
+		return app.Delete(collection)
+	})
+}
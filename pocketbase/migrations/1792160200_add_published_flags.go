@@ -0,0 +1,46 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Content is only visible to logged out visitors once its owner explicitly
+// publishes it, independent of the authed is_private rules
+var publishableCollections = []string{"users", "decks", "journal_entry"}
+
+func init() {
+	m.Register(func(app core.App) error {
+		for _, name := range publishableCollections {
+			collection, err := app.FindCollectionByNameOrId(name)
+			if err != nil {
+				return err
+			}
+
+			collection.Fields.Add(&core.BoolField{
+				Name: "published",
+			})
+
+			if err := app.Save(collection); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, func(app core.App) error { // optional revert operation
+		for _, name := range publishableCollections {
+			collection, err := app.FindCollectionByNameOrId(name)
+			if err != nil {
+				return err
+			}
+
+			collection.Fields.RemoveByName("published")
+
+			if err := app.Save(collection); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,94 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		collection := core.NewBaseCollection("jobs")
+
+		// Jobs are only ever written by the server
+		collection.ViewRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id")
+		collection.ListRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id")
+
+		usersCollection, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+		collection.Fields.Add(&core.RelationField{
+			Name:          "user",
+			Required:      false, // system jobs have no user
+			CascadeDelete: true,
+			CollectionId:  usersCollection.Id,
+		})
+
+		collection.Fields.Add(&core.TextField{
+			Name:     "kind",
+			Required: true,
+		})
+
+		collection.Fields.Add(&core.SelectField{
+			Name:      "status",
+			Required:  true,
+			MaxSelect: 1,
+			Values:    []string{"queued", "running", "succeeded", "failed"},
+		})
+
+		collection.Fields.Add(&core.NumberField{
+			Name: "progress",
+			Min:  types.Pointer(0.0),
+			Max:  types.Pointer(100.0),
+		})
+
+		collection.Fields.Add(&core.TextField{
+			Name: "message",
+		})
+
+		collection.Fields.Add(&core.TextField{
+			Name: "error",
+		})
+
+		collection.Fields.Add(&core.JSONField{
+			Name: "result",
+		})
+
+		collection.Fields.Add(&core.DateField{
+			Name: "started",
+		})
+
+		collection.Fields.Add(&core.DateField{
+			Name: "finished",
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "updated",
+			OnCreate: true,
+			OnUpdate: true,
+		})
+
+		collection.AddIndex("idx_jobs_by_user_created", false, "user, created", "")
+		collection.AddIndex("idx_jobs_by_status", false, "status", "")
+
+		err = app.Save(collection)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}, func(app core.App) error { // optional revert operation
+		collection, err := app.FindCollectionByNameOrId("jobs")
+		if err != nil {
+			return err
+		}
+
+		return app.Delete(collection)
+	})
+}
@@ -0,0 +1,109 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Flags are managed by superusers, clients read them via /api/fushigi/v1/features
+		flags := core.NewBaseCollection("feature_flags")
+
+		flags.Fields.Add(&core.TextField{
+			Name:     "key",
+			Required: true,
+			Pattern:  `^[a-z0-9_]+$`,
+		})
+
+		flags.Fields.Add(&core.TextField{
+			Name: "description",
+		})
+
+		flags.Fields.Add(&core.BoolField{
+			Name: "enabled",
+		})
+
+		// Percentage of users that get the flag when it isn't globally enabled
+		flags.Fields.Add(&core.NumberField{
+			Name:    "rollout_percent",
+			OnlyInt: true,
+			Min:     types.Pointer(0.0),
+			Max:     types.Pointer(100.0),
+		})
+
+		flags.Fields.Add(&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		})
+
+		flags.Fields.Add(&core.AutodateField{
+			Name:     "updated",
+			OnCreate: true,
+			OnUpdate: true,
+		})
+
+		flags.AddIndex("idx_feature_flags_by_key", true, "key", "")
+
+		if err := app.Save(flags); err != nil {
+			return err
+		}
+
+		overrides := core.NewBaseCollection("feature_flag_overrides")
+
+		overrides.Fields.Add(&core.RelationField{
+			Name:          "flag",
+			Required:      true,
+			CascadeDelete: true,
+			CollectionId:  flags.Id,
+		})
+
+		usersCollection, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+		overrides.Fields.Add(&core.RelationField{
+			Name:          "user",
+			Required:      true,
+			CascadeDelete: true,
+			CollectionId:  usersCollection.Id,
+		})
+
+		overrides.Fields.Add(&core.BoolField{
+			Name: "enabled",
+		})
+
+		overrides.Fields.Add(&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		})
+
+		overrides.Fields.Add(&core.AutodateField{
+			Name:     "updated",
+			OnCreate: true,
+			OnUpdate: true,
+		})
+
+		overrides.AddIndex("idx_feature_flag_overrides_by_flag_per_user", true, "flag, user", "")
+
+		err = app.Save(overrides)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}, func(app core.App) error { // optional revert operation
+		for _, name := range []string{"feature_flag_overrides", "feature_flags"} {
+			collection, err := app.FindCollectionByNameOrId(name)
+			if err != nil {
+				return err
+			}
+			if err := app.Delete(collection); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,109 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		collection := core.NewBaseCollection("user_settings")
+
+		// Settings are created by the server on signup and never deleted on their own
+		collection.ViewRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id")
+		collection.ListRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id")
+		collection.UpdateRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id && (@request.body.user:isset = false || @request.body.user = @request.auth.id)")
+
+		usersCollection, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+		collection.Fields.Add(&core.RelationField{
+			Name:          "user",
+			Required:      true,
+			CascadeDelete: true,
+			CollectionId:  usersCollection.Id,
+		})
+
+		collection.Fields.Add(&core.TextField{
+			Name: "locale",
+		})
+
+		collection.Fields.Add(&core.TextField{
+			Name: "timezone",
+		})
+
+		collection.Fields.Add(&core.SelectField{
+			Name:      "theme",
+			MaxSelect: 1,
+			Values:    []string{"system", "light", "dark"},
+		})
+
+		languagesCollection, err := app.FindCollectionByNameOrId("languages")
+		if err != nil {
+			return err
+		}
+		collection.Fields.Add(&core.RelationField{
+			Name:          "default_language",
+			CascadeDelete: false,
+			CollectionId:  languagesCollection.Id,
+		})
+
+		collection.Fields.Add(&core.BoolField{
+			Name: "notify_email",
+		})
+
+		collection.Fields.Add(&core.BoolField{
+			Name: "notify_push",
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "updated",
+			OnCreate: true,
+			OnUpdate: true,
+		})
+
+		collection.AddIndex("idx_user_settings_by_user", true, "user", "")
+
+		if err := app.Save(collection); err != nil {
+			return err
+		}
+
+		// Users that signed up before settings existed get the defaults too
+		japanese, _ := app.FindFirstRecordByFilter("languages", "name = 'Japanese'")
+		users, err := app.FindAllRecords(usersCollection)
+		if err != nil {
+			return err
+		}
+		for _, user := range users {
+			record := core.NewRecord(collection)
+			record.Set("user", user.Id)
+			record.Set("locale", "en")
+			record.Set("timezone", "UTC")
+			record.Set("theme", "system")
+			if japanese != nil {
+				record.Set("default_language", japanese.Id)
+			}
+			record.Set("notify_email", true)
+			record.Set("notify_push", true)
+			if err := app.Save(record); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, func(app core.App) error { // optional revert operation
+		collection, err := app.FindCollectionByNameOrId("user_settings")
+		if err != nil {
+			return err
+		}
+
+		return app.Delete(collection)
+	})
+}
@@ -0,0 +1,150 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Bodies are Go html/template, subjects text/template. Both can use
+// {{.AppName}}, {{.AppURL}}, {{.ActionURL}}, {{.Name}}, {{.Email}} and {{.DueCount}}.
+var defaultEmailTemplates = []struct {
+	key, locale, subject, body string
+}{
+	{
+		"verification", "en",
+		"Verify your {{.AppName}} email",
+		`<p>Hello{{if .Name}} {{.Name}}{{end}},</p>
+<p>Thank you for joining us at {{.AppName}}.</p>
+<p>Click on the button below to verify your email address.</p>
+<p><a class="btn" href="{{.ActionURL}}" target="_blank" rel="noopener">Verify</a></p>
+<p>Thanks,<br/>{{.AppName}} team</p>`,
+	},
+	{
+		"verification", "ja",
+		"{{.AppName}} メールアドレスの確認",
+		`<p>{{if .Name}}{{.Name}}様{{else}}こんにちは{{end}}、</p>
+<p>{{.AppName}}にご登録いただきありがとうございます。</p>
+<p>下のボタンをクリックして、メールアドレスを確認してください。</p>
+<p><a class="btn" href="{{.ActionURL}}" target="_blank" rel="noopener">確認する</a></p>
+<p>{{.AppName}}チーム</p>`,
+	},
+	{
+		"password_reset", "en",
+		"Reset your {{.AppName}} password",
+		`<p>Hello{{if .Name}} {{.Name}}{{end}},</p>
+<p>Click on the button below to reset your password.</p>
+<p><a class="btn" href="{{.ActionURL}}" target="_blank" rel="noopener">Reset password</a></p>
+<p><i>If you didn't ask to reset your password, you can ignore this email.</i></p>
+<p>Thanks,<br/>{{.AppName}} team</p>`,
+	},
+	{
+		"password_reset", "ja",
+		"{{.AppName}} パスワードの再設定",
+		`<p>{{if .Name}}{{.Name}}様{{else}}こんにちは{{end}}、</p>
+<p>下のボタンをクリックして、パスワードを再設定してください。</p>
+<p><a class="btn" href="{{.ActionURL}}" target="_blank" rel="noopener">パスワードを再設定</a></p>
+<p><i>お心当たりがない場合は、このメールを無視してください。</i></p>
+<p>{{.AppName}}チーム</p>`,
+	},
+	{
+		"email_change", "en",
+		"Confirm your {{.AppName}} new email address",
+		`<p>Hello{{if .Name}} {{.Name}}{{end}},</p>
+<p>Click on the button below to confirm your new email address.</p>
+<p><a class="btn" href="{{.ActionURL}}" target="_blank" rel="noopener">Confirm new email</a></p>
+<p><i>If you didn't ask to change your email address, you can ignore this email.</i></p>
+<p>Thanks,<br/>{{.AppName}} team</p>`,
+	},
+	{
+		"email_change", "ja",
+		"{{.AppName}} 新しいメールアドレスの確認",
+		`<p>{{if .Name}}{{.Name}}様{{else}}こんにちは{{end}}、</p>
+<p>下のボタンをクリックして、新しいメールアドレスを確認してください。</p>
+<p><a class="btn" href="{{.ActionURL}}" target="_blank" rel="noopener">新しいメールアドレスを確認</a></p>
+<p><i>お心当たりがない場合は、このメールを無視してください。</i></p>
+<p>{{.AppName}}チーム</p>`,
+	},
+	{
+		"reminder", "en",
+		"{{.DueCount}} grammar points are waiting for review",
+		`<p>Hello{{if .Name}} {{.Name}}{{end}},</p>
+<p>You have {{.DueCount}} grammar points due for review today.</p>
+<p><a class="btn" href="{{.ActionURL}}" target="_blank" rel="noopener">Start reviewing</a></p>
+<p>Thanks,<br/>{{.AppName}} team</p>`,
+	},
+	{
+		"reminder", "ja",
+		"復習待ちの文法が{{.DueCount}}件あります",
+		`<p>{{if .Name}}{{.Name}}様{{else}}こんにちは{{end}}、</p>
+<p>今日復習する文法が{{.DueCount}}件あります。</p>
+<p><a class="btn" href="{{.ActionURL}}" target="_blank" rel="noopener">復習を始める</a></p>
+<p>{{.AppName}}チーム</p>`,
+	},
+}
+
+func init() {
+	m.Register(func(app core.App) error {
+		// No API rules, templates are edited from the dashboard by superusers
+		collection := core.NewBaseCollection("email_templates")
+
+		collection.Fields.Add(&core.TextField{
+			Name:     "key",
+			Required: true,
+		})
+
+		collection.Fields.Add(&core.TextField{
+			Name:     "locale",
+			Required: true,
+		})
+
+		collection.Fields.Add(&core.TextField{
+			Name:     "subject",
+			Required: true,
+		})
+
+		// Plain text rather than an editor field so the rich text editor doesn't
+		// mangle template actions
+		collection.Fields.Add(&core.TextField{
+			Name:     "body",
+			Required: true,
+			Max:      50000,
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "updated",
+			OnCreate: true,
+			OnUpdate: true,
+		})
+
+		collection.AddIndex("idx_email_templates_by_key_locale", true, "`key`, `locale`", "")
+
+		if err := app.Save(collection); err != nil {
+			return err
+		}
+
+		for _, t := range defaultEmailTemplates {
+			record := core.NewRecord(collection)
+			record.Set("key", t.key)
+			record.Set("locale", t.locale)
+			record.Set("subject", t.subject)
+			record.Set("body", t.body)
+			if err := app.Save(record); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, func(app core.App) error { // optional revert operation
+		collection, err := app.FindCollectionByNameOrId("email_templates")
+		if err != nil {
+			return err
+		}
+
+		return app.Delete(collection)
+	})
+}
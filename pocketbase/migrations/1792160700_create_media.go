@@ -0,0 +1,88 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		collection := core.NewBaseCollection("media")
+
+		collection.ViewRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id")
+		collection.ListRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id")
+		collection.CreateRule = types.Pointer("@request.auth.id != '' && @request.body.user = @request.auth.id")
+		collection.UpdateRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id && (@request.body.user:isset = false || @request.body.user = @request.auth.id)")
+		collection.DeleteRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id")
+
+		usersCollection, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+		collection.Fields.Add(&core.RelationField{
+			Name:          "user",
+			Required:      true,
+			CascadeDelete: true,
+			CollectionId:  usersCollection.Id,
+		})
+
+		journalCollection, err := app.FindCollectionByNameOrId("journal_entry")
+		if err != nil {
+			return err
+		}
+		collection.Fields.Add(&core.RelationField{
+			Name:          "journal_entry",
+			CascadeDelete: true,
+			CollectionId:  journalCollection.Id,
+		})
+
+		collection.Fields.Add(&core.SelectField{
+			Name:      "kind",
+			Required:  true,
+			MaxSelect: 1,
+			Values:    []string{"attachment", "audio"},
+		})
+
+		collection.Fields.Add(&core.FileField{
+			Name:      "file",
+			Required:  true,
+			MaxSelect: 1,
+			MaxSize:   50 << 20,
+		})
+
+		// Kept up to date by the server from the uploaded file, for quotas
+		collection.Fields.Add(&core.NumberField{
+			Name:    "size",
+			OnlyInt: true,
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "updated",
+			OnCreate: true,
+			OnUpdate: true,
+		})
+
+		collection.AddIndex("idx_media_by_user", false, "user", "")
+		collection.AddIndex("idx_media_by_journal_entry", false, "journal_entry", "")
+
+		err = app.Save(collection)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}, func(app core.App) error { // optional revert operation
+		collection, err := app.FindCollectionByNameOrId("media")
+		if err != nil {
+			return err
+		}
+
+		return app.Delete(collection)
+	})
+}
@@ -0,0 +1,36 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		users, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		// Overrides the instance wide quota, in megabytes. Only superusers may set it.
+		users.Fields.Add(&core.NumberField{
+			Name:    "storage_quota",
+			OnlyInt: true,
+			Min:     types.Pointer(0.0),
+		})
+		users.UpdateRule = types.Pointer("id = @request.auth.id && @request.body.storage_quota:isset = false")
+
+		return app.Save(users)
+	}, func(app core.App) error { // optional revert operation
+		users, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		users.Fields.RemoveByName("storage_quota")
+		users.UpdateRule = types.Pointer("id = @request.auth.id")
+
+		return app.Save(users)
+	})
+}
@@ -0,0 +1,41 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		users, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		avatar, ok := users.Fields.GetByName("avatar").(*core.FileField)
+		if !ok {
+			avatar = &core.FileField{Name: "avatar", MaxSelect: 1}
+			users.Fields.Add(avatar)
+		}
+
+		// Uploads are resized to 512x512 by the server, svg can't be so it's out
+		avatar.MimeTypes = []string{"image/jpeg", "image/png", "image/gif", "image/webp"}
+		avatar.MaxSize = 10 << 20
+		avatar.Thumbs = []string{"64x64", "128x128"}
+
+		return app.Save(users)
+	}, func(app core.App) error { // optional revert operation
+		users, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		if avatar, ok := users.Fields.GetByName("avatar").(*core.FileField); ok {
+			avatar.MimeTypes = []string{"image/jpeg", "image/png", "image/svg+xml", "image/gif", "image/webp"}
+			avatar.MaxSize = 0
+			avatar.Thumbs = nil
+		}
+
+		return app.Save(users)
+	})
+}
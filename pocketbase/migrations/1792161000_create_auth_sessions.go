@@ -0,0 +1,77 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// No API rules, users list and revoke their sessions through the custom routes
+		collection := core.NewBaseCollection("auth_sessions")
+
+		usersCollection, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+		collection.Fields.Add(&core.RelationField{
+			Name:          "user",
+			Required:      true,
+			CascadeDelete: true,
+			CollectionId:  usersCollection.Id,
+		})
+
+		// sha256 of the auth token, the token itself is never stored
+		collection.Fields.Add(&core.TextField{
+			Name:     "token_hash",
+			Required: true,
+			Hidden:   true,
+		})
+
+		collection.Fields.Add(&core.TextField{
+			Name: "user_agent",
+			Max:  500,
+		})
+
+		collection.Fields.Add(&core.TextField{
+			Name: "ip",
+		})
+
+		collection.Fields.Add(&core.DateField{
+			Name: "last_seen",
+		})
+
+		// Revoked sessions are kept until their token would have expired anyway
+		collection.Fields.Add(&core.BoolField{
+			Name: "revoked",
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "updated",
+			OnCreate: true,
+			OnUpdate: true,
+		})
+
+		collection.AddIndex("idx_auth_sessions_by_token_hash", true, "token_hash", "")
+		collection.AddIndex("idx_auth_sessions_by_user", false, "user", "")
+
+		err = app.Save(collection)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}, func(app core.App) error { // optional revert operation
+		collection, err := app.FindCollectionByNameOrId("auth_sessions")
+		if err != nil {
+			return err
+		}
+
+		return app.Delete(collection)
+	})
+}
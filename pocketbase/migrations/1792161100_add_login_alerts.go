@@ -0,0 +1,147 @@
+package migrations
+
+import (
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+var loginAlertTemplates = []struct {
+	locale, subject, body string
+}{
+	{
+		"en",
+		"New login to your {{.AppName}} account",
+		`<p>Hello{{if .Name}} {{.Name}}{{end}},</p>
+<p>We noticed a login to your {{.AppName}} account from a new device or location:</p>
+<p>{{.UserAgent}}<br/>{{.IP}}</p>
+<p>If this was you, you can ignore this email.</p>
+<p><strong>If this wasn't you, change your password and sign out your other sessions from the app's settings.</strong></p>
+<p>Thanks,<br/>{{.AppName}} team</p>`,
+	},
+	{
+		"ja",
+		"{{.AppName}} アカウントへの新しいログイン",
+		`<p>{{if .Name}}{{.Name}}様{{else}}こんにちは{{end}}、</p>
+<p>新しい端末または場所から{{.AppName}}アカウントへのログインがありました。</p>
+<p>{{.UserAgent}}<br/>{{.IP}}</p>
+<p>ご本人によるログインの場合は、このメールを無視してください。</p>
+<p><strong>お心当たりがない場合は、パスワードを変更し、アプリの設定から他のセッションをログアウトしてください。</strong></p>
+<p>{{.AppName}}チーム</p>`,
+	},
+}
+
+func init() {
+	m.Register(func(app core.App) error {
+		// No API rules, only written by the server when a user logs in
+		devices := core.NewBaseCollection("login_devices")
+
+		usersCollection, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+		devices.Fields.Add(&core.RelationField{
+			Name:          "user",
+			Required:      true,
+			CascadeDelete: true,
+			CollectionId:  usersCollection.Id,
+		})
+
+		// sha256 of the IP and user agent the login came from
+		devices.Fields.Add(&core.TextField{
+			Name:     "fingerprint",
+			Required: true,
+		})
+
+		devices.Fields.Add(&core.TextField{
+			Name: "user_agent",
+			Max:  500,
+		})
+
+		devices.Fields.Add(&core.TextField{
+			Name: "ip",
+		})
+
+		devices.Fields.Add(&core.DateField{
+			Name: "last_login",
+		})
+
+		devices.Fields.Add(&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		})
+
+		devices.Fields.Add(&core.AutodateField{
+			Name:     "updated",
+			OnCreate: true,
+			OnUpdate: true,
+		})
+
+		devices.AddIndex("idx_login_devices_by_user_fingerprint", true, "user, fingerprint", "")
+
+		if err := app.Save(devices); err != nil {
+			return err
+		}
+
+		settings, err := app.FindCollectionByNameOrId("user_settings")
+		if err != nil {
+			return err
+		}
+		settings.Fields.Add(&core.BoolField{
+			Name: "login_alerts",
+		})
+		if err := app.Save(settings); err != nil {
+			return err
+		}
+		if _, err := app.DB().Update("user_settings", dbx.Params{"login_alerts": true}, nil).Execute(); err != nil {
+			return err
+		}
+
+		templates, err := app.FindCollectionByNameOrId("email_templates")
+		if err != nil {
+			return err
+		}
+		for _, t := range loginAlertTemplates {
+			record := core.NewRecord(templates)
+			record.Set("key", "login_alert")
+			record.Set("locale", t.locale)
+			record.Set("subject", t.subject)
+			record.Set("body", t.body)
+			if err := app.Save(record); err != nil {
+				return err
+			}
+		}
+
+		// Replaced by our own alerts, which respect the user's settings
+		usersCollection.AuthAlert.Enabled = false
+		return app.Save(usersCollection)
+	}, func(app core.App) error { // optional revert operation
+		users, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+		users.AuthAlert.Enabled = true
+		if err := app.Save(users); err != nil {
+			return err
+		}
+
+		if _, err := app.DB().Delete("email_templates", dbx.HashExp{"key": "login_alert"}).Execute(); err != nil {
+			return err
+		}
+
+		settings, err := app.FindCollectionByNameOrId("user_settings")
+		if err != nil {
+			return err
+		}
+		settings.Fields.RemoveByName("login_alerts")
+		if err := app.Save(settings); err != nil {
+			return err
+		}
+
+		devices, err := app.FindCollectionByNameOrId("login_devices")
+		if err != nil {
+			return err
+		}
+		return app.Delete(devices)
+	})
+}
@@ -0,0 +1,47 @@
+package migrations
+
+import (
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		users, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		// Whether the user chose a password, accounts created through OAuth2 get
+		// a random one they don't know. Maintained by the server.
+		users.Fields.Add(&core.BoolField{
+			Name: "has_password",
+		})
+		users.UpdateRule = types.Pointer("id = @request.auth.id && @request.body.storage_quota:isset = false && @request.body.has_password:isset = false")
+
+		if err := app.Save(users); err != nil {
+			return err
+		}
+
+		// Accounts with a linked provider may have signed up through it, assume
+		// they didn't pick a password to be safe
+		_, err = app.DB().NewQuery(`
+			UPDATE users SET has_password = id NOT IN (
+				SELECT recordRef FROM _externalAuths WHERE collectionRef = {:collection}
+			)
+		`).Bind(dbx.Params{"collection": users.Id}).Execute()
+		return err
+	}, func(app core.App) error { // optional revert operation
+		users, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		users.Fields.RemoveByName("has_password")
+		users.UpdateRule = types.Pointer("id = @request.auth.id && @request.body.storage_quota:isset = false")
+
+		return app.Save(users)
+	})
+}
@@ -0,0 +1,82 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Exports are only ever written by the export jobs, users can just
+		// download and delete theirs
+		collection := core.NewBaseCollection("exports")
+
+		collection.ViewRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id")
+		collection.ListRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id")
+		collection.DeleteRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id")
+
+		usersCollection, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+		collection.Fields.Add(&core.RelationField{
+			Name:          "user",
+			Required:      true,
+			CascadeDelete: true,
+			CollectionId:  usersCollection.Id,
+		})
+
+		jobsCollection, err := app.FindCollectionByNameOrId("jobs")
+		if err != nil {
+			return err
+		}
+		collection.Fields.Add(&core.RelationField{
+			Name:         "job",
+			CollectionId: jobsCollection.Id,
+		})
+
+		collection.Fields.Add(&core.SelectField{
+			Name:      "format",
+			Required:  true,
+			MaxSelect: 1,
+			Values:    []string{"pdf", "epub"},
+		})
+
+		// Protected so a leaked URL alone doesn't give away a whole journal
+		collection.Fields.Add(&core.FileField{
+			Name:      "file",
+			Required:  true,
+			MaxSelect: 1,
+			MaxSize:   200 << 20,
+			Protected: true,
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "updated",
+			OnCreate: true,
+			OnUpdate: true,
+		})
+
+		collection.AddIndex("idx_exports_by_user", false, "user", "")
+
+		err = app.Save(collection)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}, func(app core.App) error { // optional revert operation
+		collection, err := app.FindCollectionByNameOrId("exports")
+		if err != nil {
+			return err
+		}
+
+		return app.Delete(collection)
+	})
+}
@@ -0,0 +1,30 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("exports")
+		if err != nil {
+			return err
+		}
+
+		format := collection.Fields.GetByName("format").(*core.SelectField)
+		format.Values = []string{"pdf", "epub", "markdown"}
+
+		return app.Save(collection)
+	}, func(app core.App) error { // optional revert operation
+		collection, err := app.FindCollectionByNameOrId("exports")
+		if err != nil {
+			return err
+		}
+
+		format := collection.Fields.GetByName("format").(*core.SelectField)
+		format.Values = []string{"pdf", "epub"}
+
+		return app.Save(collection)
+	})
+}
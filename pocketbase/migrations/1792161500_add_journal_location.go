@@ -0,0 +1,33 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("journal_entry")
+		if err != nil {
+			return err
+		}
+
+		// Free text like "Shibuya, Tokyo, Japan", journaling apps record where
+		// an entry was written
+		collection.Fields.Add(&core.TextField{
+			Name: "location",
+			Max:  200,
+		})
+
+		return app.Save(collection)
+	}, func(app core.App) error { // optional revert operation
+		collection, err := app.FindCollectionByNameOrId("journal_entry")
+		if err != nil {
+			return err
+		}
+
+		collection.Fields.RemoveByName("location")
+
+		return app.Save(collection)
+	})
+}
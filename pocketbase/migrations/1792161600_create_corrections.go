@@ -0,0 +1,100 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// A correction to one sentence of an entry, received from another
+		// learner or a native speaker
+		collection := core.NewBaseCollection("corrections")
+
+		collection.ViewRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id")
+		collection.ListRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id")
+		collection.CreateRule = types.Pointer("@request.auth.id != '' && @request.body.user = @request.auth.id && @request.body.journal_entry.user = @request.auth.id")
+		collection.UpdateRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id && (@request.body.user:isset = false || @request.body.user = @request.auth.id)")
+		collection.DeleteRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id")
+
+		usersCollection, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+		collection.Fields.Add(&core.RelationField{
+			Name:          "user",
+			Required:      true,
+			CascadeDelete: true,
+			CollectionId:  usersCollection.Id,
+		})
+
+		journalCollection, err := app.FindCollectionByNameOrId("journal_entry")
+		if err != nil {
+			return err
+		}
+		collection.Fields.Add(&core.RelationField{
+			Name:          "journal_entry",
+			Required:      true,
+			CascadeDelete: true,
+			CollectionId:  journalCollection.Id,
+		})
+
+		// Empty for overall feedback on the entry rather than one sentence
+		collection.Fields.Add(&core.TextField{
+			Name: "original",
+			Max:  2000,
+		})
+
+		collection.Fields.Add(&core.TextField{
+			Name: "corrected",
+			Max:  2000,
+		})
+
+		collection.Fields.Add(&core.TextField{
+			Name: "comment",
+			Max:  5000,
+		})
+
+		// Display name of whoever made the correction, they usually have no
+		// account here
+		collection.Fields.Add(&core.TextField{
+			Name: "corrector",
+			Max:  100,
+		})
+
+		// Where the correction was imported from, empty when made here
+		collection.Fields.Add(&core.SelectField{
+			Name:      "source",
+			MaxSelect: 1,
+			Values:    []string{"lang8", "langcorrect"},
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "updated",
+			OnCreate: true,
+			OnUpdate: true,
+		})
+
+		collection.AddIndex("idx_corrections_by_journal_entry", false, "journal_entry", "")
+
+		err = app.Save(collection)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}, func(app core.App) error { // optional revert operation
+		collection, err := app.FindCollectionByNameOrId("corrections")
+		if err != nil {
+			return err
+		}
+
+		return app.Delete(collection)
+	})
+}
@@ -0,0 +1,43 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("decks")
+		if err != nil {
+			return err
+		}
+
+		// Anyone with the token can read the deck, published or not. Tokens
+		// are only ever issued by the share route, so clients can't pick a
+		// guessable one.
+		collection.Fields.Add(&core.TextField{
+			Name: "share_token",
+			Max:  64,
+		})
+		collection.AddIndex("idx_decks_share_token", true, "share_token", "share_token != ''")
+
+		collection.CreateRule = types.Pointer("@request.auth.id != '' && @request.body.user = @request.auth.id && @request.body.share_token:isset = false")
+		collection.UpdateRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id && (@request.body.user:isset = false || @request.body.user = @request.auth.id) && @request.body.share_token:isset = false")
+
+		return app.Save(collection)
+	}, func(app core.App) error { // optional revert operation
+		collection, err := app.FindCollectionByNameOrId("decks")
+		if err != nil {
+			return err
+		}
+
+		collection.RemoveIndex("idx_decks_share_token")
+		collection.Fields.RemoveByName("share_token")
+
+		collection.CreateRule = types.Pointer("@request.auth.id != '' && @request.body.user = @request.auth.id")
+		collection.UpdateRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id && (@request.body.user:isset = false || @request.body.user = @request.auth.id)")
+
+		return app.Save(collection)
+	})
+}
@@ -0,0 +1,56 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Content copied from elsewhere remembers where it came from, so importing it
+// again updates the copy instead of adding another
+var sourcedCollections = []string{"grammar", "decks"}
+
+func init() {
+	m.Register(func(app core.App) error {
+		for _, name := range sourcedCollections {
+			collection, err := app.FindCollectionByNameOrId(name)
+			if err != nil {
+				return err
+			}
+
+			// e.g. the instance a deck was imported from
+			collection.Fields.Add(&core.TextField{
+				Name: "source",
+				Max:  500,
+			})
+			// the id the content has at its source
+			collection.Fields.Add(&core.TextField{
+				Name: "source_id",
+				Max:  100,
+			})
+			collection.AddIndex("idx_"+name+"_by_source", false, "user, source, source_id", "source != ''")
+
+			if err := app.Save(collection); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, func(app core.App) error { // optional revert operation
+		for _, name := range sourcedCollections {
+			collection, err := app.FindCollectionByNameOrId(name)
+			if err != nil {
+				return err
+			}
+
+			collection.RemoveIndex("idx_" + name + "_by_source")
+			collection.Fields.RemoveByName("source")
+			collection.Fields.RemoveByName("source_id")
+
+			if err := app.Save(collection); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
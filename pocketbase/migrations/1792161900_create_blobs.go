@@ -0,0 +1,107 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		// Uploaded files are stored once per content, whatever number of media
+		// records point at them
+		collection := core.NewBaseCollection("blobs")
+
+		// sha256 of the content, hex encoded
+		collection.Fields.Add(&core.TextField{
+			Name:     "hash",
+			Required: true,
+			Min:      64,
+			Max:      64,
+		})
+
+		collection.Fields.Add(&core.FileField{
+			Name:      "file",
+			Required:  true,
+			MaxSelect: 1,
+			MaxSize:   50 << 20,
+		})
+
+		collection.Fields.Add(&core.NumberField{
+			Name:    "size",
+			OnlyInt: true,
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "updated",
+			OnCreate: true,
+			OnUpdate: true,
+		})
+
+		collection.AddIndex("idx_blobs_hash", true, "hash", "")
+
+		if err := app.Save(collection); err != nil {
+			return err
+		}
+
+		media, err := app.FindCollectionByNameOrId("media")
+		if err != nil {
+			return err
+		}
+
+		// New uploads end up in a blob, media saved before keep their file
+		media.Fields.Add(&core.RelationField{
+			Name:         "blob",
+			MaxSelect:    1,
+			CollectionId: collection.Id,
+		})
+		media.Fields.Add(&core.TextField{
+			Name: "hash",
+			Max:  64,
+		})
+		media.Fields.GetByName("file").(*core.FileField).Required = false
+		media.AddIndex("idx_media_by_blob", false, "blob", "")
+
+		// pointing media at a blob would give access to someone else's file
+		media.CreateRule = types.Pointer("@request.auth.id != '' && @request.body.user = @request.auth.id && @request.body.blob:isset = false && @request.body.hash:isset = false")
+		media.UpdateRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id && (@request.body.user:isset = false || @request.body.user = @request.auth.id) && @request.body.blob:isset = false && @request.body.hash:isset = false")
+
+		if err := app.Save(media); err != nil {
+			return err
+		}
+
+		// Only the server writes blobs, users can read the ones their media
+		// use. The rules need the relation above to exist.
+		collection.ViewRule = types.Pointer("@request.auth.id != '' && media_via_blob.user ?= @request.auth.id")
+		collection.ListRule = types.Pointer("@request.auth.id != '' && media_via_blob.user ?= @request.auth.id")
+
+		return app.Save(collection)
+	}, func(app core.App) error { // optional revert operation
+		media, err := app.FindCollectionByNameOrId("media")
+		if err != nil {
+			return err
+		}
+
+		media.RemoveIndex("idx_media_by_blob")
+		media.CreateRule = types.Pointer("@request.auth.id != '' && @request.body.user = @request.auth.id")
+		media.UpdateRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id && (@request.body.user:isset = false || @request.body.user = @request.auth.id)")
+		media.Fields.RemoveByName("blob")
+		media.Fields.RemoveByName("hash")
+		media.Fields.GetByName("file").(*core.FileField).Required = true
+		if err := app.Save(media); err != nil {
+			return err
+		}
+
+		collection, err := app.FindCollectionByNameOrId("blobs")
+		if err != nil {
+			return err
+		}
+
+		return app.Delete(collection)
+	})
+}
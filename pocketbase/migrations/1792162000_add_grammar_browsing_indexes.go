@@ -0,0 +1,58 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// browsingIndexes back the grammar browsing queries of both clients, the
+// most frequent ones by far. Tags are a json array rather than a join table,
+// so tag filters can't use an index and lean on these to narrow the rows.
+var browsingIndexes = []struct {
+	collection string
+	name       string
+	columns    string
+}{
+	// grammar of one language, the library's or a user's
+	{"grammar", "idx_grammar_by_language_user", "language, user"},
+	// what changed since a client last synced
+	{"grammar", "idx_grammar_by_user_updated", "user, updated"},
+	// the search route, which filters by user and pages by usage then id
+	{"grammar", "idx_grammar_by_user_usage", "user, usage, id"},
+	// the deck list, sorted by name
+	{"decks", "idx_decks_by_user_name", "user, name"},
+}
+
+func init() {
+	m.Register(func(app core.App) error {
+		for _, index := range browsingIndexes {
+			collection, err := app.FindCollectionByNameOrId(index.collection)
+			if err != nil {
+				return err
+			}
+
+			collection.AddIndex(index.name, false, index.columns, "")
+
+			if err := app.Save(collection); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, func(app core.App) error { // optional revert operation
+		for _, index := range browsingIndexes {
+			collection, err := app.FindCollectionByNameOrId(index.collection)
+			if err != nil {
+				return err
+			}
+
+			collection.RemoveIndex(index.name)
+
+			if err := app.Save(collection); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,160 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		usersCollection, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		// An import is staged first, so the user can preview and fix what
+		// was read before committing it. Only ever written by the server.
+		imports := core.NewBaseCollection("imports")
+		imports.ViewRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id")
+		imports.ListRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id")
+
+		imports.Fields.Add(&core.RelationField{
+			Name:          "user",
+			Required:      true,
+			CascadeDelete: true,
+			CollectionId:  usersCollection.Id,
+		})
+		imports.Fields.Add(&core.TextField{
+			Name:     "kind",
+			Required: true,
+		})
+		imports.Fields.Add(&core.SelectField{
+			Name:      "status",
+			Required:  true,
+			MaxSelect: 1,
+			Values:    []string{"staged", "committed", "rolled_back"},
+		})
+		// the uploaded archive, kept while staged for the attachments it holds
+		imports.Fields.Add(&core.FileField{
+			Name:      "archive",
+			MaxSelect: 1,
+			MaxSize:   32 << 20,
+			Protected: true,
+		})
+		// parts of the archive that couldn't be read at all
+		imports.Fields.Add(&core.JSONField{
+			Name: "problems",
+		})
+		imports.Fields.Add(&core.JSONField{
+			Name: "result",
+		})
+		imports.Fields.Add(&core.DateField{
+			Name: "committed",
+		})
+		imports.Fields.Add(&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		})
+		imports.Fields.Add(&core.AutodateField{
+			Name:     "updated",
+			OnCreate: true,
+			OnUpdate: true,
+		})
+		imports.AddIndex("idx_imports_by_user_created", false, "user, created", "")
+		imports.AddIndex("idx_imports_by_status_created", false, "status, created", "")
+
+		if err := app.Save(imports); err != nil {
+			return err
+		}
+
+		// One staged journal entry, edited through the imports routes
+		items := core.NewBaseCollection("import_items")
+		items.ViewRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id")
+		items.ListRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id")
+
+		items.Fields.Add(&core.RelationField{
+			Name:          "import",
+			Required:      true,
+			CascadeDelete: true,
+			CollectionId:  imports.Id,
+		})
+		items.Fields.Add(&core.RelationField{
+			Name:          "user",
+			Required:      true,
+			CascadeDelete: true,
+			CollectionId:  usersCollection.Id,
+		})
+		// order in the archive
+		items.Fields.Add(&core.NumberField{
+			Name:    "position",
+			OnlyInt: true,
+		})
+		items.Fields.Add(&core.TextField{
+			Name: "source",
+		})
+		items.Fields.Add(&core.JSONField{
+			Name:    "entry",
+			MaxSize: 1 << 20,
+		})
+		items.Fields.Add(&core.SelectField{
+			Name:      "status",
+			Required:  true,
+			MaxSelect: 1,
+			Values:    []string{"ready", "duplicate", "invalid"},
+		})
+		items.Fields.Add(&core.TextField{
+			Name: "error",
+		})
+		// left out when committing
+		items.Fields.Add(&core.BoolField{
+			Name: "skip",
+		})
+		items.AddIndex("idx_import_items_by_import_position", false, "import, position", "")
+
+		if err := app.Save(items); err != nil {
+			return err
+		}
+
+		// Entries remember the import that created them, so it can be rolled
+		// back. Set by the server only.
+		journal, err := app.FindCollectionByNameOrId("journal_entry")
+		if err != nil {
+			return err
+		}
+		journal.Fields.Add(&core.RelationField{
+			Name:         "import",
+			MaxSelect:    1,
+			CollectionId: imports.Id,
+		})
+		journal.AddIndex("idx_journal_entry_by_import", false, "import", "import != ''")
+		journal.CreateRule = types.Pointer("@request.auth.id != '' && @request.body.user = @request.auth.id && @request.body.import:isset = false")
+		journal.UpdateRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id && (@request.body.user:isset = false || @request.body.user = @request.auth.id) && @request.body.import:isset = false")
+
+		return app.Save(journal)
+	}, func(app core.App) error { // optional revert operation
+		journal, err := app.FindCollectionByNameOrId("journal_entry")
+		if err != nil {
+			return err
+		}
+		journal.RemoveIndex("idx_journal_entry_by_import")
+		journal.Fields.RemoveByName("import")
+		journal.CreateRule = types.Pointer("@request.auth.id != '' && @request.body.user = @request.auth.id")
+		journal.UpdateRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id && (@request.body.user:isset = false || @request.body.user = @request.auth.id)")
+		if err := app.Save(journal); err != nil {
+			return err
+		}
+
+		for _, name := range []string{"import_items", "imports"} {
+			collection, err := app.FindCollectionByNameOrId(name)
+			if err != nil {
+				return err
+			}
+			if err := app.Delete(collection); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,84 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Journal entries remember where they came from like grammar and decks do,
+// so users can filter by origin and importing the same export again updates
+// the entries instead of adding them twice
+func init() {
+	m.Register(func(app core.App) error {
+		journal, err := app.FindCollectionByNameOrId("journal_entry")
+		if err != nil {
+			return err
+		}
+
+		// e.g. dayone, or the site corrections came from
+		journal.Fields.Add(&core.TextField{
+			Name: "source",
+			Max:  500,
+		})
+		// the id the entry has at its source
+		journal.Fields.Add(&core.TextField{
+			Name: "source_id",
+			Max:  100,
+		})
+		journal.AddIndex("idx_journal_entry_by_source", false, "user, source, source_id", "source != ''")
+
+		if err := app.Save(journal); err != nil {
+			return err
+		}
+
+		imports, err := app.FindCollectionByNameOrId("imports")
+		if err != nil {
+			return err
+		}
+		// the source the entries of the import get
+		imports.Fields.Add(&core.TextField{
+			Name: "source",
+			Max:  500,
+		})
+		if err := app.Save(imports); err != nil {
+			return err
+		}
+
+		items, err := app.FindCollectionByNameOrId("import_items")
+		if err != nil {
+			return err
+		}
+		// update items replace an entry imported before from the same source
+		items.Fields.GetByName("status").(*core.SelectField).Values = []string{"ready", "update", "duplicate", "invalid"}
+
+		return app.Save(items)
+	}, func(app core.App) error { // optional revert operation
+		items, err := app.FindCollectionByNameOrId("import_items")
+		if err != nil {
+			return err
+		}
+		items.Fields.GetByName("status").(*core.SelectField).Values = []string{"ready", "duplicate", "invalid"}
+		if err := app.Save(items); err != nil {
+			return err
+		}
+
+		imports, err := app.FindCollectionByNameOrId("imports")
+		if err != nil {
+			return err
+		}
+		imports.Fields.RemoveByName("source")
+		if err := app.Save(imports); err != nil {
+			return err
+		}
+
+		journal, err := app.FindCollectionByNameOrId("journal_entry")
+		if err != nil {
+			return err
+		}
+		journal.RemoveIndex("idx_journal_entry_by_source")
+		journal.Fields.RemoveByName("source")
+		journal.Fields.RemoveByName("source_id")
+
+		return app.Save(journal)
+	})
+}
@@ -0,0 +1,87 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		collection := core.NewBaseCollection("sessions")
+
+		// Sessions are started and stopped through the sessions routes, which
+		// work out their duration. Users can delete ones recorded by mistake.
+		collection.ViewRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id")
+		collection.ListRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id")
+		collection.DeleteRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id")
+
+		usersCollection, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+		collection.Fields.Add(&core.RelationField{
+			Name:          "user",
+			Required:      true,
+			CascadeDelete: true,
+			CollectionId:  usersCollection.Id,
+		})
+
+		collection.Fields.Add(&core.SelectField{
+			Name:      "kind",
+			Required:  true,
+			MaxSelect: 1,
+			Values:    []string{"writing", "review"},
+		})
+
+		collection.Fields.Add(&core.DateField{
+			Name:     "started",
+			Required: true,
+		})
+
+		// empty while the session runs
+		collection.Fields.Add(&core.DateField{
+			Name: "ended",
+		})
+
+		// in seconds, set once the session ends
+		collection.Fields.Add(&core.NumberField{
+			Name:    "duration",
+			OnlyInt: true,
+			Min:     types.Pointer(0.0),
+		})
+
+		// the entry a writing session was spent on, if any
+		journalCollection, err := app.FindCollectionByNameOrId("journal_entry")
+		if err != nil {
+			return err
+		}
+		collection.Fields.Add(&core.RelationField{
+			Name:         "journal_entry",
+			MaxSelect:    1,
+			CollectionId: journalCollection.Id,
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "updated",
+			OnCreate: true,
+			OnUpdate: true,
+		})
+
+		collection.AddIndex("idx_sessions_by_user_started", false, "user, started", "")
+
+		return app.Save(collection)
+	}, func(app core.App) error { // optional revert operation
+		collection, err := app.FindCollectionByNameOrId("sessions")
+		if err != nil {
+			return err
+		}
+
+		return app.Delete(collection)
+	})
+}
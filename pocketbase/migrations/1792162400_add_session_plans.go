@@ -0,0 +1,55 @@
+package migrations
+
+import (
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Guided study sessions are planned by the server: a target duration and the
+// cards to review and learn in it. They record whether the user saw them
+// through, which achievements can build on.
+func init() {
+	m.Register(func(app core.App) error {
+		sessions, err := app.FindCollectionByNameOrId("sessions")
+		if err != nil {
+			return err
+		}
+
+		sessions.Fields.GetByName("kind").(*core.SelectField).Values = []string{"writing", "review", "study"}
+
+		// in seconds, zero for sessions that weren't planned
+		sessions.Fields.Add(&core.NumberField{
+			Name:    "target_duration",
+			OnlyInt: true,
+			Min:     types.Pointer(0.0),
+		})
+		// the srs records planned for review and to learn
+		sessions.Fields.Add(&core.JSONField{
+			Name:    "plan",
+			MaxSize: 100 * 1024,
+		})
+		// set when a planned session is stopped having reached its target
+		sessions.Fields.Add(&core.BoolField{
+			Name: "completed",
+		})
+
+		return app.Save(sessions)
+	}, func(app core.App) error { // optional revert operation
+		sessions, err := app.FindCollectionByNameOrId("sessions")
+		if err != nil {
+			return err
+		}
+
+		if _, err := app.DB().Delete("sessions", dbx.HashExp{"kind": "study"}).Execute(); err != nil {
+			return err
+		}
+		sessions.Fields.GetByName("kind").(*core.SelectField).Values = []string{"writing", "review"}
+		sessions.Fields.RemoveByName("target_duration")
+		sessions.Fields.RemoveByName("plan")
+		sessions.Fields.RemoveByName("completed")
+
+		return app.Save(sessions)
+	})
+}
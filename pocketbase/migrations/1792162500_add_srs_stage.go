@@ -0,0 +1,50 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Cards carry their mastery stage, kept up to date by a hook as they're
+// reviewed, so progress screens can filter and break cards down by it
+func init() {
+	m.Register(func(app core.App) error {
+		srs, err := app.FindCollectionByNameOrId("srs")
+		if err != nil {
+			return err
+		}
+
+		srs.Fields.Add(&core.SelectField{
+			Name:      "stage",
+			MaxSelect: 1,
+			Values:    []string{"learning", "young", "mature", "mastered", "burned"},
+		})
+		srs.AddIndex("idx_srs_by_user_stage", false, "user, stage", "")
+
+		if err := app.Save(srs); err != nil {
+			return err
+		}
+
+		// same cutoffs as srs.Card.DerivedStage
+		_, err = app.DB().NewQuery(`
+			UPDATE srs SET stage = CASE
+				WHEN last_reviewed = '' OR repetition = 0 OR interval_days < 7 THEN 'learning'
+				WHEN interval_days < 21 THEN 'young'
+				WHEN interval_days < 90 THEN 'mature'
+				WHEN interval_days < 180 THEN 'mastered'
+				ELSE 'burned'
+			END
+		`).Execute()
+		return err
+	}, func(app core.App) error { // optional revert operation
+		srs, err := app.FindCollectionByNameOrId("srs")
+		if err != nil {
+			return err
+		}
+
+		srs.RemoveIndex("idx_srs_by_user_stage")
+		srs.Fields.RemoveByName("stage")
+
+		return app.Save(srs)
+	})
+}
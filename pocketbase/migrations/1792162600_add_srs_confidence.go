@@ -0,0 +1,37 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Users can rate how confident they feel about a grammar when they add it,
+// which the calibration report holds against how well they retain it
+func init() {
+	m.Register(func(app core.App) error {
+		srs, err := app.FindCollectionByNameOrId("srs")
+		if err != nil {
+			return err
+		}
+
+		// from 1 (no idea) to 5 (know it cold), optional
+		srs.Fields.Add(&core.NumberField{
+			Name:    "confidence",
+			OnlyInt: true,
+			Min:     types.Pointer(1.0),
+			Max:     types.Pointer(5.0),
+		})
+
+		return app.Save(srs)
+	}, func(app core.App) error { // optional revert operation
+		srs, err := app.FindCollectionByNameOrId("srs")
+		if err != nil {
+			return err
+		}
+
+		srs.Fields.RemoveByName("confidence")
+
+		return app.Save(srs)
+	})
+}
@@ -0,0 +1,105 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		usersCollection, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+		grammarCollection, err := app.FindCollectionByNameOrId("grammar")
+		if err != nil {
+			return err
+		}
+
+		// A user's memory aid for a grammar, which they can share with the
+		// community. Votes are counted by the server.
+		mnemonics := core.NewBaseCollection("mnemonics")
+		mnemonics.ViewRule = types.Pointer("@request.auth.id != '' && (user = @request.auth.id || public = true)")
+		mnemonics.ListRule = types.Pointer("@request.auth.id != '' && (user = @request.auth.id || public = true)")
+		mnemonics.CreateRule = types.Pointer("@request.auth.id != '' && @request.body.user = @request.auth.id && @request.body.votes:isset = false")
+		mnemonics.UpdateRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id && @request.body.user:isset = false && @request.body.grammar:isset = false && @request.body.votes:isset = false")
+		mnemonics.DeleteRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id")
+
+		mnemonics.Fields.Add(&core.RelationField{
+			Name:          "user",
+			Required:      true,
+			CascadeDelete: true,
+			CollectionId:  usersCollection.Id,
+		})
+		mnemonics.Fields.Add(&core.RelationField{
+			Name:          "grammar",
+			Required:      true,
+			CascadeDelete: true,
+			CollectionId:  grammarCollection.Id,
+		})
+		mnemonics.Fields.Add(&core.TextField{
+			Name:     "text",
+			Required: true,
+			Max:      2000,
+		})
+		mnemonics.Fields.Add(&core.BoolField{
+			Name: "public",
+		})
+		mnemonics.Fields.Add(&core.NumberField{
+			Name:    "votes",
+			OnlyInt: true,
+			Min:     types.Pointer(0.0),
+		})
+		mnemonics.Fields.Add(&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		})
+		mnemonics.Fields.Add(&core.AutodateField{
+			Name:     "updated",
+			OnCreate: true,
+			OnUpdate: true,
+		})
+		// one per user and grammar
+		mnemonics.AddIndex("idx_mnemonics_by_user_grammar", true, "user, grammar", "")
+		mnemonics.AddIndex("idx_mnemonics_by_grammar_votes", false, "grammar, votes", "public = true")
+
+		if err := app.Save(mnemonics); err != nil {
+			return err
+		}
+
+		// Who voted for what, only written through the vote routes
+		votes := core.NewBaseCollection("mnemonic_votes")
+		votes.Fields.Add(&core.RelationField{
+			Name:          "mnemonic",
+			Required:      true,
+			CascadeDelete: true,
+			CollectionId:  mnemonics.Id,
+		})
+		votes.Fields.Add(&core.RelationField{
+			Name:          "user",
+			Required:      true,
+			CascadeDelete: true,
+			CollectionId:  usersCollection.Id,
+		})
+		votes.Fields.Add(&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		})
+		votes.AddIndex("idx_mnemonic_votes_by_mnemonic_user", true, "mnemonic, user", "")
+		votes.AddIndex("idx_mnemonic_votes_by_user", false, "user", "")
+
+		return app.Save(votes)
+	}, func(app core.App) error { // optional revert operation
+		for _, name := range []string{"mnemonic_votes", "mnemonics"} {
+			collection, err := app.FindCollectionByNameOrId(name)
+			if err != nil {
+				return err
+			}
+			if err := app.Delete(collection); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
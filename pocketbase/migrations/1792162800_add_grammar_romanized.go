@@ -0,0 +1,57 @@
+package migrations
+
+import (
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/grammar"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Grammar keeps its usage in romaji next to it, kept up to date by a hook, so
+// searching without a Japanese keyboard still finds it
+func init() {
+	m.Register(func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("grammar")
+		if err != nil {
+			return err
+		}
+
+		collection.Fields.Add(&core.TextField{
+			Name: "romanized",
+			Max:  1000,
+		})
+		if err := app.Save(collection); err != nil {
+			return err
+		}
+
+		languages, err := app.FindAllRecords("languages")
+		if err != nil {
+			return err
+		}
+		names := map[string]string{}
+		for _, language := range languages {
+			names[language.Id] = language.GetString("name")
+		}
+
+		records, err := app.FindAllRecords("grammar")
+		if err != nil {
+			return err
+		}
+		for _, record := range records {
+			record.Set("romanized", grammar.Romanized(names[record.GetString("language")], record.GetString("usage")))
+			if err := app.SaveNoValidate(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, func(app core.App) error { // optional revert operation
+		collection, err := app.FindCollectionByNameOrId("grammar")
+		if err != nil {
+			return err
+		}
+
+		collection.Fields.RemoveByName("romanized")
+
+		return app.Save(collection)
+	})
+}
@@ -0,0 +1,38 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Grammar carries its rank in a corpus frequency list superusers import, so
+// the library can be browsed and new cards learned most common first
+func init() {
+	m.Register(func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("grammar")
+		if err != nil {
+			return err
+		}
+
+		// 1 is the most frequent, zero when the list doesn't have it
+		collection.Fields.Add(&core.NumberField{
+			Name:    "frequency_rank",
+			OnlyInt: true,
+			Min:     types.Pointer(0.0),
+		})
+		collection.AddIndex("idx_grammar_by_language_frequency", false, "language, frequency_rank", "frequency_rank > 0")
+
+		return app.Save(collection)
+	}, func(app core.App) error { // optional revert operation
+		collection, err := app.FindCollectionByNameOrId("grammar")
+		if err != nil {
+			return err
+		}
+
+		collection.RemoveIndex("idx_grammar_by_language_frequency")
+		collection.Fields.RemoveByName("frequency_rank")
+
+		return app.Save(collection)
+	})
+}
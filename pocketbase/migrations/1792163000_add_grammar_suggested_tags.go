@@ -0,0 +1,33 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Grammar users add without tags gets tags proposed in the background, which
+// wait here until the user accepts or dismisses them
+func init() {
+	m.Register(func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("grammar")
+		if err != nil {
+			return err
+		}
+
+		collection.Fields.Add(&core.JSONField{
+			Name:    "suggested_tags",
+			MaxSize: 2 * 1024,
+		})
+
+		return app.Save(collection)
+	}, func(app core.App) error { // optional revert operation
+		collection, err := app.FindCollectionByNameOrId("grammar")
+		if err != nil {
+			return err
+		}
+
+		collection.Fields.RemoveByName("suggested_tags")
+
+		return app.Save(collection)
+	})
+}
@@ -0,0 +1,48 @@
+package migrations
+
+import (
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/journal"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Journal entries are tagged with their topics as they're saved, so users can
+// look back at what they wrote about work, travel and so on
+func init() {
+	m.Register(func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("journal_entry")
+		if err != nil {
+			return err
+		}
+
+		collection.Fields.Add(&core.JSONField{
+			Name:    "topics",
+			MaxSize: 2 * 1024,
+		})
+		if err := app.Save(collection); err != nil {
+			return err
+		}
+
+		records, err := app.FindAllRecords("journal_entry")
+		if err != nil {
+			return err
+		}
+		for _, record := range records {
+			record.Set("topics", journal.ExtractTopics(journal.Text(record)))
+			if err := app.SaveNoValidate(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, func(app core.App) error { // optional revert operation
+		collection, err := app.FindCollectionByNameOrId("journal_entry")
+		if err != nil {
+			return err
+		}
+
+		collection.Fields.RemoveByName("topics")
+
+		return app.Save(collection)
+	})
+}
@@ -0,0 +1,125 @@
+package migrations
+
+import (
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+var weeklyDigestTemplates = []struct {
+	locale, subject, body string
+}{
+	{
+		"en",
+		"Your {{.AppName}} week: {{.EntryCount}} journal entries",
+		`<p>Hello{{if .Name}} {{.Name}}{{end}},</p>
+<p>You wrote {{.EntryCount}} journal entries last week and {{.DueCount}} grammar points are due for review.</p>
+{{if .Summary}}<p>{{.Summary}}</p>{{end}}
+<p>Your feedback report has the mistakes that keep coming back, the patterns you lean on and grammar to try next.</p>
+<p><a class="btn" href="{{.ActionURL}}" target="_blank" rel="noopener">Read your report</a></p>
+<p>Thanks,<br/>{{.AppName}} team</p>`,
+	},
+	{
+		"ja",
+		"{{.AppName}} 今週のふりかえり：日記{{.EntryCount}}件",
+		`<p>{{if .Name}}{{.Name}}様{{else}}こんにちは{{end}}、</p>
+<p>先週は日記を{{.EntryCount}}件書きました。復習する文法が{{.DueCount}}件あります。</p>
+{{if .Summary}}<p>{{.Summary}}</p>{{end}}
+<p>フィードバックレポートでは、繰り返している間違い、よく使う文型、次に使ってみたい文法を確認できます。</p>
+<p><a class="btn" href="{{.ActionURL}}" target="_blank" rel="noopener">レポートを読む</a></p>
+<p>{{.AppName}}チーム</p>`,
+	},
+}
+
+// Weekly feedback reports on what users wrote in their journal, generated by
+// a job every week and linked from a digest email
+func init() {
+	m.Register(func(app core.App) error {
+		// Only written by the report job, users can read and delete theirs
+		collection := core.NewBaseCollection("reports")
+		collection.ViewRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id")
+		collection.ListRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id")
+		collection.DeleteRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id")
+
+		usersCollection, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+		collection.Fields.Add(&core.RelationField{
+			Name:          "user",
+			Required:      true,
+			CascadeDelete: true,
+			CollectionId:  usersCollection.Id,
+		})
+
+		// the report covers [week_start, week_start + 7 days)
+		collection.Fields.Add(&core.DateField{
+			Name:     "week_start",
+			Required: true,
+		})
+
+		collection.Fields.Add(&core.NumberField{
+			Name:    "entries",
+			OnlyInt: true,
+			Min:     types.Pointer(0.0),
+		})
+
+		collection.Fields.Add(&core.JSONField{
+			Name:    "feedback",
+			MaxSize: 100 * 1024,
+		})
+
+		// whether a model wrote the feedback or only the built-in analysis
+		collection.Fields.Add(&core.SelectField{
+			Name:      "generated_by",
+			Required:  true,
+			MaxSelect: 1,
+			Values:    []string{"rules", "model"},
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "updated",
+			OnCreate: true,
+			OnUpdate: true,
+		})
+
+		collection.AddIndex("idx_reports_by_user_week", true, "user, week_start", "")
+
+		if err := app.Save(collection); err != nil {
+			return err
+		}
+
+		templates, err := app.FindCollectionByNameOrId("email_templates")
+		if err != nil {
+			return err
+		}
+		for _, t := range weeklyDigestTemplates {
+			record := core.NewRecord(templates)
+			record.Set("key", "weekly_digest")
+			record.Set("locale", t.locale)
+			record.Set("subject", t.subject)
+			record.Set("body", t.body)
+			if err := app.Save(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, func(app core.App) error { // optional revert operation
+		if _, err := app.DB().Delete("email_templates", dbx.HashExp{"key": "weekly_digest"}).Execute(); err != nil {
+			return err
+		}
+
+		collection, err := app.FindCollectionByNameOrId("reports")
+		if err != nil {
+			return err
+		}
+
+		return app.Delete(collection)
+	})
+}
@@ -0,0 +1,102 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Corrections the user accepts are filed as categorized errors, so they can
+// see which kinds of mistakes they're growing out of
+func init() {
+	m.Register(func(app core.App) error {
+		corrections, err := app.FindCollectionByNameOrId("corrections")
+		if err != nil {
+			return err
+		}
+		corrections.Fields.Add(&core.BoolField{
+			Name: "accepted",
+		})
+		if err := app.Save(corrections); err != nil {
+			return err
+		}
+
+		// Only written by a hook as corrections are accepted
+		collection := core.NewBaseCollection("errors")
+		collection.ViewRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id")
+		collection.ListRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id")
+
+		usersCollection, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+		collection.Fields.Add(&core.RelationField{
+			Name:          "user",
+			Required:      true,
+			CascadeDelete: true,
+			CollectionId:  usersCollection.Id,
+		})
+
+		collection.Fields.Add(&core.RelationField{
+			Name:          "correction",
+			Required:      true,
+			CascadeDelete: true,
+			CollectionId:  corrections.Id,
+		})
+
+		collection.Fields.Add(&core.SelectField{
+			Name:      "category",
+			Required:  true,
+			MaxSelect: 1,
+			Values:    []string{"particle", "conjugation", "register", "vocabulary", "other"},
+		})
+
+		// what the correction changed, with what both sentences share trimmed
+		collection.Fields.Add(&core.TextField{
+			Name: "original",
+			Max:  2000,
+		})
+		collection.Fields.Add(&core.TextField{
+			Name: "corrected",
+			Max:  2000,
+		})
+
+		// when the mistake was made, the date of the entry it was made in
+		collection.Fields.Add(&core.DateField{
+			Name:     "occurred",
+			Required: true,
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "updated",
+			OnCreate: true,
+			OnUpdate: true,
+		})
+
+		collection.AddIndex("idx_errors_by_correction", true, "correction", "")
+		collection.AddIndex("idx_errors_by_user_occurred", false, "user, occurred", "")
+
+		return app.Save(collection)
+	}, func(app core.App) error { // optional revert operation
+		collection, err := app.FindCollectionByNameOrId("errors")
+		if err != nil {
+			return err
+		}
+		if err := app.Delete(collection); err != nil {
+			return err
+		}
+
+		corrections, err := app.FindCollectionByNameOrId("corrections")
+		if err != nil {
+			return err
+		}
+		corrections.Fields.RemoveByName("accepted")
+
+		return app.Save(corrections)
+	})
+}
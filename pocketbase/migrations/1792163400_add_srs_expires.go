@@ -0,0 +1,35 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Cards can be temporary, like the drills made from a user's recurring
+// mistakes, and are removed once they expire
+func init() {
+	m.Register(func(app core.App) error {
+		srs, err := app.FindCollectionByNameOrId("srs")
+		if err != nil {
+			return err
+		}
+
+		// empty for cards that stay
+		srs.Fields.Add(&core.DateField{
+			Name: "expires",
+		})
+		srs.AddIndex("idx_srs_by_expires", false, "expires", "expires != ''")
+
+		return app.Save(srs)
+	}, func(app core.App) error { // optional revert operation
+		srs, err := app.FindCollectionByNameOrId("srs")
+		if err != nil {
+			return err
+		}
+
+		srs.RemoveIndex("idx_srs_by_expires")
+		srs.Fields.RemoveByName("expires")
+
+		return app.Save(srs)
+	})
+}
@@ -0,0 +1,76 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Conversations users practice speaking with the model, in a scenario like
+// ordering food, with what to study from them once they're over
+func init() {
+	m.Register(func(app core.App) error {
+		// Only written through the conversation routes, which talk to the
+		// model, users can read and delete theirs
+		collection := core.NewBaseCollection("conversations")
+		collection.ViewRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id")
+		collection.ListRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id")
+		collection.DeleteRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id")
+
+		usersCollection, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+		collection.Fields.Add(&core.RelationField{
+			Name:          "user",
+			Required:      true,
+			CascadeDelete: true,
+			CollectionId:  usersCollection.Id,
+		})
+
+		// the preset the conversation plays out, "free" for none
+		collection.Fields.Add(&core.TextField{
+			Name:     "scenario",
+			Required: true,
+			Max:      50,
+		})
+
+		// the turns so far, as [{"role", "content", "sent"}]
+		collection.Fields.Add(&core.JSONField{
+			Name:    "messages",
+			MaxSize: 200 * 1024,
+		})
+
+		// what the model suggests studying, once the conversation ended
+		collection.Fields.Add(&core.JSONField{
+			Name:    "analysis",
+			MaxSize: 50 * 1024,
+		})
+
+		collection.Fields.Add(&core.DateField{
+			Name: "ended",
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "updated",
+			OnCreate: true,
+			OnUpdate: true,
+		})
+
+		collection.AddIndex("idx_conversations_by_user", false, "user, created", "")
+
+		return app.Save(collection)
+	}, func(app core.App) error { // optional revert operation
+		collection, err := app.FindCollectionByNameOrId("conversations")
+		if err != nil {
+			return err
+		}
+
+		return app.Delete(collection)
+	})
+}
@@ -0,0 +1,50 @@
+package migrations
+
+import (
+	"slices"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Journal entries spoken instead of written, transcribed from a recording
+// kept alongside as audio media, and corrections written by a model
+func init() {
+	m.Register(func(app core.App) error {
+		journal, err := app.FindCollectionByNameOrId("journal_entry")
+		if err != nil {
+			return err
+		}
+		journal.Fields.Add(&core.BoolField{
+			Name: "spoken",
+		})
+		if err := app.Save(journal); err != nil {
+			return err
+		}
+
+		corrections, err := app.FindCollectionByNameOrId("corrections")
+		if err != nil {
+			return err
+		}
+		source := corrections.Fields.GetByName("source").(*core.SelectField)
+		source.Values = append(source.Values, "model")
+		return app.Save(corrections)
+	}, func(app core.App) error { // optional revert operation
+		corrections, err := app.FindCollectionByNameOrId("corrections")
+		if err != nil {
+			return err
+		}
+		source := corrections.Fields.GetByName("source").(*core.SelectField)
+		source.Values = slices.DeleteFunc(source.Values, func(v string) bool { return v == "model" })
+		if err := app.Save(corrections); err != nil {
+			return err
+		}
+
+		journal, err := app.FindCollectionByNameOrId("journal_entry")
+		if err != nil {
+			return err
+		}
+		journal.Fields.RemoveByName("spoken")
+		return app.Save(journal)
+	})
+}
@@ -0,0 +1,108 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// A log of every request AI features send to the provider on a user's
+// behalf, so they can see what left the server
+func init() {
+	m.Register(func(app core.App) error {
+		// Only written by the server as calls are made, users can read and
+		// purge theirs
+		collection := core.NewBaseCollection("ai_audit")
+		collection.ViewRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id")
+		collection.ListRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id")
+		collection.DeleteRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id")
+
+		usersCollection, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+		collection.Fields.Add(&core.RelationField{
+			Name:          "user",
+			Required:      true,
+			CascadeDelete: true,
+			CollectionId:  usersCollection.Id,
+		})
+
+		// the host of the API the request went to
+		collection.Fields.Add(&core.TextField{
+			Name:     "provider",
+			Required: true,
+			Max:      255,
+		})
+
+		collection.Fields.Add(&core.TextField{
+			Name: "model",
+			Max:  100,
+		})
+
+		collection.Fields.Add(&core.SelectField{
+			Name:      "operation",
+			Required:  true,
+			MaxSelect: 1,
+			Values:    []string{"complete", "transcribe", "synthesize"},
+		})
+
+		// SHA-256 of what was sent, which isn't stored again
+		collection.Fields.Add(&core.TextField{
+			Name:     "prompt_hash",
+			Required: true,
+			Max:      64,
+		})
+
+		collection.Fields.Add(&core.NumberField{
+			Name:    "prompt_tokens",
+			OnlyInt: true,
+			Min:     types.Pointer(0.0),
+		})
+
+		collection.Fields.Add(&core.NumberField{
+			Name:    "completion_tokens",
+			OnlyInt: true,
+			Min:     types.Pointer(0.0),
+		})
+
+		// the record whose content was sent, empty for several or none
+		collection.Fields.Add(&core.TextField{
+			Name: "record_collection",
+			Max:  100,
+		})
+
+		collection.Fields.Add(&core.TextField{
+			Name: "record_id",
+			Max:  15,
+		})
+
+		collection.Fields.Add(&core.NumberField{
+			Name:    "duration_ms",
+			OnlyInt: true,
+			Min:     types.Pointer(0.0),
+		})
+
+		// why the request failed, empty when it didn't
+		collection.Fields.Add(&core.TextField{
+			Name: "error",
+			Max:  1000,
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		})
+
+		collection.AddIndex("idx_ai_audit_by_user_created", false, "user, created", "")
+
+		return app.Save(collection)
+	}, func(app core.App) error { // optional revert operation
+		collection, err := app.FindCollectionByNameOrId("ai_audit")
+		if err != nil {
+			return err
+		}
+
+		return app.Delete(collection)
+	})
+}
@@ -0,0 +1,79 @@
+package migrations
+
+import (
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/prompts"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// The prompts AI features send, editable by superusers a version at a time
+// and per language instead of being fixed in the code
+func init() {
+	m.Register(func(app core.App) error {
+		// No API rules, templates are edited from the dashboard by superusers
+		collection := core.NewBaseCollection("ai_prompts")
+
+		collection.Fields.Add(&core.TextField{
+			Name:     "key",
+			Required: true,
+			Max:      100,
+		})
+
+		// the name of the language the variant is for, empty for any
+		collection.Fields.Add(&core.TextField{
+			Name: "language",
+			Max:  100,
+		})
+
+		// assigned as templates are saved, the newest is the one in use
+		collection.Fields.Add(&core.NumberField{
+			Name:    "version",
+			OnlyInt: true,
+		})
+
+		// Plain text rather than an editor field so the rich text editor doesn't
+		// mangle template actions
+		collection.Fields.Add(&core.TextField{
+			Name:     "system",
+			Required: true,
+			Max:      20000,
+		})
+
+		// empty sends the feature's input as is
+		collection.Fields.Add(&core.TextField{
+			Name: "user",
+			Max:  20000,
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		})
+
+		collection.AddIndex("idx_ai_prompts_by_key_language_version", true, "`key`, `language`, `version`", "")
+
+		if err := app.Save(collection); err != nil {
+			return err
+		}
+
+		for key, system := range prompts.Defaults {
+			record := core.NewRecord(collection)
+			record.Set("key", key)
+			record.Set("version", 1)
+			record.Set("system", system)
+			if err := app.Save(record); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, func(app core.App) error { // optional revert operation
+		collection, err := app.FindCollectionByNameOrId("ai_prompts")
+		if err != nil {
+			return err
+		}
+
+		return app.Delete(collection)
+	})
+}
@@ -0,0 +1,143 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Users rate what the model wrote for them, and what it wrote keeps the
+// prompt template version it was rendered from, so prompt changes can be
+// judged by the ratings they get
+func init() {
+	m.Register(func(app core.App) error {
+		prompts, err := app.FindCollectionByNameOrId("ai_prompts")
+		if err != nil {
+			return err
+		}
+
+		// the template the output was rendered from, empty for output the
+		// model didn't write or rendered from a built in default
+		outputs := map[string]string{
+			"corrections":   "prompt",
+			"reports":       "prompt",
+			"conversations": "analysis_prompt",
+		}
+		for name, field := range outputs {
+			collection, err := app.FindCollectionByNameOrId(name)
+			if err != nil {
+				return err
+			}
+			collection.Fields.Add(&core.RelationField{
+				Name:         field,
+				CollectionId: prompts.Id,
+			})
+			if name == "corrections" {
+				// users write their own corrections, but only the server says
+				// which came from a prompt
+				collection.CreateRule = types.Pointer("@request.auth.id != '' && @request.body.user = @request.auth.id && @request.body.journal_entry.user = @request.auth.id && @request.body.prompt:isset = false")
+				collection.UpdateRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id && (@request.body.user:isset = false || @request.body.user = @request.auth.id) && @request.body.prompt:isset = false")
+			}
+			if err := app.Save(collection); err != nil {
+				return err
+			}
+		}
+
+		// Only written through the feedback routes, which check the output is
+		// the user's and the model's
+		collection := core.NewBaseCollection("ai_feedback")
+		collection.ViewRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id")
+		collection.ListRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id")
+		collection.DeleteRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id")
+
+		usersCollection, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+		collection.Fields.Add(&core.RelationField{
+			Name:          "user",
+			Required:      true,
+			CascadeDelete: true,
+			CollectionId:  usersCollection.Id,
+		})
+
+		// the output rated
+		collection.Fields.Add(&core.SelectField{
+			Name:      "output_collection",
+			Required:  true,
+			MaxSelect: 1,
+			Values:    []string{"corrections", "reports", "conversations"},
+		})
+
+		collection.Fields.Add(&core.TextField{
+			Name:     "output_id",
+			Required: true,
+			Max:      15,
+		})
+
+		// copied from the output when rated, as outputs are regenerated and
+		// deleted
+		collection.Fields.Add(&core.TextField{
+			Name:     "key",
+			Required: true,
+			Max:      100,
+		})
+
+		collection.Fields.Add(&core.RelationField{
+			Name:         "prompt",
+			CollectionId: prompts.Id,
+		})
+
+		collection.Fields.Add(&core.SelectField{
+			Name:      "rating",
+			Required:  true,
+			MaxSelect: 1,
+			Values:    []string{"up", "down"},
+		})
+
+		collection.Fields.Add(&core.TextField{
+			Name: "comment",
+			Max:  1000,
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "updated",
+			OnCreate: true,
+			OnUpdate: true,
+		})
+
+		collection.AddIndex("idx_ai_feedback_by_user_output", true, "user, output_collection, output_id", "")
+		collection.AddIndex("idx_ai_feedback_by_key_prompt", false, "`key`, prompt", "")
+
+		return app.Save(collection)
+	}, func(app core.App) error { // optional revert operation
+		collection, err := app.FindCollectionByNameOrId("ai_feedback")
+		if err != nil {
+			return err
+		}
+		if err := app.Delete(collection); err != nil {
+			return err
+		}
+
+		for name, field := range map[string]string{"corrections": "prompt", "reports": "prompt", "conversations": "analysis_prompt"} {
+			collection, err := app.FindCollectionByNameOrId(name)
+			if err != nil {
+				return err
+			}
+			collection.Fields.RemoveByName(field)
+			if name == "corrections" {
+				collection.CreateRule = types.Pointer("@request.auth.id != '' && @request.body.user = @request.auth.id && @request.body.journal_entry.user = @request.auth.id")
+				collection.UpdateRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id && (@request.body.user:isset = false || @request.body.user = @request.auth.id)")
+			}
+			if err := app.Save(collection); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
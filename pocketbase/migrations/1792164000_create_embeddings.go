@@ -0,0 +1,56 @@
+package migrations
+
+import (
+	"slices"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Grammar and journal entries are embedded for semantic search. Vectors are
+// blobs, which collections have no field for, so they get a plain table of
+// their own, keyed by the record they were made from.
+func init() {
+	m.Register(func(app core.App) error {
+		_, err := app.DB().NewQuery(`
+			CREATE TABLE IF NOT EXISTS _embeddings (
+				collection TEXT NOT NULL,
+				record     TEXT NOT NULL,
+				user       TEXT NOT NULL DEFAULT '',
+				model      TEXT NOT NULL,
+				hash       TEXT NOT NULL,
+				vector     BLOB NOT NULL,
+				updated    TEXT NOT NULL DEFAULT '',
+				PRIMARY KEY (collection, record)
+			)
+		`).Execute()
+		if err != nil {
+			return err
+		}
+		_, err = app.DB().NewQuery("CREATE INDEX IF NOT EXISTS idx__embeddings_by_collection_user ON _embeddings (collection, user)").Execute()
+		if err != nil {
+			return err
+		}
+
+		audit, err := app.FindCollectionByNameOrId("ai_audit")
+		if err != nil {
+			return err
+		}
+		operation := audit.Fields.GetByName("operation").(*core.SelectField)
+		operation.Values = append(operation.Values, "embed")
+		return app.Save(audit)
+	}, func(app core.App) error { // optional revert operation
+		audit, err := app.FindCollectionByNameOrId("ai_audit")
+		if err != nil {
+			return err
+		}
+		operation := audit.Fields.GetByName("operation").(*core.SelectField)
+		operation.Values = slices.DeleteFunc(operation.Values, func(v string) bool { return v == "embed" })
+		if err := app.Save(audit); err != nil {
+			return err
+		}
+
+		_, err = app.DB().NewQuery("DROP TABLE IF EXISTS _embeddings").Execute()
+		return err
+	})
+}
@@ -0,0 +1,130 @@
+package migrations
+
+import (
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/prompts"
+
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Comparisons of similar grammar written by the model, kept so the same
+// grammar compared again doesn't go back to it
+func init() {
+	m.Register(func(app core.App) error {
+		// Only written by the comparison jobs. Comparisons of library grammar
+		// have no user and are shared, ones including a user's own grammar
+		// are theirs.
+		collection := core.NewBaseCollection("grammar_comparisons")
+		collection.ViewRule = types.Pointer("@request.auth.id != '' && (user = '' || user = @request.auth.id)")
+		collection.ListRule = types.Pointer("@request.auth.id != '' && (user = '' || user = @request.auth.id)")
+
+		usersCollection, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+		collection.Fields.Add(&core.RelationField{
+			Name:          "user",
+			CascadeDelete: true,
+			CollectionId:  usersCollection.Id,
+		})
+
+		grammarCollection, err := app.FindCollectionByNameOrId("grammar")
+		if err != nil {
+			return err
+		}
+		// a comparison missing one of its grammar is no use, so it goes with
+		// any of them
+		collection.Fields.Add(&core.RelationField{
+			Name:          "grammar",
+			Required:      true,
+			CascadeDelete: true,
+			MinSelect:     2,
+			MaxSelect:     4,
+			CollectionId:  grammarCollection.Id,
+		})
+
+		// the grammar ids, sorted and comma separated
+		collection.Fields.Add(&core.TextField{
+			Name:     "key",
+			Required: true,
+			Max:      100,
+		})
+
+		// the locale explanations are written in
+		collection.Fields.Add(&core.TextField{
+			Name:     "locale",
+			Required: true,
+			Max:      10,
+		})
+
+		// of the grammar as compared, a comparison is written again once any
+		// of it is edited
+		collection.Fields.Add(&core.TextField{
+			Name:     "hash",
+			Required: true,
+			Max:      64,
+		})
+
+		collection.Fields.Add(&core.TextField{
+			Name: "summary",
+			Max:  2000,
+		})
+
+		collection.Fields.Add(&core.JSONField{
+			Name:    "aspects",
+			MaxSize: 100000,
+		})
+
+		collection.Fields.Add(&core.JSONField{
+			Name:    "examples",
+			MaxSize: 100000,
+		})
+
+		promptsCollection, err := app.FindCollectionByNameOrId("ai_prompts")
+		if err != nil {
+			return err
+		}
+		collection.Fields.Add(&core.RelationField{
+			Name:         "prompt",
+			CollectionId: promptsCollection.Id,
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "updated",
+			OnCreate: true,
+			OnUpdate: true,
+		})
+
+		collection.AddIndex("idx_grammar_comparisons_by_user_key_locale", true, "user, `key`, locale", "")
+
+		if err := app.Save(collection); err != nil {
+			return err
+		}
+
+		// the prompt collection was seeded with the defaults there were, this
+		// one is new unless the collection was created after it
+		existing, err := app.CountRecords(promptsCollection, dbx.HashExp{"key": prompts.KeyGrammarComparison})
+		if err != nil || existing > 0 {
+			return err
+		}
+		record := core.NewRecord(promptsCollection)
+		record.Set("key", prompts.KeyGrammarComparison)
+		record.Set("version", 1)
+		record.Set("system", prompts.Defaults[prompts.KeyGrammarComparison])
+		return app.Save(record)
+	}, func(app core.App) error { // optional revert operation
+		collection, err := app.FindCollectionByNameOrId("grammar_comparisons")
+		if err != nil {
+			return err
+		}
+
+		return app.Delete(collection)
+	})
+}
@@ -0,0 +1,177 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Entries are shown to an audience, only their writer, the users following
+// them that they approved, the members of some groups or every user, instead
+// of being private or not. Published entries stay visible to logged out visitors whatever their
+// audience.
+func init() {
+	m.Register(func(app core.App) error {
+		usersCollection, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		// a user following another, seen by both. Followers wait for the user
+		// they follow to approve them, and either can end the follow.
+		follows := core.NewBaseCollection("follows")
+		follows.ViewRule = types.Pointer("@request.auth.id != '' && (user = @request.auth.id || following = @request.auth.id)")
+		follows.ListRule = types.Pointer("@request.auth.id != '' && (user = @request.auth.id || following = @request.auth.id)")
+		follows.CreateRule = types.Pointer("@request.auth.id != '' && @request.body.user = @request.auth.id && @request.body.following != @request.auth.id && (@request.body.status:isset = false || @request.body.status = 'pending')")
+		follows.UpdateRule = types.Pointer("@request.auth.id != '' && following = @request.auth.id && @request.body.user:isset = false && @request.body.following:isset = false")
+		follows.DeleteRule = types.Pointer("@request.auth.id != '' && (user = @request.auth.id || following = @request.auth.id)")
+
+		follows.Fields.Add(&core.RelationField{
+			Name:          "user",
+			Required:      true,
+			CascadeDelete: true,
+			CollectionId:  usersCollection.Id,
+		})
+
+		follows.Fields.Add(&core.RelationField{
+			Name:          "following",
+			Required:      true,
+			CascadeDelete: true,
+			CollectionId:  usersCollection.Id,
+		})
+
+		// set to pending on new follows
+		follows.Fields.Add(&core.SelectField{
+			Name:      "status",
+			MaxSelect: 1,
+			Values:    []string{"pending", "approved"},
+		})
+
+		follows.Fields.Add(&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		})
+
+		follows.AddIndex("idx_follows_by_user_following", true, "user, following", "")
+		follows.AddIndex("idx_follows_by_following", false, "following", "")
+
+		if err := app.Save(follows); err != nil {
+			return err
+		}
+
+		// users sharing entries with each other, whose members are picked by
+		// its owner
+		groups := core.NewBaseCollection("groups")
+		groups.ViewRule = types.Pointer("@request.auth.id != '' && (owner = @request.auth.id || members.id ?= @request.auth.id)")
+		groups.ListRule = types.Pointer("@request.auth.id != '' && (owner = @request.auth.id || members.id ?= @request.auth.id)")
+		groups.CreateRule = types.Pointer("@request.auth.id != '' && @request.body.owner = @request.auth.id")
+		groups.UpdateRule = types.Pointer("@request.auth.id != '' && owner = @request.auth.id && (@request.body.owner:isset = false || @request.body.owner = @request.auth.id)")
+		groups.DeleteRule = types.Pointer("@request.auth.id != '' && owner = @request.auth.id")
+
+		groups.Fields.Add(&core.RelationField{
+			Name:          "owner",
+			Required:      true,
+			CascadeDelete: true,
+			CollectionId:  usersCollection.Id,
+		})
+
+		groups.Fields.Add(&core.TextField{
+			Name:     "name",
+			Required: true,
+			Max:      100,
+		})
+
+		groups.Fields.Add(&core.RelationField{
+			Name:         "members",
+			MaxSelect:    9999,
+			CollectionId: usersCollection.Id,
+		})
+
+		groups.Fields.Add(&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		})
+
+		groups.Fields.Add(&core.AutodateField{
+			Name:     "updated",
+			OnCreate: true,
+			OnUpdate: true,
+		})
+
+		if err := app.Save(groups); err != nil {
+			return err
+		}
+
+		collection, err := app.FindCollectionByNameOrId("journal_entry")
+		if err != nil {
+			return err
+		}
+
+		// set to private on entries saved without one
+		collection.Fields.Add(&core.SelectField{
+			Name:      "audience",
+			MaxSelect: 1,
+			Values:    []string{"private", "followers", "groups", "public"},
+		})
+
+		// the groups an entry with the groups audience is shown to, ones its
+		// writer owns or is a member of
+		collection.Fields.Add(&core.RelationField{
+			Name:         "groups",
+			MaxSelect:    9999,
+			CollectionId: groups.Id,
+		})
+
+		collection.ViewRule = types.Pointer("@request.auth.id != '' && (user = @request.auth.id || audience = 'public' || (audience = 'followers' && @collection.follows.user ?= @request.auth.id && @collection.follows.following ?= user && @collection.follows.status ?= 'approved') || (audience = 'groups' && (groups.owner ?= @request.auth.id || groups.members.id ?= @request.auth.id)))")
+		collection.ListRule = collection.ViewRule
+
+		if err := app.Save(collection); err != nil {
+			return err
+		}
+
+		// entries that weren't private were shown to every user
+		_, err = app.DB().NewQuery("UPDATE journal_entry SET audience = CASE WHEN is_private THEN 'private' ELSE 'public' END").Execute()
+		if err != nil {
+			return err
+		}
+
+		collection.Fields.RemoveByName("is_private")
+		return app.Save(collection)
+	}, func(app core.App) error { // optional revert operation
+		collection, err := app.FindCollectionByNameOrId("journal_entry")
+		if err != nil {
+			return err
+		}
+
+		collection.Fields.Add(&core.BoolField{
+			Name: "is_private",
+		})
+		collection.ViewRule = types.Pointer("@request.auth.id != '' && (user = @request.auth.id || is_private = false)")
+		collection.ListRule = types.Pointer("@request.auth.id != '' && (user = @request.auth.id || is_private = false)")
+		if err := app.Save(collection); err != nil {
+			return err
+		}
+
+		_, err = app.DB().NewQuery("UPDATE journal_entry SET is_private = audience != 'public'").Execute()
+		if err != nil {
+			return err
+		}
+
+		collection.Fields.RemoveByName("audience")
+		collection.Fields.RemoveByName("groups")
+		if err := app.Save(collection); err != nil {
+			return err
+		}
+
+		for _, name := range []string{"groups", "follows"} {
+			c, err := app.FindCollectionByNameOrId(name)
+			if err != nil {
+				return err
+			}
+			if err := app.Delete(c); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,72 @@
+package migrations
+
+import (
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Users pick who new entries are shown to and who can see their profile and
+// stats once, in their settings, everything being private until they do
+func init() {
+	m.Register(func(app core.App) error {
+		settings, err := app.FindCollectionByNameOrId("user_settings")
+		if err != nil {
+			return err
+		}
+
+		settings.Fields.Add(&core.SelectField{
+			Name:      "default_audience",
+			MaxSelect: 1,
+			Values:    []string{"private", "followers", "groups", "public"},
+		})
+
+		for _, name := range []string{"profile_visibility", "stats_visibility"} {
+			settings.Fields.Add(&core.SelectField{
+				Name:      name,
+				MaxSelect: 1,
+				Values:    []string{"private", "followers", "public"},
+			})
+		}
+
+		if err := app.Save(settings); err != nil {
+			return err
+		}
+		_, err = app.DB().Update("user_settings", dbx.Params{
+			"default_audience":   "private",
+			"profile_visibility": "private",
+			"stats_visibility":   "private",
+		}, nil).Execute()
+		if err != nil {
+			return err
+		}
+
+		// profiles are the user records, which other users can now see too
+		// when their settings allow it, followers once they're approved
+		users, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+		users.ViewRule = types.Pointer("id = @request.auth.id || (@request.auth.id != '' && (user_settings_via_user.profile_visibility ?= 'public' || (user_settings_via_user.profile_visibility ?= 'followers' && follows_via_following.user ?= @request.auth.id && follows_via_following.status ?= 'approved')))")
+		return app.Save(users)
+	}, func(app core.App) error { // optional revert operation
+		users, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+		users.ViewRule = types.Pointer("id = @request.auth.id")
+		if err := app.Save(users); err != nil {
+			return err
+		}
+
+		settings, err := app.FindCollectionByNameOrId("user_settings")
+		if err != nil {
+			return err
+		}
+		for _, name := range []string{"default_audience", "profile_visibility", "stats_visibility"} {
+			settings.Fields.RemoveByName(name)
+		}
+		return app.Save(settings)
+	})
+}
@@ -0,0 +1,121 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// A learner can let their tutor read all their entries, whatever their
+// audience, and correct them here
+func init() {
+	m.Register(func(app core.App) error {
+		// Only the learner says who their tutors are, either of them can end it
+		collection := core.NewBaseCollection("tutors")
+		collection.ViewRule = types.Pointer("@request.auth.id != '' && (user = @request.auth.id || tutor = @request.auth.id)")
+		collection.ListRule = types.Pointer("@request.auth.id != '' && (user = @request.auth.id || tutor = @request.auth.id)")
+		collection.CreateRule = types.Pointer("@request.auth.id != '' && @request.body.user = @request.auth.id && @request.body.tutor != @request.auth.id")
+		collection.UpdateRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id && (@request.body.user:isset = false || @request.body.user = @request.auth.id) && @request.body.tutor:isset = false")
+		collection.DeleteRule = types.Pointer("@request.auth.id != '' && (user = @request.auth.id || tutor = @request.auth.id)")
+
+		usersCollection, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+		collection.Fields.Add(&core.RelationField{
+			Name:          "user",
+			Required:      true,
+			CascadeDelete: true,
+			CollectionId:  usersCollection.Id,
+		})
+
+		collection.Fields.Add(&core.RelationField{
+			Name:          "tutor",
+			Required:      true,
+			CascadeDelete: true,
+			CollectionId:  usersCollection.Id,
+		})
+
+		// whether the tutor may add corrections to the entries they read
+		collection.Fields.Add(&core.BoolField{
+			Name: "can_correct",
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "updated",
+			OnCreate: true,
+			OnUpdate: true,
+		})
+
+		collection.AddIndex("idx_tutors_by_user_tutor", true, "user, tutor", "")
+		collection.AddIndex("idx_tutors_by_tutor", false, "tutor", "")
+
+		if err := app.Save(collection); err != nil {
+			return err
+		}
+
+		journal, err := app.FindCollectionByNameOrId("journal_entry")
+		if err != nil {
+			return err
+		}
+		journal.ViewRule = types.Pointer("@request.auth.id != '' && (user = @request.auth.id || audience = 'public' || (audience = 'followers' && @collection.follows.user ?= @request.auth.id && @collection.follows.following ?= user && @collection.follows.status ?= 'approved') || (audience = 'groups' && (groups.owner ?= @request.auth.id || groups.members.id ?= @request.auth.id)) || (@collection.tutors.user ?= user && @collection.tutors.tutor ?= @request.auth.id))")
+		journal.ListRule = journal.ViewRule
+		if err := app.Save(journal); err != nil {
+			return err
+		}
+
+		// Corrections stay the learner's, the tutor who wrote one can still
+		// see, edit and delete it while they tutor them
+		corrections, err := app.FindCollectionByNameOrId("corrections")
+		if err != nil {
+			return err
+		}
+		corrections.Fields.Add(&core.RelationField{
+			Name:         "author",
+			CollectionId: usersCollection.Id,
+		})
+
+		tutoring := "@collection.tutors.user ?= user && @collection.tutors.tutor ?= @request.auth.id && @collection.tutors.can_correct ?= true"
+		corrections.ViewRule = types.Pointer("@request.auth.id != '' && (user = @request.auth.id || author = @request.auth.id)")
+		corrections.ListRule = corrections.ViewRule
+		corrections.CreateRule = types.Pointer("@request.auth.id != '' && @request.body.prompt:isset = false && ((@request.body.user = @request.auth.id && @request.body.journal_entry.user = @request.auth.id && @request.body.author:isset = false) || (@request.body.author = @request.auth.id && @request.body.journal_entry.user = @request.body.user && @collection.tutors.user ?= @request.body.user && @collection.tutors.tutor ?= @request.auth.id && @collection.tutors.can_correct ?= true))")
+		corrections.UpdateRule = types.Pointer("@request.auth.id != '' && @request.body.prompt:isset = false && @request.body.author:isset = false && ((user = @request.auth.id && (@request.body.user:isset = false || @request.body.user = @request.auth.id)) || (author = @request.auth.id && @request.body.user:isset = false && @request.body.journal_entry:isset = false && " + tutoring + "))")
+		corrections.DeleteRule = types.Pointer("@request.auth.id != '' && (user = @request.auth.id || (author = @request.auth.id && " + tutoring + "))")
+		return app.Save(corrections)
+	}, func(app core.App) error { // optional revert operation
+		corrections, err := app.FindCollectionByNameOrId("corrections")
+		if err != nil {
+			return err
+		}
+		corrections.Fields.RemoveByName("author")
+		corrections.ViewRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id")
+		corrections.ListRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id")
+		corrections.CreateRule = types.Pointer("@request.auth.id != '' && @request.body.user = @request.auth.id && @request.body.journal_entry.user = @request.auth.id && @request.body.prompt:isset = false")
+		corrections.UpdateRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id && (@request.body.user:isset = false || @request.body.user = @request.auth.id) && @request.body.prompt:isset = false")
+		corrections.DeleteRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id")
+		if err := app.Save(corrections); err != nil {
+			return err
+		}
+
+		journal, err := app.FindCollectionByNameOrId("journal_entry")
+		if err != nil {
+			return err
+		}
+		journal.ViewRule = types.Pointer("@request.auth.id != '' && (user = @request.auth.id || audience = 'public' || (audience = 'followers' && @collection.follows.user ?= @request.auth.id && @collection.follows.following ?= user && @collection.follows.status ?= 'approved') || (audience = 'groups' && (groups.owner ?= @request.auth.id || groups.members.id ?= @request.auth.id)))")
+		journal.ListRule = journal.ViewRule
+		if err := app.Save(journal); err != nil {
+			return err
+		}
+
+		collection, err := app.FindCollectionByNameOrId("tutors")
+		if err != nil {
+			return err
+		}
+		return app.Delete(collection)
+	})
+}
@@ -0,0 +1,106 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// A tutor session bundles recent entries and the learner's questions into a
+// document for a tutor, whose annotations come back as corrections
+func init() {
+	m.Register(func(app core.App) error {
+		// written and annotated through the custom routes only
+		collection := core.NewBaseCollection("tutor_sessions")
+		collection.ViewRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id")
+		collection.ListRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id")
+		collection.DeleteRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id")
+
+		usersCollection, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+		collection.Fields.Add(&core.RelationField{
+			Name:          "user",
+			Required:      true,
+			CascadeDelete: true,
+			CollectionId:  usersCollection.Id,
+		})
+
+		// the learner's tutor the session is for, if they have an account
+		collection.Fields.Add(&core.RelationField{
+			Name:         "tutor",
+			CollectionId: usersCollection.Id,
+		})
+
+		// the entries as they were split into sentences for the document, so
+		// annotations still line up after the learner edits them
+		collection.Fields.Add(&core.JSONField{
+			Name:    "entries",
+			MaxSize: 1000000,
+		})
+
+		collection.Fields.Add(&core.JSONField{
+			Name:    "questions",
+			MaxSize: 100000,
+		})
+
+		// lets the tutor read and annotate the session without an account
+		collection.Fields.Add(&core.TextField{
+			Name: "share_token",
+			Max:  64,
+		})
+
+		collection.Fields.Add(&core.DateField{
+			Name: "annotated",
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "updated",
+			OnCreate: true,
+			OnUpdate: true,
+		})
+
+		collection.AddIndex("idx_tutor_sessions_share_token", true, "share_token", "share_token != ''")
+		collection.AddIndex("idx_tutor_sessions_by_user", false, "user, created", "")
+
+		if err := app.Save(collection); err != nil {
+			return err
+		}
+
+		// corrections annotated in a session, replaced when it's annotated again
+		corrections, err := app.FindCollectionByNameOrId("corrections")
+		if err != nil {
+			return err
+		}
+		corrections.Fields.Add(&core.RelationField{
+			Name:         "tutor_session",
+			CollectionId: collection.Id,
+		})
+		corrections.CreateRule = types.Pointer("@request.auth.id != '' && @request.body.prompt:isset = false && @request.body.tutor_session:isset = false && ((@request.body.user = @request.auth.id && @request.body.journal_entry.user = @request.auth.id && @request.body.author:isset = false) || (@request.body.author = @request.auth.id && @request.body.journal_entry.user = @request.body.user && @collection.tutors.user ?= @request.body.user && @collection.tutors.tutor ?= @request.auth.id && @collection.tutors.can_correct ?= true))")
+		corrections.UpdateRule = types.Pointer("@request.auth.id != '' && @request.body.prompt:isset = false && @request.body.author:isset = false && @request.body.tutor_session:isset = false && ((user = @request.auth.id && (@request.body.user:isset = false || @request.body.user = @request.auth.id)) || (author = @request.auth.id && @request.body.user:isset = false && @request.body.journal_entry:isset = false && @collection.tutors.user ?= user && @collection.tutors.tutor ?= @request.auth.id && @collection.tutors.can_correct ?= true))")
+		return app.Save(corrections)
+	}, func(app core.App) error { // optional revert operation
+		corrections, err := app.FindCollectionByNameOrId("corrections")
+		if err != nil {
+			return err
+		}
+		corrections.Fields.RemoveByName("tutor_session")
+		corrections.CreateRule = types.Pointer("@request.auth.id != '' && @request.body.prompt:isset = false && ((@request.body.user = @request.auth.id && @request.body.journal_entry.user = @request.auth.id && @request.body.author:isset = false) || (@request.body.author = @request.auth.id && @request.body.journal_entry.user = @request.body.user && @collection.tutors.user ?= @request.body.user && @collection.tutors.tutor ?= @request.auth.id && @collection.tutors.can_correct ?= true))")
+		corrections.UpdateRule = types.Pointer("@request.auth.id != '' && @request.body.prompt:isset = false && @request.body.author:isset = false && ((user = @request.auth.id && (@request.body.user:isset = false || @request.body.user = @request.auth.id)) || (author = @request.auth.id && @request.body.user:isset = false && @request.body.journal_entry:isset = false && @collection.tutors.user ?= user && @collection.tutors.tutor ?= @request.auth.id && @collection.tutors.can_correct ?= true))")
+		if err := app.Save(corrections); err != nil {
+			return err
+		}
+
+		collection, err := app.FindCollectionByNameOrId("tutor_sessions")
+		if err != nil {
+			return err
+		}
+		return app.Delete(collection)
+	})
+}
@@ -0,0 +1,39 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		users, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		// How much of the AI and export routes the user may use, the
+		// instance's default tier when empty. Only superusers may set it.
+		users.Fields.Add(&core.SelectField{
+			Name:      "rate_tier",
+			MaxSelect: 1,
+			Values:    []string{"demo", "standard", "trusted"},
+		})
+		users.CreateRule = types.Pointer("@request.body.rate_tier:isset = false")
+		users.UpdateRule = types.Pointer("id = @request.auth.id && @request.body.storage_quota:isset = false && @request.body.has_password:isset = false && @request.body.rate_tier:isset = false")
+
+		return app.Save(users)
+	}, func(app core.App) error { // optional revert operation
+		users, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		users.Fields.RemoveByName("rate_tier")
+		users.CreateRule = types.Pointer("")
+		users.UpdateRule = types.Pointer("id = @request.auth.id && @request.body.storage_quota:isset = false && @request.body.has_password:isset = false")
+
+		return app.Save(users)
+	})
+}
@@ -0,0 +1,132 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Public entries and mnemonics that look like spam are held back from other
+// users until a superuser reviews them
+func init() {
+	m.Register(func(app core.App) error {
+		usersCollection, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		// superusers only, through the admin routes
+		queue := core.NewBaseCollection("moderation_queue")
+
+		queue.Fields.Add(&core.TextField{
+			Name:     "collection",
+			Required: true,
+			Max:      100,
+		})
+
+		queue.Fields.Add(&core.TextField{
+			Name:     "record",
+			Required: true,
+			Max:      15,
+		})
+
+		queue.Fields.Add(&core.RelationField{
+			Name:          "user",
+			Required:      true,
+			CascadeDelete: true,
+			CollectionId:  usersCollection.Id,
+		})
+
+		// the heuristics the content tripped, e.g. ["links", "velocity"]
+		queue.Fields.Add(&core.JSONField{
+			Name:    "reasons",
+			MaxSize: 10000,
+		})
+
+		queue.Fields.Add(&core.SelectField{
+			Name:      "status",
+			Required:  true,
+			MaxSelect: 1,
+			Values:    []string{"pending", "approved", "removed"},
+		})
+
+		queue.Fields.Add(&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		})
+
+		queue.Fields.Add(&core.AutodateField{
+			Name:     "updated",
+			OnCreate: true,
+			OnUpdate: true,
+		})
+
+		queue.AddIndex("idx_moderation_queue_by_record", true, "collection, record", "")
+		queue.AddIndex("idx_moderation_queue_by_status", false, "status, created", "")
+
+		if err := app.Save(queue); err != nil {
+			return err
+		}
+
+		journal, err := app.FindCollectionByNameOrId("journal_entry")
+		if err != nil {
+			return err
+		}
+		// set by the spam heuristics, only superusers clear it
+		journal.Fields.Add(&core.BoolField{
+			Name: "held",
+		})
+		journal.ViewRule = types.Pointer("@request.auth.id != '' && (user = @request.auth.id || (held = false && (audience = 'public' || (audience = 'followers' && @collection.follows.user ?= @request.auth.id && @collection.follows.following ?= user && @collection.follows.status ?= 'approved') || (audience = 'groups' && (groups.owner ?= @request.auth.id || groups.members.id ?= @request.auth.id)) || (@collection.tutors.user ?= user && @collection.tutors.tutor ?= @request.auth.id))))")
+		journal.ListRule = journal.ViewRule
+		journal.CreateRule = types.Pointer("@request.auth.id != '' && @request.body.user = @request.auth.id && @request.body.import:isset = false && @request.body.held:isset = false")
+		journal.UpdateRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id && (@request.body.user:isset = false || @request.body.user = @request.auth.id) && @request.body.import:isset = false && @request.body.held:isset = false")
+		if err := app.Save(journal); err != nil {
+			return err
+		}
+
+		mnemonics, err := app.FindCollectionByNameOrId("mnemonics")
+		if err != nil {
+			return err
+		}
+		mnemonics.Fields.Add(&core.BoolField{
+			Name: "held",
+		})
+		mnemonics.ViewRule = types.Pointer("@request.auth.id != '' && (user = @request.auth.id || (public = true && held = false))")
+		mnemonics.ListRule = mnemonics.ViewRule
+		mnemonics.CreateRule = types.Pointer("@request.auth.id != '' && @request.body.user = @request.auth.id && @request.body.votes:isset = false && @request.body.held:isset = false")
+		mnemonics.UpdateRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id && @request.body.user:isset = false && @request.body.grammar:isset = false && @request.body.votes:isset = false && @request.body.held:isset = false")
+		return app.Save(mnemonics)
+	}, func(app core.App) error { // optional revert operation
+		mnemonics, err := app.FindCollectionByNameOrId("mnemonics")
+		if err != nil {
+			return err
+		}
+		mnemonics.Fields.RemoveByName("held")
+		mnemonics.ViewRule = types.Pointer("@request.auth.id != '' && (user = @request.auth.id || public = true)")
+		mnemonics.ListRule = mnemonics.ViewRule
+		mnemonics.CreateRule = types.Pointer("@request.auth.id != '' && @request.body.user = @request.auth.id && @request.body.votes:isset = false")
+		mnemonics.UpdateRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id && @request.body.user:isset = false && @request.body.grammar:isset = false && @request.body.votes:isset = false")
+		if err := app.Save(mnemonics); err != nil {
+			return err
+		}
+
+		journal, err := app.FindCollectionByNameOrId("journal_entry")
+		if err != nil {
+			return err
+		}
+		journal.Fields.RemoveByName("held")
+		journal.ViewRule = types.Pointer("@request.auth.id != '' && (user = @request.auth.id || audience = 'public' || (audience = 'followers' && @collection.follows.user ?= @request.auth.id && @collection.follows.following ?= user && @collection.follows.status ?= 'approved') || (audience = 'groups' && (groups.owner ?= @request.auth.id || groups.members.id ?= @request.auth.id)) || (@collection.tutors.user ?= user && @collection.tutors.tutor ?= @request.auth.id))")
+		journal.ListRule = journal.ViewRule
+		journal.CreateRule = types.Pointer("@request.auth.id != '' && @request.body.user = @request.auth.id && @request.body.import:isset = false")
+		journal.UpdateRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id && (@request.body.user:isset = false || @request.body.user = @request.auth.id) && @request.body.import:isset = false")
+		if err := app.Save(journal); err != nil {
+			return err
+		}
+
+		queue, err := app.FindCollectionByNameOrId("moderation_queue")
+		if err != nil {
+			return err
+		}
+		return app.Delete(queue)
+	})
+}
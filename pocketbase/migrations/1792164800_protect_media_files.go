@@ -0,0 +1,36 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Media files were served to anyone with their URL. Protected, clients load
+// them through the signed URLs of the media routes instead.
+func init() {
+	m.Register(func(app core.App) error {
+		for _, name := range []string{"media", "blobs"} {
+			collection, err := app.FindCollectionByNameOrId(name)
+			if err != nil {
+				return err
+			}
+			collection.Fields.GetByName("file").(*core.FileField).Protected = true
+			if err := app.Save(collection); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, func(app core.App) error { // optional revert operation
+		for _, name := range []string{"media", "blobs"} {
+			collection, err := app.FindCollectionByNameOrId(name)
+			if err != nil {
+				return err
+			}
+			collection.Fields.GetByName("file").(*core.FileField).Protected = false
+			if err := app.Save(collection); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
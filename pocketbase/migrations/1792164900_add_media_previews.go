@@ -0,0 +1,70 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// List views show media from what a background job works out once per
+// upload, rather than loading whole files
+func init() {
+	m.Register(func(app core.App) error {
+		media, err := app.FindCollectionByNameOrId("media")
+		if err != nil {
+			return err
+		}
+
+		// images scaled down to fit a list
+		media.Fields.Add(&core.FileField{
+			Name:      "thumbnail",
+			MaxSelect: 1,
+			MaxSize:   2 << 20,
+			Protected: true,
+		})
+
+		// of the original image, in pixels
+		media.Fields.Add(&core.NumberField{
+			Name:    "width",
+			OnlyInt: true,
+		})
+		media.Fields.Add(&core.NumberField{
+			Name:    "height",
+			OnlyInt: true,
+		})
+
+		// of audio, in seconds
+		media.Fields.Add(&core.NumberField{
+			Name: "duration",
+		})
+
+		// peaks of audio from 0 to 1, evenly spread over it
+		media.Fields.Add(&core.JSONField{
+			Name:    "waveform",
+			MaxSize: 10000,
+		})
+
+		// when the file was last processed, empty while it waits to be
+		media.Fields.Add(&core.DateField{
+			Name: "processed",
+		})
+
+		media.CreateRule = types.Pointer("@request.auth.id != '' && @request.body.user = @request.auth.id && @request.body.blob:isset = false && @request.body.hash:isset = false && @request.body.thumbnail:isset = false && @request.body.width:isset = false && @request.body.height:isset = false && @request.body.duration:isset = false && @request.body.waveform:isset = false && @request.body.processed:isset = false")
+		media.UpdateRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id && (@request.body.user:isset = false || @request.body.user = @request.auth.id) && @request.body.blob:isset = false && @request.body.hash:isset = false && @request.body.thumbnail:isset = false && @request.body.width:isset = false && @request.body.height:isset = false && @request.body.duration:isset = false && @request.body.waveform:isset = false && @request.body.processed:isset = false")
+
+		return app.Save(media)
+	}, func(app core.App) error { // optional revert operation
+		media, err := app.FindCollectionByNameOrId("media")
+		if err != nil {
+			return err
+		}
+
+		for _, name := range []string{"thumbnail", "width", "height", "duration", "waveform", "processed"} {
+			media.Fields.RemoveByName(name)
+		}
+		media.CreateRule = types.Pointer("@request.auth.id != '' && @request.body.user = @request.auth.id && @request.body.blob:isset = false && @request.body.hash:isset = false")
+		media.UpdateRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id && (@request.body.user:isset = false || @request.body.user = @request.auth.id) && @request.body.blob:isset = false && @request.body.hash:isset = false")
+
+		return app.Save(media)
+	})
+}
@@ -0,0 +1,34 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Spoken entries keep what their recording was transcribed as, so they can
+// be transcribed again as long as the user hasn't edited them
+func init() {
+	m.Register(func(app core.App) error {
+		journal, err := app.FindCollectionByNameOrId("journal_entry")
+		if err != nil {
+			return err
+		}
+		journal.Fields.Add(&core.TextField{
+			Name:   "transcript",
+			Hidden: true,
+		})
+		journal.CreateRule = types.Pointer("@request.auth.id != '' && @request.body.user = @request.auth.id && @request.body.import:isset = false && @request.body.held:isset = false && @request.body.transcript:isset = false")
+		journal.UpdateRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id && (@request.body.user:isset = false || @request.body.user = @request.auth.id) && @request.body.import:isset = false && @request.body.held:isset = false && @request.body.transcript:isset = false")
+		return app.Save(journal)
+	}, func(app core.App) error { // optional revert operation
+		journal, err := app.FindCollectionByNameOrId("journal_entry")
+		if err != nil {
+			return err
+		}
+		journal.Fields.RemoveByName("transcript")
+		journal.CreateRule = types.Pointer("@request.auth.id != '' && @request.body.user = @request.auth.id && @request.body.import:isset = false && @request.body.held:isset = false")
+		journal.UpdateRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id && (@request.body.user:isset = false || @request.body.user = @request.auth.id) && @request.body.import:isset = false && @request.body.held:isset = false")
+		return app.Save(journal)
+	})
+}
@@ -0,0 +1,37 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Instances pick the languages their users study during setup, the others
+// stay out of the language pickers
+func init() {
+	m.Register(func(app core.App) error {
+		languages, err := app.FindCollectionByNameOrId("languages")
+		if err != nil {
+			return err
+		}
+		languages.Fields.Add(&core.BoolField{
+			Name: "enabled",
+		})
+		languages.ListRule = types.Pointer("@request.auth.id != '' && enabled = true")
+		if err := app.Save(languages); err != nil {
+			return err
+		}
+
+		// every language was on so far
+		_, err = app.DB().Update("languages", map[string]any{"enabled": true}, nil).Execute()
+		return err
+	}, func(app core.App) error { // optional revert operation
+		languages, err := app.FindCollectionByNameOrId("languages")
+		if err != nil {
+			return err
+		}
+		languages.Fields.RemoveByName("enabled")
+		languages.ListRule = types.Pointer("@request.auth.id != ''")
+		return app.Save(languages)
+	})
+}
@@ -0,0 +1,100 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// The operational settings of the instance, one record superusers edit from
+// the dashboard and the server applies as it's saved, without a restart
+func init() {
+	m.Register(func(app core.App) error {
+		// No API rules, superusers only
+		collection := core.NewBaseCollection("instance_settings")
+
+		// limits of the expensive routes by tier in place of the environment's,
+		// e.g. {"demo": 5, "standard": 50}
+		collection.Fields.Add(&core.JSONField{
+			Name:    "rate_limit_ai",
+			MaxSize: 1000,
+		})
+		collection.Fields.Add(&core.JSONField{
+			Name:    "rate_limit_exports",
+			MaxSize: 1000,
+		})
+
+		// empty keeps the environment's
+		collection.Fields.Add(&core.SelectField{
+			Name:      "rate_limit_default_tier",
+			MaxSelect: 1,
+			Values:    []string{"demo", "standard", "trusted"},
+		})
+
+		// the models and voice of the providers set up in the environment,
+		// empty keeps the environment's
+		collection.Fields.Add(&core.TextField{
+			Name: "ai_model",
+			Max:  200,
+		})
+		collection.Fields.Add(&core.TextField{
+			Name: "ai_transcribe_model",
+			Max:  200,
+		})
+		collection.Fields.Add(&core.TextField{
+			Name: "ai_speech_model",
+			Max:  200,
+		})
+		collection.Fields.Add(&core.TextField{
+			Name: "ai_speech_voice",
+			Max:  100,
+		})
+
+		// instance wide switches, whoever the user
+		collection.Fields.Add(&core.BoolField{
+			Name: "signups",
+		})
+		collection.Fields.Add(&core.BoolField{
+			Name: "ai_features",
+		})
+
+		// cron expression of automatic backups, empty turns them off
+		collection.Fields.Add(&core.TextField{
+			Name: "backup_cron",
+			Max:  100,
+		})
+
+		// 0 keeps every automatic backup
+		collection.Fields.Add(&core.NumberField{
+			Name:    "backup_max_keep",
+			OnlyInt: true,
+			Min:     types.Pointer(0.0),
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "updated",
+			OnCreate: true,
+			OnUpdate: true,
+		})
+
+		if err := app.Save(collection); err != nil {
+			return err
+		}
+
+		// what the server was set up with before, backups every sunday at
+		// midnight keeping three weeks worth
+		record := core.NewRecord(collection)
+		record.Set("signups", true)
+		record.Set("ai_features", true)
+		record.Set("backup_cron", "0 0 * * 0")
+		record.Set("backup_max_keep", 3)
+		return app.Save(record)
+	}, func(app core.App) error { // optional revert operation
+		collection, err := app.FindCollectionByNameOrId("instance_settings")
+		if err != nil {
+			return err
+		}
+
+		return app.Delete(collection)
+	})
+}
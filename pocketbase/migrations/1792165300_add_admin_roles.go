@@ -0,0 +1,39 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		users, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		// The staff role letting the user into part of the admin routes, none
+		// when empty. Only superusers and full admins may set it.
+		users.Fields.Add(&core.SelectField{
+			Name:      "admin_role",
+			MaxSelect: 1,
+			Values:    []string{"admin", "moderator", "support"},
+		})
+		users.CreateRule = types.Pointer("@request.body.rate_tier:isset = false && @request.body.admin_role:isset = false")
+		users.UpdateRule = types.Pointer("id = @request.auth.id && @request.body.storage_quota:isset = false && @request.body.has_password:isset = false && @request.body.rate_tier:isset = false && @request.body.admin_role:isset = false")
+
+		return app.Save(users)
+	}, func(app core.App) error { // optional revert operation
+		users, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		users.Fields.RemoveByName("admin_role")
+		users.CreateRule = types.Pointer("@request.body.rate_tier:isset = false")
+		users.UpdateRule = types.Pointer("id = @request.auth.id && @request.body.storage_quota:isset = false && @request.body.has_password:isset = false && @request.body.rate_tier:isset = false")
+
+		return app.Save(users)
+	})
+}
@@ -0,0 +1,163 @@
+package migrations
+
+import (
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+var announcementTemplates = []struct {
+	locale, subject, body string
+}{
+	{
+		"en",
+		"{{.AppName}}: {{.Title}}",
+		`<p>Hello{{if .Name}} {{.Name}}{{end}},</p>
+<p style="white-space: pre-line">{{.Message}}</p>
+<p><a class="btn" href="{{.ActionURL}}" target="_blank" rel="noopener">Open {{.AppName}}</a></p>
+<p>Thanks,<br/>{{.AppName}} team</p>`,
+	},
+	{
+		"ja",
+		"{{.AppName}}：{{.Title}}",
+		`<p>{{if .Name}}{{.Name}}様{{else}}こんにちは{{end}}、</p>
+<p style="white-space: pre-line">{{.Message}}</p>
+<p><a class="btn" href="{{.ActionURL}}" target="_blank" rel="noopener">{{.AppName}}を開く</a></p>
+<p>{{.AppName}}チーム</p>`,
+	},
+}
+
+// Announcements operators make to every user, e.g. of a maintenance window
+// or a new feature, shown in the apps and optionally emailed
+func init() {
+	m.Register(func(app core.App) error {
+		// Written by superusers and full admins, users see the ones running
+		announcements := core.NewBaseCollection("announcements")
+		announcements.ListRule = types.Pointer("@request.auth.id != '' && (starts = '' || starts <= @now) && (ends = '' || ends > @now)")
+		announcements.ViewRule = announcements.ListRule
+
+		announcements.Fields.Add(&core.TextField{
+			Name:     "title",
+			Required: true,
+			Max:      200,
+		})
+
+		// plain text, line breaks kept
+		announcements.Fields.Add(&core.TextField{
+			Name:     "message",
+			Required: true,
+			Max:      5000,
+		})
+
+		announcements.Fields.Add(&core.SelectField{
+			Name:      "kind",
+			Required:  true,
+			MaxSelect: 1,
+			Values:    []string{"info", "maintenance", "feature"},
+		})
+
+		// shown from starts, right away when empty, until ends, for good when
+		// empty. A maintenance window is announced ending when it's over.
+		announcements.Fields.Add(&core.DateField{
+			Name: "starts",
+		})
+		announcements.Fields.Add(&core.DateField{
+			Name: "ends",
+		})
+
+		// emailed to users who get emails once it starts
+		announcements.Fields.Add(&core.BoolField{
+			Name: "email",
+		})
+
+		// when the email went out
+		announcements.Fields.Add(&core.DateField{
+			Name:   "emailed",
+			Hidden: true,
+		})
+
+		announcements.Fields.Add(&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		})
+
+		announcements.Fields.Add(&core.AutodateField{
+			Name:     "updated",
+			OnCreate: true,
+			OnUpdate: true,
+		})
+
+		announcements.AddIndex("idx_announcements_by_starts", false, "starts, ends", "")
+
+		if err := app.Save(announcements); err != nil {
+			return err
+		}
+
+		usersCollection, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		// Which announcements each user read, through the API routes
+		reads := core.NewBaseCollection("announcement_reads")
+		reads.ListRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id")
+		reads.ViewRule = reads.ListRule
+
+		reads.Fields.Add(&core.RelationField{
+			Name:          "user",
+			Required:      true,
+			CascadeDelete: true,
+			CollectionId:  usersCollection.Id,
+		})
+
+		reads.Fields.Add(&core.RelationField{
+			Name:          "announcement",
+			Required:      true,
+			CascadeDelete: true,
+			CollectionId:  announcements.Id,
+		})
+
+		reads.Fields.Add(&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		})
+
+		reads.AddIndex("idx_announcement_reads_by_user", true, "user, announcement", "")
+
+		if err := app.Save(reads); err != nil {
+			return err
+		}
+
+		templates, err := app.FindCollectionByNameOrId("email_templates")
+		if err != nil {
+			return err
+		}
+		for _, t := range announcementTemplates {
+			record := core.NewRecord(templates)
+			record.Set("key", "announcement")
+			record.Set("locale", t.locale)
+			record.Set("subject", t.subject)
+			record.Set("body", t.body)
+			if err := app.Save(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, func(app core.App) error { // optional revert operation
+		if _, err := app.DB().Delete("email_templates", dbx.HashExp{"key": "announcement"}).Execute(); err != nil {
+			return err
+		}
+
+		for _, name := range []string{"announcement_reads", "announcements"} {
+			collection, err := app.FindCollectionByNameOrId(name)
+			if err != nil {
+				return err
+			}
+			if err := app.Delete(collection); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
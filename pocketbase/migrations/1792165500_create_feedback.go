@@ -0,0 +1,93 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Feedback and bug reports users send from the apps, triaged by superusers
+// and support staff
+func init() {
+	m.Register(func(app core.App) error {
+		usersCollection, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		// Sent through the API routes, users can read theirs
+		collection := core.NewBaseCollection("feedback")
+		collection.ListRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id")
+		collection.ViewRule = collection.ListRule
+
+		collection.Fields.Add(&core.RelationField{
+			Name:          "user",
+			Required:      true,
+			CascadeDelete: true,
+			CollectionId:  usersCollection.Id,
+		})
+
+		collection.Fields.Add(&core.TextField{
+			Name:     "message",
+			Required: true,
+			Max:      5000,
+		})
+
+		// e.g. "1.4.0 (112)"
+		collection.Fields.Add(&core.TextField{
+			Name: "app_version",
+			Max:  50,
+		})
+
+		collection.Fields.Add(&core.SelectField{
+			Name:      "platform",
+			Required:  true,
+			MaxSelect: 1,
+			Values:    []string{"ios", "web", "other"},
+		})
+
+		// whatever the app collected to help reproduce it, e.g. the device,
+		// the OS version and recent errors
+		collection.Fields.Add(&core.JSONField{
+			Name:    "diagnostics",
+			MaxSize: 100 * 1024,
+		})
+
+		collection.Fields.Add(&core.SelectField{
+			Name:      "status",
+			Required:  true,
+			MaxSelect: 1,
+			Values:    []string{"new", "triaged", "resolved", "dismissed"},
+		})
+
+		// what whoever triaged it noted, for staff only
+		collection.Fields.Add(&core.TextField{
+			Name:   "note",
+			Max:    5000,
+			Hidden: true,
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "updated",
+			OnCreate: true,
+			OnUpdate: true,
+		})
+
+		collection.AddIndex("idx_feedback_by_status", false, "status, created", "")
+		collection.AddIndex("idx_feedback_by_user", false, "user, created", "")
+
+		return app.Save(collection)
+	}, func(app core.App) error { // optional revert operation
+		collection, err := app.FindCollectionByNameOrId("feedback")
+		if err != nil {
+			return err
+		}
+
+		return app.Delete(collection)
+	})
+}
@@ -0,0 +1,66 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// The app versions each platform needs, so builds that would write data the
+// server no longer understands are told to upgrade instead
+func init() {
+	m.Register(func(app core.App) error {
+		// No API rules, superusers only. Clients read them via
+		// /api/fushigi/handshake
+		collection := core.NewBaseCollection("client_versions")
+
+		// what clients send before the slash of the Fushigi-Client header,
+		// e.g. "ios" in "ios/1.4.2"
+		collection.Fields.Add(&core.TextField{
+			Name:     "platform",
+			Required: true,
+			Max:      50,
+			Pattern:  `^[a-z0-9_]+$`,
+		})
+
+		// older builds can read but not write, empty lets every build write
+		collection.Fields.Add(&core.TextField{
+			Name:    "min_version",
+			Max:     50,
+			Pattern: `^\d+(\.\d+){0,3}$`,
+		})
+
+		// older builds are asked to upgrade without being refused anything
+		collection.Fields.Add(&core.TextField{
+			Name:    "recommended_version",
+			Max:     50,
+			Pattern: `^\d+(\.\d+){0,3}$`,
+		})
+
+		// where the upgrade is, e.g. the App Store listing
+		collection.Fields.Add(&core.URLField{
+			Name: "update_url",
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "updated",
+			OnCreate: true,
+			OnUpdate: true,
+		})
+
+		collection.AddIndex("idx_client_versions_by_platform", true, "platform", "")
+
+		return app.Save(collection)
+	}, func(app core.App) error { // optional revert operation
+		collection, err := app.FindCollectionByNameOrId("client_versions")
+		if err != nil {
+			return err
+		}
+
+		return app.Delete(collection)
+	})
+}
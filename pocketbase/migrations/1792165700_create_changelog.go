@@ -0,0 +1,89 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// What each release brought, one record per item, which clients show as a
+// "what's new" sheet after upgrading
+func init() {
+	m.Register(func(app core.App) error {
+		// No API rules, superusers only. Clients read it via
+		// /api/fushigi/v1/changelog, with flagged items only for users who
+		// have the flag.
+		collection := core.NewBaseCollection("changelog")
+
+		collection.Fields.Add(&core.TextField{
+			Name:     "version",
+			Required: true,
+			Max:      50,
+			Pattern:  `^\d+(\.\d+){0,3}$`,
+		})
+
+		// empty for every platform, otherwise as in the Fushigi-Client header
+		collection.Fields.Add(&core.TextField{
+			Name:    "platform",
+			Max:     50,
+			Pattern: `^[a-z0-9_]+$`,
+		})
+
+		collection.Fields.Add(&core.TextField{
+			Name:     "title",
+			Required: true,
+			Max:      200,
+		})
+
+		collection.Fields.Add(&core.TextField{
+			Name: "description",
+			Max:  2000,
+		})
+
+		collection.Fields.Add(&core.SelectField{
+			Name:      "kind",
+			Required:  true,
+			MaxSelect: 1,
+			Values:    []string{"feature", "improvement", "fix"},
+		})
+
+		// key of the feature flag the item is behind, shown only to users
+		// who have it
+		collection.Fields.Add(&core.TextField{
+			Name:    "flag",
+			Max:     100,
+			Pattern: `^[a-z0-9_]+$`,
+		})
+
+		// items of a release are shown in this order
+		collection.Fields.Add(&core.NumberField{
+			Name:    "position",
+			OnlyInt: true,
+		})
+
+		collection.Fields.Add(&core.DateField{
+			Name: "released",
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "updated",
+			OnCreate: true,
+			OnUpdate: true,
+		})
+
+		collection.AddIndex("idx_changelog_by_version", false, "version, position", "")
+
+		return app.Save(collection)
+	}, func(app core.App) error { // optional revert operation
+		collection, err := app.FindCollectionByNameOrId("changelog")
+		if err != nil {
+			return err
+		}
+
+		return app.Delete(collection)
+	})
+}
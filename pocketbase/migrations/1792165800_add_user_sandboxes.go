@@ -0,0 +1,56 @@
+package migrations
+
+import (
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Sandboxes are accounts of their own, so whatever users try in them stays
+// out of their real reviews and history and goes in one delete
+func init() {
+	m.Register(func(app core.App) error {
+		users, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		// The user whose sandbox this account is, empty for real accounts.
+		// Only the sandbox routes set it.
+		users.Fields.Add(&core.RelationField{
+			Name:          "sandbox_of",
+			MaxSelect:     1,
+			CascadeDelete: true,
+			CollectionId:  users.Id,
+		})
+		users.AddIndex("idx_users_by_sandbox_of", true, "sandbox_of", "sandbox_of != ''")
+		users.CreateRule = types.Pointer("@request.body.rate_tier:isset = false && @request.body.admin_role:isset = false && @request.body.sandbox_of:isset = false")
+		users.UpdateRule = types.Pointer("id = @request.auth.id && @request.body.storage_quota:isset = false && @request.body.has_password:isset = false && @request.body.rate_tier:isset = false && @request.body.admin_role:isset = false && @request.body.sandbox_of:isset = false")
+
+		return app.Save(users)
+	}, func(app core.App) error { // optional revert operation
+		users, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		// sandboxes can't be told apart from real accounts anymore
+		sandboxes, err := app.FindAllRecords(users, dbx.NewExp("sandbox_of != ''"))
+		if err != nil {
+			return err
+		}
+		for _, sandbox := range sandboxes {
+			if err := app.Delete(sandbox); err != nil {
+				return err
+			}
+		}
+
+		users.RemoveIndex("idx_users_by_sandbox_of")
+		users.Fields.RemoveByName("sandbox_of")
+		users.CreateRule = types.Pointer("@request.body.rate_tier:isset = false && @request.body.admin_role:isset = false")
+		users.UpdateRule = types.Pointer("id = @request.auth.id && @request.body.storage_quota:isset = false && @request.body.has_password:isset = false && @request.body.rate_tier:isset = false && @request.body.admin_role:isset = false")
+
+		return app.Save(users)
+	})
+}
@@ -0,0 +1,64 @@
+package migrations
+
+import (
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Study profiles are accounts of their own behind the user's login, so each
+// keeps its own grammar, reviews and stats
+func init() {
+	m.Register(func(app core.App) error {
+		users, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		// The account whose study profile this is, empty for real accounts,
+		// which are their own first profile. Only the profile routes set it.
+		users.Fields.Add(&core.RelationField{
+			Name:          "profile_of",
+			MaxSelect:     1,
+			CascadeDelete: true,
+			CollectionId:  users.Id,
+		})
+
+		// e.g. "JLPT prep", empty on a real account until it's renamed
+		users.Fields.Add(&core.TextField{
+			Name: "profile_name",
+			Max:  100,
+		})
+
+		users.AddIndex("idx_users_by_profile_of", false, "profile_of", "profile_of != ''")
+		users.CreateRule = types.Pointer("@request.body.rate_tier:isset = false && @request.body.admin_role:isset = false && @request.body.sandbox_of:isset = false && @request.body.profile_of:isset = false && @request.body.profile_name:isset = false")
+		users.UpdateRule = types.Pointer("id = @request.auth.id && @request.body.storage_quota:isset = false && @request.body.has_password:isset = false && @request.body.rate_tier:isset = false && @request.body.admin_role:isset = false && @request.body.sandbox_of:isset = false && @request.body.profile_of:isset = false && @request.body.profile_name:isset = false")
+
+		return app.Save(users)
+	}, func(app core.App) error { // optional revert operation
+		users, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		// profiles can't be told apart from real accounts anymore
+		profiles, err := app.FindAllRecords(users, dbx.NewExp("profile_of != ''"))
+		if err != nil {
+			return err
+		}
+		for _, profile := range profiles {
+			if err := app.Delete(profile); err != nil {
+				return err
+			}
+		}
+
+		users.RemoveIndex("idx_users_by_profile_of")
+		users.Fields.RemoveByName("profile_of")
+		users.Fields.RemoveByName("profile_name")
+		users.CreateRule = types.Pointer("@request.body.rate_tier:isset = false && @request.body.admin_role:isset = false && @request.body.sandbox_of:isset = false")
+		users.UpdateRule = types.Pointer("id = @request.auth.id && @request.body.storage_quota:isset = false && @request.body.has_password:isset = false && @request.body.rate_tier:isset = false && @request.body.admin_role:isset = false && @request.body.sandbox_of:isset = false")
+
+		return app.Save(users)
+	})
+}
@@ -0,0 +1,118 @@
+package migrations
+
+import (
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Child accounts are managed by a parent, so each family member keeps their
+// own reviews. Children log in with a username instead of an email.
+func init() {
+	m.Register(func(app core.App) error {
+		users, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		// The account managing this one, empty for everyone but children.
+		// Only the children routes set it.
+		users.Fields.Add(&core.RelationField{
+			Name:          "parent",
+			MaxSelect:     1,
+			CascadeDelete: true,
+			CollectionId:  users.Id,
+		})
+
+		// what children log in with, the parent picking it
+		users.Fields.Add(&core.TextField{
+			Name:    "username",
+			Max:     30,
+			Pattern: `^[a-z0-9][a-z0-9_.-]{2,29}$`,
+		})
+
+		// what the parent lets the child do, e.g. {"ai": false,
+		// "public_content": false}
+		users.Fields.Add(&core.JSONField{
+			Name:    "parental_controls",
+			MaxSize: 1000,
+		})
+
+		users.AddIndex("idx_users_by_username", true, "username", "username != ''")
+		users.AddIndex("idx_users_by_parent", false, "parent", "parent != ''")
+		users.PasswordAuth.IdentityFields = []string{"email", "username"}
+		users.CreateRule = types.Pointer("@request.body.rate_tier:isset = false && @request.body.admin_role:isset = false && @request.body.sandbox_of:isset = false && @request.body.profile_of:isset = false && @request.body.profile_name:isset = false && @request.body.parent:isset = false && @request.body.username:isset = false && @request.body.parental_controls:isset = false")
+		users.UpdateRule = types.Pointer("id = @request.auth.id && @request.body.storage_quota:isset = false && @request.body.has_password:isset = false && @request.body.rate_tier:isset = false && @request.body.admin_role:isset = false && @request.body.sandbox_of:isset = false && @request.body.profile_of:isset = false && @request.body.profile_name:isset = false && @request.body.parent:isset = false && @request.body.username:isset = false && @request.body.parental_controls:isset = false")
+
+		if err := app.Save(users); err != nil {
+			return err
+		}
+
+		// children only see what others share when their parent lets them
+		journal, err := app.FindCollectionByNameOrId("journal_entry")
+		if err != nil {
+			return err
+		}
+		journal.ViewRule = types.Pointer("@request.auth.id != '' && (user = @request.auth.id || ((@request.auth.parent = '' || @request.auth.parental_controls.public_content = true) && held = false && (audience = 'public' || (audience = 'followers' && @collection.follows.user ?= @request.auth.id && @collection.follows.following ?= user && @collection.follows.status ?= 'approved') || (audience = 'groups' && (groups.owner ?= @request.auth.id || groups.members.id ?= @request.auth.id)) || (@collection.tutors.user ?= user && @collection.tutors.tutor ?= @request.auth.id))))")
+		journal.ListRule = journal.ViewRule
+		if err := app.Save(journal); err != nil {
+			return err
+		}
+
+		mnemonics, err := app.FindCollectionByNameOrId("mnemonics")
+		if err != nil {
+			return err
+		}
+		mnemonics.ViewRule = types.Pointer("@request.auth.id != '' && (user = @request.auth.id || ((@request.auth.parent = '' || @request.auth.parental_controls.public_content = true) && public = true && held = false))")
+		mnemonics.ListRule = mnemonics.ViewRule
+		return app.Save(mnemonics)
+	}, func(app core.App) error { // optional revert operation
+		mnemonics, err := app.FindCollectionByNameOrId("mnemonics")
+		if err != nil {
+			return err
+		}
+		mnemonics.ViewRule = types.Pointer("@request.auth.id != '' && (user = @request.auth.id || (public = true && held = false))")
+		mnemonics.ListRule = mnemonics.ViewRule
+		if err := app.Save(mnemonics); err != nil {
+			return err
+		}
+
+		journal, err := app.FindCollectionByNameOrId("journal_entry")
+		if err != nil {
+			return err
+		}
+		journal.ViewRule = types.Pointer("@request.auth.id != '' && (user = @request.auth.id || (held = false && (audience = 'public' || (audience = 'followers' && @collection.follows.user ?= @request.auth.id && @collection.follows.following ?= user && @collection.follows.status ?= 'approved') || (audience = 'groups' && (groups.owner ?= @request.auth.id || groups.members.id ?= @request.auth.id)) || (@collection.tutors.user ?= user && @collection.tutors.tutor ?= @request.auth.id))))")
+		journal.ListRule = journal.ViewRule
+		if err := app.Save(journal); err != nil {
+			return err
+		}
+
+		users, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		// children couldn't log in anymore
+		children, err := app.FindAllRecords(users, dbx.NewExp("parent != ''"))
+		if err != nil {
+			return err
+		}
+		for _, child := range children {
+			if err := app.Delete(child); err != nil {
+				return err
+			}
+		}
+
+		users.PasswordAuth.IdentityFields = []string{"email"}
+		users.RemoveIndex("idx_users_by_username")
+		users.RemoveIndex("idx_users_by_parent")
+		users.Fields.RemoveByName("parent")
+		users.Fields.RemoveByName("username")
+		users.Fields.RemoveByName("parental_controls")
+		users.CreateRule = types.Pointer("@request.body.rate_tier:isset = false && @request.body.admin_role:isset = false && @request.body.sandbox_of:isset = false && @request.body.profile_of:isset = false && @request.body.profile_name:isset = false")
+		users.UpdateRule = types.Pointer("id = @request.auth.id && @request.body.storage_quota:isset = false && @request.body.has_password:isset = false && @request.body.rate_tier:isset = false && @request.body.admin_role:isset = false && @request.body.sandbox_of:isset = false && @request.body.profile_of:isset = false && @request.body.profile_name:isset = false")
+
+		return app.Save(users)
+	})
+}
@@ -0,0 +1,53 @@
+package migrations
+
+import (
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Guests try the app in accounts of their own, seeded with some study
+// history, which are purged once their trial is over
+func init() {
+	m.Register(func(app core.App) error {
+		users, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		// When the guest account is purged, empty for everyone else. Only
+		// the guests route sets it.
+		users.Fields.Add(&core.DateField{
+			Name: "guest_expires",
+		})
+		users.AddIndex("idx_users_by_guest_expires", false, "guest_expires", "guest_expires != ''")
+		users.CreateRule = types.Pointer("@request.body.rate_tier:isset = false && @request.body.admin_role:isset = false && @request.body.sandbox_of:isset = false && @request.body.profile_of:isset = false && @request.body.profile_name:isset = false && @request.body.parent:isset = false && @request.body.username:isset = false && @request.body.parental_controls:isset = false && @request.body.guest_expires:isset = false")
+		users.UpdateRule = types.Pointer("id = @request.auth.id && @request.body.storage_quota:isset = false && @request.body.has_password:isset = false && @request.body.rate_tier:isset = false && @request.body.admin_role:isset = false && @request.body.sandbox_of:isset = false && @request.body.profile_of:isset = false && @request.body.profile_name:isset = false && @request.body.parent:isset = false && @request.body.username:isset = false && @request.body.parental_controls:isset = false && @request.body.guest_expires:isset = false")
+
+		return app.Save(users)
+	}, func(app core.App) error { // optional revert operation
+		users, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		// guests would stay forever otherwise
+		guests, err := app.FindAllRecords(users, dbx.NewExp("guest_expires != ''"))
+		if err != nil {
+			return err
+		}
+		for _, guest := range guests {
+			if err := app.Delete(guest); err != nil {
+				return err
+			}
+		}
+
+		users.RemoveIndex("idx_users_by_guest_expires")
+		users.Fields.RemoveByName("guest_expires")
+		users.CreateRule = types.Pointer("@request.body.rate_tier:isset = false && @request.body.admin_role:isset = false && @request.body.sandbox_of:isset = false && @request.body.profile_of:isset = false && @request.body.profile_name:isset = false && @request.body.parent:isset = false && @request.body.username:isset = false && @request.body.parental_controls:isset = false")
+		users.UpdateRule = types.Pointer("id = @request.auth.id && @request.body.storage_quota:isset = false && @request.body.has_password:isset = false && @request.body.rate_tier:isset = false && @request.body.admin_role:isset = false && @request.body.sandbox_of:isset = false && @request.body.profile_of:isset = false && @request.body.profile_name:isset = false && @request.body.parent:isset = false && @request.body.username:isset = false && @request.body.parental_controls:isset = false")
+
+		return app.Save(users)
+	})
+}
@@ -0,0 +1,67 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Users invite others with a referral code, which new accounts are
+// attributed to, optionally earning extra AI requests
+func init() {
+	m.Register(func(app core.App) error {
+		users, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		// Made the first time the user asks for it. These are hidden, the
+		// referrals routes showing users their own.
+		users.Fields.Add(&core.TextField{
+			Name:    "referral_code",
+			Max:     20,
+			Pattern: `^[a-z0-9]+$`,
+			Hidden:  true,
+		})
+
+		// the user whose code the account signed up with, cleared if they
+		// delete their account
+		users.Fields.Add(&core.RelationField{
+			Name:         "referred_by",
+			MaxSelect:    1,
+			CollectionId: users.Id,
+			Hidden:       true,
+		})
+
+		// extra AI requests an hour, earned by referring users who verify
+		// their address
+		users.Fields.Add(&core.NumberField{
+			Name:    "ai_bonus",
+			OnlyInt: true,
+			Min:     types.Pointer(0.0),
+			Hidden:  true,
+		})
+
+		users.AddIndex("idx_users_by_referral_code", true, "referral_code", "referral_code != ''")
+		users.AddIndex("idx_users_by_referred_by", false, "referred_by", "referred_by != ''")
+		users.CreateRule = types.Pointer("@request.body.rate_tier:isset = false && @request.body.admin_role:isset = false && @request.body.sandbox_of:isset = false && @request.body.profile_of:isset = false && @request.body.profile_name:isset = false && @request.body.parent:isset = false && @request.body.username:isset = false && @request.body.parental_controls:isset = false && @request.body.guest_expires:isset = false && @request.body.referral_code:isset = false && @request.body.referred_by:isset = false && @request.body.ai_bonus:isset = false")
+		users.UpdateRule = types.Pointer("id = @request.auth.id && @request.body.storage_quota:isset = false && @request.body.has_password:isset = false && @request.body.rate_tier:isset = false && @request.body.admin_role:isset = false && @request.body.sandbox_of:isset = false && @request.body.profile_of:isset = false && @request.body.profile_name:isset = false && @request.body.parent:isset = false && @request.body.username:isset = false && @request.body.parental_controls:isset = false && @request.body.guest_expires:isset = false && @request.body.referral_code:isset = false && @request.body.referred_by:isset = false && @request.body.ai_bonus:isset = false")
+
+		return app.Save(users)
+	}, func(app core.App) error { // optional revert operation
+		users, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		users.RemoveIndex("idx_users_by_referral_code")
+		users.RemoveIndex("idx_users_by_referred_by")
+		users.Fields.RemoveByName("referral_code")
+		users.Fields.RemoveByName("referred_by")
+		users.Fields.RemoveByName("ai_bonus")
+		users.CreateRule = types.Pointer("@request.body.rate_tier:isset = false && @request.body.admin_role:isset = false && @request.body.sandbox_of:isset = false && @request.body.profile_of:isset = false && @request.body.profile_name:isset = false && @request.body.parent:isset = false && @request.body.username:isset = false && @request.body.parental_controls:isset = false && @request.body.guest_expires:isset = false")
+		users.UpdateRule = types.Pointer("id = @request.auth.id && @request.body.storage_quota:isset = false && @request.body.has_password:isset = false && @request.body.rate_tier:isset = false && @request.body.admin_role:isset = false && @request.body.sandbox_of:isset = false && @request.body.profile_of:isset = false && @request.body.profile_name:isset = false && @request.body.parent:isset = false && @request.body.username:isset = false && @request.body.parental_controls:isset = false && @request.body.guest_expires:isset = false")
+
+		return app.Save(users)
+	})
+}
@@ -0,0 +1,93 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+func init() {
+	m.Register(func(app core.App) error {
+		collection := core.NewBaseCollection("review_summaries")
+
+		// Summaries are written by the reviews route as it saves a batch of
+		// reviews, for the recap clients show at the end of a session
+		collection.ViewRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id")
+		collection.ListRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id")
+
+		usersCollection, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+		collection.Fields.Add(&core.RelationField{
+			Name:          "user",
+			Required:      true,
+			CascadeDelete: true,
+			CollectionId:  usersCollection.Id,
+		})
+
+		// the review session the batch ended, if the client timed one
+		sessionsCollection, err := app.FindCollectionByNameOrId("sessions")
+		if err != nil {
+			return err
+		}
+		collection.Fields.Add(&core.RelationField{
+			Name:         "session",
+			MaxSelect:    1,
+			CollectionId: sessionsCollection.Id,
+		})
+
+		collection.Fields.Add(&core.NumberField{
+			Name:    "reviewed",
+			OnlyInt: true,
+			Min:     types.Pointer(0.0),
+		})
+
+		// answered with a grade of 3 or more, which SM-2 counts as recalled
+		collection.Fields.Add(&core.NumberField{
+			Name:    "correct",
+			OnlyInt: true,
+			Min:     types.Pointer(0.0),
+		})
+
+		// the longest run of correct answers in a row
+		collection.Fields.Add(&core.NumberField{
+			Name:    "best_streak",
+			OnlyInt: true,
+			Min:     types.Pointer(0.0),
+		})
+
+		// in seconds, the session's when there's one, otherwise from the
+		// first review to the last
+		collection.Fields.Add(&core.NumberField{
+			Name:    "duration",
+			OnlyInt: true,
+			Min:     types.Pointer(0.0),
+		})
+
+		collection.Fields.Add(&core.DateField{
+			Name:     "started",
+			Required: true,
+		})
+
+		collection.Fields.Add(&core.DateField{
+			Name:     "ended",
+			Required: true,
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		})
+
+		collection.AddIndex("idx_review_summaries_by_user", false, "user, started", "")
+
+		return app.Save(collection)
+	}, func(app core.App) error { // optional revert operation
+		collection, err := app.FindCollectionByNameOrId("review_summaries")
+		if err != nil {
+			return err
+		}
+		return app.Delete(collection)
+	})
+}
@@ -0,0 +1,61 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Each user's queue for the day is worked out once by the nightly rollover,
+// so fetching it doesn't go through every card the user has
+func init() {
+	m.Register(func(app core.App) error {
+		usersCollection, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		// superusers only, served through the plan routes
+		collection := core.NewBaseCollection("review_queues")
+
+		collection.Fields.Add(&core.RelationField{
+			Name:          "user",
+			Required:      true,
+			CascadeDelete: true,
+			CollectionId:  usersCollection.Id,
+		})
+
+		// the day the queue is for in the user's time zone, e.g. 2025-01-31
+		collection.Fields.Add(&core.TextField{
+			Name:     "date",
+			Required: true,
+			Max:      10,
+		})
+
+		// srs record ids in the order to study them
+		collection.Fields.Add(&core.JSONField{
+			Name:    "reviews",
+			MaxSize: 2000000,
+		})
+
+		collection.Fields.Add(&core.JSONField{
+			Name:    "new",
+			MaxSize: 10000,
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "updated",
+			OnCreate: true,
+			OnUpdate: true,
+		})
+
+		collection.AddIndex("idx_review_queues_by_user", true, "user", "")
+
+		return app.Save(collection)
+	}, func(app core.App) error { // optional revert operation
+		collection, err := app.FindCollectionByNameOrId("review_queues")
+		if err != nil {
+			return err
+		}
+		return app.Delete(collection)
+	})
+}
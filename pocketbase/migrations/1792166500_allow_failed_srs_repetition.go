@@ -0,0 +1,43 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// SM-2 puts the repetition of a failed card back to 0, which a required
+// number field rejects, now that the server grades reviews itself
+func init() {
+	m.Register(func(app core.App) error {
+		srs, err := app.FindCollectionByNameOrId("srs")
+		if err != nil {
+			return err
+		}
+
+		repetition, ok := srs.Fields.GetByName("repetition").(*core.NumberField)
+		if !ok {
+			return nil
+		}
+		repetition.Required = false
+		repetition.OnlyInt = true
+		repetition.Min = types.Pointer(0.0)
+
+		return app.Save(srs)
+	}, func(app core.App) error { // optional revert operation
+		srs, err := app.FindCollectionByNameOrId("srs")
+		if err != nil {
+			return err
+		}
+
+		repetition, ok := srs.Fields.GetByName("repetition").(*core.NumberField)
+		if !ok {
+			return nil
+		}
+		repetition.Required = true
+		repetition.OnlyInt = false
+		repetition.Min = nil
+
+		return app.Save(srs)
+	})
+}
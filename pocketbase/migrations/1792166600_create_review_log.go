@@ -0,0 +1,90 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Cards only keep their latest review state, so every review is also logged
+// for history and analytics to be rebuilt from
+func init() {
+	m.Register(func(app core.App) error {
+		usersCollection, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+		srsCollection, err := app.FindCollectionByNameOrId("srs")
+		if err != nil {
+			return err
+		}
+
+		// written by the review routes, users can only read theirs
+		collection := core.NewBaseCollection("review_log")
+		collection.ViewRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id")
+		collection.ListRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id")
+
+		collection.Fields.Add(&core.RelationField{
+			Name:          "user",
+			Required:      true,
+			CascadeDelete: true,
+			CollectionId:  usersCollection.Id,
+		})
+
+		collection.Fields.Add(&core.RelationField{
+			Name:          "srs",
+			Required:      true,
+			CascadeDelete: true,
+			CollectionId:  srsCollection.Id,
+		})
+
+		// SM-2's answer quality, 0 to 5
+		collection.Fields.Add(&core.NumberField{
+			Name:    "grade",
+			OnlyInt: true,
+			Min:     types.Pointer(0.0),
+			Max:     types.Pointer(5.0),
+		})
+
+		collection.Fields.Add(&core.DateField{
+			Name:     "reviewed_at",
+			Required: true,
+		})
+
+		// in days, 0 for a card reviewed for the first time
+		collection.Fields.Add(&core.NumberField{
+			Name:    "previous_interval",
+			OnlyInt: true,
+			Min:     types.Pointer(0.0),
+		})
+
+		collection.Fields.Add(&core.NumberField{
+			Name:    "new_interval",
+			OnlyInt: true,
+			Min:     types.Pointer(0.0),
+		})
+
+		// how long the user took to answer, when the client timed it
+		collection.Fields.Add(&core.NumberField{
+			Name:    "latency_ms",
+			OnlyInt: true,
+			Min:     types.Pointer(0.0),
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		})
+
+		collection.AddIndex("idx_review_log_by_user", false, "user, reviewed_at", "")
+		collection.AddIndex("idx_review_log_by_srs", false, "srs, reviewed_at", "")
+
+		return app.Save(collection)
+	}, func(app core.App) error { // optional revert operation
+		collection, err := app.FindCollectionByNameOrId("review_log")
+		if err != nil {
+			return err
+		}
+		return app.Delete(collection)
+	})
+}
@@ -0,0 +1,51 @@
+package migrations
+
+import (
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Users cap how many new cards and reviews the due list hands them a day,
+// existing users starting from Anki's defaults
+func init() {
+	m.Register(func(app core.App) error {
+		settings, err := app.FindCollectionByNameOrId("user_settings")
+		if err != nil {
+			return err
+		}
+
+		// 0 for none, e.g. while on holiday
+		settings.Fields.Add(&core.NumberField{
+			Name:    "daily_new_limit",
+			OnlyInt: true,
+			Min:     types.Pointer(0.0),
+			Max:     types.Pointer(1000.0),
+		})
+
+		settings.Fields.Add(&core.NumberField{
+			Name:    "daily_review_limit",
+			OnlyInt: true,
+			Min:     types.Pointer(0.0),
+			Max:     types.Pointer(10000.0),
+		})
+
+		if err := app.Save(settings); err != nil {
+			return err
+		}
+		_, err = app.DB().Update("user_settings", dbx.Params{
+			"daily_new_limit":    20,
+			"daily_review_limit": 200,
+		}, nil).Execute()
+		return err
+	}, func(app core.App) error { // optional revert operation
+		settings, err := app.FindCollectionByNameOrId("user_settings")
+		if err != nil {
+			return err
+		}
+		settings.Fields.RemoveByName("daily_new_limit")
+		settings.Fields.RemoveByName("daily_review_limit")
+		return app.Save(settings)
+	})
+}
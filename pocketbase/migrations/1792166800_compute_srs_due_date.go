@@ -0,0 +1,50 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// due_date was an autodate set when a card was created and never again, so
+// every card looked due from then on. It's now a plain date a hook keeps in
+// step with the review state, backfilled from it.
+func init() {
+	// swaps the field for another kind of field, its index going away and
+	// coming back with it
+	replace := func(app core.App, field core.Field) error {
+		srs, err := app.FindCollectionByNameOrId("srs")
+		if err != nil {
+			return err
+		}
+		srs.RemoveIndex("idx_srs_by_user_due")
+		srs.Fields.RemoveByName("due_date")
+		if err := app.Save(srs); err != nil {
+			return err
+		}
+
+		srs.Fields.Add(field)
+		srs.AddIndex("idx_srs_by_user_due", false, "user, due_date", "")
+		return app.Save(srs)
+	}
+
+	m.Register(func(app core.App) error {
+		if err := replace(app, &core.DateField{Name: "due_date"}); err != nil {
+			return err
+		}
+
+		// same as srs.Card.DueDate, cards never reviewed are due from creation
+		_, err := app.DB().NewQuery(`
+			UPDATE srs SET due_date = CASE
+				WHEN last_reviewed = '' THEN created
+				ELSE strftime('%Y-%m-%d %H:%M:%fZ', last_reviewed, '+' || CAST(interval_days AS INTEGER) || ' days')
+			END
+		`).Execute()
+		return err
+	}, func(app core.App) error { // optional revert operation
+		if err := replace(app, &core.AutodateField{Name: "due_date", OnCreate: true}); err != nil {
+			return err
+		}
+		_, err := app.DB().NewQuery("UPDATE srs SET due_date = created").Execute()
+		return err
+	})
+}
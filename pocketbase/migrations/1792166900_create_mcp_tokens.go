@@ -0,0 +1,75 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Desktop assistants connect to the MCP server with tokens of their own,
+// limited to what the user let them do and revocable without signing out
+func init() {
+	m.Register(func(app core.App) error {
+		usersCollection, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		// No API rules, users manage their tokens through the custom routes
+		collection := core.NewBaseCollection("mcp_tokens")
+
+		collection.Fields.Add(&core.RelationField{
+			Name:          "user",
+			Required:      true,
+			CascadeDelete: true,
+			CollectionId:  usersCollection.Id,
+		})
+
+		// e.g. the assistant it was made for
+		collection.Fields.Add(&core.TextField{
+			Name:     "name",
+			Required: true,
+			Max:      100,
+		})
+
+		// sha256 of the token, which is only shown once
+		collection.Fields.Add(&core.TextField{
+			Name:     "token_hash",
+			Required: true,
+			Hidden:   true,
+		})
+
+		collection.Fields.Add(&core.SelectField{
+			Name:      "scopes",
+			Required:  true,
+			MaxSelect: 3,
+			Values:    []string{"grammar:read", "reviews:read", "journal:write"},
+		})
+
+		collection.Fields.Add(&core.DateField{
+			Name: "last_used",
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "updated",
+			OnCreate: true,
+			OnUpdate: true,
+		})
+
+		collection.AddIndex("idx_mcp_tokens_by_token_hash", true, "token_hash", "")
+		collection.AddIndex("idx_mcp_tokens_by_user", false, "user", "")
+
+		return app.Save(collection)
+	}, func(app core.App) error { // optional revert operation
+		collection, err := app.FindCollectionByNameOrId("mcp_tokens")
+		if err != nil {
+			return err
+		}
+
+		return app.Delete(collection)
+	})
+}
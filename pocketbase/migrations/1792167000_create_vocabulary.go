@@ -0,0 +1,160 @@
+package migrations
+
+import (
+	"github.com/pocketbase/dbx"
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Vocabulary is kept like grammar, a shared library plus what users add for
+// themselves, and cards can now be for either: item_type says which of the
+// grammar and vocabulary relations a card fills.
+func init() {
+	m.Register(func(app core.App) error {
+		collection := core.NewBaseCollection("vocabulary")
+
+		collection.ViewRule = types.Pointer("@request.auth.id != '' && (user = @request.auth.id || user = null)")
+		collection.ListRule = types.Pointer("@request.auth.id != '' && (user = @request.auth.id || user = null)")
+		collection.CreateRule = types.Pointer("@request.auth.id != '' && @request.body.user = @request.auth.id")
+		collection.UpdateRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id && (@request.body.user:isset = false || @request.body.user = @request.auth.id)")
+		collection.DeleteRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id")
+
+		usersCollection, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+		collection.Fields.Add(&core.RelationField{
+			Name:          "user",
+			Required:      false,
+			CascadeDelete: true,
+			CollectionId:  usersCollection.Id,
+		})
+
+		languagesCollection, err := app.FindCollectionByNameOrId("languages")
+		if err != nil {
+			return err
+		}
+		collection.Fields.Add(&core.RelationField{
+			Name:          "language",
+			Required:      true,
+			CascadeDelete: false,
+			CollectionId:  languagesCollection.Id,
+		})
+
+		collection.Fields.Add(&core.TextField{
+			Name:     "term",
+			Required: true,
+			Max:      200,
+		})
+
+		collection.Fields.Add(&core.TextField{
+			Name: "reading",
+			Max:  200,
+		})
+
+		collection.Fields.Add(&core.TextField{
+			Name:     "meaning",
+			Required: true,
+		})
+
+		collection.Fields.Add(&core.TextField{
+			Name: "part_of_speech",
+			Max:  50,
+		})
+
+		collection.Fields.Add(&core.JSONField{
+			Name: "tags",
+		})
+
+		collection.Fields.Add(&core.JSONField{
+			Name: "examples",
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "updated",
+			OnCreate: true,
+			OnUpdate: true,
+		})
+
+		collection.AddIndex("idx_vocabulary_by_language_user", false, "language, user", "")
+		collection.AddIndex("idx_vocabulary_by_user_term", false, "user, term, id", "")
+
+		if err := app.Save(collection); err != nil {
+			return err
+		}
+
+		srs, err := app.FindCollectionByNameOrId("srs")
+		if err != nil {
+			return err
+		}
+
+		srs.Fields.Add(&core.SelectField{
+			Name:      "item_type",
+			MaxSelect: 1,
+			Values:    []string{"grammar", "vocabulary"},
+		})
+		if grammar, ok := srs.Fields.GetByName("grammar").(*core.RelationField); ok {
+			grammar.Required = false
+		}
+		srs.Fields.Add(&core.RelationField{
+			Name:          "vocabulary",
+			CascadeDelete: true,
+			CollectionId:  collection.Id,
+		})
+
+		// one card per item a user studies, cards leaving the other relation
+		// empty
+		srs.RemoveIndex("idx_srs_by_grammar_per_user")
+		srs.AddIndex("idx_srs_by_grammar_per_user", true, "user, grammar", "grammar != ''")
+		srs.AddIndex("idx_srs_by_vocabulary_per_user", true, "user, vocabulary", "vocabulary != ''")
+
+		if err := app.Save(srs); err != nil {
+			return err
+		}
+
+		_, err = app.DB().NewQuery("UPDATE srs SET item_type = 'grammar'").Execute()
+		return err
+	}, func(app core.App) error { // optional revert operation
+		// deleted as records so their review log goes with them
+		cards, err := app.FindAllRecords("srs", dbx.HashExp{"item_type": "vocabulary"})
+		if err != nil {
+			return err
+		}
+		for _, card := range cards {
+			if err := app.Delete(card); err != nil {
+				return err
+			}
+		}
+
+		srs, err := app.FindCollectionByNameOrId("srs")
+		if err != nil {
+			return err
+		}
+
+		srs.RemoveIndex("idx_srs_by_vocabulary_per_user")
+		srs.RemoveIndex("idx_srs_by_grammar_per_user")
+		srs.AddIndex("idx_srs_by_grammar_per_user", true, "user, grammar", "")
+		srs.Fields.RemoveByName("vocabulary")
+		srs.Fields.RemoveByName("item_type")
+		if grammar, ok := srs.Fields.GetByName("grammar").(*core.RelationField); ok {
+			grammar.Required = true
+		}
+
+		if err := app.Save(srs); err != nil {
+			return err
+		}
+
+		collection, err := app.FindCollectionByNameOrId("vocabulary")
+		if err != nil {
+			return err
+		}
+
+		return app.Delete(collection)
+	})
+}
@@ -0,0 +1,88 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Kanji are reference data every user reads and only `kanji import` writes,
+// from KANJIDIC2 and KRADFILE. Components are the parts a kanji is written
+// with that are kanji themselves.
+func init() {
+	m.Register(func(app core.App) error {
+		collection := core.NewBaseCollection("kanji")
+
+		collection.ViewRule = types.Pointer("@request.auth.id != ''")
+		collection.ListRule = types.Pointer("@request.auth.id != ''")
+
+		collection.Fields.Add(&core.TextField{
+			Name:     "character",
+			Required: true,
+			Max:      2,
+		})
+
+		collection.Fields.Add(&core.JSONField{
+			Name: "meanings",
+		})
+
+		collection.Fields.Add(&core.JSONField{
+			Name: "on_readings",
+		})
+
+		collection.Fields.Add(&core.JSONField{
+			Name: "kun_readings",
+		})
+
+		collection.Fields.Add(&core.NumberField{
+			Name:    "stroke_count",
+			OnlyInt: true,
+			Min:     types.Pointer(0.0),
+		})
+
+		// the old four-level JLPT KANJIDIC has, 1 being the hardest
+		collection.Fields.Add(&core.NumberField{
+			Name:    "jlpt",
+			OnlyInt: true,
+			Min:     types.Pointer(0.0),
+			Max:     types.Pointer(4.0),
+		})
+
+		collection.Fields.Add(&core.JSONField{
+			Name: "radicals",
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "updated",
+			OnCreate: true,
+			OnUpdate: true,
+		})
+
+		collection.AddIndex("idx_kanji_by_character", true, "character", "")
+		collection.AddIndex("idx_kanji_by_jlpt", false, "jlpt", "jlpt > 0")
+
+		if err := app.Save(collection); err != nil {
+			return err
+		}
+
+		// a relation can only point to a collection once it's saved
+		collection.Fields.Add(&core.RelationField{
+			Name:         "components",
+			MaxSelect:    20,
+			CollectionId: collection.Id,
+		})
+		return app.Save(collection)
+	}, func(app core.App) error { // optional revert operation
+		collection, err := app.FindCollectionByNameOrId("kanji")
+		if err != nil {
+			return err
+		}
+
+		return app.Delete(collection)
+	})
+}
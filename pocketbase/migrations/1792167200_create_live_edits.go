@@ -0,0 +1,98 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// A live edit is a learner and their tutor annotating a tutor session's
+// document together, its annotations imported as corrections when it ends.
+// The document being edited lives in memory, saved here every few seconds so
+// a restart doesn't lose it.
+func init() {
+	m.Register(func(app core.App) error {
+		// written through the custom routes only
+		collection := core.NewBaseCollection("live_edits")
+		collection.ViewRule = types.Pointer("@request.auth.id != '' && (user = @request.auth.id || tutor = @request.auth.id)")
+		collection.ListRule = types.Pointer("@request.auth.id != '' && (user = @request.auth.id || tutor = @request.auth.id)")
+
+		usersCollection, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+		collection.Fields.Add(&core.RelationField{
+			Name:          "user",
+			Required:      true,
+			CascadeDelete: true,
+			CollectionId:  usersCollection.Id,
+		})
+		collection.Fields.Add(&core.RelationField{
+			Name:          "tutor",
+			Required:      true,
+			CascadeDelete: true,
+			CollectionId:  usersCollection.Id,
+		})
+
+		sessionsCollection, err := app.FindCollectionByNameOrId("tutor_sessions")
+		if err != nil {
+			return err
+		}
+		collection.Fields.Add(&core.RelationField{
+			Name:          "tutor_session",
+			Required:      true,
+			CascadeDelete: true,
+			CollectionId:  sessionsCollection.Id,
+		})
+
+		journalCollection, err := app.FindCollectionByNameOrId("journal_entry")
+		if err != nil {
+			return err
+		}
+		collection.Fields.Add(&core.RelationField{
+			Name:          "journal_entry",
+			Required:      true,
+			CascadeDelete: true,
+			CollectionId:  journalCollection.Id,
+		})
+
+		collection.Fields.Add(&core.TextField{
+			Name: "document",
+			Max:  200000,
+		})
+
+		collection.Fields.Add(&core.NumberField{
+			Name:    "revision",
+			OnlyInt: true,
+			Min:     types.Pointer(0.0),
+		})
+
+		// when the annotations were imported, empty while it's being edited
+		collection.Fields.Add(&core.DateField{
+			Name: "ended",
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		})
+
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "updated",
+			OnCreate: true,
+			OnUpdate: true,
+		})
+
+		collection.AddIndex("idx_live_edits_by_user", false, "user, created", "")
+		collection.AddIndex("idx_live_edits_by_tutor", false, "tutor, created", "")
+
+		return app.Save(collection)
+	}, func(app core.App) error { // optional revert operation
+		collection, err := app.FindCollectionByNameOrId("live_edits")
+		if err != nil {
+			return err
+		}
+
+		return app.Delete(collection)
+	})
+}
@@ -0,0 +1,100 @@
+package migrations
+
+import (
+	"strings"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// importedCollections can be filled by imports besides the journal, Anki
+// notes becoming grammar or vocabulary. Like journal entries they remember
+// the import that created them so it can be rolled back, and where they came
+// from so importing the same notes again updates them.
+var importedCollections = []string{"grammar", "vocabulary"}
+
+// importUnset keeps users from setting the import themselves
+const importUnset = " && @request.body.import:isset = false"
+
+func init() {
+	m.Register(func(app core.App) error {
+		imports, err := app.FindCollectionByNameOrId("imports")
+		if err != nil {
+			return err
+		}
+
+		vocabulary, err := app.FindCollectionByNameOrId("vocabulary")
+		if err != nil {
+			return err
+		}
+		// grammar has these already
+		vocabulary.Fields.Add(&core.TextField{
+			Name: "source",
+			Max:  500,
+		})
+		vocabulary.Fields.Add(&core.TextField{
+			Name: "source_id",
+			Max:  100,
+		})
+		vocabulary.AddIndex("idx_vocabulary_by_source", false, "user, source, source_id", "source != ''")
+		if err := app.Save(vocabulary); err != nil {
+			return err
+		}
+
+		for _, name := range importedCollections {
+			collection, err := app.FindCollectionByNameOrId(name)
+			if err != nil {
+				return err
+			}
+
+			collection.Fields.Add(&core.RelationField{
+				Name:         "import",
+				MaxSelect:    1,
+				CollectionId: imports.Id,
+			})
+			collection.AddIndex("idx_"+name+"_by_import", false, "import", "import != ''")
+			if collection.CreateRule != nil {
+				*collection.CreateRule += importUnset
+			}
+			if collection.UpdateRule != nil {
+				*collection.UpdateRule += importUnset
+			}
+
+			if err := app.Save(collection); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, func(app core.App) error { // optional revert operation
+		for _, name := range importedCollections {
+			collection, err := app.FindCollectionByNameOrId(name)
+			if err != nil {
+				return err
+			}
+
+			collection.RemoveIndex("idx_" + name + "_by_import")
+			collection.Fields.RemoveByName("import")
+			if collection.CreateRule != nil {
+				*collection.CreateRule = strings.TrimSuffix(*collection.CreateRule, importUnset)
+			}
+			if collection.UpdateRule != nil {
+				*collection.UpdateRule = strings.TrimSuffix(*collection.UpdateRule, importUnset)
+			}
+
+			if err := app.Save(collection); err != nil {
+				return err
+			}
+		}
+
+		vocabulary, err := app.FindCollectionByNameOrId("vocabulary")
+		if err != nil {
+			return err
+		}
+		vocabulary.RemoveIndex("idx_vocabulary_by_source")
+		vocabulary.Fields.RemoveByName("source")
+		vocabulary.Fields.RemoveByName("source_id")
+
+		return app.Save(vocabulary)
+	})
+}
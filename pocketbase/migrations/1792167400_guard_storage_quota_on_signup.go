@@ -0,0 +1,31 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Keeps signups from giving themselves a storage quota, which only the
+// update rule guarded.
+func init() {
+	m.Register(func(app core.App) error {
+		users, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		users.CreateRule = types.Pointer("@request.body.rate_tier:isset = false && @request.body.admin_role:isset = false && @request.body.sandbox_of:isset = false && @request.body.profile_of:isset = false && @request.body.profile_name:isset = false && @request.body.parent:isset = false && @request.body.username:isset = false && @request.body.parental_controls:isset = false && @request.body.guest_expires:isset = false && @request.body.referral_code:isset = false && @request.body.referred_by:isset = false && @request.body.ai_bonus:isset = false && @request.body.storage_quota:isset = false")
+
+		return app.Save(users)
+	}, func(app core.App) error { // optional revert operation
+		users, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		users.CreateRule = types.Pointer("@request.body.rate_tier:isset = false && @request.body.admin_role:isset = false && @request.body.sandbox_of:isset = false && @request.body.profile_of:isset = false && @request.body.profile_name:isset = false && @request.body.parent:isset = false && @request.body.username:isset = false && @request.body.parental_controls:isset = false && @request.body.guest_expires:isset = false && @request.body.referral_code:isset = false && @request.body.referred_by:isset = false && @request.body.ai_bonus:isset = false")
+
+		return app.Save(users)
+	})
+}
@@ -0,0 +1,33 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Keeps clients from setting the size of their media, which storage usage
+// is summed from.
+func init() {
+	m.Register(func(app core.App) error {
+		media, err := app.FindCollectionByNameOrId("media")
+		if err != nil {
+			return err
+		}
+
+		media.CreateRule = types.Pointer("@request.auth.id != '' && @request.body.user = @request.auth.id && @request.body.blob:isset = false && @request.body.hash:isset = false && @request.body.thumbnail:isset = false && @request.body.width:isset = false && @request.body.height:isset = false && @request.body.duration:isset = false && @request.body.waveform:isset = false && @request.body.processed:isset = false && @request.body.size:isset = false")
+		media.UpdateRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id && (@request.body.user:isset = false || @request.body.user = @request.auth.id) && @request.body.blob:isset = false && @request.body.hash:isset = false && @request.body.thumbnail:isset = false && @request.body.width:isset = false && @request.body.height:isset = false && @request.body.duration:isset = false && @request.body.waveform:isset = false && @request.body.processed:isset = false && @request.body.size:isset = false")
+
+		return app.Save(media)
+	}, func(app core.App) error { // optional revert operation
+		media, err := app.FindCollectionByNameOrId("media")
+		if err != nil {
+			return err
+		}
+
+		media.CreateRule = types.Pointer("@request.auth.id != '' && @request.body.user = @request.auth.id && @request.body.blob:isset = false && @request.body.hash:isset = false && @request.body.thumbnail:isset = false && @request.body.width:isset = false && @request.body.height:isset = false && @request.body.duration:isset = false && @request.body.waveform:isset = false && @request.body.processed:isset = false")
+		media.UpdateRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id && (@request.body.user:isset = false || @request.body.user = @request.auth.id) && @request.body.blob:isset = false && @request.body.hash:isset = false && @request.body.thumbnail:isset = false && @request.body.width:isset = false && @request.body.height:isset = false && @request.body.duration:isset = false && @request.body.waveform:isset = false && @request.body.processed:isset = false")
+
+		return app.Save(media)
+	})
+}
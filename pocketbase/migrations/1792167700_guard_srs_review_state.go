@@ -0,0 +1,38 @@
+package migrations
+
+import (
+	"strings"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// reviewStateUnset keeps clients from saving a card's review state
+// themselves, it's graded on the server by the srs routes
+const reviewStateUnset = " && @request.body.ease_factor:isset = false && @request.body.interval_days:isset = false && @request.body.repetition:isset = false && @request.body.last_reviewed:isset = false && @request.body.due_date:isset = false"
+
+func init() {
+	m.Register(func(app core.App) error {
+		srs, err := app.FindCollectionByNameOrId("srs")
+		if err != nil {
+			return err
+		}
+
+		if srs.UpdateRule != nil && !strings.Contains(*srs.UpdateRule, "@request.body.ease_factor:isset") {
+			*srs.UpdateRule += reviewStateUnset
+		}
+
+		return app.Save(srs)
+	}, func(app core.App) error { // optional revert operation
+		srs, err := app.FindCollectionByNameOrId("srs")
+		if err != nil {
+			return err
+		}
+
+		if srs.UpdateRule != nil {
+			*srs.UpdateRule = strings.TrimSuffix(*srs.UpdateRule, reviewStateUnset)
+		}
+
+		return app.Save(srs)
+	})
+}
@@ -0,0 +1,38 @@
+package migrations
+
+import (
+	"strings"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// reviewedUnset keeps clients from creating cards that were already reviewed,
+// reviews only being saved by the srs routes, which log each one
+const reviewedUnset = " && @request.body.last_reviewed:isset = false"
+
+func init() {
+	m.Register(func(app core.App) error {
+		srs, err := app.FindCollectionByNameOrId("srs")
+		if err != nil {
+			return err
+		}
+
+		if srs.CreateRule != nil && !strings.Contains(*srs.CreateRule, "@request.body.last_reviewed:isset") {
+			*srs.CreateRule += reviewedUnset
+		}
+
+		return app.Save(srs)
+	}, func(app core.App) error { // optional revert operation
+		srs, err := app.FindCollectionByNameOrId("srs")
+		if err != nil {
+			return err
+		}
+
+		if srs.CreateRule != nil {
+			*srs.CreateRule = strings.TrimSuffix(*srs.CreateRule, reviewedUnset)
+		}
+
+		return app.Save(srs)
+	})
+}
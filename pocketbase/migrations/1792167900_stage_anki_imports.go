@@ -0,0 +1,59 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Anki notes are staged like journal entries, previewed and fixed before
+// they're committed as grammar or vocabulary
+func init() {
+	m.Register(func(app core.App) error {
+		imports, err := app.FindCollectionByNameOrId("imports")
+		if err != nil {
+			return err
+		}
+		// what notes are imported as, e.g. {"target": "vocabulary",
+		// "language": "..."}, empty for journal imports
+		imports.Fields.Add(&core.JSONField{
+			Name:    "options",
+			MaxSize: 10000,
+		})
+		if err := app.Save(imports); err != nil {
+			return err
+		}
+
+		items, err := app.FindCollectionByNameOrId("import_items")
+		if err != nil {
+			return err
+		}
+		// one staged Anki note, staged in place of an entry
+		items.Fields.Add(&core.JSONField{
+			Name:    "note",
+			MaxSize: 1 << 20,
+		})
+		return app.Save(items)
+	}, func(app core.App) error { // optional revert operation
+		// staged notes can't be committed anymore
+		_, err := app.DB().NewQuery("DELETE FROM imports WHERE kind = 'anki_import' AND status = 'staged'").Execute()
+		if err != nil {
+			return err
+		}
+
+		items, err := app.FindCollectionByNameOrId("import_items")
+		if err != nil {
+			return err
+		}
+		items.Fields.RemoveByName("note")
+		if err := app.Save(items); err != nil {
+			return err
+		}
+
+		imports, err := app.FindCollectionByNameOrId("imports")
+		if err != nil {
+			return err
+		}
+		imports.Fields.RemoveByName("options")
+		return app.Save(imports)
+	})
+}
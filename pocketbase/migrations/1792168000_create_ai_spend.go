@@ -0,0 +1,40 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// AI spend is rolled up by day, provider and model as calls are made, in a
+// plain table of its own so the totals outlive the audit log users purge.
+// It keeps no user, only what the instance spent.
+func init() {
+	m.Register(func(app core.App) error {
+		_, err := app.DB().NewQuery(`
+			CREATE TABLE IF NOT EXISTS _ai_spend (
+				day               TEXT NOT NULL,
+				provider          TEXT NOT NULL,
+				model             TEXT NOT NULL DEFAULT '',
+				requests          INTEGER NOT NULL DEFAULT 0,
+				prompt_tokens     INTEGER NOT NULL DEFAULT 0,
+				completion_tokens INTEGER NOT NULL DEFAULT 0,
+				PRIMARY KEY (day, provider, model)
+			)
+		`).Execute()
+		if err != nil {
+			return err
+		}
+
+		// what's left of the audit log so far
+		_, err = app.DB().NewQuery(`
+			INSERT INTO _ai_spend (day, provider, model, requests, prompt_tokens, completion_tokens)
+			SELECT substr(created, 1, 10), provider, model, COUNT(*), SUM(prompt_tokens), SUM(completion_tokens)
+			FROM ai_audit
+			GROUP BY substr(created, 1, 10), provider, model
+		`).Execute()
+		return err
+	}, func(app core.App) error { // optional revert operation
+		_, err := app.DB().NewQuery("DROP TABLE IF EXISTS _ai_spend").Execute()
+		return err
+	})
+}
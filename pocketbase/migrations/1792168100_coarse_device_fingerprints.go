@@ -0,0 +1,66 @@
+package migrations
+
+import (
+	"net"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/security"
+)
+
+// Login devices are fingerprinted by their user agent and network rather
+// than their exact address. Known devices are fingerprinted again so they
+// don't all look new on their next login, keeping the latest of those that
+// turn out to be the same device. The network is the /24 (IPv4) or /48
+// (IPv6) of the address, as of this migration.
+func init() {
+	m.Register(func(app core.App) error {
+		return refingerprintDevices(app, func(device *core.Record) string {
+			network := ""
+			if ip := net.ParseIP(device.GetString("ip")); ip.To4() != nil {
+				network = ip.Mask(net.CIDRMask(24, 32)).String()
+			} else if ip != nil {
+				network = ip.Mask(net.CIDRMask(48, 128)).String()
+			}
+			return security.SHA256(network + "|" + device.GetString("user_agent"))
+		})
+	}, func(app core.App) error { // optional revert operation
+		return refingerprintDevices(app, func(device *core.Record) string {
+			return security.SHA256(device.GetString("ip") + "|" + device.GetString("user_agent"))
+		})
+	})
+}
+
+func refingerprintDevices(app core.App, fingerprint func(device *core.Record) string) error {
+	devices, err := app.FindRecordsByFilter("login_devices", "", "-last_login", 0, 0)
+	if err != nil {
+		return err
+	}
+
+	// a new fingerprint can be an old one of another device, so they're all
+	// cleared before any is set
+	kept := map[string]*core.Record{}
+	for _, device := range devices {
+		key := device.GetString("user") + "|" + fingerprint(device)
+		if _, ok := kept[key]; ok {
+			if err := app.Delete(device); err != nil {
+				return err
+			}
+			continue
+		}
+		kept[key] = device
+
+		device.Set("fingerprint", device.Id)
+		if err := app.Save(device); err != nil {
+			return err
+		}
+	}
+
+	for _, device := range kept {
+		device.Set("fingerprint", fingerprint(device))
+		if err := app.Save(device); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// The srs hooks guard the review state instead of the collection's rules,
+// which can't tell the iOS builds that still save it themselves apart.
+func init() {
+	m.Register(func(app core.App) error {
+		srs, err := app.FindCollectionByNameOrId("srs")
+		if err != nil {
+			return err
+		}
+
+		srs.CreateRule = types.Pointer("@request.auth.id != '' && @request.body.user = @request.auth.id")
+		srs.UpdateRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id && (@request.body.user:isset = false || @request.body.user = @request.auth.id)")
+
+		return app.Save(srs)
+	}, func(app core.App) error { // optional revert operation
+		srs, err := app.FindCollectionByNameOrId("srs")
+		if err != nil {
+			return err
+		}
+
+		srs.CreateRule = types.Pointer("@request.auth.id != '' && @request.body.user = @request.auth.id && @request.body.last_reviewed:isset = false")
+		srs.UpdateRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id && (@request.body.user:isset = false || @request.body.user = @request.auth.id) && @request.body.ease_factor:isset = false && @request.body.interval_days:isset = false && @request.body.repetition:isset = false && @request.body.last_reviewed:isset = false && @request.body.due_date:isset = false")
+
+		return app.Save(srs)
+	})
+}
@@ -0,0 +1,38 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Vocabulary carries its rank in the frequency lists superusers import, like
+// grammar does
+func init() {
+	m.Register(func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("vocabulary")
+		if err != nil {
+			return err
+		}
+
+		// 1 is the most frequent, zero when the list doesn't have it
+		collection.Fields.Add(&core.NumberField{
+			Name:    "frequency_rank",
+			OnlyInt: true,
+			Min:     types.Pointer(0.0),
+		})
+		collection.AddIndex("idx_vocabulary_by_language_frequency", false, "language, frequency_rank", "frequency_rank > 0")
+
+		return app.Save(collection)
+	}, func(app core.App) error { // optional revert operation
+		collection, err := app.FindCollectionByNameOrId("vocabulary")
+		if err != nil {
+			return err
+		}
+
+		collection.RemoveIndex("idx_vocabulary_by_language_frequency")
+		collection.Fields.RemoveByName("frequency_rank")
+
+		return app.Save(collection)
+	})
+}
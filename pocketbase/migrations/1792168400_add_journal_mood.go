@@ -0,0 +1,35 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+// Entries can say how their writer felt that day, which markdown exports
+// carry in their frontmatter
+func init() {
+	m.Register(func(app core.App) error {
+		collection, err := app.FindCollectionByNameOrId("journal_entry")
+		if err != nil {
+			return err
+		}
+
+		// left empty when the writer doesn't say
+		collection.Fields.Add(&core.SelectField{
+			Name:      "mood",
+			MaxSelect: 1,
+			Values:    []string{"great", "good", "okay", "bad", "awful"},
+		})
+
+		return app.Save(collection)
+	}, func(app core.App) error { // optional revert operation
+		collection, err := app.FindCollectionByNameOrId("journal_entry")
+		if err != nil {
+			return err
+		}
+
+		collection.Fields.RemoveByName("mood")
+
+		return app.Save(collection)
+	})
+}
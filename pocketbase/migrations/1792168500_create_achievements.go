@@ -0,0 +1,54 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Achievements are milestones users earn by seeing guided study sessions
+// through, awarded by the server
+func init() {
+	m.Register(func(app core.App) error {
+		usersCollection, err := app.FindCollectionByNameOrId("users")
+		if err != nil {
+			return err
+		}
+
+		// users read theirs, only the server awards them
+		collection := core.NewBaseCollection("achievements")
+		collection.ListRule = types.Pointer("@request.auth.id != '' && user = @request.auth.id")
+		collection.ViewRule = collection.ListRule
+
+		collection.Fields.Add(&core.RelationField{
+			Name:          "user",
+			Required:      true,
+			CascadeDelete: true,
+			CollectionId:  usersCollection.Id,
+		})
+
+		// which achievement, e.g. "sessions_10"
+		collection.Fields.Add(&core.TextField{
+			Name:     "key",
+			Required: true,
+			Max:      50,
+		})
+
+		// when it was earned
+		collection.Fields.Add(&core.AutodateField{
+			Name:     "created",
+			OnCreate: true,
+		})
+
+		collection.AddIndex("idx_achievements_by_user_key", true, "user, key", "")
+
+		return app.Save(collection)
+	}, func(app core.App) error { // optional revert operation
+		collection, err := app.FindCollectionByNameOrId("achievements")
+		if err != nil {
+			return err
+		}
+
+		return app.Delete(collection)
+	})
+}
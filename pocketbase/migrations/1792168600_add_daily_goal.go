@@ -0,0 +1,35 @@
+package migrations
+
+import (
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+	"github.com/pocketbase/pocketbase/tools/types"
+)
+
+// Users can set how many minutes a day they mean to study, which the daily
+// plan fits its new grammar into
+func init() {
+	m.Register(func(app core.App) error {
+		settings, err := app.FindCollectionByNameOrId("user_settings")
+		if err != nil {
+			return err
+		}
+
+		// 0 for no goal
+		settings.Fields.Add(&core.NumberField{
+			Name:    "daily_goal_minutes",
+			OnlyInt: true,
+			Min:     types.Pointer(0.0),
+			Max:     types.Pointer(600.0),
+		})
+
+		return app.Save(settings)
+	}, func(app core.App) error { // optional revert operation
+		settings, err := app.FindCollectionByNameOrId("user_settings")
+		if err != nil {
+			return err
+		}
+		settings.Fields.RemoveByName("daily_goal_minutes")
+		return app.Save(settings)
+	})
+}
@@ -0,0 +1,6 @@
+package main
+
+// Third-party plugins compiled into the server, one blank import each. Every
+// plugin registers itself from its init, see the plugins package.
+//
+//	import _ "example.com/fushigi-korean"
@@ -0,0 +1,131 @@
+// Package plugins lets third-party Go extensions be compiled into the server
+// without forking main.go. A plugin calls Register from its package's init,
+// and is compiled in with a blank import in plugins.go next to main.go, the
+// way database/sql drivers are.
+package plugins
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+
+	"github.com/bunkbed-tech/fushigi/pocketbase/internal/events"
+
+	"github.com/pocketbase/pocketbase/core"
+	m "github.com/pocketbase/pocketbase/migrations"
+)
+
+var namePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,39}$`)
+
+// Plugin is an extension compiled into the server
+type Plugin interface {
+	// Name is unique among plugins, lowercase letters, digits and hyphens.
+	// The plugin's routes live under /api/fushigi/v1/plugins/{name}.
+	Name() string
+
+	// Setup binds the plugin's hooks, crons, routes and migrations. It runs
+	// once, after the built-in hooks are bound and before the app starts.
+	Setup(r *Registrar) error
+}
+
+// Route is a custom route of a plugin, documented in the OpenAPI spec along
+// with the built-in ones
+type Route struct {
+	Method  string
+	Path    string
+	Summary string
+
+	// Zero values of the request body and response types, nil when there is none
+	Request  any
+	Response any
+
+	// Public routes can be called without logging in, the others act on
+	// behalf of the logged in user like the built-in ones
+	Public bool
+
+	Handler func(e *core.RequestEvent) error
+}
+
+// Event is a domain event, Record being the record it's about
+type Event = events.Event
+
+// The domain events plugins can subscribe to, see the events package
+const (
+	GrammarCreated   = events.GrammarCreated
+	ReviewCompleted  = events.ReviewCompleted
+	CardAdded        = events.CardAdded
+	CardRescheduled  = events.CardRescheduled
+	CardRemoved      = events.CardRemoved
+	EntryWritten     = events.EntryWritten
+	EntryPublished   = events.EntryPublished
+	SessionCompleted = events.SessionCompleted
+)
+
+// Registrar is what a plugin sets itself up with
+type Registrar struct {
+	// App binds hooks, e.g. App.OnRecordCreate("grammar")
+	App core.App
+
+	// Events subscribes to the domain events, e.g.
+	// Events.On(plugins.ReviewCompleted)
+	Events *events.Bus
+
+	name   string
+	routes []Route
+}
+
+// Route adds a route under the plugin's path
+func (r *Registrar) Route(route Route) {
+	r.routes = append(r.routes, route)
+}
+
+// Cron schedules a job, its id being namespaced by the plugin's name
+func (r *Registrar) Cron(id string, expr string, fn func()) error {
+	return r.App.Cron().Add("plugin_"+r.name+"_"+id, expr, fn)
+}
+
+// Migration registers a migration run with the built-in ones, in the order
+// of their files. Its file should be unique and start with a Unix timestamp
+// like theirs, so it runs after the collections it builds on.
+func (r *Registrar) Migration(file string, up func(app core.App) error, down func(app core.App) error) {
+	m.Register(up, down, file)
+}
+
+var (
+	mu      sync.Mutex
+	plugins []Plugin
+)
+
+// Register makes a plugin available to the server. It panics when the name is
+// invalid or taken, which is a build mistake.
+func Register(p Plugin) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if !namePattern.MatchString(p.Name()) {
+		panic(fmt.Sprintf("plugins: invalid name %q", p.Name()))
+	}
+	for _, other := range plugins {
+		if other.Name() == p.Name() {
+			panic(fmt.Sprintf("plugins: %q registered twice", p.Name()))
+		}
+	}
+	plugins = append(plugins, p)
+}
+
+// Setup sets up every registered plugin in the order they were registered,
+// returning the routes of each by name
+func Setup(app core.App, bus *events.Bus) (map[string][]Route, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	routes := make(map[string][]Route, len(plugins))
+	for _, p := range plugins {
+		r := &Registrar{App: app, Events: bus, name: p.Name()}
+		if err := p.Setup(r); err != nil {
+			return nil, fmt.Errorf("plugin %s: %w", p.Name(), err)
+		}
+		routes[p.Name()] = r.routes
+	}
+	return routes, nil
+}